@@ -2,9 +2,11 @@ package client
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/codedellemc/libstorage/api/context"
 	"github.com/codedellemc/libstorage/api/types"
+	"github.com/codedellemc/libstorage/api/utils/tracing"
 )
 
 func init() {
@@ -20,10 +22,14 @@ type client struct {
 	logRequests  bool
 	logResponses bool
 	serverName   string
+	bearerToken  string
+	tracer       *tracing.Tracer
+	retries      int
+	retryBackoff time.Duration
 }
 
 // New returns a new API client.
-func New(host string, transport *http.Transport) types.APIClient {
+func New(host string, transport http.RoundTripper) types.APIClient {
 	return &client{
 		Client: http.Client{
 			Transport: transport,
@@ -43,3 +49,20 @@ func (c *client) LogRequests(enabled bool) {
 func (c *client) LogResponses(enabled bool) {
 	c.logResponses = enabled
 }
+
+func (c *client) BearerToken(token string) {
+	c.bearerToken = token
+}
+
+func (c *client) TraceCollector(url string, sampleRate int) {
+	if url == "" {
+		c.tracer = nil
+		return
+	}
+	c.tracer = tracing.NewWithCollector(url, "libstorage-client", sampleRate)
+}
+
+func (c *client) Retries(retries int, backoff time.Duration) {
+	c.retries = retries
+	c.retryBackoff = backoff
+}
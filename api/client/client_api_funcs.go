@@ -2,10 +2,13 @@ package client
 
 import (
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"io"
 	"strconv"
 
+	"github.com/akutz/goof"
+
 	"github.com/codedellemc/libstorage/api/types"
 )
 
@@ -107,6 +110,42 @@ func (c *client) VolumesByService(
 	return reply, nil
 }
 
+func (c *client) VolumesStream(
+	ctx types.Context,
+	service string,
+	attachments types.VolumeAttachmentsTypes,
+	fn func(service string, volume *types.Volume) error) error {
+
+	url := fmt.Sprintf("/volumes?stream&attachments=%v", attachments)
+	if service != "" {
+		url = fmt.Sprintf(
+			"/volumes/%s?stream&attachments=%v", service, attachments)
+	}
+
+	res, err := c.httpGet(ctx, url, nil)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	dec := json.NewDecoder(res.Body)
+	for {
+		item := &types.VolumeStreamItem{}
+		if err := dec.Decode(item); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if item.Error != "" {
+			return goof.WithField("service", item.Service, item.Error)
+		}
+		if err := fn(item.Service, item.Volume); err != nil {
+			return err
+		}
+	}
+}
+
 func (c *client) VolumeInspect(
 	ctx types.Context,
 	service, volumeID string,
@@ -162,6 +201,20 @@ func (c *client) VolumeCopy(
 	return &reply, nil
 }
 
+func (c *client) VolumeExpand(
+	ctx types.Context,
+	service, volumeID string,
+	request *types.VolumeExpandRequest) (*types.Volume, error) {
+
+	reply := types.Volume{}
+	if _, err := c.httpPost(ctx,
+		fmt.Sprintf("/volumes/%s/%s?expand", service, volumeID),
+		request, &reply); err != nil {
+		return nil, err
+	}
+	return &reply, nil
+}
+
 func (c *client) VolumeRemove(
 	ctx types.Context,
 	service, volumeID string) error {
@@ -7,12 +7,14 @@ import (
 	"io"
 	"io/ioutil"
 	"net/http"
+	"time"
 
 	"github.com/akutz/goof"
 	"golang.org/x/net/context/ctxhttp"
 
 	"github.com/codedellemc/libstorage/api/context"
 	"github.com/codedellemc/libstorage/api/types"
+	"github.com/codedellemc/libstorage/api/utils/tracing"
 )
 
 type headerKey int
@@ -46,11 +48,25 @@ func (c *client) httpDo(
 	}
 
 	url := fmt.Sprintf("http://%s%s", c.host, path)
-	req, err := http.NewRequest(method, url, reqBody)
+	req, err := http.NewRequest(method, url, newPayloadReader(reqBody))
 	if err != nil {
 		return nil, err
 	}
 
+	if c.retries > 0 && isMutation(method) {
+		key, err := types.NewUUID()
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set(types.IdempotencyKeyHeader, key.String())
+	}
+
+	if c.bearerToken != "" {
+		req.Header.Set(
+			types.AuthorizationHeader,
+			fmt.Sprintf("Bearer %s", c.bearerToken))
+	}
+
 	ctx = context.RequireTX(ctx)
 	tx := context.MustTransaction(ctx)
 	ctx = ctx.WithValue(transactionHeaderKey, tx)
@@ -119,12 +135,19 @@ func (c *client) httpDo(
 		}
 	}
 
+	ctx, span := c.tracer.StartSpan(
+		ctx, fmt.Sprintf("%s %s", method, path), tracing.KindClient)
+	span.Inject(req.Header)
+
 	c.logRequest(req)
 
-	res, err := ctxhttp.Do(ctx, &c.Client, req)
+	res, err := c.do(ctx, req, reqBody)
 	if err != nil {
+		span.FinishWithError(err)
 		return nil, err
 	}
+	span.SetTag("http.status_code", fmt.Sprintf("%d", res.StatusCode))
+	defer span.Finish()
 	defer c.setServerName(res)
 
 	c.logResponse(res)
@@ -146,6 +169,75 @@ func (c *client) httpDo(
 	return res, nil
 }
 
+// do sends the request, resending it up to the client's number of retries if
+// it cannot be delivered. A request that modifies a resource is only resent
+// if it has an idempotency key, in which case it is also resent if the server
+// is still executing an earlier attempt or did not complete it in time.
+func (c *client) do(
+	ctx types.Context,
+	req *http.Request,
+	reqBody []byte) (*http.Response, error) {
+
+	backoff := c.retryBackoff
+	for attempt := 0; ; attempt++ {
+
+		if attempt > 0 {
+			r := *req
+			r.Body = ioutil.NopCloser(newPayloadReader(reqBody))
+			req = &r
+		}
+
+		res, err := ctxhttp.Do(ctx, &c.Client, req)
+		if attempt >= c.retries || !isRetryable(req, res, err) {
+			return res, err
+		}
+
+		if res != nil {
+			res.Body.Close()
+			ctx.WithField("status", res.StatusCode).Warn("retrying request")
+		} else {
+			ctx.WithError(err).Warn("retrying request")
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		}
+		backoff = backoff * 2
+	}
+}
+
+// isRetryable returns a flag indicating whether a request may be resent.
+func isRetryable(req *http.Request, res *http.Response, err error) bool {
+	hasKey := req.Header.Get(types.IdempotencyKeyHeader) != ""
+	if err != nil {
+		return !isMutation(req.Method) || hasKey
+	}
+	if !hasKey {
+		return false
+	}
+	switch res.StatusCode {
+	case http.StatusConflict:
+		// resources that already exist or are busy are conflicts too, but
+		// only the original request's being in progress is worth retrying
+		return res.Header.Get(types.RetryAfterHeader) != ""
+	case http.StatusRequestTimeout:
+		return true
+	}
+	return false
+}
+
+func isMutation(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	return true
+}
+
 func (c *client) setServerName(res *http.Response) {
 	c.serverName = res.Header.Get(types.ServerNameHeader)
 }
@@ -182,17 +274,21 @@ func (c *client) httpDelete(
 	return c.httpDo(ctx, "DELETE", path, nil, reply)
 }
 
-func encPayload(payload interface{}) (io.Reader, error) {
+func encPayload(payload interface{}) ([]byte, error) {
 	if payload == nil {
 		return nil, nil
 	}
+	return json.Marshal(payload)
+}
 
-	buf, err := json.Marshal(payload)
-	if err != nil {
-		return nil, err
+// newPayloadReader returns a reader for an encoded payload. A nil reader is
+// returned if there is no payload so that requests without a payload do not
+// have a body.
+func newPayloadReader(buf []byte) io.Reader {
+	if buf == nil {
+		return nil
 	}
-
-	return bytes.NewReader(buf), nil
+	return bytes.NewReader(buf)
 }
 
 func decRes(body io.Reader, reply interface{}) error {
@@ -9,6 +9,8 @@ import (
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/akutz/gotil"
+
+	"github.com/codedellemc/libstorage/api/types"
 )
 
 func (c *client) logRequest(req *http.Request) {
@@ -24,6 +26,12 @@ func (c *client) logRequest(req *http.Request) {
 	fmt.Fprint(w, "HTTP REQUEST (CLIENT)")
 	fmt.Fprintln(w, " -------------------------")
 
+	// never log the bearer token
+	if v := req.Header.Get(types.AuthorizationHeader); v != "" {
+		req.Header.Set(types.AuthorizationHeader, "Bearer ******")
+		defer req.Header.Set(types.AuthorizationHeader, v)
+	}
+
 	buf, err := httputil.DumpRequest(req, true)
 	if err != nil {
 		return
@@ -46,9 +54,11 @@ func (c *client) logResponse(res *http.Response) {
 	fmt.Fprint(w, "HTTP RESPONSE (CLIENT)")
 	fmt.Fprintln(w, " -------------------------")
 
+	// binary and streamed bodies are not logged
+	ct := res.Header.Get("Content-Type")
 	buf, err := httputil.DumpResponse(
 		res,
-		res.Header.Get("Content-Type") != "application/octet-stream")
+		ct != "application/octet-stream" && ct != "application/x-ndjson")
 	if err != nil {
 		return
 	}
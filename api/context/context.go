@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	log "github.com/Sirupsen/logrus"
 	gcontext "github.com/gorilla/context"
@@ -118,6 +119,20 @@ func WithStorageService(
 	return newContext(parent, ServiceKey, service, nil, nil)
 }
 
+// WithTimeout returns a copy of parent that is cancelled once the timeout
+// elapses or the returned function is invoked, whichever occurs first.
+func WithTimeout(
+	parent types.Context,
+	timeout time.Duration) (types.Context, context.CancelFunc) {
+
+	tctx, cancel := context.WithTimeout(parent, timeout)
+	ctx := newContext(tctx, nil, nil, nil, nil)
+	if logger, ok := parent.Value(LoggerKey).(*log.Logger); ok {
+		ctx.logger = logger
+	}
+	return ctx, cancel
+}
+
 // WithStorageSession returns a context that is logged into the storage
 // platform.
 func WithStorageSession(parent context.Context) (types.Context, error) {
@@ -173,7 +188,7 @@ func (ctx *lsc) Value(key interface{}) interface{} {
 		key = customKeyID
 	}
 
-	if key == HTTPRequestKey {
+	if key == HTTPRequestKey && ctx.req != nil {
 		return ctx.req
 	}
 
@@ -312,6 +327,21 @@ func LocalDevices(ctx context.Context) (*types.LocalDevices, bool) {
 	return v, ok
 }
 
+// HTTPRequest returns the HTTP request for which the context was created. This
+// value is valid only for contexts created on the server.
+func HTTPRequest(ctx context.Context) (*http.Request, bool) {
+	v, ok := ctx.Value(HTTPRequestKey).(*http.Request)
+	return v, ok && v != nil
+}
+
+// AuthToken returns the context's validated bearer token. This value is valid
+// only for contexts created on the server and only when authentication is
+// enabled.
+func AuthToken(ctx context.Context) (*types.AuthToken, bool) {
+	v, ok := ctx.Value(AuthTokenKey).(*types.AuthToken)
+	return v, ok
+}
+
 // Transaction returns the context's Transaction. This value is valid on both
 // the client and the server.
 func Transaction(ctx context.Context) (*types.Transaction, bool) {
@@ -374,6 +404,13 @@ func Server(ctx context.Context) (string, bool) {
 	return stringValue(ctx, ServerKey)
 }
 
+// Endpoint returns the name of the server endpoint that received the
+// request. This value is only valid for contexts created on the server by
+// one of its endpoints.
+func Endpoint(ctx context.Context) (string, bool) {
+	return stringValue(ctx, EndpointKey)
+}
+
 // Service returns the context's storage service. This value is valid only for
 // contexts created on the server. The value is only available after the
 // service has been injected as part of the ServiceValidator handler or by
@@ -79,6 +79,16 @@ const (
 	// TLSKey is a context key.
 	TLSKey
 
+	// AuthTokenKey is the key for the request's authenticated bearer token.
+	AuthTokenKey
+
+	// SpanKey is the key for the current span of the context's trace.
+	SpanKey
+
+	// EndpointKey is the key for the name of the server endpoint that
+	// received the request.
+	EndpointKey
+
 	// keyEOF should always be the final key
 	keyEOF
 )
@@ -105,6 +115,9 @@ var (
 		UserKey:           "user",
 		HostKey:           "host",
 		TLSKey:            "tls",
+		AuthTokenKey:      "authToken",
+		SpanKey:           "span",
+		EndpointKey:       "endpoint",
 	}
 )
 
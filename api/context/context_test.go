@@ -1,8 +1,10 @@
 package context
 
 import (
+	"net/http"
 	"os"
 	"testing"
+	"time"
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
@@ -110,3 +112,37 @@ func TestContextIDLogCustomLoggerKey(t *testing.T) {
 	ctx = ctx.WithValue(testLogKeyHello, "world")
 	ctx.Info("testing custom log keys")
 }
+
+func TestHTTPRequest(t *testing.T) {
+
+	_, ok := HTTPRequest(Background())
+	assert.False(t, ok)
+
+	req, _ := http.NewRequest(http.MethodPost, "/volumes/mock", nil)
+	ctx := WithRequestRoute(Background(), req, nil)
+	v, ok := HTTPRequest(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, req, v)
+
+	// the request is still available from contexts derived from the
+	// request's context
+	ctx = ctx.WithValue(ServiceKey, &service{})
+	v, ok = HTTPRequest(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, req, v)
+}
+
+func TestWithTimeout(t *testing.T) {
+	parent := Background().WithValue(ServerKey, serverName)
+	ctx, cancel := WithTimeout(parent, time.Hour)
+	_, ok := ctx.Deadline()
+	assert.True(t, ok)
+	assert.NoError(t, ctx.Err())
+	assert.Equal(t, serverName, ctx.Value(ServerKey))
+	assert.Equal(t, parent.Value(LoggerKey), ctx.Value(LoggerKey))
+
+	cancel()
+	<-ctx.Done()
+	assert.Error(t, ctx.Err())
+	assert.NoError(t, parent.Err())
+}
@@ -0,0 +1,145 @@
+// Package plugin bridges storage drivers that are compiled and shipped as
+// separate executables to the libStorage server. A plugin is an executable
+// whose main function serves a storage driver with the Serve function. The
+// server starts the plugins it discovers and invokes their drivers over
+// net/rpc with HashiCorp's go-plugin, so a storage vendor may ship a driver
+// without forking libStorage.
+package plugin
+
+import (
+	"net/rpc"
+	"os/exec"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/akutz/goof"
+	goplugin "github.com/hashicorp/go-plugin"
+
+	"github.com/codedellemc/libstorage/api/types"
+)
+
+// Handshake is the configuration with which the server verifies that an
+// executable is a libStorage plugin that speaks the same protocol. A plugin
+// that is executed directly, instead of by the server, exits with an error
+// that explains it is a plugin.
+var Handshake = goplugin.HandshakeConfig{
+	ProtocolVersion:  1,
+	MagicCookieKey:   "LIBSTORAGE_PLUGIN",
+	MagicCookieValue: "storage-driver",
+}
+
+// storageDriverPluginName is the name under which a plugin serves its
+// storage driver.
+const storageDriverPluginName = "storageDriver"
+
+// Serve serves the storage driver constructed by the provided function. It
+// is invoked by a plugin's main function and returns when the server stops
+// the plugin. The server constructs an instance of the driver for each of
+// the services configured with the driver.
+func Serve(ctor types.NewStorageDriver) {
+	goplugin.Serve(&goplugin.ServeConfig{
+		HandshakeConfig: Handshake,
+		Plugins:         pluginMap(ctor),
+	})
+}
+
+// Plugin is a plugin the server started.
+type Plugin struct {
+	name   string
+	path   string
+	client *goplugin.Client
+	rpc    *rpc.Client
+}
+
+// Start starts the plugin at the specified path and returns it once the
+// plugin has reported the name of the storage driver it serves.
+func Start(ctx types.Context, path string) (*Plugin, error) {
+	client := goplugin.NewClient(&goplugin.ClientConfig{
+		HandshakeConfig: Handshake,
+		Plugins:         pluginMap(nil),
+		Cmd:             exec.Command(path),
+		Managed:         true,
+	})
+
+	p, err := start(client, path)
+	if err != nil {
+		client.Kill()
+		return nil, err
+	}
+
+	ctx.WithFields(log.Fields{
+		"path":   path,
+		"driver": p.name,
+	}).Info("started storage driver plugin")
+	return p, nil
+}
+
+func start(client *goplugin.Client, path string) (*Plugin, error) {
+	cp, err := client.Client()
+	if err != nil {
+		return nil, goof.WithFieldE(
+			"path", path, "error starting plugin", err)
+	}
+
+	raw, err := cp.Dispense(storageDriverPluginName)
+	if err != nil {
+		return nil, goof.WithFieldE(
+			"path", path, "error dispensing storage driver", err)
+	}
+
+	p := &Plugin{path: path, client: client, rpc: raw.(*rpc.Client)}
+	if err := p.rpc.Call("Plugin.Name", new(interface{}), &p.name); err != nil {
+		return nil, goof.WithFieldE(
+			"path", path, "error getting storage driver name", err)
+	}
+	if p.name == "" {
+		return nil, goof.WithField(
+			"path", path, "plugin storage driver has no name")
+	}
+	return p, nil
+}
+
+// Name returns the name of the storage driver the plugin serves.
+func (p *Plugin) Name() string {
+	return p.name
+}
+
+// Path returns the path of the plugin's executable.
+func (p *Plugin) Path() string {
+	return p.path
+}
+
+// NewStorageDriver returns a new instance of the storage driver the plugin
+// serves. The instance is created in the plugin when the driver is
+// initialized.
+func (p *Plugin) NewStorageDriver() types.StorageDriver {
+	return &driver{name: p.name, client: p.rpc}
+}
+
+// Kill stops the plugin.
+func (p *Plugin) Kill() {
+	p.client.Kill()
+}
+
+// storageDriverPlugin is the go-plugin plugin with which a storage driver is
+// served and dispensed. Only a plugin's side has a constructor.
+type storageDriverPlugin struct {
+	ctor types.NewStorageDriver
+}
+
+func pluginMap(ctor types.NewStorageDriver) map[string]goplugin.Plugin {
+	return map[string]goplugin.Plugin{
+		storageDriverPluginName: &storageDriverPlugin{ctor: ctor},
+	}
+}
+
+func (p *storageDriverPlugin) Server(
+	*goplugin.MuxBroker) (interface{}, error) {
+
+	return newServer(p.ctor), nil
+}
+
+func (p *storageDriverPlugin) Client(
+	b *goplugin.MuxBroker, c *rpc.Client) (interface{}, error) {
+
+	return c, nil
+}
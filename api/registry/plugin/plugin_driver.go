@@ -0,0 +1,329 @@
+package plugin
+
+import (
+	"encoding/json"
+	"net/rpc"
+
+	gofig "github.com/akutz/gofig/types"
+	"github.com/akutz/goof"
+
+	"github.com/codedellemc/libstorage/api/types"
+)
+
+// driver is the server's side of a plugin's storage driver. It forwards the
+// operations to the instance of the driver it created in the plugin.
+type driver struct {
+	name   string
+	id     int
+	client *rpc.Client
+}
+
+func (d *driver) Name() string {
+	return d.name
+}
+
+// Init creates an instance of the driver in the plugin and initializes it
+// with the configuration, which is sent to the plugin as JSON.
+func (d *driver) Init(ctx types.Context, config gofig.Config) error {
+	if err := d.client.Call("Plugin.New", new(interface{}), &d.id); err != nil {
+		return goof.WithFieldE(
+			"driver", d.name, "error creating plugin driver", err)
+	}
+	buf, err := config.ToJSON()
+	if err != nil {
+		return err
+	}
+	return d.call(ctx, "Init", &callArgs{Config: buf}, nil)
+}
+
+func (d *driver) HealthCheck(ctx types.Context) error {
+	return d.call(ctx, "HealthCheck", &callArgs{}, nil)
+}
+
+func (d *driver) Capabilities(
+	ctx types.Context) (*types.StorageCapabilities, error) {
+
+	r := &callResult{}
+	if err := d.call(ctx, "Capabilities", &callArgs{}, r); err != nil {
+		return nil, err
+	}
+	return r.Capabilities, nil
+}
+
+func (d *driver) NextDeviceInfo(
+	ctx types.Context) (*types.NextDeviceInfo, error) {
+
+	r := &callResult{}
+	if err := d.call(ctx, "NextDeviceInfo", &callArgs{}, r); err != nil {
+		return nil, err
+	}
+	return r.NextDevice, nil
+}
+
+func (d *driver) Type(ctx types.Context) (types.StorageType, error) {
+	r := &callResult{}
+	if err := d.call(ctx, "Type", &callArgs{}, r); err != nil {
+		return "", err
+	}
+	return r.Type, nil
+}
+
+func (d *driver) InstanceInspect(
+	ctx types.Context,
+	opts types.Store) (*types.Instance, error) {
+
+	r := &callResult{}
+	if err := d.call(ctx, "InstanceInspect", &callArgs{
+		Opts: storeMap(opts),
+	}, r); err != nil {
+		return nil, err
+	}
+	return r.Instance, nil
+}
+
+func (d *driver) Volumes(
+	ctx types.Context,
+	opts *types.VolumesOpts) ([]*types.Volume, error) {
+
+	r := &callResult{}
+	if err := d.call(ctx, "Volumes", &callArgs{
+		Attachments: opts.Attachments,
+		Pagination:  opts.Pagination,
+		Opts:        storeMap(opts.Opts),
+	}, r); err != nil {
+		return nil, err
+	}
+	return r.Volumes, nil
+}
+
+func (d *driver) VolumeInspect(
+	ctx types.Context,
+	volumeID string,
+	opts *types.VolumeInspectOpts) (*types.Volume, error) {
+
+	return d.callVolume(ctx, "VolumeInspect", &callArgs{
+		VolumeID:    volumeID,
+		Attachments: opts.Attachments,
+		Opts:        storeMap(opts.Opts),
+	})
+}
+
+func (d *driver) VolumeCreate(
+	ctx types.Context,
+	volumeName string,
+	opts *types.VolumeCreateOpts) (*types.Volume, error) {
+
+	a := volumeCreateArgs(opts)
+	a.VolumeName = volumeName
+	return d.callVolume(ctx, "VolumeCreate", a)
+}
+
+func (d *driver) VolumeCreateFromSnapshot(
+	ctx types.Context,
+	snapshotID, volumeName string,
+	opts *types.VolumeCreateOpts) (*types.Volume, error) {
+
+	a := volumeCreateArgs(opts)
+	a.SnapshotID = snapshotID
+	a.VolumeName = volumeName
+	return d.callVolume(ctx, "VolumeCreateFromSnapshot", a)
+}
+
+func (d *driver) VolumeCopy(
+	ctx types.Context,
+	volumeID, volumeName string,
+	opts types.Store) (*types.Volume, error) {
+
+	return d.callVolume(ctx, "VolumeCopy", &callArgs{
+		VolumeID:   volumeID,
+		VolumeName: volumeName,
+		Opts:       storeMap(opts),
+	})
+}
+
+func (d *driver) VolumeExpand(
+	ctx types.Context,
+	volumeID string,
+	newSize int64,
+	opts types.Store) (*types.Volume, error) {
+
+	return d.callVolume(ctx, "VolumeExpand", &callArgs{
+		VolumeID: volumeID,
+		NewSize:  newSize,
+		Opts:     storeMap(opts),
+	})
+}
+
+func (d *driver) VolumeSnapshot(
+	ctx types.Context,
+	volumeID, snapshotName string,
+	opts types.Store) (*types.Snapshot, error) {
+
+	return d.callSnapshot(ctx, "VolumeSnapshot", &callArgs{
+		VolumeID:     volumeID,
+		SnapshotName: snapshotName,
+		Opts:         storeMap(opts),
+	})
+}
+
+func (d *driver) VolumeRemove(
+	ctx types.Context,
+	volumeID string,
+	opts types.Store) error {
+
+	return d.call(ctx, "VolumeRemove", &callArgs{
+		VolumeID: volumeID,
+		Opts:     storeMap(opts),
+	}, nil)
+}
+
+func (d *driver) VolumeAttach(
+	ctx types.Context,
+	volumeID string,
+	opts *types.VolumeAttachOpts) (*types.Volume, string, error) {
+
+	r := &callResult{}
+	if err := d.call(ctx, "VolumeAttach", &callArgs{
+		VolumeID:   volumeID,
+		NextDevice: opts.NextDevice,
+		Force:      opts.Force,
+		Opts:       storeMap(opts.Opts),
+	}, r); err != nil {
+		return nil, "", err
+	}
+	return r.Volume, r.Token, nil
+}
+
+func (d *driver) VolumeDetach(
+	ctx types.Context,
+	volumeID string,
+	opts *types.VolumeDetachOpts) (*types.Volume, error) {
+
+	return d.callVolume(ctx, "VolumeDetach", &callArgs{
+		VolumeID: volumeID,
+		Force:    opts.Force,
+		Opts:     storeMap(opts.Opts),
+	})
+}
+
+func (d *driver) Snapshots(
+	ctx types.Context,
+	opts types.Store) ([]*types.Snapshot, error) {
+
+	r := &callResult{}
+	if err := d.call(ctx, "Snapshots", &callArgs{
+		Opts: storeMap(opts),
+	}, r); err != nil {
+		return nil, err
+	}
+	return r.Snapshots, nil
+}
+
+func (d *driver) SnapshotInspect(
+	ctx types.Context,
+	snapshotID string,
+	opts types.Store) (*types.Snapshot, error) {
+
+	return d.callSnapshot(ctx, "SnapshotInspect", &callArgs{
+		SnapshotID: snapshotID,
+		Opts:       storeMap(opts),
+	})
+}
+
+func (d *driver) SnapshotCopy(
+	ctx types.Context,
+	snapshotID, snapshotName, destinationID string,
+	opts types.Store) (*types.Snapshot, error) {
+
+	return d.callSnapshot(ctx, "SnapshotCopy", &callArgs{
+		SnapshotID:    snapshotID,
+		SnapshotName:  snapshotName,
+		DestinationID: destinationID,
+		Opts:          storeMap(opts),
+	})
+}
+
+func (d *driver) SnapshotRemove(
+	ctx types.Context,
+	snapshotID string,
+	opts types.Store) error {
+
+	return d.call(ctx, "SnapshotRemove", &callArgs{
+		SnapshotID: snapshotID,
+		Opts:       storeMap(opts),
+	}, nil)
+}
+
+func volumeCreateArgs(opts *types.VolumeCreateOpts) *callArgs {
+	return &callArgs{
+		AvailabilityZone: opts.AvailabilityZone,
+		IOPS:             opts.IOPS,
+		Size:             opts.Size,
+		Type:             opts.Type,
+		Encrypted:        opts.Encrypted,
+		Opts:             storeMap(opts.Opts),
+	}
+}
+
+func (d *driver) callVolume(
+	ctx types.Context,
+	method string,
+	a *callArgs) (*types.Volume, error) {
+
+	r := &callResult{}
+	if err := d.call(ctx, method, a, r); err != nil {
+		return nil, err
+	}
+	return r.Volume, nil
+}
+
+func (d *driver) callSnapshot(
+	ctx types.Context,
+	method string,
+	a *callArgs) (*types.Snapshot, error) {
+
+	r := &callResult{}
+	if err := d.call(ctx, method, a, r); err != nil {
+		return nil, err
+	}
+	return r.Snapshot, nil
+}
+
+// call invokes an operation of the driver's instance in the plugin. The
+// result is decoded into r if it is not nil.
+func (d *driver) call(
+	ctx types.Context,
+	method string,
+	a *callArgs,
+	r *callResult) error {
+
+	cbuf, err := json.Marshal(newCallContext(ctx))
+	if err != nil {
+		return err
+	}
+	abuf, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+
+	reply := &CallReply{}
+	if err := d.client.Call("Plugin.Call", &CallArgs{
+		ID:      d.id,
+		Method:  method,
+		Context: cbuf,
+		Args:    abuf,
+	}, reply); err != nil {
+		return goof.WithFieldsE(map[string]interface{}{
+			"driver": d.name,
+			"method": method,
+		}, "error calling plugin", err)
+	}
+
+	if reply.Error != nil {
+		return reply.Error.toError(a)
+	}
+	if r == nil {
+		return nil
+	}
+	return json.Unmarshal(reply.Result, r)
+}
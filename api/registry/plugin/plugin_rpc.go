@@ -0,0 +1,172 @@
+package plugin
+
+import (
+	"github.com/akutz/goof"
+
+	"github.com/codedellemc/libstorage/api/context"
+	"github.com/codedellemc/libstorage/api/types"
+	"github.com/codedellemc/libstorage/api/utils"
+)
+
+// CallArgs are the arguments of the RPC with which the server invokes an
+// operation of a plugin's storage driver. The context and the operation's
+// arguments are encoded as JSON since the instance IDs they may contain
+// carry metadata that only survives JSON encoding.
+type CallArgs struct {
+	// ID is the ID of the driver instance.
+	ID int
+
+	// Method is the name of the operation.
+	Method string
+
+	// Context is the JSON-encoded callContext.
+	Context []byte
+
+	// Args is the JSON-encoded callArgs.
+	Args []byte
+}
+
+// CallReply is the reply to a CallArgs RPC.
+type CallReply struct {
+	// Result is the JSON-encoded callResult.
+	Result []byte
+
+	// Error is the error the operation returned.
+	Error *CallError
+}
+
+// CallError is an error returned by a plugin's storage driver. Only the
+// error's message and code are sent to the server.
+type CallError struct {
+	Message        string
+	Code           types.ErrorCode
+	NotImplemented bool
+}
+
+func newCallError(err error) *CallError {
+	return &CallError{
+		Message:        err.Error(),
+		Code:           types.ErrorCodeOf(err),
+		NotImplemented: err == types.ErrNotImplemented,
+	}
+}
+
+// toError returns the error the driver returned. The errors with a code are
+// returned as the typed errors that correspond to their codes so that the
+// server responds with the same status as it does for built-in drivers.
+func (e *CallError) toError(a *callArgs) error {
+	if e.NotImplemented {
+		return types.ErrNotImplemented
+	}
+	err := goof.New(e.Message)
+	if e.Code == "" {
+		return err
+	}
+	resourceID := a.VolumeID
+	if resourceID == "" {
+		resourceID = a.SnapshotID
+	}
+	return utils.NewErrorCodeError(e.Code, resourceID, err)
+}
+
+// callContext is the part of an operation's context that is sent to a
+// plugin.
+type callContext struct {
+	InstanceID   *types.InstanceID   `json:"instanceID,omitempty"`
+	LocalDevices *types.LocalDevices `json:"localDevices,omitempty"`
+	Service      string              `json:"service,omitempty"`
+}
+
+func newCallContext(ctx types.Context) *callContext {
+	c := &callContext{}
+	if iid, ok := context.InstanceID(ctx); ok {
+		c.InstanceID = iid
+	}
+	if ld, ok := context.LocalDevices(ctx); ok {
+		c.LocalDevices = ld
+	}
+	if name, ok := context.ServiceName(ctx); ok {
+		c.Service = name
+	}
+	return c
+}
+
+// context returns a new context with the values sent by the server.
+func (c *callContext) context() types.Context {
+	ctx := context.Background()
+	if c.InstanceID != nil {
+		ctx = ctx.WithValue(context.InstanceIDKey, c.InstanceID)
+	}
+	if c.LocalDevices != nil {
+		ctx = ctx.WithValue(context.LocalDevicesKey, c.LocalDevices)
+	}
+	if c.Service != "" {
+		ctx = ctx.WithValue(context.ServiceKey, c.Service)
+	}
+	return ctx
+}
+
+// callArgs are the arguments of the storage driver operations. Each
+// operation uses the fields that correspond to its parameters.
+type callArgs struct {
+	Config        string `json:"config,omitempty"`
+	VolumeID      string `json:"volumeID,omitempty"`
+	VolumeName    string `json:"volumeName,omitempty"`
+	SnapshotID    string `json:"snapshotID,omitempty"`
+	SnapshotName  string `json:"snapshotName,omitempty"`
+	DestinationID string `json:"destinationID,omitempty"`
+	NewSize       int64  `json:"newSize,omitempty"`
+	Force         bool   `json:"force,omitempty"`
+
+	Attachments types.VolumeAttachmentsTypes `json:"attachments,omitempty"`
+	Pagination  *types.VolumesPagination     `json:"pagination,omitempty"`
+
+	AvailabilityZone *string `json:"availabilityZone,omitempty"`
+	IOPS             *int64  `json:"iops,omitempty"`
+	Size             *int64  `json:"size,omitempty"`
+	Type             *string `json:"type,omitempty"`
+	Encrypted        *bool   `json:"encrypted,omitempty"`
+	NextDevice       *string `json:"nextDevice,omitempty"`
+
+	Opts map[string]interface{} `json:"opts,omitempty"`
+}
+
+// store returns the operation's options.
+func (a *callArgs) store() types.Store {
+	if a.Opts == nil {
+		return utils.NewStore()
+	}
+	return utils.NewStoreWithData(a.Opts)
+}
+
+func (a *callArgs) volumeCreateOpts() *types.VolumeCreateOpts {
+	return &types.VolumeCreateOpts{
+		AvailabilityZone: a.AvailabilityZone,
+		IOPS:             a.IOPS,
+		Size:             a.Size,
+		Type:             a.Type,
+		Encrypted:        a.Encrypted,
+		Opts:             a.store(),
+	}
+}
+
+func storeMap(store types.Store) map[string]interface{} {
+	if store == nil {
+		return nil
+	}
+	return store.Map()
+}
+
+// callResult is the result of a storage driver operation. Each operation
+// sets the fields that correspond to its return values.
+type callResult struct {
+	Type         types.StorageType          `json:"type,omitempty"`
+	NextDevice   *types.NextDeviceInfo      `json:"nextDevice,omitempty"`
+	Capabilities *types.StorageCapabilities `json:"capabilities,omitempty"`
+	Instance     *types.Instance            `json:"instance,omitempty"`
+	Volume       *types.Volume              `json:"volume,omitempty"`
+	Volumes      []*types.Volume            `json:"volumes,omitempty"`
+	Snapshot     *types.Snapshot            `json:"snapshot,omitempty"`
+	Snapshots    []*types.Snapshot          `json:"snapshots,omitempty"`
+	Token        string                     `json:"token,omitempty"`
+}
@@ -0,0 +1,170 @@
+package plugin
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync"
+
+	gofigCore "github.com/akutz/gofig"
+	"github.com/akutz/goof"
+
+	"github.com/codedellemc/libstorage/api/types"
+)
+
+// server is a plugin's side of its storage driver. It holds the instances of
+// the driver the server created and invokes their operations.
+type server struct {
+	sync.Mutex
+	ctor    types.NewStorageDriver
+	drivers map[int]types.StorageDriver
+	nextID  int
+}
+
+func newServer(ctor types.NewStorageDriver) *server {
+	return &server{ctor: ctor, drivers: map[int]types.StorageDriver{}}
+}
+
+// Name returns the name of the storage driver.
+func (s *server) Name(args interface{}, reply *string) error {
+	*reply = s.ctor().Name()
+	return nil
+}
+
+// New creates an instance of the storage driver and returns its ID.
+func (s *server) New(args interface{}, reply *int) error {
+	s.Lock()
+	defer s.Unlock()
+	s.nextID++
+	s.drivers[s.nextID] = s.ctor()
+	*reply = s.nextID
+	return nil
+}
+
+// Call invokes an operation of an instance of the storage driver. The
+// errors returned by the operation are sent in the reply; the error returned
+// by Call is reserved for requests the plugin cannot handle.
+func (s *server) Call(args *CallArgs, reply *CallReply) error {
+	s.Lock()
+	d, ok := s.drivers[args.ID]
+	s.Unlock()
+	if !ok {
+		return goof.WithField("id", args.ID, "invalid driver instance")
+	}
+
+	c := &callContext{}
+	if err := json.Unmarshal(args.Context, c); err != nil {
+		return err
+	}
+	a := &callArgs{}
+	if err := json.Unmarshal(args.Args, a); err != nil {
+		return err
+	}
+
+	r, err := call(c.context(), d, args.Method, a)
+	if err != nil {
+		reply.Error = newCallError(err)
+		return nil
+	}
+	if reply.Result, err = json.Marshal(r); err != nil {
+		return err
+	}
+	return nil
+}
+
+func call(
+	ctx types.Context,
+	d types.StorageDriver,
+	method string,
+	a *callArgs) (*callResult, error) {
+
+	var (
+		err error
+		r   = &callResult{}
+	)
+
+	switch method {
+	case "Init":
+		config := gofigCore.New()
+		if err = config.ReadConfig(
+			bytes.NewReader([]byte(a.Config))); err != nil {
+			return nil, err
+		}
+		err = d.Init(ctx, config)
+	case "HealthCheck":
+		if hc, ok := d.(types.StorageDriverWithHealthCheck); ok {
+			err = hc.HealthCheck(ctx)
+		} else {
+			err = types.ErrNotImplemented
+		}
+	case "Capabilities":
+		if sc, ok := d.(types.StorageDriverWithCapabilities); ok {
+			r.Capabilities, err = sc.Capabilities(ctx)
+		} else {
+			err = types.ErrNotImplemented
+		}
+	case "NextDeviceInfo":
+		r.NextDevice, err = d.NextDeviceInfo(ctx)
+	case "Type":
+		r.Type, err = d.Type(ctx)
+	case "InstanceInspect":
+		r.Instance, err = d.InstanceInspect(ctx, a.store())
+	case "Volumes":
+		r.Volumes, err = d.Volumes(ctx, &types.VolumesOpts{
+			Attachments: a.Attachments,
+			Pagination:  a.Pagination,
+			Opts:        a.store(),
+		})
+	case "VolumeInspect":
+		r.Volume, err = d.VolumeInspect(ctx, a.VolumeID,
+			&types.VolumeInspectOpts{
+				Attachments: a.Attachments,
+				Opts:        a.store(),
+			})
+	case "VolumeCreate":
+		r.Volume, err = d.VolumeCreate(
+			ctx, a.VolumeName, a.volumeCreateOpts())
+	case "VolumeCreateFromSnapshot":
+		r.Volume, err = d.VolumeCreateFromSnapshot(
+			ctx, a.SnapshotID, a.VolumeName, a.volumeCreateOpts())
+	case "VolumeCopy":
+		r.Volume, err = d.VolumeCopy(
+			ctx, a.VolumeID, a.VolumeName, a.store())
+	case "VolumeExpand":
+		r.Volume, err = d.VolumeExpand(
+			ctx, a.VolumeID, a.NewSize, a.store())
+	case "VolumeSnapshot":
+		r.Snapshot, err = d.VolumeSnapshot(
+			ctx, a.VolumeID, a.SnapshotName, a.store())
+	case "VolumeRemove":
+		err = d.VolumeRemove(ctx, a.VolumeID, a.store())
+	case "VolumeAttach":
+		r.Volume, r.Token, err = d.VolumeAttach(ctx, a.VolumeID,
+			&types.VolumeAttachOpts{
+				NextDevice: a.NextDevice,
+				Force:      a.Force,
+				Opts:       a.store(),
+			})
+	case "VolumeDetach":
+		r.Volume, err = d.VolumeDetach(ctx, a.VolumeID,
+			&types.VolumeDetachOpts{
+				Force: a.Force,
+				Opts:  a.store(),
+			})
+	case "Snapshots":
+		r.Snapshots, err = d.Snapshots(ctx, a.store())
+	case "SnapshotInspect":
+		r.Snapshot, err = d.SnapshotInspect(ctx, a.SnapshotID, a.store())
+	case "SnapshotCopy":
+		r.Snapshot, err = d.SnapshotCopy(
+			ctx, a.SnapshotID, a.SnapshotName, a.DestinationID, a.store())
+	case "SnapshotRemove":
+		err = d.SnapshotRemove(ctx, a.SnapshotID, a.store())
+	default:
+		err = types.ErrNotImplemented
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
@@ -0,0 +1,205 @@
+package plugin
+
+import (
+	"net"
+	"net/rpc"
+	"testing"
+
+	gofigCore "github.com/akutz/gofig"
+	gofig "github.com/akutz/gofig/types"
+	"github.com/akutz/goof"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codedellemc/libstorage/api/context"
+	"github.com/codedellemc/libstorage/api/types"
+	"github.com/codedellemc/libstorage/api/utils"
+)
+
+const testDriverName = "plugintest"
+
+// testDriver is the storage driver a test plugin serves. Its operations
+// return what they received so that a test can assert what survived the
+// round trip from the server to the plugin.
+type testDriver struct {
+	types.StorageDriver
+	config gofig.Config
+}
+
+func newTestDriver() types.StorageDriver {
+	return &testDriver{}
+}
+
+func (d *testDriver) Name() string {
+	return testDriverName
+}
+
+func (d *testDriver) Init(ctx types.Context, config gofig.Config) error {
+	d.config = config
+	return nil
+}
+
+func (d *testDriver) Type(ctx types.Context) (types.StorageType, error) {
+	return types.NAS, nil
+}
+
+// VolumeInspect returns a volume named after the context's service that is
+// attached to the context's instance, with the device name in the options.
+func (d *testDriver) VolumeInspect(
+	ctx types.Context,
+	volumeID string,
+	opts *types.VolumeInspectOpts) (*types.Volume, error) {
+
+	v := &types.Volume{ID: volumeID}
+	v.Name, _ = context.ServiceName(ctx)
+	if iid, ok := context.InstanceID(ctx); ok {
+		v.Attachments = []*types.VolumeAttachment{{
+			InstanceID: iid,
+			DeviceName: opts.Opts.GetString("deviceName"),
+		}}
+	}
+	v.Fields = map[string]string{
+		"configured": d.config.GetString("plugintest.key"),
+	}
+	return v, nil
+}
+
+// VolumeRemove returns the error named by the volume's ID.
+func (d *testDriver) VolumeRemove(
+	ctx types.Context,
+	volumeID string,
+	opts types.Store) error {
+
+	switch volumeID {
+	case "notFound":
+		return utils.NewNotFoundError(volumeID)
+	case "busy":
+		return utils.NewBusyError(volumeID, goof.New("volume attached"))
+	case "denied":
+		return utils.NewPermissionDeniedError(volumeID, nil)
+	case "failed":
+		return goof.New("remove failed")
+	case "notImplemented":
+		return types.ErrNotImplemented
+	}
+	return nil
+}
+
+// newTestPlugin returns an RPC client connected in process to a plugin that
+// serves the test driver, as go-plugin connects the server to a plugin.
+func newTestPlugin(t *testing.T) *rpc.Client {
+	s := rpc.NewServer()
+	if err := s.RegisterName("Plugin", newServer(newTestDriver)); err != nil {
+		t.Fatal(err)
+	}
+	sc, cc := net.Pipe()
+	go s.ServeConn(sc)
+	return rpc.NewClient(cc)
+}
+
+func newTestPluginDriver(t *testing.T) (*driver, types.Context) {
+	client := newTestPlugin(t)
+
+	var name string
+	assert.NoError(t, client.Call("Plugin.Name", new(interface{}), &name))
+	assert.Equal(t, testDriverName, name)
+
+	ctx := context.Background()
+	config := gofigCore.New()
+	config.Set("plugintest.key", "value")
+
+	d := &driver{name: name, client: client}
+	if err := d.Init(ctx, config); err != nil {
+		t.Fatal(err)
+	}
+	return d, ctx
+}
+
+func TestPluginCallContext(t *testing.T) {
+	d, ctx := newTestPluginDriver(t)
+	defer d.client.Close()
+
+	st, err := d.Type(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, types.NAS, st)
+
+	iid := &types.InstanceID{
+		ID:     "i-1234",
+		Driver: testDriverName,
+		Fields: map[string]string{"zone": "a"},
+	}
+	assert.NoError(t, iid.MarshalMetadata(map[string]string{"vpc": "vpc-1"}))
+	ctx = ctx.WithValue(context.ServiceKey, "nas")
+	ctx = ctx.WithValue(context.InstanceIDKey, iid)
+	opts := utils.NewStore()
+	opts.Set("deviceName", "/dev/xvdb")
+
+	v, err := d.VolumeInspect(
+		ctx, "vol-1", &types.VolumeInspectOpts{Opts: opts})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, "vol-1", v.ID)
+	assert.Equal(t, "nas", v.Name)
+	assert.Equal(t, "value", v.Fields["configured"])
+	if assert.Len(t, v.Attachments, 1) {
+		a := v.Attachments[0]
+		assert.Equal(t, "/dev/xvdb", a.DeviceName)
+		assert.Equal(t, "i-1234", a.InstanceID.ID)
+		assert.Equal(t, testDriverName, a.InstanceID.Driver)
+		assert.Equal(t, "a", a.InstanceID.Fields["zone"])
+		md := map[string]string{}
+		assert.NoError(t, a.InstanceID.UnmarshalMetadata(&md))
+		assert.Equal(t, "vpc-1", md["vpc"])
+	}
+}
+
+func TestPluginCallError(t *testing.T) {
+	d, ctx := newTestPluginDriver(t)
+	defer d.client.Close()
+
+	tests := []struct {
+		volumeID string
+		code     types.ErrorCode
+	}{
+		{"ok", ""},
+		{"notFound", types.ErrorCodeNotFound},
+		{"busy", types.ErrorCodeBusy},
+		{"denied", types.ErrorCodePermissionDenied},
+		{"failed", ""},
+	}
+	for _, tt := range tests {
+		err := d.VolumeRemove(ctx, tt.volumeID, nil)
+		if tt.volumeID == "ok" {
+			assert.NoError(t, err)
+			continue
+		}
+		if !assert.Error(t, err, tt.volumeID) {
+			continue
+		}
+		assert.Equal(t, tt.code, types.ErrorCodeOf(err), tt.volumeID)
+		if tt.code == "" {
+			assert.Equal(t, "remove failed", err.Error())
+			continue
+		}
+		ef := err.(interface {
+			Fields() map[string]interface{}
+		})
+		assert.Equal(t, tt.volumeID, ef.Fields()["resourceID"], tt.volumeID)
+	}
+
+	err := d.VolumeRemove(ctx, "notImplemented", nil)
+	assert.Equal(t, types.ErrNotImplemented, err)
+
+	// the test driver has no capabilities
+	_, err = d.Capabilities(ctx)
+	assert.Equal(t, types.ErrNotImplemented, err)
+}
+
+func TestPluginInvalidInstance(t *testing.T) {
+	client := newTestPlugin(t)
+	defer client.Close()
+
+	d := &driver{name: testDriverName, id: 99, client: client}
+	_, err := d.Type(context.Background())
+	assert.EqualError(t, err, "error calling plugin")
+}
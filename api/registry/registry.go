@@ -6,6 +6,7 @@ import (
 	"strings"
 	"sync"
 
+	gofig "github.com/akutz/gofig/types"
 	"github.com/akutz/goof"
 
 	"github.com/codedellemc/libstorage/api/types"
@@ -29,6 +30,9 @@ var (
 
 	routers    = []types.Router{}
 	routersRWL = &sync.RWMutex{}
+
+	embeddedServerCtor    types.NewEmbeddedServer
+	embeddedServerCtorRWL = &sync.RWMutex{}
 )
 
 // RegisterRouter registers a Router.
@@ -38,6 +42,15 @@ func RegisterRouter(router types.Router) {
 	routers = append(routers, router)
 }
 
+// RegisterEmbeddedServer registers the function that starts an embedded
+// server. The function is registered by the server package, so an embedded
+// server is only available to programs that link the server.
+func RegisterEmbeddedServer(ctor types.NewEmbeddedServer) {
+	embeddedServerCtorRWL.Lock()
+	defer embeddedServerCtorRWL.Unlock()
+	embeddedServerCtor = ctor
+}
+
 // RegisterStorageExecutor registers a StorageExecutor.
 func RegisterStorageExecutor(name string, ctor types.NewStorageExecutor) {
 	storExecsCtorsRWL.Lock()
@@ -95,6 +108,22 @@ func NewStorageExecutor(name string) (types.StorageExecutor, error) {
 	return ctor(), nil
 }
 
+// NewEmbeddedServer starts a new embedded server.
+func NewEmbeddedServer(
+	ctx types.Context,
+	config gofig.Config) (types.EmbeddedServer, error) {
+
+	embeddedServerCtorRWL.RLock()
+	ctor := embeddedServerCtor
+	embeddedServerCtorRWL.RUnlock()
+
+	if ctor == nil {
+		return nil, goof.New("embedded server not registered")
+	}
+
+	return ctor(ctx, config)
+}
+
 // NewClientDriver returns a new instance of the driver specified by
 // the driver name.
 func NewClientDriver(
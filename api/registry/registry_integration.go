@@ -1,6 +1,10 @@
 package registry
 
 import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"sync"
 
 	log "github.com/Sirupsen/logrus"
@@ -14,15 +18,21 @@ import (
 type idm struct {
 	types.IntegrationDriver
 	sync.RWMutex
-	ctx    types.Context
-	config gofig.Config
-	used   map[string]int
+	ctx       types.Context
+	config    gofig.Config
+	used      map[string]int
+	volLocks  map[string]*sync.Mutex
+	stateFile string
 }
 
 // NewIntegrationDriverManager returns a new integration driver manager.
 func NewIntegrationDriverManager(
 	d types.IntegrationDriver) types.IntegrationDriver {
-	return &idm{IntegrationDriver: d, used: map[string]int{}}
+	return &idm{
+		IntegrationDriver: d,
+		used:              map[string]int{},
+		volLocks:          map[string]*sync.Mutex{},
+	}
 }
 
 func (d *idm) Name() string {
@@ -37,13 +47,17 @@ func (d *idm) Init(ctx types.Context, config gofig.Config) error {
 	d.ctx = ctx
 	d.config = config
 	d.used = map[string]int{}
+	d.volLocks = map[string]*sync.Mutex{}
+	d.stateFile = config.GetString(types.ConfigIgVolOpsMountStateFile)
 
+	d.loadCounts(ctx)
 	d.initPathCache(ctx)
 
 	ctx.WithFields(log.Fields{
 		types.ConfigIgVolOpsPathCacheEnabled:  d.pathCacheEnabled(),
 		types.ConfigIgVolOpsPathCacheAsync:    d.pathCacheAsync(),
 		types.ConfigIgVolOpsUnmountIgnoreUsed: d.ignoreUsedCount(),
+		types.ConfigIgVolOpsMountStateFile:    d.stateFile,
 		types.ConfigIgVolOpsMountPreempt:      d.preempt(),
 		types.ConfigIgVolOpsCreateDisable:     d.disableCreate(),
 		types.ConfigIgVolOpsRemoveDisable:     d.disableRemove(),
@@ -111,6 +125,10 @@ func (d *idm) List(
 		vmn := vm.VolumeName()
 		if !d.isCounted(vmn) && vm.MountPoint() != "" {
 			d.initCount(vmn)
+		} else if d.isCounted(vmn) && vm.MountPoint() == "" {
+			// the volume was unmounted by something other than this
+			// driver, such as a reboot, so its persisted count is stale
+			d.removeCount(vmn)
 		}
 	}
 
@@ -143,6 +161,8 @@ func (d *idm) Mount(
 		"opts":       opts}
 	ctx.WithFields(fields).Debug("mounting volume")
 
+	defer d.lockVolume(volumeName)()
+
 	mp, vol, err := d.IntegrationDriver.Mount(
 		ctx.Join(d.ctx), volumeID, volumeName, opts)
 	if err != nil {
@@ -170,6 +190,8 @@ func (d *idm) Unmount(
 		"opts":       opts}
 	ctx.WithFields(fields).Debug("unmounting volume")
 
+	defer d.lockVolume(volumeName)()
+
 	if d.ignoreUsedCount() ||
 		d.resetCount(volumeName) ||
 		!d.isCounted(volumeName) {
@@ -273,12 +295,21 @@ func (d *idm) initCount(volumeName string) {
 	d.Lock()
 	defer d.Unlock()
 	d.used[volumeName] = 0
+	d.saveCounts()
 	d.ctx.WithFields(log.Fields{
 		"volumeName": volumeName,
 		"count":      0,
 	}).Debug("init count")
 }
 
+func (d *idm) removeCount(volumeName string) {
+	d.Lock()
+	defer d.Unlock()
+	delete(d.used, volumeName)
+	d.saveCounts()
+	d.ctx.WithField("volumeName", volumeName).Debug("removed count")
+}
+
 func (d *idm) resetCount(volumeName string) bool {
 	d.Lock()
 	defer d.Unlock()
@@ -289,6 +320,7 @@ func (d *idm) resetCount(volumeName string) bool {
 			"count":      c,
 		}).Info("count reset")
 		d.used[volumeName] = 0
+		d.saveCounts()
 		return true
 	}
 	return false
@@ -304,6 +336,7 @@ func (d *idm) addCount(volumeName string, delta int) {
 		c = 1
 	}
 	d.used[volumeName] = c
+	d.saveCounts()
 	d.ctx.WithFields(log.Fields{
 		"volumeName": volumeName,
 		"count":      c,
@@ -325,6 +358,72 @@ func (d *idm) decCount(volumeName string) {
 	d.addCount(volumeName, -1)
 }
 
+// lockVolume serializes the mount and unmount operations of a volume so that
+// its count reflects the operations in the order in which they complete. The
+// returned function releases the lock.
+func (d *idm) lockVolume(volumeName string) func() {
+	d.Lock()
+	l, ok := d.volLocks[volumeName]
+	if !ok {
+		l = &sync.Mutex{}
+		d.volLocks[volumeName] = l
+	}
+	d.Unlock()
+	l.Lock()
+	return l.Unlock
+}
+
+// loadCounts reads the counts persisted by a previous instance of the
+// driver so that volumes shared by multiple consumers are not unmounted
+// from under the remaining consumers after the process restarts.
+func (d *idm) loadCounts(ctx types.Context) {
+	if d.stateFile == "" {
+		return
+	}
+	buf, err := ioutil.ReadFile(d.stateFile)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			ctx.WithError(err).Warn("error reading mount state file")
+		}
+		return
+	}
+	used := map[string]int{}
+	if err := json.Unmarshal(buf, &used); err != nil {
+		ctx.WithError(err).Warn("error parsing mount state file")
+		return
+	}
+	d.used = used
+	ctx.WithFields(log.Fields{
+		"stateFile": d.stateFile,
+		"volumes":   len(used),
+	}).Info("loaded mount counts")
+}
+
+// saveCounts persists the counts. The file is replaced atomically so that
+// it is never partially written. The caller must hold the lock.
+func (d *idm) saveCounts() {
+	if d.stateFile == "" {
+		return
+	}
+	buf, err := json.Marshal(d.used)
+	if err != nil {
+		d.ctx.WithError(err).Error("error encoding mount counts")
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(d.stateFile), 0755); err != nil {
+		d.ctx.WithError(err).Error("error creating mount state dir")
+		return
+	}
+	tmp := d.stateFile + ".tmp"
+	if err := ioutil.WriteFile(tmp, buf, 0644); err != nil {
+		d.ctx.WithError(err).Error("error writing mount state file")
+		return
+	}
+	if err := os.Rename(tmp, d.stateFile); err != nil {
+		d.ctx.WithError(err).Error("error writing mount state file")
+	}
+}
+
 func (d *idm) preempt() bool {
 	return d.config.GetBool(types.ConfigIgVolOpsMountPreempt)
 }
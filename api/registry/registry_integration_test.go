@@ -0,0 +1,193 @@
+package registry
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	gofigCore "github.com/akutz/gofig"
+	gofig "github.com/akutz/gofig/types"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codedellemc/libstorage/api/context"
+	"github.com/codedellemc/libstorage/api/types"
+)
+
+// testIntegrationDriver mounts volumes at /mnt/<name>, lists the volumes it
+// is given, and counts the volumes it unmounts.
+type testIntegrationDriver struct {
+	types.IntegrationDriver
+	volumes  []types.VolumeMapping
+	unmounts int
+}
+
+func (d *testIntegrationDriver) Name() string {
+	return "integrationtest"
+}
+
+func (d *testIntegrationDriver) Init(
+	ctx types.Context, config gofig.Config) error {
+	return nil
+}
+
+func (d *testIntegrationDriver) List(
+	ctx types.Context,
+	opts types.Store) ([]types.VolumeMapping, error) {
+	return d.volumes, nil
+}
+
+func (d *testIntegrationDriver) Mount(
+	ctx types.Context,
+	volumeID, volumeName string,
+	opts *types.VolumeMountOpts) (string, *types.Volume, error) {
+	return "/mnt/" + volumeName, &types.Volume{Name: volumeName}, nil
+}
+
+func (d *testIntegrationDriver) Unmount(
+	ctx types.Context,
+	volumeID, volumeName string,
+	opts types.Store) error {
+	d.unmounts++
+	return nil
+}
+
+type testVolumeMapping struct {
+	name       string
+	mountPoint string
+}
+
+func (v *testVolumeMapping) VolumeName() string {
+	return v.name
+}
+
+func (v *testVolumeMapping) MountPoint() string {
+	return v.mountPoint
+}
+
+func (v *testVolumeMapping) Status() map[string]interface{} {
+	return nil
+}
+
+func newTestIntegrationDriverManager(
+	t *testing.T,
+	d types.IntegrationDriver,
+	stateFile string,
+	pathCache bool) types.IntegrationDriver {
+
+	config := gofigCore.New()
+	config.Set(types.ConfigIgVolOpsMountStateFile, stateFile)
+	config.Set(types.ConfigIgVolOpsPathCacheEnabled, pathCache)
+	m := NewIntegrationDriverManager(d)
+	if err := m.Init(context.Background(), config); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func readTestMountCounts(t *testing.T, stateFile string) map[string]int {
+	buf, err := ioutil.ReadFile(stateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	used := map[string]int{}
+	if err := json.Unmarshal(buf, &used); err != nil {
+		t.Fatal(err)
+	}
+	return used
+}
+
+func newTestStateDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "libstorage-registry-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+// TestIntegrationMountCounts asserts that the mount counts are persisted and
+// that a volume is unmounted once its last consumer unmounts it, even if the
+// driver restarts in between.
+func TestIntegrationMountCounts(t *testing.T) {
+	dir := newTestStateDir(t)
+	defer os.RemoveAll(dir)
+	stateFile := filepath.Join(dir, "state", "mounts.json")
+
+	ctx := context.Background()
+	d := &testIntegrationDriver{}
+	m := newTestIntegrationDriverManager(t, d, stateFile, false)
+
+	for i := 0; i < 2; i++ {
+		mp, _, err := m.Mount(ctx, "", "data", &types.VolumeMountOpts{})
+		assert.NoError(t, err)
+		assert.Equal(t, "/mnt/data", mp)
+	}
+	assert.Equal(t, map[string]int{"data": 2},
+		readTestMountCounts(t, stateFile))
+
+	// the counts are loaded when the driver is restarted
+	m = newTestIntegrationDriverManager(t, d, stateFile, false)
+	assert.NoError(t, m.Unmount(ctx, "", "data", nil))
+	assert.Equal(t, 0, d.unmounts)
+	assert.Equal(t, map[string]int{"data": 1},
+		readTestMountCounts(t, stateFile))
+
+	assert.NoError(t, m.Unmount(ctx, "", "data", nil))
+	assert.Equal(t, 1, d.unmounts)
+	assert.Equal(t, map[string]int{"data": 0},
+		readTestMountCounts(t, stateFile))
+
+	_, err := os.Stat(stateFile + ".tmp")
+	assert.True(t, os.IsNotExist(err))
+}
+
+// TestIntegrationMountCountsStale asserts that the persisted count of a
+// volume that is no longer mounted is removed when the volumes are listed.
+func TestIntegrationMountCountsStale(t *testing.T) {
+	dir := newTestStateDir(t)
+	defer os.RemoveAll(dir)
+	stateFile := filepath.Join(dir, "mounts.json")
+	if err := ioutil.WriteFile(
+		stateFile, []byte(`{"data":2,"logs":1}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	d := &testIntegrationDriver{volumes: []types.VolumeMapping{
+		&testVolumeMapping{name: "data"},
+		&testVolumeMapping{name: "logs", mountPoint: "/mnt/logs"},
+	}}
+	m := newTestIntegrationDriverManager(t, d, stateFile, true)
+	_, err := m.List(context.Background(), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"logs": 1},
+		readTestMountCounts(t, stateFile))
+}
+
+// TestIntegrationMountCountsInvalid asserts that a state file that cannot be
+// read or parsed is ignored.
+func TestIntegrationMountCountsInvalid(t *testing.T) {
+	dir := newTestStateDir(t)
+	defer os.RemoveAll(dir)
+	stateFile := filepath.Join(dir, "mounts.json")
+	if err := ioutil.WriteFile(
+		stateFile, []byte(`{"data":`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	d := &testIntegrationDriver{}
+	m := newTestIntegrationDriverManager(t, d, stateFile, false)
+	assert.NoError(t, m.Unmount(ctx, "", "data", nil))
+	assert.Equal(t, 1, d.unmounts)
+	assert.Equal(t, map[string]int{"data": 0},
+		readTestMountCounts(t, stateFile))
+
+	// a state file that is a directory is neither read nor written
+	defer os.Remove(dir + ".tmp")
+	m = newTestIntegrationDriverManager(t, d, dir, false)
+	_, _, err := m.Mount(ctx, "", "data", &types.VolumeMountOpts{})
+	assert.NoError(t, err)
+	assert.NoError(t, m.Unmount(ctx, "", "data", nil))
+	assert.Equal(t, 2, d.unmounts)
+}
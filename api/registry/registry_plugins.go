@@ -0,0 +1,97 @@
+package registry
+
+import (
+	"io/ioutil"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/akutz/goof"
+
+	"github.com/codedellemc/libstorage/api/registry/plugin"
+	"github.com/codedellemc/libstorage/api/types"
+)
+
+var (
+	storDriverPlugins    = []*plugin.Plugin{}
+	storDriverPluginsRWL = &sync.RWMutex{}
+)
+
+// LoadStorageDriverPlugins starts the executables in the specified directory
+// as storage driver plugins and registers the drivers they serve. A plugin
+// may not serve a driver with the name of a registered driver. The plugins
+// that are already loaded are skipped.
+func LoadStorageDriverPlugins(ctx types.Context, dir string) error {
+	fis, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return goof.WithFieldE("dir", dir, "error reading plugins dir", err)
+	}
+
+	for _, fi := range fis {
+		if !fi.Mode().IsRegular() || fi.Mode().Perm()&0111 == 0 {
+			continue
+		}
+
+		filePath := path.Join(dir, fi.Name())
+		if isStorageDriverPluginLoaded(filePath) {
+			continue
+		}
+
+		p, err := plugin.Start(ctx, filePath)
+		if err != nil {
+			return err
+		}
+
+		if err := registerStorageDriverPlugin(p); err != nil {
+			p.Kill()
+			return err
+		}
+	}
+
+	return nil
+}
+
+func isStorageDriverPluginLoaded(filePath string) bool {
+	storDriverPluginsRWL.RLock()
+	defer storDriverPluginsRWL.RUnlock()
+	for _, p := range storDriverPlugins {
+		if p.Path() == filePath {
+			return true
+		}
+	}
+	return false
+}
+
+func registerStorageDriverPlugin(p *plugin.Plugin) error {
+	storDriverCtorsRWL.Lock()
+	defer storDriverCtorsRWL.Unlock()
+
+	name := strings.ToLower(p.Name())
+	if _, ok := storDriverCtors[name]; ok {
+		return goof.WithFields(goof.Fields{
+			"driver": p.Name(),
+			"path":   p.Path(),
+		}, "plugin driver already registered")
+	}
+	storDriverCtors[name] = p.NewStorageDriver
+
+	storDriverPluginsRWL.Lock()
+	defer storDriverPluginsRWL.Unlock()
+	storDriverPlugins = append(storDriverPlugins, p)
+	return nil
+}
+
+// CloseStorageDriverPlugins stops the storage driver plugins and
+// unregisters the drivers they serve.
+func CloseStorageDriverPlugins() {
+	storDriverCtorsRWL.Lock()
+	defer storDriverCtorsRWL.Unlock()
+	storDriverPluginsRWL.Lock()
+	defer storDriverPluginsRWL.Unlock()
+
+	for _, p := range storDriverPlugins {
+		delete(storDriverCtors, strings.ToLower(p.Name()))
+		p.Kill()
+	}
+	storDriverPlugins = []*plugin.Plugin{}
+}
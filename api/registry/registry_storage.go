@@ -1,6 +1,11 @@
 package registry
 
-import "github.com/codedellemc/libstorage/api/types"
+import (
+	"fmt"
+
+	"github.com/codedellemc/libstorage/api/types"
+	"github.com/codedellemc/libstorage/api/utils/tracing"
+)
 
 type sdm struct {
 	types.StorageDriver
@@ -8,8 +13,8 @@ type sdm struct {
 }
 
 type sdmWithLogin struct {
-	types.StorageDriverWithLogin
-	types.Context
+	*sdm
+	login types.StorageDriverWithLogin
 }
 
 // NewStorageDriverManager returns a new storage driver manager.
@@ -21,7 +26,17 @@ func NewStorageDriverManager(
 // NewStorageDriverManagerWithLogin returns a new storage driver manager.
 func NewStorageDriverManagerWithLogin(
 	d types.StorageDriverWithLogin) types.StorageDriverWithLogin {
-	return &sdmWithLogin{StorageDriverWithLogin: d}
+	return &sdmWithLogin{sdm: &sdm{StorageDriver: d}, login: d}
+}
+
+// startSpan joins the context with the manager's context and, if the context
+// is part of a trace, starts a span that times the driver's operation.
+func (d *sdm) startSpan(
+	ctx types.Context, op string) (types.Context, *tracing.Span) {
+
+	return tracing.StartSpan(
+		ctx.Join(d.Context),
+		fmt.Sprintf("%s.%s", d.StorageDriver.Name(), op))
 }
 
 func (d *sdm) API() types.APIClient {
@@ -38,6 +53,34 @@ func (d *sdm) XCLI() types.StorageExecutorCLI {
 	return nil
 }
 
+func (d *sdm) HealthCheck(
+	ctx types.Context) error {
+
+	if sd, ok := d.StorageDriver.(types.StorageDriverWithHealthCheck); ok {
+		return sd.HealthCheck(ctx.Join(d.Context))
+	}
+	return types.ErrNotImplemented
+}
+
+func (d *sdm) Capabilities(
+	ctx types.Context) (*types.StorageCapabilities, error) {
+
+	if sd, ok := d.StorageDriver.(types.StorageDriverWithCapabilities); ok {
+		return sd.Capabilities(ctx.Join(d.Context))
+	}
+	return nil, types.ErrNotImplemented
+}
+
+func (d *sdm) OptsSchema(
+	ctx types.Context,
+	operation string) []byte {
+
+	if sd, ok := d.StorageDriver.(types.StorageDriverWithOptsSchema); ok {
+		return sd.OptsSchema(ctx.Join(d.Context), operation)
+	}
+	return nil
+}
+
 func (d *sdm) NextDeviceInfo(
 	ctx types.Context) (*types.NextDeviceInfo, error) {
 
@@ -52,105 +95,163 @@ func (d *sdm) Type(
 
 func (d *sdm) InstanceInspect(
 	ctx types.Context,
-	opts types.Store) (*types.Instance, error) {
+	opts types.Store) (inst *types.Instance, err error) {
+
+	ctx, span := d.startSpan(ctx, "InstanceInspect")
+	defer func() { span.FinishWithError(err) }()
 
-	return d.StorageDriver.InstanceInspect(ctx.Join(d.Context), opts)
+	return d.StorageDriver.InstanceInspect(ctx, opts)
 }
 
 func (d *sdm) Volumes(
 	ctx types.Context,
-	opts *types.VolumesOpts) ([]*types.Volume, error) {
+	opts *types.VolumesOpts) (vols []*types.Volume, err error) {
+
+	ctx, span := d.startSpan(ctx, "Volumes")
+	defer func() { span.FinishWithError(err) }()
 
-	return d.StorageDriver.Volumes(ctx.Join(d.Context), opts)
+	return d.StorageDriver.Volumes(ctx, opts)
+}
+
+func (d *sdm) VolumesIterate(
+	ctx types.Context,
+	opts *types.VolumesOpts,
+	fn func(volume *types.Volume) error) (err error) {
+
+	sd, ok := d.StorageDriver.(types.StorageDriverWithVolumesIterator)
+	if !ok {
+		return types.ErrNotImplemented
+	}
+
+	ctx, span := d.startSpan(ctx, "VolumesIterate")
+	defer func() { span.FinishWithError(err) }()
+
+	return sd.VolumesIterate(ctx, opts, fn)
 }
 
 func (d *sdm) VolumeInspect(
 	ctx types.Context,
 	volumeID string,
-	opts *types.VolumeInspectOpts) (*types.Volume, error) {
+	opts *types.VolumeInspectOpts) (vol *types.Volume, err error) {
+
+	ctx, span := d.startSpan(ctx, "VolumeInspect")
+	defer func() { span.FinishWithError(err) }()
 
-	return d.StorageDriver.VolumeInspect(ctx.Join(d.Context), volumeID, opts)
+	return d.StorageDriver.VolumeInspect(ctx, volumeID, opts)
 }
 
 func (d *sdm) VolumeCreate(
 	ctx types.Context,
 	name string,
-	opts *types.VolumeCreateOpts) (*types.Volume, error) {
+	opts *types.VolumeCreateOpts) (vol *types.Volume, err error) {
+
+	ctx, span := d.startSpan(ctx, "VolumeCreate")
+	defer func() { span.FinishWithError(err) }()
 
-	return d.StorageDriver.VolumeCreate(ctx.Join(d.Context), name, opts)
+	return d.StorageDriver.VolumeCreate(ctx, name, opts)
 }
 
 func (d *sdm) VolumeCreateFromSnapshot(
 	ctx types.Context,
 	snapshotID,
 	volumeName string,
-	opts *types.VolumeCreateOpts) (*types.Volume, error) {
+	opts *types.VolumeCreateOpts) (vol *types.Volume, err error) {
+
+	ctx, span := d.startSpan(ctx, "VolumeCreateFromSnapshot")
+	defer func() { span.FinishWithError(err) }()
 
 	return d.StorageDriver.VolumeCreateFromSnapshot(
-		ctx.Join(d.Context), snapshotID, volumeName, opts)
+		ctx, snapshotID, volumeName, opts)
 }
 
 func (d *sdm) VolumeCopy(
 	ctx types.Context,
 	volumeID,
 	volumeName string,
-	opts types.Store) (*types.Volume, error) {
+	opts types.Store) (vol *types.Volume, err error) {
+
+	ctx, span := d.startSpan(ctx, "VolumeCopy")
+	defer func() { span.FinishWithError(err) }()
 
-	return d.StorageDriver.VolumeCopy(
-		ctx.Join(d.Context), volumeID, volumeName, opts)
+	return d.StorageDriver.VolumeCopy(ctx, volumeID, volumeName, opts)
+}
+
+func (d *sdm) VolumeExpand(
+	ctx types.Context,
+	volumeID string,
+	newSize int64,
+	opts types.Store) (vol *types.Volume, err error) {
+
+	ctx, span := d.startSpan(ctx, "VolumeExpand")
+	defer func() { span.FinishWithError(err) }()
+
+	return d.StorageDriver.VolumeExpand(ctx, volumeID, newSize, opts)
 }
 
 func (d *sdm) VolumeSnapshot(
 	ctx types.Context,
 	volumeID,
 	snapshotName string,
-	opts types.Store) (*types.Snapshot, error) {
+	opts types.Store) (snap *types.Snapshot, err error) {
+
+	ctx, span := d.startSpan(ctx, "VolumeSnapshot")
+	defer func() { span.FinishWithError(err) }()
 
-	return d.StorageDriver.VolumeSnapshot(
-		ctx.Join(d.Context), volumeID, snapshotName, opts)
+	return d.StorageDriver.VolumeSnapshot(ctx, volumeID, snapshotName, opts)
 }
 
 func (d *sdm) VolumeRemove(
 	ctx types.Context,
 	volumeID string,
-	opts types.Store) error {
+	opts types.Store) (err error) {
 
-	return d.StorageDriver.VolumeRemove(
-		ctx.Join(d.Context), volumeID, opts)
+	ctx, span := d.startSpan(ctx, "VolumeRemove")
+	defer func() { span.FinishWithError(err) }()
+
+	return d.StorageDriver.VolumeRemove(ctx, volumeID, opts)
 }
 
 func (d *sdm) VolumeAttach(
 	ctx types.Context,
 	volumeID string,
-	opts *types.VolumeAttachOpts) (*types.Volume, string, error) {
+	opts *types.VolumeAttachOpts) (vol *types.Volume, tok string, err error) {
+
+	ctx, span := d.startSpan(ctx, "VolumeAttach")
+	defer func() { span.FinishWithError(err) }()
 
-	return d.StorageDriver.VolumeAttach(
-		ctx.Join(d.Context), volumeID, opts)
+	return d.StorageDriver.VolumeAttach(ctx, volumeID, opts)
 }
 
 func (d *sdm) VolumeDetach(
 	ctx types.Context,
 	volumeID string,
-	opts *types.VolumeDetachOpts) (*types.Volume, error) {
+	opts *types.VolumeDetachOpts) (vol *types.Volume, err error) {
+
+	ctx, span := d.startSpan(ctx, "VolumeDetach")
+	defer func() { span.FinishWithError(err) }()
 
-	return d.StorageDriver.VolumeDetach(
-		ctx.Join(d.Context), volumeID, opts)
+	return d.StorageDriver.VolumeDetach(ctx, volumeID, opts)
 }
 
 func (d *sdm) Snapshots(
 	ctx types.Context,
-	opts types.Store) ([]*types.Snapshot, error) {
+	opts types.Store) (snaps []*types.Snapshot, err error) {
 
-	return d.StorageDriver.Snapshots(ctx.Join(d.Context), opts)
+	ctx, span := d.startSpan(ctx, "Snapshots")
+	defer func() { span.FinishWithError(err) }()
+
+	return d.StorageDriver.Snapshots(ctx, opts)
 }
 
 func (d *sdm) SnapshotInspect(
 	ctx types.Context,
 	snapshotID string,
-	opts types.Store) (*types.Snapshot, error) {
+	opts types.Store) (snap *types.Snapshot, err error) {
+
+	ctx, span := d.startSpan(ctx, "SnapshotInspect")
+	defer func() { span.FinishWithError(err) }()
 
-	return d.StorageDriver.SnapshotInspect(
-		ctx.Join(d.Context), snapshotID, opts)
+	return d.StorageDriver.SnapshotInspect(ctx, snapshotID, opts)
 }
 
 func (d *sdm) SnapshotCopy(
@@ -158,22 +259,31 @@ func (d *sdm) SnapshotCopy(
 	snapshotID,
 	snapshotName,
 	destinationID string,
-	opts types.Store) (*types.Snapshot, error) {
+	opts types.Store) (snap *types.Snapshot, err error) {
+
+	ctx, span := d.startSpan(ctx, "SnapshotCopy")
+	defer func() { span.FinishWithError(err) }()
 
 	return d.StorageDriver.SnapshotCopy(
-		ctx.Join(d.Context), snapshotID, snapshotName, destinationID, opts)
+		ctx, snapshotID, snapshotName, destinationID, opts)
 }
 
 func (d *sdm) SnapshotRemove(
 	ctx types.Context,
 	snapshotID string,
-	opts types.Store) error {
+	opts types.Store) (err error) {
 
-	return d.StorageDriver.SnapshotRemove(ctx.Join(d.Context), snapshotID, opts)
+	ctx, span := d.startSpan(ctx, "SnapshotRemove")
+	defer func() { span.FinishWithError(err) }()
+
+	return d.StorageDriver.SnapshotRemove(ctx, snapshotID, opts)
 }
 
 func (d *sdmWithLogin) Login(
-	ctx types.Context) (interface{}, error) {
+	ctx types.Context) (sess interface{}, err error) {
+
+	ctx, span := d.startSpan(ctx, "Login")
+	defer func() { span.FinishWithError(err) }()
 
-	return d.StorageDriverWithLogin.Login(ctx.Join(d.Context))
+	return d.login.Login(ctx)
 }
@@ -0,0 +1,41 @@
+package registry
+
+import (
+	"testing"
+
+	"github.com/akutz/goof"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codedellemc/libstorage/api/context"
+	"github.com/codedellemc/libstorage/api/types"
+)
+
+type testStorageDriver struct {
+	types.StorageDriver
+}
+
+type testHealthCheckDriver struct {
+	types.StorageDriver
+	err error
+}
+
+func (d *testHealthCheckDriver) HealthCheck(ctx types.Context) error {
+	return d.err
+}
+
+func TestStorageDriverManagerHealthCheck(t *testing.T) {
+	ctx := context.Background()
+
+	d := NewStorageDriverManager(&testStorageDriver{})
+	hc, ok := d.(types.StorageDriverWithHealthCheck)
+	if !assert.True(t, ok) {
+		t.FailNow()
+	}
+	assert.Equal(t, types.ErrNotImplemented, hc.HealthCheck(ctx))
+
+	hd := &testHealthCheckDriver{}
+	hc = NewStorageDriverManager(hd).(types.StorageDriverWithHealthCheck)
+	assert.NoError(t, hc.HealthCheck(ctx))
+	hd.err = goof.New("unauthorized")
+	assert.EqualError(t, hc.HealthCheck(ctx), "unauthorized")
+}
@@ -0,0 +1,59 @@
+package audit
+
+import (
+	gofig "github.com/akutz/gofig/types"
+
+	"github.com/codedellemc/libstorage/api/types"
+)
+
+// Sink receives audit records.
+type Sink interface {
+
+	// Audit emits an audit record.
+	Audit(ctx types.Context, record *types.AuditRecord)
+}
+
+type sinks []Sink
+
+func (s sinks) Audit(ctx types.Context, record *types.AuditRecord) {
+	for _, sink := range s {
+		sink.Audit(ctx, record)
+	}
+}
+
+// New returns a new audit sink that emits records to each of the sinks
+// configured with libstorage.server.audit.file,
+// libstorage.server.audit.syslog, and libstorage.server.audit.webhook. A nil
+// value is returned if no sinks are configured.
+func New(ctx types.Context, config gofig.Config) (Sink, error) {
+
+	var s sinks
+
+	if path := config.GetString(types.ConfigServerAuditFile); path != "" {
+		sink, err := newFileSink(path)
+		if err != nil {
+			return nil, err
+		}
+		ctx.WithField("path", path).Info("configured file audit sink")
+		s = append(s, sink)
+	}
+
+	if config.GetBool(types.ConfigServerAuditSyslog) {
+		sink, err := newSyslogSink()
+		if err != nil {
+			return nil, err
+		}
+		ctx.Info("configured syslog audit sink")
+		s = append(s, sink)
+	}
+
+	if url := config.GetString(types.ConfigServerAuditWebhook); url != "" {
+		ctx.WithField("url", url).Info("configured webhook audit sink")
+		s = append(s, newWebhookSink(url))
+	}
+
+	if len(s) == 0 {
+		return nil, nil
+	}
+	return s, nil
+}
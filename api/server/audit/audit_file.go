@@ -0,0 +1,42 @@
+package audit
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/codedellemc/libstorage/api/types"
+)
+
+// fileSink appends audit records to a file, one JSON document per line.
+type fileSink struct {
+	sync.Mutex
+	f *os.File
+}
+
+func newFileSink(path string) (*fileSink, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &fileSink{f: f}, nil
+}
+
+func (s *fileSink) Audit(ctx types.Context, record *types.AuditRecord) {
+	buf, err := json.Marshal(record)
+	if err != nil {
+		ctx.WithError(err).Error("error encoding audit record")
+		return
+	}
+	buf = append(buf, '\n')
+
+	s.Lock()
+	defer s.Unlock()
+	if _, err := s.f.Write(buf); err != nil {
+		ctx.WithError(err).Error("error writing audit record")
+	}
+}
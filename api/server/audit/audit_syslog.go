@@ -0,0 +1,34 @@
+// +build !windows
+
+package audit
+
+import (
+	"encoding/json"
+	"log/syslog"
+
+	"github.com/codedellemc/libstorage/api/types"
+)
+
+// syslogSink emits audit records to the local syslog daemon.
+type syslogSink struct {
+	w *syslog.Writer
+}
+
+func newSyslogSink() (*syslogSink, error) {
+	w, err := syslog.New(syslog.LOG_NOTICE|syslog.LOG_AUTHPRIV, "libstorage")
+	if err != nil {
+		return nil, err
+	}
+	return &syslogSink{w: w}, nil
+}
+
+func (s *syslogSink) Audit(ctx types.Context, record *types.AuditRecord) {
+	buf, err := json.Marshal(record)
+	if err != nil {
+		ctx.WithError(err).Error("error encoding audit record")
+		return
+	}
+	if err := s.w.Notice(string(buf)); err != nil {
+		ctx.WithError(err).Error("error writing audit record")
+	}
+}
@@ -0,0 +1,11 @@
+// +build windows
+
+package audit
+
+import (
+	"github.com/akutz/goof"
+)
+
+func newSyslogSink() (Sink, error) {
+	return nil, goof.New("syslog audit sink unsupported on windows")
+}
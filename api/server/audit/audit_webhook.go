@@ -0,0 +1,20 @@
+package audit
+
+import (
+	"github.com/codedellemc/libstorage/api/types"
+	"github.com/codedellemc/libstorage/api/utils"
+)
+
+// webhookSink posts audit records to a URL without delaying the operations
+// being audited.
+type webhookSink struct {
+	*utils.Webhook
+}
+
+func newWebhookSink(url string) *webhookSink {
+	return &webhookSink{utils.NewWebhook(url)}
+}
+
+func (s *webhookSink) Audit(ctx types.Context, record *types.AuditRecord) {
+	s.Post(ctx, record)
+}
@@ -0,0 +1,107 @@
+package events
+
+import (
+	"sync"
+
+	log "github.com/Sirupsen/logrus"
+	gofig "github.com/akutz/gofig/types"
+
+	"github.com/codedellemc/libstorage/api/types"
+	"github.com/codedellemc/libstorage/api/utils"
+)
+
+const (
+	// subscriberQueueSize is the number of events that may be waiting to be
+	// received by a subscriber before new events are dropped.
+	subscriberQueueSize = 64
+
+	// historySize is the number of the most recent events retained so that a
+	// subscriber that reconnects may receive the events it missed.
+	historySize = 256
+)
+
+// Bus publishes volume lifecycle events to subscribers and to the webhooks
+// configured with libstorage.server.events.webhooks.
+type Bus struct {
+	sync.Mutex
+	nextID   int64
+	history  []*types.Event
+	subs     map[chan *types.Event]struct{}
+	webhooks []*utils.Webhook
+}
+
+// New returns a new event bus.
+func New(ctx types.Context, config gofig.Config) *Bus {
+	b := &Bus{subs: map[chan *types.Event]struct{}{}}
+	for _, url := range config.GetStringSlice(
+		types.ConfigServerEventsWebhooks) {
+		ctx.WithField("url", url).Info("configured event webhook")
+		b.webhooks = append(b.webhooks, utils.NewWebhook(url))
+	}
+	return b
+}
+
+// Publish assigns the event its ID and delivers it to all subscribers and
+// webhooks. Publish never blocks on a slow subscriber or webhook; instead the
+// event is dropped for that recipient.
+func (b *Bus) Publish(ctx types.Context, e *types.Event) {
+	b.Lock()
+	b.nextID++
+	e.ID = b.nextID
+
+	b.history = append(b.history, e)
+	if len(b.history) > historySize {
+		b.history = b.history[len(b.history)-historySize:]
+	}
+
+	for c := range b.subs {
+		select {
+		case c <- e:
+		default:
+			ctx.WithField("eventID", e.ID).Warn(
+				"subscriber queue full; dropped event")
+		}
+	}
+	b.Unlock()
+
+	ctx.WithFields(log.Fields{
+		"eventID":   e.ID,
+		"eventType": e.Type,
+	}).Debug("published event")
+
+	for _, w := range b.webhooks {
+		w.Post(ctx, e)
+	}
+}
+
+// Subscribe returns a channel on which published events are received and a
+// function that must be invoked to unsubscribe. If lastID is greater than
+// zero then the retained events with a greater ID are received first.
+func (b *Bus) Subscribe(lastID int64) (<-chan *types.Event, func()) {
+	b.Lock()
+	defer b.Unlock()
+
+	var missed []*types.Event
+	if lastID > 0 {
+		for _, e := range b.history {
+			if e.ID > lastID {
+				missed = append(missed, e)
+			}
+		}
+	}
+
+	c := make(chan *types.Event, subscriberQueueSize+len(missed))
+	for _, e := range missed {
+		c <- e
+	}
+	b.subs[c] = struct{}{}
+
+	var once sync.Once
+	return c, func() {
+		once.Do(func() {
+			b.Lock()
+			delete(b.subs, c)
+			b.Unlock()
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package events
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	gofigCore "github.com/akutz/gofig"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codedellemc/libstorage/api/context"
+	"github.com/codedellemc/libstorage/api/types"
+)
+
+func newTestBus() (*Bus, types.Context) {
+	ctx := context.Background()
+	return New(ctx, gofigCore.New()), ctx
+}
+
+func TestBus(t *testing.T) {
+	b, ctx := newTestBus()
+
+	c1, unsub1 := b.Subscribe(0)
+	c2, unsub2 := b.Subscribe(0)
+	defer unsub2()
+
+	b.Publish(ctx, &types.Event{Type: types.EventVolumeCreated})
+	b.Publish(ctx, &types.Event{Type: types.EventVolumeRemoved})
+
+	for _, c := range []<-chan *types.Event{c1, c2} {
+		e := <-c
+		assert.Equal(t, int64(1), e.ID)
+		assert.Equal(t, types.EventVolumeCreated, e.Type)
+		e = <-c
+		assert.Equal(t, int64(2), e.ID)
+		assert.Equal(t, types.EventVolumeRemoved, e.Type)
+	}
+
+	// an unsubscribed channel receives no more events, and unsubscribing
+	// again is harmless
+	unsub1()
+	unsub1()
+	b.Publish(ctx, &types.Event{Type: types.EventVolumeAttached})
+	assert.Equal(t, int64(3), (<-c2).ID)
+	select {
+	case e := <-c1:
+		t.Fatalf("unexpected event %d", e.ID)
+	default:
+	}
+}
+
+func TestBusReplay(t *testing.T) {
+	b, ctx := newTestBus()
+	for i := 0; i < historySize+10; i++ {
+		b.Publish(ctx, &types.Event{Type: types.EventVolumeCreated})
+	}
+
+	// a subscriber that reconnects receives the events it missed
+	c, unsub := b.Subscribe(int64(historySize + 7))
+	defer unsub()
+	assert.Equal(t, int64(historySize+8), (<-c).ID)
+	assert.Equal(t, int64(historySize+9), (<-c).ID)
+	assert.Equal(t, int64(historySize+10), (<-c).ID)
+
+	// only the most recent events are retained
+	c, unsub = b.Subscribe(1)
+	defer unsub()
+	assert.Len(t, c, historySize)
+	assert.Equal(t, int64(11), (<-c).ID)
+}
+
+func TestBusSlowSubscriber(t *testing.T) {
+	b, ctx := newTestBus()
+	c, unsub := b.Subscribe(0)
+	defer unsub()
+
+	// events for a subscriber whose queue is full are dropped rather than
+	// blocking the publisher
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < subscriberQueueSize+5; i++ {
+			b.Publish(ctx, &types.Event{Type: types.EventVolumeCreated})
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("publish blocked on a full subscriber queue")
+	}
+	assert.Len(t, c, subscriberQueueSize)
+}
+
+func TestBusWebhook(t *testing.T) {
+	received := make(chan *types.Event, 1)
+	hs := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, req *http.Request) {
+			e := &types.Event{}
+			if err := json.NewDecoder(req.Body).Decode(e); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			received <- e
+		}))
+	defer hs.Close()
+
+	config := gofigCore.New()
+	config.Set(types.ConfigServerEventsWebhooks, []string{hs.URL})
+	ctx := context.Background()
+	b := New(ctx, config)
+
+	b.Publish(ctx, &types.Event{
+		Type: types.EventVolumeDetached, Service: "ebs", VolumeID: "vol-1"})
+
+	select {
+	case e := <-received:
+		assert.Equal(t, int64(1), e.ID)
+		assert.Equal(t, types.EventVolumeDetached, e.Type)
+		assert.Equal(t, "ebs", e.Service)
+		assert.Equal(t, "vol-1", e.VolumeID)
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook did not receive event")
+	}
+}
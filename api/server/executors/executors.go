@@ -25,7 +25,7 @@ func init() {
 			panic(err)
 		}
 
-		executors[path] = &ExecutorInfoEx{
+		ei := &ExecutorInfoEx{
 			ExecutorInfo: types.ExecutorInfo{
 				Name:         path,
 				MD5Checksum:  bd.info.MD5Checksum(),
@@ -33,6 +33,12 @@ func init() {
 				LastModified: bd.info.ModTime().Unix(),
 			},
 		}
+		if goos, goarch, libc, ok := types.ParseExecutorName(path); ok {
+			ei.OS = goos
+			ei.Arch = goarch
+			ei.Libc = libc
+		}
+		executors[path] = ei
 	}
 }
 
@@ -49,6 +55,23 @@ func ExecutorInfos() <-chan *ExecutorInfoEx {
 	return c
 }
 
+// Matches returns a flag indicating whether the executor is built for the
+// provided OS, architecture, and C library. An empty OS or architecture
+// matches any executor, and a C library other than musl matches the
+// executors that are not built for musl.
+func (i *ExecutorInfoEx) Matches(goos, goarch, libc string) bool {
+	if goos != "" && goos != i.OS {
+		return false
+	}
+	if goarch != "" && goarch != i.Arch {
+		return false
+	}
+	if libc != "" && (libc == types.LibcMusl) != (i.Libc == types.LibcMusl) {
+		return false
+	}
+	return true
+}
+
 // ExecutorInfoInspect returns the executor info for the provided name.
 func ExecutorInfoInspect(name string, data bool) (*ExecutorInfoEx, error) {
 	ei, ok := executors[name]
@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	gofig "github.com/akutz/gofig/types"
+
+	"github.com/codedellemc/libstorage/api/context"
+	"github.com/codedellemc/libstorage/api/types"
+	"github.com/codedellemc/libstorage/api/utils"
+)
+
+// authHandler is a global HTTP filter for authenticating requests with a
+// bearer token.
+type authHandler struct {
+	handler   types.APIFunc
+	auth      *authenticator
+	endpoints map[string]*authenticator
+}
+
+// authPublicRoutes are the names of the routes whose requests are not
+// authenticated. The dashboard route serves a static page that requests the
+// data it displays with a token entered on the page.
+var authPublicRoutes = map[string]bool{
+	"ui": true,
+}
+
+// authenticator verifies the bearer tokens of the requests received by one
+// or more endpoints.
+type authenticator struct {
+	tokens map[string]string
+	jwt    *jwtVerifier
+}
+
+// NewAuthHandler returns a new global HTTP filter for authenticating requests
+// with a bearer token. The bearer token may be one of the static tokens
+// configured with libstorage.server.auth.tokens or a JSON web token signed by
+// the key configured with libstorage.server.auth.jwt.key or one of the keys
+// published at libstorage.server.auth.jwt.jwks.
+//
+// An endpoint that defines its own auth properties, such as
+// libstorage.server.endpoints.public.auth.tokens, authenticates the requests
+// it receives with those instead, and an endpoint whose auth.disabled
+// property is true does not authenticate requests.
+//
+// A nil value is returned if no endpoint authenticates requests, indicating
+// authentication is disabled.
+func NewAuthHandler(
+	ctx types.Context, config gofig.Config) (types.Middleware, error) {
+
+	auth, err := newAuthenticator(ctx, config, types.ConfigServerAuth)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoints := map[string]*authenticator{}
+	if endpointsObj, ok := config.Get(
+		types.ConfigEndpoints).(map[string]interface{}); ok {
+
+		for name := range endpointsObj {
+			prefix := fmt.Sprintf("%s.%s.auth", types.ConfigEndpoints, name)
+			if config.GetBool(prefix + ".disabled") {
+				endpoints[name] = nil
+				continue
+			}
+			if config.Get(prefix) == nil {
+				continue
+			}
+			ea, err := newAuthenticator(ctx, config, prefix)
+			if err != nil {
+				return nil, err
+			}
+			endpoints[name] = ea
+		}
+	}
+
+	enabled := auth != nil
+	for _, ea := range endpoints {
+		enabled = enabled || ea != nil
+	}
+	if !enabled {
+		return nil, nil
+	}
+
+	return &authHandler{auth: auth, endpoints: endpoints}, nil
+}
+
+// newAuthenticator returns an authenticator for the static tokens and JSON
+// web token keys configured with the auth properties that have the provided
+// prefix. A nil value is returned if neither are configured.
+func newAuthenticator(
+	ctx types.Context,
+	config gofig.Config,
+	prefix string) (*authenticator, error) {
+
+	tokens := map[string]string{}
+	if tokensObj, ok := config.Get(
+		prefix + ".tokens").(map[string]interface{}); ok {
+
+		for subject := range tokensObj {
+			key := fmt.Sprintf("%s.tokens.%s", prefix, subject)
+			token := config.GetString(key)
+			if token == "" {
+				continue
+			}
+			tokens[token] = subject
+		}
+	}
+
+	jwt, err := newJWTVerifier(
+		ctx,
+		config.GetString(prefix+".jwt.key"),
+		config.GetString(prefix+".jwt.jwks"),
+		config.GetString(prefix+".jwt.audience"),
+		config.GetString(prefix+".jwt.issuer"))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tokens) == 0 && jwt == nil {
+		return nil, nil
+	}
+
+	ctx.WithFields(log.Fields{
+		"config": prefix,
+		"tokens": len(tokens),
+		"jwt":    jwt != nil,
+	}).Info("configured authentication")
+
+	return &authenticator{tokens: tokens, jwt: jwt}, nil
+}
+
+func (h *authHandler) Name() string {
+	return "auth-handler"
+}
+
+func (h *authHandler) Handler(m types.APIFunc) types.APIFunc {
+	return (&authHandler{m, h.auth, h.endpoints}).Handle
+}
+
+// Handle is the type's Handler function.
+func (h *authHandler) Handle(
+	ctx types.Context,
+	w http.ResponseWriter,
+	req *http.Request,
+	store types.Store) error {
+
+	if route, ok := context.Route(ctx); ok &&
+		authPublicRoutes[route.GetName()] {
+		return h.handler(ctx, w, req, store)
+	}
+
+	auth := h.auth
+	if name, ok := context.Endpoint(ctx); ok {
+		if ea, ok := h.endpoints[name]; ok {
+			auth = ea
+		}
+	}
+	if auth == nil {
+		return h.handler(ctx, w, req, store)
+	}
+
+	tok, err := auth.authenticate(
+		ctx, req.Header.Get(types.AuthorizationHeader))
+	if err != nil {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="libstorage"`)
+		return err
+	}
+
+	ctx = ctx.WithValue(context.AuthTokenKey, tok)
+	ctx = ctx.WithValue(context.UserKey, tok.Subject)
+	ctx.Debug("authenticated request")
+
+	return h.handler(ctx, w, req, store)
+}
+
+func (a *authenticator) authenticate(
+	ctx types.Context, header string) (*types.AuthToken, error) {
+
+	if header == "" {
+		return nil, utils.NewUnauthorizedError("missing bearer token")
+	}
+
+	parts := strings.SplitN(header, " ", 2)
+	if len(parts) != 2 || !strings.EqualFold(parts[0], "bearer") {
+		return nil, utils.NewUnauthorizedError("invalid authorization scheme")
+	}
+	token := strings.TrimSpace(parts[1])
+
+	// compare every static token in constant time so the response time does
+	// not reveal how much of a token matched
+	var subject string
+	for k, v := range a.tokens {
+		if subtle.ConstantTimeCompare([]byte(k), []byte(token)) == 1 {
+			subject = v
+		}
+	}
+	if subject != "" {
+		return &types.AuthToken{Subject: subject}, nil
+	}
+
+	if a.jwt == nil {
+		return nil, utils.NewUnauthorizedError("invalid bearer token")
+	}
+
+	tok, err := a.jwt.verify(ctx, token)
+	if err != nil {
+		ctx.WithError(err).Debug("invalid json web token")
+		return nil, utils.NewUnauthorizedError("invalid bearer token")
+	}
+	return tok, nil
+}
@@ -0,0 +1,354 @@
+package handlers
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/akutz/goof"
+	"github.com/akutz/gotil"
+
+	"github.com/codedellemc/libstorage/api/types"
+)
+
+const (
+	// jwksRefreshInterval is the minimum amount of time between requests for
+	// the JWKS document when a token is signed by an unknown key.
+	jwksRefreshInterval = time.Minute * 5
+
+	// jwksTimeout is the amount of time after which a request for the JWKS
+	// document times out.
+	jwksTimeout = time.Second * 10
+)
+
+// jwksClient requests the JWKS document. A request for the document is made
+// while a token is verified, so it must not block the request being
+// authenticated indefinitely.
+var jwksClient = &http.Client{Timeout: jwksTimeout}
+
+var jwtHashes = map[string]crypto.Hash{
+	"HS256": crypto.SHA256,
+	"HS384": crypto.SHA384,
+	"HS512": crypto.SHA512,
+	"RS256": crypto.SHA256,
+	"RS384": crypto.SHA384,
+	"RS512": crypto.SHA512,
+}
+
+// jwtVerifier validates JSON web tokens signed with either a shared secret
+// (HS256, HS384, HS512) or an RSA key (RS256, RS384, RS512). A token must be
+// issued for the audience and by the issuer, if they are configured.
+type jwtVerifier struct {
+	sync.RWMutex
+	secret    []byte
+	pubKey    *rsa.PublicKey
+	audience  string
+	issuer    string
+	jwksURL   string
+	jwksKeys  map[string]*rsa.PublicKey
+	jwksFetch time.Time
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+type jwtClaims struct {
+	Sub string      `json:"sub"`
+	Iss string      `json:"iss"`
+	Aud jwtAudience `json:"aud"`
+	Exp float64     `json:"exp"`
+	Nbf float64     `json:"nbf"`
+}
+
+// jwtAudience is the aud claim, which is either a single audience or an
+// array of audiences.
+type jwtAudience []string
+
+func (a *jwtAudience) UnmarshalJSON(buf []byte) error {
+	var s string
+	if err := json.Unmarshal(buf, &s); err == nil {
+		*a = jwtAudience{s}
+		return nil
+	}
+	var ss []string
+	if err := json.Unmarshal(buf, &ss); err != nil {
+		return err
+	}
+	*a = jwtAudience(ss)
+	return nil
+}
+
+func (a jwtAudience) contains(audience string) bool {
+	for _, s := range a {
+		if s == audience {
+			return true
+		}
+	}
+	return false
+}
+
+type jwks struct {
+	Keys []struct {
+		Kty string `json:"kty"`
+		Kid string `json:"kid"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+	} `json:"keys"`
+}
+
+// newJWTVerifier returns a new JSON web token verifier. The key may be a
+// shared secret, a PEM-encoded RSA public key, or the path to a file that
+// contains either. The aud and iss claims are not checked if the audience
+// and issuer are empty. A nil value is returned if both the key and the JWKS
+// URL are empty.
+func newJWTVerifier(
+	ctx types.Context,
+	key, jwksURL, audience, issuer string) (*jwtVerifier, error) {
+
+	if key == "" && jwksURL == "" {
+		return nil, nil
+	}
+
+	v := &jwtVerifier{
+		jwksURL:  jwksURL,
+		audience: audience,
+		issuer:   issuer,
+	}
+
+	if key != "" {
+		buf := []byte(key)
+		if gotil.FileExists(key) {
+			var err error
+			if buf, err = ioutil.ReadFile(key); err != nil {
+				return nil, err
+			}
+		}
+		if block, _ := pem.Decode(buf); block != nil {
+			pubKey, err := parseRSAPublicKey(block.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			v.pubKey = pubKey
+		} else {
+			v.secret = buf
+		}
+	}
+
+	if jwksURL != "" {
+		if err := v.refreshJWKS(); err != nil {
+			return nil, err
+		}
+		ctx.WithField("jwks", jwksURL).Debug("loaded json web key set")
+	}
+
+	return v, nil
+}
+
+// verify validates the signature and claims of the JSON web token and returns
+// the identity it establishes.
+func (v *jwtVerifier) verify(
+	ctx types.Context, token string) (*types.AuthToken, error) {
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, goof.New("malformed token")
+	}
+
+	var header jwtHeader
+	if err := decodeJWTSegment(parts[0], &header); err != nil {
+		return nil, err
+	}
+
+	hash, ok := jwtHashes[header.Alg]
+	if !ok {
+		return nil, goof.WithField("alg", header.Alg, "unsupported algorithm")
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, err
+	}
+
+	signed := []byte(parts[0] + "." + parts[1])
+
+	if strings.HasPrefix(header.Alg, "HS") {
+		if v.secret == nil {
+			return nil, goof.WithField(
+				"alg", header.Alg, "no shared secret configured")
+		}
+		mac := hmac.New(hash.New, v.secret)
+		mac.Write(signed)
+		if !hmac.Equal(sig, mac.Sum(nil)) {
+			return nil, goof.New("invalid signature")
+		}
+	} else {
+		pubKey, err := v.rsaKey(ctx, header.Kid)
+		if err != nil {
+			return nil, err
+		}
+		h := hash.New()
+		h.Write(signed)
+		if err := rsa.VerifyPKCS1v15(
+			pubKey, hash, h.Sum(nil), sig); err != nil {
+			return nil, goof.New("invalid signature")
+		}
+	}
+
+	var claims jwtClaims
+	if err := decodeJWTSegment(parts[1], &claims); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().Unix()
+	if claims.Exp > 0 && now >= int64(claims.Exp) {
+		return nil, goof.New("token expired")
+	}
+	if claims.Nbf > 0 && now < int64(claims.Nbf) {
+		return nil, goof.New("token not yet valid")
+	}
+	if claims.Sub == "" {
+		return nil, goof.New("missing subject")
+	}
+	if v.issuer != "" && claims.Iss != v.issuer {
+		return nil, goof.WithField("iss", claims.Iss, "invalid issuer")
+	}
+	if v.audience != "" && !claims.Aud.contains(v.audience) {
+		return nil, goof.WithField(
+			"aud", []string(claims.Aud), "invalid audience")
+	}
+
+	return &types.AuthToken{
+		Subject: claims.Sub,
+		Expires: int64(claims.Exp),
+	}, nil
+}
+
+// rsaKey returns the RSA public key used to verify a token. A token signed by
+// a key missing from the JWKS document causes the document to be reloaded,
+// but no more often than the jwksRefreshInterval. The keys already loaded
+// continue to be used if the document cannot be reloaded.
+func (v *jwtVerifier) rsaKey(
+	ctx types.Context, kid string) (*rsa.PublicKey, error) {
+
+	if kid == "" || v.jwksURL == "" {
+		if v.pubKey == nil {
+			return nil, goof.New("no public key configured")
+		}
+		return v.pubKey, nil
+	}
+
+	v.RLock()
+	pubKey, ok := v.jwksKeys[kid]
+	stale := time.Since(v.jwksFetch) > jwksRefreshInterval
+	v.RUnlock()
+
+	if ok {
+		return pubKey, nil
+	}
+
+	if stale {
+		ctx.WithField("kid", kid).Debug("reloading json web key set")
+		if err := v.refreshJWKS(); err != nil {
+			ctx.WithError(err).Warn("error reloading json web key set")
+			return nil, goof.WithField("kid", kid, "unknown key")
+		}
+		v.RLock()
+		pubKey, ok = v.jwksKeys[kid]
+		v.RUnlock()
+		if ok {
+			return pubKey, nil
+		}
+	}
+
+	return nil, goof.WithField("kid", kid, "unknown key")
+}
+
+// refreshJWKS reloads the JWKS document. The time of the attempt is
+// recorded even if it fails so that an unavailable document is not requested
+// for every token, and the keys already loaded are kept.
+func (v *jwtVerifier) refreshJWKS() error {
+	v.Lock()
+	v.jwksFetch = time.Now()
+	v.Unlock()
+
+	res, err := jwksClient.Get(v.jwksURL)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return goof.WithFields(goof.Fields{
+			"jwks":   v.jwksURL,
+			"status": res.StatusCode,
+		}, "error loading json web key set")
+	}
+
+	var doc jwks
+	if err := json.NewDecoder(res.Body).Decode(&doc); err != nil {
+		return err
+	}
+
+	keys := map[string]*rsa.PublicKey{}
+	for _, k := range doc.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return err
+		}
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+
+	v.Lock()
+	v.jwksKeys = keys
+	v.Unlock()
+
+	return nil
+}
+
+func parseRSAPublicKey(der []byte) (*rsa.PublicKey, error) {
+	if key, err := x509.ParsePKIXPublicKey(der); err == nil {
+		if pubKey, ok := key.(*rsa.PublicKey); ok {
+			return pubKey, nil
+		}
+		return nil, goof.New("public key is not an rsa key")
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	pubKey, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return nil, goof.New("certificate does not contain an rsa key")
+	}
+	return pubKey, nil
+}
+
+func decodeJWTSegment(seg string, v interface{}) error {
+	buf, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(buf, v)
+}
@@ -0,0 +1,350 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codedellemc/libstorage/api/context"
+)
+
+func newTestRSAKey(t *testing.T) *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func testRSAKeyPEM(t *testing.T, key *rsa.PrivateKey) string {
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(
+		&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+// newTestJWT returns a token with the specified header and claims that is
+// signed with key, which is either a shared secret or an RSA private key.
+// A token whose alg is not supported is not signed.
+func newTestJWT(
+	t *testing.T,
+	header, claims map[string]interface{},
+	key interface{}) string {
+
+	seg := func(v interface{}) string {
+		buf, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return base64.RawURLEncoding.EncodeToString(buf)
+	}
+	signed := seg(header) + "." + seg(claims)
+
+	hash, ok := jwtHashes[header["alg"].(string)]
+	if !ok {
+		// an unsecured token, such as one with the alg none, is unsigned
+		return signed + "."
+	}
+	var sig []byte
+	switch tk := key.(type) {
+	case []byte:
+		mac := hmac.New(hash.New, tk)
+		mac.Write([]byte(signed))
+		sig = mac.Sum(nil)
+	case *rsa.PrivateKey:
+		h := hash.New()
+		h.Write([]byte(signed))
+		var err error
+		if sig, err = rsa.SignPKCS1v15(
+			rand.Reader, tk, hash, h.Sum(nil)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func jwtClaimsFor(sub string, exp, nbf time.Duration) map[string]interface{} {
+	claims := map[string]interface{}{"sub": sub}
+	if exp != 0 {
+		claims["exp"] = time.Now().Add(exp).Unix()
+	}
+	if nbf != 0 {
+		claims["nbf"] = time.Now().Add(nbf).Unix()
+	}
+	return claims
+}
+
+func TestJWTVerifierSecret(t *testing.T) {
+	ctx := context.Background()
+	secret := []byte("s3cr3t")
+	v, err := newJWTVerifier(ctx, string(secret), "", "", "")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	hs256 := map[string]interface{}{"alg": "HS256", "typ": "JWT"}
+	tests := []struct {
+		token string
+		err   string
+	}{
+		{newTestJWT(t, hs256, jwtClaimsFor("alice", time.Hour, 0), secret),
+			""},
+		{newTestJWT(t, map[string]interface{}{"alg": "HS512"},
+			jwtClaimsFor("alice", 0, 0), secret), ""},
+		{newTestJWT(t, hs256, jwtClaimsFor("alice", time.Hour, 0),
+			[]byte("wrong")), "invalid signature"},
+		{newTestJWT(t, hs256, jwtClaimsFor("alice", -time.Minute, 0),
+			secret), "token expired"},
+		{newTestJWT(t, hs256, jwtClaimsFor("alice", time.Hour, time.Hour),
+			secret), "token not yet valid"},
+		{newTestJWT(t, hs256, jwtClaimsFor("", time.Hour, 0), secret),
+			"missing subject"},
+		{newTestJWT(t, map[string]interface{}{"alg": "none"},
+			jwtClaimsFor("alice", time.Hour, 0), secret),
+			"unsupported algorithm"},
+		{"a.b", "malformed token"},
+	}
+	for i, tt := range tests {
+		tok, err := v.verify(ctx, tt.token)
+		if tt.err != "" {
+			assert.EqualError(t, err, tt.err, "%d", i)
+			continue
+		}
+		if assert.NoError(t, err, "%d", i) {
+			assert.Equal(t, "alice", tok.Subject)
+		}
+	}
+
+	// a verifier without a public key rejects tokens signed with rsa
+	rs256 := newTestJWT(t, map[string]interface{}{"alg": "RS256"},
+		jwtClaimsFor("alice", time.Hour, 0), newTestRSAKey(t))
+	_, err = v.verify(ctx, rs256)
+	assert.EqualError(t, err, "no public key configured")
+}
+
+func TestJWTVerifierPublicKey(t *testing.T) {
+	ctx := context.Background()
+	key := newTestRSAKey(t)
+	keyPEM := testRSAKeyPEM(t, key)
+	v, err := newJWTVerifier(ctx, keyPEM, "", "", "")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	claims := jwtClaimsFor("alice", time.Hour, 0)
+	tok, err := v.verify(ctx, newTestJWT(
+		t, map[string]interface{}{"alg": "RS256"}, claims, key))
+	if assert.NoError(t, err) {
+		assert.Equal(t, "alice", tok.Subject)
+		assert.Equal(t, claims["exp"], tok.Expires)
+	}
+
+	_, err = v.verify(ctx, newTestJWT(
+		t, map[string]interface{}{"alg": "RS256"}, claims,
+		newTestRSAKey(t)))
+	assert.EqualError(t, err, "invalid signature")
+
+	// a token signed with the public key as an hmac secret, as an attacker
+	// who knows the public key could sign it, is not accepted
+	_, err = v.verify(ctx, newTestJWT(
+		t, map[string]interface{}{"alg": "HS256"}, claims,
+		[]byte(keyPEM)))
+	assert.EqualError(t, err, "no shared secret configured")
+}
+
+func TestJWTVerifierAudienceIssuer(t *testing.T) {
+	ctx := context.Background()
+	secret := []byte("s3cr3t")
+	v, err := newJWTVerifier(
+		ctx, string(secret), "", "libstorage", "https://auth.example.com")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	claims := func(iss string, aud interface{}) map[string]interface{} {
+		c := jwtClaimsFor("alice", time.Hour, 0)
+		if iss != "" {
+			c["iss"] = iss
+		}
+		if aud != nil {
+			c["aud"] = aud
+		}
+		return c
+	}
+
+	hs256 := map[string]interface{}{"alg": "HS256"}
+	tests := []struct {
+		claims map[string]interface{}
+		err    string
+	}{
+		{claims("https://auth.example.com", "libstorage"), ""},
+		{claims("https://auth.example.com",
+			[]string{"rexray", "libstorage"}), ""},
+		{claims("https://auth.example.com", "rexray"), "invalid audience"},
+		{claims("https://auth.example.com", []string{}), "invalid audience"},
+		{claims("https://auth.example.com", nil), "invalid audience"},
+		{claims("https://evil.example.com", "libstorage"), "invalid issuer"},
+		{claims("", "libstorage"), "invalid issuer"},
+		{claims("https://auth.example.com", 1), "json: cannot unmarshal " +
+			"number into Go value of type []string"},
+	}
+	for i, tt := range tests {
+		_, err := v.verify(ctx, newTestJWT(t, hs256, tt.claims, secret))
+		if tt.err != "" {
+			assert.EqualError(t, err, tt.err, "%d", i)
+			continue
+		}
+		assert.NoError(t, err, "%d", i)
+	}
+}
+
+// testJWKSServer serves a JSON web key set and counts the requests for it.
+// The key set is not served while the server fails.
+type testJWKSServer struct {
+	sync.Mutex
+	keys     map[string]*rsa.PrivateKey
+	fail     bool
+	requests int
+}
+
+func (s *testJWKSServer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	s.Lock()
+	defer s.Unlock()
+	s.requests++
+
+	if s.fail {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	b64 := func(buf []byte) string {
+		return base64.RawURLEncoding.EncodeToString(buf)
+	}
+	var doc jwks
+	for kid, key := range s.keys {
+		doc.Keys = append(doc.Keys, struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		}{
+			"RSA", kid,
+			b64(key.PublicKey.N.Bytes()),
+			b64(big.NewInt(int64(key.PublicKey.E)).Bytes()),
+		})
+	}
+	json.NewEncoder(w).Encode(&doc)
+}
+
+func (s *testJWKSServer) requestCount() int {
+	s.Lock()
+	defer s.Unlock()
+	return s.requests
+}
+
+func TestJWTVerifierJWKS(t *testing.T) {
+	ctx := context.Background()
+	k1, k2 := newTestRSAKey(t), newTestRSAKey(t)
+
+	js := &testJWKSServer{keys: map[string]*rsa.PrivateKey{"k1": k1}}
+	hs := httptest.NewServer(js)
+	defer hs.Close()
+
+	v, err := newJWTVerifier(ctx, "", hs.URL, "", "")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, 1, js.requestCount())
+
+	claims := jwtClaimsFor("alice", time.Hour, 0)
+	header := func(kid string) map[string]interface{} {
+		return map[string]interface{}{"alg": "RS256", "kid": kid}
+	}
+
+	tok, err := v.verify(ctx, newTestJWT(t, header("k1"), claims, k1))
+	if assert.NoError(t, err) {
+		assert.Equal(t, "alice", tok.Subject)
+	}
+
+	// a token signed by another key under a known kid is rejected
+	_, err = v.verify(ctx, newTestJWT(t, header("k1"), claims, k2))
+	assert.EqualError(t, err, "invalid signature")
+
+	// an unknown kid does not reload the key set more than once per
+	// refresh interval
+	js.Lock()
+	js.keys["k2"] = k2
+	js.Unlock()
+	_, err = v.verify(ctx, newTestJWT(t, header("k2"), claims, k2))
+	assert.EqualError(t, err, "unknown key")
+	assert.Equal(t, 1, js.requestCount())
+
+	v.Lock()
+	v.jwksFetch = time.Now().Add(-jwksRefreshInterval - time.Second)
+	v.Unlock()
+	tok, err = v.verify(ctx, newTestJWT(t, header("k2"), claims, k2))
+	if assert.NoError(t, err) {
+		assert.Equal(t, "alice", tok.Subject)
+	}
+	assert.Equal(t, 2, js.requestCount())
+
+	_, err = v.verify(ctx, newTestJWT(t, map[string]interface{}{
+		"alg": "RS512", "kid": "k1"}, claims, k1))
+	assert.NoError(t, err)
+}
+
+func TestJWTVerifierJWKSRefreshError(t *testing.T) {
+	ctx := context.Background()
+	k1 := newTestRSAKey(t)
+
+	js := &testJWKSServer{keys: map[string]*rsa.PrivateKey{"k1": k1}}
+	hs := httptest.NewServer(js)
+	defer hs.Close()
+
+	v, err := newJWTVerifier(ctx, "", hs.URL, "", "")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	claims := jwtClaimsFor("alice", time.Hour, 0)
+	header := func(kid string) map[string]interface{} {
+		return map[string]interface{}{"alg": "RS256", "kid": kid}
+	}
+
+	js.Lock()
+	js.fail = true
+	js.Unlock()
+	v.Lock()
+	v.jwksFetch = time.Now().Add(-jwksRefreshInterval - time.Second)
+	v.Unlock()
+
+	// a failed reload keeps the keys already loaded
+	_, err = v.verify(ctx, newTestJWT(t, header("k2"), claims, k1))
+	assert.EqualError(t, err, "unknown key")
+	assert.Equal(t, 2, js.requestCount())
+	_, err = v.verify(ctx, newTestJWT(t, header("k1"), claims, k1))
+	assert.NoError(t, err)
+
+	// and is not retried until the refresh interval passes
+	_, err = v.verify(ctx, newTestJWT(t, header("k2"), claims, k1))
+	assert.EqualError(t, err, "unknown key")
+	assert.Equal(t, 2, js.requestCount())
+
+	// the initial load must succeed
+	_, err = newJWTVerifier(ctx, "", hs.URL, "", "")
+	assert.Error(t, err)
+}
@@ -9,6 +9,10 @@ import (
 	"github.com/codedellemc/libstorage/api/types"
 )
 
+// statusUnprocessableEntity is the HTTP status 422, which the net/http
+// package of Go 1.6 does not define.
+const statusUnprocessableEntity = 422
+
 // errorHandler is a global HTTP filter for handlling errors
 type errorHandler struct {
 	handler types.APIFunc
@@ -41,6 +45,13 @@ func (h *errorHandler) Handle(
 
 	ctx.Error(err)
 
+	// only a request rejected because the original request with the same
+	// idempotency key is executing is resent by the client, not every
+	// conflict
+	if types.IsErrorCode(err, types.ErrorCodeInProgress) {
+		w.Header().Set(types.RetryAfterHeader, "1")
+	}
+
 	httpErr := goof.NewHTTPError(err, getStatus(err))
 	httputils.WriteJSON(w, httpErr.Status(), httpErr)
 	return nil
@@ -50,8 +61,50 @@ func getStatus(err error) int {
 	switch err.(type) {
 	case *types.ErrBadAdminToken:
 		return http.StatusUnauthorized
+	case *types.ErrUnauthorized:
+		return http.StatusUnauthorized
+	case *types.ErrForbidden:
+		return http.StatusForbidden
+	case *types.ErrPolicyViolation:
+		return http.StatusForbidden
+	case *types.ErrBadRequest:
+		return http.StatusBadRequest
+	case *types.ErrTooManyRequests:
+		return http.StatusTooManyRequests
+	case *types.ErrConflict:
+		return http.StatusConflict
 	case *types.ErrNotFound:
 		return http.StatusNotFound
+	default:
+		return getErrorCodeStatus(types.ErrorCodeOf(err))
+	}
+}
+
+// getErrorCodeStatus returns the status of an error with a code. Errors
+// with a code include the ErrAlreadyExists, ErrBusy, ErrQuotaExceeded,
+// ErrAuthFailed, ErrPermissionDenied, ErrTimeout, ErrInProgress, and
+// ErrIdempotencyKeyReused errors as well as the errors drivers return when
+// the commands with which they manage storage fail.
+func getErrorCodeStatus(code types.ErrorCode) int {
+	switch code {
+	case types.ErrorCodeNotFound:
+		return http.StatusNotFound
+	case types.ErrorCodeAlreadyExists,
+		types.ErrorCodeBusy,
+		types.ErrorCodeInProgress:
+		return http.StatusConflict
+	case types.ErrorCodeIdempotencyKeyReused:
+		return statusUnprocessableEntity
+	case types.ErrorCodePermissionDenied:
+		return http.StatusForbidden
+	case types.ErrorCodeQuotaExceeded:
+		return http.StatusInsufficientStorage
+	case types.ErrorCodeAuthFailed:
+		// the storage platform rejected the server's credentials, not the
+		// client's, so the client is not asked to authenticate
+		return http.StatusBadGateway
+	case types.ErrorCodeTimeout:
+		return http.StatusGatewayTimeout
 	default:
 		return http.StatusInternalServerError
 	}
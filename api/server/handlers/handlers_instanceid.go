@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/codedellemc/libstorage/api/context"
+	"github.com/codedellemc/libstorage/api/server/services"
 	"github.com/codedellemc/libstorage/api/types"
 )
 
@@ -45,6 +46,7 @@ func (h *instanceIDHandler) Handle(
 			return err
 		}
 		valMap[strings.ToLower(val.Driver)] = val
+		services.Heartbeat(ctx, val.Driver, val)
 	}
 
 	ctx = ctx.WithValue(context.AllInstanceIDsKey, valMap)
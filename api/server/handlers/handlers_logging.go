@@ -67,23 +67,31 @@ func (h *loggingHandler) Handle(
 	var err error
 	var reqDump []byte
 	if h.logRequests {
-		if reqDump, err = httputil.DumpRequest(req, true); err != nil {
+		// never log the bearer token
+		if v := req.Header.Get(types.AuthorizationHeader); v != "" {
+			req.Header.Set(types.AuthorizationHeader, "Bearer ******")
+			reqDump, err = httputil.DumpRequest(req, true)
+			req.Header.Set(types.AuthorizationHeader, v)
+		} else {
+			reqDump, err = httputil.DumpRequest(req, true)
+		}
+		if err != nil {
 			return err
 		}
 	}
 
-	rec := httptest.NewRecorder()
+	rec := &streamRecorder{ResponseRecorder: httptest.NewRecorder(), w: w}
 	reqErr := h.handler(ctx, rec, req, store)
 
-	logRequest(h.logRequests, bw, rec, req, reqDump)
+	logRequest(h.logRequests, bw, rec.ResponseRecorder, req, reqDump)
 
-	if reqErr != nil {
+	if reqErr != nil || rec.streaming {
 		return reqErr
 	}
 
 	if h.logResponses {
 		fmt.Fprintln(bw, "")
-		logResponse(bw, rec, req)
+		logResponse(bw, rec.ResponseRecorder, req)
 		fmt.Fprintln(bw, "")
 	}
 
@@ -98,6 +106,50 @@ func (h *loggingHandler) Handle(
 	return nil
 }
 
+// streamRecorder records a response so that it can be logged, except that
+// streamed responses, such as events, are written to the client directly
+// since they are not complete until the client closes them.
+type streamRecorder struct {
+	*httptest.ResponseRecorder
+	w         http.ResponseWriter
+	streaming bool
+}
+
+func (r *streamRecorder) WriteHeader(code int) {
+	switch r.Header().Get("Content-Type") {
+	case "text/event-stream", "application/x-ndjson":
+		r.streaming = true
+		for k, v := range r.Header() {
+			r.w.Header()[k] = v
+		}
+		r.w.WriteHeader(code)
+	}
+	r.ResponseRecorder.WriteHeader(code)
+}
+
+func (r *streamRecorder) Write(buf []byte) (int, error) {
+	if r.streaming {
+		return r.w.Write(buf)
+	}
+	return r.ResponseRecorder.Write(buf)
+}
+
+func (r *streamRecorder) Flush() {
+	if !r.streaming {
+		return
+	}
+	if f, ok := r.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (r *streamRecorder) CloseNotify() <-chan bool {
+	if cn, ok := r.w.(http.CloseNotifier); ok {
+		return cn.CloseNotify()
+	}
+	return nil
+}
+
 func logRequest(
 	l bool,
 	w io.Writer,
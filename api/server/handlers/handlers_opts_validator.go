@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/codedellemc/libstorage/api/context"
+	"github.com/codedellemc/libstorage/api/types"
+	"github.com/codedellemc/libstorage/api/utils"
+	"github.com/codedellemc/libstorage/api/utils/schema"
+)
+
+// optsValidator is an HTTP filter for validating the driver-specific options
+// of a request against the schema the service's driver declares for them.
+type optsValidator struct {
+	handler types.APIFunc
+}
+
+// NewOptsValidator returns a new filter for validating the driver-specific
+// options of a request. The filter must follow the service validator and the
+// POST args handler.
+func NewOptsValidator() types.Middleware {
+	return &optsValidator{}
+}
+
+func (h *optsValidator) Name() string {
+	return "opts-validator"
+}
+
+func (h *optsValidator) Handler(m types.APIFunc) types.APIFunc {
+	return (&optsValidator{m}).Handle
+}
+
+// Handle is the type's Handler function.
+func (h *optsValidator) Handle(
+	ctx types.Context,
+	w http.ResponseWriter,
+	req *http.Request,
+	store types.Store) error {
+
+	svc, ok := context.Service(ctx)
+	if !ok {
+		return h.handler(ctx, w, req, store)
+	}
+	route, ok := context.Route(ctx)
+	if !ok {
+		return h.handler(ctx, w, req, store)
+	}
+	sd, ok := svc.Driver().(types.StorageDriverWithOptsSchema)
+	if !ok {
+		return h.handler(ctx, w, req, store)
+	}
+
+	s := sd.OptsSchema(ctx, route.GetName())
+	if s == nil {
+		return h.handler(ctx, w, req, store)
+	}
+
+	opts := map[string]interface{}{}
+	if reqOpts := store.GetStore("opts"); reqOpts != nil {
+		opts = reqOpts.Map()
+	}
+
+	if err := schema.ValidateObj(ctx, s, opts); err != nil {
+		return utils.NewBadRequestError("invalid opts", err)
+	}
+
+	return h.handler(ctx, w, req, store)
+}
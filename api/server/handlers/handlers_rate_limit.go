@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	gofig "github.com/akutz/gofig/types"
+
+	"github.com/codedellemc/libstorage/api/context"
+	"github.com/codedellemc/libstorage/api/types"
+	"github.com/codedellemc/libstorage/api/utils"
+)
+
+const (
+	// rateLimitPruneInterval is the minimum amount of time between removing
+	// the buckets of clients that are no longer limited.
+	rateLimitPruneInterval = time.Minute
+)
+
+// rateLimitHandler is a global HTTP filter for limiting the rate at which
+// clients issue requests.
+type rateLimitHandler struct {
+	handler types.APIFunc
+	read    *rateLimiter
+	mutate  *rateLimiter
+}
+
+// NewRateLimitHandler returns a new global HTTP filter for limiting the rate
+// at which clients issue requests. Reads, the GET and HEAD requests, are
+// limited by libstorage.server.rateLimit.read and all other requests are
+// limited by libstorage.server.rateLimit.mutate. Clients are identified by
+// their authenticated subject if authentication is enabled; otherwise by
+// their remote IP address.
+//
+// A nil value is returned if neither class of requests is limited.
+func NewRateLimitHandler(
+	ctx types.Context, config gofig.Config) types.Middleware {
+
+	read := newRateLimiter(
+		config.GetInt(types.ConfigServerRateLimitReadRate),
+		config.GetInt(types.ConfigServerRateLimitReadBurst))
+	mutate := newRateLimiter(
+		config.GetInt(types.ConfigServerRateLimitMutateRate),
+		config.GetInt(types.ConfigServerRateLimitMutateBurst))
+
+	if read == nil && mutate == nil {
+		return nil
+	}
+
+	fields := log.Fields{}
+	if read != nil {
+		fields["readRate"] = read.rate
+		fields["readBurst"] = read.burst
+	}
+	if mutate != nil {
+		fields["mutateRate"] = mutate.rate
+		fields["mutateBurst"] = mutate.burst
+	}
+	ctx.WithFields(fields).Info("configured rate limiting")
+
+	return &rateLimitHandler{read: read, mutate: mutate}
+}
+
+func (h *rateLimitHandler) Name() string {
+	return "rate-limit-handler"
+}
+
+func (h *rateLimitHandler) Handler(m types.APIFunc) types.APIFunc {
+	return (&rateLimitHandler{m, h.read, h.mutate}).Handle
+}
+
+// Handle is the type's Handler function.
+func (h *rateLimitHandler) Handle(
+	ctx types.Context,
+	w http.ResponseWriter,
+	req *http.Request,
+	store types.Store) error {
+
+	limiter := h.mutate
+	if req.Method == http.MethodGet || req.Method == http.MethodHead {
+		limiter = h.read
+	}
+	if limiter == nil {
+		return h.handler(ctx, w, req, store)
+	}
+
+	client := rateLimitClient(ctx, req)
+	if wait := limiter.take(client); wait > 0 {
+		w.Header().Set("Retry-After", fmt.Sprintf(
+			"%d", int64(math.Ceil(wait.Seconds()))))
+		return utils.NewTooManyRequestsError(client)
+	}
+
+	return h.handler(ctx, w, req, store)
+}
+
+// rateLimitClient returns the identity by which a request is limited.
+func rateLimitClient(ctx types.Context, req *http.Request) string {
+	if tok, ok := context.AuthToken(ctx); ok {
+		return "subject:" + tok.Subject
+	}
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		host = req.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// rateLimiter is a collection of token buckets, one per client. A bucket
+// holds at most burst tokens and is refilled at rate tokens per second. Each
+// request removes one token from its client's bucket.
+type rateLimiter struct {
+	sync.Mutex
+	rate    int
+	burst   int
+	buckets map[string]*tokenBucket
+	pruned  time.Time
+}
+
+type tokenBucket struct {
+	tokens float64
+	filled time.Time
+}
+
+// newRateLimiter returns a new rate limiter. If the burst is less than the
+// rate then the burst is the rate. A nil value is returned if the rate is
+// not positive.
+func newRateLimiter(rate, burst int) *rateLimiter {
+	if rate <= 0 {
+		return nil
+	}
+	if burst < rate {
+		burst = rate
+	}
+	return &rateLimiter{
+		rate:    rate,
+		burst:   burst,
+		buckets: map[string]*tokenBucket{},
+		pruned:  time.Now(),
+	}
+}
+
+// take removes a token from the client's bucket. If the bucket is empty then
+// no token is removed and the amount of time until a token is available is
+// returned.
+func (l *rateLimiter) take(client string) time.Duration {
+	l.Lock()
+	defer l.Unlock()
+
+	now := time.Now()
+	l.prune(now)
+
+	b, ok := l.buckets[client]
+	if !ok {
+		b = &tokenBucket{tokens: float64(l.burst), filled: now}
+		l.buckets[client] = b
+	} else {
+		l.fill(b, now)
+	}
+
+	if b.tokens < 1 {
+		return time.Duration((1 - b.tokens) / float64(l.rate) *
+			float64(time.Second))
+	}
+	b.tokens--
+	return 0
+}
+
+func (l *rateLimiter) fill(b *tokenBucket, now time.Time) {
+	b.tokens += now.Sub(b.filled).Seconds() * float64(l.rate)
+	if b.tokens > float64(l.burst) {
+		b.tokens = float64(l.burst)
+	}
+	b.filled = now
+}
+
+// prune removes the buckets that are full, since a full bucket is no
+// different than a bucket that does not exist.
+func (l *rateLimiter) prune(now time.Time) {
+	if now.Sub(l.pruned) < rateLimitPruneInterval {
+		return
+	}
+	for client, b := range l.buckets {
+		l.fill(b, now)
+		if b.tokens >= float64(l.burst) {
+			delete(l.buckets, client)
+		}
+	}
+	l.pruned = now
+}
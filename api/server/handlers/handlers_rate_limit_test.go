@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	gofigCore "github.com/akutz/gofig"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codedellemc/libstorage/api/context"
+	"github.com/codedellemc/libstorage/api/types"
+	"github.com/codedellemc/libstorage/api/utils"
+)
+
+func TestNewRateLimiter(t *testing.T) {
+	assert.Nil(t, newRateLimiter(0, 10))
+	assert.Nil(t, newRateLimiter(-1, 0))
+
+	l := newRateLimiter(5, 2)
+	assert.Equal(t, 5, l.rate)
+	assert.Equal(t, 5, l.burst)
+
+	l = newRateLimiter(5, 20)
+	assert.Equal(t, 20, l.burst)
+}
+
+func TestRateLimiterTake(t *testing.T) {
+	l := newRateLimiter(10, 10)
+
+	for i := 0; i < 10; i++ {
+		assert.Zero(t, l.take("a"), "%d", i)
+	}
+	wait := l.take("a")
+	assert.True(t, wait > 0 && wait <= 100*time.Millisecond, "%v", wait)
+
+	// clients have their own buckets
+	assert.Zero(t, l.take("b"))
+
+	// a bucket is refilled at the rate
+	l.Lock()
+	l.buckets["a"].filled = l.buckets["a"].filled.Add(-200 * time.Millisecond)
+	l.Unlock()
+	assert.Zero(t, l.take("a"))
+	assert.Zero(t, l.take("a"))
+	assert.NotZero(t, l.take("a"))
+
+	// full buckets are pruned
+	l.Lock()
+	l.buckets["b"].filled = l.buckets["b"].filled.Add(-time.Hour)
+	l.pruned = l.pruned.Add(-2 * rateLimitPruneInterval)
+	l.Unlock()
+	l.take("c")
+	l.Lock()
+	_, ok := l.buckets["b"]
+	_, okA := l.buckets["a"]
+	l.Unlock()
+	assert.False(t, ok)
+	assert.True(t, okA)
+}
+
+func TestRateLimitClient(t *testing.T) {
+	req := &http.Request{RemoteAddr: "10.0.0.1:5000"}
+	ctx := context.Background()
+	assert.Equal(t, "ip:10.0.0.1", rateLimitClient(ctx, req))
+
+	req.RemoteAddr = "@"
+	assert.Equal(t, "ip:@", rateLimitClient(ctx, req))
+
+	ctx = ctx.WithValue(
+		context.AuthTokenKey, &types.AuthToken{Subject: "alice"})
+	assert.Equal(t, "subject:alice", rateLimitClient(ctx, req))
+}
+
+func TestRateLimitHandler(t *testing.T) {
+	config := gofigCore.New()
+	assert.Nil(t, NewRateLimitHandler(context.Background(), config))
+
+	config.Set(types.ConfigServerRateLimitMutateRate, 1)
+	config.Set(types.ConfigServerRateLimitMutateBurst, 1)
+	m := NewRateLimitHandler(context.Background(), config)
+	if !assert.NotNil(t, m) {
+		t.FailNow()
+	}
+
+	handled := 0
+	h := m.Handler(func(
+		ctx types.Context,
+		w http.ResponseWriter,
+		req *http.Request,
+		store types.Store) error {
+		handled++
+		return nil
+	})
+
+	do := func(method string) (*httptest.ResponseRecorder, error) {
+		req, err := http.NewRequest(method, "/volumes", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.RemoteAddr = "10.0.0.1:5000"
+		w := httptest.NewRecorder()
+		return w, h(context.Background(), w, req, utils.NewStore())
+	}
+
+	_, err := do("POST")
+	assert.NoError(t, err)
+	w, err := do("POST")
+	assert.IsType(t, &types.ErrTooManyRequests{}, err)
+	assert.Equal(t, http.StatusTooManyRequests, getStatus(err))
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+
+	// reads are not limited unless a read rate is configured
+	for i := 0; i < 5; i++ {
+		_, err = do("GET")
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 6, handled)
+}
@@ -10,6 +10,7 @@ import (
 	//log "github.com/Sirupsen/logrus"
 
 	"github.com/codedellemc/libstorage/api/types"
+	"github.com/codedellemc/libstorage/api/utils"
 	"github.com/codedellemc/libstorage/api/utils/schema"
 )
 
@@ -44,6 +45,17 @@ func (h *schemaValidator) Name() string {
 	return "schema-validator"
 }
 
+// RequestSchema returns the JSON schema against which request payloads are
+// validated.
+func (h *schemaValidator) RequestSchema() []byte {
+	return h.reqSchema
+}
+
+// ResponseSchema returns the JSON schema that describes response payloads.
+func (h *schemaValidator) ResponseSchema() []byte {
+	return h.resSchema
+}
+
 func (h *schemaValidator) Handler(m types.APIFunc) types.APIFunc {
 	return (&schemaValidator{
 		m, h.reqSchema, h.resSchema, h.newReqObjFunc}).Handle
@@ -65,7 +77,7 @@ func (h *schemaValidator) Handle(
 	if h.reqSchema != nil {
 		err = schema.Validate(ctx, h.reqSchema, reqBody)
 		if err != nil {
-			return fmt.Errorf("validate req schema: validation error: %v", err)
+			return utils.NewBadRequestError("schema validation failed", err)
 		}
 	}
 
@@ -74,8 +86,7 @@ func (h *schemaValidator) Handle(
 		reqObj := h.newReqObjFunc()
 		if len(reqBody) > 0 {
 			if err = json.Unmarshal(reqBody, reqObj); err != nil {
-				return fmt.Errorf(
-					"validate req schema: unmarshal error: %v", err)
+				return utils.NewBadRequestError("invalid json", err)
 			}
 		}
 		ctx = ctx.WithValue("reqObj", reqObj)
@@ -46,6 +46,10 @@ func (h *serviceValidator) Handle(
 		return utils.NewNotFoundError(serviceName)
 	}
 
+	if err := services.Authorize(ctx, service); err != nil {
+		return err
+	}
+
 	ctx = context.WithStorageService(ctx, service)
 	return h.handler(ctx, w, req, store)
 }
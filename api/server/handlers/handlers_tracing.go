@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"net/http"
+
+	gofig "github.com/akutz/gofig/types"
+
+	"github.com/codedellemc/libstorage/api/types"
+	"github.com/codedellemc/libstorage/api/utils/tracing"
+)
+
+// tracingHandler is a global HTTP filter for tracing requests.
+type tracingHandler struct {
+	handler types.APIFunc
+	tracer  *tracing.Tracer
+}
+
+// NewTracingHandler returns a new global HTTP filter for tracing requests.
+// Each request is timed by a span that continues the trace identified by the
+// request's B3 headers or, if the request is not part of a trace, starts a
+// new trace. The spans are emitted to the collector specified by
+// libstorage.tracing.collector.
+//
+// A nil value is returned if no collector is configured.
+func NewTracingHandler(
+	ctx types.Context, config gofig.Config) types.Middleware {
+
+	tracer := tracing.New(config, "libstorage-server")
+	if tracer == nil {
+		return nil
+	}
+
+	ctx.WithField(
+		"collector", config.GetString(types.ConfigTracingCollector)).Info(
+		"configured tracing")
+
+	return &tracingHandler{tracer: tracer}
+}
+
+func (h *tracingHandler) Name() string {
+	return "tracing-handler"
+}
+
+func (h *tracingHandler) Handler(m types.APIFunc) types.APIFunc {
+	return (&tracingHandler{m, h.tracer}).Handle
+}
+
+// Handle is the type's Handler function.
+func (h *tracingHandler) Handle(
+	ctx types.Context,
+	w http.ResponseWriter,
+	req *http.Request,
+	store types.Store) error {
+
+	ctx, span := h.tracer.StartServerSpan(ctx, req)
+	span.SetTag("http.method", req.Method)
+	span.SetTag("http.path", req.URL.Path)
+
+	// the trace ID allows a client to find the trace of a slow request
+	w.Header().Set(types.TraceIDHeader, span.TraceID)
+
+	err := h.handler(ctx, w, req, store)
+	span.FinishWithError(err)
+	return err
+}
@@ -2,6 +2,7 @@ package httputils
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"time"
@@ -57,7 +58,13 @@ func WriteTask(
 	okStatus int) error {
 
 	if store.GetBool("async") {
-		WriteJSON(w, http.StatusAccepted, task)
+		// the task is retained after it completes so the client can retrieve
+		// its result, and waiting on the task ensures it is removed from the
+		// task service once the retention period elapses
+		services.TaskAsync(ctx, task.ID)
+		services.TaskWaitC(ctx, task.ID)
+		w.Header().Set("Location", fmt.Sprintf("/tasks/%d", task.ID))
+		WriteJSON(w, http.StatusAccepted, taskSnapshot(ctx, task))
 		return nil
 	}
 
@@ -75,8 +82,18 @@ func WriteTask(
 		}
 		WriteJSON(w, okStatus, task.Result)
 	case <-exeTimeout.C:
-		WriteJSON(w, http.StatusRequestTimeout, task)
+		WriteJSON(w, http.StatusRequestTimeout, taskSnapshot(ctx, task))
 	}
 
 	return nil
 }
+
+// taskSnapshot returns a copy of a task that may still be executing so that
+// the task can be written while it is updated. The task's fields may only be
+// read directly once it is completed.
+func taskSnapshot(ctx types.Context, task *types.Task) *types.Task {
+	if t := services.TaskInspect(ctx, task.ID); t != nil {
+		return t
+	}
+	return &types.Task{ID: task.ID, State: types.TaskStateRunning}
+}
@@ -0,0 +1,56 @@
+package admin
+
+import (
+	gofig "github.com/akutz/gofig/types"
+
+	"github.com/codedellemc/libstorage/api/registry"
+	"github.com/codedellemc/libstorage/api/server/httputils"
+	"github.com/codedellemc/libstorage/api/types"
+)
+
+func init() {
+	registry.RegisterRouter(&router{})
+}
+
+type router struct {
+	config gofig.Config
+	routes []types.Route
+}
+
+func (r *router) Name() string {
+	return "admin-router"
+}
+
+func (r *router) Init(config gofig.Config) {
+	r.config = config
+	r.initRoutes()
+}
+
+// Routes returns the available routes.
+func (r *router) Routes() []types.Route {
+	return r.routes
+}
+
+func (r *router) initRoutes() {
+
+	r.routes = []types.Route{
+
+		// GET
+		httputils.NewGetRoute(
+			"adminTasks",
+			"/admin/tasks",
+			r.tasks),
+
+		// POST
+		httputils.NewPostRoute(
+			"servicesReload",
+			"/admin/services",
+			r.servicesReload),
+
+		// DELETE
+		httputils.NewDeleteRoute(
+			"adminTaskCancel",
+			"/admin/tasks/{taskID}",
+			r.taskCancel),
+	}
+}
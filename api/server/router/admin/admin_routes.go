@@ -0,0 +1,120 @@
+package admin
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+
+	gofig "github.com/akutz/gofig/types"
+
+	"github.com/codedellemc/libstorage/api/context"
+	"github.com/codedellemc/libstorage/api/registry"
+	"github.com/codedellemc/libstorage/api/server/httputils"
+	"github.com/codedellemc/libstorage/api/server/services"
+	"github.com/codedellemc/libstorage/api/types"
+	"github.com/codedellemc/libstorage/api/utils"
+	apicnfg "github.com/codedellemc/libstorage/api/utils/config"
+)
+
+// servicesReload creates, recreates, and removes storage services so that
+// they match the services in a new configuration. The new configuration is
+// the YAML or JSON document in the request's body or, if the body is empty,
+// the configuration files read at startup.
+func (r *router) servicesReload(
+	ctx types.Context,
+	w http.ResponseWriter,
+	req *http.Request,
+	store types.Store) error {
+
+	if err := checkAdminToken(ctx, store); err != nil {
+		return err
+	}
+
+	config, err := newConfig(req)
+	if err != nil {
+		return err
+	}
+
+	reply, err := services.Reload(ctx, config)
+	if err != nil {
+		return err
+	}
+
+	httputils.WriteJSON(w, http.StatusOK, reply)
+	return nil
+}
+
+// tasks lists the queued and running tasks of the storage services so that
+// operators can see what is blocking a service's queue.
+func (r *router) tasks(
+	ctx types.Context,
+	w http.ResponseWriter,
+	req *http.Request,
+	store types.Store) error {
+
+	if err := checkAdminToken(ctx, store); err != nil {
+		return err
+	}
+
+	httputils.WriteJSON(w, http.StatusOK, services.TaskQueue(ctx))
+	return nil
+}
+
+// taskCancel cancels a queued task. The request that created the task
+// receives the cancelled task's error.
+func (r *router) taskCancel(
+	ctx types.Context,
+	w http.ResponseWriter,
+	req *http.Request,
+	store types.Store) error {
+
+	if err := checkAdminToken(ctx, store); err != nil {
+		return err
+	}
+
+	task, err := services.TaskCancel(ctx, store.GetInt("taskID"))
+	if err != nil {
+		return err
+	}
+
+	ctx.WithField("taskID", task.ID).Warn("cancelled task")
+	httputils.WriteJSON(w, http.StatusOK, task)
+	return nil
+}
+
+func checkAdminToken(ctx types.Context, store types.Store) error {
+	expectedToken, ok := ctx.Value(context.AdminTokenKey).(string)
+	if !ok {
+		return utils.NewBadAdminTokenError("missing")
+	}
+
+	actualToken := store.GetString("admin")
+	if expectedToken != actualToken {
+		return utils.NewBadAdminTokenError(actualToken)
+	}
+	return nil
+}
+
+func newConfig(req *http.Request) (gofig.Config, error) {
+
+	buf, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(bytes.TrimSpace(buf)) == 0 {
+		config, err := apicnfg.NewConfig()
+		if err != nil {
+			return nil, err
+		}
+		return config.Scope(types.ConfigServer), nil
+	}
+
+	config := registry.NewConfig()
+	if err := config.ReadConfig(bytes.NewReader(buf)); err != nil {
+		return nil, utils.NewBadRequestError("invalid config", err)
+	}
+	types.BackCompat(config)
+
+	return config.Scope(types.ConfigServer), nil
+}
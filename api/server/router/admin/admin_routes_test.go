@@ -0,0 +1,53 @@
+package admin
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codedellemc/libstorage/api/context"
+	"github.com/codedellemc/libstorage/api/types"
+	"github.com/codedellemc/libstorage/api/utils"
+)
+
+func TestCheckAdminToken(t *testing.T) {
+	store := utils.NewStore()
+	store.Set("admin", "s3cr3t")
+
+	err := checkAdminToken(context.Background(), store)
+	assert.IsType(t, &types.ErrBadAdminToken{}, err)
+
+	ctx := context.Background().WithValue(context.AdminTokenKey, "s3cr3t")
+	assert.NoError(t, checkAdminToken(ctx, store))
+
+	store.Set("admin", "guess")
+	assert.IsType(t, &types.ErrBadAdminToken{}, checkAdminToken(ctx, store))
+}
+
+func TestNewConfig(t *testing.T) {
+	newReq := func(body string) *http.Request {
+		req, err := http.NewRequest(
+			"POST", "/admin/services", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return req
+	}
+
+	config, err := newConfig(newReq(`
+libstorage:
+  server:
+    services:
+      ebs:
+        driver: ebs
+`))
+	if assert.NoError(t, err) {
+		assert.Equal(t, "ebs",
+			config.GetString(types.ConfigServices+".ebs.driver"))
+	}
+
+	_, err = newConfig(newReq("libstorage: ["))
+	assert.IsType(t, &types.ErrBadRequest{}, err)
+}
@@ -0,0 +1,87 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/akutz/goof"
+
+	"github.com/codedellemc/libstorage/api/server/services"
+	"github.com/codedellemc/libstorage/api/types"
+)
+
+const (
+	// keepAliveInterval is how often a comment is written to an idle event
+	// stream so that intermediaries do not close the connection.
+	keepAliveInterval = time.Second * 30
+)
+
+// events streams volume lifecycle events to the client as server-sent events.
+// Only events for services the client is authorized to read are sent, and
+// the optional service query parameter restricts the stream to a single
+// service. A client that reconnects with the Last-Event-ID header receives the
+// retained events it missed.
+func (r *router) events(
+	ctx types.Context,
+	w http.ResponseWriter,
+	req *http.Request,
+	store types.Store) error {
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return goof.New("streaming unsupported")
+	}
+
+	var closed <-chan bool
+	if cn, ok := w.(http.CloseNotifier); ok {
+		closed = cn.CloseNotify()
+	}
+
+	var lastEventID int64
+	if v := req.Header.Get("Last-Event-ID"); v != "" {
+		lastEventID, _ = strconv.ParseInt(v, 10, 64)
+	}
+
+	serviceName := store.GetString("service")
+
+	c, unsubscribe := services.SubscribeEvents(ctx, lastEventID)
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ctx.WithField("lastEventID", lastEventID).Debug("streaming events")
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-closed:
+			ctx.Debug("event stream closed by client")
+			return nil
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+		case e := <-c:
+			if serviceName != "" && e.Service != serviceName {
+				continue
+			}
+			svc := services.GetStorageService(ctx, e.Service)
+			if svc == nil || services.Authorize(ctx, svc) != nil {
+				continue
+			}
+			buf, err := json.Marshal(e)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, buf)
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package events
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codedellemc/libstorage/api/context"
+	"github.com/codedellemc/libstorage/api/utils"
+)
+
+// testResponseWriter is a response writer that cannot be flushed.
+type testResponseWriter struct {
+	http.ResponseWriter
+}
+
+func TestEventsStreamingUnsupported(t *testing.T) {
+	req, err := http.NewRequest("GET", "/events", nil)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	err = (&router{}).events(
+		context.Background(), &testResponseWriter{}, req, utils.NewStore())
+	assert.EqualError(t, err, "streaming unsupported")
+}
@@ -20,8 +20,17 @@ func (r *router) executors(
 	req *http.Request,
 	store types.Store) error {
 
+	var (
+		goos   = store.GetString("os")
+		goarch = store.GetString("arch")
+		libc   = store.GetString("libc")
+	)
+
 	var reply types.ExecutorsMap = map[string]*types.ExecutorInfo{}
 	for ei := range executors.ExecutorInfos() {
+		if !ei.Matches(goos, goarch, libc) {
+			continue
+		}
 		reply[ei.Name] = &ei.ExecutorInfo
 	}
 
@@ -0,0 +1,201 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/codedellemc/libstorage/api"
+	"github.com/codedellemc/libstorage/api/registry"
+	"github.com/codedellemc/libstorage/api/server/httputils"
+	"github.com/codedellemc/libstorage/api/types"
+	"github.com/codedellemc/libstorage/api/utils/schema"
+)
+
+var (
+	pathParamRx = regexp.MustCompile(`\{([^}:]+)(?::[^}]*)?\}`)
+)
+
+// schemaProvider is implemented by the route middleware that validates
+// request payloads against a JSON schema.
+type schemaProvider interface {
+	RequestSchema() []byte
+	ResponseSchema() []byte
+}
+
+type object map[string]interface{}
+
+func (r *router) openAPIInspect(
+	ctx types.Context,
+	w http.ResponseWriter,
+	req *http.Request,
+	store types.Store) error {
+
+	doc, err := newDocument()
+	if err != nil {
+		return err
+	}
+
+	httputils.WriteJSON(w, http.StatusOK, doc)
+	return nil
+}
+
+// newDocument returns an OpenAPI document that describes the routes of all
+// of the registered routers. The document's schemas are the definitions of
+// the libStorage JSON schema.
+func newDocument() (object, error) {
+
+	schemas, err := newSchemas()
+	if err != nil {
+		return nil, err
+	}
+
+	version := ""
+	if api.Version != nil {
+		version = api.Version.SemVer
+	}
+
+	var (
+		paths  = object{}
+		opIDs  = map[string]int{}
+		errRes = object{
+			"description": "The operation failed.",
+			"content":     jsonContent(schemaRef("error")),
+		}
+	)
+
+	for rtr := range registry.Routers() {
+		tag := strings.TrimSuffix(rtr.Name(), "-router")
+		for _, route := range rtr.Routes() {
+
+			path, params := parsePath(route)
+			pathItem, ok := paths[path].(object)
+			if !ok {
+				pathItem = object{}
+				paths[path] = pathItem
+			}
+
+			opID := route.GetName()
+			if n := opIDs[opID]; n > 0 {
+				opID = fmt.Sprintf("%s%d", opID, n+1)
+			}
+			opIDs[route.GetName()]++
+
+			res := object{"description": "The operation succeeded."}
+			op := object{
+				"operationId": opID,
+				"tags":        []string{tag},
+				"responses":   object{"2XX": res, "default": errRes},
+			}
+			if len(params) > 0 {
+				op["parameters"] = params
+			}
+
+			for _, m := range route.GetMiddlewares() {
+				sp, ok := m.(schemaProvider)
+				if !ok {
+					continue
+				}
+				if s := sp.ResponseSchema(); s != nil {
+					res["content"] = jsonContent(schemaRefFromSchema(s))
+				}
+				if s := sp.RequestSchema(); s != nil {
+					op["requestBody"] = object{
+						"required": true,
+						"content":  jsonContent(schemaRefFromSchema(s)),
+					}
+					op["responses"].(object)["400"] = object{
+						"description": "The request payload is invalid.",
+						"content":     jsonContent(schemaRef("error")),
+					}
+				}
+			}
+
+			pathItem[strings.ToLower(route.GetMethod())] = op
+		}
+	}
+
+	return object{
+		"openapi": "3.0.0",
+		"info": object{
+			"title":   "libStorage",
+			"version": version,
+		},
+		"paths":      paths,
+		"components": object{"schemas": schemas},
+	}, nil
+}
+
+// newSchemas returns the definitions of the libStorage JSON schema with their
+// references rewritten to refer to the OpenAPI document's schemas.
+func newSchemas() (object, error) {
+	buf := strings.Replace(
+		schema.JSONSchema, "#/definitions/", "#/components/schemas/", -1)
+	var doc struct {
+		Definitions object `json:"definitions"`
+	}
+	if err := json.Unmarshal([]byte(buf), &doc); err != nil {
+		return nil, err
+	}
+	return doc.Definitions, nil
+}
+
+// parsePath returns the OpenAPI path for a route as well as the route's path
+// parameters. The route's required query strings are appended to the path
+// since routes that share a path and method are distinguished by them.
+func parsePath(route types.Route) (string, []object) {
+
+	params := []object{}
+	path := pathParamRx.ReplaceAllStringFunc(
+		route.GetPath(), func(v string) string {
+			name := pathParamRx.FindStringSubmatch(v)[1]
+			params = append(params, object{
+				"name":     name,
+				"in":       "path",
+				"required": true,
+				"schema":   object{"type": "string"},
+			})
+			return fmt.Sprintf("{%s}", name)
+		})
+
+	queries := route.GetQueries()
+	qs := []string{}
+	for i := 0; i+1 < len(queries); i += 2 {
+		if queries[i+1] == "" {
+			qs = append(qs, queries[i])
+		} else {
+			qs = append(qs, fmt.Sprintf("%s=%s", queries[i], queries[i+1]))
+		}
+	}
+	if len(qs) > 0 {
+		path = fmt.Sprintf("%s?%s", path, strings.Join(qs, "&"))
+	}
+
+	return path, params
+}
+
+func jsonContent(s object) object {
+	return object{"application/json": object{"schema": s}}
+}
+
+func schemaRef(name string) object {
+	return object{"$ref": "#/components/schemas/" + name}
+}
+
+// schemaRefFromSchema returns a reference to the schema to which one of the
+// schemas in the schema package refers.
+func schemaRefFromSchema(s []byte) object {
+	var v struct {
+		Ref string `json:"$ref"`
+	}
+	if err := json.Unmarshal(s, &v); err != nil {
+		return object{}
+	}
+	i := strings.LastIndex(v.Ref, "/")
+	if i < 0 {
+		return object{}
+	}
+	return schemaRef(v.Ref[i+1:])
+}
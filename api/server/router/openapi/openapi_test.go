@@ -0,0 +1,100 @@
+package openapi
+
+import (
+	"testing"
+
+	gofig "github.com/akutz/gofig/types"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codedellemc/libstorage/api/registry"
+	"github.com/codedellemc/libstorage/api/server/handlers"
+	"github.com/codedellemc/libstorage/api/server/httputils"
+	"github.com/codedellemc/libstorage/api/types"
+	"github.com/codedellemc/libstorage/api/utils/schema"
+)
+
+func TestParsePath(t *testing.T) {
+	route := httputils.NewPostRoute(
+		"volumeAttach",
+		"/volumes/{service}/{volumeID:.+}",
+		nil).Queries("attach")
+
+	path, params := parsePath(route)
+	assert.Equal(t, "/volumes/{service}/{volumeID}?attach", path)
+	assert.Len(t, params, 2)
+	assert.Equal(t, "service", params[0]["name"])
+	assert.Equal(t, "volumeID", params[1]["name"])
+}
+
+func TestSchemaRefFromSchema(t *testing.T) {
+	assert.Equal(t,
+		object{"$ref": "#/components/schemas/volumeCreateRequest"},
+		schemaRefFromSchema(schema.VolumeCreateRequestSchema))
+}
+
+func TestNewSchemas(t *testing.T) {
+	schemas, err := newSchemas()
+	assert.NoError(t, err)
+	assert.Contains(t, schemas, "volume")
+	assert.Contains(t, schemas, "error")
+	assert.Contains(t, schemas["volume"].(map[string]interface{}),
+		"properties")
+}
+
+func TestNewDocument(t *testing.T) {
+	for r := range registry.Routers() {
+		r.Init(nil)
+	}
+
+	doc, err := newDocument()
+	assert.NoError(t, err)
+	assert.Equal(t, "3.0.0", doc["openapi"])
+
+	paths := doc["paths"].(object)
+	pathItem, ok := paths["/openapi.json"].(object)
+	if !assert.True(t, ok) {
+		t.FailNow()
+	}
+	op := pathItem["get"].(object)
+	assert.Equal(t, "openapi", op["operationId"])
+	assert.Equal(t, []string{"openapi"}, op["tags"])
+}
+
+func TestRequestBody(t *testing.T) {
+	registry.RegisterRouter(&testRouter{})
+
+	doc, err := newDocument()
+	assert.NoError(t, err)
+
+	pathItem := doc["paths"].(object)["/volumes/{service}"].(object)
+	op := pathItem["post"].(object)
+	assert.Contains(t, op, "requestBody")
+
+	responses := op["responses"].(object)
+	assert.Contains(t, responses, "400")
+	assert.Equal(t,
+		jsonContent(schemaRef("volume")),
+		responses["2XX"].(object)["content"])
+}
+
+type testRouter struct{}
+
+func (r *testRouter) Name() string {
+	return "test-router"
+}
+
+func (r *testRouter) Init(config gofig.Config) {
+}
+
+func (r *testRouter) Routes() []types.Route {
+	return []types.Route{
+		httputils.NewPostRoute(
+			"volumeCreate",
+			"/volumes/{service}",
+			nil,
+			handlers.NewSchemaValidator(
+				schema.VolumeCreateRequestSchema,
+				schema.VolumeSchema,
+				nil)),
+	}
+}
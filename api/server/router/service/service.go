@@ -48,5 +48,16 @@ func (r *router) initRoutes() {
 			r.serviceInspect,
 			handlers.NewServiceValidator(),
 			handlers.NewSchemaValidator(nil, schema.ServiceInfoSchema, nil)),
+
+		httputils.NewGetRoute(
+			"health",
+			"/health",
+			r.servicesHealth),
+
+		httputils.NewGetRoute(
+			"serviceHealth",
+			"/services/{service}/health",
+			r.serviceHealth,
+			handlers.NewServiceValidator()),
 	}
 }
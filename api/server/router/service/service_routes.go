@@ -45,6 +45,50 @@ func (r *router) serviceInspect(
 	return nil
 }
 
+// servicesHealth responds with the health of all of the services. The
+// response's status is 503 unless all of the services are healthy.
+func (r *router) servicesHealth(
+	ctx types.Context,
+	w http.ResponseWriter,
+	req *http.Request,
+	store types.Store) error {
+
+	reply := &types.ServerHealth{
+		Status:   types.HealthStatusHealthy,
+		Services: map[string]*types.ServiceHealth{},
+	}
+	for service := range services.StorageServices(ctx) {
+		h := services.ServiceHealth(service)
+		if h.Status != types.HealthStatusHealthy {
+			reply.Status = types.HealthStatusDegraded
+		}
+		reply.Services[h.Name] = h
+	}
+
+	httputils.WriteJSON(w, healthStatusCode(reply.Status), reply)
+	return nil
+}
+
+// serviceHealth responds with the health of a service. The response's status
+// is 503 unless the service is healthy.
+func (r *router) serviceHealth(
+	ctx types.Context,
+	w http.ResponseWriter,
+	req *http.Request,
+	store types.Store) error {
+
+	h := services.ServiceHealth(context.MustService(ctx))
+	httputils.WriteJSON(w, healthStatusCode(h.Status), h)
+	return nil
+}
+
+func healthStatusCode(status types.HealthStatus) int {
+	if status == types.HealthStatusHealthy {
+		return http.StatusOK
+	}
+	return http.StatusServiceUnavailable
+}
+
 func toServiceInfo(
 	ctx types.Context,
 	service types.StorageService,
@@ -74,14 +118,44 @@ func toServiceInfo(
 	if err != nil {
 		return nil, err
 	}
+	caps, err := capabilities(ctx, d, st)
+	if err != nil {
+		return nil, err
+	}
 
 	return &types.ServiceInfo{
 		Name:     service.Name(),
 		Instance: instance,
 		Driver: &types.DriverInfo{
-			Name:       d.Name(),
-			Type:       st,
-			NextDevice: nd,
+			Name:         d.Name(),
+			Type:         st,
+			NextDevice:   nd,
+			Capabilities: caps,
 		},
 	}, nil
 }
+
+// capabilities returns the capabilities advertised by a driver, or nil if the
+// driver does not advertise them. The attach semantics default to the type of
+// storage the driver provides.
+func capabilities(
+	ctx types.Context,
+	d types.StorageDriver,
+	st types.StorageType) (*types.StorageCapabilities, error) {
+
+	sd, ok := d.(types.StorageDriverWithCapabilities)
+	if !ok {
+		return nil, nil
+	}
+	caps, err := sd.Capabilities(ctx)
+	if err == types.ErrNotImplemented {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if caps != nil && caps.AttachSemantics == "" {
+		caps.AttachSemantics = st
+	}
+	return caps, nil
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codedellemc/libstorage/api/context"
+	"github.com/codedellemc/libstorage/api/types"
+	"github.com/codedellemc/libstorage/api/utils"
+)
+
+// testStorageService is a storage service without a health monitor.
+type testStorageService struct {
+	types.StorageService
+}
+
+func (s *testStorageService) Name() string {
+	return "ebs"
+}
+
+func TestHealthStatusCode(t *testing.T) {
+	assert.Equal(t, http.StatusOK, healthStatusCode(types.HealthStatusHealthy))
+	assert.Equal(t, http.StatusServiceUnavailable,
+		healthStatusCode(types.HealthStatusDegraded))
+	assert.Equal(t, http.StatusServiceUnavailable,
+		healthStatusCode(types.HealthStatusUnknown))
+}
+
+func TestServiceHealth(t *testing.T) {
+	ctx := context.Background().WithValue(
+		context.ServiceKey, &testStorageService{})
+	req, err := http.NewRequest("GET", "/services/ebs/health", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	r := &router{}
+	if !assert.NoError(t, r.serviceHealth(ctx, rec, req, utils.NewStore())) {
+		t.FailNow()
+	}
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	h := &types.ServiceHealth{}
+	if assert.NoError(t, json.NewDecoder(rec.Body).Decode(h)) {
+		assert.Equal(t, "ebs", h.Name)
+		assert.Equal(t, types.HealthStatusUnknown, h.Status)
+	}
+}
@@ -82,6 +82,7 @@ func (r *router) initRoutes() {
 				schema.VolumeSchema,
 				func() interface{} { return &types.VolumeCreateRequest{} }),
 			handlers.NewPostArgsHandler(),
+			handlers.NewOptsValidator(),
 		).Queries("create"),
 
 		// copy snapshot
@@ -98,6 +99,7 @@ func (r *router) initRoutes() {
 					return &types.SnapshotCopyRequest{}
 				}),
 			handlers.NewPostArgsHandler(),
+			handlers.NewOptsValidator(),
 		).Queries("copy"),
 
 		// DELETE
@@ -183,17 +183,24 @@ func (r *router) volumeCreate(
 		ctx types.Context,
 		svc types.StorageService) (interface{}, error) {
 
+		name := store.GetString("name")
+		opts := &types.VolumeCreateOpts{
+			AvailabilityZone: store.GetStringPtr("availabilityZone"),
+			IOPS:             store.GetInt64Ptr("iops"),
+			Size:             store.GetInt64Ptr("size"),
+			Type:             store.GetStringPtr("type"),
+			Opts:             store,
+		}
+
+		release, err := services.CheckVolumeCreatePolicy(
+			ctx, svc, name, opts)
+		if err != nil {
+			return nil, err
+		}
+
 		v, err := svc.Driver().VolumeCreateFromSnapshot(
-			ctx,
-			store.GetString("snapshotID"),
-			store.GetString("name"),
-			&types.VolumeCreateOpts{
-				AvailabilityZone: store.GetStringPtr("availabilityZone"),
-				IOPS:             store.GetInt64Ptr("iops"),
-				Size:             store.GetInt64Ptr("size"),
-				Type:             store.GetStringPtr("type"),
-				Opts:             store,
-			})
+			ctx, store.GetString("snapshotID"), name, opts)
+		release()
 
 		if err != nil {
 			return nil, err
@@ -3,6 +3,7 @@ package tasks
 import (
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/codedellemc/libstorage/api/server/httputils"
 	"github.com/codedellemc/libstorage/api/server/services"
@@ -35,6 +36,44 @@ func (r *router) taskInspect(
 		return utils.NewNotFoundError(store.GetString("taskID"))
 	}
 
-	httputils.WriteJSON(w, http.StatusOK, task)
+	if !store.IsSet("wait") {
+		httputils.WriteJSON(w, http.StatusOK, task)
+		return nil
+	}
+
+	timeout := r.waitTimeout(store)
+	ctx.WithField("timeout", timeout).Debug("waiting on task")
+
+	select {
+	case <-services.TaskWaitC(ctx, task.ID):
+		// the task is inspected again since it was inspected before it
+		// completed
+		if t := services.TaskInspect(ctx, task.ID); t != nil {
+			task = t
+		}
+		httputils.WriteJSON(w, http.StatusOK, task)
+	case <-time.After(timeout):
+		if t := services.TaskInspect(ctx, task.ID); t != nil {
+			task = t
+		}
+		httputils.WriteJSON(w, http.StatusAccepted, task)
+	}
 	return nil
 }
+
+// waitTimeout returns the amount of time to wait on a task. The wait query
+// parameter may specify a duration, otherwise the configured task execution
+// timeout is used.
+func (r *router) waitTimeout(store types.Store) time.Duration {
+	if v, ok := store.Get("wait").(string); ok {
+		if d, err := time.ParseDuration(v); err == nil {
+			return d
+		}
+	}
+	d, err := time.ParseDuration(
+		r.config.GetString(types.ConfigServerTasksExeTimeout))
+	if err != nil {
+		return time.Duration(time.Second * 60)
+	}
+	return d
+}
@@ -0,0 +1,36 @@
+package tasks
+
+import (
+	"testing"
+	"time"
+
+	gofigCore "github.com/akutz/gofig"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codedellemc/libstorage/api/types"
+	"github.com/codedellemc/libstorage/api/utils"
+)
+
+func TestWaitTimeout(t *testing.T) {
+	config := gofigCore.New()
+	r := &router{config: config}
+
+	tests := []struct {
+		wait       interface{}
+		exeTimeout string
+		timeout    time.Duration
+	}{
+		{"5s", "", 5 * time.Second},
+		{"250ms", "1m", 250 * time.Millisecond},
+		{"", "2m", 2 * time.Minute},
+		{"soon", "2m", 2 * time.Minute},
+		{true, "2m", 2 * time.Minute},
+		{"", "never", time.Minute},
+	}
+	for i, tt := range tests {
+		config.Set(types.ConfigServerTasksExeTimeout, tt.exeTimeout)
+		store := utils.NewStore()
+		store.Set("wait", tt.wait)
+		assert.Equal(t, tt.timeout, r.waitTimeout(store), "%d", i)
+	}
+}
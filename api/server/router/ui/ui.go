@@ -0,0 +1,46 @@
+package ui
+
+import (
+	gofig "github.com/akutz/gofig/types"
+
+	"github.com/codedellemc/libstorage/api/registry"
+	"github.com/codedellemc/libstorage/api/server/httputils"
+	"github.com/codedellemc/libstorage/api/types"
+)
+
+func init() {
+	registry.RegisterRouter(&router{})
+}
+
+type router struct {
+	config gofig.Config
+	routes []types.Route
+}
+
+func (r *router) Name() string {
+	return "ui-router"
+}
+
+func (r *router) Init(config gofig.Config) {
+	r.config = config
+	r.initRoutes()
+}
+
+// Routes returns the available routes.
+func (r *router) Routes() []types.Route {
+	return r.routes
+}
+
+func (r *router) initRoutes() {
+
+	// the dashboard is only served if it is enabled
+	if r.config == nil || !r.config.GetBool(types.ConfigServerUIEnabled) {
+		r.routes = nil
+		return
+	}
+
+	r.routes = []types.Route{
+		// GET
+		httputils.NewGetRoute("ui", "/ui", r.dashboard),
+	}
+}
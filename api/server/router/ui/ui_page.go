@@ -0,0 +1,152 @@
+package ui
+
+// dashboardPage is the read-only dashboard. The data is requested with the
+// bearer token entered on the page, if any, which is kept in the browser's
+// session storage. Values are only ever inserted into the page as text.
+const dashboardPage = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>libStorage</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+h1 { font-size: 1.4em; }
+h2 { font-size: 1.1em; margin-top: 2em; }
+table { border-collapse: collapse; width: 100%; }
+th, td { text-align: left; padding: 4px 8px; border-bottom: 1px solid #ddd; }
+th { background: #f4f4f4; }
+#error { color: #b00; }
+.muted { color: #888; }
+</style>
+</head>
+<body>
+<h1>libStorage</h1>
+<form id="auth">
+<label>Token <input id="token" type="password" size="40"></label>
+<button type="submit">Refresh</button>
+<span id="updated" class="muted"></span>
+</form>
+<p id="error"></p>
+
+<h2>Services</h2>
+<table>
+<thead><tr><th>Name</th><th>Driver</th></tr></thead>
+<tbody id="services"></tbody>
+</table>
+
+<h2>Volumes</h2>
+<table>
+<thead><tr>
+<th>Service</th><th>ID</th><th>Name</th><th>Size (GB)</th><th>Status</th>
+<th>Attachments</th>
+</tr></thead>
+<tbody id="volumes"></tbody>
+</table>
+
+<h2>Tasks</h2>
+<table>
+<thead><tr>
+<th>ID</th><th>State</th><th>User</th><th>Queued</th><th>Completed</th>
+<th>Error</th>
+</tr></thead>
+<tbody id="tasks"></tbody>
+</table>
+
+<script>
+(function() {
+  var tokenInput = document.getElementById("token");
+  tokenInput.value = sessionStorage.getItem("libstorage.token") || "";
+
+  function get(path) {
+    var headers = {};
+    if (tokenInput.value) {
+      headers["Authorization"] = "Bearer " + tokenInput.value;
+    }
+    return fetch(path, {headers: headers}).then(function(res) {
+      if (!res.ok) {
+        throw new Error(path + ": " + res.status + " " + res.statusText);
+      }
+      return res.json();
+    });
+  }
+
+  function fill(id, rows) {
+    var tbody = document.getElementById(id);
+    while (tbody.firstChild) {
+      tbody.removeChild(tbody.firstChild);
+    }
+    rows.forEach(function(row) {
+      var tr = document.createElement("tr");
+      row.forEach(function(v) {
+        var td = document.createElement("td");
+        td.textContent = v === undefined || v === null ? "" : String(v);
+        tr.appendChild(td);
+      });
+      tbody.appendChild(tr);
+    });
+  }
+
+  function time(epoch) {
+    return epoch ? new Date(epoch * 1000).toLocaleString() : "";
+  }
+
+  function attachments(v) {
+    return (v.attachments || []).map(function(a) {
+      var id = a.instanceID ? a.instanceID.id : "";
+      return [id, a.deviceName, a.status].filter(Boolean).join(" ");
+    }).join("; ");
+  }
+
+  function refresh() {
+    document.getElementById("error").textContent = "";
+    Promise.all([
+      get("/services"), get("/volumes?attachments=1"), get("/tasks")
+    ]).then(function(results) {
+      var services = results[0] || {}, volumes = results[1] || {},
+        tasks = results[2] || {};
+
+      fill("services", Object.keys(services).sort().map(function(k) {
+        var s = services[k];
+        return [s.name, s.driver ? s.driver.name : ""];
+      }));
+
+      var rows = [];
+      Object.keys(volumes).sort().forEach(function(svc) {
+        var vols = volumes[svc] || {};
+        Object.keys(vols).sort().forEach(function(k) {
+          var v = vols[k];
+          rows.push([svc, v.id, v.name, v.size, v.status, attachments(v)]);
+        });
+      });
+      fill("volumes", rows);
+
+      fill("tasks", Object.keys(tasks).map(function(k) {
+        return tasks[k];
+      }).sort(function(a, b) {
+        return b.id - a.id;
+      }).map(function(t) {
+        var err = t.error ? (t.error.message || JSON.stringify(t.error)) : "";
+        return [t.id, t.state, t.user, time(t.queueTime),
+          time(t.completeTime), err];
+      }));
+
+      document.getElementById("updated").textContent =
+        "Updated " + new Date().toLocaleTimeString();
+    }).catch(function(err) {
+      document.getElementById("error").textContent = err.message;
+    });
+  }
+
+  document.getElementById("auth").addEventListener("submit", function(e) {
+    e.preventDefault();
+    sessionStorage.setItem("libstorage.token", tokenInput.value);
+    refresh();
+  });
+
+  refresh();
+  setInterval(refresh, 10000);
+})();
+</script>
+</body>
+</html>
+`
@@ -0,0 +1,24 @@
+package ui
+
+import (
+	"net/http"
+
+	"github.com/codedellemc/libstorage/api/types"
+)
+
+// dashboard writes the dashboard page. The page is static; the services,
+// volumes, and tasks it displays are requested by the browser from the
+// server's API.
+func (r *router) dashboard(
+	ctx types.Context,
+	w http.ResponseWriter,
+	req *http.Request,
+	store types.Store) error {
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Frame-Options", "DENY")
+	w.WriteHeader(http.StatusOK)
+	_, err := w.Write([]byte(dashboardPage))
+	return err
+}
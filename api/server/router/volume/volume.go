@@ -51,6 +51,22 @@ func (r *router) initRoutes() {
 	r.routes = []types.Route{
 		// GET
 
+		// stream all volumes from all services
+		httputils.NewGetRoute(
+			"volumesStream",
+			"/volumes",
+			r.volumesStream,
+		).Queries("stream"),
+
+		// stream all volumes from a specific service
+		httputils.NewGetRoute(
+			"volumesStreamForService",
+			"/volumes/{service}",
+			r.volumesStreamForService,
+			handlers.NewServiceValidator(),
+			handlers.NewStorageSessionHandler(),
+		).Queries("stream"),
+
 		// get all volumes from all services
 		httputils.NewGetRoute(
 			"volumes",
@@ -93,6 +109,7 @@ func (r *router) initRoutes() {
 				schema.VolumeMapSchema,
 				func() interface{} { return &types.VolumeDetachRequest{} }),
 			handlers.NewPostArgsHandler(),
+			handlers.NewOptsValidator(),
 		).Queries("detach"),
 
 		// create a new volume
@@ -107,6 +124,7 @@ func (r *router) initRoutes() {
 				schema.VolumeSchema,
 				func() interface{} { return &types.VolumeCreateRequest{} }),
 			handlers.NewPostArgsHandler(),
+			handlers.NewOptsValidator(),
 		),
 
 		// create a new volume using an existing volume as the baseline
@@ -121,8 +139,24 @@ func (r *router) initRoutes() {
 				schema.VolumeSchema,
 				func() interface{} { return &types.VolumeCopyRequest{} }),
 			handlers.NewPostArgsHandler(),
+			handlers.NewOptsValidator(),
 		).Queries("copy"),
 
+		// expand an existing volume
+		httputils.NewPostRoute(
+			"volumeExpand",
+			"/volumes/{service}/{volumeID}",
+			r.volumeExpand,
+			handlers.NewServiceValidator(),
+			handlers.NewStorageSessionHandler(),
+			handlers.NewSchemaValidator(
+				schema.VolumeExpandRequestSchema,
+				schema.VolumeSchema,
+				func() interface{} { return &types.VolumeExpandRequest{} }),
+			handlers.NewPostArgsHandler(),
+			handlers.NewOptsValidator(),
+		).Queries("expand"),
+
 		// snapshot an existing volume
 		httputils.NewPostRoute(
 			"volumeSnapshot",
@@ -135,6 +169,7 @@ func (r *router) initRoutes() {
 				schema.SnapshotSchema,
 				func() interface{} { return &types.VolumeSnapshotRequest{} }),
 			handlers.NewPostArgsHandler(),
+			handlers.NewOptsValidator(),
 		).Queries("snapshot"),
 
 		// attach an existing volume
@@ -149,6 +184,7 @@ func (r *router) initRoutes() {
 				schema.VolumeSchema,
 				func() interface{} { return &types.VolumeAttachRequest{} }),
 			handlers.NewPostArgsHandler(),
+			handlers.NewOptsValidator(),
 		).Queries("attach"),
 
 		// detach all volumes for all services
@@ -175,6 +211,7 @@ func (r *router) initRoutes() {
 				schema.VolumeSchema,
 				func() interface{} { return &types.VolumeDetachRequest{} }),
 			handlers.NewPostArgsHandler(),
+			handlers.NewOptsValidator(),
 		).Queries("detach"),
 
 		// DELETE
@@ -1,7 +1,9 @@
 package volume
 
 import (
+	"encoding/json"
 	"net/http"
+	"sort"
 	"strings"
 	"sync"
 
@@ -55,6 +57,7 @@ func (r *router) volumes(
 
 			return getFilteredVolumes(ctx, req, store, svc, opts, filter)
 		}
+		run = services.CachedTaskRun(ctx, run)
 
 		task := service.TaskExecute(ctx, run, schema.VolumeMapSchema)
 		taskIDs = append(taskIDs, task.ID)
@@ -108,25 +111,263 @@ func (r *router) volumesForService(
 
 	opts := &types.VolumesOpts{
 		Attachments: store.GetAttachments(),
+		Pagination:  parsePagination(store),
 		Opts:        store,
 	}
 
+	mw := &nextMarkerWriter{ResponseWriter: w}
+
+	// the volumes are cached before they are paginated so that a cached
+	// result still sets the marker for the next page
+	getVolumes := services.CachedTaskRun(ctx, func(
+		ctx types.Context,
+		svc types.StorageService) (interface{}, error) {
+		return getFilteredVolumes(ctx, req, store, svc, opts, filter)
+	})
+
 	run := func(
 		ctx types.Context,
 		svc types.StorageService) (interface{}, error) {
 
-		return getFilteredVolumes(ctx, req, store, svc, opts, filter)
+		result, err := getVolumes(ctx, svc)
+		if err != nil {
+			return nil, err
+		}
+		objMap := result.(types.VolumeMap)
+		if opts.Pagination == nil {
+			return objMap, nil
+		}
+		objMap, nextMarker := paginateVolumes(objMap, opts.Pagination)
+		mw.setMarker(nextMarker)
+		return objMap, nil
 	}
 
 	return httputils.WriteTask(
 		ctx,
 		r.config,
-		w,
+		mw,
 		store,
 		service.TaskExecute(ctx, run, schema.VolumeMapSchema),
 		http.StatusOK)
 }
 
+// volumesStream streams the volumes of all services as newline-delimited
+// JSON. The services are listed concurrently, and each volume is written as
+// soon as its service's driver lists it.
+func (r *router) volumesStream(
+	ctx types.Context,
+	w http.ResponseWriter,
+	req *http.Request,
+	store types.Store) error {
+
+	var svcs []types.StorageService
+	for service := range services.StorageServices(ctx) {
+		svcs = append(svcs, service)
+	}
+	return streamVolumes(ctx, w, req, store, svcs, true)
+}
+
+// volumesStreamForService streams the volumes of a service as
+// newline-delimited JSON.
+func (r *router) volumesStreamForService(
+	ctx types.Context,
+	w http.ResponseWriter,
+	req *http.Request,
+	store types.Store) error {
+
+	svcs := []types.StorageService{context.MustService(ctx)}
+	return streamVolumes(ctx, w, req, store, svcs, false)
+}
+
+// streamVolumes writes the volumes of the specified services to the response
+// as they are listed. Since the response's status is written before the
+// volumes are listed, a service that fails to list its volumes writes an
+// item with the error instead. The newSession flag indicates whether each
+// service's task must log into the service's storage platform.
+func streamVolumes(
+	ctx types.Context,
+	w http.ResponseWriter,
+	req *http.Request,
+	store types.Store,
+	svcs []types.StorageService,
+	newSession bool) error {
+
+	filter, err := parseFilter(store)
+	if err != nil {
+		return err
+	}
+	if filter != nil {
+		store.Set("filter", filter)
+	}
+
+	sw, err := newVolumeStreamWriter(w)
+	if err != nil {
+		return err
+	}
+
+	opts := &types.VolumesOpts{
+		Attachments: store.GetAttachments(),
+		Opts:        store,
+	}
+
+	run := func(
+		ctx types.Context,
+		svc types.StorageService) (interface{}, error) {
+
+		err := func() error {
+			if newSession {
+				var err error
+				ctx = context.WithStorageService(ctx, svc)
+				if ctx, err = context.WithStorageSession(ctx); err != nil {
+					return err
+				}
+			}
+			return iterateFilteredVolumes(
+				ctx, req, store, svc, opts, filter,
+				func(v *types.Volume) error {
+					return sw.write(&types.VolumeStreamItem{
+						Service: svc.Name(),
+						Volume:  v,
+					})
+				})
+		}()
+
+		if err != nil && err != errVolumeStreamClosed {
+			sw.write(&types.VolumeStreamItem{
+				Service: svc.Name(),
+				Error:   err.Error(),
+			})
+		}
+		return nil, err
+	}
+
+	var taskIDs []int
+	for _, svc := range svcs {
+		taskIDs = append(taskIDs, svc.TaskExecute(ctx, run, nil).ID)
+	}
+	services.TaskWaitAll(ctx, taskIDs...)
+
+	return nil
+}
+
+var errVolumeStreamClosed = goof.New("volume stream closed by client")
+
+// volumeStreamWriter writes the items of a volume stream. The services'
+// volumes are listed concurrently, so access to the response is synchronized.
+type volumeStreamWriter struct {
+	sync.Mutex
+	enc      *json.Encoder
+	flusher  http.Flusher
+	closed   <-chan bool
+	isClosed bool
+}
+
+// newVolumeStreamWriter writes the header of a volume stream's response.
+func newVolumeStreamWriter(w http.ResponseWriter) (*volumeStreamWriter, error) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return nil, goof.New("streaming unsupported")
+	}
+
+	sw := &volumeStreamWriter{enc: json.NewEncoder(w), flusher: flusher}
+	if cn, ok := w.(http.CloseNotifier); ok {
+		sw.closed = cn.CloseNotify()
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	return sw, nil
+}
+
+// write encodes the item as a single line and flushes it to the client. An
+// error is returned if the client closed the stream.
+func (sw *volumeStreamWriter) write(item *types.VolumeStreamItem) error {
+	sw.Lock()
+	defer sw.Unlock()
+	if !sw.isClosed {
+		select {
+		case <-sw.closed:
+			sw.isClosed = true
+		default:
+		}
+	}
+	if sw.isClosed {
+		return errVolumeStreamClosed
+	}
+	if err := sw.enc.Encode(item); err != nil {
+		return err
+	}
+	sw.flusher.Flush()
+	return nil
+}
+
+// parsePagination returns the pagination options specified by the limit and
+// marker query parameters; otherwise a nil value is returned.
+func parsePagination(store types.Store) *types.VolumesPagination {
+	if !store.IsSet("limit") && !store.IsSet("marker") {
+		return nil
+	}
+	return &types.VolumesPagination{
+		Limit:  store.GetInt("limit"),
+		Marker: store.GetString("marker"),
+	}
+}
+
+// paginateVolumes returns the page of volumes described by the pagination
+// options as well as the marker for the next page. The marker is empty if
+// there are no more volumes.
+func paginateVolumes(
+	objMap types.VolumeMap,
+	p *types.VolumesPagination) (types.VolumeMap, string) {
+
+	ids := []string{}
+	for id := range objMap {
+		if p.Marker == "" || id > p.Marker {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	var nextMarker string
+	if p.Limit > 0 && len(ids) > p.Limit {
+		ids = ids[:p.Limit]
+		nextMarker = ids[len(ids)-1]
+	}
+
+	page := types.VolumeMap{}
+	for _, id := range ids {
+		page[id] = objMap[id]
+	}
+	return page, nextMarker
+}
+
+// nextMarkerWriter is a ResponseWriter that writes the NextMarkerHeader when
+// the response header is written. The marker is set by the task that lists
+// the volumes, so access to it is synchronized.
+type nextMarkerWriter struct {
+	http.ResponseWriter
+	sync.Mutex
+	marker string
+}
+
+func (w *nextMarkerWriter) setMarker(marker string) {
+	w.Lock()
+	defer w.Unlock()
+	w.marker = marker
+}
+
+func (w *nextMarkerWriter) WriteHeader(code int) {
+	w.Lock()
+	if w.marker != "" {
+		w.Header().Set(types.NextMarkerHeader, w.marker)
+	}
+	w.Unlock()
+	w.ResponseWriter.WriteHeader(code)
+}
+
 func getFilteredVolumes(
 	ctx types.Context,
 	req *http.Request,
@@ -135,11 +376,92 @@ func getFilteredVolumes(
 	opts *types.VolumesOpts,
 	filter *types.Filter) (types.VolumeMap, error) {
 
+	match, err := newVolumeMatcher(ctx, req, store, storSvc, opts, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	objs, err := storSvc.Driver().Volumes(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	objMap := types.VolumeMap{}
+	for _, obj := range objs {
+		ok, err := match(obj)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			objMap[obj.ID] = obj
+		}
+	}
+
+	return objMap, nil
+}
+
+// iterateFilteredVolumes invokes the callback for each of the service's
+// volumes that matches the filter. If the service's driver can iterate its
+// volumes then each volume is passed to the callback as soon as it is
+// listed.
+func iterateFilteredVolumes(
+	ctx types.Context,
+	req *http.Request,
+	store types.Store,
+	storSvc types.StorageService,
+	opts *types.VolumesOpts,
+	filter *types.Filter,
+	fn func(volume *types.Volume) error) error {
+
+	match, err := newVolumeMatcher(ctx, req, store, storSvc, opts, filter)
+	if err != nil {
+		return err
+	}
+
+	matchFn := func(obj *types.Volume) error {
+		ok, err := match(obj)
+		if err != nil || !ok {
+			return err
+		}
+		return fn(obj)
+	}
+
+	d := storSvc.Driver()
+	if vi, ok := d.(types.StorageDriverWithVolumesIterator); ok {
+		if err := vi.VolumesIterate(
+			ctx, opts, matchFn); err != types.ErrNotImplemented {
+			return err
+		}
+	}
+
+	objs, err := d.Volumes(ctx, opts)
+	if err != nil {
+		return err
+	}
+	for _, obj := range objs {
+		if err := matchFn(obj); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newVolumeMatcher returns a function that indicates whether a volume listed
+// by the service matches the filter and attachment options. The function
+// also removes the attachments of other instances from the volume if only
+// the requesting instance's attachments are requested.
+func newVolumeMatcher(
+	ctx types.Context,
+	req *http.Request,
+	store types.Store,
+	storSvc types.StorageService,
+	opts *types.VolumesOpts,
+	filter *types.Filter) (func(obj *types.Volume) (bool, error), error) {
+
 	var (
 		filterOp    types.FilterOperator
 		filterLeft  string
 		filterRight string
-		objMap      = types.VolumeMap{}
 	)
 
 	iid, iidOK := context.InstanceID(ctx)
@@ -147,23 +469,16 @@ func getFilteredVolumes(
 		return nil, utils.NewMissingInstanceIDError(storSvc.Name())
 	}
 
-	objs, err := storSvc.Driver().Volumes(ctx, opts)
-	if err != nil {
-		return nil, err
-	}
-
 	if filter != nil {
 		filterOp = filter.Op
 		filterLeft = strings.ToLower(filter.Left)
 		filterRight = strings.ToLower(filter.Right)
 	}
 
-	for _, obj := range objs {
+	return func(obj *types.Volume) (bool, error) {
 
-		if filterOp == types.FilterEqualityMatch && filterLeft == "name" {
-			if !strings.EqualFold(obj.Name, filterRight) {
-				continue
-			}
+		if filterLeft == "name" && !matchName(obj.Name, filterOp, filterRight) {
+			return false, nil
 		}
 
 		// if only the requesting instance's attachments are requested then
@@ -179,28 +494,26 @@ func getFilteredVolumes(
 		}
 
 		if opts.Attachments.Attached() && len(obj.Attachments) == 0 {
-			continue
+			return false, nil
 		}
 
 		if opts.Attachments.Unattached() && len(obj.Attachments) > 0 {
-			continue
+			return false, nil
 		}
 
 		if OnVolume != nil {
 			ctx.Debug("invoking OnVolume handler")
 			ok, err := OnVolume(ctx, req, store, obj)
 			if err != nil {
-				return nil, err
+				return false, err
 			}
 			if !ok {
-				continue
+				return false, nil
 			}
 		}
 
-		objMap[obj.ID] = obj
-	}
-
-	return objMap, nil
+		return true, nil
+	}, nil
 }
 
 func (r *router) volumeInspect(
@@ -292,7 +605,8 @@ func (r *router) volumeInspect(
 		r.config,
 		w,
 		store,
-		service.TaskExecute(ctx, run, schema.VolumeSchema),
+		service.TaskExecute(
+			ctx, services.CachedTaskRun(ctx, run), schema.VolumeSchema),
 		http.StatusOK)
 }
 
@@ -308,17 +622,24 @@ func (r *router) volumeCreate(
 		ctx types.Context,
 		svc types.StorageService) (interface{}, error) {
 
-		v, err := svc.Driver().VolumeCreate(
-			ctx,
-			store.GetString("name"),
-			&types.VolumeCreateOpts{
-				AvailabilityZone: store.GetStringPtr("availabilityZone"),
-				IOPS:             store.GetInt64Ptr("iops"),
-				Size:             store.GetInt64Ptr("size"),
-				Type:             store.GetStringPtr("type"),
-				Encrypted:        store.GetBoolPtr("encrypted"),
-				Opts:             store,
-			})
+		name := store.GetString("name")
+		opts := &types.VolumeCreateOpts{
+			AvailabilityZone: store.GetStringPtr("availabilityZone"),
+			IOPS:             store.GetInt64Ptr("iops"),
+			Size:             store.GetInt64Ptr("size"),
+			Type:             store.GetStringPtr("type"),
+			Encrypted:        store.GetBoolPtr("encrypted"),
+			Opts:             store,
+		}
+
+		release, err := services.CheckVolumeCreatePolicy(
+			ctx, svc, name, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		v, err := svc.Driver().VolumeCreate(ctx, name, opts)
+		release()
 
 		if err != nil {
 			return nil, err
@@ -358,11 +679,18 @@ func (r *router) volumeCopy(
 		ctx types.Context,
 		svc types.StorageService) (interface{}, error) {
 
+		release, err := services.CheckVolumeCreatePolicy(
+			ctx, svc, store.GetString("volumeName"), nil)
+		if err != nil {
+			return nil, err
+		}
+
 		v, err := svc.Driver().VolumeCopy(
 			ctx,
 			store.GetString("volumeID"),
 			store.GetString("volumeName"),
 			store)
+		release()
 
 		if err != nil {
 			return nil, err
@@ -390,6 +718,56 @@ func (r *router) volumeCopy(
 		http.StatusCreated)
 }
 
+func (r *router) volumeExpand(
+	ctx types.Context,
+	w http.ResponseWriter,
+	req *http.Request,
+	store types.Store) error {
+
+	service := context.MustService(ctx)
+
+	run := func(
+		ctx types.Context,
+		svc types.StorageService) (interface{}, error) {
+
+		newSize := store.GetInt64("newSize")
+		if err := services.CheckVolumeExpandPolicy(
+			ctx, svc, newSize); err != nil {
+			return nil, err
+		}
+
+		v, err := svc.Driver().VolumeExpand(
+			ctx,
+			store.GetString("volumeID"),
+			newSize,
+			store)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if OnVolume != nil {
+			ok, err := OnVolume(ctx, req, store, v)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				return nil, utils.NewNotFoundError(v.ID)
+			}
+		}
+
+		return v, nil
+	}
+
+	return httputils.WriteTask(
+		ctx,
+		r.config,
+		w,
+		store,
+		service.TaskExecute(ctx, run, schema.VolumeSchema),
+		http.StatusOK)
+}
+
 func (r *router) volumeSnapshot(
 	ctx types.Context,
 	w http.ResponseWriter,
@@ -433,14 +811,19 @@ func (r *router) volumeAttach(
 		ctx types.Context,
 		svc types.StorageService) (interface{}, error) {
 
-		v, attTokn, err := svc.Driver().VolumeAttach(
-			ctx,
-			store.GetString("volumeID"),
-			&types.VolumeAttachOpts{
-				NextDevice: store.GetStringPtr("nextDeviceName"),
-				Force:      store.GetBool("force"),
-				Opts:       store,
-			})
+		volumeID := store.GetString("volumeID")
+		opts := &types.VolumeAttachOpts{
+			NextDevice: store.GetStringPtr("nextDeviceName"),
+			Force:      store.GetBool("force"),
+			Opts:       store,
+		}
+
+		if err := services.PreemptVolumeAttach(
+			ctx, svc, volumeID, opts); err != nil {
+			return nil, err
+		}
+
+		v, attTokn, err := svc.Driver().VolumeAttach(ctx, volumeID, opts)
 
 		if err != nil {
 			return nil, err
@@ -701,6 +1084,11 @@ func (r *router) volumeRemove(
 		ctx types.Context,
 		svc types.StorageService) (interface{}, error) {
 
+		if err := services.CheckVolumeRemovePolicy(
+			ctx, svc, store.GetString("volumeID"), store); err != nil {
+			return nil, err
+		}
+
 		return nil, svc.Driver().VolumeRemove(
 			ctx,
 			store.GetString("volumeID"),
@@ -716,6 +1104,24 @@ func (r *router) volumeRemove(
 		http.StatusNoContent)
 }
 
+// matchName returns a flag indicating whether or not a volume name matches
+// the right operand of a name filter. Names are compared without regard to
+// case.
+func matchName(name string, op types.FilterOperator, right string) bool {
+	name = strings.ToLower(name)
+	switch op {
+	case types.FilterEqualityMatch:
+		return name == right
+	case types.FilterSubstrings:
+		return strings.Contains(name, right)
+	case types.FilterSubstringsPrefix:
+		return strings.HasSuffix(name, right)
+	case types.FilterSubstringsPostfix:
+		return strings.HasPrefix(name, right)
+	}
+	return true
+}
+
 func parseFilter(store types.Store) (*types.Filter, error) {
 	if !store.IsSet("filter") {
 		return nil, nil
@@ -0,0 +1,208 @@
+package volume
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/akutz/goof"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codedellemc/libstorage/api/context"
+	"github.com/codedellemc/libstorage/api/types"
+	"github.com/codedellemc/libstorage/api/utils"
+)
+
+// testStorageService is a storage service whose driver lists a fixed set of
+// volumes or fails with an error.
+type testStorageService struct {
+	types.StorageService
+	driver *testStorageDriver
+}
+
+func (s *testStorageService) Name() string {
+	return "test"
+}
+
+func (s *testStorageService) Driver() types.StorageDriver {
+	return s.driver
+}
+
+type testStorageDriver struct {
+	types.StorageDriver
+	volumes []*types.Volume
+	err     error
+}
+
+func (s *testStorageDriver) Volumes(
+	ctx types.Context,
+	opts *types.VolumesOpts) ([]*types.Volume, error) {
+
+	return s.volumes, s.err
+}
+
+func newTestVolumeMap(n int) types.VolumeMap {
+	objMap := types.VolumeMap{}
+	for i := 0; i < n; i++ {
+		id := fmt.Sprintf("vol-%02d", i)
+		objMap[id] = &types.Volume{ID: id}
+	}
+	return objMap
+}
+
+func TestParsePagination(t *testing.T) {
+	store := utils.NewStore()
+	assert.Nil(t, parsePagination(store))
+
+	store.Set("limit", "10")
+	assert.Equal(t,
+		&types.VolumesPagination{Limit: 10}, parsePagination(store))
+
+	store = utils.NewStore()
+	store.Set("marker", "vol-01")
+	assert.Equal(t,
+		&types.VolumesPagination{Marker: "vol-01"}, parsePagination(store))
+
+	// a limit that is not a number is no limit
+	store.Set("limit", "ten")
+	assert.Equal(t, 0, parsePagination(store).Limit)
+}
+
+func TestPaginateVolumes(t *testing.T) {
+	objMap := newTestVolumeMap(5)
+
+	var (
+		pages  [][]string
+		marker string
+	)
+	for {
+		page, next := paginateVolumes(
+			objMap, &types.VolumesPagination{Limit: 2, Marker: marker})
+		ids := []string{}
+		for id := range page {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		pages = append(pages, ids)
+		if next == "" {
+			break
+		}
+		marker = next
+	}
+	assert.Equal(t, [][]string{
+		{"vol-00", "vol-01"},
+		{"vol-02", "vol-03"},
+		{"vol-04"},
+	}, pages)
+
+	// no limit returns the volumes after the marker
+	page, next := paginateVolumes(
+		objMap, &types.VolumesPagination{Marker: "vol-02"})
+	assert.Len(t, page, 2)
+	assert.Empty(t, next)
+
+	// a full last page has no next marker
+	page, next = paginateVolumes(objMap, &types.VolumesPagination{Limit: 5})
+	assert.Len(t, page, 5)
+	assert.Empty(t, next)
+
+	// a marker after the last volume returns an empty page
+	page, next = paginateVolumes(
+		objMap, &types.VolumesPagination{Limit: 2, Marker: "vol-99"})
+	assert.Empty(t, page)
+	assert.Empty(t, next)
+}
+
+func TestNextMarkerWriter(t *testing.T) {
+	rec := httptest.NewRecorder()
+	w := &nextMarkerWriter{ResponseWriter: rec}
+	w.setMarker("vol-01")
+	w.WriteHeader(200)
+	assert.Equal(t, "vol-01", rec.Header().Get(types.NextMarkerHeader))
+
+	rec = httptest.NewRecorder()
+	w = &nextMarkerWriter{ResponseWriter: rec}
+	w.WriteHeader(200)
+	_, ok := rec.Header()[types.NextMarkerHeader]
+	assert.False(t, ok)
+}
+
+func TestMatchName(t *testing.T) {
+	tests := []struct {
+		filter string
+		name   string
+		match  bool
+	}{
+		{"(name=Data)", "data", true},
+		{"(name=data)", "DATA", true},
+		{"(name=data)", "data-1", false},
+		{"(name=*at*)", "data", true},
+		{"(name=*at*)", "dome", false},
+		{"(name=*ta)", "data", true},
+		{"(name=*ta)", "tad", false},
+		{"(name=da*)", "data", true},
+		{"(name=da*)", "ada", false},
+		{"(name=*)", "anything", true},
+	}
+	for _, tt := range tests {
+		store := utils.NewStore()
+		store.Set("filter", tt.filter)
+		filter, err := parseFilter(store)
+		if !assert.NoError(t, err, tt.filter) {
+			continue
+		}
+		// the volume matcher lowers the filter's right operand
+		right := strings.ToLower(filter.Right)
+		assert.Equal(t, tt.match, matchName(tt.name, filter.Op, right),
+			"%s %s", tt.filter, tt.name)
+	}
+
+	store := utils.NewStore()
+	store.Set("filter", "(name=")
+	_, err := parseFilter(store)
+	assert.Error(t, err)
+}
+
+func TestGetFilteredVolumes(t *testing.T) {
+	ctx := context.Background()
+	store := utils.NewStore()
+	svc := &testStorageService{driver: &testStorageDriver{
+		volumes: []*types.Volume{
+			{ID: "vol-1", Name: "data"},
+			{ID: "vol-2", Name: "logs"},
+			{ID: "vol-3", Name: "data-old"},
+		},
+	}}
+
+	filterStore := utils.NewStore()
+	filterStore.Set("filter", "(name=DATA*)")
+	filter, err := parseFilter(filterStore)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	objMap, err := getFilteredVolumes(
+		ctx, nil, store, svc, &types.VolumesOpts{}, filter)
+	assert.NoError(t, err)
+	assert.Len(t, objMap, 2)
+	assert.Contains(t, objMap, "vol-1")
+	assert.Contains(t, objMap, "vol-3")
+
+	objMap, err = getFilteredVolumes(
+		ctx, nil, store, svc, &types.VolumesOpts{}, nil)
+	assert.NoError(t, err)
+	assert.Len(t, objMap, 3)
+
+	// the driver's errors are returned
+	svc.driver.err = goof.New("backend unavailable")
+	_, err = getFilteredVolumes(
+		ctx, nil, store, svc, &types.VolumesOpts{}, nil)
+	assert.EqualError(t, err, "backend unavailable")
+
+	// the requesting instance's attachments require its instance ID
+	_, err = getFilteredVolumes(ctx, nil, store, svc, &types.VolumesOpts{
+		Attachments: types.VolumeAttachmentsMine}, nil)
+	assert.IsType(t, &types.ErrMissingInstanceID{}, err)
+}
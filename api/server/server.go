@@ -7,6 +7,7 @@ import (
 	"os/signal"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -19,6 +20,7 @@ import (
 	glogrus "github.com/codedellemc/gournal/logrus"
 
 	"github.com/codedellemc/libstorage/api/context"
+	"github.com/codedellemc/libstorage/api/registry"
 	"github.com/codedellemc/libstorage/api/server/services"
 	"github.com/codedellemc/libstorage/api/types"
 	"github.com/codedellemc/libstorage/api/utils"
@@ -45,6 +47,7 @@ type server struct {
 	closeSignal  chan int
 	closedSignal chan int
 	closeOnce    *sync.Once
+	draining     int32
 
 	routers        []types.Router
 	routeHandlers  map[string][]types.Middleware
@@ -58,7 +61,10 @@ type server struct {
 	stdErr io.WriteCloser
 }
 
-func newServer(goCtx gocontext.Context, config gofig.Config) (*server, error) {
+func newServer(
+	goCtx gocontext.Context,
+	config gofig.Config,
+	embedded bool) (*server, error) {
 
 	adminTokenUUID, err := types.NewUUID()
 	if err != nil {
@@ -149,10 +155,20 @@ func newServer(goCtx gocontext.Context, config gofig.Config) (*server, error) {
 
 	s.ctx.Info("initializing server")
 
-	if err := s.initEndpoints(s.ctx); err != nil {
-		return nil, err
+	// an embedded server does not listen on any endpoints
+	if !embedded {
+		if err := s.initEndpoints(s.ctx); err != nil {
+			return nil, err
+		}
+		s.ctx.Info("initialized endpoints")
+	}
+
+	if dir := s.config.GetString(types.ConfigServerPluginsDir); dir != "" {
+		if err := registry.LoadStorageDriverPlugins(s.ctx, dir); err != nil {
+			return nil, err
+		}
+		s.ctx.WithField("dir", dir).Info("loaded storage driver plugins")
 	}
-	s.ctx.Info("initialized endpoints")
 
 	if err := services.Init(s.ctx, s.config); err != nil {
 		return nil, err
@@ -167,7 +183,9 @@ func newServer(goCtx gocontext.Context, config gofig.Config) (*server, error) {
 		s.stdErr = getLogIO(logConfig.Stderr, types.ConfigLogStderr)
 	}
 
-	s.initGlobalMiddleware()
+	if err := s.initGlobalMiddleware(); err != nil {
+		return nil, err
+	}
 
 	if err := s.initRouters(); err != nil {
 		return nil, err
@@ -186,7 +204,7 @@ func Serve(
 	goCtx gocontext.Context,
 	config gofig.Config) (types.Server, <-chan error, error) {
 
-	s, err := newServer(goCtx, config)
+	s, err := newServer(goCtx, config, false)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -195,7 +213,8 @@ func Serve(
 	srvErrs := make(chan error, len(s.servers))
 
 	for _, srv := range s.servers {
-		srv.srv.Handler = s.createMux(srv.ctx)
+		srv.srv.Handler = srv.verifyClientHandler(
+			s.corsHandler(s.gzipHandler(s.createMux(srv.ctx))))
 		go func(srv *HTTPServer) {
 			srv.ctx.Info("api listening")
 			if err := srv.Serve(); err != nil {
@@ -262,8 +281,13 @@ func (s *server) Close() (err error) {
 func (s *server) close() error {
 	s.ctx.Info("shutting down server")
 
+	// reject the requests that arrive on existing connections while the
+	// server is draining
+	atomic.StoreInt32(&s.draining, 1)
+
 	for _, srv := range s.servers {
 		srv.ctx.Info("shutting down endpoint")
+		srv.srv.SetKeepAlivesEnabled(false)
 		if err := srv.Close(); err != nil {
 			srv.ctx.Error(err)
 		}
@@ -276,6 +300,9 @@ func (s *server) close() error {
 		srv.ctx.Debug("shutdown endpoint complete")
 	}
 
+	s.drainTasks()
+	registry.CloseStorageDriverPlugins()
+
 	if s.stdOut != nil {
 		if err := s.stdOut.Close(); err != nil {
 			log.Error(err)
@@ -293,6 +320,32 @@ func (s *server) close() error {
 	return nil
 }
 
+// drainTasks waits for the queued and running tasks to complete so that
+// in-flight storage operations are not interrupted, but no longer than the
+// duration specified by libstorage.server.tasks.drainTimeout.
+func (s *server) drainTasks() {
+	drainTimeout, err := time.ParseDuration(
+		s.config.GetString(types.ConfigServerTasksDrainTimeout))
+	if err != nil {
+		drainTimeout = time.Duration(time.Second * 30)
+	}
+	if drainTimeout <= 0 {
+		return
+	}
+
+	s.ctx.WithField("timeout", drainTimeout).Info("draining tasks")
+
+	timeout := time.NewTimer(drainTimeout)
+	defer timeout.Stop()
+
+	select {
+	case <-services.TaskDrainC(s.ctx):
+		s.ctx.Info("drained tasks")
+	case <-timeout.C:
+		s.ctx.Warn("timed out draining tasks")
+	}
+}
+
 // CloseOnAbort is a helper function that can be called by programs, such as
 // tests or a command line or service application.
 func CloseOnAbort() {
@@ -307,6 +360,12 @@ func CloseOnAbort() {
 	go func() {
 		<-sigc
 		fmt.Println("received abort signal")
+		go func() {
+			// a second signal aborts the drain of the task queue
+			<-sigc
+			fmt.Println("received second abort signal")
+			os.Exit(1)
+		}()
 		for range Close() {
 		}
 		os.Exit(1)
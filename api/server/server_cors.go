@@ -0,0 +1,118 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+
+	"github.com/codedellemc/libstorage/api/types"
+)
+
+const (
+	corsAllowAll = "*"
+
+	corsAllowedMethods = "GET, HEAD, POST, PUT, DELETE"
+)
+
+// corsExposedHeaders are the response headers that scripts running in a
+// browser are permitted to read.
+var corsExposedHeaders = strings.Join([]string{
+	types.ServerNameHeader,
+	types.TransactionHeader,
+	types.NextMarkerHeader,
+	types.TraceIDHeader,
+}, ", ")
+
+// corsHandler returns a handler that adds the headers of the Cross-Origin
+// Resource Sharing protocol to the responses of requests from the origins
+// listed in libstorage.server.cors.allowedOrigins before invoking h. The
+// preflight requests of allowed origins are answered without invoking h.
+//
+// The handler h is returned as is if no origins are allowed.
+func (s *server) corsHandler(h http.Handler) http.Handler {
+
+	origins := s.config.GetStringSlice(types.ConfigServerCORSAllowedOrigins)
+	if len(origins) == 0 {
+		return h
+	}
+
+	credentials := s.config.GetBool(types.ConfigServerCORSAllowCredentials)
+
+	var maxAge string
+	if v := s.config.GetString(types.ConfigServerCORSMaxAge); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			s.ctx.WithField("maxAge", v).Warn("invalid cors max age")
+		} else {
+			maxAge = fmt.Sprintf("%d", int64(d.Seconds()))
+		}
+	}
+
+	s.ctx.WithFields(log.Fields{
+		"origins":     origins,
+		"credentials": credentials,
+	}).Info("configured cors")
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		origin := req.Header.Get("Origin")
+		if origin == "" {
+			h.ServeHTTP(w, req)
+			return
+		}
+
+		w.Header().Add("Vary", "Origin")
+		allowed, wildcard := corsAllowed(origins, origin)
+		if !allowed {
+			h.ServeHTTP(w, req)
+			return
+		}
+
+		// an origin that is only allowed by the wildcard is never allowed to
+		// send credentials, otherwise any site could issue requests with
+		// the credentials of the browser's user
+		if wildcard {
+			w.Header().Set("Access-Control-Allow-Origin", corsAllowAll)
+		} else {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			if credentials {
+				w.Header().Set("Access-Control-Allow-Credentials", "true")
+			}
+		}
+
+		reqMethod := req.Header.Get("Access-Control-Request-Method")
+		if req.Method != http.MethodOptions || reqMethod == "" {
+			w.Header().Set(
+				"Access-Control-Expose-Headers", corsExposedHeaders)
+			h.ServeHTTP(w, req)
+			return
+		}
+
+		// a preflight request
+		w.Header().Set("Access-Control-Allow-Methods", corsAllowedMethods)
+		if v := req.Header.Get("Access-Control-Request-Headers"); v != "" {
+			w.Header().Set("Access-Control-Allow-Headers", v)
+		}
+		if maxAge != "" {
+			w.Header().Set("Access-Control-Max-Age", maxAge)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// corsAllowed returns a flag indicating whether an origin is allowed and a
+// flag indicating whether it is only allowed because all origins are.
+func corsAllowed(origins []string, origin string) (bool, bool) {
+	var wildcard bool
+	for _, o := range origins {
+		if strings.EqualFold(o, origin) {
+			return true, false
+		}
+		if o == corsAllowAll {
+			wildcard = true
+		}
+	}
+	return wildcard, wildcard
+}
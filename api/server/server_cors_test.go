@@ -0,0 +1,28 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCORSAllowed(t *testing.T) {
+	tests := []struct {
+		origins  []string
+		origin   string
+		allowed  bool
+		wildcard bool
+	}{
+		{[]string{"https://a.com"}, "https://a.com", true, false},
+		{[]string{"https://a.com"}, "HTTPS://A.COM", true, false},
+		{[]string{"https://a.com"}, "https://b.com", false, false},
+		{[]string{"*"}, "https://b.com", true, true},
+		{[]string{"*", "https://a.com"}, "https://a.com", true, false},
+		{[]string{"https://a.com", "*"}, "https://b.com", true, true},
+	}
+	for _, tt := range tests {
+		allowed, wildcard := corsAllowed(tt.origins, tt.origin)
+		assert.Equal(t, tt.allowed, allowed, "%v %s", tt.origins, tt.origin)
+		assert.Equal(t, tt.wildcard, wildcard, "%v %s", tt.origins, tt.origin)
+	}
+}
@@ -0,0 +1,63 @@
+package server
+
+import (
+	"net/http"
+
+	gocontext "golang.org/x/net/context"
+
+	gofig "github.com/akutz/gofig/types"
+
+	"github.com/codedellemc/libstorage/api/context"
+	"github.com/codedellemc/libstorage/api/registry"
+	"github.com/codedellemc/libstorage/api/types"
+)
+
+func init() {
+	registry.RegisterEmbeddedServer(newEmbeddedServer)
+}
+
+type embeddedServer struct {
+	*server
+	handler http.Handler
+}
+
+// ServeEmbedded starts a libStorage server that does not listen on any
+// endpoints. The server handles the requests that are dispatched to its
+// ServeHTTP function, such as those of a client that uses the embedded
+// transport.
+func ServeEmbedded(
+	goCtx gocontext.Context,
+	config gofig.Config) (types.EmbeddedServer, error) {
+
+	s, err := newServer(goCtx, config, true)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := s.ctx.WithValue(context.HostKey, "embedded://"+s.name)
+	ctx = ctx.WithValue(context.TLSKey, false)
+
+	// there are no endpoints to close, so the close signal is acknowledged
+	// as soon as it is received
+	go func() {
+		<-s.closeSignal
+		s.ctx.Debug("received close signal")
+		s.closedSignal <- 1
+	}()
+
+	s.ctx.Info("embedded server started")
+
+	return &embeddedServer{server: s, handler: s.createMux(ctx)}, nil
+}
+
+func newEmbeddedServer(
+	ctx types.Context,
+	config gofig.Config) (types.EmbeddedServer, error) {
+
+	return ServeEmbedded(ctx, config)
+}
+
+// ServeHTTP handles a request dispatched to the embedded server.
+func (s *embeddedServer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	s.handler.ServeHTTP(w, req)
+}
@@ -0,0 +1,195 @@
+package server
+
+import (
+	"compress/gzip"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/akutz/goof"
+
+	"github.com/codedellemc/libstorage/api/server/httputils"
+	"github.com/codedellemc/libstorage/api/types"
+)
+
+// gzipHandler returns a handler that decompresses gzip-compressed request
+// bodies and compresses the responses of clients that accept gzip before
+// invoking h. A response is only compressed once it exceeds
+// libstorage.server.compression.minSize bytes, or when it is flushed, as
+// streamed responses are. A decompressed request body may not exceed
+// libstorage.server.compression.maxRequestSize bytes so that a small
+// compressed body cannot exhaust the server's memory.
+//
+// The handler h is returned as is if libstorage.server.compression.disabled
+// is true.
+func (s *server) gzipHandler(h http.Handler) http.Handler {
+
+	if s.config.GetBool(types.ConfigServerCompressionDisabled) {
+		return h
+	}
+	return newGzipHandler(
+		h,
+		s.config.GetInt(types.ConfigServerCompressionMinSize),
+		int64(s.config.GetInt(types.ConfigServerCompressionMaxRequestSize)))
+}
+
+func newGzipHandler(
+	h http.Handler, minSize int, maxRequestSize int64) http.Handler {
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if strings.EqualFold(req.Header.Get("Content-Encoding"), "gzip") {
+			body, err := gzip.NewReader(req.Body)
+			if err != nil {
+				httpErr := goof.NewHTTPError(
+					goof.WithError("invalid gzip request body", err),
+					http.StatusBadRequest)
+				httputils.WriteJSON(w, httpErr.Status(), httpErr)
+				return
+			}
+			defer body.Close()
+			req.Body = http.MaxBytesReader(w, body, maxRequestSize)
+			req.ContentLength = -1
+			req.Header.Del("Content-Encoding")
+			req.Header.Del("Content-Length")
+		}
+
+		w.Header().Add("Vary", "Accept-Encoding")
+		if req.Method == http.MethodHead || !acceptsGzip(req) {
+			h.ServeHTTP(w, req)
+			return
+		}
+
+		gw := &gzipResponseWriter{
+			ResponseWriter: w,
+			minSize:        minSize,
+			code:           http.StatusOK,
+		}
+		defer gw.Close()
+		h.ServeHTTP(gw, req)
+	})
+}
+
+// acceptsGzip returns a flag indicating whether the request's
+// Accept-Encoding header lists gzip with a non-zero quality.
+func acceptsGzip(req *http.Request) bool {
+	for _, v := range strings.Split(req.Header.Get("Accept-Encoding"), ",") {
+		parts := strings.Split(v, ";")
+		if !strings.EqualFold(strings.TrimSpace(parts[0]), "gzip") {
+			continue
+		}
+		for _, p := range parts[1:] {
+			p = strings.TrimSpace(p)
+			if !strings.HasPrefix(p, "q=") {
+				continue
+			}
+			if q, err := strconv.ParseFloat(p[2:], 64); err == nil && q == 0 {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// gzipResponseWriter buffers the beginning of a response until it is known
+// whether the response is large enough to be compressed.
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	minSize int
+	code    int
+	buf     []byte
+	started bool
+	gz      *gzip.Writer
+}
+
+func (w *gzipResponseWriter) WriteHeader(code int) {
+	if !w.started {
+		w.code = code
+	}
+}
+
+func (w *gzipResponseWriter) Write(buf []byte) (int, error) {
+	if !w.started {
+		w.buf = append(w.buf, buf...)
+		if len(w.buf) < w.minSize {
+			return len(buf), nil
+		}
+		if err := w.start(true); err != nil {
+			return 0, err
+		}
+		return len(buf), nil
+	}
+	if w.gz != nil {
+		return w.gz.Write(buf)
+	}
+	return w.ResponseWriter.Write(buf)
+}
+
+// start writes the response's header and the buffered part of its body,
+// compressing the response if compress is true and the response may be
+// compressed.
+func (w *gzipResponseWriter) start(compress bool) error {
+	w.started = true
+
+	hdr := w.Header()
+	if compress &&
+		hdr.Get("Content-Encoding") == "" &&
+		w.code != http.StatusNoContent &&
+		w.code != http.StatusNotModified {
+
+		hdr.Set("Content-Encoding", "gzip")
+		hdr.Del("Content-Length")
+		w.gz = gzip.NewWriter(w.ResponseWriter)
+	}
+
+	w.ResponseWriter.WriteHeader(w.code)
+
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	if w.gz != nil {
+		_, err := w.gz.Write(buf)
+		return err
+	}
+	_, err := w.ResponseWriter.Write(buf)
+	return err
+}
+
+// Flush writes the response so far to the client. A response that is
+// flushed before it is complete is a streamed response and so is compressed
+// regardless of its size.
+func (w *gzipResponseWriter) Flush() {
+	if !w.started {
+		if err := w.start(true); err != nil {
+			return
+		}
+	}
+	if w.gz != nil {
+		w.gz.Flush()
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *gzipResponseWriter) CloseNotify() <-chan bool {
+	if cn, ok := w.ResponseWriter.(http.CloseNotifier); ok {
+		return cn.CloseNotify()
+	}
+	return nil
+}
+
+// Close completes the response.
+func (w *gzipResponseWriter) Close() error {
+	if !w.started {
+		if err := w.start(false); err != nil {
+			return err
+		}
+	}
+	if w.gz != nil {
+		return w.gz.Close()
+	}
+	return nil
+}
@@ -0,0 +1,240 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func gzipRequest(method string, body io.Reader) *http.Request {
+	req := httptest.NewRequest(method, "/volumes", body)
+	req.Header.Set("Accept-Encoding", "gzip")
+	return req
+}
+
+func gunzip(t *testing.T, r io.Reader) string {
+	gz, err := gzip.NewReader(r)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	buf, err := ioutil.ReadAll(gz)
+	assert.NoError(t, err)
+	return string(buf)
+}
+
+func gzipBody(t *testing.T, body string) *bytes.Buffer {
+	buf := &bytes.Buffer{}
+	gz := gzip.NewWriter(buf)
+	_, err := io.WriteString(gz, body)
+	assert.NoError(t, err)
+	assert.NoError(t, gz.Close())
+	return buf
+}
+
+func TestGzipHandlerMinSize(t *testing.T) {
+	tests := []struct {
+		size       int
+		code       int
+		compressed bool
+	}{
+		{0, http.StatusOK, false},
+		{15, http.StatusOK, false},
+		{16, http.StatusOK, true},
+		{4096, http.StatusOK, true},
+		{15, http.StatusCreated, false},
+		{16, http.StatusCreated, true},
+	}
+	for _, tt := range tests {
+		body := strings.Repeat("a", tt.size)
+		h := newGzipHandler(
+			http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				w.WriteHeader(tt.code)
+				// write one byte at a time to cross the threshold mid-body
+				for i := range body {
+					io.WriteString(w, body[i:i+1])
+				}
+			}), 16, 1024)
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, gzipRequest(http.MethodGet, nil))
+		assert.Equal(t, tt.code, w.Code, "%d", tt.size)
+		assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+		if !tt.compressed {
+			assert.Empty(t, w.Header().Get("Content-Encoding"), "%d", tt.size)
+			assert.Equal(t, body, w.Body.String(), "%d", tt.size)
+			continue
+		}
+		assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+		assert.Equal(t, body, gunzip(t, w.Body), "%d", tt.size)
+	}
+}
+
+func TestGzipHandlerNoBody(t *testing.T) {
+	tests := []struct {
+		code  int
+		flush bool
+	}{
+		{http.StatusNoContent, false},
+		{http.StatusNoContent, true},
+		{http.StatusNotModified, false},
+		{http.StatusNotModified, true},
+	}
+	for _, tt := range tests {
+		h := newGzipHandler(
+			http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				w.WriteHeader(tt.code)
+				if tt.flush {
+					w.(http.Flusher).Flush()
+				}
+			}), 16, 1024)
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, gzipRequest(http.MethodGet, nil))
+		assert.Equal(t, tt.code, w.Code)
+		assert.Empty(t, w.Header().Get("Content-Encoding"), "%d", tt.code)
+		assert.Zero(t, w.Body.Len(), "%d", tt.code)
+	}
+}
+
+func TestGzipHandlerNotCompressed(t *testing.T) {
+	body := strings.Repeat("a", 64)
+	h := newGzipHandler(
+		http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if req.URL.Query().Get("encoded") != "" {
+				w.Header().Set("Content-Encoding", "identity")
+			}
+			io.WriteString(w, body)
+		}), 16, 1024)
+
+	head := gzipRequest(http.MethodHead, nil)
+	noGzip := httptest.NewRequest(http.MethodGet, "/volumes", nil)
+	zeroQ := httptest.NewRequest(http.MethodGet, "/volumes", nil)
+	zeroQ.Header.Set("Accept-Encoding", "deflate, gzip;q=0")
+	encoded := gzipRequest(http.MethodGet, nil)
+	encoded.URL.RawQuery = "encoded=true"
+
+	for _, req := range []*http.Request{head, noGzip, zeroQ, encoded} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NotEqual(t, "gzip", w.Header().Get("Content-Encoding"))
+		assert.Equal(t, body, w.Body.String())
+	}
+}
+
+// TestGzipHandlerStreamed asserts that each flushed part of a streamed
+// response, such as an event or a line of the volumes stream, reaches the
+// client before the response is complete.
+func TestGzipHandlerStreamed(t *testing.T) {
+	tests := []struct {
+		contentType string
+		parts       []string
+	}{
+		{
+			"text/event-stream",
+			[]string{"data: {\"id\":1}\n", "\n", "data: {\"id\":2}\n", "\n"},
+		},
+		{
+			"application/x-ndjson",
+			[]string{"{\"id\":\"vol-1\"}\n", "{\"id\":\"vol-2\"}\n"},
+		},
+	}
+	for _, tt := range tests {
+		next := make(chan struct{})
+		s := httptest.NewServer(newGzipHandler(
+			http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				for _, p := range tt.parts {
+					io.WriteString(w, p)
+					w.(http.Flusher).Flush()
+					<-next
+				}
+			}), 1024, 1024))
+
+		req, _ := http.NewRequest(http.MethodGet, s.URL, nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		res, err := http.DefaultClient.Do(req)
+		if !assert.NoError(t, err) {
+			s.Close()
+			continue
+		}
+		assert.Equal(t, "gzip", res.Header.Get("Content-Encoding"))
+		assert.Equal(t, tt.contentType, res.Header.Get("Content-Type"))
+
+		var r *bufio.Reader
+		for i, p := range tt.parts {
+			read := make(chan string)
+			go func() {
+				if r == nil {
+					gz, err := gzip.NewReader(res.Body)
+					if err != nil {
+						close(read)
+						return
+					}
+					r = bufio.NewReader(gz)
+				}
+				line, _ := r.ReadString('\n')
+				read <- line
+			}()
+			select {
+			case line := <-read:
+				assert.Equal(t, p, line, "%s %d", tt.contentType, i)
+			case <-time.After(5 * time.Second):
+				t.Fatalf("%s: part %d was not flushed", tt.contentType, i)
+			}
+			next <- struct{}{}
+		}
+
+		rest, err := ioutil.ReadAll(r)
+		assert.NoError(t, err)
+		assert.Empty(t, rest)
+		res.Body.Close()
+		s.Close()
+	}
+}
+
+func TestGzipHandlerRequestBody(t *testing.T) {
+	h := newGzipHandler(
+		http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			assert.Empty(t, req.Header.Get("Content-Encoding"))
+			buf, err := ioutil.ReadAll(req.Body)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+				return
+			}
+			w.Write(buf)
+		}), 1024, 64)
+
+	body := strings.Repeat("a", 64)
+	req := httptest.NewRequest(
+		http.MethodPost, "/volumes", gzipBody(t, body))
+	req.Header.Set("Content-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, body, w.Body.String())
+
+	// a body that compresses well must not decompress beyond the limit
+	req = httptest.NewRequest(
+		http.MethodPost, "/volumes", gzipBody(t, strings.Repeat("a", 1<<20)))
+	req.Header.Set("Content-Encoding", "gzip")
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+
+	req = httptest.NewRequest(
+		http.MethodPost, "/volumes", strings.NewReader(body))
+	req.Header.Set("Content-Encoding", "gzip")
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -10,6 +10,7 @@ import (
 	"net/http"
 	"os"
 	"sync"
+	"sync/atomic"
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/akutz/goof"
@@ -18,6 +19,7 @@ import (
 
 	"github.com/codedellemc/libstorage/api/context"
 	"github.com/codedellemc/libstorage/api/registry"
+	"github.com/codedellemc/libstorage/api/server/httputils"
 	"github.com/codedellemc/libstorage/api/types"
 	"github.com/codedellemc/libstorage/api/utils"
 )
@@ -130,12 +132,22 @@ func (s *server) initEndpoints(ctx types.Context) error {
 			return err
 		}
 
+		var clientVerifier *utils.TLSClientVerifier
+		if tlsConfig != nil {
+			clientVerifier, err = utils.NewTLSClientVerifier(
+				s.config.Scope(endpoint), logFields, endpoint)
+			if err != nil {
+				return err
+			}
+		}
+
 		ctx.WithFields(logFields).Info("configured endpoint")
 
-		srv, err := s.newHTTPServer(proto, addr, tlsConfig)
+		srv, err := s.newHTTPServer(endpointName, proto, addr, tlsConfig)
 		if err != nil {
 			return err
 		}
+		srv.clientVerifier = clientVerifier
 
 		ctx.Info("server created")
 		s.servers = append(s.servers, srv)
@@ -172,6 +184,13 @@ func (s *server) makeHTTPHandler(
 
 		w.Header().Set(types.ServerNameHeader, s.name)
 
+		if atomic.LoadInt32(&s.draining) == 1 {
+			w.Header().Set("Connection", "close")
+			http.Error(
+				w, "server is shutting down", http.StatusServiceUnavailable)
+			return
+		}
+
 		ctx := context.WithRequestRoute(ctx, req, route)
 
 		if req.TLS != nil {
@@ -227,7 +246,8 @@ func (s *server) createMux(ctx types.Context) *mux.Router {
 }
 
 func (s *server) newHTTPServer(
-	proto, laddr string, tlsConfig *tls.Config) (*HTTPServer, error) {
+	endpointName, proto, laddr string,
+	tlsConfig *tls.Config) (*HTTPServer, error) {
 
 	var (
 		l   net.Listener
@@ -247,6 +267,7 @@ func (s *server) newHTTPServer(
 	host := fmt.Sprintf("%s://%s", proto, laddr)
 	ctx := s.ctx.WithValue(context.HostKey, host)
 	ctx = ctx.WithValue(context.TLSKey, tlsConfig != nil)
+	ctx = ctx.WithValue(context.EndpointKey, endpointName)
 
 	logger := ctx.Value(context.LoggerKey).(*log.Logger)
 	errLogger := &httpServerErrLogger{logger}
@@ -269,9 +290,27 @@ func (s *server) newHTTPServer(
 // l   net.Listener, is a TCP or Socket listener that dispatches incoming
 // request to the router.
 type HTTPServer struct {
-	srv *http.Server
-	l   net.Listener
-	ctx types.Context
+	srv            *http.Server
+	l              net.Listener
+	ctx            types.Context
+	clientVerifier *utils.TLSClientVerifier
+}
+
+// verifyClientHandler returns a handler that rejects the requests of TLS
+// clients whose certificates are revoked or not pinned before invoking h.
+func (s *HTTPServer) verifyClientHandler(h http.Handler) http.Handler {
+	if s.clientVerifier == nil {
+		return h
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if err := s.clientVerifier.Verify(req.TLS); err != nil {
+			s.ctx.WithError(err).Warn("rejected tls client")
+			httpErr := goof.NewHTTPError(err, http.StatusUnauthorized)
+			httputils.WriteJSON(w, httpErr.Status(), httpErr)
+			return
+		}
+		h.ServeHTTP(w, req)
+	})
 }
 
 // Serve starts listening for inbound requests.
@@ -5,7 +5,7 @@ import (
 	"github.com/codedellemc/libstorage/api/types"
 )
 
-func (s *server) initGlobalMiddleware() {
+func (s *server) initGlobalMiddleware() error {
 
 	s.addGlobalMiddleware(handlers.NewQueryParamsHandler())
 
@@ -18,9 +18,30 @@ func (s *server) initGlobalMiddleware() {
 
 	s.addGlobalMiddleware(handlers.NewTransactionHandler())
 	s.addGlobalMiddleware(handlers.NewErrorHandler())
+
+	if tracingHandler := handlers.NewTracingHandler(
+		s.ctx, s.config); tracingHandler != nil {
+		s.addGlobalMiddleware(tracingHandler)
+	}
+
+	authHandler, err := handlers.NewAuthHandler(s.ctx, s.config)
+	if err != nil {
+		return err
+	}
+	if authHandler != nil {
+		s.addGlobalMiddleware(authHandler)
+	}
+
+	if rateLimitHandler := handlers.NewRateLimitHandler(
+		s.ctx, s.config); rateLimitHandler != nil {
+		s.addGlobalMiddleware(rateLimitHandler)
+	}
+
 	s.addGlobalMiddleware(handlers.NewInstanceIDHandler())
 	s.addGlobalMiddleware(handlers.NewLocalDevicesHandler())
 	s.addGlobalMiddleware(handlers.NewOnRequestHandler())
+
+	return nil
 }
 
 func (s *server) initRouteMiddleware() {
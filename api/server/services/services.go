@@ -9,6 +9,8 @@ import (
 	"github.com/akutz/goof"
 
 	"github.com/codedellemc/libstorage/api/context"
+	"github.com/codedellemc/libstorage/api/server/audit"
+	"github.com/codedellemc/libstorage/api/server/events"
 	"github.com/codedellemc/libstorage/api/types"
 )
 
@@ -18,9 +20,13 @@ var (
 )
 
 type serviceContainer struct {
+	ctx             types.Context
 	config          gofig.Config
+	reloadLock      sync.Mutex
 	storageServices map[string]types.StorageService
 	taskService     *globalTaskService
+	auditSink       audit.Sink
+	eventBus        *events.Bus
 }
 
 // Init initializes the types.
@@ -50,12 +56,20 @@ func Init(ctx types.Context, config gofig.Config) error {
 }
 
 func (sc *serviceContainer) Init(ctx types.Context, config gofig.Config) error {
+	sc.ctx = ctx
 	sc.config = config
 
 	if err := sc.taskService.Init(ctx, config); err != nil {
 		return err
 	}
 
+	auditSink, err := audit.New(ctx, config)
+	if err != nil {
+		return err
+	}
+	sc.auditSink = auditSink
+	sc.eventBus = events.New(ctx, config)
+
 	if err := sc.initStorageServices(ctx); err != nil {
 		return err
 	}
@@ -86,11 +100,19 @@ func GetStorageService(
 }
 
 // StorageServices returns a channel on which all the storage services are
-// received.
+// received. Services the context's authenticated subject is not authorized to
+// access are omitted.
 func StorageServices(ctx types.Context) <-chan types.StorageService {
+	servicesByServerRWL.RLock()
+	storSvcs := getStorageServices(ctx)
+	servicesByServerRWL.RUnlock()
+
 	c := make(chan types.StorageService)
 	go func() {
-		for _, v := range getStorageServices(ctx) {
+		for _, v := range storSvcs {
+			if err := Authorize(ctx, v); err != nil {
+				continue
+			}
 			c <- v
 		}
 		close(c)
@@ -105,16 +127,38 @@ func (sc *serviceContainer) initStorageServices(ctx types.Context) error {
 	if sc.config == nil {
 		panic("sc.config is nil")
 	}
-	cfgSvcs := sc.config.Get(types.ConfigServices)
+	cfgSvcsMap, err := getServicesMap(sc.config)
+	if err != nil {
+		return err
+	}
+	ctx.WithField("count", len(cfgSvcsMap)).Debug("got services map")
+
+	for serviceName := range cfgSvcsMap {
+		serviceName = strings.ToLower(serviceName)
+		storSvc, err := newStorageService(ctx, sc.config, serviceName)
+		if err != nil {
+			return err
+		}
+		sc.storageServices[serviceName] = storSvc
+	}
+
+	return nil
+}
+
+// getServicesMap returns the map of service names to service configurations.
+// If no services are configured a single service is created for the driver
+// specified by the property libstorage.driver.
+func getServicesMap(config gofig.Config) (map[string]interface{}, error) {
+	cfgSvcs := config.Get(types.ConfigServices)
 	cfgSvcsMap, ok := cfgSvcs.(map[string]interface{})
 	if !ok {
-		driverName := sc.config.GetString("libstorage.driver")
+		driverName := config.GetString("libstorage.driver")
 		if driverName == "" {
 			err := goof.WithFields(goof.Fields{
 				"configKey": types.ConfigServices,
 				"obj":       cfgSvcs,
 			}, "invalid format")
-			return err
+			return nil, err
 		}
 
 		cfgSvcsMap = map[string]interface{}{
@@ -123,30 +167,33 @@ func (sc *serviceContainer) initStorageServices(ctx types.Context) error {
 			},
 		}
 	}
-	ctx.WithField("count", len(cfgSvcsMap)).Debug("got services map")
+	return cfgSvcsMap, nil
+}
 
-	for serviceName := range cfgSvcsMap {
-		serviceName = strings.ToLower(serviceName)
+func newStorageService(
+	ctx types.Context,
+	config gofig.Config,
+	serviceName string) (*storageService, error) {
 
-		storSvc := &storageService{name: serviceName}
+	storSvc := &storageService{name: serviceName}
 
-		ctx := ctx.WithValue(context.StorageServiceKey, storSvc)
-		ctx.Debug("processing service config")
+	ctx = ctx.WithValue(context.StorageServiceKey, storSvc)
+	ctx.Debug("processing service config")
 
-		scope := fmt.Sprintf("libstorage.server.services.%s", serviceName)
-		ctx.WithField("scope", scope).Debug(
-			"getting scoped config for service")
-		config := sc.config.Scope(scope)
+	scope := serviceScope(serviceName)
+	ctx.WithField("scope", scope).Debug(
+		"getting scoped config for service")
 
-		if err := storSvc.Init(ctx, config); err != nil {
-			return err
-		}
-
-		ctx.Info("created new service")
-		sc.storageServices[serviceName] = storSvc
+	if err := storSvc.Init(ctx, config.Scope(scope)); err != nil {
+		return nil, err
 	}
 
-	return nil
+	ctx.Info("created new service")
+	return storSvc, nil
+}
+
+func serviceScope(serviceName string) string {
+	return fmt.Sprintf("libstorage.server.services.%s", serviceName)
 }
 
 func getTaskService(ctx types.Context) *globalTaskService {
@@ -163,9 +210,55 @@ func getTaskService(ctx types.Context) *globalTaskService {
 	return servicesByServer[serverName].taskService
 }
 
-// Tasks returns a channel on which all tasks are received.
+func getEventBus(ctx types.Context) *events.Bus {
+
+	serverName, ok := context.Server(ctx)
+	if !ok {
+		panic("ctx is missing ServerName")
+	}
+
+	servicesByServerRWL.RLock()
+	defer servicesByServerRWL.RUnlock()
+
+	return servicesByServer[serverName].eventBus
+}
+
+// SubscribeEvents returns a channel on which volume lifecycle events are
+// received and a function that must be invoked to unsubscribe. If lastEventID
+// is greater than zero then the retained events that occurred after the
+// specified event are received first.
+func SubscribeEvents(
+	ctx types.Context, lastEventID int64) (<-chan *types.Event, func()) {
+	return getEventBus(ctx).Subscribe(lastEventID)
+}
+
+func getAuditSink(ctx types.Context) audit.Sink {
+
+	serverName, ok := context.Server(ctx)
+	if !ok {
+		panic("ctx is missing ServerName")
+	}
+
+	servicesByServerRWL.RLock()
+	defer servicesByServerRWL.RUnlock()
+
+	return servicesByServer[serverName].auditSink
+}
+
+// Tasks returns a channel on which all tasks are received. Tasks the
+// context's authenticated subject is not authorized to access are omitted.
 func Tasks(ctx types.Context) <-chan *types.Task {
-	return getTaskService(ctx).Tasks()
+	s := getTaskService(ctx)
+	c := make(chan *types.Task)
+	go func() {
+		for t := range s.Tasks() {
+			if s.taskAuthorized(ctx, t.ID) {
+				c <- t
+			}
+		}
+		close(c)
+	}()
+	return c
 }
 
 // TaskTrack creates a new, trackable task.
@@ -181,9 +274,32 @@ func TaskExecute(
 	return getTaskService(ctx).TaskExecute(ctx, run, schema)
 }
 
-// TaskInspect returns the task with the specified ID.
+// TaskInspect returns the task with the specified ID. A nil value is
+// returned if the context's authenticated subject is not authorized to access
+// the task.
 func TaskInspect(ctx types.Context, taskID int) *types.Task {
-	return getTaskService(ctx).TaskInspect(taskID)
+	s := getTaskService(ctx)
+	if !s.taskAuthorized(ctx, taskID) {
+		return nil
+	}
+	return s.TaskInspect(taskID)
+}
+
+// TaskQueue returns the queued and running tasks of the storage services,
+// keyed by the services' names.
+func TaskQueue(ctx types.Context) map[string][]*types.AdminTask {
+	return getTaskService(ctx).TaskQueue()
+}
+
+// TaskCancel cancels a queued task.
+func TaskCancel(ctx types.Context, taskID int) (*types.Task, error) {
+	return getTaskService(ctx).TaskCancel(taskID)
+}
+
+// TaskAsync marks the specified task as one whose result is retrieved by the
+// client after it completes.
+func TaskAsync(ctx types.Context, taskID int) {
+	getTaskService(ctx).TaskAsync(taskID)
 }
 
 // TaskWait blocks until the specified task is completed.
@@ -197,6 +313,12 @@ func TaskWaitC(ctx types.Context, taskID int) <-chan int {
 	return getTaskService(ctx).TaskWaitC(taskID)
 }
 
+// TaskDrainC returns a channel that is closed once there are no tasks queued
+// or running.
+func TaskDrainC(ctx types.Context) <-chan int {
+	return getTaskService(ctx).TaskDrainC()
+}
+
 // TaskWaitAll blocks until all the specified task are complete.
 func TaskWaitAll(ctx types.Context, taskIDs ...int) {
 	getTaskService(ctx).TaskWaitAll(taskIDs...)
@@ -0,0 +1,82 @@
+package services
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/codedellemc/libstorage/api/context"
+	"github.com/codedellemc/libstorage/api/types"
+)
+
+// auditTask emits an audit record for a completed task if the task was
+// executed on behalf of a request that modifies a storage platform's
+// resources, such as creating, removing, attaching, detaching, or
+// snapshotting a volume.
+func auditTask(t *task, duration time.Duration) {
+
+	req, ok := context.HTTPRequest(t.ctx)
+	if !ok || req.Method == http.MethodGet || req.Method == http.MethodHead {
+		return
+	}
+
+	sink := getAuditSink(t.ctx)
+	if sink == nil {
+		return
+	}
+
+	vars := mux.Vars(req)
+	r := &types.AuditRecord{
+		Time:       t.CompleteTime,
+		TaskID:     t.ID,
+		Result:     t.State,
+		VolumeID:   vars["volumeID"],
+		SnapshotID: vars["snapshotID"],
+		Duration:   int64(duration / time.Millisecond),
+	}
+
+	if t.storService != nil {
+		r.Service = t.storService.Name()
+	} else if svc, ok := context.Service(t.ctx); ok {
+		r.Service = svc.Name()
+	}
+
+	if route, ok := t.ctx.Value(context.RouteKey).(types.Route); ok {
+		r.Operation = route.GetName()
+	}
+
+	if tok, ok := context.AuthToken(t.ctx); ok {
+		r.Subject = tok.Subject
+	} else if user, ok := t.ctx.Value(context.UserKey).(string); ok {
+		r.Subject = user
+	}
+
+	if tx, ok := context.Transaction(t.ctx); ok && tx.ID != nil {
+		r.TxID = tx.ID.String()
+	}
+
+	if t.Error != nil {
+		r.Error = t.Error.Error()
+	}
+
+	// operations such as volumeCreate do not know the volume's ID until the
+	// volume exists
+	if r.VolumeID == "" {
+		switch tr := t.Result.(type) {
+		case *types.Volume:
+			r.VolumeID = tr.ID
+		case *types.Snapshot:
+			r.VolumeID = tr.VolumeID
+			if r.SnapshotID == "" {
+				r.SnapshotID = tr.ID
+			}
+		case *types.VolumeAttachResponse:
+			if tr.Volume != nil {
+				r.VolumeID = tr.Volume.ID
+			}
+		}
+	}
+
+	sink.Audit(t.ctx, r)
+}
@@ -0,0 +1,84 @@
+package services
+
+import (
+	"net/http"
+
+	"github.com/codedellemc/libstorage/api/context"
+	"github.com/codedellemc/libstorage/api/types"
+	"github.com/codedellemc/libstorage/api/utils"
+)
+
+const authAllowAll = "*"
+
+// Authorize returns an error if the context's authenticated subject is not
+// permitted to access the storage service. Subjects listed in the service's
+// libstorage.server.auth.allow property have read-write access, and subjects
+// listed in libstorage.server.auth.allowRead have read-only access. A service
+// with neither property is accessible to all authenticated subjects.
+//
+// Requests that have not been authenticated, such as when authentication is
+// disabled, are always authorized.
+func Authorize(ctx types.Context, svc types.StorageService) error {
+	tok, ok := context.AuthToken(ctx)
+	if !ok {
+		return nil
+	}
+
+	config := svc.Config()
+	allow := config.GetStringSlice(types.ConfigServerAuthAllow)
+	allowRead := config.GetStringSlice(types.ConfigServerAuthAllowRead)
+
+	if len(allow) == 0 && len(allowRead) == 0 {
+		return nil
+	}
+
+	if authContains(allow, tok.Subject) {
+		return nil
+	}
+
+	if authIsReadRequest(ctx) && authContains(allowRead, tok.Subject) {
+		return nil
+	}
+
+	return utils.NewForbiddenError(tok.Subject, svc.Name())
+}
+
+// authorizeTask returns an error if the context's authenticated subject is
+// not permitted to access the task. A task is accessible to the subject that
+// created it, or to every subject if it was created by a request that was not
+// authenticated, and only if the subject may access the task's storage
+// service.
+func authorizeTask(ctx types.Context, t *task) error {
+	tok, ok := context.AuthToken(ctx)
+	if !ok {
+		return nil
+	}
+	var service string
+	if t.storService != nil {
+		if err := Authorize(ctx, t.storService); err != nil {
+			return err
+		}
+		service = t.storService.Name()
+	}
+	if t.User != "" && t.User != tok.Subject {
+		return utils.NewForbiddenError(tok.Subject, service)
+	}
+	return nil
+}
+
+func authIsReadRequest(ctx types.Context) bool {
+	req, ok := context.HTTPRequest(ctx)
+	if !ok {
+		return false
+	}
+	return req.Method == http.MethodGet || req.Method == http.MethodHead
+}
+
+func authContains(subjects []string, subject string) bool {
+	for _, s := range subjects {
+		if s == subject || s == authAllowAll {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,54 @@
+package services
+
+import (
+	"testing"
+
+	gofigCore "github.com/akutz/gofig"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codedellemc/libstorage/api/context"
+	"github.com/codedellemc/libstorage/api/types"
+)
+
+func TestAuthorizeTask(t *testing.T) {
+	config := gofigCore.New()
+	config.Set(types.ConfigServerAuthAllow, []string{"alice", "bob"})
+	svc := &storageService{name: "ebs", config: config}
+
+	subject := func(sub string) types.Context {
+		ctx := context.Background()
+		if sub == "" {
+			return ctx
+		}
+		return ctx.WithValue(
+			context.AuthTokenKey, &types.AuthToken{Subject: sub})
+	}
+	newTask := func(user string, svc types.StorageService) *task {
+		return &task{Task: types.Task{User: user}, storService: svc}
+	}
+
+	tests := []struct {
+		subject    string
+		task       *task
+		authorized bool
+	}{
+		{"", newTask("alice", svc), true},
+		{"alice", newTask("alice", svc), true},
+		{"bob", newTask("alice", svc), false},
+		{"bob", newTask("", svc), true},
+		{"carol", newTask("", svc), false},
+		{"carol", newTask("carol", svc), false},
+		{"carol", newTask("carol", nil), true},
+		{"carol", newTask("", nil), true},
+		{"carol", newTask("alice", nil), false},
+	}
+	for i, tt := range tests {
+		err := authorizeTask(subject(tt.subject), tt.task)
+		if tt.authorized {
+			assert.NoError(t, err, "%d", i)
+			continue
+		}
+		_, ok := err.(*types.ErrForbidden)
+		assert.True(t, ok, "%d %v", i, err)
+	}
+}
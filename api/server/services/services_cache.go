@@ -0,0 +1,122 @@
+package services
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"github.com/codedellemc/libstorage/api/context"
+	"github.com/codedellemc/libstorage/api/types"
+	"github.com/codedellemc/libstorage/api/utils"
+)
+
+// initCache creates the storage service's response cache if the property
+// libstorage.server.cache.ttl is a positive duration.
+func (s *storageService) initCache(ctx types.Context) error {
+	ttl, err := time.ParseDuration(
+		s.config.GetString(types.ConfigServerCacheTTL))
+	if err != nil {
+		return err
+	}
+	if ttl <= 0 {
+		return nil
+	}
+	ctx.WithField("ttl", ttl).Debug("configured response cache")
+	s.cache = utils.NewTTLStore(ttl, true)
+	return nil
+}
+
+// CachedTaskRun returns a task function that serves the result of run from
+// the response cache of the storage service that executes the task. The
+// result is cached by the HTTP request's path, query string, and instance
+// headers, so run must not depend on any other part of the request.
+//
+// The task function is returned unaltered if the request is not a read. The
+// result of run is not cached if the service's cache is disabled or if run
+// returns an error.
+func CachedTaskRun(
+	ctx types.Context,
+	run types.StorageTaskRunFunc) types.StorageTaskRunFunc {
+
+	req, ok := context.HTTPRequest(ctx)
+	if !ok || (req.Method != http.MethodGet && req.Method != http.MethodHead) {
+		return run
+	}
+
+	return func(
+		ctx types.Context,
+		svc types.StorageService) (interface{}, error) {
+
+		s, ok := svc.(*storageService)
+		if !ok || s.cache == nil {
+			return run(ctx, svc)
+		}
+
+		key := cacheKey(svc, req)
+		if result := s.cache.Get(key); result != nil {
+			ctx.Debug("serving cached result")
+			return result, nil
+		}
+
+		result, err := run(ctx, svc)
+		if err != nil || result == nil {
+			return result, err
+		}
+		s.cache.Set(key, result)
+		return result, nil
+	}
+}
+
+// invalidateCacheTask returns a task function that clears the storage
+// service's response cache both before and after the task executes if the
+// context's HTTP request may modify the service's volumes or snapshots.
+func (s *storageService) invalidateCacheTask(
+	ctx types.Context,
+	run types.StorageTaskRunFunc) types.StorageTaskRunFunc {
+
+	if s.cache == nil {
+		return run
+	}
+
+	req, ok := context.HTTPRequest(ctx)
+	if !ok || req.Method == http.MethodGet || req.Method == http.MethodHead {
+		return run
+	}
+
+	return func(
+		ctx types.Context,
+		svc types.StorageService) (interface{}, error) {
+
+		s.invalidateCache(ctx)
+		defer s.invalidateCache(ctx)
+		return run(ctx, svc)
+	}
+}
+
+func (s *storageService) invalidateCache(ctx types.Context) {
+	for _, k := range s.cache.Keys() {
+		s.cache.Delete(k)
+	}
+	ctx.Debug("invalidated response cache")
+}
+
+// cacheKey returns the key for a cached result. The key is a digest since the
+// cache's keys are case-insensitive, but volume and instance IDs may not be.
+func cacheKey(svc types.StorageService, req *http.Request) string {
+	h := sha1.New()
+	write := func(v string) {
+		h.Write([]byte(v))
+		h.Write([]byte{0})
+	}
+	write(svc.Name())
+	write(req.URL.Path)
+	write(req.URL.RawQuery)
+	for _, v := range req.Header[types.InstanceIDHeader] {
+		write(v)
+	}
+	for _, v := range req.Header[types.LocalDevicesHeader] {
+		write(v)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
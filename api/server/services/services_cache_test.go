@@ -0,0 +1,154 @@
+package services
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	gofigCore "github.com/akutz/gofig"
+	"github.com/akutz/goof"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codedellemc/libstorage/api/context"
+	"github.com/codedellemc/libstorage/api/types"
+	"github.com/codedellemc/libstorage/api/utils"
+)
+
+func newTestCacheCtx(
+	t *testing.T, method, url, instanceID string) types.Context {
+
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if instanceID != "" {
+		req.Header.Set(types.InstanceIDHeader, instanceID)
+	}
+	return context.Background().WithValue(context.HTTPRequestKey, req)
+}
+
+func TestInitCache(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		ttl     string
+		enabled bool
+		err     bool
+	}{
+		{"1m", true, false},
+		{"0s", false, false},
+		{"-1s", false, false},
+		{"soon", false, true},
+	}
+	for _, tt := range tests {
+		config := gofigCore.New()
+		config.Set(types.ConfigServerCacheTTL, tt.ttl)
+		s := &storageService{name: "ebs", config: config}
+		err := s.initCache(ctx)
+		if tt.err {
+			assert.Error(t, err, tt.ttl)
+			continue
+		}
+		assert.NoError(t, err, tt.ttl)
+		assert.Equal(t, tt.enabled, s.cache != nil, tt.ttl)
+	}
+}
+
+func TestCachedTaskRun(t *testing.T) {
+	s := &storageService{
+		name: "ebs", cache: utils.NewTTLStore(time.Hour, true)}
+
+	runs := 0
+	run := func(
+		ctx types.Context, svc types.StorageService) (interface{}, error) {
+		runs++
+		return runs, nil
+	}
+	exec := func(ctx types.Context) interface{} {
+		r, err := CachedTaskRun(ctx, run)(ctx, s)
+		assert.NoError(t, err)
+		return r
+	}
+
+	get := newTestCacheCtx(t, "GET", "/volumes/ebs", "")
+	assert.Equal(t, 1, exec(get))
+	assert.Equal(t, 1, exec(get))
+	assert.Equal(t, 1, exec(newTestCacheCtx(t, "HEAD", "/volumes/ebs", "")))
+
+	// the path, the query, and the instance ID are part of the key
+	assert.Equal(t, 2, exec(newTestCacheCtx(t, "GET", "/volumes/ebs/v", "")))
+	assert.Equal(t, 3, exec(
+		newTestCacheCtx(t, "GET", "/volumes/ebs?attachments", "")))
+	assert.Equal(t, 4, exec(
+		newTestCacheCtx(t, "GET", "/volumes/ebs", "iid-1")))
+	assert.Equal(t, 4, exec(
+		newTestCacheCtx(t, "GET", "/volumes/ebs", "iid-1")))
+
+	// mutations and requests outside of an http request are not cached
+	post := newTestCacheCtx(t, "POST", "/volumes/ebs", "")
+	assert.Equal(t, 5, exec(post))
+	assert.Equal(t, 6, exec(post))
+	assert.Equal(t, 7, exec(context.Background()))
+
+	// a service without a cache is not cached
+	other := &storageService{name: "efs"}
+	r, err := CachedTaskRun(get, run)(get, other)
+	assert.NoError(t, err)
+	assert.Equal(t, 8, r)
+	r, _ = CachedTaskRun(get, run)(get, other)
+	assert.Equal(t, 9, r)
+}
+
+func TestCachedTaskRunError(t *testing.T) {
+	s := &storageService{
+		name: "ebs", cache: utils.NewTTLStore(time.Hour, true)}
+	ctx := newTestCacheCtx(t, "GET", "/volumes/ebs/vol-1", "")
+
+	runs := 0
+	fail := func(
+		ctx types.Context, svc types.StorageService) (interface{}, error) {
+		runs++
+		return nil, goof.New("failed")
+	}
+	for i := 0; i < 2; i++ {
+		_, err := CachedTaskRun(ctx, fail)(ctx, s)
+		assert.EqualError(t, err, "failed")
+	}
+	assert.Equal(t, 2, runs)
+	assert.Empty(t, s.cache.Keys())
+}
+
+func TestInvalidateCacheTask(t *testing.T) {
+	s := &storageService{
+		name: "ebs", cache: utils.NewTTLStore(time.Hour, true)}
+
+	get := newTestCacheCtx(t, "GET", "/volumes/ebs", "")
+	cached := func(
+		ctx types.Context, svc types.StorageService) (interface{}, error) {
+		return "cached", nil
+	}
+	CachedTaskRun(get, cached)(get, s)
+	assert.Len(t, s.cache.Keys(), 1)
+
+	// reads do not invalidate the cache
+	_, err := s.invalidateCacheTask(get, cached)(get, s)
+	assert.NoError(t, err)
+	assert.Len(t, s.cache.Keys(), 1)
+
+	// a mutation invalidates the cache before and after it executes, even
+	// if it fails
+	post := newTestCacheCtx(t, "POST", "/volumes/ebs", "")
+	_, err = s.invalidateCacheTask(post, func(
+		ctx types.Context, svc types.StorageService) (interface{}, error) {
+		assert.Empty(t, s.cache.Keys())
+		CachedTaskRun(get, cached)(get, s)
+		return nil, goof.New("failed")
+	})(post, s)
+	assert.EqualError(t, err, "failed")
+	assert.Empty(t, s.cache.Keys())
+
+	// a service without a cache is not affected
+	other := &storageService{name: "efs"}
+	r, err := other.invalidateCacheTask(post, cached)(post, other)
+	assert.NoError(t, err)
+	assert.Equal(t, "cached", r)
+}
@@ -0,0 +1,123 @@
+package services
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/codedellemc/libstorage/api/context"
+	"github.com/codedellemc/libstorage/api/types"
+)
+
+// publishTaskEvents publishes the volume lifecycle events that result from a
+// task that completed successfully. The events are determined by the name of
+// the route that executed the task.
+func publishTaskEvents(t *task) {
+
+	if t.Error != nil {
+		return
+	}
+
+	req, ok := context.HTTPRequest(t.ctx)
+	if !ok || req.Method == http.MethodGet || req.Method == http.MethodHead {
+		return
+	}
+
+	route, ok := t.ctx.Value(context.RouteKey).(types.Route)
+	if !ok {
+		return
+	}
+
+	var serviceName string
+	if t.storService != nil {
+		serviceName = t.storService.Name()
+	} else if svc, ok := context.Service(t.ctx); ok {
+		serviceName = svc.Name()
+	}
+
+	vars := mux.Vars(req)
+	newEvent := func(
+		eventType types.EventType, service string) *types.Event {
+		return &types.Event{
+			Type:       eventType,
+			Time:       t.CompleteTime,
+			Service:    service,
+			VolumeID:   vars["volumeID"],
+			SnapshotID: vars["snapshotID"],
+		}
+	}
+
+	var events []*types.Event
+
+	switch route.GetName() {
+
+	case "volumeCreate", "volumeCopy", "snapshotCreate":
+		if v, ok := t.Result.(*types.Volume); ok {
+			e := newEvent(types.EventVolumeCreated, serviceName)
+			e.VolumeID = v.ID
+			e.Volume = v
+			events = append(events, e)
+		}
+
+	case "volumeRemove":
+		events = append(events, newEvent(types.EventVolumeRemoved, serviceName))
+
+	case "volumeAttach":
+		if r, ok := t.Result.(*types.VolumeAttachResponse); ok {
+			e := newEvent(types.EventVolumeAttached, serviceName)
+			e.Volume = r.Volume
+			events = append(events, e)
+		}
+
+	case "volumeDetach":
+		e := newEvent(types.EventVolumeDetached, serviceName)
+		if v, ok := t.Result.(*types.Volume); ok {
+			e.Volume = v
+		}
+		events = append(events, e)
+
+	case "volumesDetachForService":
+		if vm, ok := t.Result.(types.VolumeMap); ok {
+			for _, v := range vm {
+				e := newEvent(types.EventVolumeDetached, serviceName)
+				e.VolumeID = v.ID
+				e.Volume = v
+				events = append(events, e)
+			}
+		}
+
+	case "volumesDetachAll":
+		if svm, ok := t.Result.(types.ServiceVolumeMap); ok {
+			for service, vm := range svm {
+				for _, v := range vm {
+					e := newEvent(types.EventVolumeDetached, service)
+					e.VolumeID = v.ID
+					e.Volume = v
+					events = append(events, e)
+				}
+			}
+		}
+
+	case "volumeSnapshot", "snapshotCopy":
+		if s, ok := t.Result.(*types.Snapshot); ok {
+			e := newEvent(types.EventSnapshotCreated, serviceName)
+			e.VolumeID = s.VolumeID
+			e.SnapshotID = s.ID
+			e.Snapshot = s
+			events = append(events, e)
+		}
+
+	case "snapshotRemove":
+		events = append(
+			events, newEvent(types.EventSnapshotRemoved, serviceName))
+	}
+
+	if len(events) == 0 {
+		return
+	}
+
+	bus := getEventBus(t.ctx)
+	for _, e := range events {
+		bus.Publish(t.ctx, e)
+	}
+}
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"net/http"
+	"testing"
+
+	gofigCore "github.com/akutz/gofig"
+	"github.com/akutz/goof"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codedellemc/libstorage/api/context"
+	"github.com/codedellemc/libstorage/api/server/events"
+	"github.com/codedellemc/libstorage/api/types"
+)
+
+const testEventsServerName = "services-events-test"
+
+// testRoute is a route that only has a name.
+type testRoute struct {
+	types.Route
+	name string
+}
+
+func (r *testRoute) GetName() string {
+	return r.name
+}
+
+func newTestEventBus(t *testing.T) (*events.Bus, types.Context) {
+	ctx := context.Background().WithValue(
+		context.ServerKey, testEventsServerName)
+	bus := events.New(ctx, gofigCore.New())
+	servicesByServerRWL.Lock()
+	servicesByServer[testEventsServerName] = &serviceContainer{
+		eventBus: bus,
+	}
+	servicesByServerRWL.Unlock()
+	return bus, ctx
+}
+
+func newTestEventTask(
+	t *testing.T,
+	ctx types.Context,
+	method, route string,
+	result interface{},
+	err error) *task {
+
+	req, rerr := http.NewRequest(method, "/volumes/ebs", nil)
+	if rerr != nil {
+		t.Fatal(rerr)
+	}
+	ctx = ctx.WithValue(context.HTTPRequestKey, req)
+	ctx = ctx.WithValue(context.RouteKey, &testRoute{name: route})
+	return &task{
+		Task:        types.Task{Result: result, Error: err, CompleteTime: 1},
+		ctx:         ctx,
+		storService: &storageService{name: "ebs"},
+	}
+}
+
+func TestPublishTaskEvents(t *testing.T) {
+	bus, ctx := newTestEventBus(t)
+	c, unsub := bus.Subscribe(0)
+	defer unsub()
+
+	vol := &types.Volume{ID: "vol-1"}
+	tests := []struct {
+		method string
+		route  string
+		result interface{}
+		err    error
+		events []types.EventType
+	}{
+		{"POST", "volumeCreate", vol, nil,
+			[]types.EventType{types.EventVolumeCreated}},
+		{"DELETE", "volumeRemove", nil, nil,
+			[]types.EventType{types.EventVolumeRemoved}},
+		{"POST", "volumeDetach", vol, nil,
+			[]types.EventType{types.EventVolumeDetached}},
+		{"POST", "volumeSnapshot", &types.Snapshot{ID: "snap-1"}, nil,
+			[]types.EventType{types.EventSnapshotCreated}},
+		{"POST", "volumesDetachAll", types.ServiceVolumeMap{
+			"ebs": types.VolumeMap{"vol-1": vol},
+			"efs": types.VolumeMap{"vol-2": {ID: "vol-2"}},
+		}, nil, []types.EventType{
+			types.EventVolumeDetached, types.EventVolumeDetached}},
+
+		// failed tasks, inspections, unknown routes, and unexpected
+		// results publish no events
+		{"POST", "volumeCreate", nil, goof.New("failed"), nil},
+		{"GET", "volumeInspect", vol, nil, nil},
+		{"POST", "volumeCreate", "not a volume", nil, nil},
+		{"POST", "volumeExpand", vol, nil, nil},
+	}
+	for i, tt := range tests {
+		publishTaskEvents(
+			newTestEventTask(t, ctx, tt.method, tt.route, tt.result, tt.err))
+		for _, et := range tt.events {
+			if assert.NotEmpty(t, c, "%d", i) {
+				e := <-c
+				assert.Equal(t, et, e.Type, "%d", i)
+				assert.Equal(t, int64(1), e.Time, "%d", i)
+			}
+		}
+		assert.Empty(t, c, "%d", i)
+	}
+
+	// the service of each detached volume is the service it belongs to
+	publishTaskEvents(newTestEventTask(t, ctx, "POST", "volumesDetachAll",
+		types.ServiceVolumeMap{"efs": types.VolumeMap{"vol-2": {ID: "vol-2"}}},
+		nil))
+	e := <-c
+	assert.Equal(t, "efs", e.Service)
+	assert.Equal(t, "vol-2", e.VolumeID)
+
+	publishTaskEvents(
+		newTestEventTask(t, ctx, "POST", "volumeCreate", vol, nil))
+	e = <-c
+	assert.Equal(t, "ebs", e.Service)
+	assert.Equal(t, "vol-1", e.VolumeID)
+	assert.Equal(t, vol, e.Volume)
+}
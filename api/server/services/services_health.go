@@ -0,0 +1,195 @@
+package services
+
+import (
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/akutz/goof"
+
+	"github.com/codedellemc/libstorage/api/context"
+	"github.com/codedellemc/libstorage/api/types"
+	"github.com/codedellemc/libstorage/api/utils"
+)
+
+// healthMonitor periodically checks the health of a storage service and
+// retains the result of the most recent check.
+type healthMonitor struct {
+	sync.RWMutex
+	ctx      types.Context
+	svc      *storageService
+	interval time.Duration
+	timeout  time.Duration
+	last     *types.ServiceHealth
+	probing  bool
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// initHealth creates the storage service's health monitor. If
+// libstorage.server.health.interval is greater than zero the monitor checks
+// the service's health in the background at that interval. Otherwise, which
+// is the default, the service's health is checked only when it is requested.
+func (s *storageService) initHealth(ctx types.Context) error {
+	interval, err := time.ParseDuration(
+		s.config.GetString(types.ConfigServerHealthInterval))
+	if err != nil {
+		return err
+	}
+	timeout, err := time.ParseDuration(
+		s.config.GetString(types.ConfigServerHealthTimeout))
+	if err != nil {
+		return err
+	}
+
+	s.health = &healthMonitor{
+		ctx:      ctx.WithValue(context.DriverKey, s.driver),
+		svc:      s,
+		interval: interval,
+		timeout:  timeout,
+		last: &types.ServiceHealth{
+			Name:   s.name,
+			Status: types.HealthStatusUnknown,
+		},
+		stop: make(chan struct{}),
+	}
+
+	if interval > 0 {
+		ctx.WithField("interval", interval).Debug(
+			"configured health monitor")
+		go s.health.run()
+	}
+	return nil
+}
+
+// ServiceHealth returns the result of the storage service's most recent
+// health check.
+func ServiceHealth(svc types.StorageService) *types.ServiceHealth {
+	s, ok := svc.(*storageService)
+	if !ok || s.health == nil {
+		return &types.ServiceHealth{
+			Name:   svc.Name(),
+			Status: types.HealthStatusUnknown,
+		}
+	}
+	return s.health.Health()
+}
+
+// Health returns a copy of the result of the most recent health check. The
+// service is checked first if the monitor does not run in the background.
+func (m *healthMonitor) Health() *types.ServiceHealth {
+	if m.interval <= 0 {
+		m.check()
+	}
+	m.RLock()
+	defer m.RUnlock()
+	h := *m.last
+	return &h
+}
+
+func (m *healthMonitor) close() {
+	m.stopOnce.Do(func() { close(m.stop) })
+}
+
+func (m *healthMonitor) run() {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	for {
+		m.check()
+		select {
+		case <-ticker.C:
+		case <-m.stop:
+			return
+		}
+	}
+}
+
+// check probes the service and records the result. The probe's context is
+// cancelled once the timeout elapses so that a driver that honors it
+// returns. A check is skipped while the probe of a previous check that timed
+// out has yet to return so that a storage platform that stops responding
+// does not accumulate probes.
+func (m *healthMonitor) check() {
+	m.Lock()
+	if m.probing {
+		m.Unlock()
+		m.ctx.Debug("skipping health check; previous probe still running")
+		return
+	}
+	m.probing = true
+	m.Unlock()
+
+	start := time.Now()
+
+	ctx := m.ctx
+	if m.timeout > 0 {
+		var cancel func()
+		ctx, cancel = context.WithTimeout(m.ctx, m.timeout)
+		defer cancel()
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		err := m.probe(ctx)
+		m.Lock()
+		m.probing = false
+		m.Unlock()
+		errs <- err
+	}()
+
+	var err error
+	select {
+	case err = <-errs:
+	case <-ctx.Done():
+		err = goof.WithField(
+			"timeout", m.timeout, "health check timed out")
+	}
+
+	h := &types.ServiceHealth{
+		Name:      m.svc.name,
+		Status:    types.HealthStatusHealthy,
+		CheckedAt: start.Unix(),
+		Latency:   int64(time.Since(start) / time.Millisecond),
+	}
+	if err != nil {
+		h.Status = types.HealthStatusDegraded
+		h.Error = err.Error()
+	}
+
+	m.Lock()
+	prev := m.last.Status
+	m.last = h
+	m.Unlock()
+
+	if prev != h.Status {
+		lf := log.Fields{"status": h.Status}
+		if err != nil {
+			m.ctx.WithError(err).WithFields(lf).Warn("service health changed")
+		} else {
+			m.ctx.WithFields(lf).Info("service health changed")
+		}
+	}
+}
+
+// probe logs into the storage platform and invokes the driver's health
+// check. Drivers that do not provide a health check are probed by listing a
+// single volume.
+func (m *healthMonitor) probe(ctx types.Context) error {
+	ctx, err := context.WithStorageSession(ctx)
+	if err != nil {
+		return err
+	}
+
+	d := m.svc.driver
+	if hc, ok := d.(types.StorageDriverWithHealthCheck); ok {
+		if err := hc.HealthCheck(ctx); err != types.ErrNotImplemented {
+			return err
+		}
+	}
+
+	_, err = d.Volumes(ctx, &types.VolumesOpts{
+		Pagination: &types.VolumesPagination{Limit: 1},
+		Opts:       utils.NewStore(),
+	})
+	return err
+}
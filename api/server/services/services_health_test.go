@@ -0,0 +1,195 @@
+package services
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	gofigCore "github.com/akutz/gofig"
+	"github.com/akutz/goof"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codedellemc/libstorage/api/context"
+	"github.com/codedellemc/libstorage/api/types"
+)
+
+// testHealthDriver is a storage driver whose volumes are listed with an
+// error, if set, and whose listing blocks until it is released, if set.
+// The blocked listing does not honor its context's cancellation.
+type testHealthDriver struct {
+	types.StorageDriver
+	sync.Mutex
+	err     error
+	release chan struct{}
+	calls   int
+}
+
+func (d *testHealthDriver) Name() string {
+	return "healthtest"
+}
+
+func (d *testHealthDriver) Volumes(
+	ctx types.Context,
+	opts *types.VolumesOpts) ([]*types.Volume, error) {
+
+	d.Lock()
+	d.calls++
+	err, release := d.err, d.release
+	d.Unlock()
+	if release != nil {
+		<-release
+	}
+	return nil, err
+}
+
+func (d *testHealthDriver) callCount() int {
+	d.Lock()
+	defer d.Unlock()
+	return d.calls
+}
+
+// testHealthCheckDriver is a storage driver with a health check.
+type testHealthCheckDriver struct {
+	testHealthDriver
+	healthErr error
+}
+
+func (d *testHealthCheckDriver) HealthCheck(ctx types.Context) error {
+	return d.healthErr
+}
+
+func newTestHealthService(
+	t *testing.T,
+	d types.StorageDriver,
+	interval, timeout string) *storageService {
+
+	config := gofigCore.New()
+	config.Set(types.ConfigServerHealthInterval, interval)
+	config.Set(types.ConfigServerHealthTimeout, timeout)
+	s := &storageService{name: "ebs", driver: d, config: config}
+	if err := s.initHealth(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestInitHealthError(t *testing.T) {
+	for _, tt := range [][]string{{"soon", "10s"}, {"0s", "soon"}} {
+		config := gofigCore.New()
+		config.Set(types.ConfigServerHealthInterval, tt[0])
+		config.Set(types.ConfigServerHealthTimeout, tt[1])
+		s := &storageService{name: "ebs", config: config}
+		assert.Error(t, s.initHealth(context.Background()), "%v", tt)
+	}
+}
+
+// TestServiceHealth asserts that a service whose monitor does not run in the
+// background is checked when its health is requested.
+func TestServiceHealth(t *testing.T) {
+	d := &testHealthDriver{}
+	s := newTestHealthService(t, d, "0s", "10s")
+	defer s.close()
+
+	h := ServiceHealth(s)
+	assert.Equal(t, "ebs", h.Name)
+	assert.Equal(t, types.HealthStatusHealthy, h.Status)
+	assert.Empty(t, h.Error)
+	assert.NotZero(t, h.CheckedAt)
+	assert.Equal(t, 1, d.callCount())
+
+	d.err = goof.New("unauthorized")
+	h = ServiceHealth(s)
+	assert.Equal(t, types.HealthStatusDegraded, h.Status)
+	assert.Equal(t, "unauthorized", h.Error)
+	assert.Equal(t, 2, d.callCount())
+
+	// the health of a service without a monitor is unknown
+	h = ServiceHealth(&storageService{name: "efs"})
+	assert.Equal(t, "efs", h.Name)
+	assert.Equal(t, types.HealthStatusUnknown, h.Status)
+}
+
+// TestServiceHealthCheck asserts that a driver's health check is preferred
+// to listing its volumes unless it is not implemented.
+func TestServiceHealthCheck(t *testing.T) {
+	d := &testHealthCheckDriver{}
+	s := newTestHealthService(t, d, "0s", "10s")
+	defer s.close()
+
+	assert.Equal(t, types.HealthStatusHealthy, ServiceHealth(s).Status)
+	assert.Equal(t, 0, d.callCount())
+
+	d.healthErr = goof.New("expired credentials")
+	h := ServiceHealth(s)
+	assert.Equal(t, types.HealthStatusDegraded, h.Status)
+	assert.Equal(t, "expired credentials", h.Error)
+	assert.Equal(t, 0, d.callCount())
+
+	d.healthErr = types.ErrNotImplemented
+	d.err = goof.New("unreachable")
+	h = ServiceHealth(s)
+	assert.Equal(t, "unreachable", h.Error)
+	assert.Equal(t, 1, d.callCount())
+}
+
+// TestServiceHealthTimeout asserts that a probe that does not return in time
+// degrades the service, and that no further probes are started until it
+// returns.
+func TestServiceHealthTimeout(t *testing.T) {
+	d := &testHealthDriver{release: make(chan struct{})}
+	s := newTestHealthService(t, d, "0s", "20ms")
+	defer s.close()
+
+	h := ServiceHealth(s)
+	assert.Equal(t, types.HealthStatusDegraded, h.Status)
+	assert.Contains(t, h.Error, "health check timed out")
+
+	assert.Equal(t, h, ServiceHealth(s))
+	assert.Equal(t, 1, d.callCount())
+
+	d.Lock()
+	close(d.release)
+	d.release = nil
+	d.Unlock()
+	for i := 0; ; i++ {
+		s.health.RLock()
+		probing := s.health.probing
+		s.health.RUnlock()
+		if !probing {
+			break
+		}
+		if i == 100 {
+			t.Fatal("probe did not return")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	assert.Equal(t, types.HealthStatusHealthy, ServiceHealth(s).Status)
+	assert.Equal(t, 2, d.callCount())
+}
+
+// TestServiceHealthBackground asserts that a monitor with an interval checks
+// its service in the background until it is closed.
+func TestServiceHealthBackground(t *testing.T) {
+	d := &testHealthDriver{}
+	s := newTestHealthService(t, d, "10ms", "10s")
+
+	for i := 0; d.callCount() < 3; i++ {
+		if i == 100 {
+			t.Fatal("service was not checked")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(t, types.HealthStatusHealthy, ServiceHealth(s).Status)
+
+	// a check may be in progress when the monitor is closed
+	s.close()
+	time.Sleep(20 * time.Millisecond)
+	calls := d.callCount()
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, calls, d.callCount())
+
+	// requesting the health does not check the service
+	ServiceHealth(s)
+	assert.Equal(t, calls, d.callCount())
+}
@@ -0,0 +1,176 @@
+package services
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+
+	"github.com/codedellemc/libstorage/api/context"
+	"github.com/codedellemc/libstorage/api/types"
+	"github.com/codedellemc/libstorage/api/utils"
+)
+
+// heartbeats records the last time the server received a request from each
+// instance, keyed by the instance's storage driver and ID.
+var heartbeats = &heartbeatRegistry{seen: map[string]*heartbeat{}}
+
+type heartbeatRegistry struct {
+	sync.RWMutex
+	seen map[string]*heartbeat
+}
+
+type heartbeat struct {
+	time time.Time
+
+	// subject is the subject of the bearer token with which the instance
+	// sent its first heartbeat. It is empty if the server does not require
+	// authentication.
+	subject string
+}
+
+func heartbeatKey(driverName, instanceID string) string {
+	return strings.ToLower(driverName) + ":" + instanceID
+}
+
+// Heartbeat records that the server received a request from the instance.
+// Every request that includes an instance ID header is a heartbeat.
+//
+// The instance ID header is asserted by the client, so an instance is bound
+// to the subject of the bearer token with which it sent its first heartbeat,
+// and requests that include its ID but are authenticated as another subject
+// do not keep it alive.
+func Heartbeat(ctx types.Context, driverName string, iid *types.InstanceID) {
+	if iid == nil || iid.ID == "" {
+		return
+	}
+
+	var subject string
+	if tok, ok := context.AuthToken(ctx); ok {
+		subject = tok.Subject
+	}
+
+	key := heartbeatKey(driverName, iid.ID)
+
+	heartbeats.Lock()
+	defer heartbeats.Unlock()
+
+	hb, ok := heartbeats.seen[key]
+	if !ok {
+		heartbeats.seen[key] = &heartbeat{time: time.Now(), subject: subject}
+		return
+	}
+	if hb.subject != subject {
+		ctx.WithFields(log.Fields{
+			"instanceID": iid.ID,
+			"subject":    subject,
+		}).Warn("ignoring heartbeat of instance bound to another subject")
+		return
+	}
+	hb.time = time.Now()
+}
+
+// LastHeartbeat returns the last time the server received a request from the
+// instance. A false value is returned if the server has not received a
+// request from the instance.
+func LastHeartbeat(driverName, instanceID string) (time.Time, bool) {
+	heartbeats.RLock()
+	defer heartbeats.RUnlock()
+	hb, ok := heartbeats.seen[heartbeatKey(driverName, instanceID)]
+	if !ok {
+		return time.Time{}, false
+	}
+	return hb.time, true
+}
+
+// attachPreemption is the storage service's configuration for forcibly
+// attaching volumes that are attached to instances that are no longer alive.
+type attachPreemption struct {
+	timeout time.Duration
+	since   time.Time
+}
+
+// initPreempt enables the preemption of volume attachments if the property
+// libstorage.server.volume.attach.preempt is true. An instance is considered
+// dead once the server has not received a request from it for
+// libstorage.server.heartbeat.timeout.
+func (s *storageService) initPreempt(ctx types.Context) error {
+	if !s.config.GetBool(types.ConfigServerVolumeAttachPreempt) {
+		return nil
+	}
+	timeout, err := time.ParseDuration(
+		s.config.GetString(types.ConfigServerHeartbeatTimeout))
+	if err != nil {
+		return err
+	}
+	ctx.WithField("timeout", timeout).Debug("configured attach preemption")
+	s.preempt = &attachPreemption{timeout: timeout, since: time.Now()}
+	return nil
+}
+
+// isDead returns a flag indicating whether the instance has not sent a
+// heartbeat within the timeout. An instance from which no heartbeat was ever
+// received is not considered dead until the service has been running for
+// longer than the timeout.
+func (p *attachPreemption) isDead(driverName, instanceID string) bool {
+	last, ok := LastHeartbeat(driverName, instanceID)
+	if !ok {
+		last = p.since
+	}
+	return time.Since(last) > p.timeout
+}
+
+// PreemptVolumeAttach forces the attachment of a volume if the storage
+// service preempts attachments and the volume is only attached to instances
+// other than the one in the context that are dead. Forcing the attachment
+// detaches the volume from the dead instances so that block volumes may be
+// failed over when a node dies.
+func PreemptVolumeAttach(
+	ctx types.Context,
+	svc types.StorageService,
+	volumeID string,
+	opts *types.VolumeAttachOpts) error {
+
+	s, ok := svc.(*storageService)
+	if !ok || s.preempt == nil || opts.Force {
+		return nil
+	}
+
+	iid, ok := context.InstanceID(ctx)
+	if !ok {
+		return nil
+	}
+
+	vol, err := s.driver.VolumeInspect(
+		ctx, volumeID, &types.VolumeInspectOpts{
+			Attachments: types.VolumeAttachmentsTrue,
+			Opts:        utils.NewStore(),
+		})
+	if err != nil {
+		return err
+	}
+
+	driverName := s.driver.Name()
+	var dead []string
+	for _, att := range vol.Attachments {
+		if att.InstanceID == nil || att.InstanceID.ID == iid.ID {
+			continue
+		}
+		if !s.preempt.isDead(driverName, att.InstanceID.ID) {
+			return nil
+		}
+		dead = append(dead, att.InstanceID.ID)
+	}
+
+	if len(dead) == 0 {
+		return nil
+	}
+
+	ctx.WithFields(log.Fields{
+		"volumeID":      volumeID,
+		"deadInstances": dead,
+	}).Warn("preempting volume attachment")
+	opts.Force = true
+	return nil
+}
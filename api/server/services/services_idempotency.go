@@ -0,0 +1,190 @@
+package services
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/codedellemc/libstorage/api/context"
+	"github.com/codedellemc/libstorage/api/types"
+	"github.com/codedellemc/libstorage/api/utils"
+)
+
+// idempotencyStore retains the results of the requests that modify a storage
+// service's resources by the requests' idempotency keys.
+type idempotencyStore struct {
+	sync.Mutex
+	results  types.Store
+	inFlight map[string]string
+}
+
+// idempotentResult is a retained result and the fingerprint of the request
+// that produced it. A result is wrapped since the result of a request such as
+// a volume removal is nil.
+type idempotentResult struct {
+	fingerprint string
+	result      interface{}
+}
+
+// initIdempotency creates the storage service's idempotency store if the
+// property libstorage.server.idempotency.ttl is a positive duration.
+func (s *storageService) initIdempotency(ctx types.Context) error {
+	ttl, err := time.ParseDuration(
+		s.config.GetString(types.ConfigServerIdempotencyTTL))
+	if err != nil {
+		return err
+	}
+	if ttl <= 0 {
+		return nil
+	}
+	ctx.WithField("ttl", ttl).Debug("configured idempotency store")
+	s.idempotency = &idempotencyStore{
+		results:  utils.NewTTLStore(ttl, true),
+		inFlight: map[string]string{},
+	}
+	return nil
+}
+
+// idempotentTask returns a task function that returns the original result of
+// a request that modifies the storage service's resources if the request is
+// repeated with the same idempotency key. Only successful results are
+// retained, so a request that failed is executed again when it is repeated.
+//
+// A repeated request is rejected with an ErrInProgress error while the
+// original request is executing. A request that reuses a key with a different
+// method, path, query, or payload is rejected with an ErrIdempotencyKeyReused
+// error.
+func (s *storageService) idempotentTask(
+	ctx types.Context,
+	run types.StorageTaskRunFunc) types.StorageTaskRunFunc {
+
+	if s.idempotency == nil {
+		return run
+	}
+
+	req, ok := context.HTTPRequest(ctx)
+	if !ok || req.Method == http.MethodGet || req.Method == http.MethodHead {
+		return run
+	}
+
+	token := req.Header.Get(types.IdempotencyKeyHeader)
+	if token == "" {
+		return run
+	}
+
+	fp, err := idempotencyFingerprint(ctx, req)
+	if err != nil {
+		ctx.WithError(err).Warn("error fingerprinting idempotent request")
+		return run
+	}
+	key := idempotencyKey(s, token)
+
+	return func(
+		ctx types.Context,
+		svc types.StorageService) (interface{}, error) {
+
+		r, err := s.idempotency.begin(key, fp)
+		if err != nil {
+			ctx.WithField("idempotencyKey", token).WithError(err).Warn(
+				"rejected request with idempotency key")
+			return nil, err
+		}
+		if r != nil {
+			ctx.WithField("idempotencyKey", token).Debug(
+				"serving original result of repeated request")
+			return r.result, nil
+		}
+
+		// the key is released even if the request panics so that the
+		// request may be repeated
+		defer s.idempotency.release(key)
+
+		result, err := run(ctx, svc)
+		if err == nil {
+			s.idempotency.retain(key, fp, result)
+		}
+		return result, err
+	}
+}
+
+// begin returns the retained result for the key or, if there is no retained
+// result, marks the key's request as in progress. The key is rejected if it
+// was used for a request with a different fingerprint.
+func (st *idempotencyStore) begin(
+	key, fingerprint string) (*idempotentResult, error) {
+
+	st.Lock()
+	defer st.Unlock()
+	if r, ok := st.results.Get(key).(*idempotentResult); ok {
+		if r.fingerprint != fingerprint {
+			return nil, utils.NewIdempotencyKeyReusedError()
+		}
+		return r, nil
+	}
+	if fp, ok := st.inFlight[key]; ok {
+		if fp != fingerprint {
+			return nil, utils.NewIdempotencyKeyReusedError()
+		}
+		return nil, utils.NewInProgressError()
+	}
+	st.inFlight[key] = fingerprint
+	return nil, nil
+}
+
+// retain retains the result of the key's request.
+func (st *idempotencyStore) retain(
+	key, fingerprint string, result interface{}) {
+
+	st.Lock()
+	defer st.Unlock()
+	st.results.Set(key, &idempotentResult{fingerprint, result})
+}
+
+// release marks the key's request as no longer in progress.
+func (st *idempotencyStore) release(key string) {
+	st.Lock()
+	defer st.Unlock()
+	delete(st.inFlight, key)
+}
+
+// idempotencyKey returns the key of a retained result. Keys are scoped to
+// the storage service.
+func idempotencyKey(svc types.StorageService, token string) string {
+	h := sha1.New()
+	h.Write([]byte(svc.Name()))
+	h.Write([]byte{0})
+	h.Write([]byte(token))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// idempotencyFingerprint returns a hash of the request's method, path, query,
+// and payload so that a key that is reused for a different request does not
+// return the result of the original request. The payload is hashed as the
+// request object the schema validator decoded since the request's body has
+// already been read.
+func idempotencyFingerprint(
+	ctx types.Context, req *http.Request) (string, error) {
+
+	var body []byte
+	if reqObj := ctx.Value("reqObj"); reqObj != nil {
+		buf, err := json.Marshal(reqObj)
+		if err != nil {
+			return "", err
+		}
+		body = buf
+	}
+
+	h := sha1.New()
+	write := func(v []byte) {
+		h.Write(v)
+		h.Write([]byte{0})
+	}
+	write([]byte(req.Method))
+	write([]byte(req.URL.Path))
+	write([]byte(req.URL.RawQuery))
+	write(body)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
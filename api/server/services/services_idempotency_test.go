@@ -0,0 +1,189 @@
+package services
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/akutz/goof"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codedellemc/libstorage/api/context"
+	"github.com/codedellemc/libstorage/api/types"
+	"github.com/codedellemc/libstorage/api/utils"
+)
+
+func newTestIdempotentService() *storageService {
+	return &storageService{
+		name: "idempotency-test",
+		idempotency: &idempotencyStore{
+			results:  utils.NewTTLStore(time.Hour, true),
+			inFlight: map[string]string{},
+		},
+	}
+}
+
+func newTestIdempotentCtx(
+	t *testing.T,
+	method, url, token string,
+	reqObj interface{}) types.Context {
+
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "" {
+		req.Header.Set(types.IdempotencyKeyHeader, token)
+	}
+	ctx := context.Background().WithValue(context.HTTPRequestKey, req)
+	if reqObj != nil {
+		ctx = ctx.WithValue("reqObj", reqObj)
+	}
+	return ctx
+}
+
+type testIdempotentReq struct {
+	Name string `json:"name"`
+	Size int64  `json:"size"`
+}
+
+func TestIdempotentTask(t *testing.T) {
+	s := newTestIdempotentService()
+
+	runs := 0
+	run := func(
+		ctx types.Context, svc types.StorageService) (interface{}, error) {
+		runs++
+		return runs, nil
+	}
+	exec := func(ctx types.Context) (interface{}, error) {
+		return s.idempotentTask(ctx, run)(ctx, s)
+	}
+
+	const url = "/volumes/idempotency-test"
+	req := &testIdempotentReq{Name: "vol", Size: 1}
+
+	r, err := exec(newTestIdempotentCtx(t, "POST", url, "k1", req))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, r)
+
+	// a repeated request returns the original result
+	r, err = exec(newTestIdempotentCtx(t, "POST", url, "k1",
+		&testIdempotentReq{Name: "vol", Size: 1}))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, r)
+	assert.Equal(t, 1, runs)
+
+	// a key that is reused for a different request is rejected
+	tests := []types.Context{
+		newTestIdempotentCtx(t, "POST", url, "k1",
+			&testIdempotentReq{Name: "vol", Size: 2}),
+		newTestIdempotentCtx(t, "POST", url, "k1", nil),
+		newTestIdempotentCtx(t, "POST", url+"?attach", "k1", req),
+		newTestIdempotentCtx(t, "DELETE", url, "k1", req),
+		newTestIdempotentCtx(t, "POST", url+"/other", "k1", req),
+	}
+	for i, ctx := range tests {
+		_, err := exec(ctx)
+		assert.Error(t, err, "%d", i)
+		assert.Equal(t, types.ErrorCodeIdempotencyKeyReused,
+			types.ErrorCodeOf(err), "%d", i)
+	}
+	assert.Equal(t, 1, runs)
+
+	// requests without a key or that do not modify resources are executed
+	for _, ctx := range []types.Context{
+		newTestIdempotentCtx(t, "POST", url, "", req),
+		newTestIdempotentCtx(t, "GET", url, "k1", nil),
+		context.Background(),
+	} {
+		_, err := exec(ctx)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 4, runs)
+
+	// a key is scoped to its service
+	other := newTestIdempotentService()
+	other.name = "idempotency-test-other"
+	ctx := newTestIdempotentCtx(t, "POST", url, "k1", req)
+	r, err = other.idempotentTask(ctx, run)(ctx, other)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, r)
+}
+
+func TestIdempotentTaskError(t *testing.T) {
+	s := newTestIdempotentService()
+	ctx := newTestIdempotentCtx(t, "DELETE", "/volumes/x/y", "k1", nil)
+
+	runs := 0
+	fail := func(
+		ctx types.Context, svc types.StorageService) (interface{}, error) {
+		runs++
+		return nil, goof.New("failed")
+	}
+	_, err := s.idempotentTask(ctx, fail)(ctx, s)
+	assert.EqualError(t, err, "failed")
+
+	// a failed request is not retained and is executed again
+	_, err = s.idempotentTask(ctx, fail)(ctx, s)
+	assert.EqualError(t, err, "failed")
+	assert.Equal(t, 2, runs)
+	assert.Empty(t, s.idempotency.inFlight)
+
+	// a request that panics does not leave its key in progress
+	func() {
+		defer func() { recover() }()
+		s.idempotentTask(ctx, func(
+			types.Context, types.StorageService) (interface{}, error) {
+			panic("boom")
+		})(ctx, s)
+	}()
+	assert.Empty(t, s.idempotency.inFlight)
+
+	r, err := s.idempotentTask(ctx, func(
+		types.Context, types.StorageService) (interface{}, error) {
+		return nil, nil
+	})(ctx, s)
+	assert.NoError(t, err)
+	assert.Nil(t, r)
+}
+
+func TestIdempotentTaskInProgress(t *testing.T) {
+	s := newTestIdempotentService()
+	const url = "/volumes/idempotency-test"
+	req := &testIdempotentReq{Name: "vol"}
+
+	started, release := make(chan struct{}), make(chan struct{})
+	done := make(chan error)
+	ctx := newTestIdempotentCtx(t, "POST", url, "k1", req)
+	go func() {
+		_, err := s.idempotentTask(ctx, func(
+			types.Context, types.StorageService) (interface{}, error) {
+			close(started)
+			<-release
+			return "vol", nil
+		})(ctx, s)
+		done <- err
+	}()
+	<-started
+
+	noop := func(
+		types.Context, types.StorageService) (interface{}, error) {
+		return nil, nil
+	}
+	_, err := s.idempotentTask(ctx, noop)(ctx, s)
+	assert.Equal(t, types.ErrorCodeInProgress, types.ErrorCodeOf(err))
+
+	ctx2 := newTestIdempotentCtx(t, "POST", url, "k1",
+		&testIdempotentReq{Name: "other"})
+	_, err = s.idempotentTask(ctx2, noop)(ctx2, s)
+	assert.Equal(t,
+		types.ErrorCodeIdempotencyKeyReused, types.ErrorCodeOf(err))
+
+	close(release)
+	assert.NoError(t, <-done)
+
+	r, err := s.idempotentTask(ctx, noop)(ctx, s)
+	assert.NoError(t, err)
+	assert.Equal(t, "vol", r)
+}
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/gorilla/mux"
+
+	"github.com/codedellemc/libstorage/api/context"
+)
+
+// volumeLocks serializes the mutating operations performed on the same
+// volume of the same storage service. The locks are keyed by the service's
+// name rather than held by a service instance so that operations remain
+// serialized while a reloaded service and the instance it replaced both
+// execute tasks.
+var volumeLocks = &volumeLockManager{}
+
+type volumeLockKey struct {
+	service  string
+	volumeID string
+}
+
+// volumeLockManager grants the lock of a volume to the operations that
+// reserve it in the order in which they reserve it. A lock exists only while
+// at least one operation holds or is waiting on it.
+type volumeLockManager struct {
+	sync.Mutex
+	locks map[volumeLockKey][]*volumeLockTicket
+}
+
+// volumeLockTicket is an operation's reservation of a volume's lock. The
+// operation holds the lock once the ticket is ready and until the ticket is
+// released.
+type volumeLockTicket struct {
+	m       *volumeLockManager
+	key     volumeLockKey
+	ready   chan struct{}
+	release func()
+}
+
+// reserve queues a ticket for the lock of the specified volume. The ticket
+// is ready at once if no other operation holds or is waiting on the lock.
+func (m *volumeLockManager) reserve(key volumeLockKey) *volumeLockTicket {
+	m.Lock()
+	defer m.Unlock()
+
+	if m.locks == nil {
+		m.locks = map[volumeLockKey][]*volumeLockTicket{}
+	}
+
+	tk := &volumeLockTicket{m: m, key: key, ready: make(chan struct{})}
+	var once sync.Once
+	tk.release = func() { once.Do(tk.remove) }
+
+	m.locks[key] = append(m.locks[key], tk)
+	if len(m.locks[key]) == 1 {
+		close(tk.ready)
+	}
+	return tk
+}
+
+// remove removes the ticket from its lock's queue and, if the ticket held
+// the lock, hands the lock to the next ticket.
+func (tk *volumeLockTicket) remove() {
+	tk.m.Lock()
+	defer tk.m.Unlock()
+
+	tickets := tk.m.locks[tk.key]
+	for i, t := range tickets {
+		if t != tk {
+			continue
+		}
+		tickets = append(tickets[:i], tickets[i+1:]...)
+		if len(tickets) == 0 {
+			delete(tk.m.locks, tk.key)
+			return
+		}
+		tk.m.locks[tk.key] = tickets
+		if i == 0 {
+			close(tickets[0].ready)
+		}
+		return
+	}
+}
+
+// reserveVolumeLock reserves the lock of the volume the task modifies. Nil
+// is returned for tasks that do not target a volume or only inspect it, so
+// reads are never blocked by mutating operations.
+func reserveVolumeLock(t *task) *volumeLockTicket {
+	if t.storService == nil {
+		return nil
+	}
+	req, ok := context.HTTPRequest(t.ctx)
+	if !ok || req.Method == http.MethodGet || req.Method == http.MethodHead {
+		return nil
+	}
+	volumeID := mux.Vars(req)["volumeID"]
+	if volumeID == "" {
+		return nil
+	}
+	return volumeLocks.reserve(volumeLockKey{
+		service:  t.storService.Name(),
+		volumeID: volumeID,
+	})
+}
+
+// execVolumeTask executes a task while it holds the volume lock for which
+// the ticket is reserved. The lock is released once the task completes,
+// even if the task panics. A nil ticket executes the task without a lock.
+func execVolumeTask(t *task, tk *volumeLockTicket) {
+	if tk == nil {
+		execTask(t)
+		return
+	}
+	defer tk.release()
+
+	t.ctx.WithField("volumeID", tk.key.volumeID).Debug(
+		"waiting on volume lock")
+	<-tk.ready
+	t.ctx.WithField("volumeID", tk.key.volumeID).Debug(
+		"acquired volume lock")
+
+	execTask(t)
+}
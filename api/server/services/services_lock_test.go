@@ -0,0 +1,150 @@
+package services
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codedellemc/libstorage/api/context"
+	"github.com/codedellemc/libstorage/api/types"
+)
+
+func isReady(tk *volumeLockTicket) bool {
+	select {
+	case <-tk.ready:
+		return true
+	default:
+		return false
+	}
+}
+
+func TestVolumeLockManager(t *testing.T) {
+	m := &volumeLockManager{}
+	vol1 := volumeLockKey{service: "ebs", volumeID: "vol-1"}
+	vol2 := volumeLockKey{service: "ebs", volumeID: "vol-2"}
+	other := volumeLockKey{service: "efs", volumeID: "vol-1"}
+
+	first := m.reserve(vol1)
+	second := m.reserve(vol1)
+	third := m.reserve(vol1)
+	assert.True(t, isReady(first))
+	assert.False(t, isReady(second))
+	assert.False(t, isReady(third))
+
+	// the locks of other volumes, and of the same volume of another
+	// service, are independent
+	assert.True(t, isReady(m.reserve(vol2)))
+	assert.True(t, isReady(m.reserve(other)))
+
+	first.release()
+	first.release()
+	assert.True(t, isReady(second))
+	assert.False(t, isReady(third))
+
+	// a waiting ticket may be released without acquiring the lock
+	fourth := m.reserve(vol1)
+	third.release()
+	assert.False(t, isReady(fourth))
+	second.release()
+	assert.True(t, isReady(fourth))
+	fourth.release()
+
+	m.Lock()
+	_, ok := m.locks[vol1]
+	m.Unlock()
+	assert.False(t, ok)
+}
+
+func TestReserveVolumeLock(t *testing.T) {
+	_, ctx := newTestTaskService(t)
+	svc := &storageService{name: "services-lock-test"}
+
+	reserve := func(method, path string) *volumeLockTicket {
+		var tk *volumeLockTicket
+		r := mux.NewRouter()
+		h := func(w http.ResponseWriter, req *http.Request) {
+			tk = reserveVolumeLock(&task{
+				ctx:         ctx.WithValue(context.HTTPRequestKey, req),
+				storService: svc,
+			})
+		}
+		r.HandleFunc("/volumes/{service}/{volumeID}", h)
+		r.HandleFunc("/volumes/{service}", h)
+		req, _ := http.NewRequest(method, path, nil)
+		r.ServeHTTP(httptest.NewRecorder(), req)
+		return tk
+	}
+
+	assert.Nil(t, reserve(http.MethodGet, "/volumes/ebs/vol-1"))
+	assert.Nil(t, reserve(http.MethodHead, "/volumes/ebs/vol-1"))
+	assert.Nil(t, reserve(http.MethodPost, "/volumes/ebs"))
+	assert.Nil(t, reserveVolumeLock(&task{ctx: ctx, storService: svc}))
+
+	tk := reserve(http.MethodDelete, "/volumes/ebs/vol-1")
+	if assert.NotNil(t, tk) {
+		assert.Equal(t, volumeLockKey{
+			service:  "services-lock-test",
+			volumeID: "vol-1",
+		}, tk.key)
+		assert.True(t, isReady(tk))
+		tk.release()
+	}
+}
+
+// TestExecVolumeTaskPanic asserts that a volume's lock is released when the
+// task that holds it panics.
+func TestExecVolumeTaskPanic(t *testing.T) {
+	_, ctx := newTestTaskService(t)
+	key := volumeLockKey{service: "services-lock-test", volumeID: "vol-1"}
+
+	tk := volumeLocks.reserve(key)
+	next := volumeLocks.reserve(key)
+	func() {
+		defer func() { assert.NotNil(t, recover()) }()
+		execVolumeTask(newGenericTask(ctx, func(
+			ctx types.Context) (interface{}, error) {
+			assert.False(t, isReady(next))
+			panic("driver panic")
+		}, nil), tk)
+	}()
+	assert.True(t, isReady(next))
+	next.release()
+}
+
+// TestTaskPool asserts that any of a pool's workers executes the next task
+// while another worker is busy.
+func TestTaskPool(t *testing.T) {
+	s, ctx := newTestTaskService(t)
+	svc := &storageService{name: "ebs", tasks: newTaskPool(2)}
+	defer svc.tasks.close()
+
+	var (
+		running = make(chan struct{})
+		release = make(chan struct{})
+	)
+	first := newStorageServiceTask(ctx, func(
+		ctx types.Context,
+		svc types.StorageService) (interface{}, error) {
+		close(running)
+		<-release
+		return "first", nil
+	}, svc, nil)
+	second := newStorageServiceTask(ctx, func(
+		ctx types.Context,
+		svc types.StorageService) (interface{}, error) {
+		return "second", nil
+	}, svc, nil)
+
+	svc.tasks.enqueue(first)
+	<-running
+	svc.tasks.enqueue(second)
+	s.TaskWait(second.ID)
+	assert.Equal(t, "second", s.TaskInspect(second.ID).Result)
+
+	close(release)
+	s.TaskWait(first.ID)
+	assert.Equal(t, "first", s.TaskInspect(first.ID).Result)
+}
@@ -0,0 +1,209 @@
+package services
+
+import (
+	"regexp"
+	"sync"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/akutz/goof"
+
+	"github.com/codedellemc/libstorage/api/types"
+	"github.com/codedellemc/libstorage/api/utils"
+)
+
+const (
+	policyMaxVolumeSize     = "maxVolumeSize"
+	policyMaxVolumeCount    = "maxVolumeCount"
+	policyVolumeNamePattern = "volumeNamePattern"
+	policyRequiredOpts      = "requiredOpts"
+)
+
+// volumePolicy limits the volumes a storage service may create and remove.
+// Creates are serialized with createLock when the volume count is limited so
+// that concurrent creates cannot all pass the check before any of them has
+// created its volume.
+type volumePolicy struct {
+	maxSize      int64
+	maxCount     int
+	namePattern  *regexp.Regexp
+	requiredOpts []string
+	createLock   sync.Mutex
+}
+
+// initPolicy parses the storage service's policy from the properties defined
+// under libstorage.server.policy. The service has no policy if none of the
+// properties are defined.
+func (s *storageService) initPolicy(ctx types.Context) error {
+	var (
+		config = s.config
+		p      = &volumePolicy{
+			maxSize: int64(
+				config.GetInt(types.ConfigServerPolicyMaxVolumeSize)),
+			maxCount: config.GetInt(
+				types.ConfigServerPolicyMaxVolumeCount),
+			requiredOpts: config.GetStringSlice(
+				types.ConfigServerPolicyRequiredOpts),
+		}
+	)
+
+	if v := config.GetString(
+		types.ConfigServerPolicyVolumeNamePattern); v != "" {
+		rx, err := regexp.Compile(v)
+		if err != nil {
+			return goof.WithFieldE(
+				policyVolumeNamePattern, v, "invalid volume policy", err)
+		}
+		p.namePattern = rx
+	}
+
+	if p.maxSize <= 0 && p.maxCount <= 0 &&
+		p.namePattern == nil && len(p.requiredOpts) == 0 {
+		return nil
+	}
+
+	ctx.WithFields(log.Fields{
+		policyMaxVolumeSize:  p.maxSize,
+		policyMaxVolumeCount: p.maxCount,
+		policyRequiredOpts:   p.requiredOpts,
+	}).Debug("configured volume policy")
+	s.policy = p
+	return nil
+}
+
+func noopRelease() {}
+
+// CheckVolumeCreatePolicy returns an ErrPolicyViolation error if creating a
+// volume with the given name and options violates the storage service's
+// policy. The options may be nil when a volume is copied. Checking the
+// maximum volume count lists the service's volumes.
+//
+// If the check passes the returned function must be invoked once the volume
+// has been created, or its creation has failed. Until then the service's
+// other creates wait on the maximum volume count check.
+func CheckVolumeCreatePolicy(
+	ctx types.Context,
+	svc types.StorageService,
+	name string,
+	opts *types.VolumeCreateOpts) (func(), error) {
+
+	s, ok := svc.(*storageService)
+	if !ok || s.policy == nil {
+		return noopRelease, nil
+	}
+	p := s.policy
+
+	if p.namePattern != nil && !p.namePattern.MatchString(name) {
+		return nil, utils.NewPolicyViolationError(
+			s.name, policyVolumeNamePattern, p.namePattern.String(), name)
+	}
+
+	if opts != nil {
+		if p.maxSize > 0 && opts.Size != nil && *opts.Size > p.maxSize {
+			return nil, utils.NewPolicyViolationError(
+				s.name, policyMaxVolumeSize, p.maxSize, *opts.Size)
+		}
+		for _, k := range p.requiredOpts {
+			if !isVolumeCreateOptSet(opts, k) {
+				return nil, utils.NewPolicyViolationError(
+					s.name, policyRequiredOpts, p.requiredOpts, k)
+			}
+		}
+	}
+
+	if p.maxCount <= 0 {
+		return noopRelease, nil
+	}
+
+	p.createLock.Lock()
+	vols, err := s.driver.Volumes(
+		ctx, &types.VolumesOpts{Opts: utils.NewStore()})
+	if err != nil {
+		p.createLock.Unlock()
+		return nil, err
+	}
+	if len(vols) >= p.maxCount {
+		p.createLock.Unlock()
+		return nil, utils.NewPolicyViolationError(
+			s.name, policyMaxVolumeCount, p.maxCount, len(vols)+1)
+	}
+
+	var once sync.Once
+	return func() { once.Do(p.createLock.Unlock) }, nil
+}
+
+// CheckVolumeExpandPolicy returns an ErrPolicyViolation error if expanding a
+// volume to the given size violates the storage service's policy.
+func CheckVolumeExpandPolicy(
+	ctx types.Context,
+	svc types.StorageService,
+	newSize int64) error {
+
+	s, ok := svc.(*storageService)
+	if !ok || s.policy == nil {
+		return nil
+	}
+	p := s.policy
+
+	if p.maxSize > 0 && newSize > p.maxSize {
+		return utils.NewPolicyViolationError(
+			s.name, policyMaxVolumeSize, p.maxSize, newSize)
+	}
+
+	return nil
+}
+
+// CheckVolumeRemovePolicy returns an ErrPolicyViolation error if removing the
+// specified volume violates the storage service's policy. A service with a
+// volume name pattern may only remove the volumes whose names match it.
+func CheckVolumeRemovePolicy(
+	ctx types.Context,
+	svc types.StorageService,
+	volumeID string,
+	opts types.Store) error {
+
+	s, ok := svc.(*storageService)
+	if !ok || s.policy == nil || s.policy.namePattern == nil {
+		return nil
+	}
+
+	v, err := s.driver.VolumeInspect(
+		ctx, volumeID, &types.VolumeInspectOpts{Opts: opts})
+	if err != nil {
+		return err
+	}
+
+	if !s.policy.namePattern.MatchString(v.Name) {
+		return utils.NewPolicyViolationError(
+			s.name,
+			policyVolumeNamePattern,
+			s.policy.namePattern.String(),
+			v.Name)
+	}
+
+	return nil
+}
+
+// isVolumeCreateOptSet returns a flag indicating whether the named option is
+// set. The name is either one of the fields of a volume create request or a
+// key of the request's opts object.
+func isVolumeCreateOptSet(opts *types.VolumeCreateOpts, name string) bool {
+	switch name {
+	case "availabilityZone":
+		return opts.AvailabilityZone != nil
+	case "encrypted":
+		return opts.Encrypted != nil
+	case "iops":
+		return opts.IOPS != nil
+	case "size":
+		return opts.Size != nil
+	case "type":
+		return opts.Type != nil
+	}
+	if opts.Opts == nil {
+		return false
+	}
+	if reqOpts := opts.Opts.GetStore("opts"); reqOpts != nil {
+		return reqOpts.IsSet(name)
+	}
+	return false
+}
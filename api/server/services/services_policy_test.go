@@ -0,0 +1,144 @@
+package services
+
+import (
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codedellemc/libstorage/api/context"
+	"github.com/codedellemc/libstorage/api/types"
+	"github.com/codedellemc/libstorage/api/utils"
+)
+
+// policyTestDriver is a storage driver that creates volumes in memory. It
+// lists its volumes slowly so that concurrent creates overlap.
+type policyTestDriver struct {
+	types.StorageDriver
+	sync.Mutex
+	vols []*types.Volume
+}
+
+func (d *policyTestDriver) Volumes(
+	ctx types.Context,
+	opts *types.VolumesOpts) ([]*types.Volume, error) {
+
+	d.Lock()
+	vols := append([]*types.Volume{}, d.vols...)
+	d.Unlock()
+	time.Sleep(10 * time.Millisecond)
+	return vols, nil
+}
+
+func (d *policyTestDriver) VolumeCreate(
+	ctx types.Context,
+	name string,
+	opts *types.VolumeCreateOpts) (*types.Volume, error) {
+
+	d.Lock()
+	defer d.Unlock()
+	v := &types.Volume{ID: name, Name: name}
+	d.vols = append(d.vols, v)
+	return v, nil
+}
+
+func isPolicyViolation(err error) bool {
+	_, ok := err.(*types.ErrPolicyViolation)
+	return ok
+}
+
+func TestCheckVolumeCreatePolicy(t *testing.T) {
+	ctx := context.Background()
+	size := func(v int64) *int64 { return &v }
+	reqOpts := utils.NewStore()
+	reqOpts.Set("kmsKeyID", "key")
+	store := utils.NewStore()
+	store.Set("opts", reqOpts)
+
+	s := &storageService{
+		name:   "ebs",
+		driver: &policyTestDriver{},
+		policy: &volumePolicy{
+			maxSize:      100,
+			namePattern:  regexp.MustCompile("^team-a-"),
+			requiredOpts: []string{"size", "kmsKeyID"},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		opts      *types.VolumeCreateOpts
+		violation bool
+	}{
+		{"team-a-1", &types.VolumeCreateOpts{
+			Size: size(100), Opts: store}, false},
+		{"team-a-1", nil, false},
+		{"team-b-1", nil, true},
+		{"team-a-1", &types.VolumeCreateOpts{
+			Size: size(101), Opts: store}, true},
+		{"team-a-1", &types.VolumeCreateOpts{Opts: store}, true},
+		{"team-a-1", &types.VolumeCreateOpts{
+			Size: size(1), Opts: utils.NewStore()}, true},
+	}
+	for i, tt := range tests {
+		release, err := CheckVolumeCreatePolicy(ctx, s, tt.name, tt.opts)
+		if tt.violation {
+			assert.True(t, isPolicyViolation(err), "%d %v", i, err)
+			continue
+		}
+		assert.NoError(t, err, "%d", i)
+		release()
+	}
+
+	s.policy = nil
+	release, err := CheckVolumeCreatePolicy(ctx, s, "team-b-1", nil)
+	assert.NoError(t, err)
+	release()
+}
+
+// TestCheckVolumeCreatePolicyMaxCount asserts that concurrent creates do not
+// exceed the maximum volume count.
+func TestCheckVolumeCreatePolicyMaxCount(t *testing.T) {
+	const (
+		maxCount = 3
+		creates  = 10
+	)
+
+	ctx := context.Background()
+	d := &policyTestDriver{}
+	s := &storageService{
+		name:   "ebs",
+		driver: d,
+		policy: &volumePolicy{maxCount: maxCount},
+	}
+
+	var (
+		wg         sync.WaitGroup
+		violations = make(chan error, creates)
+	)
+	for i := 0; i < creates; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			release, err := CheckVolumeCreatePolicy(ctx, s, "vol", nil)
+			if err != nil {
+				violations <- err
+				return
+			}
+			defer release()
+			d.VolumeCreate(ctx, "vol", nil)
+		}()
+	}
+	wg.Wait()
+	close(violations)
+
+	assert.Len(t, d.vols, maxCount)
+	n := 0
+	for err := range violations {
+		assert.True(t, isPolicyViolation(err), "%v", err)
+		n++
+	}
+	assert.Equal(t, creates-maxCount, n)
+}
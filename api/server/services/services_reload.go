@@ -0,0 +1,124 @@
+package services
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	gofig "github.com/akutz/gofig/types"
+
+	"github.com/codedellemc/libstorage/api/context"
+	"github.com/codedellemc/libstorage/api/types"
+)
+
+// Reload updates the server's storage services to match the services defined
+// by the given configuration. Services that are new are created, services
+// whose configuration changed are recreated, and services that are no longer
+// defined are removed. Services whose configuration is unchanged, as well as
+// the requests and tasks that are already using a service, are unaffected.
+//
+// The server's services are not altered if any of the new services cannot
+// be initialized.
+func Reload(
+	ctx types.Context,
+	config gofig.Config) (*types.ServicesReload, error) {
+
+	serverName, ok := context.Server(ctx)
+	if !ok {
+		panic("ctx is missing ServerName")
+	}
+
+	servicesByServerRWL.RLock()
+	sc := servicesByServer[serverName]
+	servicesByServerRWL.RUnlock()
+
+	// only one reload may be in progress at a time
+	sc.reloadLock.Lock()
+	defer sc.reloadLock.Unlock()
+
+	oldSvcsMap, err := getServicesMap(sc.config)
+	if err != nil {
+		return nil, err
+	}
+	newSvcsMap, err := getServicesMap(config)
+	if err != nil {
+		return nil, err
+	}
+	oldSvcsMap = lowerKeys(oldSvcsMap)
+	newSvcsMap = lowerKeys(newSvcsMap)
+
+	var (
+		result   = &types.ServicesReload{}
+		storSvcs = map[string]types.StorageService{}
+		created  = []*storageService{}
+		retired  = []*storageService{}
+	)
+
+	for serviceName, svcConfig := range newSvcsMap {
+		oldSvc, exists := sc.storageServices[serviceName]
+		if exists &&
+			reflect.DeepEqual(oldSvcsMap[serviceName], svcConfig) {
+			storSvcs[serviceName] = oldSvc
+			continue
+		}
+
+		storSvc, err := newStorageService(sc.ctx, config, serviceName)
+		if err != nil {
+			for _, s := range created {
+				s.close()
+			}
+			return nil, err
+		}
+		created = append(created, storSvc)
+		storSvcs[serviceName] = storSvc
+
+		if exists {
+			result.Updated = append(result.Updated, serviceName)
+			if s, ok := oldSvc.(*storageService); ok {
+				retired = append(retired, s)
+			}
+		} else {
+			result.Added = append(result.Added, serviceName)
+		}
+	}
+
+	for serviceName, oldSvc := range sc.storageServices {
+		if _, ok := storSvcs[serviceName]; ok {
+			continue
+		}
+		result.Removed = append(result.Removed, serviceName)
+		if s, ok := oldSvc.(*storageService); ok {
+			retired = append(retired, s)
+		}
+	}
+
+	servicesByServerRWL.Lock()
+	sc.config = config
+	sc.storageServices = storSvcs
+	servicesByServerRWL.Unlock()
+
+	for _, s := range retired {
+		s.close()
+	}
+
+	sort.Strings(result.Added)
+	sort.Strings(result.Updated)
+	sort.Strings(result.Removed)
+
+	sc.ctx.WithFields(log.Fields{
+		"added":   result.Added,
+		"updated": result.Updated,
+		"removed": result.Removed,
+	}).Info("reloaded services")
+
+	return result, nil
+}
+
+func lowerKeys(m map[string]interface{}) map[string]interface{} {
+	lm := map[string]interface{}{}
+	for k, v := range m {
+		lm[strings.ToLower(k)] = v
+	}
+	return lm
+}
@@ -0,0 +1,173 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	gofigCore "github.com/akutz/gofig"
+	gofig "github.com/akutz/gofig/types"
+	"github.com/akutz/goof"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codedellemc/libstorage/api/context"
+	"github.com/codedellemc/libstorage/api/registry"
+	"github.com/codedellemc/libstorage/api/types"
+)
+
+const (
+	testReloadServerName = "services-reload-test"
+	testReloadDriverName = "reloadtest"
+)
+
+// testReloadDriver is a storage driver whose initialization fails if its
+// service's configuration sets fail.
+type testReloadDriver struct {
+	types.StorageDriver
+}
+
+func (d *testReloadDriver) Name() string {
+	return testReloadDriverName
+}
+
+func (d *testReloadDriver) Init(
+	ctx types.Context, config gofig.Config) error {
+
+	if config.GetBool("fail") {
+		return goof.New("init failed")
+	}
+	return nil
+}
+
+func init() {
+	registry.RegisterStorageDriver(testReloadDriverName,
+		func() types.StorageDriver { return &testReloadDriver{} })
+}
+
+func newTestReloadConfig(t *testing.T, yml string) gofig.Config {
+	config := gofigCore.New()
+	if err := config.ReadConfig(strings.NewReader(yml)); err != nil {
+		t.Fatal(err)
+	}
+	// the defaults are otherwise registered by the imports/config package
+	config.Set(types.ConfigServerCacheTTL, "0s")
+	config.Set(types.ConfigServerIdempotencyTTL, "0s")
+	config.Set(types.ConfigServerHealthInterval, "0s")
+	config.Set(types.ConfigServerHealthTimeout, "10s")
+	return config.Scope(types.ConfigServer)
+}
+
+func newTestReloadServices(
+	t *testing.T, yml string) (*serviceContainer, types.Context) {
+
+	ctx := context.Background().WithValue(
+		context.ServerKey, testReloadServerName)
+	sc := &serviceContainer{
+		ctx:             ctx,
+		config:          newTestReloadConfig(t, yml),
+		storageServices: map[string]types.StorageService{},
+	}
+	if err := sc.initStorageServices(ctx); err != nil {
+		t.Fatal(err)
+	}
+	servicesByServerRWL.Lock()
+	servicesByServer[testReloadServerName] = sc
+	servicesByServerRWL.Unlock()
+	return sc, ctx
+}
+
+const testReloadConfig = `
+libstorage:
+  server:
+    services:
+      ebs:
+        driver: reloadtest
+      efs:
+        driver: reloadtest
+      s3fs:
+        driver: reloadtest
+`
+
+// TestReload asserts that a reload adds, recreates, and removes the services
+// whose configuration changed and keeps the others.
+func TestReload(t *testing.T) {
+	sc, ctx := newTestReloadServices(t, testReloadConfig)
+	oldSvcs := map[string]types.StorageService{}
+	for k, v := range sc.storageServices {
+		oldSvcs[k] = v
+	}
+
+	config := newTestReloadConfig(t, `
+libstorage:
+  server:
+    services:
+      EBS:
+        driver: reloadtest
+      efs:
+        driver: reloadtest
+        region: us-west-2
+      scaleio:
+        driver: reloadtest
+`)
+	result, err := Reload(ctx, config)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, []string{"scaleio"}, result.Added)
+	assert.Equal(t, []string{"efs"}, result.Updated)
+	assert.Equal(t, []string{"s3fs"}, result.Removed)
+
+	assert.Len(t, sc.storageServices, 3)
+	assert.True(t, oldSvcs["ebs"] == GetStorageService(ctx, "ebs"))
+	assert.False(t, oldSvcs["efs"] == GetStorageService(ctx, "efs"))
+	assert.NotNil(t, GetStorageService(ctx, "scaleio"))
+	assert.Nil(t, GetStorageService(ctx, "s3fs"))
+	assert.True(t, sc.config == config)
+
+	// the retired services' workers are stopped
+	assert.True(t, oldSvcs["efs"].(*storageService).tasks.closed)
+	assert.True(t, oldSvcs["s3fs"].(*storageService).tasks.closed)
+	assert.False(t, oldSvcs["ebs"].(*storageService).tasks.closed)
+
+	// reloading the same configuration changes nothing
+	result, err = Reload(ctx, config)
+	assert.NoError(t, err)
+	assert.Empty(t, result.Added)
+	assert.Empty(t, result.Updated)
+	assert.Empty(t, result.Removed)
+}
+
+// TestReloadError asserts that the services are unchanged when a new service
+// cannot be initialized or the configuration is invalid.
+func TestReloadError(t *testing.T) {
+	sc, ctx := newTestReloadServices(t, testReloadConfig)
+	oldConfig := sc.config
+	oldSvcs := map[string]types.StorageService{}
+	for k, v := range sc.storageServices {
+		oldSvcs[k] = v
+	}
+
+	_, err := Reload(ctx, newTestReloadConfig(t, `
+libstorage:
+  server:
+    services:
+      ebs:
+        driver: reloadtest
+      scaleio:
+        driver: reloadtest
+        fail: true
+`))
+	assert.EqualError(t, err, "init failed")
+	assert.Equal(t, oldSvcs, sc.storageServices)
+	assert.True(t, sc.config == oldConfig)
+	for _, svc := range oldSvcs {
+		assert.False(t, svc.(*storageService).tasks.closed)
+	}
+
+	_, err = Reload(ctx, newTestReloadConfig(t, `
+libstorage:
+  server:
+    services: ebs
+`))
+	assert.EqualError(t, err, "invalid format")
+	assert.Equal(t, oldSvcs, sc.storageServices)
+}
@@ -1,19 +1,108 @@
 package services
 
 import (
+	"sync"
+
 	gofig "github.com/akutz/gofig/types"
 	"github.com/akutz/goof"
 
 	"github.com/codedellemc/libstorage/api/context"
 	"github.com/codedellemc/libstorage/api/registry"
 	"github.com/codedellemc/libstorage/api/types"
+	"github.com/codedellemc/libstorage/api/utils/secrets"
 )
 
 type storageService struct {
-	name          string
-	driver        types.StorageDriver
-	config        gofig.Config
-	taskExecQueue chan *task
+	name        string
+	driver      types.StorageDriver
+	config      gofig.Config
+	tasks       *taskPool
+	cache       types.Store
+	idempotency *idempotencyStore
+	health      *healthMonitor
+	policy      *volumePolicy
+	preempt     *attachPreemption
+}
+
+// taskPool executes the tasks enqueued on it with a fixed number of workers
+// in the order in which they were enqueued. Any worker may execute any task;
+// the mutating operations on a single volume are serialized by the volume's
+// lock rather than by routing them to the same worker.
+type taskPool struct {
+	sync.Mutex
+	ready  *sync.Cond
+	queue  []*task
+	closed bool
+}
+
+func newTaskPool(workers int) *taskPool {
+	p := &taskPool{}
+	p.ready = sync.NewCond(&p.Mutex)
+	for i := 0; i < workers; i++ {
+		go p.run()
+	}
+	return p
+}
+
+func (p *taskPool) enqueue(t *task) {
+	p.Lock()
+	defer p.Unlock()
+	if p.closed {
+		// the pool's service has been removed, but the tasks of the
+		// requests that were already being handled must still be executed
+		go execVolumeTask(t, reserveVolumeLock(t))
+		return
+	}
+	p.queue = append(p.queue, t)
+	p.ready.Signal()
+}
+
+// remove removes a task from the pool's queue and returns a flag indicating
+// whether the task was queued.
+func (p *taskPool) remove(t *task) bool {
+	p.Lock()
+	defer p.Unlock()
+	for i, qt := range p.queue {
+		if qt == t {
+			p.queue = append(p.queue[:i], p.queue[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// close stops the pool's workers once the tasks already enqueued on it are
+// executed.
+func (p *taskPool) close() {
+	p.Lock()
+	defer p.Unlock()
+	if p.closed {
+		return
+	}
+	p.closed = true
+	p.ready.Broadcast()
+}
+
+func (p *taskPool) run() {
+	for {
+		p.Lock()
+		for len(p.queue) == 0 && !p.closed {
+			p.ready.Wait()
+		}
+		if len(p.queue) == 0 {
+			p.Unlock()
+			return
+		}
+		t := p.queue[0]
+		p.queue[0] = nil
+		p.queue = p.queue[1:]
+		// the volume's lock is reserved while the pool is locked so that
+		// the operations on a volume hold its lock in the order in which
+		// they were enqueued
+		tk := reserveVolumeLock(t)
+		p.Unlock()
+		execVolumeTask(t, tk)
+	}
 }
 
 func (s *storageService) Init(ctx types.Context, config gofig.Config) error {
@@ -23,15 +112,53 @@ func (s *storageService) Init(ctx types.Context, config gofig.Config) error {
 		return err
 	}
 
-	s.taskExecQueue = make(chan *task)
-	go func() {
-		for t := range s.taskExecQueue {
-			execTask(t)
-		}
-	}()
+	if err := s.initCache(ctx); err != nil {
+		return err
+	}
+
+	if err := s.initIdempotency(ctx); err != nil {
+		return err
+	}
+
+	if err := s.initPolicy(ctx); err != nil {
+		return err
+	}
+
+	if err := s.initHealth(ctx); err != nil {
+		return err
+	}
+
+	if err := s.initPreempt(ctx); err != nil {
+		return err
+	}
+
+	workers := s.config.GetInt(types.ConfigServerTasksWorkers)
+	if workers < 1 {
+		workers = 1
+	}
+	ctx.WithField("workers", workers).Debug("configured task workers")
+
+	s.tasks = newTaskPool(workers)
 	return nil
 }
 
+// close stops the service's health monitor as well as its task workers once
+// the tasks already enqueued on them are executed.
+func (s *storageService) close() {
+	if s.tasks != nil {
+		s.tasks.close()
+	}
+	if s.health != nil {
+		s.health.close()
+	}
+}
+
+// dequeue removes a task from the service's queue and returns a flag
+// indicating whether the task was queued.
+func (s *storageService) dequeue(t *task) bool {
+	return s.tasks != nil && s.tasks.remove(t)
+}
+
 func (s *storageService) initStorageDriver(ctx types.Context) error {
 	driverName := s.config.GetString("driver")
 	if driverName == "" {
@@ -53,6 +180,14 @@ func (s *storageService) initStorageDriver(ctx types.Context) error {
 
 	ctx = ctx.WithValue(context.DriverKey, driver)
 
+	// resolve the secrets to which the configuration refers so the driver
+	// reads them as if they were in the configuration
+	config, err := secrets.Config(ctx, s.config)
+	if err != nil {
+		return err
+	}
+	s.config = config
+
 	if err := driver.Init(ctx, s.config); err != nil {
 		return err
 	}
@@ -74,8 +209,10 @@ func (s *storageService) TaskExecute(
 	run types.StorageTaskRunFunc,
 	schema []byte) *types.Task {
 
+	run = s.invalidateCacheTask(ctx, run)
+	run = s.idempotentTask(ctx, run)
 	t := newStorageServiceTask(ctx, run, s, schema)
-	go func() { s.taskExecQueue <- t }()
+	s.tasks.enqueue(t)
 	return &t.Task
 }
 
@@ -0,0 +1,109 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/akutz/goof"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codedellemc/libstorage/api/types"
+)
+
+// TestTaskPoolOrder asserts that a pool with a single worker executes its
+// tasks one at a time in the order in which they were enqueued, and that a
+// task's error does not stop the worker.
+func TestTaskPoolOrder(t *testing.T) {
+	s, ctx := newTestTaskService(t)
+	svc := &storageService{name: "ebs", tasks: newTaskPool(1)}
+	defer svc.tasks.close()
+
+	var (
+		order   = make(chan int, 3)
+		release = make(chan struct{})
+		tasks   []*task
+	)
+	for i := 0; i < 3; i++ {
+		i := i
+		tk := newStorageServiceTask(ctx, func(
+			ctx types.Context,
+			svc types.StorageService) (interface{}, error) {
+			<-release
+			order <- i
+			if i == 1 {
+				return nil, goof.New("failed")
+			}
+			return i, nil
+		}, svc, nil)
+		svc.tasks.enqueue(tk)
+		tasks = append(tasks, tk)
+	}
+	close(release)
+
+	for _, tk := range tasks {
+		s.TaskWait(tk.ID)
+	}
+	close(order)
+	executed := []int{}
+	for i := range order {
+		executed = append(executed, i)
+	}
+	assert.Equal(t, []int{0, 1, 2}, executed)
+
+	assert.Equal(t, 0, s.TaskInspect(tasks[0].ID).Result)
+	assert.EqualError(t, s.TaskInspect(tasks[1].ID).Error, "failed")
+	assert.Equal(t, types.TaskState(types.TaskStateError),
+		s.TaskInspect(tasks[1].ID).State)
+	assert.Equal(t, 2, s.TaskInspect(tasks[2].ID).Result)
+}
+
+// TestTaskPoolClose asserts that the tasks queued on a pool when it is
+// closed, and the tasks enqueued on it afterwards, are still executed.
+func TestTaskPoolClose(t *testing.T) {
+	s, ctx := newTestTaskService(t)
+	svc := &storageService{name: "ebs", tasks: newTaskPool(1)}
+
+	var (
+		running = make(chan struct{})
+		release = make(chan struct{})
+	)
+	first := newStorageServiceTask(ctx, func(
+		ctx types.Context,
+		svc types.StorageService) (interface{}, error) {
+		close(running)
+		<-release
+		return "first", nil
+	}, svc, nil)
+	newTask := func(result string) *task {
+		return newStorageServiceTask(ctx, func(
+			ctx types.Context,
+			svc types.StorageService) (interface{}, error) {
+			return result, nil
+		}, svc, nil)
+	}
+
+	svc.tasks.enqueue(first)
+	<-running
+	queued, removed := newTask("queued"), newTask("removed")
+	svc.tasks.enqueue(queued)
+	svc.tasks.enqueue(removed)
+	assert.True(t, svc.dequeue(removed))
+	assert.False(t, svc.dequeue(removed))
+
+	svc.close()
+	svc.close()
+	late := newTask("late")
+	svc.tasks.enqueue(late)
+	s.TaskWait(late.ID)
+	assert.Equal(t, "late", s.TaskInspect(late.ID).Result)
+
+	close(release)
+	s.TaskWait(first.ID)
+	s.TaskWait(queued.ID)
+	assert.Equal(t, "first", s.TaskInspect(first.ID).Result)
+	assert.Equal(t, "queued", s.TaskInspect(queued.ID).Result)
+	assert.Equal(t, types.TaskState(types.TaskStateQueued),
+		s.TaskInspect(removed.ID).State)
+
+	// a service without a pool has no queue
+	assert.False(t, (&storageService{}).dequeue(removed))
+}
@@ -3,6 +3,7 @@ package services
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
@@ -13,10 +14,15 @@ import (
 
 	"github.com/codedellemc/libstorage/api/context"
 	"github.com/codedellemc/libstorage/api/types"
+	"github.com/codedellemc/libstorage/api/utils"
 	"github.com/codedellemc/libstorage/api/utils/schema"
 )
 
+// task is a task tracked by the task service. The fields of the embedded
+// types.Task that change while the task executes are guarded by the task's
+// lock, and the task is read by other goroutines only through snapshots.
 type task struct {
+	sync.RWMutex
 	types.Task
 	ctx                           types.Context
 	runFunc                       types.TaskRunFunc
@@ -24,11 +30,14 @@ type task struct {
 	storService                   types.StorageService
 	resultSchema                  []byte
 	resultSchemaValidationEnabled bool
+	async                         bool
 	done                          chan int
 }
 
 func newTask(ctx types.Context, schema []byte) *task {
-	t := getTaskService(ctx).taskTrack(ctx)
+	s := getTaskService(ctx)
+	s.taskPending(1)
+	t := s.taskTrack(ctx)
 	t.resultSchema = schema
 	t.done = make(chan int)
 	return t
@@ -57,59 +66,106 @@ func newStorageServiceTask(
 }
 
 func execTask(t *task) {
-	defer func() {
-		t.CompleteTime = time.Now().Unix()
-		if t.Error != nil {
-			t.ctx.Error(t.Error)
-			t.State = types.TaskStateError
-		} else {
-			t.State = types.TaskStateSuccess
-		}
-		close(t.done)
-		t.ctx.Debug("task completed")
-	}()
+	start := time.Now()
 
+	t.Lock()
 	t.State = types.TaskStateRunning
-	t.StartTime = time.Now().Unix()
+	t.StartTime = start.Unix()
+	t.Unlock()
 
 	t.ctx.Info("executing task")
 
+	result, err := runTask(t)
+	completeTask(t, result, err, time.Since(start))
+}
+
+// runTask invokes the task's function and validates its result.
+func runTask(t *task) (interface{}, error) {
+	// the context passed to the task is cancelled once the task's timeout
+	// elapses so that a storage platform that stops responding does not
+	// block the task forever
+	ctx := t.ctx
+	if timeout := getTaskService(t.ctx).taskTimeout(t); timeout > 0 {
+		var cancel func()
+		ctx, cancel = context.WithTimeout(t.ctx, timeout)
+		defer cancel()
+		ctx.WithField("timeout", timeout).Debug("set task timeout")
+	}
+
+	var (
+		result interface{}
+		err    error
+	)
 	if t.storRunFunc != nil && t.storService != nil {
-		t.Result, t.Error = t.storRunFunc(t.ctx, t.storService)
+		result, err = t.storRunFunc(ctx, t.storService)
 	} else if t.runFunc != nil {
-		t.Result, t.Error = t.runFunc(t.ctx)
+		result, err = t.runFunc(ctx)
 	} else {
-		t.Error = goof.New("invalid task")
+		err = goof.New("invalid task")
 	}
 
-	if t.Error != nil {
-		return
+	if err != nil {
+		if ctx.Err() != nil {
+			err = utils.NewTimeoutError("", err)
+		}
+		return result, err
 	}
 
-	if t.Result == nil {
+	if result == nil {
 		t.ctx.Debug("skipping response schema validation; result == nil")
-		return
+		return nil, nil
 	}
 
 	if t.resultSchema == nil {
 		t.ctx.Debug("skipping response schema validation; schema == nil")
-		return
+		return result, nil
 	}
 
 	if !t.resultSchemaValidationEnabled {
 		t.ctx.Debug("skipping response schema validation; disabled")
-		return
+		return result, nil
 	}
 
-	var buf []byte
-	if buf, t.Error = json.Marshal(t.Result); t.Error != nil {
-		return
+	buf, err := json.Marshal(result)
+	if err != nil {
+		return result, err
 	}
 
-	t.Error = schema.Validate(t.ctx, t.resultSchema, buf)
-	if t.Error != nil {
-		return
+	return result, schema.Validate(t.ctx, t.resultSchema, buf)
+}
+
+// completeTask records the task's result and signals that it is completed.
+func completeTask(
+	t *task, result interface{}, err error, duration time.Duration) {
+
+	t.Lock()
+	t.CompleteTime = time.Now().Unix()
+	t.Result = result
+	t.Error = err
+	if err != nil {
+		t.State = types.TaskStateError
+	} else {
+		t.State = types.TaskStateSuccess
 	}
+	t.Unlock()
+
+	if err != nil {
+		t.ctx.Error(err)
+	}
+	close(t.done)
+	getTaskService(t.ctx).taskPending(-1)
+	t.ctx.Debug("task completed")
+	auditTask(t, duration)
+	publishTaskEvents(t)
+}
+
+// snapshot returns a copy of the task that is safe to read while the task
+// executes.
+func (t *task) snapshot() *types.Task {
+	t.RLock()
+	defer t.RUnlock()
+	tt := t.Task
+	return &tt
 }
 
 type globalTaskService struct {
@@ -117,7 +173,10 @@ type globalTaskService struct {
 	name                          string
 	config                        gofig.Config
 	tasks                         map[int]*task
+	nextTaskID                    int
 	resultSchemaValidationEnabled bool
+	pending                       int
+	drainWaiters                  []chan int
 }
 
 // Init initializes the service.
@@ -142,7 +201,7 @@ func (s *globalTaskService) Tasks() <-chan *types.Task {
 	tasks := []*types.Task{}
 	s.RLock()
 	for _, v := range s.tasks {
-		tasks = append(tasks, &v.Task)
+		tasks = append(tasks, v.snapshot())
 	}
 	s.RUnlock()
 
@@ -163,18 +222,26 @@ func (s *globalTaskService) TaskTrack(ctx types.Context) *types.Task {
 func (s *globalTaskService) taskTrack(ctx types.Context) *task {
 
 	now := time.Now().Unix()
-	s.RLock()
-	taskID := len(s.tasks)
-	s.RUnlock()
+
+	// task IDs are never reused, even after a task is removed, so that a
+	// client polling a task is never handed a different task
+	s.Lock()
+	taskID := s.nextTaskID
+	s.nextTaskID++
+	s.Unlock()
 
 	t := &task{
 		Task: types.Task{
 			ID:        taskID,
 			QueueTime: now,
+			State:     types.TaskStateQueued,
 		},
 		resultSchemaValidationEnabled: s.resultSchemaValidationEnabled,
 		ctx: ctx.WithValue(context.TaskKey, fmt.Sprintf("%d", taskID)),
 	}
+	if tok, ok := context.AuthToken(ctx); ok {
+		t.User = tok.Subject
+	}
 
 	s.Lock()
 	s.tasks[taskID] = t
@@ -194,16 +261,99 @@ func (s *globalTaskService) TaskExecute(
 	return &t.Task
 }
 
-// TaskInspect returns the task with the specified ID.
+// TaskInspect returns a snapshot of the task with the specified ID.
 func (s *globalTaskService) TaskInspect(taskID int) *types.Task {
 	s.RLock()
 	defer s.RUnlock()
 	if t, ok := s.tasks[taskID]; ok {
-		return &t.Task
+		return t.snapshot()
 	}
 	return nil
 }
 
+// taskAuthorized returns a flag indicating whether the context's
+// authenticated subject is authorized to access the specified task.
+func (s *globalTaskService) taskAuthorized(
+	ctx types.Context, taskID int) bool {
+
+	s.RLock()
+	t, ok := s.tasks[taskID]
+	s.RUnlock()
+
+	return ok && authorizeTask(ctx, t) == nil
+}
+
+// TaskQueue returns the queued and running tasks of the storage services,
+// keyed by the services' names and sorted by their IDs.
+func (s *globalTaskService) TaskQueue() map[string][]*types.AdminTask {
+	now := time.Now().Unix()
+	queue := map[string][]*types.AdminTask{}
+
+	s.RLock()
+	defer s.RUnlock()
+
+	for _, t := range s.tasks {
+		if t.storService == nil {
+			continue
+		}
+		st := t.snapshot()
+		if st.State != types.TaskStateQueued &&
+			st.State != types.TaskStateRunning {
+			continue
+		}
+		at := &types.AdminTask{Task: st, Age: now - st.QueueTime}
+		if route, ok := context.Route(t.ctx); ok {
+			at.Operation = route.GetName()
+		}
+		name := t.storService.Name()
+		queue[name] = append(queue[name], at)
+	}
+
+	for _, tasks := range queue {
+		sort.Sort(adminTasksByID(tasks))
+	}
+	return queue
+}
+
+type adminTasksByID []*types.AdminTask
+
+func (a adminTasksByID) Len() int           { return len(a) }
+func (a adminTasksByID) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a adminTasksByID) Less(i, j int) bool { return a[i].ID < a[j].ID }
+
+// TaskCancel removes a queued task from its storage service's queue and
+// completes it with an error. Tasks that are running or completed cannot be
+// cancelled.
+func (s *globalTaskService) TaskCancel(taskID int) (*types.Task, error) {
+	s.RLock()
+	t, ok := s.tasks[taskID]
+	s.RUnlock()
+
+	if !ok {
+		return nil, utils.NewNotFoundError(fmt.Sprintf("%d", taskID))
+	}
+
+	svc, ok := t.storService.(*storageService)
+	if !ok || !svc.dequeue(t) {
+		return nil, utils.NewConflictError("task not queued")
+	}
+
+	completeTask(
+		t, nil, goof.WithField("taskID", taskID, "task cancelled"), 0)
+	return t.snapshot(), nil
+}
+
+// TaskAsync marks the specified task as one whose result is retrieved by the
+// client after it completes, so it is retained for at least the duration
+// specified by `libstorage.server.tasks.asyncRetention`.
+func (s *globalTaskService) TaskAsync(taskID int) {
+	s.Lock()
+	defer s.Unlock()
+	if t, ok := s.tasks[taskID]; ok {
+		t.async = true
+	}
+}
+
 // TaskWait blocks until the specified task is completed.
 func (s *globalTaskService) TaskWait(taskID int) {
 	<-s.TaskWaitC(taskID)
@@ -235,9 +385,50 @@ func (s *globalTaskService) TaskWaitC(taskID int) <-chan int {
 	return c
 }
 
+// taskTimeout returns the duration after which a task's context is cancelled.
+// The timeout configured for the task's operation takes precedence over the
+// default timeout, and the request that created the task may shorten either
+// with the timeout header. A duration of zero means the task never times out.
+func (s *globalTaskService) taskTimeout(t *task) time.Duration {
+	timeout, err := time.ParseDuration(
+		s.config.GetString(types.ConfigServerTasksTimeout))
+	if err != nil {
+		timeout = 0
+	}
+
+	if route, ok := context.Route(t.ctx); ok {
+		key := fmt.Sprintf(
+			"%s.%s", types.ConfigServerTasksTimeouts, route.GetName())
+		if v := s.config.GetString(key); v != "" {
+			if d, err := time.ParseDuration(v); err == nil {
+				timeout = d
+			}
+		}
+	}
+
+	if req, ok := context.HTTPRequest(t.ctx); ok {
+		if v := req.Header.Get(types.TimeoutHeader); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil || d <= 0 {
+				t.ctx.WithField("timeout", v).Warn("invalid timeout header")
+			} else if timeout == 0 || d < timeout {
+				timeout = d
+			}
+		}
+	}
+
+	return timeout
+}
+
 // taskRemoveAfter tells the task service to remove the task after the duration
-// specified by `libstorage.server.tasks.logTimeout`.
+// specified by `libstorage.server.tasks.logTimeout`, or for async tasks the
+// duration specified by `libstorage.server.tasks.asyncRetention` if it is
+// longer.
 func (s *globalTaskService) taskRemoveAfter(t *task) {
+	s.RLock()
+	async := t.async
+	s.RUnlock()
+
 	go func() {
 		logTimeoutDur, err := time.ParseDuration(
 			s.config.GetString(types.ConfigServerTasksLogTimeout))
@@ -245,6 +436,17 @@ func (s *globalTaskService) taskRemoveAfter(t *task) {
 			logTimeoutDur = time.Duration(time.Second * 60)
 		}
 
+		if async {
+			retentionDur, err := time.ParseDuration(
+				s.config.GetString(types.ConfigServerTasksAsyncRetention))
+			if err != nil {
+				retentionDur = time.Duration(time.Minute * 10)
+			}
+			if retentionDur > logTimeoutDur {
+				logTimeoutDur = retentionDur
+			}
+		}
+
 		// wait to remove the task
 		time.Sleep(logTimeoutDur)
 
@@ -265,6 +467,36 @@ func (s *globalTaskService) taskRemoveAfter(t *task) {
 	}()
 }
 
+// taskPending adjusts the number of tasks that are queued or running and
+// signals the callers of TaskDrainC once there are none.
+func (s *globalTaskService) taskPending(delta int) {
+	s.Lock()
+	defer s.Unlock()
+	s.pending += delta
+	if s.pending > 0 {
+		return
+	}
+	for _, c := range s.drainWaiters {
+		close(c)
+	}
+	s.drainWaiters = nil
+}
+
+// TaskDrainC returns a channel that is closed once there are no tasks queued
+// or running, including tasks that are enqueued after this function is
+// invoked.
+func (s *globalTaskService) TaskDrainC() <-chan int {
+	s.Lock()
+	defer s.Unlock()
+	c := make(chan int)
+	if s.pending == 0 {
+		close(c)
+		return c
+	}
+	s.drainWaiters = append(s.drainWaiters, c)
+	return c
+}
+
 // TaskWaitAll blocks until all the specified task are complete.
 func (s *globalTaskService) TaskWaitAll(taskIDs ...int) {
 	<-s.TaskWaitAllC(taskIDs...)
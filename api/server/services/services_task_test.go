@@ -0,0 +1,176 @@
+package services
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	gofigCore "github.com/akutz/gofig"
+	"github.com/akutz/goof"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codedellemc/libstorage/api/context"
+	"github.com/codedellemc/libstorage/api/types"
+)
+
+const testTaskServerName = "services-task-test"
+
+func newTestTaskService(t *testing.T) (*globalTaskService, types.Context) {
+	ctx := context.Background().WithValue(
+		context.ServerKey, testTaskServerName)
+	s := &globalTaskService{name: "global-task-service"}
+	if err := s.Init(ctx, gofigCore.New()); err != nil {
+		t.Fatal(err)
+	}
+	servicesByServerRWL.Lock()
+	servicesByServer[testTaskServerName] = &serviceContainer{taskService: s}
+	servicesByServerRWL.Unlock()
+	return s, ctx
+}
+
+// TestTasks asserts that tasks may be listed and inspected while they
+// execute.
+func TestTasks(t *testing.T) {
+	s, ctx := newTestTaskService(t)
+
+	var (
+		wg      sync.WaitGroup
+		release = make(chan struct{})
+		ids     []int
+	)
+	for i := 0; i < 10; i++ {
+		i := i
+		task := s.TaskExecute(ctx, func(
+			ctx types.Context) (interface{}, error) {
+			<-release
+			if i%2 == 1 {
+				return nil, goof.New("odd")
+			}
+			return i, nil
+		}, nil)
+		ids = append(ids, task.ID)
+	}
+
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				for tt := range s.Tasks() {
+					assert.NotEmpty(t, tt.State)
+				}
+				for _, id := range ids {
+					assert.NotNil(t, s.TaskInspect(id))
+				}
+			}
+		}()
+	}
+	close(release)
+	s.TaskWaitAll(ids...)
+	wg.Wait()
+
+	n := 0
+	for tt := range s.Tasks() {
+		n++
+		i := tt.Result
+		if tt.Error != nil {
+			assert.Equal(t, types.TaskState(types.TaskStateError), tt.State)
+			continue
+		}
+		assert.Equal(t, types.TaskState(types.TaskStateSuccess), tt.State)
+		assert.Equal(t, 0, i.(int)%2)
+		assert.NotZero(t, tt.CompleteTime)
+	}
+	assert.Equal(t, len(ids), n)
+	assert.Nil(t, s.TaskInspect(len(ids)+1))
+}
+
+func TestTaskQueueAndCancel(t *testing.T) {
+	s, ctx := newTestTaskService(t)
+	svc := &storageService{name: "ebs", tasks: newTaskPool(1)}
+	defer svc.tasks.close()
+
+	var (
+		running = make(chan struct{})
+		release = make(chan struct{})
+	)
+	enqueue := func(run types.StorageTaskRunFunc) *task {
+		tk := newStorageServiceTask(ctx, run, svc, nil)
+		svc.tasks.enqueue(tk)
+		return tk
+	}
+	first := enqueue(func(
+		ctx types.Context,
+		svc types.StorageService) (interface{}, error) {
+		close(running)
+		<-release
+		return "first", nil
+	})
+	second := enqueue(func(
+		ctx types.Context,
+		svc types.StorageService) (interface{}, error) {
+		return "second", nil
+	})
+	<-running
+
+	queue := s.TaskQueue()
+	if assert.Len(t, queue["ebs"], 2) {
+		assert.Equal(t, first.ID, queue["ebs"][0].ID)
+		assert.Equal(t, types.TaskState(types.TaskStateRunning),
+			queue["ebs"][0].State)
+		assert.Equal(t, second.ID, queue["ebs"][1].ID)
+		assert.Equal(t, types.TaskState(types.TaskStateQueued),
+			queue["ebs"][1].State)
+	}
+
+	cancelled, err := s.TaskCancel(second.ID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, types.TaskState(types.TaskStateError),
+			cancelled.State)
+		assert.EqualError(t, cancelled.Error, "task cancelled")
+	}
+
+	_, err = s.TaskCancel(first.ID)
+	assert.IsType(t, &types.ErrConflict{}, err)
+	_, err = s.TaskCancel(second.ID)
+	assert.IsType(t, &types.ErrConflict{}, err)
+	_, err = s.TaskCancel(-1)
+	assert.IsType(t, &types.ErrNotFound{}, err)
+
+	close(release)
+	s.TaskWait(first.ID)
+	assert.Equal(t, "first", s.TaskInspect(first.ID).Result)
+	assert.Empty(t, s.TaskQueue()["ebs"])
+}
+
+// TestTaskAsyncRetention asserts that a completed async task is retained
+// until the async retention elapses while other tasks are removed after the
+// log timeout, and that the IDs of removed tasks are not reused.
+func TestTaskAsyncRetention(t *testing.T) {
+	s, ctx := newTestTaskService(t)
+	s.config.Set(types.ConfigServerTasksLogTimeout, "10ms")
+	s.config.Set(types.ConfigServerTasksAsyncRetention, "1s")
+
+	run := func(ctx types.Context) (interface{}, error) { return "ok", nil }
+	done := s.TaskExecute(ctx, run, nil)
+	async := s.TaskExecute(ctx, run, nil)
+	s.TaskAsync(async.ID)
+	s.TaskAsync(-1)
+	<-s.TaskWaitC(done.ID)
+	<-s.TaskWaitC(async.ID)
+
+	time.Sleep(200 * time.Millisecond)
+	assert.Nil(t, s.TaskInspect(done.ID))
+	if tk := s.TaskInspect(async.ID); assert.NotNil(t, tk) {
+		assert.Equal(t, "ok", tk.Result)
+	}
+
+	next := s.TaskExecute(ctx, run, nil)
+	assert.True(t, next.ID > async.ID)
+	assert.NotEqual(t, done.ID, next.ID)
+
+	for i := 0; i < 100 && s.TaskInspect(async.ID) != nil; i++ {
+		time.Sleep(30 * time.Millisecond)
+	}
+	assert.Nil(t, s.TaskInspect(async.ID))
+}
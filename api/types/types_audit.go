@@ -0,0 +1,40 @@
+package types
+
+// AuditRecord describes the outcome of an operation that modified a storage
+// platform's resources.
+type AuditRecord struct {
+
+	// Time is the epoch time at which the operation completed.
+	Time int64 `json:"time"`
+
+	// TxID is the ID of the transaction to which the operation belongs.
+	TxID string `json:"txID,omitempty"`
+
+	// Subject is the authenticated entity that requested the operation.
+	Subject string `json:"subject,omitempty"`
+
+	// Service is the name of the service that performed the operation.
+	Service string `json:"service"`
+
+	// Operation is the name of the operation, ex. volumeCreate.
+	Operation string `json:"operation"`
+
+	// VolumeID is the ID of the volume on which the operation was performed.
+	VolumeID string `json:"volumeID,omitempty"`
+
+	// SnapshotID is the ID of the snapshot on which the operation was
+	// performed.
+	SnapshotID string `json:"snapshotID,omitempty"`
+
+	// TaskID is the ID of the task that executed the operation.
+	TaskID int `json:"taskID"`
+
+	// Result is the task state with which the operation completed.
+	Result TaskState `json:"result"`
+
+	// Error is the error that caused the operation to fail.
+	Error string `json:"error,omitempty"`
+
+	// Duration is the number of milliseconds the operation took to complete.
+	Duration int64 `json:"duration"`
+}
@@ -0,0 +1,20 @@
+package types
+
+// AuthToken is the identity established by a bearer token that was presented
+// by a client and validated by the server.
+type AuthToken struct {
+
+	// Subject is the name of the entity to which the token was issued. For
+	// static tokens this is the name under which the token is configured; for
+	// JSON web tokens it is the value of the "sub" claim.
+	Subject string `json:"sub"`
+
+	// Expires is the epoch time at which the token expires. A value of zero
+	// indicates the token does not expire.
+	Expires int64 `json:"exp,omitempty"`
+}
+
+// ContextLoggerField indicates to the context logger what data to log.
+func (t *AuthToken) ContextLoggerField() (string, interface{}) {
+	return "authSubject", t.Subject
+}
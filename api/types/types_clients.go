@@ -3,6 +3,7 @@ package types
 import (
 	"io"
 	"strings"
+	"time"
 )
 
 // ClientType is a client's type.
@@ -46,6 +47,49 @@ func ParseClientType(str string) ClientType {
 	return UnknownClientType
 }
 
+// ClientTransport is the means by which a client sends requests to a
+// libStorage server.
+type ClientTransport int
+
+const (
+	// UnknownClientTransport is an unknown client transport.
+	UnknownClientTransport ClientTransport = iota
+
+	// HTTPClientTransport is the default client transport -- a client that
+	// sends requests to the libStorage endpoint specified by the host address
+	// over TCP or a UNIX socket.
+	HTTPClientTransport
+
+	// EmbeddedClientTransport is a client transport that starts a libStorage
+	// server in the client's process and dispatches requests to it directly,
+	// removing any need for a separate libStorage endpoint.
+	EmbeddedClientTransport
+)
+
+// String returns the client transport's string representation.
+func (t ClientTransport) String() string {
+	switch t {
+	case HTTPClientTransport:
+		return "http"
+	case EmbeddedClientTransport:
+		return "embedded"
+	default:
+		return ""
+	}
+}
+
+// ParseClientTransport parses a new client transport.
+func ParseClientTransport(str string) ClientTransport {
+	str = strings.ToLower(str)
+	switch str {
+	case "http":
+		return HTTPClientTransport
+	case "embedded":
+		return EmbeddedClientTransport
+	}
+	return UnknownClientTransport
+}
+
 // Client is the libStorage client.
 type Client interface {
 
@@ -88,6 +132,24 @@ type APIClient interface {
 	// LogResponses enables or disables the logging of client HTTP responses.
 	LogResponses(enabled bool)
 
+	// BearerToken sets the token the client uses to authenticate with the
+	// server. An empty value disables authentication.
+	BearerToken(token string)
+
+	// TraceCollector sets the URL of the collector to which the client emits
+	// the spans that time its requests, as well as the percentage of new
+	// traces that are sampled. An empty URL disables tracing, except that
+	// requests made as part of an existing trace still propagate the trace to
+	// the server.
+	TraceCollector(url string, sampleRate int)
+
+	// Retries sets the number of times the client resends a request that
+	// cannot be delivered, as well as the delay before the first retry. The
+	// delay doubles with each retry. Requests that modify resources are sent
+	// with an idempotency key so that the server returns the original result
+	// if a request that was delivered is resent.
+	Retries(retries int, backoff time.Duration)
+
 	// Root returns a list of root resources.
 	Root(ctx Context) ([]string, error)
 
@@ -114,6 +176,16 @@ type APIClient interface {
 		service string,
 		attachments VolumeAttachmentsTypes) (VolumeMap, error)
 
+	// VolumesStream streams the Volumes of all Services, or of a single
+	// service if the service name is not empty. The callback is invoked for
+	// each volume as the server lists it. The stream is closed if the
+	// callback returns an error, and that error is returned.
+	VolumesStream(
+		ctx Context,
+		service string,
+		attachments VolumeAttachmentsTypes,
+		fn func(service string, volume *Volume) error) error
+
 	// VolumeInspect gets information about a single volume.
 	VolumeInspect(
 		ctx Context,
@@ -138,6 +210,12 @@ type APIClient interface {
 		service, volumeID string,
 		request *VolumeCopyRequest) (*Volume, error)
 
+	// VolumeExpand expands a single volume.
+	VolumeExpand(
+		ctx Context,
+		service, volumeID string,
+		request *VolumeExpandRequest) (*Volume, error)
+
 	// VolumeRemove removes a single volume.
 	VolumeRemove(
 		ctx Context,
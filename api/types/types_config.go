@@ -21,6 +21,9 @@ const (
 	// ConfigClientType is a config key.
 	ConfigClientType = ConfigClient + ".type"
 
+	// ConfigClientTransport is a config key.
+	ConfigClientTransport = ConfigClient + ".transport"
+
 	// ConfigHost is a config key.
 	ConfigHost = ConfigRoot + ".host"
 
@@ -57,6 +60,15 @@ const (
 	// ConfigLogHTTPResponses is a config key.
 	ConfigLogHTTPResponses = ConfigLogging + ".httpResponses"
 
+	// ConfigTracing is a config key.
+	ConfigTracing = ConfigRoot + ".tracing"
+
+	// ConfigTracingCollector is a config key.
+	ConfigTracingCollector = ConfigTracing + ".collector"
+
+	// ConfigTracingSampleRate is a config key.
+	ConfigTracingSampleRate = ConfigTracing + ".sampleRate"
+
 	// ConfigHTTPDisableKeepAlive is a config key.
 	ConfigHTTPDisableKeepAlive = ConfigRoot + ".http.disableKeepAlive"
 
@@ -81,9 +93,33 @@ const (
 	// ConfigExecutorNoDownload is a config key.
 	ConfigExecutorNoDownload = ConfigRoot + ".executor.disableDownload"
 
+	// ConfigExecutorAgent is a config key.
+	ConfigExecutorAgent = ConfigRoot + ".executor.agent"
+
 	// ConfigClientCacheInstanceID is a config key.
 	ConfigClientCacheInstanceID = ConfigClient + ".cache.instanceID"
 
+	// ConfigClientCacheLocalDevices is a config key.
+	ConfigClientCacheLocalDevices = ConfigClient + ".cache.localDevices"
+
+	// ConfigClientHeartbeatInterval is a config key.
+	ConfigClientHeartbeatInterval = ConfigClient + ".heartbeat.interval"
+
+	// ConfigClientAuthToken is a config key.
+	ConfigClientAuthToken = ConfigClient + ".auth.token"
+
+	// ConfigClientRetries is a config key.
+	ConfigClientRetries = ConfigClient + ".retries"
+
+	// ConfigClientRetryBackoff is a config key.
+	ConfigClientRetryBackoff = ConfigClient + ".retryBackoff"
+
+	// ConfigClientHosts is a config key.
+	ConfigClientHosts = ConfigClient + ".hosts"
+
+	// ConfigClientHealthCheckInterval is a config key.
+	ConfigClientHealthCheckInterval = ConfigClient + ".healthCheck.interval"
+
 	// ConfigTLS is a config key.
 	ConfigTLS = ConfigRoot + ".tls"
 
@@ -105,6 +141,15 @@ const (
 	// ConfigTLSKeyFile is a config key.
 	ConfigTLSKeyFile = ConfigTLS + ".keyFile"
 
+	// ConfigTLSClientCAFile is a config key.
+	ConfigTLSClientCAFile = ConfigTLS + ".clientCAFile"
+
+	// ConfigTLSClientCRLFile is a config key.
+	ConfigTLSClientCRLFile = ConfigTLS + ".clientCRLFile"
+
+	// ConfigTLSClientCertFingerprints is a config key.
+	ConfigTLSClientCertFingerprints = ConfigTLS + ".clientCertFingerprints"
+
 	// ConfigDeviceAttachTimeout is a config key.
 	ConfigDeviceAttachTimeout = ConfigRoot + ".device.attachTimeout"
 
@@ -123,4 +168,159 @@ const (
 
 	// ConfigServerTasksLogTimeout is a config key.
 	ConfigServerTasksLogTimeout = ConfigServerTasks + ".logTimeout"
+
+	// ConfigServerTasksAsyncRetention is a config key.
+	ConfigServerTasksAsyncRetention = ConfigServerTasks + ".asyncRetention"
+
+	// ConfigServerTasksWorkers is a config key.
+	ConfigServerTasksWorkers = ConfigServerTasks + ".workers"
+
+	// ConfigServerTasksDrainTimeout is a config key.
+	ConfigServerTasksDrainTimeout = ConfigServerTasks + ".drainTimeout"
+
+	// ConfigServerTasksTimeout is a config key.
+	ConfigServerTasksTimeout = ConfigServerTasks + ".timeout"
+
+	// ConfigServerTasksTimeouts is a config key.
+	ConfigServerTasksTimeouts = ConfigServerTasks + ".timeouts"
+
+	// ConfigServerAuth is a config key.
+	ConfigServerAuth = ConfigServer + ".auth"
+
+	// ConfigServerAuthTokens is a config key.
+	ConfigServerAuthTokens = ConfigServerAuth + ".tokens"
+
+	// ConfigServerAuthJWTKey is a config key.
+	ConfigServerAuthJWTKey = ConfigServerAuth + ".jwt.key"
+
+	// ConfigServerAuthJWKS is a config key.
+	ConfigServerAuthJWKS = ConfigServerAuth + ".jwt.jwks"
+
+	// ConfigServerAuthJWTAudience is a config key.
+	ConfigServerAuthJWTAudience = ConfigServerAuth + ".jwt.audience"
+
+	// ConfigServerAuthJWTIssuer is a config key.
+	ConfigServerAuthJWTIssuer = ConfigServerAuth + ".jwt.issuer"
+
+	// ConfigServerAuthAllow is a config key.
+	ConfigServerAuthAllow = ConfigServerAuth + ".allow"
+
+	// ConfigServerAuthAllowRead is a config key.
+	ConfigServerAuthAllowRead = ConfigServerAuth + ".allowRead"
+
+	// ConfigServerAudit is a config key.
+	ConfigServerAudit = ConfigServer + ".audit"
+
+	// ConfigServerAuditFile is a config key.
+	ConfigServerAuditFile = ConfigServerAudit + ".file"
+
+	// ConfigServerAuditSyslog is a config key.
+	ConfigServerAuditSyslog = ConfigServerAudit + ".syslog"
+
+	// ConfigServerAuditWebhook is a config key.
+	ConfigServerAuditWebhook = ConfigServerAudit + ".webhook"
+
+	// ConfigServerEvents is a config key.
+	ConfigServerEvents = ConfigServer + ".events"
+
+	// ConfigServerEventsWebhooks is a config key.
+	ConfigServerEventsWebhooks = ConfigServerEvents + ".webhooks"
+
+	// ConfigServerCache is a config key.
+	ConfigServerCache = ConfigServer + ".cache"
+
+	// ConfigServerCacheTTL is a config key.
+	ConfigServerCacheTTL = ConfigServerCache + ".ttl"
+
+	// ConfigServerIdempotency is a config key.
+	ConfigServerIdempotency = ConfigServer + ".idempotency"
+
+	// ConfigServerIdempotencyTTL is a config key.
+	ConfigServerIdempotencyTTL = ConfigServerIdempotency + ".ttl"
+
+	// ConfigServerHealth is a config key.
+	ConfigServerHealth = ConfigServer + ".health"
+
+	// ConfigServerHealthInterval is a config key.
+	ConfigServerHealthInterval = ConfigServerHealth + ".interval"
+
+	// ConfigServerHealthTimeout is a config key.
+	ConfigServerHealthTimeout = ConfigServerHealth + ".timeout"
+
+	// ConfigServerPlugins is a config key.
+	ConfigServerPlugins = ConfigServer + ".plugins"
+
+	// ConfigServerPluginsDir is a config key.
+	ConfigServerPluginsDir = ConfigServerPlugins + ".dir"
+
+	// ConfigServerHeartbeat is a config key.
+	ConfigServerHeartbeat = ConfigServer + ".heartbeat"
+
+	// ConfigServerHeartbeatTimeout is a config key.
+	ConfigServerHeartbeatTimeout = ConfigServerHeartbeat + ".timeout"
+
+	// ConfigServerVolumeAttachPreempt is a config key.
+	ConfigServerVolumeAttachPreempt = ConfigServer + ".volume.attach.preempt"
+
+	// ConfigServerPolicy is a config key.
+	ConfigServerPolicy = ConfigServer + ".policy"
+
+	// ConfigServerPolicyMaxVolumeSize is a config key.
+	ConfigServerPolicyMaxVolumeSize = ConfigServerPolicy + ".maxVolumeSize"
+
+	// ConfigServerPolicyMaxVolumeCount is a config key.
+	ConfigServerPolicyMaxVolumeCount = ConfigServerPolicy + ".maxVolumeCount"
+
+	// ConfigServerPolicyVolumeNamePattern is a config key.
+	ConfigServerPolicyVolumeNamePattern = ConfigServerPolicy +
+		".volumeNamePattern"
+
+	// ConfigServerPolicyRequiredOpts is a config key.
+	ConfigServerPolicyRequiredOpts = ConfigServerPolicy + ".requiredOpts"
+
+	// ConfigServerRateLimit is a config key.
+	ConfigServerRateLimit = ConfigServer + ".rateLimit"
+
+	// ConfigServerRateLimitReadRate is a config key.
+	ConfigServerRateLimitReadRate = ConfigServerRateLimit + ".read.rate"
+
+	// ConfigServerRateLimitReadBurst is a config key.
+	ConfigServerRateLimitReadBurst = ConfigServerRateLimit + ".read.burst"
+
+	// ConfigServerRateLimitMutateRate is a config key.
+	ConfigServerRateLimitMutateRate = ConfigServerRateLimit + ".mutate.rate"
+
+	// ConfigServerRateLimitMutateBurst is a config key.
+	ConfigServerRateLimitMutateBurst = ConfigServerRateLimit + ".mutate.burst"
+
+	// ConfigServerCORS is a config key.
+	ConfigServerCORS = ConfigServer + ".cors"
+
+	// ConfigServerCORSAllowedOrigins is a config key.
+	ConfigServerCORSAllowedOrigins = ConfigServerCORS + ".allowedOrigins"
+
+	// ConfigServerCORSAllowCredentials is a config key.
+	ConfigServerCORSAllowCredentials = ConfigServerCORS + ".allowCredentials"
+
+	// ConfigServerCORSMaxAge is a config key.
+	ConfigServerCORSMaxAge = ConfigServerCORS + ".maxAge"
+
+	// ConfigServerCompression is a config key.
+	ConfigServerCompression = ConfigServer + ".compression"
+
+	// ConfigServerCompressionDisabled is a config key.
+	ConfigServerCompressionDisabled = ConfigServerCompression + ".disabled"
+
+	// ConfigServerCompressionMinSize is a config key.
+	ConfigServerCompressionMinSize = ConfigServerCompression + ".minSize"
+
+	// ConfigServerCompressionMaxRequestSize is a config key.
+	ConfigServerCompressionMaxRequestSize = ConfigServerCompression +
+		".maxRequestSize"
+
+	// ConfigServerUI is a config key.
+	ConfigServerUI = ConfigServer + ".ui"
+
+	// ConfigServerUIEnabled is a config key.
+	ConfigServerUIEnabled = ConfigServerUI + ".enabled"
 )
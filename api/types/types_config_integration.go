@@ -19,9 +19,18 @@ const (
 	//ConfigIgVolOpsMountPath is a config key.
 	ConfigIgVolOpsMountPath = ConfigIgVolOpsMount + ".path"
 
+	//ConfigIgVolOpsMountPathTemplate is a config key.
+	ConfigIgVolOpsMountPathTemplate = ConfigIgVolOpsMount + ".pathTemplate"
+
+	//ConfigIgVolOpsMountGrow is a config key.
+	ConfigIgVolOpsMountGrow = ConfigIgVolOpsMount + ".grow"
+
 	//ConfigIgVolOpsMountRootPath is a config key.
 	ConfigIgVolOpsMountRootPath = ConfigIgVolOpsMount + ".rootPath"
 
+	//ConfigIgVolOpsMountStateFile is a config key.
+	ConfigIgVolOpsMountStateFile = ConfigIgVolOpsMount + ".stateFile"
+
 	//ConfigIgVolOpsUnmount is a config key.
 	ConfigIgVolOpsUnmount = ConfigIgVolOps + ".unmount"
 
@@ -37,8 +37,42 @@ const (
 	// LSXCmdSupported is the command to execute to find out if an executor
 	// is valid for a given platform on the current host.
 	LSXCmdSupported = "supported"
+
+	// LSXCmdAgent is the command to execute to run the executor binary as an
+	// agent that serves the other commands over a UNIX socket.
+	LSXCmdAgent = "agent"
+
+	// LSXAgentRPCRun is the name of the RPC with which a client runs a
+	// command in the executor agent.
+	LSXAgentRPCRun = "Agent.Run"
 )
 
+// LSXAgentRequest is a request to run a command in the executor agent.
+type LSXAgentRequest struct {
+
+	// Args are the arguments with which the executor binary would be
+	// executed, beginning with the name of the executor.
+	Args []string
+
+	// Config is the client's configuration as JSON. The executor binary
+	// receives the same configuration as environment variables.
+	Config []byte
+}
+
+// LSXAgentReply is the executor agent's reply to an LSXAgentRequest.
+type LSXAgentReply struct {
+
+	// Output is what the executor binary would have written to stdout.
+	Output []byte
+
+	// ExitCode is the exit code with which the executor binary would have
+	// exited.
+	ExitCode int
+
+	// Error is the message of the command's error, if any.
+	Error string
+}
+
 const (
 
 	// DeviceScanQuick performs a shallow, quick scan.
@@ -150,6 +184,25 @@ type StorageExecutorWithSupported interface {
 		opts Store) (bool, error)
 }
 
+// StorageExecutorWithDeviceReady is an executor whose devices do not appear
+// in the local devices list until they are mounted, such as the file systems
+// and buckets of network storage platforms. Instead of waiting for the
+// attach token to appear in the local devices list, the executor binary
+// waits until the executor reports that the device identified by the token
+// is ready to be mounted.
+type StorageExecutorWithDeviceReady interface {
+	StorageExecutorFunctions
+
+	// DeviceReady returns a flag indicating whether or not the device
+	// identified by the attach token is ready to be mounted on the host on
+	// which the executor resides. An error is returned only if the device
+	// can never become ready.
+	DeviceReady(
+		ctx Context,
+		token string,
+		opts Store) (bool, error)
+}
+
 // ProvidesStorageExecutorCLI is a type that provides the StorageExecutorCLI.
 type ProvidesStorageExecutorCLI interface {
 	// XCLI returns the StorageExecutorCLI.
@@ -172,3 +225,19 @@ type StorageExecutorCLI interface {
 		ctx Context,
 		opts *WaitForDeviceOpts) (bool, *LocalDevices, error)
 }
+
+// StorageExecutorCLIWithCache is a StorageExecutorCLI that caches the results
+// of the executor's InstanceID and LocalDevices commands.
+type StorageExecutorCLIWithCache interface {
+	StorageExecutorCLI
+
+	// InvalidateInstanceID removes the cached instance ID of the context's
+	// service. The cached instance IDs of all services are removed if the
+	// context does not specify a service.
+	InvalidateInstanceID(ctx Context) error
+
+	// InvalidateLocalDevices removes the cached local devices of the
+	// context's service. The cached local devices of all services are
+	// removed if the context does not specify a service.
+	InvalidateLocalDevices(ctx Context) error
+}
@@ -7,14 +7,34 @@ type NewOSDriver func() OSDriver
 type DeviceMountOpts struct {
 	MountOptions string
 	MountLabel   string
-	Opts         Store
+
+	// ReadOnly mounts the device read-only.
+	ReadOnly bool
+
+	// Bind bind mounts the path specified as the device name, such as the
+	// mount point of a previously mounted volume, instead of mounting a
+	// device. Combined with ReadOnly this exposes a volume read-only at an
+	// additional path while the original mount remains writable.
+	Bind bool
+
+	Opts Store
 }
 
 // DeviceFormatOpts are options when formatting a device.
 type DeviceFormatOpts struct {
 	NewFSType   string
 	OverwriteFS bool
-	Opts        Store
+
+	// Grow indicates that an existing file system should be resized to fill
+	// its device, such as after the underlying volume has been expanded.
+	Grow bool
+
+	// MountPoint is the path at which the device is mounted. File systems
+	// such as xfs and btrfs are grown through their mount point, which is
+	// looked up in the mount table when MountPoint is empty.
+	MountPoint string
+
+	Opts Store
 }
 
 // OSDriverManager is the management wrapper for an OSDriver.
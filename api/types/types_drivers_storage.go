@@ -138,7 +138,27 @@ func (v VolumeAttachmentsTypes) Unattached() bool {
 // VolumesOpts are options when inspecting a volume.
 type VolumesOpts struct {
 	Attachments VolumeAttachmentsTypes
-	Opts        Store
+
+	// Pagination, if not nil, limits the volumes returned to a single page.
+	// Drivers that are able to page through a backend's volumes may use it to
+	// reduce the number of volumes retrieved, but the server always applies
+	// the pagination to the volumes a driver returns.
+	Pagination *VolumesPagination
+
+	Opts Store
+}
+
+// VolumesPagination are options for retrieving a single page of volumes.
+// Volumes are paged in the order of their IDs.
+type VolumesPagination struct {
+
+	// Limit is the maximum number of volumes in the page. A value of zero
+	// indicates no limit.
+	Limit int
+
+	// Marker is the ID of the last volume in the previous page. Only volumes
+	// with IDs that sort after the marker are included in the page.
+	Marker string
 }
 
 // VolumeInspectOpts are options when inspecting a volume.
@@ -233,6 +253,14 @@ type StorageDriver interface {
 		volumeName string,
 		opts Store) (*Volume, error)
 
+	// VolumeExpand expands a volume to a new size in GB. Drivers for which
+	// expanding a volume is not possible should return ErrNotImplemented.
+	VolumeExpand(
+		ctx Context,
+		volumeID string,
+		newSize int64,
+		opts Store) (*Volume, error)
+
 	// VolumeSnapshot snapshots a volume.
 	VolumeSnapshot(
 		ctx Context,
@@ -294,3 +322,59 @@ type StorageDriverWithLogin interface {
 	Login(
 		ctx Context) (interface{}, error)
 }
+
+// StorageDriverWithHealthCheck is a StorageDriver with a HealthCheck function.
+type StorageDriverWithHealthCheck interface {
+	StorageDriver
+
+	// HealthCheck returns an error if the storage platform cannot be reached
+	// or rejects the driver's credentials. The check should be inexpensive
+	// since it is performed periodically. ErrNotImplemented is returned if
+	// the driver does not provide a health check.
+	HealthCheck(
+		ctx Context) error
+}
+
+// StorageDriverWithCapabilities is a StorageDriver that advertises its
+// capabilities.
+type StorageDriverWithCapabilities interface {
+	StorageDriver
+
+	// Capabilities returns the operations and limits the driver supports,
+	// which are included in the information about the driver's services.
+	// ErrNotImplemented is returned if the driver does not advertise its
+	// capabilities.
+	Capabilities(
+		ctx Context) (*StorageCapabilities, error)
+}
+
+// StorageDriverWithOptsSchema is a StorageDriver that declares the
+// driver-specific options its operations accept.
+type StorageDriverWithOptsSchema interface {
+	StorageDriver
+
+	// OptsSchema returns the JSON schema against which the opts object of a
+	// request for the operation with the specified route name, such as
+	// volumeCreate, is validated. The server rejects requests whose options
+	// do not match the schema. Nil is returned if the operation's options
+	// are not validated.
+	OptsSchema(
+		ctx Context,
+		operation string) []byte
+}
+
+// StorageDriverWithVolumesIterator is a StorageDriver that can yield the
+// volumes it lists one at a time.
+type StorageDriverWithVolumesIterator interface {
+	StorageDriver
+
+	// VolumesIterate invokes the callback for each volume as soon as the
+	// volume is listed so that large inventories need not be enumerated
+	// before the first volume is returned. Iteration stops if the callback
+	// returns an error, and that error is returned. ErrNotImplemented is
+	// returned if the driver does not iterate volumes.
+	VolumesIterate(
+		ctx Context,
+		opts *VolumesOpts,
+		fn func(volume *Volume) error) error
+}
@@ -18,6 +18,30 @@ type ErrUnsupportedForClientType struct{ goof.Goof }
 // ErrBadAdminToken occurs when a bad admin token is provided.
 type ErrBadAdminToken struct{ goof.Goof }
 
+// ErrUnauthorized occurs when a request is missing a valid bearer token.
+type ErrUnauthorized struct{ goof.Goof }
+
+// ErrForbidden occurs when an authenticated request is not permitted to
+// perform an operation on a service.
+type ErrForbidden struct{ goof.Goof }
+
+// ErrPolicyViolation occurs when an operation on a service violates one of
+// the service's policies.
+type ErrPolicyViolation struct{ goof.Goof }
+
+// ErrBadRequest occurs when a request's payload is malformed or does not
+// match the request's JSON schema.
+type ErrBadRequest struct{ goof.Goof }
+
+// ErrTooManyRequests occurs when a client exceeds the rate at which it may
+// issue requests.
+type ErrTooManyRequests struct{ goof.Goof }
+
+// ErrConflict occurs when a request conflicts with another request that is in
+// progress, such as an earlier attempt of a request with the same idempotency
+// key.
+type ErrConflict struct{ goof.Goof }
+
 // ErrNotFound occurs when a Driver inspects or sends an operation to a
 // resource that cannot be found.
 type ErrNotFound struct{ goof.Goof }
@@ -47,3 +71,123 @@ type ErrBatchProcess struct{ goof.Goof }
 // ErrBadFilter occurs when a bad filter is supplied via the filter query
 // string.
 type ErrBadFilter struct{ goof.Goof }
+
+// ErrAlreadyMounted occurs when a device is mounted to a path at which it is
+// already mounted and the caller requested the operation fail in that case.
+type ErrAlreadyMounted struct{ goof.Goof }
+
+// ErrMountTimedOut occurs when a mount operation does not complete within its
+// configured timeout.
+type ErrMountTimedOut struct{ goof.Goof }
+
+// ErrEncryptedDevice occurs when an operation that requires a file system is
+// performed against a device that contains an encrypted volume, such as LUKS.
+type ErrEncryptedDevice struct{ goof.Goof }
+
+// ErrorCode classifies an error so that clients may handle the error without
+// parsing its message. An error's code is recorded in the error's errorCode
+// field, which is preserved when the error is returned to a client.
+type ErrorCode string
+
+const (
+	// ErrorCodeNotFound indicates a resource does not exist.
+	ErrorCodeNotFound ErrorCode = "notFound"
+
+	// ErrorCodeAlreadyExists indicates a resource cannot be created because
+	// a resource with the same name already exists.
+	ErrorCodeAlreadyExists ErrorCode = "alreadyExists"
+
+	// ErrorCodeBusy indicates a resource is in use or in a state that does
+	// not permit the operation, and the operation may succeed if retried.
+	ErrorCodeBusy ErrorCode = "busy"
+
+	// ErrorCodeQuotaExceeded indicates an operation exceeds one of the
+	// storage platform's quotas or limits.
+	ErrorCodeQuotaExceeded ErrorCode = "quotaExceeded"
+
+	// ErrorCodeAuthFailed indicates the storage platform rejected the
+	// credentials with which the driver is configured.
+	ErrorCodeAuthFailed ErrorCode = "authFailed"
+
+	// ErrorCodePermissionDenied indicates the storage platform or the host
+	// does not permit the operation, such as a file that cannot be written.
+	ErrorCodePermissionDenied ErrorCode = "permissionDenied"
+
+	// ErrorCodeTimeout indicates an operation did not complete in time.
+	ErrorCodeTimeout ErrorCode = "timeout"
+
+	// ErrorCodeInProgress indicates a request was repeated with an
+	// idempotency key while the original request is executing, and the
+	// request may succeed if retried.
+	ErrorCodeInProgress ErrorCode = "inProgress"
+
+	// ErrorCodeIdempotencyKeyReused indicates an idempotency key was reused
+	// for a request that differs from the original request.
+	ErrorCodeIdempotencyKeyReused ErrorCode = "idempotencyKeyReused"
+)
+
+// ErrorCodeField is the name of the field in which an error's code is
+// recorded.
+const ErrorCodeField = "errorCode"
+
+// String returns the error code's string representation.
+func (c ErrorCode) String() string {
+	return string(c)
+}
+
+// ErrorCodeOf returns the code of an error, or an empty string if the error
+// does not have a code. Both the errors returned by drivers and the errors
+// clients receive from the server are supported.
+func ErrorCodeOf(err error) ErrorCode {
+	ef, ok := err.(interface {
+		Fields() map[string]interface{}
+	})
+	if !ok {
+		return ""
+	}
+	switch tv := ef.Fields()[ErrorCodeField].(type) {
+	case ErrorCode:
+		return tv
+	case string:
+		return ErrorCode(tv)
+	}
+	return ""
+}
+
+// IsErrorCode returns a flag indicating whether an error has the specified
+// code.
+func IsErrorCode(err error, code ErrorCode) bool {
+	return err != nil && ErrorCodeOf(err) == code
+}
+
+// ErrAlreadyExists occurs when a resource cannot be created because a
+// resource with the same name already exists.
+type ErrAlreadyExists struct{ goof.Goof }
+
+// ErrBusy occurs when a resource is in use or in a state that does not permit
+// an operation.
+type ErrBusy struct{ goof.Goof }
+
+// ErrQuotaExceeded occurs when an operation exceeds one of the storage
+// platform's quotas or limits.
+type ErrQuotaExceeded struct{ goof.Goof }
+
+// ErrAuthFailed occurs when the storage platform rejects the credentials
+// with which a driver is configured.
+type ErrAuthFailed struct{ goof.Goof }
+
+// ErrPermissionDenied occurs when the storage platform or the host does not
+// permit an operation.
+type ErrPermissionDenied struct{ goof.Goof }
+
+// ErrTimeout occurs when a storage platform reports that an operation did not
+// complete in time.
+type ErrTimeout struct{ goof.Goof }
+
+// ErrInProgress occurs when a request is repeated with an idempotency key
+// while the original request is executing.
+type ErrInProgress struct{ goof.Goof }
+
+// ErrIdempotencyKeyReused occurs when an idempotency key is reused for a
+// request with a different method, path, query, or payload.
+type ErrIdempotencyKeyReused struct{ goof.Goof }
@@ -0,0 +1,55 @@
+package types
+
+// EventType is the type of a volume lifecycle event.
+type EventType string
+
+const (
+	// EventVolumeCreated occurs when a volume is created, copied, or created
+	// from a snapshot.
+	EventVolumeCreated EventType = "volume.created"
+
+	// EventVolumeRemoved occurs when a volume is removed.
+	EventVolumeRemoved EventType = "volume.removed"
+
+	// EventVolumeAttached occurs when a volume is attached to an instance.
+	EventVolumeAttached EventType = "volume.attached"
+
+	// EventVolumeDetached occurs when a volume is detached from an instance.
+	EventVolumeDetached EventType = "volume.detached"
+
+	// EventSnapshotCreated occurs when a snapshot is taken of a volume or
+	// copied from another snapshot.
+	EventSnapshotCreated EventType = "snapshot.created"
+
+	// EventSnapshotRemoved occurs when a snapshot is removed.
+	EventSnapshotRemoved EventType = "snapshot.removed"
+)
+
+// Event is a volume lifecycle event.
+type Event struct {
+
+	// ID is the event's ID. Event IDs increase monotonically for the lifetime
+	// of the server.
+	ID int64 `json:"id"`
+
+	// Type is the event's type.
+	Type EventType `json:"type"`
+
+	// Time is the epoch time at which the event occurred.
+	Time int64 `json:"time"`
+
+	// Service is the name of the service on which the event occurred.
+	Service string `json:"service"`
+
+	// VolumeID is the ID of the volume to which the event pertains.
+	VolumeID string `json:"volumeID,omitempty"`
+
+	// SnapshotID is the ID of the snapshot to which the event pertains.
+	SnapshotID string `json:"snapshotID,omitempty"`
+
+	// Volume is the volume to which the event pertains, if available.
+	Volume *Volume `json:"volume,omitempty"`
+
+	// Snapshot is the snapshot to which the event pertains, if available.
+	Snapshot *Snapshot `json:"snapshot,omitempty"`
+}
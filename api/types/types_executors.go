@@ -0,0 +1,71 @@
+package types
+
+import (
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+const (
+	// ExecutorDefaultArch is the architecture of the executors whose names do
+	// not include an architecture.
+	ExecutorDefaultArch = "amd64"
+
+	// LibcMusl is the name of the musl C library. The executors built for
+	// Linux hosts that use musl, such as Alpine, are statically linked and
+	// their names end with "-musl".
+	LibcMusl = "musl"
+)
+
+// ExecutorName returns the name of the executor for the provided OS,
+// architecture, and C library. The architecture is omitted from the name of
+// an amd64 executor, and the C library is omitted unless it is musl.
+func ExecutorName(goos, goarch, libc string) string {
+	parts := []string{"lsx", goos}
+	if goarch != "" && goarch != ExecutorDefaultArch {
+		parts = append(parts, goarch)
+	}
+	if libc == LibcMusl {
+		parts = append(parts, LibcMusl)
+	}
+	name := strings.Join(parts, "-")
+	if goos == "windows" {
+		name = name + ".exe"
+	}
+	return name
+}
+
+// ParseExecutorName returns the OS, architecture, and C library for which
+// the executor with the provided name is built. The C library is empty
+// unless it is musl. A false value is returned if the name is not the name
+// of an executor.
+func ParseExecutorName(name string) (goos, goarch, libc string, ok bool) {
+	parts := strings.Split(strings.TrimSuffix(name, ".exe"), "-")
+	if len(parts) < 2 || len(parts) > 4 || parts[0] != "lsx" {
+		return "", "", "", false
+	}
+	goos, goarch, parts = parts[1], ExecutorDefaultArch, parts[2:]
+	if len(parts) > 0 && parts[len(parts)-1] == LibcMusl {
+		libc, parts = LibcMusl, parts[:len(parts)-1]
+	}
+	switch len(parts) {
+	case 0:
+	case 1:
+		goarch = parts[0]
+	default:
+		return "", "", "", false
+	}
+	return goos, goarch, libc, true
+}
+
+// HostLibc returns LibcMusl if the host is a Linux host that uses musl,
+// otherwise an empty string is returned.
+func HostLibc() string {
+	if runtime.GOOS != "linux" {
+		return ""
+	}
+	if m, _ := filepath.Glob("/lib/ld-musl-*.so.1"); len(m) > 0 {
+		return LibcMusl
+	}
+	return ""
+}
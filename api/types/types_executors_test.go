@@ -0,0 +1,40 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExecutorName(t *testing.T) {
+	assert.Equal(t, "lsx-linux", ExecutorName("linux", "amd64", ""))
+	assert.Equal(t, "lsx-linux-arm64", ExecutorName("linux", "arm64", ""))
+	assert.Equal(t, "lsx-linux-musl", ExecutorName("linux", "amd64", "musl"))
+	assert.Equal(t,
+		"lsx-linux-arm64-musl", ExecutorName("linux", "arm64", "musl"))
+	assert.Equal(t, "lsx-darwin", ExecutorName("darwin", "amd64", ""))
+	assert.Equal(t, "lsx-windows.exe", ExecutorName("windows", "amd64", ""))
+}
+
+func TestParseExecutorName(t *testing.T) {
+	assertParse := func(name, goos, goarch, libc string) {
+		o, a, l, ok := ParseExecutorName(name)
+		assert.True(t, ok, name)
+		assert.Equal(t, goos, o, name)
+		assert.Equal(t, goarch, a, name)
+		assert.Equal(t, libc, l, name)
+	}
+	assertParse("lsx-linux", "linux", "amd64", "")
+	assertParse("lsx-linux-arm64", "linux", "arm64", "")
+	assertParse("lsx-linux-musl", "linux", "amd64", "musl")
+	assertParse("lsx-linux-arm64-musl", "linux", "arm64", "musl")
+	assertParse("lsx-darwin-arm64", "darwin", "arm64", "")
+	assertParse("lsx-windows.exe", "windows", "amd64", "")
+
+	for _, name := range []string{
+		"lsx", "rexray-linux", "lsx-linux-arm64-v8-musl", "lsx-linux-a-b",
+	} {
+		_, _, _, ok := ParseExecutorName(name)
+		assert.False(t, ok, name)
+	}
+}
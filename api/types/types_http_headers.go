@@ -18,4 +18,42 @@ const (
 	// for the first time. This header is provided with every response sent
 	// from the server.
 	ServerNameHeader = "Libstorage-Servername"
+
+	// NextMarkerHeader is the HTTP header that contains the marker used to
+	// request the next page of a paginated list of volumes. The header is
+	// omitted when there are no more volumes.
+	NextMarkerHeader = "Libstorage-Nextmarker"
+
+	// AuthorizationHeader is the HTTP header that contains the bearer token
+	// used to authenticate a client with the server.
+	AuthorizationHeader = "Authorization"
+
+	// TraceIDHeader is the B3 HTTP header that contains the ID of the trace
+	// of which a request is a part.
+	TraceIDHeader = "X-B3-Traceid"
+
+	// SpanIDHeader is the B3 HTTP header that contains the ID of the span
+	// that sent a request.
+	SpanIDHeader = "X-B3-Spanid"
+
+	// ParentSpanIDHeader is the B3 HTTP header that contains the ID of the
+	// parent of the span that sent a request.
+	ParentSpanIDHeader = "X-B3-Parentspanid"
+
+	// SampledHeader is the B3 HTTP header that indicates whether a request's
+	// trace is sampled.
+	SampledHeader = "X-B3-Sampled"
+
+	// IdempotencyKeyHeader is the HTTP header that identifies a request that
+	// modifies a resource so that the server returns the original result if
+	// the request is repeated.
+	IdempotencyKeyHeader = "Libstorage-Idempotencykey"
+
+	// RetryAfterHeader is the HTTP header with which the server indicates
+	// that a rejected request may be resent.
+	RetryAfterHeader = "Retry-After"
+
+	// TimeoutHeader is the HTTP header that contains the duration, such as
+	// 30s, after which the server cancels the operation a request performs.
+	TimeoutHeader = "Libstorage-Timeout"
 )
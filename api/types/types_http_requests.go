@@ -21,6 +21,12 @@ type VolumeCopyRequest struct {
 	Opts       map[string]interface{} `json:"opts,omitempty"`
 }
 
+// VolumeExpandRequest is the JSON body for expanding a volume.
+type VolumeExpandRequest struct {
+	NewSize int64                  `json:"newSize"`
+	Opts    map[string]interface{} `json:"opts,omitempty"`
+}
+
 // VolumeSnapshotRequest is the JSON body for snapshotting a volume.
 type VolumeSnapshotRequest struct {
 	SnapshotName string                 `json:"snapshotName"`
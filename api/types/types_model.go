@@ -23,6 +23,20 @@ type SnapshotMap map[string]*Snapshot
 // ServiceVolumeMap is the response for listing volumes for multiple services.
 type ServiceVolumeMap map[string]VolumeMap
 
+// VolumeStreamItem is a line of the newline-delimited JSON response for
+// streaming the volumes of one or more services. An item with an error is
+// the last item of a stream that failed after the response was started.
+type VolumeStreamItem struct {
+	// Service is the name of the service to which the volume belongs.
+	Service string `json:"service"`
+
+	// Volume is the volume.
+	Volume *Volume `json:"volume,omitempty" yaml:",omitempty"`
+
+	// Error is the reason the stream failed.
+	Error string `json:"error,omitempty" yaml:",omitempty"`
+}
+
 // ServiceSnapshotMap is the response for listing snapshots for multiple
 // services.
 type ServiceSnapshotMap map[string]SnapshotMap
@@ -245,6 +259,16 @@ type ExecutorInfo struct {
 
 	// LastModified is the time the executor was last modified as an epoch.
 	LastModified int64 `json:"lastModified" yaml:"lastModified"`
+
+	// OS is the operating system for which the executor is built.
+	OS string `json:"os,omitempty" yaml:",omitempty"`
+
+	// Arch is the architecture for which the executor is built.
+	Arch string `json:"arch,omitempty" yaml:",omitempty"`
+
+	// Libc is the C library of the hosts for which the executor is built. It
+	// is empty unless the executor is built for hosts that use musl.
+	Libc string `json:"libc,omitempty" yaml:",omitempty"`
 }
 
 // ServiceInfo is information about a service.
@@ -259,6 +283,75 @@ type ServiceInfo struct {
 	Driver *DriverInfo `json:"driver"`
 }
 
+// HealthStatus is the health of a service.
+type HealthStatus string
+
+const (
+	// HealthStatusUnknown indicates the service has not been checked yet.
+	HealthStatusUnknown HealthStatus = "unknown"
+
+	// HealthStatusHealthy indicates the service's storage platform is
+	// reachable and accepts the driver's credentials.
+	HealthStatusHealthy HealthStatus = "healthy"
+
+	// HealthStatusDegraded indicates the service's most recent health check
+	// failed.
+	HealthStatusDegraded HealthStatus = "degraded"
+)
+
+// ServiceHealth is the result of a service's most recent health check.
+type ServiceHealth struct {
+	// Name is the service's name.
+	Name string `json:"name"`
+
+	// Status is the service's health.
+	Status HealthStatus `json:"status"`
+
+	// Error is the reason the health check failed.
+	Error string `json:"error,omitempty" yaml:",omitempty"`
+
+	// CheckedAt is the time of the health check as an epoch.
+	CheckedAt int64 `json:"checkedAt,omitempty" yaml:"checkedAt,omitempty"`
+
+	// Latency is the duration of the health check in milliseconds.
+	Latency int64 `json:"latency,omitempty" yaml:",omitempty"`
+}
+
+// ServerHealth is the health of the server's services.
+type ServerHealth struct {
+	// Status is HealthStatusHealthy if all of the services are healthy;
+	// otherwise it is HealthStatusDegraded.
+	Status HealthStatus `json:"status"`
+
+	// Services is a map of the services' names to their health.
+	Services map[string]*ServiceHealth `json:"services"`
+}
+
+// ServicesReload is the result of reloading the server's services.
+type ServicesReload struct {
+	// Added are the names of the services that were created.
+	Added []string `json:"added,omitempty" yaml:",omitempty"`
+
+	// Updated are the names of the services that were recreated because
+	// their configuration changed.
+	Updated []string `json:"updated,omitempty" yaml:",omitempty"`
+
+	// Removed are the names of the services that were removed.
+	Removed []string `json:"removed,omitempty" yaml:",omitempty"`
+}
+
+// AdminTask is a queued or running task as it is listed by the server's
+// admin resource.
+type AdminTask struct {
+	*Task
+
+	// Operation is the name of the route that created the task.
+	Operation string `json:"operation,omitempty" yaml:",omitempty"`
+
+	// Age is the number of seconds since the task was queued.
+	Age int64 `json:"age" yaml:"age"`
+}
+
 // DriverInfo is information about a driver.
 type DriverInfo struct {
 	// Name is the driver's name.
@@ -269,6 +362,29 @@ type DriverInfo struct {
 
 	// NextDevice is the next available device information for the service.
 	NextDevice *NextDeviceInfo `json:"nextDevice,omitempty" yaml:"nextDevice,omitempty"`
+
+	// Capabilities are the operations and limits the driver supports. This
+	// field is omitted if the driver does not advertise its capabilities.
+	Capabilities *StorageCapabilities `json:"capabilities,omitempty" yaml:",omitempty"`
+}
+
+// StorageCapabilities describes the operations and limits a storage driver
+// supports so that clients may adapt their behavior rather than discover
+// unsupported operations by their errors.
+type StorageCapabilities struct {
+	// SupportsSnapshots indicates whether volumes can be snapshotted.
+	SupportsSnapshots bool `json:"supportsSnapshots" yaml:"supportsSnapshots"`
+
+	// SupportsExpand indicates whether volumes can be expanded.
+	SupportsExpand bool `json:"supportsExpand" yaml:"supportsExpand"`
+
+	// AttachSemantics is how attached volumes are consumed: as block
+	// devices, as network file systems, or as object stores.
+	AttachSemantics StorageType `json:"attachSemantics" yaml:"attachSemantics"`
+
+	// MaxVolumeSize is the maximum size of a volume in GB. A value of zero
+	// indicates that the maximum size is not known.
+	MaxVolumeSize int64 `json:"maxVolumeSize,omitempty" yaml:"maxVolumeSize,omitempty"`
 }
 
 // NextDeviceInfo assists the libStorage client in determining the
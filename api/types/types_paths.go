@@ -141,10 +141,8 @@ func (k fileKey) defaultVal() string {
 		return "/var/run/libstorage"
 	case LSX:
 		switch runtime.GOOS {
-		case "linux", "darwin":
-			return fmt.Sprintf("lsx-%s", runtime.GOOS)
-		case "windows":
-			return "lsx-windows.exe"
+		case "linux", "darwin", "windows":
+			return ExecutorName(runtime.GOOS, runtime.GOARCH, HostLibc())
 		}
 	}
 	return ""
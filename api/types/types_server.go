@@ -2,7 +2,10 @@ package types
 
 import (
 	"io"
+	"net/http"
 	"strings"
+
+	gofig "github.com/akutz/gofig/types"
 )
 
 // EndpointType is a type of endpoint.
@@ -53,3 +56,15 @@ type Server interface {
 	// Addrs returns the server's configured endpoint addresses.
 	Addrs() []string
 }
+
+// EmbeddedServer is a libStorage server that does not listen on any
+// endpoints. It handles the requests that a client in the same process
+// dispatches to it directly.
+type EmbeddedServer interface {
+	Server
+	http.Handler
+}
+
+// NewEmbeddedServer is a function that starts a new EmbeddedServer.
+type NewEmbeddedServer func(
+	ctx Context, config gofig.Config) (EmbeddedServer, error)
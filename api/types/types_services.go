@@ -1,5 +1,7 @@
 package types
 
+import gofig "github.com/akutz/gofig/types"
+
 // Service is the base type for services.
 type Service interface {
 	Driver
@@ -31,6 +33,9 @@ type StorageService interface {
 	// Driver returns the service's StorageDriver.
 	Driver() StorageDriver
 
+	// Config returns the service's configuration.
+	Config() gofig.Config
+
 	// TaskExecute enqueues a task for execution.
 	TaskExecute(
 		ctx Context,
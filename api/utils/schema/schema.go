@@ -63,6 +63,10 @@ var (
 	// request.
 	VolumeCopyRequestSchema = buildSchemaVar("volumeCopyRequest")
 
+	// VolumeExpandRequestSchema is the JSON schema for a Volume expand
+	// request.
+	VolumeExpandRequestSchema = buildSchemaVar("volumeExpandRequest")
+
 	// VolumeSnapshotRequestSchema is the JSON schema for a Volume snapshot
 	// request.
 	VolumeSnapshotRequestSchema = buildSchemaVar("volumeSnapshotRequest")
@@ -255,13 +255,40 @@ const (
                     "type": "string",
                     "description": "Type is the type of storage the driver provides: block, nas, object."
                 },
-                "nextDevice": { "$ref": "#/definitions/nextDeviceInfo" }
+                "nextDevice": { "$ref": "#/definitions/nextDeviceInfo" },
+                "capabilities": { "$ref": "#/definitions/storageCapabilities" }
             },
             "required": [ "name", "type" ],
             "additionalProperties": false
         },
 
 
+        "storageCapabilities": {
+            "type": "object",
+            "properties": {
+                "supportsSnapshots": {
+                    "type": "boolean",
+                    "description": "SupportsSnapshots indicates whether volumes can be snapshotted."
+                },
+                "supportsExpand": {
+                    "type": "boolean",
+                    "description": "SupportsExpand indicates whether volumes can be expanded."
+                },
+                "attachSemantics": {
+                    "type": "string",
+                    "enum": [ "block", "nas", "object" ],
+                    "description": "AttachSemantics is how attached volumes are consumed: as block devices, as network file systems, or as object stores."
+                },
+                "maxVolumeSize": {
+                    "type": "number",
+                    "description": "MaxVolumeSize is the maximum size of a volume in GB."
+                }
+            },
+            "required": [ "supportsSnapshots", "supportsExpand", "attachSemantics" ],
+            "additionalProperties": false
+        },
+
+
         "executorInfo": {
             "type": "object",
             "properties": {
@@ -280,6 +307,18 @@ const (
                 "lastModified": {
                     "type": "number",
                     "description": "The time the executor was last modified as an epoch."
+                },
+                "os": {
+                    "type": "string",
+                    "description": "The operating system for which the executor is built."
+                },
+                "arch": {
+                    "type": "string",
+                    "description": "The architecture for which the executor is built."
+                },
+                "libc": {
+                    "type": "string",
+                    "description": "The C library of the hosts for which the executor is built. It is omitted unless the executor is built for hosts that use musl."
                 }
             },
             "required": [ "name", "md5checksum", "size", "lastModified" ],
@@ -459,6 +498,19 @@ const (
         },
 
 
+        "volumeExpandRequest": {
+            "type": "object",
+            "properties": {
+                "newSize": {
+                    "type": "number"
+                },
+                "opts": { "$ref" : "#/definitions/opts" }
+            },
+            "required": [ "newSize" ],
+            "additionalProperties": false
+        },
+
+
         "volumeSnapshotRequest": {
             "type": "object",
             "properties": {
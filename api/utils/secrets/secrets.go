@@ -0,0 +1,204 @@
+// Package secrets resolves configuration values that refer to secrets kept
+// outside of the configuration, such as in a file, in Vault, or in AWS
+// Secrets Manager.
+//
+// A reference is a value of the form ref+<scheme>://<location>[#<key>], ex.
+// ref+vault://secret/data/libstorage#accessKey. A reference with a key
+// resolves to the value of that key in the secret, which must be a JSON
+// object.
+package secrets
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	gofig "github.com/akutz/gofig/types"
+	"github.com/akutz/goof"
+
+	"github.com/codedellemc/libstorage/api/types"
+)
+
+// RefPrefix is the prefix of a value that refers to a secret.
+const RefPrefix = "ref+"
+
+// ResolveFunc returns the secret to which a reference refers. The reference's
+// key, if any, is applied by the caller.
+type ResolveFunc func(ctx types.Context, ref *url.URL) (string, error)
+
+var (
+	resolvers    = map[string]ResolveFunc{}
+	resolversRWL = &sync.RWMutex{}
+)
+
+// Register registers the function that resolves references with the given
+// scheme.
+func Register(scheme string, f ResolveFunc) {
+	resolversRWL.Lock()
+	defer resolversRWL.Unlock()
+	resolvers[strings.ToLower(scheme)] = f
+}
+
+// IsRef returns a flag indicating whether a value refers to a secret.
+func IsRef(v string) bool {
+	return strings.HasPrefix(v, RefPrefix)
+}
+
+// Resolve returns the secret to which a value refers, or the value itself if
+// it is not a reference.
+func Resolve(ctx types.Context, v string) (string, error) {
+	if !IsRef(v) {
+		return v, nil
+	}
+
+	ref, err := url.Parse(strings.TrimPrefix(v, RefPrefix))
+	if err != nil {
+		return "", goof.WithError("invalid secret reference", err)
+	}
+
+	resolversRWL.RLock()
+	f, ok := resolvers[strings.ToLower(ref.Scheme)]
+	resolversRWL.RUnlock()
+	if !ok {
+		return "", goof.WithField(
+			"scheme", ref.Scheme, "unknown secret reference scheme")
+	}
+
+	secret, err := f(ctx, ref)
+	if err != nil {
+		return "", err
+	}
+	if ref.Fragment == "" {
+		return secret, nil
+	}
+	return secretKey(secret, ref.Fragment)
+}
+
+// secretKey returns the value of a key in a secret that is a JSON object.
+func secretKey(secret, key string) (string, error) {
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(secret), &fields); err != nil {
+		return "", goof.WithFieldE(
+			"key", key, "secret is not a JSON object", err)
+	}
+	v, ok := fields[key]
+	if !ok {
+		return "", goof.WithField("key", key, "secret has no such key")
+	}
+	if s, ok := v.(string); ok {
+		return s, nil
+	}
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
+
+// Config resolves every reference in a configuration and returns a
+// configuration in which the references read as the secrets to which they
+// refer. The configuration itself is returned if it has no references.
+//
+// The returned error lists every reference that could not be resolved
+// rather than only the first.
+func Config(ctx types.Context, config gofig.Config) (gofig.Config, error) {
+	refs := map[string]bool{}
+	collectRefs(config.AllSettings(), refs)
+	if len(refs) == 0 {
+		return config, nil
+	}
+
+	var (
+		values   = map[string]string{}
+		problems []string
+	)
+	for ref := range refs {
+		v, err := Resolve(ctx, ref)
+		if err != nil {
+			// the reference, unlike the secret, is safe to log
+			problems = append(problems, fmt.Sprintf("%s: %v", ref, err))
+			continue
+		}
+		values[ref] = v
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return nil, goof.WithField(
+			"problems", problems,
+			fmt.Sprintf("error resolving secrets: %s",
+				strings.Join(problems, "; ")))
+	}
+
+	ctx.WithField("count", len(values)).Debug("resolved secrets")
+	return &secretsConfig{Config: config, values: values}, nil
+}
+
+func collectRefs(v interface{}, refs map[string]bool) {
+	switch tv := v.(type) {
+	case string:
+		if IsRef(tv) {
+			refs[tv] = true
+		}
+	case []string:
+		for _, s := range tv {
+			collectRefs(s, refs)
+		}
+	case []interface{}:
+		for _, e := range tv {
+			collectRefs(e, refs)
+		}
+	case map[string]interface{}:
+		for _, e := range tv {
+			collectRefs(e, refs)
+		}
+	case map[interface{}]interface{}:
+		for _, e := range tv {
+			collectRefs(e, refs)
+		}
+	}
+}
+
+// secretsConfig is a configuration whose references read as the secrets to
+// which they refer.
+type secretsConfig struct {
+	gofig.Config
+	values map[string]string
+}
+
+func (c *secretsConfig) value(v string) string {
+	if s, ok := c.values[v]; ok {
+		return s
+	}
+	return v
+}
+
+func (c *secretsConfig) GetString(k interface{}) string {
+	return c.value(c.Config.GetString(k))
+}
+
+func (c *secretsConfig) GetStringSlice(k interface{}) []string {
+	v := c.Config.GetStringSlice(k)
+	if len(v) == 0 {
+		return v
+	}
+	sv := make([]string, len(v))
+	for i, s := range v {
+		sv[i] = c.value(s)
+	}
+	return sv
+}
+
+func (c *secretsConfig) Get(k interface{}) interface{} {
+	v := c.Config.Get(k)
+	if s, ok := v.(string); ok {
+		return c.value(s)
+	}
+	return v
+}
+
+func (c *secretsConfig) Scope(scope interface{}) gofig.Config {
+	return &secretsConfig{Config: c.Config.Scope(scope), values: c.values}
+}
@@ -0,0 +1,29 @@
+package secrets
+
+import (
+	"io/ioutil"
+	"net/url"
+	"strings"
+
+	"github.com/akutz/goof"
+
+	"github.com/codedellemc/libstorage/api/types"
+)
+
+func init() {
+	Register("file", resolveFile)
+}
+
+// resolveFile reads a secret from a file, ex. ref+file:///etc/libstorage/key.
+// A trailing line ending is not part of the secret.
+func resolveFile(ctx types.Context, ref *url.URL) (string, error) {
+	path := ref.Host + ref.Path
+	if path == "" {
+		return "", goof.New("file secret reference has no path")
+	}
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", goof.WithFieldE("path", path, "error reading secret", err)
+	}
+	return strings.TrimRight(string(buf), "\r\n"), nil
+}
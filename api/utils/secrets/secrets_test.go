@@ -0,0 +1,65 @@
+package secrets
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolve(t *testing.T) {
+	dir, err := ioutil.TempDir("", "secrets")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer os.RemoveAll(dir)
+
+	keyFile := path.Join(dir, "key")
+	jsonFile := path.Join(dir, "s3.json")
+	assert.NoError(t, ioutil.WriteFile(keyFile, []byte("s3cr3t\n"), 0600))
+	assert.NoError(t, ioutil.WriteFile(
+		jsonFile, []byte(`{"accessKey":"AKIA","port":9000}`), 0600))
+
+	v, err := Resolve(nil, "plaintext")
+	assert.NoError(t, err)
+	assert.Equal(t, "plaintext", v)
+
+	v, err = Resolve(nil, "ref+file://"+keyFile)
+	assert.NoError(t, err)
+	assert.Equal(t, "s3cr3t", v)
+
+	v, err = Resolve(nil, "ref+file://"+jsonFile+"#accessKey")
+	assert.NoError(t, err)
+	assert.Equal(t, "AKIA", v)
+
+	v, err = Resolve(nil, "ref+file://"+jsonFile+"#port")
+	assert.NoError(t, err)
+	assert.Equal(t, "9000", v)
+
+	_, err = Resolve(nil, "ref+file://"+jsonFile+"#secretKey")
+	assert.EqualError(t, err, "secret has no such key")
+
+	_, err = Resolve(nil, "ref+file://"+keyFile+"#accessKey")
+	assert.EqualError(t, err, "secret is not a JSON object")
+
+	_, err = Resolve(nil, "ref+nope://secret")
+	assert.EqualError(t, err, "unknown secret reference scheme")
+}
+
+func TestCollectRefs(t *testing.T) {
+	refs := map[string]bool{}
+	collectRefs(map[string]interface{}{
+		"objectstore": map[string]interface{}{
+			"accessKey": "ref+vault://secret/data/s3#accessKey",
+			"region":    "us-east-1",
+		},
+		"hosts": []interface{}{"ref+file:///etc/hosts"},
+		"port":  9000,
+	}, refs)
+	assert.Equal(t, map[string]bool{
+		"ref+vault://secret/data/s3#accessKey": true,
+		"ref+file:///etc/hosts":                true,
+	}, refs)
+}
@@ -0,0 +1,106 @@
+package secrets
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/akutz/goof"
+
+	"github.com/codedellemc/libstorage/api/types"
+)
+
+const defaultVaultAddr = "https://127.0.0.1:8200"
+
+func init() {
+	Register("vault", resolveVault)
+}
+
+// resolveVault reads a secret from Vault, ex.
+// ref+vault://secret/data/libstorage#accessKey. The secret is the data of the
+// Vault secret as a JSON object; for version 2 of the key/value engine that
+// is the data of the secret's current version.
+//
+// Vault is located with VAULT_ADDR and accessed with VAULT_TOKEN or, if that
+// is not set, the token in ~/.vault-token, as the Vault CLI does.
+func resolveVault(ctx types.Context, ref *url.URL) (string, error) {
+	secretPath := strings.Trim(ref.Host+ref.Path, "/")
+	if secretPath == "" {
+		return "", goof.New("vault secret reference has no path")
+	}
+
+	addr := os.Getenv("VAULT_ADDR")
+	if addr == "" {
+		addr = defaultVaultAddr
+	}
+	token, err := vaultToken()
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequest(
+		http.MethodGet,
+		fmt.Sprintf("%s/v1/%s", strings.TrimRight(addr, "/"), secretPath),
+		nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if ns := os.Getenv("VAULT_NAMESPACE"); ns != "" {
+		req.Header.Set("X-Vault-Namespace", ns)
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", goof.WithFieldE(
+			"path", secretPath, "error reading vault secret", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return "", goof.WithFields(goof.Fields{
+			"path":   secretPath,
+			"status": res.StatusCode,
+		}, "error reading vault secret")
+	}
+
+	var secret struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&secret); err != nil {
+		return "", goof.WithFieldE(
+			"path", secretPath, "error decoding vault secret", err)
+	}
+
+	data := secret.Data
+	if inner, ok := data["data"].(map[string]interface{}); ok {
+		if _, ok := data["metadata"]; ok {
+			data = inner
+		}
+	}
+
+	buf, err := json.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
+
+func vaultToken() (string, error) {
+	if token := os.Getenv("VAULT_TOKEN"); token != "" {
+		return token, nil
+	}
+	home := os.Getenv("HOME")
+	if home == "" {
+		return "", goof.New("VAULT_TOKEN is not set")
+	}
+	buf, err := ioutil.ReadFile(path.Join(home, ".vault-token"))
+	if err != nil {
+		return "", goof.WithError("VAULT_TOKEN is not set", err)
+	}
+	return strings.TrimSpace(string(buf)), nil
+}
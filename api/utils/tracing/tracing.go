@@ -0,0 +1,258 @@
+package tracing
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	mrand "math/rand"
+	"net/http"
+	"sync"
+	"time"
+
+	gofig "github.com/akutz/gofig/types"
+
+	"github.com/codedellemc/libstorage/api/context"
+	"github.com/codedellemc/libstorage/api/types"
+	"github.com/codedellemc/libstorage/api/utils"
+)
+
+const (
+	// KindServer is the kind of a span that handles an HTTP request.
+	KindServer = "SERVER"
+
+	// KindClient is the kind of a span that sends an HTTP request.
+	KindClient = "CLIENT"
+)
+
+// Tracer emits the spans of sampled traces to a collector that accepts spans
+// in the Zipkin v2 JSON format, such as Zipkin or Jaeger.
+type Tracer struct {
+	service    string
+	sampleRate int
+	collector  *utils.Webhook
+}
+
+// New returns a new tracer that emits spans to the collector specified by
+// the property libstorage.tracing.collector. The spans are attributed to the
+// specified service name. A nil value is returned if no collector is
+// configured.
+func New(config gofig.Config, service string) *Tracer {
+	url := config.GetString(types.ConfigTracingCollector)
+	if url == "" {
+		return nil
+	}
+	return NewWithCollector(
+		url, service, config.GetInt(types.ConfigTracingSampleRate))
+}
+
+// NewWithCollector returns a new tracer that emits spans to the specified
+// collector URL. The sample rate is the percentage of new traces that are
+// sampled.
+func NewWithCollector(url, service string, sampleRate int) *Tracer {
+	return &Tracer{
+		service:    service,
+		sampleRate: sampleRate,
+		collector:  utils.NewWebhook(url),
+	}
+}
+
+// Span times an operation that is part of a trace. A span's exported fields
+// are encoded in the Zipkin v2 JSON format.
+type Span struct {
+	sync.Mutex
+	TraceID       string            `json:"traceId"`
+	ID            string            `json:"id"`
+	ParentID      string            `json:"parentId,omitempty"`
+	Name          string            `json:"name"`
+	Kind          string            `json:"kind,omitempty"`
+	Timestamp     int64             `json:"timestamp"`
+	Duration      int64             `json:"duration"`
+	LocalEndpoint *Endpoint         `json:"localEndpoint,omitempty"`
+	Tags          map[string]string `json:"tags,omitempty"`
+
+	ctx      types.Context
+	tracer   *Tracer
+	sampled  bool
+	start    time.Time
+	finished bool
+}
+
+// Endpoint is the service that records a span.
+type Endpoint struct {
+	ServiceName string `json:"serviceName"`
+}
+
+// FromContext returns the context's current span.
+func FromContext(ctx types.Context) (*Span, bool) {
+	v, ok := ctx.Value(context.SpanKey).(*Span)
+	return v, ok
+}
+
+// StartSpan starts a span that is a child of the context's current span. A
+// nil span is returned if the context is not part of a trace. A nil span may
+// be used safely, so callers need not check the returned span.
+func StartSpan(ctx types.Context, name string) (types.Context, *Span) {
+	parent, ok := FromContext(ctx)
+	if !ok {
+		return ctx, nil
+	}
+	return parent.tracer.start(
+		ctx, name, "", parent.TraceID, parent.ID, parent.sampled)
+}
+
+// StartSpan starts a span that is a child of the context's current span or,
+// if the context is not part of a trace, the root span of a new trace.
+func (t *Tracer) StartSpan(
+	ctx types.Context,
+	name, kind string) (types.Context, *Span) {
+
+	if t == nil {
+		return StartSpan(ctx, name)
+	}
+	if parent, ok := FromContext(ctx); ok {
+		return t.start(
+			ctx, name, kind, parent.TraceID, parent.ID, parent.sampled)
+	}
+	return t.start(ctx, name, kind, newID(), "", t.sample())
+}
+
+// StartServerSpan starts a span for an HTTP request that continues the trace
+// identified by the request's B3 headers or, if the request is not part of a
+// trace, starts a new trace.
+func (t *Tracer) StartServerSpan(
+	ctx types.Context,
+	req *http.Request) (types.Context, *Span) {
+
+	if t == nil {
+		return ctx, nil
+	}
+
+	name := fmt.Sprintf("%s %s", req.Method, req.URL.Path)
+
+	traceID := req.Header.Get(types.TraceIDHeader)
+	if traceID == "" {
+		return t.StartSpan(ctx, name, KindServer)
+	}
+
+	sampled := t.sample()
+	switch req.Header.Get(types.SampledHeader) {
+	case "1", "true":
+		sampled = true
+	case "0", "false":
+		sampled = false
+	}
+
+	return t.start(
+		ctx, name, KindServer, traceID,
+		req.Header.Get(types.SpanIDHeader), sampled)
+}
+
+func (t *Tracer) start(
+	ctx types.Context,
+	name, kind, traceID, parentID string,
+	sampled bool) (types.Context, *Span) {
+
+	now := time.Now()
+	s := &Span{
+		TraceID:       traceID,
+		ID:            newID(),
+		ParentID:      parentID,
+		Name:          name,
+		Kind:          kind,
+		Timestamp:     now.UnixNano() / int64(time.Microsecond),
+		LocalEndpoint: &Endpoint{ServiceName: t.service},
+		tracer:        t,
+		sampled:       sampled,
+		start:         now,
+	}
+	s.ctx = ctx.WithValue(context.SpanKey, s)
+	return s.ctx, s
+}
+
+func (t *Tracer) sample() bool {
+	if t.sampleRate >= 100 {
+		return true
+	}
+	return mrand.Intn(100) < t.sampleRate
+}
+
+// SetTag records a tag on the span.
+func (s *Span) SetTag(key, value string) {
+	if s == nil {
+		return
+	}
+	s.Lock()
+	defer s.Unlock()
+	if s.Tags == nil {
+		s.Tags = map[string]string{}
+	}
+	s.Tags[key] = value
+}
+
+// Inject sets the B3 headers that propagate the span's trace to the
+// recipient of an HTTP request.
+func (s *Span) Inject(header http.Header) {
+	if s == nil {
+		return
+	}
+	header.Set(types.TraceIDHeader, s.TraceID)
+	header.Set(types.SpanIDHeader, s.ID)
+	if s.ParentID != "" {
+		header.Set(types.ParentSpanIDHeader, s.ParentID)
+	}
+	if s.sampled {
+		header.Set(types.SampledHeader, "1")
+	} else {
+		header.Set(types.SampledHeader, "0")
+	}
+}
+
+// Finish records the span's duration and emits the span if its trace is
+// sampled.
+func (s *Span) Finish() {
+	s.FinishWithError(nil)
+}
+
+// FinishWithError records the error, if any, with which the span's operation
+// failed as well as the span's duration, and emits the span if its trace is
+// sampled.
+func (s *Span) FinishWithError(err error) {
+	if s == nil {
+		return
+	}
+	if err != nil {
+		s.SetTag("error", err.Error())
+	}
+	s.Lock()
+	if s.finished {
+		s.Unlock()
+		return
+	}
+	s.finished = true
+	s.Duration = int64(time.Since(s.start) / time.Microsecond)
+	s.Unlock()
+
+	if s.sampled {
+		s.tracer.collector.Post(s.ctx, []*Span{s})
+	}
+}
+
+// ContextLoggerFields returns the span's IDs so that they are included in the
+// log entries of the span's context.
+func (s *Span) ContextLoggerFields() map[string]interface{} {
+	return map[string]interface{}{
+		"traceID": s.TraceID,
+		"spanID":  s.ID,
+	}
+}
+
+// newID returns a random, 64-bit ID encoded as 16 hex characters.
+func newID() string {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		for i := range buf {
+			buf[i] = byte(mrand.Intn(256))
+		}
+	}
+	return hex.EncodeToString(buf)
+}
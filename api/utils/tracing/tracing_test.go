@@ -0,0 +1,56 @@
+package tracing
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codedellemc/libstorage/api/context"
+	"github.com/codedellemc/libstorage/api/types"
+)
+
+func TestStartSpanWithoutTrace(t *testing.T) {
+	ctx := context.Background()
+	spanCtx, span := StartSpan(ctx, "op")
+	assert.Nil(t, span)
+	assert.Equal(t, ctx, spanCtx)
+
+	// a nil span may be used safely
+	span.SetTag("key", "value")
+	span.Inject(http.Header{})
+	span.FinishWithError(nil)
+}
+
+func TestStartServerSpan(t *testing.T) {
+	tracer := &Tracer{service: "test", sampleRate: 0}
+
+	req, err := http.NewRequest("GET", "http://localhost/volumes", nil)
+	assert.NoError(t, err)
+	req.Header.Set(types.TraceIDHeader, "463ac35c9f6413ad")
+	req.Header.Set(types.SpanIDHeader, "a2fb4a1d1a96d312")
+	req.Header.Set(types.SampledHeader, "0")
+
+	ctx, span := tracer.StartServerSpan(context.Background(), req)
+	assert.Equal(t, "463ac35c9f6413ad", span.TraceID)
+	assert.Equal(t, "a2fb4a1d1a96d312", span.ParentID)
+	assert.Equal(t, "GET /volumes", span.Name)
+	assert.Equal(t, KindServer, span.Kind)
+	assert.Len(t, span.ID, 16)
+
+	_, child := StartSpan(ctx, "ebs.Volumes")
+	assert.Equal(t, span.TraceID, child.TraceID)
+	assert.Equal(t, span.ID, child.ParentID)
+
+	header := http.Header{}
+	child.Inject(header)
+	assert.Equal(t, span.TraceID, header.Get(types.TraceIDHeader))
+	assert.Equal(t, child.ID, header.Get(types.SpanIDHeader))
+	assert.Equal(t, span.ID, header.Get(types.ParentSpanIDHeader))
+	assert.Equal(t, "0", header.Get(types.SampledHeader))
+
+	// the trace is not sampled, so finishing the spans emits nothing
+	child.Finish()
+	span.Finish()
+	assert.True(t, child.Duration >= 0)
+}
@@ -66,3 +66,18 @@ func getBool(
 
 	return false
 }
+
+func getStringSlice(
+	config gofig.Config,
+	key string,
+	roots ...string) []string {
+
+	for _, r := range roots {
+		rk := strings.Replace(key, "libstorage.", fmt.Sprintf("%s.", r), 1)
+		if val := config.GetStringSlice(rk); len(val) > 0 {
+			return val
+		}
+	}
+
+	return config.GetStringSlice(key)
+}
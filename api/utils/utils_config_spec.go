@@ -0,0 +1,100 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	gofig "github.com/akutz/gofig/types"
+	"github.com/akutz/goof"
+)
+
+// ConfigSpec declares the configuration properties of a driver so that a
+// driver is able to validate its configuration, and log it without exposing
+// secrets, when it is initialized.
+type ConfigSpec []*ConfigKeySpec
+
+// ConfigKeySpec declares a configuration property.
+type ConfigKeySpec struct {
+
+	// Key is the property's key, ex. efs.region.
+	Key string
+
+	// Required indicates the property must have a value.
+	Required bool
+
+	// Secret indicates the property's value must not be logged.
+	Secret bool
+
+	// Values are the property's allowed values, compared without regard to
+	// case. Any value is allowed if there are none.
+	Values []string
+
+	// Validate returns an error if the property's value is invalid. It is not
+	// invoked for empty values.
+	Validate func(value string) error
+}
+
+// Validate validates a configuration against the spec. The returned error
+// lists every problem with the configuration rather than only the first.
+func (s ConfigSpec) Validate(config gofig.Config) error {
+	var problems []string
+	for _, ks := range s {
+		if err := ks.validate(config.GetString(ks.Key)); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	return goof.WithField(
+		"problems", problems,
+		fmt.Sprintf("invalid config: %s", strings.Join(problems, "; ")))
+}
+
+func (ks *ConfigKeySpec) validate(v string) error {
+	if v == "" {
+		if ks.Required {
+			return fmt.Errorf("%s is required", ks.Key)
+		}
+		return nil
+	}
+	if len(ks.Values) > 0 && !containsFold(ks.Values, v) {
+		return fmt.Errorf("%s must be one of %s, not %q",
+			ks.Key, strings.Join(ks.Values, ", "), v)
+	}
+	if ks.Validate != nil {
+		if err := ks.Validate(v); err != nil {
+			return fmt.Errorf("%s is invalid: %v", ks.Key, err)
+		}
+	}
+	return nil
+}
+
+// Fields returns the values of the spec's properties as log fields. Each
+// field is named for its key without the key's first segment, such as
+// region for efs.region, and the values of secrets are masked.
+func (s ConfigSpec) Fields(config gofig.Config) log.Fields {
+	fields := log.Fields{}
+	for _, ks := range s {
+		name := ks.Key
+		if i := strings.Index(name, "."); i >= 0 {
+			name = name[i+1:]
+		}
+		v := config.GetString(ks.Key)
+		if ks.Secret && v != "" {
+			v = "******"
+		}
+		fields[name] = v
+	}
+	return fields
+}
+
+func containsFold(values []string, v string) bool {
+	for _, s := range values {
+		if strings.EqualFold(s, v) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+
+	log "github.com/Sirupsen/logrus"
+	gofigCore "github.com/akutz/gofig"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigKeySpecValidate(t *testing.T) {
+	ks := &ConfigKeySpec{Key: "objectstore.mounter"}
+	assert.NoError(t, ks.validate(""))
+	assert.NoError(t, ks.validate("anything"))
+
+	ks.Required = true
+	assert.EqualError(t, ks.validate(""), "objectstore.mounter is required")
+
+	ks.Values = []string{"s3fs", "goofys"}
+	assert.NoError(t, ks.validate("S3FS"))
+	assert.EqualError(t, ks.validate("rclone"),
+		`objectstore.mounter must be one of s3fs, goofys, not "rclone"`)
+
+	ks = &ConfigKeySpec{
+		Key: "efs.lifecyclePolicy",
+		Validate: func(v string) error {
+			return errors.New("invalid lifecycle policy")
+		},
+	}
+	assert.NoError(t, ks.validate(""))
+	assert.EqualError(t, ks.validate("3"),
+		"efs.lifecyclePolicy is invalid: invalid lifecycle policy")
+}
+
+func TestConfigSpecValidate(t *testing.T) {
+	spec := ConfigSpec{
+		{Key: "objectstore.endpoint"},
+		{Key: "objectstore.accessKey", Required: true},
+		{Key: "objectstore.secretKey", Required: true},
+		{Key: "objectstore.mounter", Values: []string{"s3fs", "goofys"}},
+	}
+
+	config := gofigCore.New()
+	config.Set("objectstore.mounter", "rclone")
+	err := spec.Validate(config)
+	assert.EqualError(t, err, "invalid config: "+
+		"objectstore.accessKey is required; "+
+		"objectstore.secretKey is required; "+
+		`objectstore.mounter must be one of s3fs, goofys, not "rclone"`)
+	ef := err.(interface {
+		Fields() map[string]interface{}
+	})
+	assert.Equal(t, []string{
+		"objectstore.accessKey is required",
+		"objectstore.secretKey is required",
+		`objectstore.mounter must be one of s3fs, goofys, not "rclone"`,
+	}, ef.Fields()["problems"])
+
+	config.Set("objectstore.accessKey", "AKIA")
+	config.Set("objectstore.secretKey", "s3cr3t")
+	config.Set("objectstore.mounter", "goofys")
+	assert.NoError(t, spec.Validate(config))
+}
+
+func TestConfigSpecFields(t *testing.T) {
+	spec := ConfigSpec{
+		{Key: "objectstore.endpoint"},
+		{Key: "objectstore.accessKey"},
+		{Key: "objectstore.secretKey", Secret: true},
+		{Key: "objectstore.sessionToken", Secret: true},
+	}
+
+	config := gofigCore.New()
+	config.Set("objectstore.endpoint", "http://127.0.0.1:9000")
+	config.Set("objectstore.accessKey", "AKIA")
+	config.Set("objectstore.secretKey", "s3cr3t")
+	assert.Equal(t, log.Fields{
+		"endpoint":     "http://127.0.0.1:9000",
+		"accessKey":    "AKIA",
+		"secretKey":    "******",
+		"sessionToken": "",
+	}, spec.Fields(config))
+}
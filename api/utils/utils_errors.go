@@ -1,6 +1,9 @@
 package utils
 
 import (
+	"strings"
+	"time"
+
 	"github.com/akutz/goof"
 
 	"github.com/codedellemc/libstorage/api/types"
@@ -24,13 +27,167 @@ func NewBadAdminTokenError(token string) error {
 	}
 }
 
+// NewUnauthorizedError returns a new ErrUnauthorized error.
+func NewUnauthorizedError(reason string) error {
+	return &types.ErrUnauthorized{
+		Goof: goof.WithField("reason", reason, "unauthorized"),
+	}
+}
+
+// NewForbiddenError returns a new ErrForbidden error.
+func NewForbiddenError(subject, service string) error {
+	return &types.ErrForbidden{
+		Goof: goof.WithFields(goof.Fields{
+			"subject": subject,
+			"service": service,
+		}, "forbidden"),
+	}
+}
+
+// NewPolicyViolationError returns a new ErrPolicyViolation error. The policy
+// is the name of the violated policy, the limit is the policy's value, and the
+// value is the one that violates the policy.
+func NewPolicyViolationError(
+	service, policy string, limit, value interface{}) error {
+	return &types.ErrPolicyViolation{
+		Goof: goof.WithFields(goof.Fields{
+			"service": service,
+			"policy":  policy,
+			"limit":   limit,
+			"value":   value,
+		}, "policy violation"),
+	}
+}
+
+// NewBadRequestError returns a new ErrBadRequest error.
+func NewBadRequestError(reason string, err error) error {
+	return &types.ErrBadRequest{Goof: goof.WithFieldE(
+		"reason", reason, "bad request", err)}
+}
+
+// NewTooManyRequestsError returns a new ErrTooManyRequests error.
+func NewTooManyRequestsError(client string) error {
+	return &types.ErrTooManyRequests{
+		Goof: goof.WithField("client", client, "too many requests"),
+	}
+}
+
+// NewConflictError returns a new ErrConflict error.
+func NewConflictError(reason string) error {
+	return &types.ErrConflict{
+		Goof: goof.WithField("reason", reason, "conflict"),
+	}
+}
+
 // NewNotFoundError returns a new ErrNotFound error.
 func NewNotFoundError(resourceID string) error {
 	return &types.ErrNotFound{
-		Goof: goof.WithField("resourceID", resourceID, "resource not found"),
+		Goof: goof.WithFields(goof.Fields{
+			"resourceID":         resourceID,
+			types.ErrorCodeField: types.ErrorCodeNotFound,
+		}, "resource not found"),
+	}
+}
+
+// NewAlreadyExistsError returns a new ErrAlreadyExists error.
+func NewAlreadyExistsError(resourceID string) error {
+	return &types.ErrAlreadyExists{
+		Goof: goof.WithFields(goof.Fields{
+			"resourceID":         resourceID,
+			types.ErrorCodeField: types.ErrorCodeAlreadyExists,
+		}, "resource already exists"),
+	}
+}
+
+// NewBusyError returns a new ErrBusy error.
+func NewBusyError(resourceID string, err error) error {
+	return &types.ErrBusy{Goof: codedGoof(goof.Fields{
+		"resourceID":         resourceID,
+		types.ErrorCodeField: types.ErrorCodeBusy,
+	}, "resource busy", err)}
+}
+
+// NewInProgressError returns a new ErrInProgress error.
+func NewInProgressError() error {
+	return &types.ErrInProgress{
+		Goof: goof.WithField(
+			types.ErrorCodeField, types.ErrorCodeInProgress,
+			"request with idempotency key in progress"),
 	}
 }
 
+// NewIdempotencyKeyReusedError returns a new ErrIdempotencyKeyReused error.
+func NewIdempotencyKeyReusedError() error {
+	return &types.ErrIdempotencyKeyReused{
+		Goof: goof.WithField(
+			types.ErrorCodeField, types.ErrorCodeIdempotencyKeyReused,
+			"idempotency key reused for a different request"),
+	}
+}
+
+// NewQuotaExceededError returns a new ErrQuotaExceeded error.
+func NewQuotaExceededError(resourceID string, err error) error {
+	return &types.ErrQuotaExceeded{Goof: codedGoof(goof.Fields{
+		"resourceID":         resourceID,
+		types.ErrorCodeField: types.ErrorCodeQuotaExceeded,
+	}, "quota exceeded", err)}
+}
+
+// NewAuthFailedError returns a new ErrAuthFailed error.
+func NewAuthFailedError(err error) error {
+	return &types.ErrAuthFailed{Goof: codedGoof(goof.Fields{
+		types.ErrorCodeField: types.ErrorCodeAuthFailed,
+	}, "authentication failed", err)}
+}
+
+// NewPermissionDeniedError returns a new ErrPermissionDenied error.
+func NewPermissionDeniedError(resourceID string, err error) error {
+	return &types.ErrPermissionDenied{Goof: codedGoof(goof.Fields{
+		"resourceID":         resourceID,
+		types.ErrorCodeField: types.ErrorCodePermissionDenied,
+	}, "permission denied", err)}
+}
+
+// NewTimeoutError returns a new ErrTimeout error.
+func NewTimeoutError(resourceID string, err error) error {
+	return &types.ErrTimeout{Goof: codedGoof(goof.Fields{
+		"resourceID":         resourceID,
+		types.ErrorCodeField: types.ErrorCodeTimeout,
+	}, "operation timed out", err)}
+}
+
+// NewErrorCodeError returns a new error with the specified code. Drivers use
+// it to translate the errors reported by storage platforms. The error is
+// returned as is if the code is unknown.
+func NewErrorCodeError(
+	code types.ErrorCode, resourceID string, err error) error {
+
+	switch code {
+	case types.ErrorCodeNotFound:
+		return NewNotFoundError(resourceID)
+	case types.ErrorCodeAlreadyExists:
+		return NewAlreadyExistsError(resourceID)
+	case types.ErrorCodeBusy:
+		return NewBusyError(resourceID, err)
+	case types.ErrorCodeQuotaExceeded:
+		return NewQuotaExceededError(resourceID, err)
+	case types.ErrorCodeAuthFailed:
+		return NewAuthFailedError(err)
+	case types.ErrorCodePermissionDenied:
+		return NewPermissionDeniedError(resourceID, err)
+	case types.ErrorCodeTimeout:
+		return NewTimeoutError(resourceID, err)
+	}
+	return err
+}
+
+func codedGoof(fields goof.Fields, msg string, err error) goof.Goof {
+	if err == nil {
+		return goof.WithFields(fields, msg)
+	}
+	return goof.WithFieldsE(fields, msg, err)
+}
+
 // NewMissingInstanceIDError returns a new ErrMissingInstanceID error.
 func NewMissingInstanceIDError(service string) error {
 	return &types.ErrMissingInstanceID{
@@ -64,3 +221,66 @@ func NewBadFilterErr(filter string, err error) error {
 	return &types.ErrBadFilter{Goof: goof.WithFieldE(
 		"filter", filter, "bad filter", err)}
 }
+
+// NewAlreadyMountedError returns a new ErrAlreadyMounted error.
+func NewAlreadyMountedError(deviceName, mountPoint string) error {
+	return &types.ErrAlreadyMounted{Goof: goof.WithFields(goof.Fields{
+		"deviceName": deviceName,
+		"mountPoint": mountPoint,
+	}, "device already mounted")}
+}
+
+// NewMountTimedOutError returns a new ErrMountTimedOut error.
+func NewMountTimedOutError(
+	deviceName, mountPoint string, timeout time.Duration) error {
+	return &types.ErrMountTimedOut{Goof: goof.WithFields(goof.Fields{
+		"deviceName":         deviceName,
+		"mountPoint":         mountPoint,
+		"timeout":            timeout.String(),
+		types.ErrorCodeField: types.ErrorCodeTimeout,
+	}, "mount timed out")}
+}
+
+// NewEncryptedDeviceError returns a new ErrEncryptedDevice error.
+func NewEncryptedDeviceError(deviceName string) error {
+	return &types.ErrEncryptedDevice{Goof: goof.WithField(
+		"deviceName", deviceName, "encrypted device")}
+}
+
+// commandErrors maps the names and descriptions of the POSIX errors that
+// command line tools report to error codes.
+var commandErrors = []struct {
+	text string
+	code types.ErrorCode
+}{
+	{"ENOENT", types.ErrorCodeNotFound},
+	{"No such file or directory", types.ErrorCodeNotFound},
+	{"does not exist", types.ErrorCodeNotFound},
+	{"EEXIST", types.ErrorCodeAlreadyExists},
+	{"File exists", types.ErrorCodeAlreadyExists},
+	{"EBUSY", types.ErrorCodeBusy},
+	{"Device or resource busy", types.ErrorCodeBusy},
+	{"EDQUOT", types.ErrorCodeQuotaExceeded},
+	{"Disk quota exceeded", types.ErrorCodeQuotaExceeded},
+	{"ENOSPC", types.ErrorCodeQuotaExceeded},
+	{"No space left on device", types.ErrorCodeQuotaExceeded},
+	{"EACCES", types.ErrorCodePermissionDenied},
+	{"Permission denied", types.ErrorCodePermissionDenied},
+	{"EPERM", types.ErrorCodePermissionDenied},
+	{"Operation not permitted", types.ErrorCodePermissionDenied},
+	{"ETIMEDOUT", types.ErrorCodeTimeout},
+	{"Connection timed out", types.ErrorCodeTimeout},
+}
+
+// CommandErrorCode returns the code of the error described by the output of
+// a failed command, or an empty string if the output does not describe a
+// known error. Drivers that manage storage with command line tools record
+// the code in the errorCode field of the errors they return.
+func CommandErrorCode(output string) types.ErrorCode {
+	for _, e := range commandErrors {
+		if strings.Contains(output, e.text) {
+			return e.code
+		}
+	}
+	return ""
+}
@@ -0,0 +1,75 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/akutz/goof"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codedellemc/libstorage/api/types"
+)
+
+func TestNewErrorCodeError(t *testing.T) {
+	inner := errors.New("platform error")
+
+	err := NewErrorCodeError(types.ErrorCodeNotFound, "vol-000", inner)
+	assert.IsType(t, &types.ErrNotFound{}, err)
+	assert.Equal(t, types.ErrorCodeNotFound, types.ErrorCodeOf(err))
+
+	err = NewErrorCodeError(types.ErrorCodeAlreadyExists, "vol-000", inner)
+	assert.IsType(t, &types.ErrAlreadyExists{}, err)
+	assert.True(t, types.IsErrorCode(err, types.ErrorCodeAlreadyExists))
+
+	err = NewErrorCodeError(types.ErrorCodeBusy, "vol-000", inner)
+	assert.IsType(t, &types.ErrBusy{}, err)
+	assert.Equal(t, types.ErrorCodeBusy, types.ErrorCodeOf(err))
+
+	err = NewErrorCodeError(types.ErrorCodeQuotaExceeded, "vol-000", nil)
+	assert.IsType(t, &types.ErrQuotaExceeded{}, err)
+	assert.Equal(t, types.ErrorCodeQuotaExceeded, types.ErrorCodeOf(err))
+
+	err = NewErrorCodeError(types.ErrorCodeAuthFailed, "", inner)
+	assert.IsType(t, &types.ErrAuthFailed{}, err)
+	assert.Equal(t, types.ErrorCodeAuthFailed, types.ErrorCodeOf(err))
+
+	err = NewErrorCodeError(types.ErrorCodePermissionDenied, "vol-000", inner)
+	assert.IsType(t, &types.ErrPermissionDenied{}, err)
+	assert.Equal(t, types.ErrorCodePermissionDenied, types.ErrorCodeOf(err))
+
+	err = NewErrorCodeError(types.ErrorCodeTimeout, "vol-000", inner)
+	assert.IsType(t, &types.ErrTimeout{}, err)
+	assert.Equal(t, types.ErrorCodeTimeout, types.ErrorCodeOf(err))
+
+	err = NewErrorCodeError("", "vol-000", inner)
+	assert.Equal(t, inner, err)
+}
+
+func TestErrorCodeOf(t *testing.T) {
+	assert.Equal(t, types.ErrorCode(""), types.ErrorCodeOf(nil))
+	assert.Equal(t,
+		types.ErrorCode(""), types.ErrorCodeOf(errors.New("error")))
+	assert.Equal(t,
+		types.ErrorCode(""), types.ErrorCodeOf(goof.New("error")))
+	assert.False(t, types.IsErrorCode(nil, types.ErrorCodeNotFound))
+
+	// the codes of the errors decoded by clients are strings
+	err := goof.WithField(types.ErrorCodeField, "busy", "resource busy")
+	assert.Equal(t, types.ErrorCodeBusy, types.ErrorCodeOf(err))
+}
+
+func TestCommandErrorCode(t *testing.T) {
+	assert.Equal(t, types.ErrorCodeNotFound, CommandErrorCode(
+		"Error ENOENT: subvolume 'vol1' does not exist"))
+	assert.Equal(t, types.ErrorCodeNotFound, CommandErrorCode(
+		"rbd: error opening image vol1: (2) No such file or directory"))
+	assert.Equal(t, types.ErrorCodeAlreadyExists, CommandErrorCode(
+		"rbd: create error: (17) File exists"))
+	assert.Equal(t, types.ErrorCodeBusy, CommandErrorCode(
+		"rbd: error: image still has watchers: (16) Device or resource busy"))
+	assert.Equal(t, types.ErrorCodePermissionDenied, CommandErrorCode(
+		"error connecting to the cluster: (1) Operation not permitted"))
+	assert.Equal(t, types.ErrorCodePermissionDenied, CommandErrorCode(
+		"mkdir: cannot create directory '/mnt/vol1': Permission denied"))
+	assert.Equal(t, types.ErrorCode(""), CommandErrorCode("error"))
+}
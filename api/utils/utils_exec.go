@@ -0,0 +1,49 @@
+package utils
+
+import (
+	"bytes"
+	"os/exec"
+
+	"github.com/akutz/goof"
+
+	"github.com/codedellemc/libstorage/api/types"
+)
+
+// RunCommand starts a command and waits for it to complete. If the context
+// is cancelled first, such as when the deadline of the operation that runs
+// the command expires, the command's process is killed and the context's
+// error is returned.
+func RunCommand(ctx types.Context, cmd *exec.Cmd) error {
+	if ctx == nil || ctx.Done() == nil {
+		return cmd.Run()
+	}
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	errc := make(chan error, 1)
+	go func() { errc <- cmd.Wait() }()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+		cmd.Process.Kill()
+		<-errc
+		return goof.WithFieldE(
+			"cmd", cmd.Path, "command cancelled", ctx.Err())
+	}
+}
+
+// CommandOutput runs a command with RunCommand and returns its standard
+// output.
+func CommandOutput(ctx types.Context, cmd *exec.Cmd) ([]byte, error) {
+	if cmd.Stdout != nil {
+		return nil, goof.New("exec: Stdout already set")
+	}
+	stdout := &bytes.Buffer{}
+	cmd.Stdout = stdout
+	err := RunCommand(ctx, cmd)
+	return stdout.Bytes(), err
+}
@@ -4,7 +4,6 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"io/ioutil"
-	"os"
 
 	log "github.com/Sirupsen/logrus"
 	gofig "github.com/akutz/gofig/types"
@@ -70,12 +69,10 @@ func ParseTLSConfig(
 		f(types.ConfigTLSServerName, serverName)
 	}
 
+	var clientCertRequired bool
 	if isSet(config, types.ConfigTLSClientCertRequired, roots...) {
-		clientCertRequired := getBool(
+		clientCertRequired = getBool(
 			config, types.ConfigTLSClientCertRequired, roots...)
-		if clientCertRequired {
-			tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
-		}
 		f(types.ConfigTLSClientCertRequired, clientCertRequired)
 	}
 
@@ -90,27 +87,59 @@ func ParseTLSConfig(
 
 		f(types.ConfigTLSTrustedCertsFile, trustedCertsFile)
 
-		buf, err := func() ([]byte, error) {
-			f, err := os.Open(trustedCertsFile)
-			if err != nil {
-				return nil, err
-			}
-			defer f.Close()
-			buf, err := ioutil.ReadAll(f)
-			if err != nil {
-				return nil, err
-			}
-			return buf, nil
-		}()
+		certPool, err := readCertPool(trustedCertsFile)
 		if err != nil {
 			return nil, err
 		}
-
-		certPool := x509.NewCertPool()
-		certPool.AppendCertsFromPEM(buf)
 		tlsConfig.RootCAs = certPool
 		tlsConfig.ClientCAs = certPool
 	}
 
+	// a CA bundle, revocation list, or pinned fingerprints for client
+	// certificates all imply that client certificates are required
+	if isSet(config, types.ConfigTLSClientCAFile, roots...) {
+		clientCAFile := getString(
+			config, types.ConfigTLSClientCAFile, roots...)
+
+		if !gotil.FileExists(clientCAFile) {
+			return nil, goof.WithField(
+				"path", clientCAFile, "invalid client ca file")
+		}
+
+		f(types.ConfigTLSClientCAFile, clientCAFile)
+
+		certPool, err := readCertPool(clientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.ClientCAs = certPool
+		clientCertRequired = true
+	}
+
+	pinned := len(getStringSlice(
+		config, types.ConfigTLSClientCertFingerprints, roots...)) > 0
+	if pinned || isSet(config, types.ConfigTLSClientCRLFile, roots...) {
+		clientCertRequired = true
+	}
+
+	if clientCertRequired {
+		// a pinned certificate need not be signed by a trusted authority
+		if pinned && tlsConfig.ClientCAs == nil {
+			tlsConfig.ClientAuth = tls.RequireAnyClientCert
+		} else {
+			tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+
 	return tlsConfig, nil
 }
+
+func readCertPool(path string) (*x509.CertPool, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	certPool := x509.NewCertPool()
+	certPool.AppendCertsFromPEM(buf)
+	return certPool, nil
+}
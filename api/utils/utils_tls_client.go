@@ -0,0 +1,299 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	gofig "github.com/akutz/gofig/types"
+	"github.com/akutz/goof"
+	"github.com/akutz/gotil"
+
+	"github.com/codedellemc/libstorage/api/types"
+)
+
+// TLSClientVerifier verifies the certificates presented by TLS clients
+// against a certificate revocation list and a list of pinned certificate
+// fingerprints. It complements the verification performed by the TLS
+// handshake against the configured certificate authorities.
+type TLSClientVerifier struct {
+	sync.RWMutex
+	crlFile      string
+	crlModTime   time.Time
+	crlIssuers   []*x509.Certificate
+	revoked      map[revokedCert][]string
+	fingerprints map[string]struct{}
+}
+
+// revokedCert identifies a revoked certificate by its issuer's raw subject
+// and its serial number, since serial numbers are only unique per issuer.
+type revokedCert struct {
+	issuer string
+	serial string
+}
+
+// NewTLSClientVerifier returns a new TLS client verifier. A nil value is
+// returned if neither a revocation list nor pinned fingerprints are
+// configured.
+func NewTLSClientVerifier(
+	config gofig.Config,
+	fields log.Fields,
+	roots ...string) (*TLSClientVerifier, error) {
+
+	if !isSet(config, types.ConfigTLS, roots...) {
+		return nil, nil
+	}
+
+	v := &TLSClientVerifier{}
+
+	if fps := getStringSlice(
+		config, types.ConfigTLSClientCertFingerprints, roots...); len(fps) > 0 {
+
+		v.fingerprints = map[string]struct{}{}
+		for _, fp := range fps {
+			v.fingerprints[normalizeFingerprint(fp)] = struct{}{}
+		}
+		if fields != nil {
+			fields[types.ConfigTLSClientCertFingerprints] = len(fps)
+		}
+	}
+
+	if isSet(config, types.ConfigTLSClientCRLFile, roots...) {
+		v.crlFile = getString(config, types.ConfigTLSClientCRLFile, roots...)
+		if !gotil.FileExists(v.crlFile) {
+			return nil, goof.WithField(
+				"path", v.crlFile, "invalid client crl file")
+		}
+
+		// the revocation list is only trusted if it is signed by one of the
+		// authorities that sign client certificates
+		caFile := getString(config, types.ConfigTLSClientCAFile, roots...)
+		if caFile == "" {
+			caFile = getString(
+				config, types.ConfigTLSTrustedCertsFile, roots...)
+		}
+		if caFile == "" {
+			return nil, goof.WithField(
+				"path", v.crlFile, "client crl requires a client ca file")
+		}
+		var err error
+		if v.crlIssuers, err = readCerts(caFile); err != nil {
+			return nil, err
+		}
+
+		if err := v.loadCRL(); err != nil {
+			return nil, err
+		}
+		if fields != nil {
+			fields[types.ConfigTLSClientCRLFile] = v.crlFile
+		}
+	}
+
+	if v.fingerprints == nil && v.crlFile == "" {
+		return nil, nil
+	}
+
+	return v, nil
+}
+
+// Verify returns an error if the client's certificate is missing, is not
+// one of the pinned certificates, or has been revoked. A certificate is only
+// checked against the revocation lists signed by the certificate's issuer.
+func (v *TLSClientVerifier) Verify(state *tls.ConnectionState) error {
+
+	if state == nil || len(state.PeerCertificates) == 0 {
+		return NewUnauthorizedError("missing client certificate")
+	}
+
+	cert := state.PeerCertificates[0]
+
+	if v.fingerprints != nil {
+		sum := sha256.Sum256(cert.Raw)
+		if _, ok := v.fingerprints[hex.EncodeToString(sum[:])]; !ok {
+			return NewUnauthorizedError("client certificate not pinned")
+		}
+	}
+
+	if v.crlFile == "" {
+		return nil
+	}
+
+	if err := v.reloadCRL(); err != nil {
+		return err
+	}
+
+	v.RLock()
+	keyIDs, ok := v.revoked[revokedCert{
+		string(cert.RawIssuer), cert.SerialNumber.String()}]
+	v.RUnlock()
+	if ok && issuedByKey(cert, keyIDs) {
+		return NewUnauthorizedError("client certificate revoked")
+	}
+
+	return nil
+}
+
+// reloadCRL reloads the revocation list if its file has been modified.
+func (v *TLSClientVerifier) reloadCRL() error {
+	info, err := os.Stat(v.crlFile)
+	if err != nil {
+		return err
+	}
+	v.RLock()
+	modified := info.ModTime().After(v.crlModTime)
+	v.RUnlock()
+	if !modified {
+		return nil
+	}
+	return v.loadCRL()
+}
+
+func (v *TLSClientVerifier) loadCRL() error {
+	info, err := os.Stat(v.crlFile)
+	if err != nil {
+		return err
+	}
+	buf, err := ioutil.ReadFile(v.crlFile)
+	if err != nil {
+		return err
+	}
+
+	crls, err := parseCRLs(buf)
+	if err != nil {
+		return goof.WithFieldE("path", v.crlFile, "invalid client crl", err)
+	}
+
+	revoked := map[revokedCert][]string{}
+	for _, crl := range crls {
+		ca, err := crlSigner(crl, v.crlIssuers)
+		if err != nil {
+			return goof.WithFieldE(
+				"path", v.crlFile, "invalid client crl", err)
+		}
+		if crl.HasExpired(time.Now()) {
+			log.WithFields(log.Fields{
+				"path":   v.crlFile,
+				"issuer": ca.Subject.CommonName,
+			}).Warn("client crl has expired")
+		}
+		for _, rc := range crl.TBSCertList.RevokedCertificates {
+			k := revokedCert{string(ca.RawSubject), rc.SerialNumber.String()}
+			revoked[k] = append(revoked[k], string(ca.SubjectKeyId))
+		}
+	}
+
+	v.Lock()
+	v.revoked = revoked
+	v.crlModTime = info.ModTime()
+	v.Unlock()
+
+	return nil
+}
+
+// parseCRLs returns the revocation lists in a file that contains one DER
+// encoded list or any number of PEM encoded lists, such as a list for each
+// of the authorities that issue client certificates.
+func parseCRLs(buf []byte) ([]*pkix.CertificateList, error) {
+	var crls []*pkix.CertificateList
+	for rest := buf; ; {
+		var block *pem.Block
+		if block, rest = pem.Decode(rest); block == nil {
+			break
+		}
+		if block.Type != "X509 CRL" {
+			continue
+		}
+		crl, err := x509.ParseDERCRL(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		crls = append(crls, crl)
+	}
+	if len(crls) > 0 {
+		return crls, nil
+	}
+	crl, err := x509.ParseDERCRL(buf)
+	if err != nil {
+		return nil, err
+	}
+	return []*pkix.CertificateList{crl}, nil
+}
+
+// crlSigner returns the issuer that signed the revocation list or an error
+// if the list is not signed by one of the issuers.
+func crlSigner(
+	crl *pkix.CertificateList,
+	issuers []*x509.Certificate) (*x509.Certificate, error) {
+
+	for _, ca := range issuers {
+		if ca.CheckCRLSignature(crl) == nil {
+			return ca, nil
+		}
+	}
+	return nil, goof.New("crl not signed by a client ca")
+}
+
+// issuedByKey returns a flag indicating whether the certificate's authority
+// key ID matches one of the key IDs of the authorities that revoked its
+// serial number. Authorities that share a subject, such as an authority
+// whose key was rotated, are distinguished by their key IDs when both the
+// certificate and the authority include one.
+func issuedByKey(cert *x509.Certificate, keyIDs []string) bool {
+	if len(cert.AuthorityKeyId) == 0 {
+		return true
+	}
+	for _, id := range keyIDs {
+		if id == "" || id == string(cert.AuthorityKeyId) {
+			return true
+		}
+	}
+	return false
+}
+
+// readCerts returns the PEM-encoded certificates in a file.
+func readCerts(path string) ([]*x509.Certificate, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		if block, buf = pem.Decode(buf); block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, goof.WithFieldE(
+				"path", path, "invalid certificate", err)
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, goof.WithField("path", path, "no certificates found")
+	}
+	return certs, nil
+}
+
+// normalizeFingerprint returns the lower-case, hex-encoded form of a SHA-256
+// fingerprint that may include colons, such as the output of
+// "openssl x509 -fingerprint -sha256".
+func normalizeFingerprint(fp string) string {
+	fp = strings.TrimSpace(fp)
+	if i := strings.Index(fp, "="); i >= 0 {
+		fp = fp[i+1:]
+	}
+	return strings.ToLower(strings.Replace(fp, ":", "", -1))
+}
@@ -0,0 +1,197 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testCA struct {
+	key  *rsa.PrivateKey
+	cert *x509.Certificate
+}
+
+func newTestCA(t *testing.T, name string) *testCA {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		SubjectKeyId:          []byte(name),
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}
+	der, err := x509.CreateCertificate(
+		rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCA{key: key, cert: cert}
+}
+
+// crl returns a PEM-encoded revocation list, signed by the authority, that
+// revokes the certificates with the specified serial numbers.
+func (ca *testCA) crl(t *testing.T, serials ...int64) []byte {
+	var revoked []pkix.RevokedCertificate
+	for _, s := range serials {
+		revoked = append(revoked, pkix.RevokedCertificate{
+			SerialNumber:   big.NewInt(s),
+			RevocationTime: time.Now(),
+		})
+	}
+	der, err := ca.cert.CreateCRL(
+		rand.Reader, ca.key, revoked, time.Now(), time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: der})
+}
+
+// peer returns a connection state with a client certificate that has the
+// serial number and claims to be issued by the authority.
+func (ca *testCA) peer(serial int64, akid []byte) *tls.ConnectionState {
+	return &tls.ConnectionState{PeerCertificates: []*x509.Certificate{{
+		SerialNumber:   big.NewInt(serial),
+		RawIssuer:      ca.cert.RawSubject,
+		AuthorityKeyId: akid,
+	}}}
+}
+
+func writeTestCAs(t *testing.T, file string, cas ...*testCA) {
+	var buf []byte
+	for _, ca := range cas {
+		buf = append(buf, pem.EncodeToMemory(
+			&pem.Block{Type: "CERTIFICATE", Bytes: ca.cert.Raw})...)
+	}
+	if err := ioutil.WriteFile(file, buf, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTLSClientVerifierCRL(t *testing.T) {
+	dir, err := ioutil.TempDir("", "crl")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer os.RemoveAll(dir)
+
+	ca, other := newTestCA(t, "client-ca"), newTestCA(t, "other-ca")
+
+	caFile := path.Join(dir, "ca.crt")
+	assert.NoError(t, ioutil.WriteFile(caFile, pem.EncodeToMemory(
+		&pem.Block{Type: "CERTIFICATE", Bytes: ca.cert.Raw}), 0644))
+	issuers, err := readCerts(caFile)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Len(t, issuers, 1)
+
+	crlFile := path.Join(dir, "ca.crl")
+	assert.NoError(t, ioutil.WriteFile(crlFile, ca.crl(t, 2), 0644))
+	v := &TLSClientVerifier{crlFile: crlFile, crlIssuers: issuers}
+	assert.NoError(t, v.loadCRL())
+
+	state := func(serial int64) *tls.ConnectionState {
+		return ca.peer(serial, nil)
+	}
+	assert.NoError(t, v.Verify(state(3)))
+	assert.EqualError(t, v.Verify(state(2)), "unauthorized")
+	assert.EqualError(t, v.Verify(nil), "unauthorized")
+
+	// a list signed by another authority is rejected, even one that
+	// revokes nothing, and the list that was loaded remains in effect
+	assert.NoError(t, ioutil.WriteFile(crlFile, other.crl(t), 0644))
+	future := time.Now().Add(time.Minute)
+	assert.NoError(t, os.Chtimes(crlFile, future, future))
+	assert.EqualError(t, v.loadCRL(), "invalid client crl")
+	assert.Error(t, v.Verify(state(2)))
+	v.RLock()
+	_, revoked := v.revoked[revokedCert{string(ca.cert.RawSubject), "2"}]
+	v.RUnlock()
+	assert.True(t, revoked)
+}
+
+func TestTLSClientVerifierCRLIssuers(t *testing.T) {
+	dir, err := ioutil.TempDir("", "crl")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer os.RemoveAll(dir)
+
+	ca1, ca2 := newTestCA(t, "client-ca-1"), newTestCA(t, "client-ca-2")
+	unknown := newTestCA(t, "unknown-ca")
+
+	caFile := path.Join(dir, "ca.crt")
+	writeTestCAs(t, caFile, ca1, ca2)
+	issuers, err := readCerts(caFile)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	// a file may include a list for each authority
+	crlFile := path.Join(dir, "ca.crl")
+	assert.NoError(t, ioutil.WriteFile(
+		crlFile, append(ca1.crl(t, 2), ca2.crl(t, 3)...), 0644))
+	v := &TLSClientVerifier{crlFile: crlFile, crlIssuers: issuers}
+	if !assert.NoError(t, v.loadCRL()) {
+		t.FailNow()
+	}
+
+	tests := []struct {
+		state   *tls.ConnectionState
+		revoked bool
+	}{
+		{ca1.peer(2, nil), true},
+		{ca1.peer(3, nil), false},
+		{ca2.peer(2, nil), false},
+		{ca2.peer(3, nil), true},
+		{unknown.peer(2, nil), false},
+		{ca1.peer(2, []byte("client-ca-1")), true},
+		// a certificate issued by another key of an authority with the
+		// same subject is not revoked by the authority's list
+		{ca1.peer(2, []byte("rotated")), false},
+	}
+	for i, tt := range tests {
+		err := v.Verify(tt.state)
+		if tt.revoked {
+			assert.EqualError(t, err, "unauthorized", "%d", i)
+			continue
+		}
+		assert.NoError(t, err, "%d", i)
+	}
+
+	// a single der encoded list is also accepted
+	block, _ := pem.Decode(ca2.crl(t, 4))
+	assert.NoError(t, ioutil.WriteFile(crlFile, block.Bytes, 0644))
+	assert.NoError(t, v.loadCRL())
+	assert.NoError(t, v.Verify(ca2.peer(3, nil)))
+	assert.Error(t, v.Verify(ca2.peer(4, nil)))
+
+	// every list in a file must be signed by a client ca
+	assert.NoError(t, ioutil.WriteFile(
+		crlFile, append(ca1.crl(t, 2), unknown.crl(t, 3)...), 0644))
+	assert.EqualError(t, v.loadCRL(), "invalid client crl")
+
+	assert.NoError(t, ioutil.WriteFile(crlFile, []byte("garbage"), 0644))
+	assert.EqualError(t, v.loadCRL(), "invalid client crl")
+}
@@ -0,0 +1,86 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/akutz/goof"
+
+	"github.com/codedellemc/libstorage/api/types"
+)
+
+const (
+	// webhookQueueSize is the number of objects that may be waiting to be
+	// posted to a webhook before new objects are dropped.
+	webhookQueueSize = 1024
+
+	// webhookTimeout is the amount of time to wait for a webhook to accept an
+	// object.
+	webhookTimeout = time.Second * 10
+)
+
+// Webhook posts JSON-encoded objects to a URL. Objects are posted in order by
+// a single goroutine so that a slow webhook never delays the caller.
+type Webhook struct {
+	url    string
+	client *http.Client
+	queue  chan *webhookPost
+}
+
+type webhookPost struct {
+	ctx types.Context
+	obj interface{}
+}
+
+// NewWebhook returns a new webhook for the specified URL.
+func NewWebhook(url string) *Webhook {
+	w := &Webhook{
+		url:    url,
+		client: &http.Client{Timeout: webhookTimeout},
+		queue:  make(chan *webhookPost, webhookQueueSize),
+	}
+	go func() {
+		for p := range w.queue {
+			if err := w.post(p.obj); err != nil {
+				p.ctx.WithError(err).Error("error posting to webhook")
+			}
+		}
+	}()
+	return w
+}
+
+// URL returns the webhook's URL.
+func (w *Webhook) URL() string {
+	return w.url
+}
+
+// Post enqueues the object to be posted to the webhook. The object is dropped
+// and an error is logged if the webhook's queue is full.
+func (w *Webhook) Post(ctx types.Context, obj interface{}) {
+	select {
+	case w.queue <- &webhookPost{ctx, obj}:
+	default:
+		ctx.WithField("url", w.url).Error("webhook queue full; dropped object")
+	}
+}
+
+func (w *Webhook) post(obj interface{}) error {
+	buf, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	res, err := w.client.Post(w.url, "application/json", bytes.NewReader(buf))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode > 299 {
+		return goof.WithFields(goof.Fields{
+			"url":    w.url,
+			"status": res.StatusCode,
+		}, "webhook rejected object")
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhook(t *testing.T) {
+	var (
+		status   = http.StatusOK
+		received []map[string]interface{}
+	)
+	hs := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, req *http.Request) {
+			assert.Equal(t, "application/json",
+				req.Header.Get("Content-Type"))
+			var obj map[string]interface{}
+			assert.NoError(t, json.NewDecoder(req.Body).Decode(&obj))
+			received = append(received, obj)
+			w.WriteHeader(status)
+		}))
+	defer hs.Close()
+
+	w := NewWebhook(hs.URL)
+	assert.Equal(t, hs.URL, w.URL())
+
+	assert.NoError(t, w.post(map[string]string{"type": "created"}))
+	if assert.Len(t, received, 1) {
+		assert.Equal(t, "created", received[0]["type"])
+	}
+
+	status = http.StatusServiceUnavailable
+	assert.EqualError(t, w.post(map[string]string{}),
+		"webhook rejected object")
+
+	// objects that cannot be encoded are not posted
+	assert.Error(t, w.post(make(chan int)))
+	assert.Len(t, received, 2)
+
+	hs.Close()
+	assert.Error(t, w.post(map[string]string{}))
+}
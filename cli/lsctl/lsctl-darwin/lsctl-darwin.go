@@ -0,0 +1,11 @@
+// +build darwin
+
+package main
+
+import (
+	"github.com/codedellemc/libstorage/cli/lsctl"
+)
+
+func main() {
+	lsctl.Run()
+}
@@ -0,0 +1,11 @@
+// +build windows
+
+package main
+
+import (
+	"github.com/codedellemc/libstorage/cli/lsctl"
+)
+
+func main() {
+	lsctl.Run()
+}
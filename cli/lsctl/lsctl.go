@@ -0,0 +1,288 @@
+// +build gofig pflag
+
+package lsctl
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	log "github.com/Sirupsen/logrus"
+	gofig "github.com/akutz/gofig/types"
+	"github.com/akutz/goof"
+	"github.com/akutz/gotil"
+	flag "github.com/spf13/pflag"
+
+	"github.com/codedellemc/libstorage/api"
+	"github.com/codedellemc/libstorage/api/context"
+	apitypes "github.com/codedellemc/libstorage/api/types"
+	apiconfig "github.com/codedellemc/libstorage/api/utils/config"
+	"github.com/codedellemc/libstorage/client"
+
+	// load the config and the integration driver used to mount volumes
+	_ "github.com/codedellemc/libstorage/drivers/integration/docker"
+	_ "github.com/codedellemc/libstorage/imports/config"
+)
+
+var (
+	cliFlags    *flag.FlagSet
+	flagHost    *string
+	flagConfig  *string
+	flagLogLvl  *string
+	flagToken   *string
+	flagService *string
+	flagJSON    *bool
+	flagHelp    *bool
+	flagVersion *bool
+)
+
+func init() {
+	cliFlags = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	cliFlags.SetInterspersed(false)
+	flagConfig = cliFlags.StringP("config", "c", "", "path")
+	flagHost = cliFlags.StringP("host", "h", "", "<proto>://<addr>")
+	flagLogLvl = cliFlags.StringP("log", "l", "warn", "error|warn|info|debug")
+	flagToken = cliFlags.StringP("token", "t", "", "bearer token")
+	flagService = cliFlags.StringP("service", "s", "", "service name")
+	flagJSON = cliFlags.Bool("json", false, "print results as JSON")
+	flagHelp = cliFlags.BoolP("help", "?", false, "print usage")
+	flagVersion = cliFlags.Bool("version", false, "print version info")
+}
+
+// command is a subcommand of the CLI.
+type command struct {
+	name  string
+	args  string
+	desc  string
+	flags func(fs *flag.FlagSet)
+	run   func(c *cli, fs *flag.FlagSet) error
+}
+
+// commandGroup is a collection of related subcommands, such as the volume
+// commands.
+type commandGroup struct {
+	name     string
+	commands []*command
+}
+
+var commandGroups = []*commandGroup{
+	{name: "service", commands: serviceCommands},
+	{name: "volume", commands: volumeCommands},
+	{name: "snapshot", commands: snapshotCommands},
+}
+
+// cli is the state shared by the subcommands.
+type cli struct {
+	ctx    apitypes.Context
+	config gofig.Config
+	client apitypes.Client
+	out    io.Writer
+	json   bool
+}
+
+// Run the CLI.
+func Run() {
+	cliFlags.Usage = printUsage
+	if err := cliFlags.Parse(os.Args[1:]); err != nil {
+		exit(err)
+	}
+
+	if *flagVersion {
+		_, _, thisExeAbsPath := gotil.GetThisPathParts()
+		fmt.Fprintf(os.Stdout, "Binary: %s\n", thisExeAbsPath)
+		fmt.Fprint(os.Stdout, api.Version.String())
+		os.Exit(0)
+	}
+
+	args := cliFlags.Args()
+	if *flagHelp || len(args) < 2 {
+		printUsage()
+	}
+
+	cmd := findCommand(args[0], args[1])
+	if cmd == nil {
+		printUsage()
+	}
+
+	name := fmt.Sprintf("%s %s %s", os.Args[0], args[0], cmd.name)
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	if cmd.flags != nil {
+		cmd.flags(fs)
+	}
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: %s %s\n", name, cmd.args)
+		fmt.Fprintln(os.Stderr, fs.FlagUsages())
+		os.Exit(1)
+	}
+	if err := fs.Parse(args[2:]); err != nil {
+		exit(err)
+	}
+
+	c, err := newCLI()
+	if err != nil {
+		exit(err)
+	}
+	if err := cmd.run(c, fs); err != nil {
+		exit(err)
+	}
+}
+
+func newCLI() (*cli, error) {
+	if lvl, err := log.ParseLevel(*flagLogLvl); err == nil {
+		log.SetLevel(lvl)
+		os.Setenv("LIBSTORAGE_LOGGING_LEVEL", *flagLogLvl)
+	}
+
+	config, err := apiconfig.NewConfig()
+	if err != nil {
+		return nil, err
+	}
+	if *flagConfig != "" {
+		if err := config.ReadConfigFile(*flagConfig); err != nil {
+			return nil, err
+		}
+	}
+	if *flagHost != "" {
+		config.Set(apitypes.ConfigHost, *flagHost)
+	}
+	if *flagToken != "" {
+		config.Set(apitypes.ConfigClientAuthToken, *flagToken)
+	}
+	if config.GetString(apitypes.ConfigHost) == "" {
+		return nil, goof.New("missing host; specify -h or LIBSTORAGE_HOST")
+	}
+
+	// the client's storage driver must be the libStorage client driver so
+	// that the commands are issued to the remote server
+	config.Set(apitypes.ConfigStorageDriver, apitypes.LibStorageDriverName)
+	if config.GetString(apitypes.ConfigIntegrationDriver) == "" {
+		config.Set(apitypes.ConfigIntegrationDriver, "docker")
+	}
+
+	c, err := client.New(nil, config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cli{
+		ctx:    context.Background(),
+		config: config,
+		client: c,
+		out:    os.Stdout,
+		json:   *flagJSON,
+	}, nil
+}
+
+func findCommand(group, name string) *command {
+	for _, g := range commandGroups {
+		if g.name != group {
+			continue
+		}
+		for _, cmd := range g.commands {
+			if cmd.name == name {
+				return cmd
+			}
+		}
+	}
+	return nil
+}
+
+// service returns the name of the service specified by the --service flag
+// or the libstorage.service property, if any.
+func (c *cli) service() string {
+	if *flagService != "" {
+		return *flagService
+	}
+	return c.config.GetString(apitypes.ConfigService)
+}
+
+// serviceContext returns a context for the operations of the service
+// returned by service. An error is returned if no service is specified.
+func (c *cli) serviceContext() (apitypes.Context, string, error) {
+	service := c.service()
+	if service == "" {
+		return nil, "", goof.New("missing service; specify -s")
+	}
+	return c.ctx.WithValue(context.ServiceKey, service), service, nil
+}
+
+// print prints a value as JSON if --json is set; otherwise the rows returned
+// by the table function are printed as a table with the provided header.
+func (c *cli) print(
+	v interface{}, header []string, table func() [][]string) error {
+
+	if c.json {
+		buf, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(c.out, string(buf))
+		return nil
+	}
+
+	w := tabwriter.NewWriter(c.out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(header, "\t"))
+	for _, row := range table() {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	return w.Flush()
+}
+
+// parseOpts parses a list of key=value pairs into a map.
+func parseOpts(opts []string) (map[string]interface{}, error) {
+	if len(opts) == 0 {
+		return nil, nil
+	}
+	m := map[string]interface{}{}
+	for _, o := range opts {
+		kv := strings.SplitN(o, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, goof.WithField("opt", o, "invalid opt; use key=value")
+		}
+		m[kv[0]] = kv[1]
+	}
+	return m, nil
+}
+
+func requireArgs(fs *flag.FlagSet, n int) []string {
+	if fs.NArg() != n {
+		fs.Usage()
+	}
+	return fs.Args()
+}
+
+func exit(err error) {
+	fmt.Fprintf(os.Stderr, "%s: error: %v\n", os.Args[0], err)
+	os.Exit(1)
+}
+
+func printUsage() {
+	fmt.Fprintf(os.Stderr,
+		"usage: %s [-options] <group> <command> [args]\n\n", os.Args[0])
+	fmt.Fprintln(os.Stderr, cliFlags.FlagUsages())
+	for _, g := range commandGroups {
+		fmt.Fprintf(os.Stderr, "  %s\n", g.name)
+		w := tabwriter.NewWriter(os.Stderr, 0, 8, 2, ' ', 0)
+		for _, cmd := range g.commands {
+			fmt.Fprintf(w, "    %s %s\t%s\n", cmd.name, cmd.args, cmd.desc)
+		}
+		w.Flush()
+		fmt.Fprintln(os.Stderr)
+	}
+	fmt.Fprintln(os.Stderr, hostUsage)
+	os.Exit(1)
+}
+
+const hostUsage = `  Host Address
+
+    The -h flag expects the address of a libStorage server, ex.
+    tcp://127.0.0.1:7979. The address may also be specified with the
+    LIBSTORAGE_HOST environment variable or the libstorage.host property
+    of the configuration file specified with -c.
+
+    The commands that operate on a single service require the service's
+    name, specified with -s or the libstorage.service property.
+`
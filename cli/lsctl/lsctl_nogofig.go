@@ -0,0 +1,15 @@
+// +build !gofig !pflag
+
+package lsctl
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+)
+
+// Run the CLI.
+func Run() {
+	fmt.Fprintf(os.Stderr, "lsctl-%s was built without gofig\n", runtime.GOOS)
+	os.Exit(1)
+}
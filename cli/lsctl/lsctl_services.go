@@ -0,0 +1,46 @@
+// +build gofig pflag
+
+package lsctl
+
+import (
+	"sort"
+
+	flag "github.com/spf13/pflag"
+)
+
+var serviceCommands = []*command{
+	{
+		name: "ls",
+		desc: "list the services",
+		run:  serviceList,
+	},
+}
+
+func serviceList(c *cli, fs *flag.FlagSet) error {
+	requireArgs(fs, 0)
+
+	services, err := c.client.API().Services(c.ctx)
+	if err != nil {
+		return err
+	}
+
+	return c.print(services, []string{"NAME", "DRIVER", "TYPE"},
+		func() [][]string {
+			names := make([]string, 0, len(services))
+			for name := range services {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+
+			var rows [][]string
+			for _, name := range names {
+				var driver, storageType string
+				if d := services[name].Driver; d != nil {
+					driver = d.Name
+					storageType = string(d.Type)
+				}
+				rows = append(rows, []string{name, driver, storageType})
+			}
+			return rows
+		})
+}
@@ -0,0 +1,158 @@
+// +build gofig pflag
+
+package lsctl
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	apitypes "github.com/codedellemc/libstorage/api/types"
+)
+
+var snapshotHeader = []string{
+	"SERVICE", "ID", "NAME", "VOLUME", "SIZE", "STATUS", "STARTED",
+}
+
+var snapshotCommands = []*command{
+	{
+		name: "ls",
+		desc: "list the snapshots of one or all services",
+		run:  snapshotList,
+	},
+	{
+		name: "inspect",
+		args: "<snapshotID>",
+		desc: "inspect a snapshot",
+		run:  snapshotInspect,
+	},
+	{
+		name: "create",
+		args: "<volumeID> <snapshotName>",
+		desc: "create a snapshot of a volume",
+		flags: func(fs *flag.FlagSet) {
+			fs.StringSlice("opt", nil, "driver-specific option, key=value")
+		},
+		run: snapshotCreate,
+	},
+	{
+		name: "rm",
+		args: "<snapshotID>",
+		desc: "remove a snapshot",
+		run:  snapshotRemove,
+	},
+}
+
+func snapshotList(c *cli, fs *flag.FlagSet) error {
+	requireArgs(fs, 0)
+
+	var (
+		ssm apitypes.ServiceSnapshotMap
+		err error
+	)
+	if service := c.service(); service != "" {
+		var sm apitypes.SnapshotMap
+		sm, err = c.client.API().SnapshotsByService(c.ctx, service)
+		if err != nil {
+			return err
+		}
+		ssm = apitypes.ServiceSnapshotMap{service: sm}
+	} else {
+		ssm, err = c.client.API().Snapshots(c.ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	return c.print(ssm, snapshotHeader, func() [][]string {
+		services := make([]string, 0, len(ssm))
+		for service := range ssm {
+			services = append(services, service)
+		}
+		sort.Strings(services)
+
+		var rows [][]string
+		for _, service := range services {
+			sm := ssm[service]
+			ids := make([]string, 0, len(sm))
+			for id := range sm {
+				ids = append(ids, id)
+			}
+			sort.Strings(ids)
+			for _, id := range ids {
+				rows = append(rows, snapshotRow(service, sm[id]))
+			}
+		}
+		return rows
+	})
+}
+
+func snapshotInspect(c *cli, fs *flag.FlagSet) error {
+	args := requireArgs(fs, 1)
+	_, service, err := c.serviceContext()
+	if err != nil {
+		return err
+	}
+	snap, err := c.client.API().SnapshotInspect(c.ctx, service, args[0])
+	if err != nil {
+		return err
+	}
+	return c.printSnapshot(service, snap)
+}
+
+func snapshotCreate(c *cli, fs *flag.FlagSet) error {
+	args := requireArgs(fs, 2)
+	_, service, err := c.serviceContext()
+	if err != nil {
+		return err
+	}
+
+	opts, _ := fs.GetStringSlice("opt")
+	reqOpts, err := parseOpts(opts)
+	if err != nil {
+		return err
+	}
+
+	snap, err := c.client.API().VolumeSnapshot(
+		c.ctx, service, args[0], &apitypes.VolumeSnapshotRequest{
+			SnapshotName: args[1],
+			Opts:         reqOpts,
+		})
+	if err != nil {
+		return err
+	}
+	return c.printSnapshot(service, snap)
+}
+
+func snapshotRemove(c *cli, fs *flag.FlagSet) error {
+	args := requireArgs(fs, 1)
+	_, service, err := c.serviceContext()
+	if err != nil {
+		return err
+	}
+	return c.client.API().SnapshotRemove(c.ctx, service, args[0])
+}
+
+func (c *cli) printSnapshot(service string, snap *apitypes.Snapshot) error {
+	return c.print(snap, snapshotHeader, func() [][]string {
+		return [][]string{snapshotRow(service, snap)}
+	})
+}
+
+func snapshotRow(service string, snap *apitypes.Snapshot) []string {
+	var started string
+	if snap.StartTime > 0 {
+		started = time.Unix(snap.StartTime, 0).Format(time.RFC3339)
+	}
+	return []string{
+		service,
+		snap.ID,
+		snap.Name,
+		snap.VolumeID,
+		fmt.Sprintf("%d", snap.VolumeSize),
+		snap.Status,
+		started,
+	}
+}
@@ -0,0 +1,319 @@
+// +build gofig pflag
+
+package lsctl
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	flag "github.com/spf13/pflag"
+
+	apitypes "github.com/codedellemc/libstorage/api/types"
+	"github.com/codedellemc/libstorage/api/utils"
+)
+
+var volumeHeader = []string{
+	"SERVICE", "ID", "NAME", "SIZE", "STATUS", "ATTACHMENTS",
+}
+
+var volumeCommands = []*command{
+	{
+		name: "ls",
+		desc: "list the volumes of one or all services",
+		run:  volumeList,
+	},
+	{
+		name: "inspect",
+		args: "<volumeID>",
+		desc: "inspect a volume",
+		run:  volumeInspect,
+	},
+	{
+		name: "create",
+		args: "<volumeName>",
+		desc: "create a volume",
+		flags: func(fs *flag.FlagSet) {
+			fs.Int64("size", 0, "size in GB")
+			fs.String("type", "", "volume type")
+			fs.Int64("iops", 0, "IOPS")
+			fs.String("availabilityZone", "", "availability zone")
+			fs.Bool("encrypted", false, "encrypt the volume")
+			fs.StringSlice("opt", nil, "driver-specific option, key=value")
+		},
+		run: volumeCreate,
+	},
+	{
+		name: "rm",
+		args: "<volumeID>",
+		desc: "remove a volume",
+		run:  volumeRemove,
+	},
+	{
+		name: "attach",
+		args: "<volumeID>",
+		desc: "attach a volume to this instance",
+		flags: func(fs *flag.FlagSet) {
+			fs.Bool("force", false, "detach the volume from other instances")
+			fs.StringSlice("opt", nil, "driver-specific option, key=value")
+		},
+		run: volumeAttach,
+	},
+	{
+		name: "detach",
+		args: "<volumeID>",
+		desc: "detach a volume from this instance",
+		flags: func(fs *flag.FlagSet) {
+			fs.Bool("force", false, "force the detach")
+		},
+		run: volumeDetach,
+	},
+	{
+		name: "mount",
+		args: "<volumeName>",
+		desc: "attach and mount a volume, creating it if necessary",
+		flags: func(fs *flag.FlagSet) {
+			fs.String("fsType", "", "file system type of a new file system")
+			fs.Bool("overwriteFs", false, "create a file system if none exists")
+			fs.Bool("preempt", false, "detach the volume from other instances")
+		},
+		run: volumeMount,
+	},
+	{
+		name: "unmount",
+		args: "<volumeName>",
+		desc: "unmount and detach a volume",
+		run:  volumeUnmount,
+	},
+}
+
+func volumeList(c *cli, fs *flag.FlagSet) error {
+	requireArgs(fs, 0)
+
+	var (
+		svm apitypes.ServiceVolumeMap
+		err error
+	)
+	if service := c.service(); service != "" {
+		var vm apitypes.VolumeMap
+		vm, err = c.client.API().VolumesByService(
+			c.ctx, service, apitypes.VolumeAttachmentsRequested)
+		if err != nil {
+			return err
+		}
+		svm = apitypes.ServiceVolumeMap{service: vm}
+	} else {
+		svm, err = c.client.API().Volumes(
+			c.ctx, apitypes.VolumeAttachmentsRequested)
+		if err != nil {
+			return err
+		}
+	}
+
+	return c.print(svm, volumeHeader, func() [][]string {
+		var rows [][]string
+		for _, service := range sortedServices(svm) {
+			vm := svm[service]
+			ids := make([]string, 0, len(vm))
+			for id := range vm {
+				ids = append(ids, id)
+			}
+			sort.Strings(ids)
+			for _, id := range ids {
+				rows = append(rows, volumeRow(service, vm[id]))
+			}
+		}
+		return rows
+	})
+}
+
+func volumeInspect(c *cli, fs *flag.FlagSet) error {
+	args := requireArgs(fs, 1)
+	_, service, err := c.serviceContext()
+	if err != nil {
+		return err
+	}
+	vol, err := c.client.API().VolumeInspect(
+		c.ctx, service, args[0], apitypes.VolumeAttachmentsRequested)
+	if err != nil {
+		return err
+	}
+	return c.printVolume(service, vol)
+}
+
+func volumeCreate(c *cli, fs *flag.FlagSet) error {
+	args := requireArgs(fs, 1)
+	_, service, err := c.serviceContext()
+	if err != nil {
+		return err
+	}
+
+	opts, _ := fs.GetStringSlice("opt")
+	reqOpts, err := parseOpts(opts)
+	if err != nil {
+		return err
+	}
+
+	req := &apitypes.VolumeCreateRequest{Name: args[0], Opts: reqOpts}
+	if fs.Changed("size") {
+		v, _ := fs.GetInt64("size")
+		req.Size = &v
+	}
+	if fs.Changed("type") {
+		v, _ := fs.GetString("type")
+		req.Type = &v
+	}
+	if fs.Changed("iops") {
+		v, _ := fs.GetInt64("iops")
+		req.IOPS = &v
+	}
+	if fs.Changed("availabilityZone") {
+		v, _ := fs.GetString("availabilityZone")
+		req.AvailabilityZone = &v
+	}
+	if fs.Changed("encrypted") {
+		v, _ := fs.GetBool("encrypted")
+		req.Encrypted = &v
+	}
+
+	vol, err := c.client.API().VolumeCreate(c.ctx, service, req)
+	if err != nil {
+		return err
+	}
+	return c.printVolume(service, vol)
+}
+
+func volumeRemove(c *cli, fs *flag.FlagSet) error {
+	args := requireArgs(fs, 1)
+	_, service, err := c.serviceContext()
+	if err != nil {
+		return err
+	}
+	return c.client.API().VolumeRemove(c.ctx, service, args[0])
+}
+
+// volumeAttach attaches a volume with the client's storage driver, rather
+// than the API client, so that the request includes the instance ID and the
+// client waits for the device to be ready.
+func volumeAttach(c *cli, fs *flag.FlagSet) error {
+	args := requireArgs(fs, 1)
+	ctx, service, err := c.serviceContext()
+	if err != nil {
+		return err
+	}
+
+	opts, _ := fs.GetStringSlice("opt")
+	reqOpts, err := parseOpts(opts)
+	if err != nil {
+		return err
+	}
+	store := utils.NewStore()
+	for k, v := range reqOpts {
+		store.Set(k, v)
+	}
+	force, _ := fs.GetBool("force")
+
+	vol, token, err := c.client.Storage().VolumeAttach(
+		ctx, args[0], &apitypes.VolumeAttachOpts{Force: force, Opts: store})
+	if err != nil {
+		return err
+	}
+	if !c.json {
+		fmt.Fprintf(c.out, "attach token: %s\n\n", token)
+	}
+	return c.printVolume(service, vol)
+}
+
+func volumeDetach(c *cli, fs *flag.FlagSet) error {
+	args := requireArgs(fs, 1)
+	ctx, service, err := c.serviceContext()
+	if err != nil {
+		return err
+	}
+	force, _ := fs.GetBool("force")
+
+	vol, err := c.client.Storage().VolumeDetach(
+		ctx, args[0],
+		&apitypes.VolumeDetachOpts{Force: force, Opts: utils.NewStore()})
+	if err != nil {
+		return err
+	}
+	if vol == nil {
+		return nil
+	}
+	return c.printVolume(service, vol)
+}
+
+// volumeMount mounts a volume with the client's integration driver, which
+// attaches the volume and creates its file system as necessary.
+func volumeMount(c *cli, fs *flag.FlagSet) error {
+	args := requireArgs(fs, 1)
+	ctx, _, err := c.serviceContext()
+	if err != nil {
+		return err
+	}
+
+	fsType, _ := fs.GetString("fsType")
+	overwriteFS, _ := fs.GetBool("overwriteFs")
+	preempt, _ := fs.GetBool("preempt")
+
+	mountPath, _, err := c.client.Integration().Mount(
+		ctx, "", args[0], &apitypes.VolumeMountOpts{
+			NewFSType:   fsType,
+			OverwriteFS: overwriteFS,
+			Preempt:     preempt,
+			Opts:        utils.NewStore(),
+		})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(c.out, mountPath)
+	return nil
+}
+
+func volumeUnmount(c *cli, fs *flag.FlagSet) error {
+	args := requireArgs(fs, 1)
+	ctx, _, err := c.serviceContext()
+	if err != nil {
+		return err
+	}
+	return c.client.Integration().Unmount(
+		ctx, "", args[0], utils.NewStore())
+}
+
+func (c *cli) printVolume(service string, vol *apitypes.Volume) error {
+	return c.print(vol, volumeHeader, func() [][]string {
+		return [][]string{volumeRow(service, vol)}
+	})
+}
+
+func volumeRow(service string, vol *apitypes.Volume) []string {
+	var atts []string
+	for _, a := range vol.Attachments {
+		att := a.Status
+		if a.InstanceID != nil {
+			att = a.InstanceID.ID
+			if a.DeviceName != "" {
+				att = fmt.Sprintf("%s:%s", att, a.DeviceName)
+			}
+		}
+		atts = append(atts, att)
+	}
+	return []string{
+		service,
+		vol.ID,
+		vol.Name,
+		fmt.Sprintf("%d", vol.Size),
+		vol.Status,
+		strings.Join(atts, ","),
+	}
+}
+
+func sortedServices(svm apitypes.ServiceVolumeMap) []string {
+	services := make([]string, 0, len(svm))
+	for service := range svm {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+	return services
+}
@@ -11,6 +11,9 @@ import (
 	"strings"
 	"time"
 
+	gofig "github.com/akutz/gofig/types"
+	"github.com/akutz/goof"
+
 	"github.com/codedellemc/libstorage/api/context"
 	"github.com/codedellemc/libstorage/api/registry"
 	apitypes "github.com/codedellemc/libstorage/api/types"
@@ -31,39 +34,85 @@ var (
 func Run() {
 
 	args := os.Args
+	if len(args) > 1 && strings.EqualFold(args[1], apitypes.LSXCmdAgent) {
+		if len(args) > 3 {
+			printUsageAndExit()
+		}
+		runAgent(args[2:])
+		return
+	}
+
 	if len(args) < 3 {
 		printUsageAndExit()
 	}
 
-	d, err := registry.NewStorageExecutor(args[1])
+	config, err := apiconfig.NewConfig()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
 		os.Exit(1)
 	}
 
-	driverName := strings.ToLower(d.Name())
+	apiconfig.UpdateLogLevel(config)
+	ctx := context.Background()
 
-	config, err := apiconfig.NewConfig()
+	d, err := newExecutor(ctx, config, args[1])
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
 		os.Exit(1)
 	}
 
-	apiconfig.UpdateLogLevel(config)
-	ctx := context.Background()
+	out, exitCode, err := execute(ctx, d, args[2:])
+	if err == errUsage {
+		printUsageAndExit()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(exitCode)
+	}
 
+	os.Stdout.Write(out)
+	os.Exit(exitCode)
+}
+
+var errUsage = goof.New("invalid executor command")
+
+// newExecutor returns a new, initialized instance of the named executor.
+func newExecutor(
+	ctx apitypes.Context,
+	config gofig.Config,
+	name string) (apitypes.StorageExecutor, error) {
+
+	d, err := registry.NewStorageExecutor(name)
+	if err != nil {
+		return nil, err
+	}
 	if err := d.Init(ctx, config); err != nil {
-		fmt.Fprintf(os.Stderr, "error: %v\n", err)
-		os.Exit(1)
+		return nil, err
 	}
+	return d, nil
+}
+
+// execute executes an executor command and returns its encoded result and
+// the exit code with which the executor binary exits.
+func execute(
+	ctx apitypes.Context,
+	d apitypes.StorageExecutor,
+	args []string) ([]byte, int, error) {
+
+	if len(args) < 1 {
+		return nil, 1, errUsage
+	}
+
+	driverName := strings.ToLower(d.Name())
 
-	cmd := cmdRx.FindString(args[2])
+	cmd := cmdRx.FindString(args[0])
 	if cmd == "" {
-		printUsageAndExit()
+		return nil, 1, errUsage
 	}
 	store := utils.NewStore()
 
 	var (
+		err      error
 		result   interface{}
 		op       string
 		exitCode int
@@ -99,32 +148,32 @@ func Run() {
 			result = opResult
 		}
 	} else if strings.EqualFold(cmd, apitypes.LSXCmdLocalDevices) {
-		if len(args) < 4 {
-			printUsageAndExit()
+		if len(args) < 2 {
+			return nil, 1, errUsage
 		}
 		op = "local devices"
 		opResult, opErr := d.LocalDevices(ctx, &apitypes.LocalDevicesOpts{
-			ScanType: apitypes.ParseDeviceScanType(args[3]),
+			ScanType: apitypes.ParseDeviceScanType(args[1]),
 			Opts:     store,
 		})
-		opResult.Driver = driverName
 		if opErr != nil {
 			err = opErr
 		} else {
+			opResult.Driver = driverName
 			result = opResult
 		}
 	} else if strings.EqualFold(cmd, apitypes.LSXCmdWaitForDevice) {
-		if len(args) < 5 {
-			printUsageAndExit()
+		if len(args) < 4 {
+			return nil, 1, errUsage
 		}
 		op = "wait"
 		opts := &apitypes.WaitForDeviceOpts{
 			LocalDevicesOpts: apitypes.LocalDevicesOpts{
-				ScanType: apitypes.ParseDeviceScanType(args[3]),
+				ScanType: apitypes.ParseDeviceScanType(args[1]),
 				Opts:     store,
 			},
-			Token:   strings.ToLower(args[4]),
-			Timeout: utils.DeviceAttachTimeout(args[5]),
+			Token:   args[2],
+			Timeout: utils.DeviceAttachTimeout(args[3]),
 		}
 
 		ldl := func() (bool, *apitypes.LocalDevices, error) {
@@ -132,8 +181,15 @@ func Run() {
 			if err != nil {
 				return false, nil, err
 			}
+			if dwr, ok := d.(apitypes.StorageExecutorWithDeviceReady); ok {
+				ready, err := dwr.DeviceReady(ctx, opts.Token, store)
+				if err != nil {
+					return false, nil, err
+				}
+				return ready, ldm, nil
+			}
 			for k := range ldm.DeviceMap {
-				if strings.ToLower(k) == opts.Token {
+				if strings.EqualFold(k, opts.Token) {
 					return true, ldm, nil
 				}
 			}
@@ -145,8 +201,9 @@ func Run() {
 			opErr    error
 			opResult *apitypes.LocalDevices
 			timeoutC = time.After(opts.Timeout)
-			tick     = time.Tick(500 * time.Millisecond)
+			tick     = time.NewTicker(500 * time.Millisecond)
 		)
+		defer tick.Stop()
 
 	TimeoutLoop:
 
@@ -155,7 +212,7 @@ func Run() {
 			case <-timeoutC:
 				exitCode = apitypes.LSXExitCodeTimedOut
 				break TimeoutLoop
-			case <-tick:
+			case <-tick.C:
 				if found, opResult, opErr = ldl(); found || opErr != nil {
 					break TimeoutLoop
 				}
@@ -164,7 +221,7 @@ func Run() {
 
 		if opErr != nil {
 			err = opErr
-		} else {
+		} else if opResult != nil {
 			opResult.Driver = driverName
 			result = opResult
 		}
@@ -178,37 +235,36 @@ func Run() {
 		if strings.EqualFold(err.Error(), apitypes.ErrNotImplemented.Error()) {
 			exitCode = apitypes.LSXExitCodeNotImplemented
 		}
-		fmt.Fprintf(os.Stderr,
-			"error: error getting %s: %v\n", op, err)
-		os.Exit(exitCode)
+		return nil, exitCode, fmt.Errorf("error getting %s: %v", op, err)
 	}
 
+	out, err := encode(result)
+	if err != nil {
+		return nil, 1, fmt.Errorf("error encoding %s: %v", op, err)
+	}
+	return out, exitCode, nil
+}
+
+// encode encodes an executor command's result as the executor binary writes
+// it to stdout.
+func encode(result interface{}) ([]byte, error) {
 	switch tr := result.(type) {
 	case bool:
-		fmt.Fprintf(os.Stdout, "%v", result)
+		return []byte(fmt.Sprintf("%v", result)), nil
 	case string:
-		fmt.Fprintln(os.Stdout, result)
+		return []byte(fmt.Sprintln(result)), nil
 	case encoding.TextMarshaler:
-		buf, err := tr.MarshalText()
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "error: error encoding %s: %v\n", op, err)
-			os.Exit(1)
-		}
-		os.Stdout.Write(buf)
+		return tr.MarshalText()
 	default:
 		buf, err := json.Marshal(result)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "error: error encoding %s: %v\n", op, err)
-			os.Exit(1)
+			return nil, err
 		}
 		if isNullBuf(buf) {
-			os.Stdout.Write(emptyJSONBuff)
-		} else {
-			os.Stdout.Write(buf)
+			return emptyJSONBuff, nil
 		}
+		return buf, nil
 	}
-
-	os.Exit(exitCode)
 }
 
 const (
@@ -250,6 +306,7 @@ func printUsage() {
 	printUsageLeftPadded(w, lpad2, "nextDevice\n")
 	printUsageLeftPadded(w, lpad2, "localDevices <scanType>\n")
 	printUsageLeftPadded(w, lpad2, "wait <scanType> <attachToken> <timeout>\n")
+	printUsageLeftPadded(w, lpad1, "%s agent [socket]\n", os.Args[0])
 	fmt.Fprintln(w)
 	executorVar := "executor:    "
 	printUsageLeftPadded(w, lpad1, executorVar)
@@ -0,0 +1,169 @@
+package lsx
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"net"
+	"net/rpc"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+
+	gofig "github.com/akutz/gofig/types"
+	"github.com/akutz/goof"
+
+	"github.com/codedellemc/libstorage/api/context"
+	"github.com/codedellemc/libstorage/api/registry"
+	apitypes "github.com/codedellemc/libstorage/api/types"
+	apiconfig "github.com/codedellemc/libstorage/api/utils/config"
+)
+
+// agent runs executor commands on behalf of the clients on the host so that
+// the executor binary need not be executed for every command. The executors
+// are initialized once for each client configuration and reused. Commands run
+// concurrently so that a command waiting for a device does not delay the
+// commands of other clients.
+type agent struct {
+	sync.Mutex
+	ctx       apitypes.Context
+	config    gofig.Config
+	executors map[string]apitypes.StorageExecutor
+}
+
+// Run runs a command in the agent.
+func (a *agent) Run(
+	req *apitypes.LSXAgentRequest, reply *apitypes.LSXAgentReply) error {
+
+	if len(req.Args) < 2 {
+		reply.ExitCode = 1
+		reply.Error = errUsage.Error()
+		return nil
+	}
+
+	d, err := a.executor(req.Args[0], req.Config)
+	if err != nil {
+		reply.ExitCode = 1
+		reply.Error = err.Error()
+		return nil
+	}
+
+	a.ctx.WithField("args", req.Args).Debug("running agent command")
+	reply.Output, reply.ExitCode, err = execute(a.ctx, d, req.Args[1:])
+	if err != nil {
+		reply.Error = err.Error()
+	}
+	return nil
+}
+
+// executor returns the named executor initialized with the client's
+// configuration, or with the agent's if the client did not send one.
+func (a *agent) executor(
+	name string, clientConfig []byte) (apitypes.StorageExecutor, error) {
+
+	name = strings.ToLower(name)
+	key := fmt.Sprintf("%s-%x", name, sha256.Sum256(clientConfig))
+
+	a.Lock()
+	defer a.Unlock()
+
+	if d, ok := a.executors[key]; ok {
+		return d, nil
+	}
+
+	config := a.config
+	if len(clientConfig) > 0 {
+		config = registry.NewConfig()
+		if err := config.ReadConfig(
+			bytes.NewReader(clientConfig)); err != nil {
+			return nil, err
+		}
+	}
+
+	d, err := newExecutor(a.ctx, config, name)
+	if err != nil {
+		return nil, err
+	}
+	a.executors[key] = d
+	return d, nil
+}
+
+// runAgent runs the executor binary as an agent that listens on the provided
+// UNIX socket, or the configured one, until it is interrupted.
+func runAgent(args []string) {
+
+	config, err := apiconfig.NewConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	apiconfig.UpdateLogLevel(config)
+	ctx := context.Background()
+
+	sock := config.GetString(apitypes.ConfigExecutorAgent)
+	if len(args) > 0 {
+		sock = args[0]
+	}
+
+	if err := removeSocket(sock); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	l, err := net.Listen("unix", sock)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// the agent runs commands as its own user, which is typically root, so
+	// only that user may connect to it
+	if err := os.Chmod(sock, 0600); err != nil {
+		l.Close()
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	srv := rpc.NewServer()
+	if err := srv.RegisterName("Agent", &agent{
+		ctx:       ctx,
+		config:    config,
+		executors: map[string]apitypes.StorageExecutor{},
+	}); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	sigc := make(chan os.Signal, 1)
+	signal.Notify(sigc, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigc
+		ctx.WithField("sock", sock).Info("stopping executor agent")
+		l.Close()
+	}()
+
+	ctx.WithField("sock", sock).Info("executor agent listening")
+	srv.Accept(l)
+	removeSocket(sock)
+}
+
+// removeSocket removes a socket file left behind by an agent that did not
+// exit cleanly, which prevents the agent from listening. An error is
+// returned rather than the file removed if the path is not a socket so that
+// a misconfigured path cannot remove a file or directory.
+func removeSocket(sock string) error {
+	fi, err := os.Lstat(sock)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if fi.Mode()&os.ModeSocket == 0 {
+		return goof.WithField("sock", sock, "refusing to remove non-socket")
+	}
+	return os.Remove(sock)
+}
@@ -25,7 +25,8 @@ type client struct {
 	xli    types.StorageExecutorCLI
 }
 
-// New returns a new libStorage client.
+// New returns a new libStorage client. The client's background activity,
+// such as its heartbeats, stops once the provided context is done.
 func New(goCtx gocontext.Context, config gofig.Config) (types.Client, error) {
 
 	if config == nil {
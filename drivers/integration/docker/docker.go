@@ -57,6 +57,10 @@ func newDriver() types.IntegrationDriver {
 func (d *driver) Init(ctx types.Context, config gofig.Config) error {
 	d.config = config
 
+	if _, err := parseMountPathTemplate(d.config); err != nil {
+		return err
+	}
+
 	ctx.WithFields(log.Fields{
 		types.ConfigIgVolOpsMountRootPath:       d.volumeRootPath(),
 		types.ConfigIgVolOpsCreateDefaultType:   d.volumeType(),
@@ -65,6 +69,7 @@ func (d *driver) Init(ctx types.Context, config gofig.Config) error {
 		types.ConfigIgVolOpsCreateDefaultAZ:     d.availabilityZone(),
 		types.ConfigIgVolOpsCreateDefaultFsType: d.fsType(),
 		types.ConfigIgVolOpsMountPath:           d.mountDirPath(),
+		types.ConfigIgVolOpsMountPathTemplate:   d.mountPathTemplate(),
 		types.ConfigIgVolOpsCreateImplicit:      d.volumeCreateImplicit(),
 	}).Info("docker integration driver successfully initialized")
 
@@ -188,7 +193,7 @@ func (d *driver) Mount(
 
 	client := context.MustClient(ctx)
 	if len(vol.Attachments) == 0 || opts.Preempt {
-		mp, err := d.getVolumeMountPath(vol.Name)
+		mp, err := d.getVolumeMountPath(ctx, vol)
 		if err != nil {
 			return "", nil, err
 		}
@@ -216,11 +221,15 @@ func (d *driver) Mount(
 				Timeout: apiconfig.DeviceAttachTimeout(d.config),
 			}
 
-			_, _, err = client.Executor().WaitForDevice(ctx, opts)
+			found, _, err := client.Executor().WaitForDevice(ctx, opts)
 			if err != nil {
 				return "", nil, goof.WithError(
 					"problem with device discovery", err)
 			}
+			if !found {
+				return "", nil, goof.WithField(
+					"token", token, "device did not appear before timeout")
+			}
 		}
 
 		vol, err = d.volumeInspectByIDOrName(
@@ -262,6 +271,7 @@ func (d *driver) Mount(
 	}
 
 	if len(mounts) > 0 {
+		d.growFs(ctx, client, ma.DeviceName, mounts[0].MountPoint, opts)
 		return d.volumeMountPath(mounts[0].MountPoint), vol, nil
 	}
 
@@ -275,14 +285,28 @@ func (d *driver) Mount(
 		&types.DeviceFormatOpts{
 			NewFSType:   opts.NewFSType,
 			OverwriteFS: opts.OverwriteFS,
+			Opts:        opts.Opts,
 		}); err != nil {
 		return "", nil, err
 	}
 
-	mountPath, err := d.getVolumeMountPath(vol.Name)
+	mountPath, err := d.getVolumeMountPath(ctx, vol)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// the device is not mounted, so any mount at the path belongs to another
+	// volume whose path the template rendered identically
+	mounts, err = client.OS().Mounts(ctx, "", mountPath, opts.Opts)
 	if err != nil {
 		return "", nil, err
 	}
+	if len(mounts) > 0 {
+		return "", nil, goof.WithFields(goof.Fields{
+			"mountPath": mountPath,
+			"device":    mounts[0].Source,
+		}, "mount path collision")
+	}
 
 	if err := os.MkdirAll(mountPath, 0755); err != nil {
 		return "", nil, err
@@ -292,10 +316,14 @@ func (d *driver) Mount(
 		ctx,
 		ma.DeviceName,
 		mountPath,
-		&types.DeviceMountOpts{}); err != nil {
+		&types.DeviceMountOpts{
+			Opts: opts.Opts,
+		}); err != nil {
 		return "", nil, err
 	}
 
+	d.growFs(ctx, client, ma.DeviceName, mountPath, opts)
+
 	mntPath := d.volumeMountPath(mountPath)
 
 	fields := log.Fields{
@@ -548,6 +576,35 @@ func (d *driver) NetworkName(
 	return "", nil
 }
 
+// growFs grows the file system of a mounted volume to fill its device, which
+// is larger than the file system once the volume has been expanded. Growing
+// the file system is best effort and does not fail the mount.
+func (d *driver) growFs(
+	ctx types.Context,
+	client types.Client,
+	deviceName, mountPoint string,
+	opts *types.VolumeMountOpts) {
+
+	if !d.growOnMount() {
+		return
+	}
+
+	if err := client.OS().Format(
+		ctx,
+		deviceName,
+		&types.DeviceFormatOpts{
+			Grow:       true,
+			MountPoint: mountPoint,
+			Opts:       opts.Opts,
+		}); err != nil {
+		ctx.WithFields(log.Fields{
+			"deviceName": deviceName,
+			"mountPoint": mountPoint,
+			"error":      err,
+		}).Warn("error growing file system")
+	}
+}
+
 func (d *driver) volumeRootPath() string {
 	return d.config.GetString(types.ConfigIgVolOpsMountRootPath)
 }
@@ -572,10 +629,18 @@ func (d *driver) fsType() string {
 	return d.config.GetString(types.ConfigIgVolOpsCreateDefaultFsType)
 }
 
+func (d *driver) growOnMount() bool {
+	return d.config.GetBool(types.ConfigIgVolOpsMountGrow)
+}
+
 func (d *driver) mountDirPath() string {
 	return d.config.GetString(types.ConfigIgVolOpsMountPath)
 }
 
+func (d *driver) mountPathTemplate() string {
+	return d.config.GetString(types.ConfigIgVolOpsMountPathTemplate)
+}
+
 func (d *driver) volumeCreateImplicit() bool {
 	return d.config.GetBool(types.ConfigIgVolOpsCreateImplicit)
 }
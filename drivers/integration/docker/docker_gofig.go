@@ -18,8 +18,10 @@ func init() {
 	r.Key(gofig.String, "", "", "", types.ConfigIgVolOpsCreateDefaultAZ)
 	r.Key(gofig.String, "", types.Lib.Join("volumes"), "",
 		types.ConfigIgVolOpsMountPath)
+	r.Key(gofig.String, "", "", "", types.ConfigIgVolOpsMountPathTemplate)
 	r.Key(gofig.String, "", "/data", "", types.ConfigIgVolOpsMountRootPath)
 	r.Key(gofig.Bool, "", true, "", types.ConfigIgVolOpsCreateImplicit)
 	r.Key(gofig.Bool, "", false, "", types.ConfigIgVolOpsMountPreempt)
+	r.Key(gofig.Bool, "", true, "", types.ConfigIgVolOpsMountGrow)
 	gofigCore.Register(r)
 }
@@ -1,22 +1,117 @@
 package docker
 
 import (
+	"bytes"
 	"fmt"
 	"path"
 	"strings"
+	"text/template"
 
+	gofig "github.com/akutz/gofig/types"
 	"github.com/akutz/goof"
+
 	"github.com/codedellemc/libstorage/api/context"
 	"github.com/codedellemc/libstorage/api/types"
 	"github.com/codedellemc/libstorage/api/utils"
 )
 
-func (d *driver) getVolumeMountPath(volumeName string) (string, error) {
-	if volumeName == "" {
+// mountPathData is the data with which a mount path template is rendered.
+type mountPathData struct {
+	MountPath  string
+	Service    string
+	VolumeName string
+	VolumeID   string
+}
+
+// parseMountPathTemplate parses the configured mount path template. A nil
+// template is returned if none is configured. A template that renders the
+// same path for different volumes is invalid since the volumes' mount paths
+// would collide.
+func parseMountPathTemplate(config gofig.Config) (*template.Template, error) {
+	text := config.GetString(types.ConfigIgVolOpsMountPathTemplate)
+	if text == "" {
+		return nil, nil
+	}
+
+	tmpl, err := template.New("mountPath").Option(
+		"missingkey=error").Parse(text)
+	if err != nil {
+		return nil, goof.WithFieldE(
+			"template", text, "invalid mount path template", err)
+	}
+
+	var paths [2]string
+	for x := range paths {
+		id := fmt.Sprintf("collision-test-%d", x)
+		if paths[x], err = renderMountPath(tmpl, &mountPathData{
+			VolumeName: id,
+			VolumeID:   id,
+		}); err != nil {
+			return nil, goof.WithFieldE(
+				"template", text, "invalid mount path template", err)
+		}
+	}
+	if paths[0] == paths[1] {
+		return nil, goof.WithField(
+			"template", text,
+			"mount path template must include the volume name or ID")
+	}
+
+	return tmpl, nil
+}
+
+func renderMountPath(
+	tmpl *template.Template, data *mountPathData) (string, error) {
+
+	buf := &bytes.Buffer{}
+	if err := tmpl.Execute(buf, data); err != nil {
+		return "", err
+	}
+	return path.Clean(buf.String()), nil
+}
+
+// getVolumeMountPath returns the path at which the volume's device is
+// mounted. The path is rendered from the mount path template of the
+// context's service, which may be defined for the individual service. If no
+// template is defined the path is the volume's name in the mount directory.
+func (d *driver) getVolumeMountPath(
+	ctx types.Context, vol *types.Volume) (string, error) {
+
+	if vol.Name == "" {
 		return "", goof.New("missing volume name")
 	}
 
-	return path.Join(d.mountDirPath(), volumeName), nil
+	config := d.config
+	serviceName, ok := context.ServiceName(ctx)
+	if ok {
+		config = config.Scope(
+			fmt.Sprintf("%s.%s", types.ConfigServices, serviceName))
+	}
+
+	mountDirPath := config.GetString(types.ConfigIgVolOpsMountPath)
+
+	tmpl, err := parseMountPathTemplate(config)
+	if err != nil {
+		return "", err
+	}
+	if tmpl == nil {
+		return path.Join(mountDirPath, vol.Name), nil
+	}
+
+	mountPath, err := renderMountPath(tmpl, &mountPathData{
+		MountPath:  mountDirPath,
+		Service:    serviceName,
+		VolumeName: vol.Name,
+		VolumeID:   vol.ID,
+	})
+	if err != nil {
+		return "", err
+	}
+	if !path.IsAbs(mountPath) {
+		return "", goof.WithField(
+			"mountPath", mountPath, "mount path must be absolute")
+	}
+	return mountPath, nil
 }
 
 func (d *driver) volumeInspectByID(
@@ -0,0 +1,91 @@
+package docker
+
+import (
+	"strings"
+	"testing"
+
+	gofigCore "github.com/akutz/gofig"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codedellemc/libstorage/api/context"
+	"github.com/codedellemc/libstorage/api/types"
+)
+
+func newTestDriver(t *testing.T, yml string) *driver {
+	config := gofigCore.New()
+	if err := config.ReadConfig(strings.NewReader(yml)); err != nil {
+		t.Fatal(err)
+	}
+	return &driver{config: config}
+}
+
+func TestParseMountPathTemplate(t *testing.T) {
+	tests := []struct {
+		tmpl string
+		err  string
+	}{
+		{"", ""},
+		{"{{.MountPath}}/{{.VolumeName}}", ""},
+		{"/mnt/{{.Service}}/{{.VolumeID}}", ""},
+		{"/mnt/{{.VolumeName", "invalid mount path template"},
+		{"/mnt/{{.Volume}}", "invalid mount path template"},
+		{"/mnt/{{.Service}}", "mount path template must include " +
+			"the volume name or ID"},
+	}
+	for _, tt := range tests {
+		config := gofigCore.New()
+		config.Set(types.ConfigIgVolOpsMountPathTemplate, tt.tmpl)
+		tmpl, err := parseMountPathTemplate(config)
+		if tt.err != "" {
+			assert.EqualError(t, err, tt.err, tt.tmpl)
+			continue
+		}
+		if assert.NoError(t, err, tt.tmpl) {
+			assert.Equal(t, tt.tmpl != "", tmpl != nil, tt.tmpl)
+		}
+	}
+}
+
+func TestGetVolumeMountPath(t *testing.T) {
+	d := newTestDriver(t, `
+libstorage:
+  integration:
+    volume:
+      operations:
+        mount:
+          path: /var/lib/libstorage/volumes
+  server:
+    services:
+      ebs:
+        libstorage:
+          integration:
+            volume:
+              operations:
+                mount:
+                  pathTemplate: "{{.MountPath}}/{{.Service}}/{{.VolumeID}}"
+      efs:
+        libstorage:
+          integration:
+            volume:
+              operations:
+                mount:
+                  pathTemplate: "mnt/{{.VolumeName}}"
+`)
+	vol := &types.Volume{ID: "vol-1", Name: "data"}
+
+	mp, err := d.getVolumeMountPath(context.Background(), vol)
+	assert.NoError(t, err)
+	assert.Equal(t, "/var/lib/libstorage/volumes/data", mp)
+
+	ctx := context.Background().WithValue(context.ServiceKey, "ebs")
+	mp, err = d.getVolumeMountPath(ctx, vol)
+	assert.NoError(t, err)
+	assert.Equal(t, "/var/lib/libstorage/volumes/ebs/vol-1", mp)
+
+	ctx = context.Background().WithValue(context.ServiceKey, "efs")
+	_, err = d.getVolumeMountPath(ctx, vol)
+	assert.EqualError(t, err, "mount path must be absolute")
+
+	_, err = d.getVolumeMountPath(ctx, &types.Volume{ID: "vol-1"})
+	assert.EqualError(t, err, "missing volume name")
+}
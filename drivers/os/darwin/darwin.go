@@ -3,7 +3,11 @@
 package darwin
 
 import (
+	"os/exec"
 	"runtime"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
 
 	gofig "github.com/akutz/gofig/types"
 	"github.com/akutz/goof"
@@ -49,7 +53,28 @@ func (d *driver) Mounts(
 	deviceName, mountPoint string,
 	opts types.Store) ([]*types.MountInfo, error) {
 
-	return nil, nil
+	mounts, err := getMounts()
+	if err != nil {
+		return nil, err
+	}
+
+	if mountPoint == "" && deviceName == "" {
+		return mounts, nil
+	} else if mountPoint != "" && deviceName != "" {
+		return nil, goof.New("cannot specify mountPoint and deviceName")
+	}
+
+	if deviceName != "" {
+		deviceName = mountSource(deviceName)
+	}
+
+	matchedMounts := []*types.MountInfo{}
+	for _, m := range mounts {
+		if m.MountPoint == mountPoint || m.Source == deviceName {
+			matchedMounts = append(matchedMounts, m)
+		}
+	}
+	return matchedMounts, nil
 }
 
 func (d *driver) Mount(
@@ -57,6 +82,53 @@ func (d *driver) Mount(
 	deviceName, mountPoint string,
 	opts *types.DeviceMountOpts) error {
 
+	if mounted, err := d.IsMounted(ctx, mountPoint, opts.Opts); err != nil {
+		return err
+	} else if mounted {
+		ctx.WithFields(log.Fields{
+			"deviceName": deviceName,
+			"mountPoint": mountPoint,
+		}).Info("mount point already mounted")
+		return nil
+	}
+
+	var args []string
+	switch {
+	case isSMBDevice(deviceName):
+		args = []string{"mount_smbfs"}
+	case isNFSDevice(deviceName):
+		args = []string{"mount", "-t", "nfs"}
+	default:
+		// other devices, such as block devices, are not mounted by the
+		// darwin driver, which has always treated them as a no-op
+		ctx.WithField("deviceName", deviceName).Debug(
+			"not mounting unsupported device")
+		return nil
+	}
+
+	var options []string
+	if opts.MountOptions != "" {
+		options = append(options, opts.MountOptions)
+	}
+	if opts.ReadOnly {
+		options = append(options, "rdonly")
+	}
+	if len(options) > 0 {
+		args = append(args, "-o", strings.Join(options, ","))
+	}
+	args = append(args, mountSource(deviceName), mountPoint)
+
+	ctx.WithField("args", args).Debug("mounting device")
+
+	out, err := exec.Command(args[0], args[1:]...).CombinedOutput()
+	if err != nil {
+		return goof.WithFieldsE(goof.Fields{
+			"deviceName": deviceName,
+			"mountPoint": mountPoint,
+			"output":     string(out),
+		}, "error mounting device", err)
+	}
+
 	return nil
 }
 
@@ -65,6 +137,26 @@ func (d *driver) Unmount(
 	mountPoint string,
 	opts types.Store) error {
 
+	if mounted, err := d.IsMounted(ctx, mountPoint, opts); err != nil {
+		return err
+	} else if !mounted {
+		return nil
+	}
+
+	args := []string{"umount"}
+	if opts != nil && opts.GetBool("force") {
+		args = append(args, "-f")
+	}
+	args = append(args, mountPoint)
+
+	out, err := exec.Command(args[0], args[1:]...).CombinedOutput()
+	if err != nil {
+		return goof.WithFieldsE(goof.Fields{
+			"mountPoint": mountPoint,
+			"output":     string(out),
+		}, "error unmounting", err)
+	}
+
 	return nil
 }
 
@@ -73,9 +165,21 @@ func (d *driver) IsMounted(
 	mountPoint string,
 	opts types.Store) (bool, error) {
 
+	mounts, err := getMounts()
+	if err != nil {
+		return false, err
+	}
+	for _, m := range mounts {
+		if m.MountPoint == mountPoint {
+			return true, nil
+		}
+	}
 	return false, nil
 }
 
+// Format is a no-op as the darwin driver only mounts network file systems,
+// which are formatted by the server that exports them, and does not format
+// other devices.
 func (d *driver) Format(
 	ctx types.Context,
 	deviceName string,
@@ -0,0 +1,80 @@
+// +build darwin
+
+package darwin
+
+import (
+	"bufio"
+	"bytes"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"github.com/akutz/goof"
+
+	"github.com/codedellemc/libstorage/api/types"
+)
+
+// mountRX matches a line of the output of the mount command, for example:
+//
+//	/dev/disk1s1 on / (apfs, local, journaled)
+//	server:/export on /Volumes/export (nfs, nodev, nosuid, mounted by user)
+//	//user@server/share on /Volumes/share (smbfs, nodev, nosuid)
+var mountRX = regexp.MustCompile(`^(.+) on (.+) \(([^,)]+)(?:, ([^)]*))?\)$`)
+
+// getMounts returns the mounts reported by the mount command.
+func getMounts() ([]*types.MountInfo, error) {
+	out, err := exec.Command("mount").Output()
+	if err != nil {
+		return nil, goof.WithError("error listing mounts", err)
+	}
+	return parseMountOutput(out)
+}
+
+// parseMountOutput parses the output of the mount command.
+func parseMountOutput(out []byte) ([]*types.MountInfo, error) {
+	mounts := []*types.MountInfo{}
+	s := bufio.NewScanner(bytes.NewReader(out))
+	for s.Scan() {
+		text := s.Text()
+		if text == "" {
+			continue
+		}
+		m := mountRX.FindStringSubmatch(text)
+		if m == nil {
+			return nil, goof.WithField("line", text, "error parsing mount")
+		}
+		mounts = append(mounts, &types.MountInfo{
+			Source:     m[1],
+			MountPoint: m[2],
+			FSType:     m[3],
+			Opts:       strings.Replace(m[4], ", ", ",", -1),
+		})
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return mounts, nil
+}
+
+// isSMBDevice returns a flag indicating whether or not the device is an SMB
+// share formatted as //[user@]server/share or smb://[user@]server/share.
+func isSMBDevice(deviceName string) bool {
+	return strings.HasPrefix(deviceName, "//") ||
+		strings.HasPrefix(deviceName, "smb://")
+}
+
+// isNFSDevice returns a flag indicating whether or not the device is an NFS
+// export formatted as server:/export.
+func isNFSDevice(deviceName string) bool {
+	return strings.Contains(deviceName, ":") &&
+		!strings.Contains(deviceName, "://")
+}
+
+// mountSource returns the source with which the specified device appears in
+// the mount table once mounted.
+func mountSource(deviceName string) string {
+	if strings.HasPrefix(deviceName, "smb://") {
+		return "//" + strings.TrimPrefix(deviceName, "smb://")
+	}
+	return deviceName
+}
@@ -3,10 +3,8 @@
 package linux
 
 import (
-	"bytes"
 	"fmt"
 	"os"
-	"os/exec"
 	"runtime"
 	"strings"
 
@@ -17,6 +15,7 @@ import (
 
 	"github.com/codedellemc/libstorage/api/registry"
 	"github.com/codedellemc/libstorage/api/types"
+	"github.com/codedellemc/libstorage/api/utils"
 )
 
 const driverName = "linux"
@@ -61,17 +60,37 @@ func (d *driver) Mounts(
 		return nil, err
 	}
 
+	if prefix := mountPointPrefix(opts); prefix != "" {
+		mounts = filterMountPointPrefix(mounts, prefix)
+	}
+
 	if mountPoint == "" && deviceName == "" {
 		return mounts, nil
 	} else if mountPoint != "" && deviceName != "" {
 		return nil, goof.New("cannot specify mountPoint and deviceName")
 	}
 
+	var matcher mountMatcher
+	if deviceName != "" {
+		matcher = getMountMatcher(deviceName)
+	}
+	source := getMountSource(deviceName)
+
 	matchedMounts := []*types.MountInfo{}
 	for _, m := range mounts {
-		if m.MountPoint == mountPoint || m.Source == deviceName {
-			matchedMounts = append(matchedMounts, m)
+		switch {
+		case mountPoint != "":
+			if m.MountPoint != mountPoint {
+				continue
+			}
+		case matcher != nil:
+			if !matcher.MatchesMount(deviceName, m) {
+				continue
+			}
+		case m.Source != source:
+			continue
 		}
+		matchedMounts = append(matchedMounts, m)
 	}
 	return matchedMounts, nil
 }
@@ -81,16 +100,76 @@ func (d *driver) Mount(
 	deviceName, mountPoint string,
 	opts *types.DeviceMountOpts) error {
 
-	if d.isNfsDevice(deviceName) {
+	if !opts.Bind && encrypted(opts.Opts) {
+		mappedDevice, err := d.cryptOpen(ctx, deviceName, opts.Opts)
+		if err != nil {
+			return err
+		}
+		deviceName = mappedDevice
+	}
+
+	var (
+		mounted bool
+		err     error
+	)
+	if opts.Bind {
+		mounted, err = isBindMountedAt(deviceName, mountPoint)
+	} else {
+		mounted, err = isMountedAt(deviceName, mountPoint)
+	}
+	if err != nil {
+		return err
+	}
 
-		if err := d.nfsMount(deviceName, mountPoint); err != nil {
+	if mounted {
+		if opts.Opts != nil && opts.Opts.GetBool("failIfMounted") {
+			return utils.NewAlreadyMountedError(deviceName, mountPoint)
+		}
+		ctx.WithFields(log.Fields{
+			"deviceName": deviceName,
+			"mountPoint": mountPoint,
+		}).Info("device already mounted")
+	} else {
+		err := d.prepareMountPoint(ctx, deviceName, mountPoint, opts)
+		if err != nil {
+			return err
+		}
+		if err := d.mount(ctx, deviceName, mountPoint, opts); err != nil {
 			return err
 		}
+	}
 
-		os.MkdirAll(d.volumeMountPath(mountPoint), d.fileModeMountPath())
-		os.Chmod(d.volumeMountPath(mountPoint), d.fileModeMountPath())
+	err = d.volumePerms(opts.Opts).apply(ctx, d.volumeMountPath(mountPoint))
+	if err != nil && !mounted {
+		// do not leave behind a mount the caller believes failed
+		if uerr := d.Unmount(ctx, mountPoint, nil); uerr != nil {
+			ctx.WithFields(log.Fields{
+				"mountPoint": mountPoint,
+				"error":      uerr,
+			}).Error("error unmounting after failed mount")
+		}
+	}
+	return err
+}
 
-		return nil
+func (d *driver) mount(
+	ctx types.Context,
+	deviceName, mountPoint string,
+	opts *types.DeviceMountOpts) error {
+
+	if opts.Bind {
+		return bindMount(deviceName, mountPoint, opts.ReadOnly)
+	}
+
+	if h := getMountHandler(deviceName); h != nil {
+
+		ctx.WithFields(log.Fields{
+			"deviceName":   deviceName,
+			"mountPoint":   mountPoint,
+			"mountHandler": h.Name(),
+		}).Debug("mounting device with mount handler")
+
+		return h.Mount(ctx, d.config, deviceName, mountPoint, opts)
 	}
 
 	fsType, err := probeFsType(deviceName)
@@ -98,21 +177,26 @@ func (d *driver) Mount(
 		return err
 	}
 
-	options := formatMountLabel("", opts.MountLabel)
-	options = fmt.Sprintf("%s,%s", opts.MountOptions, opts.MountLabel)
-	if fsType == "xfs" {
-		options = fmt.Sprintf("%s,nouuid", opts.MountLabel)
+	if err := d.fsck(ctx, deviceName, fsType, opts.Opts); err != nil {
+		return err
 	}
 
-	if err := mount(deviceName, mountPoint, fsType, options); err != nil {
-		return goof.WithFieldsE(goof.Fields{
-			"deviceName": deviceName,
-			"mountPoint": mountPoint,
-		}, "error mounting directory", err)
+	var options []string
+	if opts.MountOptions != "" {
+		options = append(options, opts.MountOptions)
 	}
+	if fsType == "xfs" {
+		options = append(options, "nouuid")
+	}
+	if opts.ReadOnly {
+		options = append(options, "ro")
+	}
+	mountOptions := mountLabelOptions(
+		d.config, strings.Join(options, ","), opts.MountLabel)
 
-	os.MkdirAll(d.volumeMountPath(mountPoint), d.fileModeMountPath())
-	os.Chmod(d.volumeMountPath(mountPoint), d.fileModeMountPath())
+	if err := mount(deviceName, mountPoint, fsType, mountOptions); err != nil {
+		return mountError(deviceName, mountPoint, fsType, err)
+	}
 
 	return nil
 }
@@ -122,7 +206,30 @@ func (d *driver) Unmount(
 	mountPoint string,
 	opts types.Store) error {
 
-	return unmount(mountPoint)
+	h, err := getMountHandlerForMountPoint(mountPoint)
+	if err != nil {
+		return err
+	}
+	if h != nil {
+		return h.Unmount(ctx, d.config, mountPoint, opts)
+	}
+
+	mounts, err := d.Mounts(ctx, "", mountPoint, opts)
+	if err != nil {
+		return err
+	}
+
+	if err := unmountWithOpts(mountPoint, opts); err != nil {
+		return err
+	}
+
+	for _, m := range mounts {
+		if err := cryptClose(ctx, m.Source); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 func (d *driver) IsMounted(
@@ -138,6 +245,20 @@ func (d *driver) Format(
 	deviceName string,
 	opts *types.DeviceFormatOpts) error {
 
+	// devices mounted by a mount handler, such as network shares, have no
+	// file system to create
+	if getMountHandler(deviceName) != nil {
+		return nil
+	}
+
+	if encrypted(opts.Opts) {
+		mappedDevice, err := d.cryptOpen(ctx, deviceName, opts.Opts)
+		if err != nil {
+			return err
+		}
+		deviceName = mappedDevice
+	}
+
 	fsType, err := probeFsType(deviceName)
 	if err != nil && err != errUnknownFileSystem {
 		return err
@@ -149,43 +270,19 @@ func (d *driver) Format(
 		"fsType":      fsType,
 		"deviceName":  deviceName,
 		"overwriteFs": opts.OverwriteFS,
+		"grow":        opts.Grow,
+		"mountPoint":  opts.MountPoint,
 		"driverName":  driverName}).Info("probe information")
 
 	if opts.OverwriteFS || !fsDetected {
-		switch opts.NewFSType {
-		case "ext4":
-			if err := exec.Command(
-				"mkfs.ext4", "-F", deviceName).Run(); err != nil {
-				return goof.WithFieldE(
-					"deviceName", deviceName,
-					"error creating filesystem",
-					err)
-			}
-		case "xfs":
-			if err := exec.Command(
-				"mkfs.xfs", "-f", deviceName).Run(); err != nil {
-				return goof.WithFieldE(
-					"deviceName", deviceName,
-					"error creating filesystem",
-					err)
-			}
-		default:
-			return errUnsupportedFileSystem
+		if err := d.mkfs(ctx, deviceName, opts); err != nil {
+			return err
 		}
+		return nil
 	}
 
-	return nil
-}
-
-func (d *driver) isNfsDevice(device string) bool {
-	return strings.Contains(device, ":")
-}
-
-func (d *driver) nfsMount(device, target string) error {
-	command := exec.Command("mount", device, target)
-	output, err := command.CombinedOutput()
-	if err != nil {
-		return goof.WithError(fmt.Sprintf("failed mounting: %s", output), err)
+	if opts.Grow {
+		return d.growFs(ctx, deviceName, fsType, opts.MountPoint)
 	}
 
 	return nil
@@ -195,57 +292,6 @@ func (d *driver) fileModeMountPath() (fileMode os.FileMode) {
 	return os.FileMode(d.volumeFileMode())
 }
 
-// from github.com/docker/docker/daemon/graphdriver/devmapper/
-// this should be abstracted outside of graphdriver but within Docker package,
-// here temporarily
-type probeData struct {
-	fsName string
-	magic  string
-	offset uint64
-}
-
-func probeFsType(device string) (string, error) {
-	probes := []probeData{
-		{"btrfs", "_BHRfS_M", 0x10040},
-		{"ext4", "\123\357", 0x438},
-		{"xfs", "XFSB", 0},
-	}
-
-	maxLen := uint64(0)
-	for _, p := range probes {
-		l := p.offset + uint64(len(p.magic))
-		if l > maxLen {
-			maxLen = l
-		}
-	}
-
-	file, err := os.Open(device)
-	if err != nil {
-		return "", err
-	}
-	defer file.Close()
-
-	buffer := make([]byte, maxLen)
-	l, err := file.Read(buffer)
-	if err != nil {
-		return "", err
-	}
-
-	if uint64(l) != maxLen {
-		return "", goof.WithField(
-			"device", device, "error detecting filesystem")
-	}
-
-	for _, p := range probes {
-		if bytes.Equal(
-			[]byte(p.magic), buffer[p.offset:p.offset+uint64(len(p.magic))]) {
-			return p.fsName, nil
-		}
-	}
-
-	return "", errUnknownFileSystem
-}
-
 func (d *driver) volumeMountPath(target string) string {
 	return fmt.Sprintf("%s%s", target, d.volumeRootPath())
 }
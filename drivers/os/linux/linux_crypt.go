@@ -0,0 +1,136 @@
+// +build linux
+
+package linux
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/akutz/goof"
+
+	"github.com/codedellemc/libstorage/api/types"
+)
+
+const (
+	// cryptMapperDir is the directory in which device-mapper devices appear.
+	cryptMapperDir = "/dev/mapper"
+
+	// cryptMapperPrefix is the prefix of the names of the device-mapper
+	// devices created for encrypted volumes.
+	cryptMapperPrefix = "libstorage-"
+)
+
+// encrypted returns a flag indicating whether or not the options request that
+// the device be encrypted.
+func encrypted(opts types.Store) bool {
+	return opts != nil && opts.GetBool("encrypt")
+}
+
+// cryptKey returns the key used to encrypt a device. A per-volume key provided
+// with the encryptionKey option takes precedence over the contents of the file
+// specified by linux.encryption.keyFile.
+func (d *driver) cryptKey(opts types.Store) ([]byte, error) {
+	if opts != nil {
+		if key := opts.GetString("encryptionKey"); key != "" {
+			return []byte(key), nil
+		}
+	}
+	keyFile := d.config.GetString("linux.encryption.keyFile")
+	if keyFile == "" {
+		return nil, goof.New("no encryption key")
+	}
+	key, err := ioutil.ReadFile(keyFile)
+	if err != nil {
+		return nil, goof.WithFieldE(
+			"keyFile", keyFile, "error reading encryption key", err)
+	}
+	return key, nil
+}
+
+// cryptOpen opens the LUKS volume on the specified device and returns the path
+// of the mapped device. A device that does not contain a file system is first
+// formatted as a LUKS volume, but a device that contains an unencrypted file
+// system is never overwritten.
+func (d *driver) cryptOpen(
+	ctx types.Context,
+	deviceName string,
+	opts types.Store) (string, error) {
+
+	name := cryptMapperPrefix + filepath.Base(deviceName)
+	mappedDevice := path.Join(cryptMapperDir, name)
+
+	if _, err := os.Stat(mappedDevice); err == nil {
+		return mappedDevice, nil
+	}
+
+	key, err := d.cryptKey(opts)
+	if err != nil {
+		return "", err
+	}
+
+	fsType, err := probeFsType(deviceName)
+	if err == errUnknownFileSystem {
+		ctx.WithField("deviceName", deviceName).Info(
+			"formatting device as luks volume")
+		if err := cryptsetup(
+			key, "luksFormat", "--batch-mode", "--key-file", "-",
+			deviceName); err != nil {
+			return "", err
+		}
+	} else if err != nil {
+		if _, ok := err.(*types.ErrEncryptedDevice); !ok {
+			return "", err
+		}
+	} else {
+		return "", goof.WithFields(goof.Fields{
+			"deviceName": deviceName,
+			"fsType":     fsType,
+		}, "device contains an unencrypted file system")
+	}
+
+	ctx.WithFields(log.Fields{
+		"deviceName":   deviceName,
+		"mappedDevice": mappedDevice,
+	}).Info("opening luks volume")
+
+	if err := cryptsetup(
+		key, "luksOpen", "--key-file", "-", deviceName, name); err != nil {
+		return "", err
+	}
+
+	return mappedDevice, nil
+}
+
+// cryptClose closes the mapping for the specified device if it is a mapped
+// device opened by cryptOpen; otherwise it does nothing.
+func cryptClose(ctx types.Context, deviceName string) error {
+	if !strings.HasPrefix(
+		deviceName, path.Join(cryptMapperDir, cryptMapperPrefix)) {
+		return nil
+	}
+	ctx.WithField("mappedDevice", deviceName).Info("closing luks volume")
+	return cryptsetup(nil, "luksClose", filepath.Base(deviceName))
+}
+
+// cryptsetup executes cryptsetup with the specified arguments. The key, if
+// any, is provided to cryptsetup on its standard input.
+func cryptsetup(key []byte, args ...string) error {
+	cmd := exec.Command("cryptsetup", args...)
+	if key != nil {
+		cmd.Stdin = bytes.NewReader(key)
+	}
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return goof.WithFieldsE(goof.Fields{
+			"command": args[0],
+			"output":  string(out),
+		}, "error executing cryptsetup", err)
+	}
+	return nil
+}
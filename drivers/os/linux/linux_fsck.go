@@ -0,0 +1,114 @@
+// +build linux
+
+package linux
+
+import (
+	"os/exec"
+	"syscall"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/akutz/goof"
+
+	"github.com/codedellemc/libstorage/api/types"
+)
+
+const (
+	// fsckPolicyAuto checks ext file systems, which skip the check when
+	// they were cleanly unmounted, and relies upon log recovery for xfs.
+	fsckPolicyAuto = "auto"
+
+	// fsckPolicyAlways forces a check of every supported file system.
+	fsckPolicyAlways = "always"
+
+	// fsckPolicyNever disables checking file systems.
+	fsckPolicyNever = "never"
+
+	// e2fsckUncorrected is the lowest e2fsck exit status indicating errors
+	// were left uncorrected or the check could not be completed.
+	e2fsckUncorrected = 4
+)
+
+// fsckPolicy returns the file system check policy for a mount. The fsck
+// option overrides the configured linux.fsck.policy.
+func (d *driver) fsckPolicy(opts types.Store) string {
+	if opts != nil {
+		if v := opts.GetString("fsck"); v != "" {
+			return v
+		}
+	}
+	if v := d.config.GetString("linux.fsck.policy"); v != "" {
+		return v
+	}
+	return fsckPolicyAuto
+}
+
+// fsck checks the file system on the specified device prior to it being
+// mounted. An error is returned if the file system has errors that could not
+// be corrected, in which case the device should not be mounted.
+func (d *driver) fsck(
+	ctx types.Context,
+	deviceName, fsType string,
+	opts types.Store) error {
+
+	policy := d.fsckPolicy(opts)
+
+	var args []string
+	switch policy {
+	case fsckPolicyNever:
+		return nil
+	case fsckPolicyAuto, fsckPolicyAlways:
+	default:
+		return goof.WithField("policy", policy, "invalid fsck policy")
+	}
+
+	switch fsType {
+	case "ext2", "ext3", "ext4":
+		args = []string{"e2fsck", "-p"}
+		if policy == fsckPolicyAlways {
+			args = append(args, "-f")
+		}
+	case "xfs":
+		if policy != fsckPolicyAlways {
+			return nil
+		}
+		args = []string{"xfs_repair", "-n"}
+	default:
+		return nil
+	}
+	args = append(args, deviceName)
+
+	out, err := exec.Command(args[0], args[1:]...).CombinedOutput()
+	status := exitStatus(err)
+
+	fields := log.Fields{
+		"deviceName": deviceName,
+		"fsType":     fsType,
+		"policy":     policy,
+		"args":       args,
+		"status":     status,
+		"output":     string(out),
+	}
+
+	if err != nil && (status < 0 || fsType == "xfs" ||
+		status >= e2fsckUncorrected) {
+		return goof.WithFieldsE(
+			fields, "file system check failed", err)
+	}
+
+	ctx.WithFields(fields).Info("checked file system")
+	return nil
+}
+
+// exitStatus returns the exit status of the command that returned the
+// specified error, 0 if the error is nil, or -1 if the status is unknown.
+func exitStatus(err error) int {
+	if err == nil {
+		return 0
+	}
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		if ws, ok := exitErr.Sys().(syscall.WaitStatus); ok {
+			return ws.ExitStatus()
+		}
+	}
+	return -1
+}
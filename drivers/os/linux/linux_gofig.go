@@ -11,5 +11,88 @@ func init() {
 	r := gofigCore.NewRegistration("Linux")
 	r.Key(gofig.Int, "", 0700, "", "linux.volume.filemode")
 	r.Key(gofig.String, "", "/data", "", "linux.volume.rootpath")
+	r.Key(gofig.Int, "", -1,
+		"Owner of the volume root path", "linux.volume.uid")
+	r.Key(gofig.Int, "", -1,
+		"Group of the volume root path", "linux.volume.gid")
+	r.Key(gofig.Bool, "", false,
+		"Apply volume ownership recursively", "linux.volume.recursive")
+	r.Key(gofig.Int, "", 0755,
+		"File mode of created mount points", "linux.volume.mountPointMode")
+	r.Key(gofig.String, "", "",
+		"Paths beneath which devices may be mounted; any path if empty",
+		"linux.volume.allowedMountRoots")
+	r.Key(gofig.String, "", "",
+		"Comma separated default NFS mount options", "linux.nfs.defaultOptions")
+	r.Key(gofig.String, "", "",
+		"Default NFS security flavor, ex. krb5", "linux.nfs.sec")
+	r.Key(gofig.Bool, "", true,
+		"Require rpc.gssd for Kerberos NFS mounts", "linux.nfs.gssdCheck")
+	r.Key(gofig.String, "", "",
+		"Keytab used to obtain Kerberos credentials for NFS mounts",
+		"linux.nfs.krb5Keytab")
+	r.Key(gofig.String, "", "",
+		"Principal used to obtain Kerberos credentials for NFS mounts",
+		"linux.nfs.krb5Principal")
+	r.Key(gofig.String, "", "",
+		"Kerberos credential cache used for NFS mounts",
+		"linux.nfs.krb5CCache")
+	r.Key(gofig.String, "", "",
+		"Expected NFSv4 ID mapping domain", "linux.nfs.idmapDomain")
+	r.Key(gofig.Bool, "", false,
+		"Fail EFS mounts if the EFS mount helper is not installed",
+		"linux.efs.requireTLS")
+	r.Key(gofig.String, "", "",
+		"GlusterFS client log file", "linux.glusterfs.logFile")
+	r.Key(gofig.String, "", "",
+		"Comma separated default CIFS mount options",
+		"linux.cifs.defaultOptions")
+	r.Key(gofig.String, "", "",
+		"File containing the CIFS credentials", "linux.cifs.credentialsFile")
+	r.Key(gofig.String, "", "admin",
+		"CephFS user as which paths are mounted", "linux.cephfs.user")
+	r.Key(gofig.String, "", "",
+		"File containing the CephFS user's secret", "linux.cephfs.secretFile")
+	r.Key(gofig.String, "", "allow_other",
+		"Comma separated default object store mount options",
+		"linux.objectstore.defaultOptions")
+	r.Key(gofig.String, "", "",
+		"File containing the s3fs credentials",
+		"linux.objectstore.passwdFile")
+	r.Key(gofig.String, "", "",
+		"IAM role with which s3fs authenticates", "linux.objectstore.iamRole")
+	r.Key(gofig.String, "", "",
+		"Shared credentials profile with which goofys authenticates",
+		"linux.objectstore.profile")
+	r.Key(gofig.String, "", "2m",
+		"Maximum duration of a mount command", "linux.mount.timeout")
+	r.Key(gofig.String, "", "",
+		"Maximum duration of an NFS mount command",
+		"linux.nfs.mountTimeout")
+	r.Key(gofig.String, "", "",
+		"Maximum duration of an EFS mount command",
+		"linux.efs.mountTimeout")
+	r.Key(gofig.String, "", "",
+		"Maximum duration of a GlusterFS mount command",
+		"linux.glusterfs.mountTimeout")
+	r.Key(gofig.String, "", "",
+		"Maximum duration of a CephFS mount command",
+		"linux.cephfs.mountTimeout")
+	r.Key(gofig.String, "", "",
+		"Maximum duration of a CIFS mount command",
+		"linux.cifs.mountTimeout")
+	r.Key(gofig.String, "", "",
+		"Maximum duration of an object store mount command",
+		"linux.objectstore.mountTimeout")
+	r.Key(gofig.Bool, "", true,
+		"Apply SELinux mount labels", "linux.selinux.enabled")
+	r.Key(gofig.String, "", "context",
+		"SELinux mount label option", "linux.selinux.contextOption")
+	r.Key(gofig.String, "", "",
+		"File containing the volume encryption key",
+		"linux.encryption.keyFile")
+	r.Key(gofig.String, "", "auto",
+		"File system check policy: auto, always, or never",
+		"linux.fsck.policy")
 	gofigCore.Register(r)
 }
@@ -4,8 +4,19 @@ package linux
 
 import (
 	"fmt"
+
+	gofig "github.com/akutz/gofig/types"
 )
 
+// selinuxContextOptions are the mount options that may be used to apply an
+// SELinux label to a mounted file system.
+var selinuxContextOptions = map[string]bool{
+	"context":     true,
+	"fscontext":   true,
+	"defcontext":  true,
+	"rootcontext": true,
+}
+
 /*
 formatMountLabel returns a string to be used by the mount command.
 The format of this string will be used to alter the labeling of the mountpoint.
@@ -13,17 +24,50 @@ The string returned is suitable to be used as the options field of the mount
 command.
 
 If you need to have additional mount point options, you can pass them in as
-the first parameter.  Second parameter is the label that you wish to apply
-to all content in the mount point.
+the first parameter. The second parameter is the name of the context option,
+such as "context" or "fscontext", and the third parameter is the label that
+you wish to apply to all content in the mount point.
 */
-func formatMountLabel(src, mountLabel string) string {
+func formatMountLabel(src, contextOption, mountLabel string) string {
 	if mountLabel != "" {
 		switch src {
 		case "":
-			src = fmt.Sprintf("context=%q", mountLabel)
+			src = fmt.Sprintf("%s=%q", contextOption, mountLabel)
 		default:
-			src = fmt.Sprintf("%s,context=%q", src, mountLabel)
+			src = fmt.Sprintf("%s,%s=%q", src, contextOption, mountLabel)
 		}
 	}
 	return src
 }
+
+// mountLabelOptions returns the mount options with the SELinux context option
+// for the label appended. The options are returned unchanged if no label is
+// specified, if labeling is disabled with linux.selinux.enabled, or if SELinux
+// is not enabled on the host.
+func mountLabelOptions(config gofig.Config, options, mountLabel string) string {
+	if mountLabel == "" ||
+		!config.GetBool("linux.selinux.enabled") ||
+		!selinuxEnabled() {
+		return options
+	}
+	contextOption := config.GetString("linux.selinux.contextOption")
+	if !selinuxContextOptions[contextOption] {
+		contextOption = "context"
+	}
+	return formatMountLabel(options, contextOption, mountLabel)
+}
+
+// selinuxEnabled returns a flag indicating whether or not SELinux is enabled
+// on the host, as indicated by the presence of a mounted selinuxfs.
+func selinuxEnabled() bool {
+	mounts, err := getMounts()
+	if err != nil {
+		return false
+	}
+	for _, m := range mounts {
+		if m.FSType == "selinuxfs" {
+			return true
+		}
+	}
+	return false
+}
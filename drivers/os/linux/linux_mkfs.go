@@ -0,0 +1,133 @@
+// +build linux
+
+package linux
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+	"text/template"
+
+	gofig "github.com/akutz/gofig/types"
+	"github.com/akutz/goof"
+
+	"github.com/codedellemc/libstorage/api/types"
+)
+
+// mkfsCommand describes how to create a file system of a specific type.
+type mkfsCommand struct {
+	// name is the name of the executable.
+	name string
+
+	// force is the flag that forces the creation of the file system even if
+	// the device already contains one.
+	force string
+
+	// label is the format string for the flag(s) that specify the file
+	// system label.
+	label string
+
+	// uuid is the format string for the flag(s) that specify the file
+	// system UUID.
+	uuid string
+}
+
+var mkfsCommands = map[string]*mkfsCommand{
+	"ext3":  {"mkfs.ext3", "-F", "-L %s", "-U %s"},
+	"ext4":  {"mkfs.ext4", "-F", "-L %s", "-U %s"},
+	"xfs":   {"mkfs.xfs", "-f", "-L %s", "-m uuid=%s"},
+	"btrfs": {"mkfs.btrfs", "-f", "-L %s", "-U %s"},
+}
+
+// mkfsTemplateData is the data available to a file system's configured mkfs
+// command template.
+type mkfsTemplateData struct {
+	DeviceName string
+	FSType     string
+	Label      string
+	UUID       string
+}
+
+// mkfsArgs returns the command and arguments used to create a file system of
+// the specified type on the device.
+//
+// If the property linux.format.<fsType>.command is set it is parsed as a Go
+// template, executed with a mkfsTemplateData object, and split on whitespace
+// to produce the command and its arguments. Otherwise one of the built-in
+// commands is used.
+func mkfsArgs(
+	config gofig.Config,
+	deviceName, fsType, label, uuid string) ([]string, error) {
+
+	if text := config.GetString(
+		fmt.Sprintf("linux.format.%s.command", fsType)); text != "" {
+
+		tpl, err := template.New(fsType).Parse(text)
+		if err != nil {
+			return nil, goof.WithFieldE(
+				"fsType", fsType, "error parsing mkfs command template", err)
+		}
+		buf := &bytes.Buffer{}
+		if err := tpl.Execute(buf, &mkfsTemplateData{
+			DeviceName: deviceName,
+			FSType:     fsType,
+			Label:      label,
+			UUID:       uuid,
+		}); err != nil {
+			return nil, goof.WithFieldE(
+				"fsType", fsType, "error executing mkfs command template", err)
+		}
+		args := strings.Fields(buf.String())
+		if len(args) == 0 {
+			return nil, goof.WithField(
+				"fsType", fsType, "empty mkfs command template")
+		}
+		return args, nil
+	}
+
+	cmd, ok := mkfsCommands[fsType]
+	if !ok {
+		return nil, errUnsupportedFileSystem
+	}
+
+	args := []string{cmd.name, cmd.force}
+	if label != "" {
+		args = append(args, strings.Fields(fmt.Sprintf(cmd.label, label))...)
+	}
+	if uuid != "" {
+		args = append(args, strings.Fields(fmt.Sprintf(cmd.uuid, uuid))...)
+	}
+	return append(args, deviceName), nil
+}
+
+// mkfs creates a file system on the specified device.
+func (d *driver) mkfs(
+	ctx types.Context,
+	deviceName string,
+	opts *types.DeviceFormatOpts) error {
+
+	var label, uuid string
+	if opts.Opts != nil {
+		label = opts.Opts.GetString("label")
+		uuid = opts.Opts.GetString("uuid")
+	}
+
+	args, err := mkfsArgs(d.config, deviceName, opts.NewFSType, label, uuid)
+	if err != nil {
+		return err
+	}
+
+	ctx.WithField("args", args).Debug("creating filesystem")
+
+	out, err := exec.Command(args[0], args[1:]...).CombinedOutput()
+	if err != nil {
+		return goof.WithFieldsE(goof.Fields{
+			"deviceName": deviceName,
+			"fsType":     opts.NewFSType,
+			"output":     string(out),
+		}, "error creating filesystem", err)
+	}
+
+	return nil
+}
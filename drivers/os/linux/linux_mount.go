@@ -11,6 +11,8 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/akutz/goof"
+
 	"github.com/codedellemc/libstorage/api/types"
 )
 
@@ -265,13 +267,13 @@ func parseTmpfsOptions(options string) (int, string, error) {
 
 // getMounts retrieves a list of mounts for the current running process.
 func getMounts() ([]*types.MountInfo, error) {
-	return parseMountTable()
+	return mountTable.get()
 }
 
 // Mounted looks at /proc/self/mountinfo to determine of the specified
 // mountpoint has been mounted
 func mounted(mountpoint string) (bool, error) {
-	entries, err := parseMountTable()
+	entries, err := getMounts()
 	if err != nil {
 		return false, err
 	}
@@ -330,27 +332,54 @@ func forceMount(device, target, mType, options string) error {
 	return nil
 }
 
-// unmount will unmount the target filesystem, so long as it is mounted.
-func unmount(target string) error {
-	if mounted, err := mounted(target); err != nil || !mounted {
-		return err
-	}
-	return forceUnmount(target)
-}
-
 func sysUnmount(target string, flag int) error {
 	return syscall.Unmount(target, flag)
 }
 
 // forceUnmount will force an unmount of the target filesystem, regardless if
 // it is mounted or not.
-func forceUnmount(target string) (err error) {
+func forceUnmount(target string, flag int) (err error) {
 	// Simple retry logic for unmount
 	for i := 0; i < 10; i++ {
-		if err = sysUnmount(target, 0); err == nil {
+		if err = sysUnmount(target, flag); err == nil {
 			return nil
 		}
 		time.Sleep(100 * time.Millisecond)
 	}
 	return
 }
+
+// mountErrors maps the errors returned by mount(2) to descriptions of their
+// most likely cause when mounting a block device.
+var mountErrors = map[syscall.Errno]string{
+	syscall.EACCES:  "device is write-protected",
+	syscall.EBUSY:   "device already mounted or mount point busy",
+	syscall.EINVAL:  "invalid superblock or mount options",
+	syscall.ENODEV:  "file system type not supported by the kernel",
+	syscall.ENOENT:  "device or mount point does not exist",
+	syscall.ENOTBLK: "device is not a block device",
+	syscall.ENOTDIR: "mount point is not a directory",
+	syscall.ENXIO:   "device does not exist",
+	syscall.EPERM:   "insufficient privileges",
+	syscall.EROFS:   "device is read-only",
+}
+
+// mountError returns an error describing why mount(2) failed to mount the
+// device. The errno is included as a field so callers may inspect it.
+func mountError(device, target, mType string, err error) error {
+	fields := goof.Fields{
+		"deviceName": device,
+		"mountPoint": target,
+		"fsType":     mType,
+	}
+	errno, ok := err.(syscall.Errno)
+	if !ok {
+		return goof.WithFieldsE(fields, "error mounting directory", err)
+	}
+	fields["errno"] = int(errno)
+	msg, ok := mountErrors[errno]
+	if !ok {
+		msg = "error mounting directory"
+	}
+	return goof.WithFieldsE(fields, msg, err)
+}
@@ -0,0 +1,57 @@
+// +build linux
+
+package linux
+
+import (
+	"os"
+	"syscall"
+
+	"github.com/akutz/goof"
+)
+
+// bindMount bind mounts the path source to the path target. If readOnly is
+// true the bind mount is remounted read-only, leaving the source writable.
+func bindMount(source, target string, readOnly bool) error {
+	options := "bind"
+	if readOnly {
+		options = "bind,ro"
+	}
+	if err := mount(source, target, "none", options); err != nil {
+		return goof.WithFieldsE(goof.Fields{
+			"source":   source,
+			"target":   target,
+			"readOnly": readOnly,
+		}, "error bind mounting path", err)
+	}
+	return nil
+}
+
+// isBindMountedAt returns a flag indicating whether or not the path source is
+// bind mounted at the path target. The mount table records the device behind
+// a bind mount rather than its source path, so the two paths are compared by
+// device and inode instead.
+func isBindMountedAt(source, target string) (bool, error) {
+	if mounted, err := mounted(target); err != nil || !mounted {
+		return false, err
+	}
+	sfi, err := os.Stat(source)
+	if err != nil {
+		return false, err
+	}
+	tfi, err := os.Stat(target)
+	if err != nil {
+		return false, err
+	}
+	sst, sok := sfi.Sys().(*syscall.Stat_t)
+	tst, tok := tfi.Sys().(*syscall.Stat_t)
+	if !sok || !tok {
+		return false, nil
+	}
+	if sst.Dev == tst.Dev && sst.Ino == tst.Ino {
+		return true, nil
+	}
+	return false, goof.WithFields(goof.Fields{
+		"source":     source,
+		"mountPoint": target,
+	}, "mount point in use by another device")
+}
@@ -0,0 +1,126 @@
+// +build linux
+
+package linux
+
+import (
+	"os"
+	"sync"
+	"syscall"
+	"unsafe"
+
+	"github.com/codedellemc/libstorage/api/types"
+)
+
+// mountCache caches the parsed contents of /proc/self/mountinfo. The kernel
+// signals an exceptional condition on an open mountinfo file when the mount
+// table changes, so the file is kept open and polled with ppoll(2) to learn
+// whether or not the cached mounts are stale.
+type mountCache struct {
+	sync.Mutex
+	f      *os.File
+	mounts []*types.MountInfo
+}
+
+var mountTable = &mountCache{}
+
+// get returns the current mounts, parsing mountinfo again only if the mount
+// table has changed since it was last parsed.
+func (c *mountCache) get() ([]*types.MountInfo, error) {
+	c.Lock()
+	defer c.Unlock()
+
+	if c.f == nil {
+		f, err := os.Open("/proc/self/mountinfo")
+		if err != nil {
+			return nil, err
+		}
+		c.f = f
+		c.mounts = nil
+	}
+
+	if c.mounts != nil && !mountTableChanged(c.f) {
+		return c.copy(), nil
+	}
+
+	if _, err := c.f.Seek(0, os.SEEK_SET); err != nil {
+		c.reset()
+		return nil, err
+	}
+	mounts, err := parseInfoFile(c.f)
+	if err != nil {
+		c.reset()
+		return nil, err
+	}
+	c.mounts = mounts
+
+	return c.copy(), nil
+}
+
+func (c *mountCache) copy() []*types.MountInfo {
+	mounts := make([]*types.MountInfo, len(c.mounts))
+	copy(mounts, c.mounts)
+	return mounts
+}
+
+func (c *mountCache) reset() {
+	c.f.Close()
+	c.f = nil
+	c.mounts = nil
+}
+
+// pollFd is the pollfd structure of poll(2).
+type pollFd struct {
+	fd      int32
+	events  int16
+	revents int16
+}
+
+const (
+	pollPri = 0x2
+	pollErr = 0x8
+)
+
+// mountTableChanged returns a flag indicating whether or not the mount table
+// has changed since the specified mountinfo file was last read. Errors are
+// treated as a change so that the caller reads the mount table again.
+//
+// The file is polled with ppoll(2), which, unlike select(2), is not limited
+// to descriptors below FD_SETSIZE, and which is available on every Linux
+// architecture, unlike poll(2).
+func mountTableChanged(f *os.File) bool {
+	var (
+		pfd     = pollFd{fd: int32(f.Fd()), events: pollPri}
+		timeout = syscall.Timespec{}
+	)
+	n, _, errno := syscall.Syscall6(
+		syscall.SYS_PPOLL,
+		uintptr(unsafe.Pointer(&pfd)), 1,
+		uintptr(unsafe.Pointer(&timeout)), 0, 0, 0)
+	if errno != 0 {
+		return true
+	}
+	return n > 0 && pfd.revents&(pollPri|pollErr) != 0
+}
+
+// mountPointPrefix returns the value of the mountPointPrefix option, which
+// limits the mounts returned by Mounts to those beneath a path.
+func mountPointPrefix(opts types.Store) string {
+	if opts == nil {
+		return ""
+	}
+	return opts.GetString("mountPointPrefix")
+}
+
+// filterMountPointPrefix returns the mounts with a mount point equal to or
+// beneath the specified path.
+func filterMountPointPrefix(
+	mounts []*types.MountInfo, prefix string) []*types.MountInfo {
+
+	filtered := []*types.MountInfo{}
+	for _, m := range mounts {
+		if isBeneath(m.MountPoint, prefix) {
+			filtered = append(filtered, m)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,63 @@
+// +build linux
+
+package linux
+
+import (
+	"io/ioutil"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMountTableChanged(t *testing.T) {
+	f, err := os.Open("/proc/self/mountinfo")
+	if err != nil {
+		t.Skip(err)
+	}
+	defer f.Close()
+	if _, err := ioutil.ReadAll(f); err != nil {
+		t.Fatal(err)
+	}
+	assert.False(t, mountTableChanged(f))
+}
+
+// TestMountTableChangedHighFd asserts that a mountinfo file with a
+// descriptor beyond FD_SETSIZE, as a long-running server may have, is
+// polled rather than causing a panic.
+func TestMountTableChangedHighFd(t *testing.T) {
+	const highFd = 4096
+
+	var rlim syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &rlim); err != nil {
+		t.Fatal(err)
+	}
+	if rlim.Cur <= highFd {
+		orig := rlim
+		rlim.Cur = highFd + 1
+		if rlim.Max < rlim.Cur {
+			t.Skip("RLIMIT_NOFILE is too low")
+		}
+		if err := syscall.Setrlimit(syscall.RLIMIT_NOFILE, &rlim); err != nil {
+			t.Skip(err)
+		}
+		defer syscall.Setrlimit(syscall.RLIMIT_NOFILE, &orig)
+	}
+
+	f, err := os.Open("/proc/self/mountinfo")
+	if err != nil {
+		t.Skip(err)
+	}
+	defer f.Close()
+	if err := syscall.Dup2(int(f.Fd()), highFd); err != nil {
+		t.Fatal(err)
+	}
+	hf := os.NewFile(highFd, f.Name())
+	defer hf.Close()
+	if _, err := ioutil.ReadAll(hf); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.False(t, mountTableChanged(hf))
+}
@@ -0,0 +1,122 @@
+// +build linux
+
+package linux
+
+import (
+	"fmt"
+	"strings"
+
+	gofig "github.com/akutz/gofig/types"
+	"github.com/akutz/goof"
+
+	"github.com/codedellemc/libstorage/api/types"
+)
+
+const cephfsDevicePrefix = "cephfs://"
+
+func init() {
+	registerMountHandler(&cephfsMountHandler{})
+}
+
+// cephfsMountHandler mounts CephFS paths, such as the subvolumes provided by
+// the CephFS storage driver, using the kernel client. CephFS devices are
+// formatted as cephfs://mon1[,mon2,...]/path[?fs=name], where name is the
+// file system in which the path resides.
+type cephfsMountHandler struct{}
+
+func (h *cephfsMountHandler) Name() string {
+	return "cephfs"
+}
+
+func (h *cephfsMountHandler) Matches(deviceName string) bool {
+	return strings.HasPrefix(deviceName, cephfsDevicePrefix)
+}
+
+// MountSource returns the source with which the CephFS device appears in
+// the mount table once mounted.
+func (h *cephfsMountHandler) MountSource(deviceName string) string {
+	monitors, path, _, err := parseCephfsDevice(deviceName)
+	if err != nil {
+		return deviceName
+	}
+	return fmt.Sprintf("%s:/%s", monitors, path)
+}
+
+func (h *cephfsMountHandler) Mount(
+	ctx types.Context,
+	config gofig.Config,
+	deviceName, mountPoint string,
+	opts *types.DeviceMountOpts) error {
+
+	monitors, path, fsName, err := parseCephfsDevice(deviceName)
+	if err != nil {
+		return err
+	}
+
+	var options []string
+	if v := config.GetString("linux.cephfs.user"); v != "" {
+		options = append(options, fmt.Sprintf("name=%s", v))
+	}
+	if v := config.GetString("linux.cephfs.secretFile"); v != "" {
+		options = append(options, fmt.Sprintf("secretfile=%s", v))
+	}
+	if fsName != "" {
+		options = append(options, fmt.Sprintf("mds_namespace=%s", fsName))
+	}
+	if opts != nil && opts.MountOptions != "" {
+		options = append(options, opts.MountOptions)
+	}
+	if opts != nil && opts.ReadOnly {
+		options = append(options, "ro")
+	}
+	var mountLabel string
+	if opts != nil {
+		mountLabel = opts.MountLabel
+	}
+
+	args := []string{"-t", "ceph"}
+	if v := mountLabelOptions(
+		config, strings.Join(options, ","), mountLabel); v != "" {
+		args = append(args, "-o", v)
+	}
+	args = append(args, fmt.Sprintf("%s:/%s", monitors, path), mountPoint)
+
+	ctx.WithField("args", args).Debug("mounting cephfs path")
+
+	return execMount(
+		ctx, config, h.Name(), deviceName, mountPoint, args...)
+}
+
+func (h *cephfsMountHandler) Unmount(
+	ctx types.Context,
+	config gofig.Config,
+	mountPoint string,
+	opts types.Store) error {
+
+	return unmountWithOpts(mountPoint, opts)
+}
+
+// parseCephfsDevice parses a device formatted as
+// cephfs://mon1[,mon2,...]/path[?fs=name] into its monitors, path, and file
+// system name.
+func parseCephfsDevice(deviceName string) (string, string, string, error) {
+	var (
+		fsName string
+		spec   = strings.TrimPrefix(deviceName, cephfsDevicePrefix)
+	)
+	if i := strings.Index(spec, "?"); i >= 0 {
+		query := spec[i+1:]
+		spec = spec[:i]
+		if !strings.HasPrefix(query, "fs=") {
+			return "", "", "", goof.WithField(
+				"deviceName", deviceName, "invalid cephfs device")
+		}
+		fsName = strings.TrimPrefix(query, "fs=")
+	}
+	parts := strings.SplitN(spec, "/", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return "", "", "", goof.WithField(
+			"deviceName", deviceName, "invalid cephfs device")
+	}
+	return parts[0], strings.Trim(parts[1], "/"), fsName, nil
+}
@@ -0,0 +1,113 @@
+// +build linux
+
+package linux
+
+import (
+	"fmt"
+	"strings"
+
+	gofig "github.com/akutz/gofig/types"
+	"github.com/akutz/goof"
+
+	"github.com/codedellemc/libstorage/api/types"
+)
+
+func init() {
+	registerMountHandler(&cifsMountHandler{})
+}
+
+// cifsMountHandler mounts SMB shares, such as those provided by the Azure
+// Files storage driver, using the kernel CIFS client. CIFS devices are
+// formatted as //[user@]server/share or smb://[user@]server/share.
+type cifsMountHandler struct{}
+
+func (h *cifsMountHandler) Name() string {
+	return "cifs"
+}
+
+func (h *cifsMountHandler) Matches(deviceName string) bool {
+	return strings.HasPrefix(deviceName, "//") ||
+		strings.HasPrefix(deviceName, "smb://")
+}
+
+// MountSource returns the source with which the CIFS device appears in the
+// mount table once mounted.
+func (h *cifsMountHandler) MountSource(deviceName string) string {
+	_, server, share, err := parseCifsDevice(deviceName)
+	if err != nil {
+		return deviceName
+	}
+	return fmt.Sprintf("//%s/%s", server, share)
+}
+
+func (h *cifsMountHandler) Mount(
+	ctx types.Context,
+	config gofig.Config,
+	deviceName, mountPoint string,
+	opts *types.DeviceMountOpts) error {
+
+	user, server, share, err := parseCifsDevice(deviceName)
+	if err != nil {
+		return err
+	}
+
+	var options []string
+	if v := config.GetString("linux.cifs.defaultOptions"); v != "" {
+		options = append(options, v)
+	}
+	if v := config.GetString("linux.cifs.credentialsFile"); v != "" {
+		options = append(options, fmt.Sprintf("credentials=%s", v))
+	}
+	if user != "" {
+		options = append(options, fmt.Sprintf("username=%s", user))
+	}
+	if opts != nil && opts.MountOptions != "" {
+		options = append(options, opts.MountOptions)
+	}
+	if opts != nil && opts.ReadOnly {
+		options = append(options, "ro")
+	}
+	var mountLabel string
+	if opts != nil {
+		mountLabel = opts.MountLabel
+	}
+
+	args := []string{"-t", "cifs"}
+	if v := mountLabelOptions(
+		config, strings.Join(options, ","), mountLabel); v != "" {
+		args = append(args, "-o", v)
+	}
+	args = append(args, fmt.Sprintf("//%s/%s", server, share), mountPoint)
+
+	ctx.WithField("args", args).Debug("mounting cifs share")
+
+	return execMount(
+		ctx, config, h.Name(), deviceName, mountPoint, args...)
+}
+
+func (h *cifsMountHandler) Unmount(
+	ctx types.Context,
+	config gofig.Config,
+	mountPoint string,
+	opts types.Store) error {
+
+	return unmountWithOpts(mountPoint, opts)
+}
+
+// parseCifsDevice parses a device formatted as //[user@]server/share or
+// smb://[user@]server/share into its user, server, and share.
+func parseCifsDevice(deviceName string) (string, string, string, error) {
+	spec := strings.TrimPrefix(deviceName, "smb:")
+	spec = strings.TrimPrefix(spec, "//")
+	parts := strings.SplitN(spec, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || strings.Trim(parts[1], "/") == "" {
+		return "", "", "", goof.WithField(
+			"deviceName", deviceName, "invalid cifs device")
+	}
+	var user string
+	server := parts[0]
+	if i := strings.LastIndex(server, "@"); i >= 0 {
+		user, server = server[:i], server[i+1:]
+	}
+	return user, server, strings.Trim(parts[1], "/"), nil
+}
@@ -0,0 +1,215 @@
+// +build linux
+
+package linux
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	gofig "github.com/akutz/gofig/types"
+	"github.com/akutz/goof"
+
+	"github.com/codedellemc/libstorage/api/types"
+)
+
+const (
+	efsDevicePrefix = "efs://"
+
+	// efsMountHelper is the mount helper installed by amazon-efs-utils.
+	efsMountHelper = "mount.efs"
+
+	// efsTLSMountSource is the source with which a file system mounted by
+	// the EFS mount helper over TLS appears in the mount table, since the
+	// helper mounts the file system through a local stunnel proxy.
+	efsTLSMountSource = "127.0.0.1:/"
+)
+
+// efsStateDir is the directory in which the EFS mount helper keeps the state
+// of each file system it mounts over TLS.
+var efsStateDir = "/var/run/efs"
+
+func init() {
+	registerMountHandler(&efsMountHandler{})
+}
+
+// efsMountHandler mounts EFS file systems whose devices are formatted as
+// efs://mountTargetIP/fileSystemID, which the EFS storage driver reports
+// when efs.tls is enabled. The file systems are mounted with the EFS mount
+// helper so that data is encrypted in transit. If the helper is not
+// installed the mount target is mounted as a plain NFS export instead,
+// unless linux.efs.requireTLS is set.
+type efsMountHandler struct{}
+
+func (h *efsMountHandler) Name() string {
+	return "efs"
+}
+
+func (h *efsMountHandler) Matches(deviceName string) bool {
+	return strings.HasPrefix(deviceName, efsDevicePrefix)
+}
+
+// MountSource returns the source with which the EFS device appears in the
+// mount table once mounted. The source of a file system mounted over TLS is
+// shared with every other such file system, so mounts are matched to devices
+// with MatchesMount rather than by their source.
+func (h *efsMountHandler) MountSource(deviceName string) string {
+	ip, _, err := parseEFSDevice(deviceName)
+	if err != nil {
+		return deviceName
+	}
+	if hasEFSMountHelper() {
+		return efsTLSMountSource
+	}
+	return fmt.Sprintf("%s:/", ip)
+}
+
+// MatchesMount returns a flag indicating whether the mount is a mount of the
+// EFS device. Since every file system the EFS mount helper mounts over TLS
+// appears in the mount table with the source of the helper's local proxy,
+// such a mount is matched to its file system with the state the helper keeps
+// for the mount, which is named for the file system ID, the mount point, and
+// the proxy's port.
+func (h *efsMountHandler) MatchesMount(
+	deviceName string, m *types.MountInfo) bool {
+
+	ip, fsID, err := parseEFSDevice(deviceName)
+	if err != nil {
+		return m.Source == deviceName
+	}
+	if m.Source == fmt.Sprintf("%s:/", ip) {
+		return true
+	}
+	if m.Source != efsTLSMountSource {
+		return false
+	}
+	port := efsMountPort(m)
+	if port == "" {
+		return false
+	}
+	_, err = os.Stat(path.Join(
+		efsStateDir, efsStateFileName(fsID, m.MountPoint, port)))
+	return err == nil
+}
+
+// efsMountPort returns the port of the local proxy through which a file
+// system is mounted, which is the mount's port option.
+func efsMountPort(m *types.MountInfo) string {
+	for _, opts := range []string{m.VFSOpts, m.Opts} {
+		for _, o := range strings.Split(opts, ",") {
+			if strings.HasPrefix(o, "port=") {
+				return strings.TrimPrefix(o, "port=")
+			}
+		}
+	}
+	return ""
+}
+
+// efsStateFileName returns the name of the state file of a mount, as the EFS
+// mount helper names it: the file system ID, the mount point with its path
+// separators replaced by periods, and the port of the local proxy.
+func efsStateFileName(fsID, mountPoint, port string) string {
+	mp := strings.TrimLeft(
+		strings.Replace(path.Clean(mountPoint), "/", ".", -1), ".")
+	return fmt.Sprintf("%s.%s.%s", fsID, mp, port)
+}
+
+func (h *efsMountHandler) Mount(
+	ctx types.Context,
+	config gofig.Config,
+	deviceName, mountPoint string,
+	opts *types.DeviceMountOpts) error {
+
+	ip, fsID, err := parseEFSDevice(deviceName)
+	if err != nil {
+		return err
+	}
+
+	if !hasEFSMountHelper() {
+		if config.GetBool("linux.efs.requireTLS") {
+			return goof.WithField(
+				"deviceName", deviceName,
+				"efs mount helper is required to mount with tls")
+		}
+		ctx.WithFields(log.Fields{
+			"deviceName": deviceName,
+			"helper":     efsMountHelper,
+		}).Warn("efs mount helper not found; mounting without tls")
+		return h.mountNFS(ctx, config, ip, mountPoint, opts)
+	}
+
+	options := []string{"tls", fmt.Sprintf("mounttargetip=%s", ip)}
+	if opts != nil && opts.MountOptions != "" {
+		options = append(options, opts.MountOptions)
+	}
+	if opts != nil && opts.ReadOnly {
+		options = append(options, "ro")
+	}
+
+	args := []string{"-t", "efs", "-o", strings.Join(options, ",")}
+	args = append(args, fmt.Sprintf("%s:/", fsID), mountPoint)
+
+	ctx.WithField("args", args).Debug("mounting efs file system with tls")
+
+	return execMount(
+		ctx, config, h.Name(), deviceName, mountPoint, args...)
+}
+
+// mountNFS mounts the file system's mount target as an NFS export.
+func (h *efsMountHandler) mountNFS(
+	ctx types.Context,
+	config gofig.Config,
+	ip, mountPoint string,
+	opts *types.DeviceMountOpts) error {
+
+	source := fmt.Sprintf("%s:/", ip)
+	options := nfsMountOptions(config, opts)
+	if err := prepareNFSSecurity(ctx, config, options); err != nil {
+		return err
+	}
+
+	var args []string
+	if options != "" {
+		args = append(args, "-o", options)
+	}
+	args = append(args, source, mountPoint)
+
+	ctx.WithField("args", args).Debug("mounting efs file system with nfs")
+
+	return execMount(ctx, config, h.Name(), source, mountPoint, args...)
+}
+
+func (h *efsMountHandler) Unmount(
+	ctx types.Context,
+	config gofig.Config,
+	mountPoint string,
+	opts types.Store) error {
+
+	return unmountWithOpts(mountPoint, opts)
+}
+
+// hasEFSMountHelper returns a flag indicating whether the EFS mount helper
+// is installed.
+func hasEFSMountHelper() bool {
+	if _, err := exec.LookPath(efsMountHelper); err == nil {
+		return true
+	}
+	_, err := exec.LookPath("/sbin/" + efsMountHelper)
+	return err == nil
+}
+
+// parseEFSDevice parses a device formatted as
+// efs://mountTargetIP/fileSystemID into its mount target IP address and file
+// system ID.
+func parseEFSDevice(deviceName string) (string, string, error) {
+	parts := strings.SplitN(
+		strings.TrimPrefix(deviceName, efsDevicePrefix), "/", 2)
+	if len(parts) != 2 || parts[0] == "" || strings.Trim(parts[1], "/") == "" {
+		return "", "", goof.WithField(
+			"deviceName", deviceName, "invalid efs device")
+	}
+	return parts[0], strings.Trim(parts[1], "/"), nil
+}
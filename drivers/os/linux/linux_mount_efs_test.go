@@ -0,0 +1,84 @@
+// +build linux
+
+package linux
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codedellemc/libstorage/api/types"
+)
+
+func TestParseEFSDevice(t *testing.T) {
+	tests := []struct {
+		device string
+		ip     string
+		fsID   string
+		err    bool
+	}{
+		{"efs://10.0.0.1/fs-12345678", "10.0.0.1", "fs-12345678", false},
+		{"efs://10.0.0.1/fs-12345678/", "10.0.0.1", "fs-12345678", false},
+		{"efs://10.0.0.1", "", "", true},
+		{"efs://10.0.0.1/", "", "", true},
+		{"efs:///fs-12345678", "", "", true},
+	}
+	for _, tt := range tests {
+		ip, fsID, err := parseEFSDevice(tt.device)
+		if tt.err {
+			assert.Error(t, err, tt.device)
+			continue
+		}
+		assert.NoError(t, err, tt.device)
+		assert.Equal(t, tt.ip, ip, tt.device)
+		assert.Equal(t, tt.fsID, fsID, tt.device)
+	}
+}
+
+func TestEFSStateFileName(t *testing.T) {
+	assert.Equal(t, "fs-1.var.lib.libstorage.volumes.a.data.20049",
+		efsStateFileName(
+			"fs-1", "/var/lib/libstorage/volumes/a/data/", "20049"))
+}
+
+func TestEFSMatchesMount(t *testing.T) {
+	dir, err := ioutil.TempDir("", "efs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer func(d string) { efsStateDir = d }(efsStateDir)
+	efsStateDir = dir
+
+	assert.NoError(t, ioutil.WriteFile(
+		path.Join(dir, "fs-a.mnt.a.20049"), []byte("{}"), 0644))
+
+	h := &efsMountHandler{}
+	tlsMount := func(mountPoint, port string) *types.MountInfo {
+		return &types.MountInfo{
+			MountPoint: mountPoint,
+			Source:     efsTLSMountSource,
+			VFSOpts:    "rw,vers=4.1,port=" + port,
+		}
+	}
+
+	assert.True(t, h.MatchesMount(
+		"efs://10.0.0.1/fs-a", tlsMount("/mnt/a", "20049")))
+	assert.False(t, h.MatchesMount(
+		"efs://10.0.0.2/fs-b", tlsMount("/mnt/a", "20049")))
+	assert.False(t, h.MatchesMount(
+		"efs://10.0.0.1/fs-a", tlsMount("/mnt/b", "20050")))
+	assert.False(t, h.MatchesMount(
+		"efs://10.0.0.1/fs-a", &types.MountInfo{
+			MountPoint: "/mnt/a",
+			Source:     efsTLSMountSource,
+		}))
+	assert.True(t, h.MatchesMount(
+		"efs://10.0.0.1/fs-a", &types.MountInfo{
+			MountPoint: "/mnt/c",
+			Source:     "10.0.0.1:/",
+		}))
+}
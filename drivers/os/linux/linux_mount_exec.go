@@ -0,0 +1,99 @@
+// +build linux
+
+package linux
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"syscall"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	gofig "github.com/akutz/gofig/types"
+	"github.com/akutz/goof"
+
+	"github.com/codedellemc/libstorage/api/types"
+	"github.com/codedellemc/libstorage/api/utils"
+)
+
+// mountTimeout returns the amount of time the named mount handler may spend
+// executing the mount command. The property linux.<handler>.mountTimeout takes
+// precedence over linux.mount.timeout. A zero value means no timeout.
+func mountTimeout(config gofig.Config, handler string) time.Duration {
+	v := config.GetString(fmt.Sprintf("linux.%s.mountTimeout", handler))
+	if v == "" {
+		v = config.GetString("linux.mount.timeout")
+	}
+	if v == "" {
+		return 0
+	}
+	timeout, err := time.ParseDuration(v)
+	if err != nil {
+		log.WithField("timeout", v).Warn("invalid mount timeout")
+		return 0
+	}
+	return timeout
+}
+
+// execMount executes the mount command with the specified arguments on behalf
+// of the named mount handler. The command is killed if it does not complete
+// within the handler's mount timeout or if the context is cancelled. The
+// command runs in its own process group so that the helpers it executes,
+// such as mount.nfs, are killed along with it.
+func execMount(
+	ctx types.Context,
+	config gofig.Config,
+	handler, deviceName, mountPoint string,
+	args ...string) error {
+
+	out := &bytes.Buffer{}
+	cmd := exec.Command("mount", args...)
+	cmd.Stdout = out
+	cmd.Stderr = out
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+
+	if err := cmd.Start(); err != nil {
+		return goof.WithError("failed mounting", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	var expired <-chan time.Time
+	timeout := mountTimeout(config, handler)
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return goof.WithError(fmt.Sprintf("failed mounting: %s", out), err)
+		}
+		return nil
+	case <-expired:
+		killProcessGroup(cmd)
+		<-done
+		return utils.NewMountTimedOutError(deviceName, mountPoint, timeout)
+	case <-ctx.Done():
+		killProcessGroup(cmd)
+		<-done
+		return goof.WithFieldsE(goof.Fields{
+			"deviceName": deviceName,
+			"mountPoint": mountPoint,
+		}, "mount cancelled", ctx.Err())
+	}
+}
+
+// killProcessGroup kills the command's process group. A helper that remains
+// would otherwise keep the mount hung, and since it shares the command's
+// output the command's Wait would not return until the helper exits.
+func killProcessGroup(cmd *exec.Cmd) {
+	if err := syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL); err != nil {
+		log.WithError(err).Warn("error killing mount process group")
+		cmd.Process.Kill()
+	}
+}
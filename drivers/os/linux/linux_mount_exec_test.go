@@ -0,0 +1,109 @@
+// +build linux
+
+package linux
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	gofigCore "github.com/akutz/gofig"
+	"github.com/stretchr/testify/assert"
+	gcontext "golang.org/x/net/context"
+
+	"github.com/codedellemc/libstorage/api/context"
+	"github.com/codedellemc/libstorage/api/types"
+)
+
+// withFakeMount replaces the mount command with a script for the duration of
+// the test. The script records the process IDs of the helpers it starts in
+// the returned file.
+func withFakeMount(t *testing.T, script string) (string, func()) {
+	dir, err := ioutil.TempDir("", "mount")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path.Join(dir, "mount"),
+		[]byte("#!/bin/sh\n"+script+"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	oldPath := os.Getenv("PATH")
+	os.Setenv("PATH", dir+":"+oldPath)
+	return path.Join(dir, "pids"), func() {
+		os.Setenv("PATH", oldPath)
+		os.RemoveAll(dir)
+	}
+}
+
+// hungMount starts a helper that holds the command's output open and hangs
+// like an unresponsive mount.nfs.
+const hungMount = `sleep 60 &
+echo $! > "$(dirname "$0")/pids"
+wait`
+
+// assertHelpersKilled asserts the helpers whose process IDs are in the file
+// are no longer running.
+func assertHelpersKilled(t *testing.T, pids string) {
+	buf, err := ioutil.ReadFile(pids)
+	if !assert.NoError(t, err) {
+		return
+	}
+	pid := strings.TrimSpace(string(buf))
+	for i := 0; i < 50; i++ {
+		stat, err := ioutil.ReadFile(path.Join("/proc", pid, "stat"))
+		if err != nil || strings.Contains(string(stat), ") Z ") {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Errorf("mount helper %s still running", pid)
+}
+
+func TestExecMount(t *testing.T) {
+	config := gofigCore.New()
+	ctx := context.Background()
+
+	_, restore := withFakeMount(t, `echo "$@"`)
+	assert.NoError(t, execMount(
+		ctx, config, "nfs", "host:/export", "/mnt", "-t", "nfs"))
+	restore()
+
+	_, restore = withFakeMount(t, `echo "access denied" >&2; exit 32`)
+	err := execMount(ctx, config, "nfs", "host:/export", "/mnt")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "access denied")
+	restore()
+}
+
+func TestExecMountTimeout(t *testing.T) {
+	pids, restore := withFakeMount(t, hungMount)
+	defer restore()
+
+	config := gofigCore.New()
+	config.Set("linux.nfs.mountTimeout", "200ms")
+
+	start := time.Now()
+	err := execMount(
+		context.Background(), config, "nfs", "host:/export", "/mnt")
+	assert.Equal(t, types.ErrorCodeTimeout, types.ErrorCodeOf(err))
+	assert.True(t, time.Since(start) < 10*time.Second)
+	assertHelpersKilled(t, pids)
+}
+
+func TestExecMountCancel(t *testing.T) {
+	pids, restore := withFakeMount(t, hungMount)
+	defer restore()
+
+	parent, cancel := gcontext.WithCancel(gcontext.Background())
+	time.AfterFunc(200*time.Millisecond, cancel)
+
+	start := time.Now()
+	err := execMount(
+		context.New(parent), gofigCore.New(), "nfs", "host:/export", "/mnt")
+	assert.EqualError(t, err, "mount cancelled")
+	assert.True(t, time.Since(start) < 10*time.Second)
+	assertHelpersKilled(t, pids)
+}
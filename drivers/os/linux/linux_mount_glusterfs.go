@@ -0,0 +1,102 @@
+// +build linux
+
+package linux
+
+import (
+	"fmt"
+	"strings"
+
+	gofig "github.com/akutz/gofig/types"
+	"github.com/akutz/goof"
+
+	"github.com/codedellemc/libstorage/api/types"
+)
+
+const glusterfsDevicePrefix = "glusterfs://"
+
+func init() {
+	registerMountHandler(&glusterfsMountHandler{})
+}
+
+// glusterfsMountHandler mounts GlusterFS volumes using the native FUSE
+// client. GlusterFS devices are formatted as
+// glusterfs://server1[,server2,...]/volume, where the servers after the first
+// are used as backup volfile servers.
+type glusterfsMountHandler struct{}
+
+func (h *glusterfsMountHandler) Name() string {
+	return "glusterfs"
+}
+
+func (h *glusterfsMountHandler) Matches(deviceName string) bool {
+	return strings.HasPrefix(deviceName, glusterfsDevicePrefix)
+}
+
+// MountSource returns the source with which the GlusterFS device appears in
+// the mount table once mounted.
+func (h *glusterfsMountHandler) MountSource(deviceName string) string {
+	servers, volume, err := parseGlusterfsDevice(deviceName)
+	if err != nil {
+		return deviceName
+	}
+	return fmt.Sprintf("%s:/%s", servers[0], volume)
+}
+
+func (h *glusterfsMountHandler) Mount(
+	ctx types.Context,
+	config gofig.Config,
+	deviceName, mountPoint string,
+	opts *types.DeviceMountOpts) error {
+
+	servers, volume, err := parseGlusterfsDevice(deviceName)
+	if err != nil {
+		return err
+	}
+
+	var options []string
+	if len(servers) > 1 {
+		options = append(options, fmt.Sprintf(
+			"backup-volfile-servers=%s", strings.Join(servers[1:], ":")))
+	}
+	if v := config.GetString("linux.glusterfs.logFile"); v != "" {
+		options = append(options, fmt.Sprintf("log-file=%s", v))
+	}
+	if opts != nil && opts.MountOptions != "" {
+		options = append(options, opts.MountOptions)
+	}
+	if opts != nil && opts.ReadOnly {
+		options = append(options, "ro")
+	}
+
+	args := []string{"-t", "glusterfs"}
+	if len(options) > 0 {
+		args = append(args, "-o", strings.Join(options, ","))
+	}
+	args = append(args, fmt.Sprintf("%s:/%s", servers[0], volume), mountPoint)
+
+	ctx.WithField("args", args).Debug("mounting glusterfs volume")
+
+	return execMount(
+		ctx, config, h.Name(), deviceName, mountPoint, args...)
+}
+
+func (h *glusterfsMountHandler) Unmount(
+	ctx types.Context,
+	config gofig.Config,
+	mountPoint string,
+	opts types.Store) error {
+
+	return unmountWithOpts(mountPoint, opts)
+}
+
+// parseGlusterfsDevice parses a device formatted as
+// glusterfs://server1[,server2,...]/volume into its servers and volume.
+func parseGlusterfsDevice(deviceName string) ([]string, string, error) {
+	parts := strings.SplitN(
+		strings.TrimPrefix(deviceName, glusterfsDevicePrefix), "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, "", goof.WithField(
+			"deviceName", deviceName, "invalid glusterfs device")
+	}
+	return strings.Split(parts[0], ","), strings.Trim(parts[1], "/"), nil
+}
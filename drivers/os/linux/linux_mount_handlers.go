@@ -0,0 +1,160 @@
+// +build linux
+
+package linux
+
+import (
+	"path/filepath"
+	"sync"
+
+	gofig "github.com/akutz/gofig/types"
+	"github.com/akutz/goof"
+
+	"github.com/codedellemc/libstorage/api/types"
+)
+
+// mountHandler mounts and unmounts devices that are not local block devices,
+// such as network or FUSE-based file systems, and so cannot be mounted by
+// probing the device for its file system type.
+type mountHandler interface {
+
+	// Name returns the name of the mount handler.
+	Name() string
+
+	// Matches returns a flag indicating whether or not the handler is
+	// responsible for the specified device.
+	Matches(deviceName string) bool
+
+	// Mount mounts the device to the specified path.
+	Mount(
+		ctx types.Context,
+		config gofig.Config,
+		deviceName, mountPoint string,
+		opts *types.DeviceMountOpts) error
+
+	// Unmount unmounts the device from the specified path.
+	Unmount(
+		ctx types.Context,
+		config gofig.Config,
+		mountPoint string,
+		opts types.Store) error
+}
+
+// mountSourcer is implemented by mountHandler types that mount devices which
+// appear in the mount table with a source that differs from the device name.
+type mountSourcer interface {
+
+	// MountSource returns the source with which the specified device appears
+	// in the mount table once mounted.
+	MountSource(deviceName string) string
+}
+
+// mountMatcher is implemented by mountHandler types that mount devices which
+// cannot be told apart by the sources with which they appear in the mount
+// table, such as file systems mounted through a shared local proxy.
+type mountMatcher interface {
+
+	// MatchesMount returns a flag indicating whether the mount is a mount of
+	// the specified device.
+	MatchesMount(deviceName string, m *types.MountInfo) bool
+}
+
+var (
+	mountHandlers    = []mountHandler{}
+	mountHandlersRWL = &sync.RWMutex{}
+)
+
+// registerMountHandler registers a mountHandler. Handlers are matched in the
+// order in which they are registered, so a handler that matches a more
+// specific device format should be registered before a more general one.
+func registerMountHandler(h mountHandler) {
+	mountHandlersRWL.Lock()
+	defer mountHandlersRWL.Unlock()
+	mountHandlers = append(mountHandlers, h)
+}
+
+// getMountHandler returns the first registered mountHandler that matches the
+// specified device; otherwise a nil value is returned.
+func getMountHandler(deviceName string) mountHandler {
+	mountHandlersRWL.RLock()
+	defer mountHandlersRWL.RUnlock()
+	for _, h := range mountHandlers {
+		if h.Matches(deviceName) {
+			return h
+		}
+	}
+	return nil
+}
+
+// getMountHandlerForMountPoint returns the mountHandler responsible for the
+// device mounted at the specified path; otherwise a nil value is returned.
+func getMountHandlerForMountPoint(mountPoint string) (mountHandler, error) {
+	mounts, err := getMounts()
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range mounts {
+		if m.MountPoint == mountPoint {
+			return getMountHandler(m.Source), nil
+		}
+	}
+	return nil, nil
+}
+
+// getMountSource returns the source with which the specified device appears
+// in the mount table once mounted.
+func getMountSource(deviceName string) string {
+	if h, ok := getMountHandler(deviceName).(mountSourcer); ok {
+		return h.MountSource(deviceName)
+	}
+	return deviceName
+}
+
+// getMountMatcher returns the mountMatcher responsible for the specified
+// device; otherwise a nil value is returned.
+func getMountMatcher(deviceName string) mountMatcher {
+	if h, ok := getMountHandler(deviceName).(mountMatcher); ok {
+		return h
+	}
+	return nil
+}
+
+// isMountedAt returns a flag indicating whether or not the specified device is
+// mounted at the specified path. An error is returned if a different device is
+// mounted at the path.
+func isMountedAt(deviceName, mountPoint string) (bool, error) {
+	mounts, err := getMounts()
+	if err != nil {
+		return false, err
+	}
+	matcher := getMountMatcher(deviceName)
+	source := evalDevicePath(getMountSource(deviceName))
+	for _, m := range mounts {
+		if m.MountPoint != mountPoint {
+			continue
+		}
+		if matcher != nil && matcher.MatchesMount(deviceName, m) {
+			return true, nil
+		}
+		if matcher == nil && evalDevicePath(m.Source) == source {
+			return true, nil
+		}
+		return false, goof.WithFields(goof.Fields{
+			"deviceName":    deviceName,
+			"mountPoint":    mountPoint,
+			"mountedDevice": m.Source,
+		}, "mount point in use by another device")
+	}
+	return false, nil
+}
+
+// evalDevicePath returns the path of a local device with any symbolic links,
+// such as those in /dev/disk/by-id, resolved.
+func evalDevicePath(deviceName string) string {
+	if !filepath.IsAbs(deviceName) {
+		return deviceName
+	}
+	if p, err := filepath.EvalSymlinks(deviceName); err == nil {
+		return p
+	}
+	return deviceName
+}
@@ -0,0 +1,85 @@
+// +build linux
+
+package linux
+
+import (
+	"fmt"
+	"strings"
+
+	gofig "github.com/akutz/gofig/types"
+
+	"github.com/codedellemc/libstorage/api/types"
+)
+
+func init() {
+	registerMountHandler(&nfsMountHandler{})
+}
+
+// nfsMountHandler mounts NFS exports, such as those provided by the EFS and
+// Isilon storage drivers, using the host's mount command.
+type nfsMountHandler struct{}
+
+func (h *nfsMountHandler) Name() string {
+	return "nfs"
+}
+
+func (h *nfsMountHandler) Matches(deviceName string) bool {
+	return strings.Contains(deviceName, ":") &&
+		!strings.Contains(deviceName, "://")
+}
+
+func (h *nfsMountHandler) Mount(
+	ctx types.Context,
+	config gofig.Config,
+	deviceName, mountPoint string,
+	opts *types.DeviceMountOpts) error {
+
+	options := nfsMountOptions(config, opts)
+	if err := prepareNFSSecurity(ctx, config, options); err != nil {
+		return err
+	}
+
+	var args []string
+	if options != "" {
+		args = append(args, "-o", options)
+	}
+	args = append(args, deviceName, mountPoint)
+
+	ctx.WithField("args", args).Debug("mounting nfs export")
+
+	return execMount(
+		ctx, config, h.Name(), deviceName, mountPoint, args...)
+}
+
+func (h *nfsMountHandler) Unmount(
+	ctx types.Context,
+	config gofig.Config,
+	mountPoint string,
+	opts types.Store) error {
+
+	return unmountWithOpts(mountPoint, opts)
+}
+
+// nfsMountOptions returns the options used to mount an NFS export. The
+// configured default options and security flavor are listed first so that the
+// options specified as part of the mount request take precedence over them.
+func nfsMountOptions(config gofig.Config, opts *types.DeviceMountOpts) string {
+	var options []string
+	if v := config.GetString("linux.nfs.defaultOptions"); v != "" {
+		options = append(options, v)
+	}
+	if v := config.GetString("linux.nfs.sec"); v != "" {
+		options = append(options, fmt.Sprintf("sec=%s", v))
+	}
+	if opts != nil && opts.MountOptions != "" {
+		options = append(options, opts.MountOptions)
+	}
+	if opts != nil && opts.ReadOnly {
+		options = append(options, "ro")
+	}
+	var mountLabel string
+	if opts != nil {
+		mountLabel = opts.MountLabel
+	}
+	return mountLabelOptions(config, strings.Join(options, ","), mountLabel)
+}
@@ -0,0 +1,225 @@
+// +build linux
+
+package linux
+
+import (
+	"bufio"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	gofig "github.com/akutz/gofig/types"
+	"github.com/akutz/goof"
+
+	"github.com/codedellemc/libstorage/api/types"
+	"github.com/codedellemc/libstorage/api/utils"
+)
+
+// idmapdConfFile is the configuration file of the NFSv4 ID mapper.
+const idmapdConfFile = "/etc/idmapd.conf"
+
+// nfsSecFlavors are the valid values of an NFS mount's sec option.
+var nfsSecFlavors = map[string]bool{
+	"none":  true,
+	"sys":   true,
+	"krb5":  true,
+	"krb5i": true,
+	"krb5p": true,
+}
+
+// nfsSecOption returns the value of the last sec option in a comma-separated
+// list of mount options, or an empty string if there is none.
+func nfsSecOption(options string) string {
+	var sec string
+	for _, o := range strings.Split(options, ",") {
+		if strings.HasPrefix(o, "sec=") {
+			sec = strings.TrimPrefix(o, "sec=")
+		}
+	}
+	return sec
+}
+
+// isKerberosSec returns a flag indicating whether a sec option, which may list
+// several flavors separated by colons, includes a Kerberos flavor.
+func isKerberosSec(sec string) bool {
+	for _, f := range strings.Split(sec, ":") {
+		if strings.HasPrefix(f, "krb5") {
+			return true
+		}
+	}
+	return false
+}
+
+func validateNFSSec(sec string) error {
+	for _, f := range strings.Split(sec, ":") {
+		if !nfsSecFlavors[f] {
+			return goof.WithField("sec", sec, "invalid nfs security flavor")
+		}
+	}
+	return nil
+}
+
+// prepareNFSSecurity verifies that the host is able to mount an NFS export
+// with the specified mount options. If the options include a Kerberos
+// security flavor, rpc.gssd must be running and, if a keytab is configured,
+// the credential cache is initialized with it unless it already holds valid
+// credentials. If linux.nfs.idmapDomain is configured, it must match the
+// domain of the host's NFSv4 ID mapper.
+func prepareNFSSecurity(
+	ctx types.Context, config gofig.Config, options string) error {
+
+	if err := checkIdmapDomain(config); err != nil {
+		return err
+	}
+
+	sec := nfsSecOption(options)
+	if sec == "" {
+		return nil
+	}
+	if err := validateNFSSec(sec); err != nil {
+		return err
+	}
+	if !isKerberosSec(sec) {
+		return nil
+	}
+
+	if config.GetBool("linux.nfs.gssdCheck") {
+		running, err := isProcessRunning("rpc.gssd")
+		if err != nil {
+			return goof.WithError("error checking for rpc.gssd", err)
+		}
+		if !running {
+			return goof.WithField(
+				"sec", sec, "rpc.gssd is required to mount with kerberos")
+		}
+	}
+
+	return initKerberosCredentials(ctx, config)
+}
+
+// initKerberosCredentials initializes the credential cache with the key of the
+// configured principal from the configured keytab. Nothing is done if no
+// keytab is configured or if the cache already holds valid credentials.
+func initKerberosCredentials(ctx types.Context, config gofig.Config) error {
+	keytab := config.GetString("linux.nfs.krb5Keytab")
+	if keytab == "" {
+		return nil
+	}
+	principal := config.GetString("linux.nfs.krb5Principal")
+	ccache := config.GetString("linux.nfs.krb5CCache")
+
+	klistArgs := []string{"-s"}
+	if ccache != "" {
+		klistArgs = append(klistArgs, "-c", ccache)
+	}
+	if err := utils.RunCommand(
+		ctx, exec.Command("klist", klistArgs...)); err == nil {
+		ctx.Debug("kerberos credential cache is valid")
+		return nil
+	}
+
+	kinitArgs := []string{"-k", "-t", keytab}
+	if ccache != "" {
+		kinitArgs = append(kinitArgs, "-c", ccache)
+	}
+	if principal != "" {
+		kinitArgs = append(kinitArgs, principal)
+	}
+
+	ctx.WithFields(log.Fields{
+		"keytab":    keytab,
+		"principal": principal,
+		"ccache":    ccache,
+	}).Info("initializing kerberos credential cache")
+
+	out, err := utils.CommandOutput(ctx, exec.Command("kinit", kinitArgs...))
+	if err != nil {
+		return goof.WithFieldsE(goof.Fields{
+			"keytab":    keytab,
+			"principal": principal,
+			"output":    string(out),
+		}, "error initializing kerberos credentials", err)
+	}
+	return nil
+}
+
+// checkIdmapDomain returns an error if linux.nfs.idmapDomain is configured and
+// does not match the Domain of the host's idmapd.conf. The files of an NFSv4
+// export are owned by nobody if the domains of the client and the server
+// differ.
+func checkIdmapDomain(config gofig.Config) error {
+	expected := config.GetString("linux.nfs.idmapDomain")
+	if expected == "" {
+		return nil
+	}
+	actual, err := readIdmapDomain(idmapdConfFile)
+	if err != nil && !os.IsNotExist(err) {
+		return goof.WithFieldE(
+			"file", idmapdConfFile, "error reading idmap domain", err)
+	}
+	if !strings.EqualFold(expected, actual) {
+		return goof.WithFields(goof.Fields{
+			"expected": expected,
+			"actual":   actual,
+			"file":     idmapdConfFile,
+		}, "idmap domain mismatch")
+	}
+	return nil
+}
+
+// readIdmapDomain returns the Domain of the General section of an
+// idmapd.conf file.
+func readIdmapDomain(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	var section string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.ToLower(strings.Trim(line, "[]"))
+			continue
+		}
+		if section != "general" {
+			continue
+		}
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		if strings.EqualFold(strings.TrimSpace(kv[0]), "Domain") {
+			return strings.TrimSpace(kv[1]), nil
+		}
+	}
+	return "", scanner.Err()
+}
+
+// isProcessRunning returns a flag indicating whether a process with the
+// specified command name is running.
+func isProcessRunning(name string) (bool, error) {
+	comms, err := filepath.Glob("/proc/[0-9]*/comm")
+	if err != nil {
+		return false, err
+	}
+	for _, comm := range comms {
+		buf, err := ioutil.ReadFile(comm)
+		if err != nil {
+			// the process exited
+			continue
+		}
+		if strings.TrimSpace(string(buf)) == name {
+			return true, nil
+		}
+	}
+	return false, nil
+}
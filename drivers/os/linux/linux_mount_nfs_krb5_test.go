@@ -0,0 +1,71 @@
+// +build linux
+
+package linux
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNFSSecOption(t *testing.T) {
+	tests := []struct {
+		options string
+		sec     string
+	}{
+		{"", ""},
+		{"vers=4.1,hard", ""},
+		{"sec=krb5", "krb5"},
+		{"vers=4.1,sec=krb5i,ro", "krb5i"},
+		{"sec=krb5:krb5p", "krb5:krb5p"},
+		{"sec=sys,sec=krb5p", "krb5p"},
+		{"nosec=krb5", ""},
+		{"sec=", ""},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.sec, nfsSecOption(tt.options), tt.options)
+	}
+}
+
+func TestReadIdmapDomain(t *testing.T) {
+	dir, err := ioutil.TempDir("", "idmapd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		conf   string
+		domain string
+	}{
+		{"", ""},
+		{"[General]\nDomain = example.com\n", "example.com"},
+		{"[general]\ndomain=example.com", "example.com"},
+		{"[General]\nVerbosity = 0\n\n  Domain =  example.com  \n",
+			"example.com"},
+		{"[General]\n# Domain = commented.com\n; Domain = commented.com\n",
+			""},
+		{"[Mapping]\nDomain = mapping.com\n[General]\nDomain = example.com\n",
+			"example.com"},
+		{"[General]\nDomain = example.com\n[Mapping]\nDomain = mapping.com\n",
+			"example.com"},
+		{"[Mapping]\nDomain = mapping.com\n", ""},
+		{"Domain = nosection.com\n", ""},
+		{"[General]\nDomain\n", ""},
+	}
+	for _, tt := range tests {
+		conf := path.Join(dir, "idmapd.conf")
+		if err := ioutil.WriteFile(conf, []byte(tt.conf), 0644); err != nil {
+			t.Fatal(err)
+		}
+		domain, err := readIdmapDomain(conf)
+		assert.NoError(t, err, tt.conf)
+		assert.Equal(t, tt.domain, domain, tt.conf)
+	}
+
+	_, err = readIdmapDomain(path.Join(dir, "missing.conf"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,45 @@
+// +build linux
+
+package linux
+
+import (
+	"testing"
+
+	gofigCore "github.com/akutz/gofig"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codedellemc/libstorage/api/types"
+)
+
+func TestNFSMountOptions(t *testing.T) {
+	tests := []struct {
+		defaultOptions string
+		sec            string
+		opts           *types.DeviceMountOpts
+		options        string
+	}{
+		{"", "", nil, ""},
+		{"", "", &types.DeviceMountOpts{}, ""},
+		{"vers=4.1", "", nil, "vers=4.1"},
+		{"", "krb5", nil, "sec=krb5"},
+		{"", "", &types.DeviceMountOpts{ReadOnly: true}, "ro"},
+		{"", "", &types.DeviceMountOpts{MountOptions: "noatime"}, "noatime"},
+		{
+			"vers=4.1,hard", "krb5p",
+			&types.DeviceMountOpts{MountOptions: "noatime", ReadOnly: true},
+			"vers=4.1,hard,sec=krb5p,noatime,ro",
+		},
+		{
+			"", "",
+			&types.DeviceMountOpts{MountLabel: "system_u:object_r:svirt"},
+			"",
+		},
+	}
+	for _, tt := range tests {
+		config := gofigCore.New()
+		config.Set("linux.nfs.defaultOptions", tt.defaultOptions)
+		config.Set("linux.nfs.sec", tt.sec)
+		config.Set("linux.selinux.enabled", false)
+		assert.Equal(t, tt.options, nfsMountOptions(config, tt.opts))
+	}
+}
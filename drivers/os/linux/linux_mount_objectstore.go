@@ -0,0 +1,174 @@
+// +build linux
+
+package linux
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	gofig "github.com/akutz/gofig/types"
+	"github.com/akutz/goof"
+
+	"github.com/codedellemc/libstorage/api/types"
+)
+
+const objectstoreDevicePrefix = "objectstore://"
+
+func init() {
+	registerMountHandler(&objectstoreMountHandler{})
+}
+
+// objectstoreMountHandler mounts the buckets of S3-compatible object stores,
+// such as the buckets provided by the object store storage driver, using
+// s3fs-fuse or goofys. Object store devices are formatted as
+// objectstore://host/bucket?mounter=m&region=r[&pathStyle=true]
+// [&scheme=http], where m is either s3fs or goofys.
+type objectstoreMountHandler struct{}
+
+// objectstoreDevice is a parsed object store device.
+type objectstoreDevice struct {
+	endpoint  string
+	bucket    string
+	mounter   string
+	region    string
+	pathStyle bool
+}
+
+func (h *objectstoreMountHandler) Name() string {
+	return "objectstore"
+}
+
+func (h *objectstoreMountHandler) Matches(deviceName string) bool {
+	return strings.HasPrefix(deviceName, objectstoreDevicePrefix)
+}
+
+// MountSource returns the source with which the bucket appears in the mount
+// table once mounted. Both FUSE clients are given the bucket's name as the
+// file system name.
+func (h *objectstoreMountHandler) MountSource(deviceName string) string {
+	dev, err := parseObjectstoreDevice(deviceName)
+	if err != nil {
+		return deviceName
+	}
+	return dev.bucket
+}
+
+func (h *objectstoreMountHandler) Mount(
+	ctx types.Context,
+	config gofig.Config,
+	deviceName, mountPoint string,
+	opts *types.DeviceMountOpts) error {
+
+	dev, err := parseObjectstoreDevice(deviceName)
+	if err != nil {
+		return err
+	}
+
+	var options []string
+	if v := config.GetString("linux.objectstore.defaultOptions"); v != "" {
+		options = append(options, v)
+	}
+
+	var fsType, source string
+	switch dev.mounter {
+	case "s3fs":
+		fsType = "fuse.s3fs"
+		source = dev.bucket
+		options = append(options,
+			fmt.Sprintf("fsname=%s", dev.bucket),
+			fmt.Sprintf("url=%s", dev.endpoint),
+			fmt.Sprintf("endpoint=%s", dev.region))
+		if dev.pathStyle {
+			options = append(options, "use_path_request_style")
+		}
+		if v := config.GetString("linux.objectstore.passwdFile"); v != "" {
+			options = append(options, fmt.Sprintf("passwd_file=%s", v))
+		}
+		if v := config.GetString("linux.objectstore.iamRole"); v != "" {
+			options = append(options, fmt.Sprintf("iam_role=%s", v))
+		}
+	case "goofys":
+		// mount.fuse executes the command before the number sign with the
+		// bucket as its first argument and passes the options prefixed
+		// with two hyphens to goofys as flags
+		fsType = "fuse"
+		source = fmt.Sprintf("goofys#%s", dev.bucket)
+		options = append(options,
+			fmt.Sprintf("--endpoint=%s", dev.endpoint),
+			fmt.Sprintf("--region=%s", dev.region))
+		if !dev.pathStyle {
+			options = append(options, "--subdomain")
+		}
+		if v := config.GetString("linux.objectstore.profile"); v != "" {
+			options = append(options, fmt.Sprintf("--profile=%s", v))
+		}
+	default:
+		return goof.WithFields(goof.Fields{
+			"deviceName": deviceName,
+			"mounter":    dev.mounter,
+		}, "invalid object store mounter")
+	}
+
+	if opts != nil && opts.MountOptions != "" {
+		options = append(options, opts.MountOptions)
+	}
+	if opts != nil && opts.ReadOnly {
+		options = append(options, "ro")
+	}
+	var mountLabel string
+	if opts != nil {
+		mountLabel = opts.MountLabel
+	}
+
+	args := []string{
+		"-t", fsType,
+		"-o", mountLabelOptions(
+			config, strings.Join(options, ","), mountLabel),
+		source,
+		mountPoint,
+	}
+
+	ctx.WithField("args", args).Debug("mounting object store bucket")
+
+	return execMount(
+		ctx, config, h.Name(), deviceName, mountPoint, args...)
+}
+
+func (h *objectstoreMountHandler) Unmount(
+	ctx types.Context,
+	config gofig.Config,
+	mountPoint string,
+	opts types.Store) error {
+
+	return unmountWithOpts(mountPoint, opts)
+}
+
+// parseObjectstoreDevice parses an object store device.
+func parseObjectstoreDevice(deviceName string) (*objectstoreDevice, error) {
+	u, err := url.Parse(deviceName)
+	if err != nil {
+		return nil, goof.WithFieldE(
+			"deviceName", deviceName, "invalid object store device", err)
+	}
+
+	bucket := strings.Trim(u.Path, "/")
+	if u.Host == "" || bucket == "" || strings.Contains(bucket, "/") {
+		return nil, goof.WithField(
+			"deviceName", deviceName, "invalid object store device")
+	}
+
+	q := u.Query()
+	scheme := q.Get("scheme")
+	if scheme == "" {
+		scheme = "https"
+	}
+
+	return &objectstoreDevice{
+		endpoint:  fmt.Sprintf("%s://%s", scheme, u.Host),
+		bucket:    bucket,
+		mounter:   q.Get("mounter"),
+		region:    q.Get("region"),
+		pathStyle: q.Get("pathStyle") == "true",
+	}, nil
+}
@@ -0,0 +1,179 @@
+// +build linux
+
+package linux
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/akutz/goof"
+
+	"github.com/codedellemc/libstorage/api/types"
+)
+
+// prepareMountPoint verifies that a device may be mounted at the mount point
+// and creates the mount point if it does not exist. The mount point must be
+// beneath one of the roots configured with linux.volume.allowedMountRoots,
+// after any symbolic links are resolved, and an existing directory must be
+// empty unless the allowNonEmpty option is set.
+func (d *driver) prepareMountPoint(
+	ctx types.Context,
+	deviceName, mountPoint string,
+	opts *types.DeviceMountOpts) error {
+
+	if !filepath.IsAbs(mountPoint) {
+		return goof.WithField(
+			"mountPoint", mountPoint, "mount point is not an absolute path")
+	}
+	mountPoint = filepath.Clean(mountPoint)
+
+	if err := d.checkMountRoot(mountPoint); err != nil {
+		return err
+	}
+
+	// a file may only be bind mounted over a file
+	wantDir := true
+	if opts.Bind {
+		if fi, err := os.Stat(deviceName); err == nil && !fi.IsDir() {
+			wantDir = false
+		}
+	}
+
+	fi, err := os.Stat(mountPoint)
+	if os.IsNotExist(err) {
+		return d.createMountPoint(ctx, mountPoint, wantDir)
+	}
+	if err != nil {
+		return goof.WithFieldE(
+			"mountPoint", mountPoint, "error inspecting mount point", err)
+	}
+
+	if fi.IsDir() != wantDir {
+		return goof.WithFields(goof.Fields{
+			"deviceName": deviceName,
+			"mountPoint": mountPoint,
+		}, "mount point type does not match device")
+	}
+
+	if !wantDir || (opts.Opts != nil && opts.Opts.GetBool("allowNonEmpty")) {
+		return nil
+	}
+
+	empty, err := isEmptyDir(mountPoint)
+	if err != nil {
+		return goof.WithFieldE(
+			"mountPoint", mountPoint, "error reading mount point", err)
+	}
+	if !empty {
+		return goof.WithField(
+			"mountPoint", mountPoint, "mount point is not empty")
+	}
+	return nil
+}
+
+func (d *driver) createMountPoint(
+	ctx types.Context, mountPoint string, dir bool) error {
+
+	mode := os.FileMode(d.config.GetInt("linux.volume.mountPointMode"))
+
+	ctx.WithFields(log.Fields{
+		"mountPoint": mountPoint,
+		"mode":       mode,
+	}).Debug("creating mount point")
+
+	if dir {
+		if err := os.MkdirAll(mountPoint, mode); err != nil {
+			return goof.WithFieldE(
+				"mountPoint", mountPoint, "error creating mount point", err)
+		}
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(mountPoint), mode); err != nil {
+		return goof.WithFieldE(
+			"mountPoint", mountPoint, "error creating mount point", err)
+	}
+	f, err := os.OpenFile(mountPoint, os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return goof.WithFieldE(
+			"mountPoint", mountPoint, "error creating mount point", err)
+	}
+	return f.Close()
+}
+
+// checkMountRoot returns an error if the mount point, with any symbolic links
+// in its existing ancestors resolved, is not beneath one of the allowed mount
+// roots. Any mount point is allowed if no roots are configured.
+func (d *driver) checkMountRoot(mountPoint string) error {
+	roots := d.config.GetStringSlice("linux.volume.allowedMountRoots")
+	if len(roots) == 0 {
+		return nil
+	}
+
+	realPath, err := resolvePath(mountPoint)
+	if err != nil {
+		return goof.WithFieldE(
+			"mountPoint", mountPoint, "error resolving mount point", err)
+	}
+
+	for _, root := range roots {
+		if root == "" {
+			continue
+		}
+		realRoot, err := resolvePath(filepath.Clean(root))
+		if err != nil {
+			continue
+		}
+		if isBeneath(realPath, realRoot) {
+			return nil
+		}
+	}
+
+	return goof.WithFields(goof.Fields{
+		"mountPoint": mountPoint,
+		"realPath":   realPath,
+		"roots":      roots,
+	}, "mount point not beneath an allowed mount root")
+}
+
+// resolvePath resolves the symbolic links of the longest existing ancestor of
+// a clean, absolute path and returns the result joined with the rest of the
+// path.
+func resolvePath(path string) (string, error) {
+	existing, rest := path, ""
+	for {
+		if _, err := os.Lstat(existing); err == nil {
+			break
+		} else if !os.IsNotExist(err) {
+			return "", err
+		}
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			break
+		}
+		rest = filepath.Join(filepath.Base(existing), rest)
+		existing = parent
+	}
+
+	realPath, err := filepath.EvalSymlinks(existing)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(realPath, rest), nil
+}
+
+func isEmptyDir(path string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	if _, err := f.Readdirnames(1); err == io.EOF {
+		return true, nil
+	} else if err != nil {
+		return false, err
+	}
+	return false, nil
+}
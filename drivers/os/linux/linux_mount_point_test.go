@@ -0,0 +1,160 @@
+// +build linux
+
+package linux
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+
+	gofigCore "github.com/akutz/gofig"
+	"github.com/stretchr/testify/assert"
+)
+
+// newMountRootsDir returns a directory with the following layout, with any
+// symbolic links in the directory's own path resolved:
+//
+//	allowed/
+//	allowed/a/
+//	allowed/up -> ..
+//	allowed/out -> ../other
+//	allowed/in -> a
+//	other/
+//	roots -> allowed
+func newMountRootsDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "mountroots")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dir, err = filepath.EvalSymlinks(dir); err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range []string{"allowed/a", "other"} {
+		if err := os.MkdirAll(path.Join(dir, d), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	links := []struct{ oldname, newname string }{
+		{"..", "allowed/up"},
+		{"../other", "allowed/out"},
+		{"a", "allowed/in"},
+		{"allowed", "roots"},
+	}
+	for _, l := range links {
+		if err := os.Symlink(l.oldname, path.Join(dir, l.newname)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestResolvePath(t *testing.T) {
+	dir := newMountRootsDir(t)
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		path     string
+		realPath string
+	}{
+		{"/", "/"},
+		{"allowed", "allowed"},
+		{"allowed/a", "allowed/a"},
+		{"allowed/a/new/dir", "allowed/a/new/dir"},
+		{"allowed/in", "allowed/a"},
+		{"allowed/in/new", "allowed/a/new"},
+		{"allowed/up", ""},
+		{"allowed/up/other/new", "other/new"},
+		{"allowed/out/new", "other/new"},
+		{"roots/a", "allowed/a"},
+		{"missing/new", "missing/new"},
+	}
+	for _, tt := range tests {
+		p, expected := tt.path, tt.realPath
+		if p != "/" {
+			p, expected = path.Join(dir, p), path.Join(dir, expected)
+		}
+		realPath, err := resolvePath(p)
+		assert.NoError(t, err, tt.path)
+		assert.Equal(t, expected, realPath, tt.path)
+	}
+}
+
+func TestIsBeneath(t *testing.T) {
+	tests := []struct {
+		path    string
+		root    string
+		beneath bool
+	}{
+		{"/mnt", "/mnt", true},
+		{"/mnt/a", "/mnt", true},
+		{"/mnt/a/b", "/mnt", true},
+		{"/mnt/a", "/mnt/a", true},
+		{"/mntx", "/mnt", false},
+		{"/mnt", "/mnt/a", false},
+		{"/etc", "/mnt", false},
+		{"/etc", "/", true},
+		{"/", "/", true},
+		{"/", "/mnt", false},
+		{"", "/mnt", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.beneath, isBeneath(tt.path, tt.root),
+			"%s beneath %s", tt.path, tt.root)
+	}
+}
+
+func TestCheckMountRoot(t *testing.T) {
+	dir := newMountRootsDir(t)
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		roots      []string
+		mountPoint string
+		allowed    bool
+	}{
+		{nil, "other/new", true},
+		{[]string{"allowed"}, "allowed", true},
+		{[]string{"allowed"}, "allowed/a", true},
+		{[]string{"allowed"}, "allowed/a/new", true},
+		{[]string{"allowed"}, "allowed/in/new", true},
+		{[]string{"allowed"}, "other", false},
+		{[]string{"allowed"}, "other/new", false},
+		{[]string{"allowed"}, "allowedx", false},
+		{[]string{"allowed"}, "allowed/../other", false},
+		{[]string{"allowed"}, "allowed/a/../../other/new", false},
+		{[]string{"allowed"}, "allowed/new/../../other/new", false},
+		{[]string{"allowed"}, "allowed/a/../new", true},
+		{[]string{"allowed"}, "allowed/up/other", false},
+		{[]string{"allowed"}, "allowed/out", false},
+		{[]string{"allowed"}, "allowed/out/new", false},
+		{[]string{"roots"}, "allowed/a", true},
+		{[]string{"roots"}, "roots/a/new", true},
+		{[]string{"roots"}, "roots/out/new", false},
+		{[]string{"allowed/a"}, "allowed/in/new", true},
+		{[]string{"allowed/in"}, "allowed/a", true},
+		{[]string{"missing", "allowed"}, "allowed/a", true},
+		{[]string{"", "other"}, "allowed/out/new", true},
+	}
+	for _, tt := range tests {
+		config := gofigCore.New()
+		if tt.roots != nil {
+			roots := make([]string, len(tt.roots))
+			for i, r := range tt.roots {
+				if r != "" {
+					roots[i] = path.Join(dir, r)
+				}
+			}
+			config.Set("linux.volume.allowedMountRoots", roots)
+		}
+		d := &driver{config: config}
+		mountPoint := dir + "/" + tt.mountPoint
+		err := d.checkMountRoot(mountPoint)
+		if tt.allowed {
+			assert.NoError(t, err, tt.mountPoint)
+		} else {
+			assert.Error(t, err, tt.mountPoint)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+// +build linux
+
+package linux
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/akutz/goof"
+
+	"github.com/codedellemc/libstorage/api/utils"
+)
+
+// from github.com/docker/docker/daemon/graphdriver/devmapper/
+// this should be abstracted outside of graphdriver but within Docker package,
+// here temporarily
+type probeData struct {
+	fsName string
+	magic  string
+	offset uint64
+}
+
+const (
+	// luksFsName is the name of the type reported for LUKS devices.
+	luksFsName = "crypto_LUKS"
+
+	// extSuperblockOffset is the offset of the ext2/3/4 superblock.
+	extSuperblockOffset = 0x400
+)
+
+var probes = []probeData{
+	{luksFsName, "LUKS\xba\xbe", 0},
+	{"btrfs", "_BHRfS_M", 0x10040},
+	{"ext", "\123\357", 0x438},
+	{"f2fs", "\x10\x20\xf5\xf2", 0x400},
+	{"xfs", "XFSB", 0},
+}
+
+// probeFsType returns the type of the file system on the specified device.
+// If none of the known signatures match then blkid is consulted when it is
+// available. An ErrEncryptedDevice error is returned for LUKS devices.
+func probeFsType(device string) (string, error) {
+	fsType, err := probeFsMagic(device)
+	if err == errUnknownFileSystem {
+		fsType, err = probeFsBlkid(device)
+	}
+	if err != nil {
+		return "", err
+	}
+	if fsType == luksFsName {
+		return "", utils.NewEncryptedDeviceError(device)
+	}
+	return fsType, nil
+}
+
+// probeFsMagic returns the type of the file system on the specified device by
+// comparing the device's contents to known file system signatures.
+func probeFsMagic(device string) (string, error) {
+	maxLen := uint64(0)
+	for _, p := range probes {
+		l := p.offset + uint64(len(p.magic))
+		if l > maxLen {
+			maxLen = l
+		}
+	}
+
+	file, err := os.Open(device)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	buffer := make([]byte, maxLen)
+	if _, err := io.ReadFull(file, buffer); err != nil {
+		return "", goof.WithFieldE(
+			"device", device, "error detecting filesystem", err)
+	}
+
+	for _, p := range probes {
+		if bytes.Equal(
+			[]byte(p.magic), buffer[p.offset:p.offset+uint64(len(p.magic))]) {
+			if p.fsName == "ext" {
+				return extFsType(buffer[extSuperblockOffset:]), nil
+			}
+			return p.fsName, nil
+		}
+	}
+
+	return "", errUnknownFileSystem
+}
+
+// extFsType returns ext2, ext3, or ext4 based on the feature flags in the
+// specified ext superblock.
+func extFsType(sb []byte) string {
+	var (
+		compat   = binary.LittleEndian.Uint32(sb[0x5c:])
+		incompat = binary.LittleEndian.Uint32(sb[0x60:])
+		roCompat = binary.LittleEndian.Uint32(sb[0x64:])
+	)
+
+	const (
+		// compatHasJournal is EXT3_FEATURE_COMPAT_HAS_JOURNAL.
+		compatHasJournal = 0x4
+
+		// incompatExt4 is the union of EXT4_FEATURE_INCOMPAT_EXTENTS,
+		// EXT4_FEATURE_INCOMPAT_64BIT, and EXT4_FEATURE_INCOMPAT_FLEX_BG.
+		incompatExt4 = 0x40 | 0x80 | 0x200
+
+		// roCompatExt4 is the union of EXT4_FEATURE_RO_COMPAT_HUGE_FILE,
+		// EXT4_FEATURE_RO_COMPAT_GDT_CSUM, EXT4_FEATURE_RO_COMPAT_DIR_NLINK,
+		// EXT4_FEATURE_RO_COMPAT_EXTRA_ISIZE, and
+		// EXT4_FEATURE_RO_COMPAT_METADATA_CSUM.
+		roCompatExt4 = 0x8 | 0x10 | 0x20 | 0x40 | 0x400
+	)
+
+	switch {
+	case incompat&incompatExt4 != 0 || roCompat&roCompatExt4 != 0:
+		return "ext4"
+	case compat&compatHasJournal != 0:
+		return "ext3"
+	default:
+		return "ext2"
+	}
+}
+
+// probeFsBlkid returns the type of the file system on the specified device as
+// reported by blkid. An errUnknownFileSystem error is returned if blkid is not
+// available or cannot identify the device's contents.
+func probeFsBlkid(device string) (string, error) {
+	blkid, err := exec.LookPath("blkid")
+	if err != nil {
+		return "", errUnknownFileSystem
+	}
+	out, err := exec.Command(
+		blkid, "-o", "value", "-s", "TYPE", device).Output()
+	if err != nil {
+		return "", errUnknownFileSystem
+	}
+	fsType := strings.TrimSpace(string(out))
+	if fsType == "" {
+		return "", errUnknownFileSystem
+	}
+	return fsType, nil
+}
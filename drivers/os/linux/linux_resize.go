@@ -0,0 +1,79 @@
+// +build linux
+
+package linux
+
+import (
+	"os/exec"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/akutz/goof"
+
+	"github.com/codedellemc/libstorage/api/types"
+)
+
+// growFs resizes the file system on the specified device so that it fills the
+// entire device. The ext file systems may be grown whether or not they are
+// mounted, but xfs and btrfs can only be grown while mounted.
+func (d *driver) growFs(
+	ctx types.Context,
+	deviceName, fsType, mountPoint string) error {
+
+	var args []string
+
+	switch fsType {
+	case "ext2", "ext3", "ext4":
+		args = []string{"resize2fs", deviceName}
+	case "xfs", "btrfs":
+		if mountPoint == "" {
+			var err error
+			if mountPoint, err = deviceMountPoint(deviceName); err != nil {
+				return err
+			}
+		}
+		if mountPoint == "" {
+			return goof.WithFields(goof.Fields{
+				"deviceName": deviceName,
+				"fsType":     fsType,
+			}, "file system must be mounted to be grown")
+		}
+		if fsType == "xfs" {
+			args = []string{"xfs_growfs", mountPoint}
+		} else {
+			args = []string{"btrfs", "filesystem", "resize", "max", mountPoint}
+		}
+	default:
+		return errUnsupportedFileSystem
+	}
+
+	ctx.WithFields(log.Fields{
+		"deviceName": deviceName,
+		"fsType":     fsType,
+		"args":       args,
+	}).Info("growing filesystem")
+
+	out, err := exec.Command(args[0], args[1:]...).CombinedOutput()
+	if err != nil {
+		return goof.WithFieldsE(goof.Fields{
+			"deviceName": deviceName,
+			"fsType":     fsType,
+			"output":     string(out),
+		}, "error growing filesystem", err)
+	}
+
+	return nil
+}
+
+// deviceMountPoint returns the first path at which the specified device is
+// mounted or an empty string if the device is not mounted.
+func deviceMountPoint(deviceName string) (string, error) {
+	mounts, err := getMounts()
+	if err != nil {
+		return "", err
+	}
+	for _, m := range mounts {
+		if m.Source == deviceName {
+			return m.MountPoint, nil
+		}
+	}
+	return "", nil
+}
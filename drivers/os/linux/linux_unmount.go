@@ -0,0 +1,124 @@
+// +build linux
+
+package linux
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/akutz/goof"
+
+	"github.com/codedellemc/libstorage/api/types"
+)
+
+// unmountWithOpts unmounts the target file system, so long as it is mounted.
+//
+// The option "force" unmounts the file system with MNT_FORCE. The option
+// "lazy" detaches the file system with MNT_DETACH if it is still busy after
+// the regular unmount attempts are exhausted. If the file system remains busy
+// the IDs of the processes holding it are included in the returned error.
+func unmountWithOpts(target string, opts types.Store) error {
+	if mounted, err := mounted(target); err != nil || !mounted {
+		return err
+	}
+
+	var force, lazy bool
+	if opts != nil {
+		force = opts.GetBool("force")
+		lazy = opts.GetBool("lazy")
+	}
+
+	flag := 0
+	if force {
+		flag |= syscall.MNT_FORCE
+	}
+
+	err := forceUnmount(target, flag)
+	if err == syscall.EBUSY && lazy {
+		err = sysUnmount(target, flag|syscall.MNT_DETACH)
+	}
+	if err == nil {
+		return nil
+	}
+
+	fields := goof.Fields{
+		"mountPoint": target,
+		"force":      force,
+		"lazy":       lazy,
+	}
+	if err != syscall.EBUSY {
+		return goof.WithFieldsE(fields, "error unmounting", err)
+	}
+	if pids, perr := mountPointPIDs(target); perr == nil {
+		fields["pids"] = pids
+	}
+	return goof.WithFieldsE(fields, "mount point busy", err)
+}
+
+// mountPointPIDs returns the IDs of the processes with an open file or a
+// working directory beneath the specified mount point.
+func mountPointPIDs(mountPoint string) ([]int, error) {
+	procs, err := ioutil.ReadDir("/proc")
+	if err != nil {
+		return nil, err
+	}
+
+	pids := []int{}
+	for _, p := range procs {
+		pid, err := strconv.Atoi(p.Name())
+		if err != nil || !p.IsDir() {
+			continue
+		}
+		if procUsesPath(pid, mountPoint) {
+			pids = append(pids, pid)
+		}
+	}
+
+	sort.Ints(pids)
+	return pids, nil
+}
+
+// procUsesPath returns a flag indicating whether or not the specified process
+// has a working directory or an open file beneath the specified path.
+func procUsesPath(pid int, path string) bool {
+	procDir := filepath.Join("/proc", strconv.Itoa(pid))
+
+	if isBeneath(readLink(filepath.Join(procDir, "cwd")), path) {
+		return true
+	}
+
+	fdDir := filepath.Join(procDir, "fd")
+	fds, err := ioutil.ReadDir(fdDir)
+	if err != nil {
+		return false
+	}
+	for _, fd := range fds {
+		if isBeneath(readLink(filepath.Join(fdDir, fd.Name())), path) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func readLink(path string) string {
+	l, err := os.Readlink(path)
+	if err != nil {
+		return ""
+	}
+	return l
+}
+
+// isBeneath returns a flag indicating whether or not the path p is equal to
+// or beneath the path root.
+func isBeneath(p, root string) bool {
+	if p == "" {
+		return false
+	}
+	return p == root || strings.HasPrefix(p, strings.TrimSuffix(root, "/")+"/")
+}
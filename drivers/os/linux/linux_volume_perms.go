@@ -0,0 +1,104 @@
+// +build linux
+
+package linux
+
+import (
+	"os"
+	"path/filepath"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/akutz/goof"
+
+	"github.com/codedellemc/libstorage/api/types"
+)
+
+// volumePerms is the ownership and file mode applied to a volume's root path
+// after the volume is mounted.
+type volumePerms struct {
+	// uid is the ID of the user that owns the root path. A negative value
+	// leaves the owner unchanged.
+	uid int
+
+	// gid is the ID of the group that owns the root path. A negative value
+	// leaves the group unchanged.
+	gid int
+
+	// mode is the file mode of the root path.
+	mode os.FileMode
+
+	// recursive indicates that the ownership is also applied to everything
+	// beneath the root path.
+	recursive bool
+}
+
+// volumePerms returns the permission policy for a volume mounted with the
+// specified options. The options uid, gid, fileMode, and recursive override
+// the configured linux.volume.uid, linux.volume.gid, linux.volume.filemode,
+// and linux.volume.recursive properties respectively.
+func (d *driver) volumePerms(opts types.Store) *volumePerms {
+	p := &volumePerms{
+		uid:       d.config.GetInt("linux.volume.uid"),
+		gid:       d.config.GetInt("linux.volume.gid"),
+		mode:      d.fileModeMountPath(),
+		recursive: d.config.GetBool("linux.volume.recursive"),
+	}
+	if opts == nil {
+		return p
+	}
+	if opts.IsSet("uid") {
+		p.uid = opts.GetInt("uid")
+	}
+	if opts.IsSet("gid") {
+		p.gid = opts.GetInt("gid")
+	}
+	if opts.IsSet("fileMode") {
+		p.mode = os.FileMode(opts.GetInt("fileMode"))
+	}
+	if opts.IsSet("recursive") {
+		p.recursive = opts.GetBool("recursive")
+	}
+	return p
+}
+
+// apply creates the root path if necessary and applies the permission policy
+// to it. A file mode that cannot be set, as on an NFS export that squashes
+// root, is logged rather than failing the mount; an ownership that cannot be
+// set is an error since the policy was requested explicitly.
+func (p *volumePerms) apply(ctx types.Context, rootPath string) error {
+	os.MkdirAll(rootPath, p.mode)
+	if err := os.Chmod(rootPath, p.mode); err != nil {
+		ctx.WithFields(log.Fields{
+			"path":  rootPath,
+			"mode":  p.mode,
+			"error": err,
+		}).Warn("error setting file mode")
+	}
+
+	if p.uid < 0 && p.gid < 0 {
+		return nil
+	}
+
+	if !p.recursive {
+		return p.chown(rootPath)
+	}
+
+	return filepath.Walk(
+		rootPath,
+		func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			return p.chown(path)
+		})
+}
+
+func (p *volumePerms) chown(path string) error {
+	if err := os.Lchown(path, p.uid, p.gid); err != nil {
+		return goof.WithFieldsE(goof.Fields{
+			"path": path,
+			"uid":  p.uid,
+			"gid":  p.gid,
+		}, "error setting owner", err)
+	}
+	return nil
+}
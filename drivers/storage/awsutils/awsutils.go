@@ -0,0 +1,32 @@
+// Package awsutils provides functions shared by the storage drivers for
+// Amazon Web Services.
+package awsutils
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/request"
+
+	"github.com/codedellemc/libstorage/api/types"
+)
+
+// WithContext returns a copy of an AWS service client whose requests are
+// cancelled, and no longer retried, once the context is cancelled, such as
+// when the deadline of the operation that sends them expires. The client
+// itself is returned if the context can never be cancelled.
+func WithContext(ctx types.Context, c *client.Client) *client.Client {
+	if ctx == nil || ctx.Done() == nil {
+		return c
+	}
+	cc := *c
+	cc.Handlers = cc.Handlers.Copy()
+	cc.Handlers.Send.PushFront(func(r *request.Request) {
+		r.HTTPRequest.Cancel = ctx.Done()
+	})
+	cc.Handlers.AfterRetry.PushFront(func(r *request.Request) {
+		if ctx.Err() != nil {
+			r.Retryable = aws.Bool(false)
+		}
+	})
+	return &cc
+}
@@ -0,0 +1,276 @@
+package awsutils
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/akutz/goof"
+)
+
+var (
+	transportLock sync.RWMutex
+	transport     http.RoundTripper
+)
+
+// scrubbedParams are the query parameters of presigned requests that carry
+// credentials or signatures. They are removed from the recorded requests,
+// and from the requests matched against them, so that a cassette may be
+// committed.
+var scrubbedParams = []string{
+	"X-Amz-Credential",
+	"X-Amz-Security-Token",
+	"X-Amz-Signature",
+	"AWSAccessKeyId",
+	"Signature",
+}
+
+// scrubbedHeaders are the response headers that are not recorded.
+var scrubbedHeaders = []string{
+	"Set-Cookie",
+	"X-Amz-Security-Token",
+}
+
+// HTTPClient returns the HTTP client of the AWS service clients the drivers
+// create. The client uses the default transport unless a cassette is in use.
+func HTTPClient() *http.Client {
+	transportLock.RLock()
+	defer transportLock.RUnlock()
+	if transport == nil {
+		return http.DefaultClient
+	}
+	return &http.Client{Transport: transport}
+}
+
+// UseCassette makes the AWS service clients the drivers create send their
+// requests with a Recorder for the cassette at the specified path, so that
+// tests may be run against recorded AWS responses. Requests are recorded to
+// the cassette if record is true and replayed from it otherwise. The returned
+// function saves the cassette, if recording, and restores the default
+// transport.
+func UseCassette(path string, record bool) (func() error, error) {
+	r, err := NewRecorder(path, record)
+	if err != nil {
+		return nil, err
+	}
+
+	transportLock.Lock()
+	transport = r
+	transportLock.Unlock()
+
+	return func() error {
+		transportLock.Lock()
+		transport = nil
+		transportLock.Unlock()
+		return r.Save()
+	}, nil
+}
+
+// Interaction is a request and its response recorded to a cassette.
+type Interaction struct {
+	Request  *InteractionRequest  `json:"request"`
+	Response *InteractionResponse `json:"response"`
+}
+
+// InteractionRequest is a recorded request.
+type InteractionRequest struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	Body   string `json:"body,omitempty"`
+}
+
+// InteractionResponse is a recorded response.
+type InteractionResponse struct {
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+}
+
+// Recorder is an http.RoundTripper that records requests and their responses
+// to a cassette or replays the responses from it.
+//
+// A request is replayed with the first unused interaction that has the same
+// method, path, query, and body. The scheme and host are ignored so that a
+// cassette recorded against one endpoint or region may be replayed for
+// another. Identical requests, such as those that poll for the state of a
+// resource, are replayed in the order in which they were recorded.
+type Recorder struct {
+	sync.Mutex
+	path         string
+	record       bool
+	transport    http.RoundTripper
+	interactions []*Interaction
+	used         []bool
+}
+
+// NewRecorder returns a new Recorder for the cassette at the specified path.
+// The cassette must exist unless requests are recorded.
+func NewRecorder(path string, record bool) (*Recorder, error) {
+	r := &Recorder{
+		path:      path,
+		record:    record,
+		transport: http.DefaultTransport,
+	}
+	if record {
+		return r, nil
+	}
+
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, goof.WithFieldE("path", path, "error reading cassette", err)
+	}
+	if err := json.Unmarshal(buf, &r.interactions); err != nil {
+		return nil, goof.WithFieldE("path", path, "invalid cassette", err)
+	}
+	r.used = make([]bool, len(r.interactions))
+	return r, nil
+}
+
+// RoundTrip records or replays a request.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	ir := &InteractionRequest{Method: req.Method, URL: scrubURL(req.URL)}
+	if req.Body != nil {
+		buf, err := ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		ir.Body = string(buf)
+		req.Body = ioutil.NopCloser(bytes.NewReader(buf))
+	}
+
+	if r.record {
+		return r.recordRequest(req, ir)
+	}
+	return r.replayRequest(req, ir)
+}
+
+func (r *Recorder) recordRequest(
+	req *http.Request, ir *InteractionRequest) (*http.Response, error) {
+
+	res, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	buf, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = ioutil.NopCloser(bytes.NewReader(buf))
+
+	header := http.Header{}
+	for k, v := range res.Header {
+		header[k] = v
+	}
+	for _, k := range scrubbedHeaders {
+		header.Del(k)
+	}
+
+	r.Lock()
+	defer r.Unlock()
+	r.interactions = append(r.interactions, &Interaction{
+		Request: ir,
+		Response: &InteractionResponse{
+			StatusCode: res.StatusCode,
+			Header:     header,
+			Body:       string(buf),
+		},
+	})
+	return res, nil
+}
+
+func (r *Recorder) replayRequest(
+	req *http.Request, ir *InteractionRequest) (*http.Response, error) {
+
+	r.Lock()
+	defer r.Unlock()
+
+	for i, in := range r.interactions {
+		if r.used[i] || !matches(in.Request, ir) {
+			continue
+		}
+		r.used[i] = true
+		body := in.Response.Body
+		return &http.Response{
+			Status:        http.StatusText(in.Response.StatusCode),
+			StatusCode:    in.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        in.Response.Header,
+			Body:          ioutil.NopCloser(bytes.NewBufferString(body)),
+			ContentLength: int64(len(body)),
+			Request:       req,
+		}, nil
+	}
+
+	return nil, goof.WithFields(goof.Fields{
+		"method":   ir.Method,
+		"url":      ir.URL,
+		"cassette": r.path,
+	}, "no recorded interaction")
+}
+
+// Save writes the recorded interactions to the cassette. Save does nothing
+// if requests are replayed.
+func (r *Recorder) Save() error {
+	if !r.record {
+		return nil
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	buf, err := json.MarshalIndent(r.interactions, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(r.path, buf, 0644)
+}
+
+// scrubURL returns a URL without the query parameters that carry
+// credentials or signatures.
+func scrubURL(u *url.URL) string {
+	query := u.Query()
+	scrubbed := false
+	for k := range query {
+		for _, p := range scrubbedParams {
+			if strings.EqualFold(k, p) {
+				query.Del(k)
+				scrubbed = true
+			}
+		}
+	}
+	if !scrubbed {
+		return u.String()
+	}
+	su := *u
+	su.RawQuery = query.Encode()
+	return su.String()
+}
+
+func matches(recorded, req *InteractionRequest) bool {
+	if recorded.Method != req.Method || recorded.Body != req.Body {
+		return false
+	}
+	return requestURI(recorded.URL) == requestURI(req.URL)
+}
+
+// requestURI returns the path and query of a URL.
+func requestURI(rawurl string) string {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return rawurl
+	}
+	return u.RequestURI()
+}
@@ -0,0 +1,106 @@
+package awsutils
+
+import (
+	"net/url"
+	"os"
+
+	"github.com/akutz/goof"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/client/metadata"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/signer/v4"
+	"github.com/aws/aws-sdk-go/private/protocol/jsonrpc"
+
+	"github.com/codedellemc/libstorage/api/types"
+	"github.com/codedellemc/libstorage/api/utils/secrets"
+)
+
+const secretsManagerService = "secretsmanager"
+
+func init() {
+	secrets.Register("awssm", resolveSecretsManager)
+}
+
+type getSecretValueInput struct {
+	SecretId     *string `type:"string" required:"true"`
+	VersionStage *string `type:"string"`
+}
+
+type getSecretValueOutput struct {
+	SecretString *string `type:"string"`
+	SecretBinary []byte  `type:"blob"`
+}
+
+// resolveSecretsManager reads a secret from AWS Secrets Manager, ex.
+// ref+awssm://libstorage/s3?region=us-east-1#accessKey. The secret's region
+// defaults to AWS_REGION, its version stage may be set with the versionStage
+// query parameter, and the secret is read with the default AWS credentials.
+//
+// The SDK has no Secrets Manager client, so the GetSecretValue operation is
+// sent with a client built from the SDK's JSON-RPC protocol handlers.
+func resolveSecretsManager(ctx types.Context, ref *url.URL) (string, error) {
+	name := ref.Host + ref.Path
+	if name == "" {
+		return "", goof.New("awssm secret reference has no name")
+	}
+
+	query := ref.Query()
+	region := query.Get("region")
+	if region == "" {
+		region = os.Getenv("AWS_REGION")
+	}
+	if region == "" {
+		return "", goof.WithField(
+			"name", name, "awssm secret reference has no region")
+	}
+
+	sess := Session(&ClientConfig{
+		ConnectTimeout: DefaultConnectTimeout,
+		MaxRetries:     aws.UseServiceDefaultRetries,
+	})
+	cfg := sess.ClientConfig(
+		secretsManagerService, &aws.Config{Region: aws.String(region)})
+	c := client.New(
+		*cfg.Config,
+		metadata.ClientInfo{
+			ServiceName:   secretsManagerService,
+			SigningRegion: cfg.SigningRegion,
+			Endpoint:      cfg.Endpoint,
+			APIVersion:    "2017-10-17",
+			JSONVersion:   "1.1",
+			TargetPrefix:  "secretsmanager",
+		},
+		cfg.Handlers)
+	signer := v4.NewSigner(cfg.Config.Credentials)
+	c.Handlers.Sign.PushBack(func(r *request.Request) {
+		_, r.Error = signer.Sign(
+			r.HTTPRequest, r.Body,
+			secretsManagerService, cfg.SigningRegion, r.Time)
+	})
+	c.Handlers.Build.PushBack(jsonrpc.Build)
+	c.Handlers.Unmarshal.PushBack(jsonrpc.Unmarshal)
+	c.Handlers.UnmarshalMeta.PushBack(jsonrpc.UnmarshalMeta)
+	c.Handlers.UnmarshalError.PushBack(jsonrpc.UnmarshalError)
+
+	input := &getSecretValueInput{SecretId: aws.String(name)}
+	if stage := query.Get("versionStage"); stage != "" {
+		input.VersionStage = aws.String(stage)
+	}
+	output := &getSecretValueOutput{}
+
+	req := WithContext(ctx, c).NewRequest(&request.Operation{
+		Name:       "GetSecretValue",
+		HTTPMethod: "POST",
+		HTTPPath:   "/",
+	}, input, output)
+	if err := req.Send(); err != nil {
+		return "", goof.WithFieldE(
+			"name", name, "error reading awssm secret", err)
+	}
+
+	if output.SecretString != nil {
+		return *output.SecretString, nil
+	}
+	return string(output.SecretBinary), nil
+}
@@ -0,0 +1,159 @@
+package awsutils
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	gofig "github.com/akutz/gofig/types"
+	"github.com/akutz/goof"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+)
+
+const (
+	// ConfigHTTPTimeout is the suffix of the key of the duration after which
+	// a driver's requests to AWS time out, ex. efs.httpTimeout. Requests do
+	// not time out if the duration is empty or zero.
+	ConfigHTTPTimeout = "httpTimeout"
+
+	// ConfigConnectTimeout is the suffix of the key of the duration after
+	// which a driver's connection attempts to AWS time out.
+	ConfigConnectTimeout = "connectTimeout"
+
+	// ConfigProxy is the suffix of the key of the URL of the proxy through
+	// which a driver sends its requests to AWS. The proxy defaults to the one
+	// in HTTPS_PROXY or HTTP_PROXY.
+	ConfigProxy = "proxy"
+
+	// ConfigMaxRetries is the suffix of the key of the number of times a
+	// driver retries a failed request to AWS. A negative number is the
+	// default of the AWS service.
+	ConfigMaxRetries = "maxRetries"
+
+	// DefaultConnectTimeout is the default connect timeout.
+	DefaultConnectTimeout = 10 * time.Second
+)
+
+// ClientConfig is the configuration of the HTTP client with which a driver
+// sends its requests to AWS.
+type ClientConfig struct {
+	HTTPTimeout    time.Duration
+	ConnectTimeout time.Duration
+	Proxy          string
+	MaxRetries     int
+}
+
+// NewClientConfig returns the HTTP client configuration of the driver with
+// the given name.
+func NewClientConfig(
+	config gofig.Config, name string) (*ClientConfig, error) {
+
+	cc := &ClientConfig{
+		ConnectTimeout: DefaultConnectTimeout,
+		Proxy:          config.GetString(name + "." + ConfigProxy),
+		MaxRetries:     aws.UseServiceDefaultRetries,
+	}
+
+	if v := config.GetString(name + "." + ConfigHTTPTimeout); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, goof.WithFieldE(
+				"key", name+"."+ConfigHTTPTimeout, "invalid duration", err)
+		}
+		cc.HTTPTimeout = d
+	}
+	if v := config.GetString(name + "." + ConfigConnectTimeout); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, goof.WithFieldE(
+				"key", name+"."+ConfigConnectTimeout, "invalid duration", err)
+		}
+		cc.ConnectTimeout = d
+	}
+	if cc.Proxy != "" {
+		if _, err := url.Parse(cc.Proxy); err != nil {
+			return nil, goof.WithFieldE(
+				"key", name+"."+ConfigProxy, "invalid proxy URL", err)
+		}
+	}
+	if config.IsSet(name + "." + ConfigMaxRetries) {
+		cc.MaxRetries = config.GetInt(name + "." + ConfigMaxRetries)
+	}
+
+	return cc, nil
+}
+
+var (
+	sessions    = map[ClientConfig]*session.Session{}
+	sessionsRWL = &sync.RWMutex{}
+)
+
+// Session returns the session shared by the AWS service clients with the
+// given HTTP client configuration. The service clients of a session share
+// its connections and the settings it read from the environment when it was
+// created, so a session should be used for every service client rather than
+// a new session created for each.
+func Session(cc *ClientConfig) *session.Session {
+	sessionsRWL.RLock()
+	sess, ok := sessions[*cc]
+	sessionsRWL.RUnlock()
+	if ok {
+		return sess
+	}
+
+	sessionsRWL.Lock()
+	defer sessionsRWL.Unlock()
+	if sess, ok := sessions[*cc]; ok {
+		return sess
+	}
+
+	sess = session.New(&aws.Config{
+		HTTPClient: newHTTPClient(cc),
+		MaxRetries: aws.Int(cc.MaxRetries),
+	})
+	sessions[*cc] = sess
+	return sess
+}
+
+func newHTTPClient(cc *ClientConfig) *http.Client {
+	proxy := http.ProxyFromEnvironment
+	if cc.Proxy != "" {
+		// the URL is validated by NewClientConfig
+		u, _ := url.Parse(cc.Proxy)
+		proxy = http.ProxyURL(u)
+	}
+	return &http.Client{
+		Timeout: cc.HTTPTimeout,
+		Transport: &clientTransport{&http.Transport{
+			Proxy: proxy,
+			Dial: (&net.Dialer{
+				Timeout:   cc.ConnectTimeout,
+				KeepAlive: 30 * time.Second,
+			}).Dial,
+			TLSHandshakeTimeout: 10 * time.Second,
+			MaxIdleConnsPerHost: 16,
+		}},
+	}
+}
+
+// clientTransport sends requests with a cassette's Recorder when one is in
+// use, as HTTPClient does, so that the clients of shared sessions may be
+// tested against recorded AWS responses.
+type clientTransport struct {
+	*http.Transport
+}
+
+func (t *clientTransport) RoundTrip(
+	req *http.Request) (*http.Response, error) {
+
+	transportLock.RLock()
+	rt := transport
+	transportLock.RUnlock()
+	if rt != nil {
+		return rt.RoundTrip(req)
+	}
+	return t.Transport.RoundTrip(req)
+}
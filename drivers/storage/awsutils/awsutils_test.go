@@ -0,0 +1,132 @@
+package awsutils
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecorder(t *testing.T) {
+	var requests int32
+	s := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&requests, 1)
+			body, _ := ioutil.ReadAll(r.Body)
+			w.Header().Set("X-Request", string(body))
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(r.URL.Path))
+		}))
+	defer s.Close()
+
+	dir, err := ioutil.TempDir("", "awsutils")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "testdata", "cassette.json")
+
+	do := func(c *http.Client, host, path, body string) *http.Response {
+		res, err := c.Post(host+path, "text/plain", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+
+	stop, err := UseCassette(path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	do(HTTPClient(), s.URL, "/a", "1")
+	do(HTTPClient(), s.URL, "/a", "1")
+	do(HTTPClient(), s.URL, "/b", "2")
+	assert.NoError(t, stop())
+	assert.Equal(t, int32(3), atomic.LoadInt32(&requests))
+	assert.Equal(t, http.DefaultClient, HTTPClient())
+
+	stop, err = UseCassette(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stop()
+
+	// the host is ignored when a request is replayed
+	c := HTTPClient()
+	res := do(c, "http://elasticfilesystem.us-east-1.amazonaws.com", "/b", "2")
+	assert.Equal(t, http.StatusCreated, res.StatusCode)
+	assert.Equal(t, "2", res.Header.Get("X-Request"))
+	body, _ := ioutil.ReadAll(res.Body)
+	assert.Equal(t, "/b", string(body))
+
+	do(c, s.URL, "/a", "1")
+	do(c, s.URL, "/a", "1")
+	_, err = c.Post(s.URL+"/a", "text/plain", strings.NewReader("1"))
+	assert.Error(t, err)
+	_, err = c.Post(s.URL+"/b", "text/plain", strings.NewReader("3"))
+	assert.Error(t, err)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&requests))
+}
+
+func TestRecorderMissingCassette(t *testing.T) {
+	_, err := NewRecorder("testdata/missing.json", false)
+	assert.Error(t, err)
+}
+
+func TestRecorderScrub(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "s3cr3t"})
+			w.Header().Set("X-Amz-Request-Id", "1")
+			w.Write([]byte("ok"))
+		}))
+	defer s.Close()
+
+	dir, err := ioutil.TempDir("", "awsutils")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "cassette.json")
+
+	stop, err := UseCassette(path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := HTTPClient().Get(
+		s.URL + "/b/k?X-Amz-Credential=AKIA&X-Amz-Signature=abc&x=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NotEmpty(t, res.Header.Get("Set-Cookie"))
+	assert.NoError(t, stop())
+
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NotContains(t, string(buf), "AKIA")
+	assert.NotContains(t, string(buf), "abc")
+	assert.NotContains(t, string(buf), "s3cr3t")
+	assert.Contains(t, string(buf), "X-Amz-Request-Id")
+
+	stop, err = UseCassette(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stop()
+
+	// requests whose signatures differ from the recorded ones still match
+	res, err = HTTPClient().Get(
+		s.URL + "/b/k?X-Amz-Credential=AKIB&X-Amz-Signature=def&x=1")
+	if assert.NoError(t, err) {
+		body, _ := ioutil.ReadAll(res.Body)
+		assert.Equal(t, "ok", string(body))
+	}
+}
@@ -0,0 +1,37 @@
+package azurefile
+
+import (
+	gofigCore "github.com/akutz/gofig"
+	gofig "github.com/akutz/gofig/types"
+)
+
+const (
+	// Name is the provider's name.
+	Name = "azurefile"
+
+	// ConfigAccountName is a config key.
+	ConfigAccountName = Name + ".accountName"
+
+	// ConfigAccountKey is a config key.
+	ConfigAccountKey = Name + ".accountKey"
+
+	// ConfigEndpointSuffix is a config key.
+	ConfigEndpointSuffix = Name + ".endpointSuffix"
+
+	// ConfigDefaultQuota is a config key.
+	ConfigDefaultQuota = Name + ".defaultQuota"
+)
+
+func init() {
+	r := gofigCore.NewRegistration("Azure Files")
+	r.Key(gofig.String, "", "",
+		"The name of the storage account", ConfigAccountName)
+	r.Key(gofig.String, "", "",
+		"The access key of the storage account", ConfigAccountKey)
+	r.Key(gofig.String, "", "core.windows.net",
+		"The suffix of the storage account's endpoint", ConfigEndpointSuffix)
+	r.Key(gofig.Int, "", 5,
+		"The quota in GB of shares created without a size",
+		ConfigDefaultQuota)
+	gofigCore.Register(r)
+}
@@ -0,0 +1,445 @@
+package storage
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+
+	log "github.com/Sirupsen/logrus"
+	gofig "github.com/akutz/gofig/types"
+	"github.com/akutz/goof"
+
+	"github.com/codedellemc/libstorage/api/context"
+	"github.com/codedellemc/libstorage/api/registry"
+	"github.com/codedellemc/libstorage/api/types"
+	"github.com/codedellemc/libstorage/api/utils"
+	"github.com/codedellemc/libstorage/drivers/storage/azurefile"
+	azureUtils "github.com/codedellemc/libstorage/drivers/storage/azurefile/utils"
+)
+
+const (
+	// attachmentsKey is the name of the share metadata in which the share's
+	// attachments are recorded as a comma-separated list of instance IDs.
+	attachmentsKey = "libstorageattachments"
+
+	attachedStatus = "attached"
+)
+
+// shareNameRX matches a valid share name: three to sixty-three lowercase
+// letters, numbers, and single hyphens that begins and ends with a letter or
+// number.
+var shareNameRX = regexp.MustCompile(`^[a-z0-9](?:[a-z0-9]|-[a-z0-9]){2,62}$`)
+
+// shareClient manages the file shares of a storage account. It is
+// implemented by azureUtils.Client.
+type shareClient interface {
+	ListShares(ctx types.Context) ([]*azureUtils.Share, error)
+	GetShare(ctx types.Context, name string) (*azureUtils.Share, error)
+	CreateShare(ctx types.Context, name string, quota int64) error
+	SetShareQuota(ctx types.Context, name string, quota int64) error
+	SetShareMetadata(
+		ctx types.Context, name string, metadata map[string]string) error
+	DeleteShare(ctx types.Context, name string) error
+}
+
+// driver is a storage driver that manages volumes as the file shares of an
+// Azure storage account. The clients mount the shares over SMB with the
+// linux OS driver's CIFS mount handler.
+type driver struct {
+	sync.Mutex
+	config gofig.Config
+	client shareClient
+}
+
+func init() {
+	registry.RegisterStorageDriver(azurefile.Name, newDriver)
+}
+
+func newDriver() types.StorageDriver {
+	return &driver{}
+}
+
+// Name returns the name of the driver
+func (d *driver) Name() string {
+	return azurefile.Name
+}
+
+// Init initializes the driver.
+func (d *driver) Init(ctx types.Context, config gofig.Config) error {
+	d.config = config
+
+	fields := log.Fields{
+		"accountName":    d.accountName(),
+		"endpointSuffix": d.endpointSuffix(),
+		"defaultQuota":   d.defaultQuota(),
+	}
+
+	if d.accountName() == "" ||
+		d.config.GetString(azurefile.ConfigAccountKey) == "" {
+		return goof.WithFields(fields,
+			"azurefile.accountName and azurefile.accountKey are required")
+	}
+
+	client, err := azureUtils.NewClient(
+		d.accountName(),
+		d.config.GetString(azurefile.ConfigAccountKey),
+		d.endpointSuffix())
+	if err != nil {
+		return err
+	}
+	d.client = client
+
+	ctx.WithFields(fields).Info("storage driver initialized")
+	return nil
+}
+
+// Type returns the type of storage a driver provides
+func (d *driver) Type(ctx types.Context) (types.StorageType, error) {
+	return types.NAS, nil
+}
+
+// Capabilities advertises NAS attach semantics and expansion up to the
+// 5 TiB quota limit of a standard file share. Share snapshots are not
+// exposed as volume snapshots.
+func (d *driver) Capabilities(
+	ctx types.Context) (*types.StorageCapabilities, error) {
+	return &types.StorageCapabilities{
+		SupportsExpand:  true,
+		AttachSemantics: types.NAS,
+		MaxVolumeSize:   5120,
+	}, nil
+}
+
+// NextDeviceInfo returns the information about the driver's next available
+// device workflow.
+func (d *driver) NextDeviceInfo(
+	ctx types.Context) (*types.NextDeviceInfo, error) {
+	return nil, nil
+}
+
+// InstanceInspect returns an instance.
+func (d *driver) InstanceInspect(
+	ctx types.Context,
+	opts types.Store) (*types.Instance, error) {
+
+	iid := context.MustInstanceID(ctx)
+	return &types.Instance{Name: iid.ID, InstanceID: iid}, nil
+}
+
+func (d *driver) Volumes(
+	ctx types.Context,
+	opts *types.VolumesOpts) ([]*types.Volume, error) {
+
+	shares, err := d.client.ListShares(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	volumes := []*types.Volume{}
+	for _, s := range shares {
+		volumes = append(volumes, d.toTypesVolume(s, opts.Attachments))
+	}
+	return utils.SortVolumeByID(volumes), nil
+}
+
+func (d *driver) VolumeInspect(
+	ctx types.Context,
+	volumeID string,
+	opts *types.VolumeInspectOpts) (*types.Volume, error) {
+
+	share, err := d.getShare(ctx, volumeID)
+	if err != nil {
+		return nil, err
+	}
+	return d.toTypesVolume(share, opts.Attachments), nil
+}
+
+// VolumeCreate creates a new share. The volume's size is the share's quota;
+// a volume created without a size receives the default quota.
+func (d *driver) VolumeCreate(ctx types.Context, volumeName string,
+	opts *types.VolumeCreateOpts) (*types.Volume, error) {
+
+	if !shareNameRX.MatchString(volumeName) {
+		return nil, goof.WithField(
+			"volumeName", volumeName, "invalid volume name")
+	}
+
+	quota := int64(d.defaultQuota())
+	if opts.Size != nil && *opts.Size > 0 {
+		quota = *opts.Size
+	}
+
+	if err := d.client.CreateShare(ctx, volumeName, quota); err != nil {
+		return nil, err
+	}
+
+	ctx.WithFields(log.Fields{
+		"volumeName": volumeName,
+		"quota":      quota,
+	}).Info("created share")
+
+	return d.VolumeInspect(ctx, volumeName,
+		&types.VolumeInspectOpts{Attachments: 0})
+}
+
+// VolumeRemove deletes a share along with its contents and snapshots.
+func (d *driver) VolumeRemove(
+	ctx types.Context,
+	volumeID string,
+	opts types.Store) error {
+
+	if _, err := d.getShare(ctx, volumeID); err != nil {
+		return err
+	}
+	return d.client.DeleteShare(ctx, volumeID)
+}
+
+// VolumeAttach records the instance in the share's libstorageattachments
+// metadata. No token is returned; the client mounts the share over SMB with
+// the //account.file.<suffix>/share device of the attachment. A forced
+// attachment replaces the instances already recorded for the share.
+func (d *driver) VolumeAttach(
+	ctx types.Context,
+	volumeID string,
+	opts *types.VolumeAttachOpts) (*types.Volume, string, error) {
+
+	d.Lock()
+	defer d.Unlock()
+
+	share, err := d.getShare(ctx, volumeID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	iid := context.MustInstanceID(ctx)
+
+	ids := attachedIDs(share)
+	if opts.Force {
+		ids = nil
+	}
+
+	attached := false
+	for _, id := range ids {
+		if id == iid.ID {
+			attached = true
+			break
+		}
+	}
+	if !attached {
+		ids = append(ids, iid.ID)
+	}
+
+	if err := d.setAttachedIDs(ctx, share, ids); err != nil {
+		return nil, "", err
+	}
+
+	ctx.WithFields(log.Fields{
+		"volumeID": volumeID,
+		"device":   d.device(volumeID),
+	}).Info("attached volume")
+
+	return d.toTypesVolume(share, types.VolumeAttachmentsTrue), "", nil
+}
+
+// VolumeDetach detaches a volume from the instance, or from all instances
+// if the detachment is forced.
+func (d *driver) VolumeDetach(
+	ctx types.Context,
+	volumeID string,
+	opts *types.VolumeDetachOpts) (*types.Volume, error) {
+
+	d.Lock()
+	defer d.Unlock()
+
+	share, err := d.getShare(ctx, volumeID)
+	if err != nil {
+		return nil, err
+	}
+
+	iid := context.MustInstanceID(ctx)
+
+	var ids []string
+	if !opts.Force {
+		for _, id := range attachedIDs(share) {
+			if id != iid.ID {
+				ids = append(ids, id)
+			}
+		}
+	}
+
+	if err := d.setAttachedIDs(ctx, share, ids); err != nil {
+		return nil, err
+	}
+
+	ctx.WithField("volumeID", volumeID).Info("detached volume")
+	return d.toTypesVolume(share, types.VolumeAttachmentsTrue), nil
+}
+
+// VolumeExpand sets a share's quota to the new size. Shares may not be
+// shrunk.
+func (d *driver) VolumeExpand(
+	ctx types.Context,
+	volumeID string,
+	newSize int64,
+	opts types.Store) (*types.Volume, error) {
+
+	share, err := d.getShare(ctx, volumeID)
+	if err != nil {
+		return nil, err
+	}
+
+	if newSize < share.Quota {
+		return nil, goof.WithFields(goof.Fields{
+			"volumeID": volumeID,
+			"size":     share.Quota,
+			"newSize":  newSize,
+		}, "cannot shrink volume")
+	}
+
+	if err := d.client.SetShareQuota(ctx, volumeID, newSize); err != nil {
+		return nil, err
+	}
+
+	return d.VolumeInspect(ctx, volumeID,
+		&types.VolumeInspectOpts{Attachments: 0})
+}
+
+// VolumeCreateFromSnapshot (not implemented).
+func (d *driver) VolumeCreateFromSnapshot(
+	ctx types.Context,
+	snapshotID, volumeName string,
+	opts *types.VolumeCreateOpts) (*types.Volume, error) {
+	return nil, types.ErrNotImplemented
+}
+
+// VolumeCopy copies an existing volume (not implemented)
+func (d *driver) VolumeCopy(
+	ctx types.Context,
+	volumeID, volumeName string,
+	opts types.Store) (*types.Volume, error) {
+	return nil, types.ErrNotImplemented
+}
+
+// VolumeSnapshot snapshots a volume (not implemented)
+func (d *driver) VolumeSnapshot(
+	ctx types.Context,
+	volumeID, snapshotName string,
+	opts types.Store) (*types.Snapshot, error) {
+	return nil, types.ErrNotImplemented
+}
+
+func (d *driver) Snapshots(
+	ctx types.Context,
+	opts types.Store) ([]*types.Snapshot, error) {
+	return nil, types.ErrNotImplemented
+}
+
+func (d *driver) SnapshotInspect(
+	ctx types.Context,
+	snapshotID string,
+	opts types.Store) (*types.Snapshot, error) {
+	return nil, types.ErrNotImplemented
+}
+
+func (d *driver) SnapshotCopy(
+	ctx types.Context,
+	snapshotID, snapshotName, destinationID string,
+	opts types.Store) (*types.Snapshot, error) {
+	return nil, types.ErrNotImplemented
+}
+
+func (d *driver) SnapshotRemove(
+	ctx types.Context,
+	snapshotID string,
+	opts types.Store) error {
+	return types.ErrNotImplemented
+}
+
+// HealthCheck verifies that the storage account's shares can be listed.
+func (d *driver) HealthCheck(ctx types.Context) error {
+	_, err := d.client.ListShares(ctx)
+	return err
+}
+
+func (d *driver) getShare(
+	ctx types.Context, volumeID string) (*azureUtils.Share, error) {
+
+	if !shareNameRX.MatchString(volumeID) {
+		return nil, utils.NewNotFoundError(volumeID)
+	}
+	return d.client.GetShare(ctx, volumeID)
+}
+
+// setAttachedIDs records the IDs of the instances to which a share is
+// attached in the share's metadata. The share's other metadata is
+// preserved.
+func (d *driver) setAttachedIDs(
+	ctx types.Context, share *azureUtils.Share, ids []string) error {
+
+	if len(ids) == 0 {
+		delete(share.Metadata, attachmentsKey)
+	} else {
+		share.Metadata[attachmentsKey] = strings.Join(ids, ",")
+	}
+	return d.client.SetShareMetadata(ctx, share.Name, share.Metadata)
+}
+
+func attachedIDs(share *azureUtils.Share) []string {
+	v := share.Metadata[attachmentsKey]
+	if v == "" {
+		return nil
+	}
+	return strings.Split(v, ",")
+}
+
+func (d *driver) toTypesVolume(
+	share *azureUtils.Share,
+	attachments types.VolumeAttachmentsTypes) *types.Volume {
+
+	vol := &types.Volume{
+		ID:   share.Name,
+		Name: share.Name,
+		Size: share.Quota,
+		Type: azurefile.Name,
+	}
+
+	if !attachments.Requested() {
+		return vol
+	}
+
+	for _, id := range attachedIDs(share) {
+		vol.Attachments = append(vol.Attachments, &types.VolumeAttachment{
+			VolumeID: share.Name,
+			InstanceID: &types.InstanceID{
+				ID:     id,
+				Driver: azurefile.Name,
+			},
+			DeviceName: d.device(share.Name),
+			Status:     attachedStatus,
+		})
+	}
+	return vol
+}
+
+// device returns the SMB path from which the volume is mounted.
+func (d *driver) device(volumeID string) string {
+	return azureUtils.Device(d.accountName(), d.endpointSuffix(), volumeID)
+}
+
+func (d *driver) accountName() string {
+	return d.config.GetString(azurefile.ConfigAccountName)
+}
+
+func (d *driver) endpointSuffix() string {
+	if v := d.config.GetString(azurefile.ConfigEndpointSuffix); v != "" {
+		return v
+	}
+	return "core.windows.net"
+}
+
+func (d *driver) defaultQuota() int {
+	if v := d.config.GetInt(azurefile.ConfigDefaultQuota); v > 0 {
+		return v
+	}
+	return 5
+}
@@ -0,0 +1,344 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+
+	gofigCore "github.com/akutz/gofig"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codedellemc/libstorage/api/context"
+	"github.com/codedellemc/libstorage/api/types"
+	"github.com/codedellemc/libstorage/api/utils"
+	"github.com/codedellemc/libstorage/drivers/storage/azurefile"
+	azureUtils "github.com/codedellemc/libstorage/drivers/storage/azurefile/utils"
+)
+
+// fakeShares is a fake of the Azure Files client that manages the shares of
+// a storage account in memory.
+type fakeShares struct {
+	shares map[string]*azureUtils.Share
+
+	// errs are the errors returned by the client's methods, keyed by the
+	// methods' names.
+	errs map[string]error
+}
+
+func newFakeShares() *fakeShares {
+	return &fakeShares{
+		shares: map[string]*azureUtils.Share{},
+		errs:   map[string]error{},
+	}
+}
+
+// copyShare returns a copy of a share so the driver cannot modify the
+// fake's shares other than with the client's methods.
+func copyShare(s *azureUtils.Share) *azureUtils.Share {
+	c := &azureUtils.Share{
+		Name:     s.Name,
+		Quota:    s.Quota,
+		Metadata: map[string]string{},
+	}
+	for k, v := range s.Metadata {
+		c.Metadata[k] = v
+	}
+	return c
+}
+
+func (f *fakeShares) ListShares(
+	ctx types.Context) ([]*azureUtils.Share, error) {
+
+	if err := f.errs["ListShares"]; err != nil {
+		return nil, err
+	}
+	shares := []*azureUtils.Share{}
+	for _, s := range f.shares {
+		shares = append(shares, copyShare(s))
+	}
+	return shares, nil
+}
+
+func (f *fakeShares) GetShare(
+	ctx types.Context, name string) (*azureUtils.Share, error) {
+
+	if err := f.errs["GetShare"]; err != nil {
+		return nil, err
+	}
+	s, ok := f.shares[name]
+	if !ok {
+		return nil, utils.NewNotFoundError(name)
+	}
+	return copyShare(s), nil
+}
+
+func (f *fakeShares) CreateShare(
+	ctx types.Context, name string, quota int64) error {
+
+	if err := f.errs["CreateShare"]; err != nil {
+		return err
+	}
+	if _, ok := f.shares[name]; ok {
+		return utils.NewAlreadyExistsError(name)
+	}
+	f.shares[name] = &azureUtils.Share{
+		Name:     name,
+		Quota:    quota,
+		Metadata: map[string]string{},
+	}
+	return nil
+}
+
+func (f *fakeShares) SetShareQuota(
+	ctx types.Context, name string, quota int64) error {
+
+	if err := f.errs["SetShareQuota"]; err != nil {
+		return err
+	}
+	s, ok := f.shares[name]
+	if !ok {
+		return utils.NewNotFoundError(name)
+	}
+	s.Quota = quota
+	return nil
+}
+
+func (f *fakeShares) SetShareMetadata(
+	ctx types.Context, name string, metadata map[string]string) error {
+
+	if err := f.errs["SetShareMetadata"]; err != nil {
+		return err
+	}
+	s, ok := f.shares[name]
+	if !ok {
+		return utils.NewNotFoundError(name)
+	}
+	s.Metadata = map[string]string{}
+	for k, v := range metadata {
+		s.Metadata[k] = v
+	}
+	return nil
+}
+
+func (f *fakeShares) DeleteShare(ctx types.Context, name string) error {
+	if err := f.errs["DeleteShare"]; err != nil {
+		return err
+	}
+	if _, ok := f.shares[name]; !ok {
+		return utils.NewNotFoundError(name)
+	}
+	delete(f.shares, name)
+	return nil
+}
+
+func newTestDriver() (*driver, *fakeShares) {
+	config := gofigCore.New()
+	config.Set(azurefile.ConfigAccountName, "account")
+	config.Set(azurefile.ConfigEndpointSuffix, "core.windows.net")
+	config.Set(azurefile.ConfigDefaultQuota, 5)
+	f := newFakeShares()
+	return &driver{config: config, client: f}, f
+}
+
+func newTestContext(id string) types.Context {
+	return context.WithValue(
+		context.Background(),
+		context.InstanceIDKey,
+		&types.InstanceID{ID: id, Driver: azurefile.Name})
+}
+
+func size(gb int64) *int64 {
+	return &gb
+}
+
+func TestInit(t *testing.T) {
+	tests := []struct {
+		accountName string
+		accountKey  string
+		err         bool
+	}{
+		{"account", "a2V5", false},
+		{"", "a2V5", true},
+		{"account", "", true},
+		{"account", "not base64!", true},
+	}
+	for _, tt := range tests {
+		config := gofigCore.New()
+		config.Set(azurefile.ConfigAccountName, tt.accountName)
+		config.Set(azurefile.ConfigAccountKey, tt.accountKey)
+		d := &driver{}
+		err := d.Init(context.Background(), config)
+		if tt.err {
+			assert.Error(t, err, "%+v", tt)
+			continue
+		}
+		assert.NoError(t, err, "%+v", tt)
+		assert.IsType(t, &azureUtils.Client{}, d.client)
+	}
+}
+
+func TestDefaults(t *testing.T) {
+	d := &driver{config: gofigCore.New()}
+	assert.Equal(t, "core.windows.net", d.endpointSuffix())
+	assert.Equal(t, 5, d.defaultQuota())
+
+	d.config.Set(azurefile.ConfigAccountName, "account")
+	d.config.Set(azurefile.ConfigEndpointSuffix, "core.chinacloudapi.cn")
+	d.config.Set(azurefile.ConfigDefaultQuota, 100)
+	assert.Equal(t, "core.chinacloudapi.cn", d.endpointSuffix())
+	assert.Equal(t, 100, d.defaultQuota())
+	assert.Equal(t,
+		"//account.file.core.chinacloudapi.cn/share1", d.device("share1"))
+}
+
+func TestShareNameRX(t *testing.T) {
+	tests := []struct {
+		name  string
+		valid bool
+	}{
+		{"abc", true},
+		{"share-1", true},
+		{"a-b-c", true},
+		{"ab", false},
+		{"Share1", false},
+		{"-share", false},
+		{"share-", false},
+		{"share--1", false},
+		{"share_1", false},
+		{strings.Repeat("a", 63), true},
+		{strings.Repeat("a", 64), false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.valid, shareNameRX.MatchString(tt.name), tt.name)
+	}
+}
+
+func TestVolumeCreate(t *testing.T) {
+	d, f := newTestDriver()
+	ctx := newTestContext("i-1")
+
+	vol, err := d.VolumeCreate(ctx, "share1", &types.VolumeCreateOpts{})
+	assert.NoError(t, err)
+	assert.Equal(t, "share1", vol.ID)
+	assert.Equal(t, int64(5), vol.Size)
+	assert.Equal(t, azurefile.Name, vol.Type)
+
+	vol, err = d.VolumeCreate(
+		ctx, "share2", &types.VolumeCreateOpts{Size: size(100)})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(100), vol.Size)
+
+	_, err = d.VolumeCreate(ctx, "share1", &types.VolumeCreateOpts{})
+	assert.IsType(t, &types.ErrAlreadyExists{}, err)
+	_, err = d.VolumeCreate(ctx, "Share3", &types.VolumeCreateOpts{})
+	assert.Error(t, err)
+	assert.Len(t, f.shares, 2)
+
+	vols, err := d.Volumes(ctx, &types.VolumesOpts{})
+	assert.NoError(t, err)
+	if assert.Len(t, vols, 2) {
+		assert.Equal(t, "share1", vols[0].ID)
+		assert.Equal(t, "share2", vols[1].ID)
+	}
+}
+
+func TestNotFound(t *testing.T) {
+	d, f := newTestDriver()
+	ctx := newTestContext("i-1")
+
+	_, err := d.VolumeInspect(ctx, "share1", &types.VolumeInspectOpts{})
+	assert.IsType(t, &types.ErrNotFound{}, err)
+	assert.IsType(t, &types.ErrNotFound{}, d.VolumeRemove(ctx, "share1", nil))
+	_, err = d.VolumeExpand(ctx, "share1", 10, nil)
+	assert.IsType(t, &types.ErrNotFound{}, err)
+	_, _, err = d.VolumeAttach(ctx, "share1", &types.VolumeAttachOpts{})
+	assert.IsType(t, &types.ErrNotFound{}, err)
+	_, err = d.VolumeDetach(ctx, "share1", &types.VolumeDetachOpts{})
+	assert.IsType(t, &types.ErrNotFound{}, err)
+
+	// invalid names are not found without a request
+	f.errs["GetShare"] = utils.NewTimeoutError("Share1", nil)
+	_, err = d.VolumeInspect(ctx, "Share1", &types.VolumeInspectOpts{})
+	assert.IsType(t, &types.ErrNotFound{}, err)
+
+	// other errors are returned as they are
+	_, err = d.VolumeInspect(ctx, "share1", &types.VolumeInspectOpts{})
+	assert.Equal(t, f.errs["GetShare"], err)
+}
+
+func TestVolumeRemove(t *testing.T) {
+	d, f := newTestDriver()
+	ctx := newTestContext("i-1")
+	f.CreateShare(ctx, "share1", 5)
+
+	assert.NoError(t, d.VolumeRemove(ctx, "share1", nil))
+	assert.Empty(t, f.shares)
+
+	f.CreateShare(ctx, "share1", 5)
+	f.errs["DeleteShare"] = utils.NewBusyError("share1", nil)
+	err := d.VolumeRemove(ctx, "share1", nil)
+	assert.Equal(t, types.ErrorCodeBusy, types.ErrorCodeOf(err))
+}
+
+func TestVolumeAttachDetach(t *testing.T) {
+	d, f := newTestDriver()
+	ctx1, ctx2 := newTestContext("i-1"), newTestContext("i-2")
+	f.CreateShare(ctx1, "share1", 5)
+	f.shares["share1"].Metadata["owner"] = "ops"
+
+	vol, token, err := d.VolumeAttach(
+		ctx1, "share1", &types.VolumeAttachOpts{})
+	assert.NoError(t, err)
+	assert.Equal(t, "", token)
+	if assert.Len(t, vol.Attachments, 1) {
+		assert.Equal(t, "i-1", vol.Attachments[0].InstanceID.ID)
+		assert.Equal(t,
+			"//account.file.core.windows.net/share1",
+			vol.Attachments[0].DeviceName)
+	}
+	assert.Equal(t, "i-1", f.shares["share1"].Metadata[attachmentsKey])
+
+	// attaching the volume to the same instance again is a no-op
+	vol, _, err = d.VolumeAttach(ctx1, "share1", &types.VolumeAttachOpts{})
+	assert.NoError(t, err)
+	assert.Len(t, vol.Attachments, 1)
+
+	vol, _, err = d.VolumeAttach(ctx2, "share1", &types.VolumeAttachOpts{})
+	assert.NoError(t, err)
+	assert.Len(t, vol.Attachments, 2)
+	assert.Equal(t, "i-1,i-2", f.shares["share1"].Metadata[attachmentsKey])
+
+	vol, _, err = d.VolumeAttach(
+		ctx2, "share1", &types.VolumeAttachOpts{Force: true})
+	assert.NoError(t, err)
+	assert.Equal(t, "i-2", f.shares["share1"].Metadata[attachmentsKey])
+
+	vol, err = d.VolumeDetach(ctx2, "share1", &types.VolumeDetachOpts{})
+	assert.NoError(t, err)
+	assert.Empty(t, vol.Attachments)
+	assert.NotContains(t, f.shares["share1"].Metadata, attachmentsKey)
+
+	// the share's other metadata is preserved
+	assert.Equal(t, "ops", f.shares["share1"].Metadata["owner"])
+
+	f.errs["SetShareMetadata"] = utils.NewBusyError("share1", nil)
+	_, _, err = d.VolumeAttach(ctx1, "share1", &types.VolumeAttachOpts{})
+	assert.Equal(t, f.errs["SetShareMetadata"], err)
+}
+
+func TestVolumeExpand(t *testing.T) {
+	d, f := newTestDriver()
+	ctx := newTestContext("i-1")
+	f.CreateShare(ctx, "share1", 10)
+
+	vol, err := d.VolumeExpand(ctx, "share1", 20, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(20), vol.Size)
+
+	_, err = d.VolumeExpand(ctx, "share1", 5, nil)
+	assert.Error(t, err)
+	assert.Equal(t, int64(20), f.shares["share1"].Quota)
+
+	f.errs["SetShareQuota"] = utils.NewQuotaExceededError("share1", nil)
+	_, err = d.VolumeExpand(ctx, "share1", 6000, nil)
+	assert.Equal(t, types.ErrorCodeQuotaExceeded, types.ErrorCodeOf(err))
+}
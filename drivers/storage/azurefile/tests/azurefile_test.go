@@ -0,0 +1,128 @@
+package azurefile
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	gofig "github.com/akutz/gofig/types"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codedellemc/libstorage/api/server"
+	apitests "github.com/codedellemc/libstorage/api/tests"
+	"github.com/codedellemc/libstorage/api/types"
+
+	// load the driver
+	"github.com/codedellemc/libstorage/drivers/storage/azurefile"
+	azurefilex "github.com/codedellemc/libstorage/drivers/storage/azurefile/executor"
+	_ "github.com/codedellemc/libstorage/drivers/storage/azurefile/storage"
+)
+
+// skipTests returns a flag indicating whether the tests are skipped. The
+// tests require the storage account specified with AZUREFILE_ACCOUNT_NAME
+// and AZUREFILE_ACCOUNT_KEY.
+func skipTests() bool {
+	return os.Getenv("AZUREFILE_ACCOUNT_NAME") == "" ||
+		os.Getenv("AZUREFILE_ACCOUNT_KEY") == ""
+}
+
+var volumeName string
+
+func init() {
+	uuid, _ := types.NewUUID()
+	volumeName = "ls-test-" + uuid.String()[:8]
+}
+
+func TestMain(m *testing.M) {
+	server.CloseOnAbort()
+	ec := m.Run()
+	os.Exit(ec)
+}
+
+func newTestConfig() []byte {
+	return []byte(fmt.Sprintf(`
+azurefile:
+  accountName: %s
+  accountKey:  %s
+`, os.Getenv("AZUREFILE_ACCOUNT_NAME"), os.Getenv("AZUREFILE_ACCOUNT_KEY")))
+}
+
+func TestInstanceID(t *testing.T) {
+	if skipTests() {
+		t.SkipNow()
+	}
+
+	iid, err := azurefilex.InstanceID()
+	assert.NoError(t, err)
+	if err != nil {
+		t.FailNow()
+	}
+
+	apitests.Run(
+		t, azurefile.Name, newTestConfig(),
+		(&apitests.InstanceIDTest{
+			Driver:   azurefile.Name,
+			Expected: iid,
+		}).Test)
+}
+
+func TestVolumeCreateRemove(t *testing.T) {
+	if skipTests() {
+		t.SkipNow()
+	}
+
+	tf := func(config gofig.Config, client types.Client, t *testing.T) {
+		size := int64(1)
+		vol, err := client.API().VolumeCreate(
+			nil, azurefile.Name, &types.VolumeCreateRequest{
+				Name: volumeName,
+				Size: &size,
+			})
+		assert.NoError(t, err)
+		if err != nil {
+			t.FailNow()
+		}
+		assert.Equal(t, volumeName, vol.ID)
+		assert.Equal(t, size, vol.Size)
+
+		assert.NoError(t, client.API().VolumeRemove(
+			nil, azurefile.Name, volumeName))
+	}
+	apitests.Run(t, azurefile.Name, newTestConfig(), tf)
+}
+
+func TestVolumeAttachDetach(t *testing.T) {
+	if skipTests() {
+		t.SkipNow()
+	}
+
+	tf := func(config gofig.Config, client types.Client, t *testing.T) {
+		_, err := client.API().VolumeCreate(
+			nil, azurefile.Name, &types.VolumeCreateRequest{Name: volumeName})
+		assert.NoError(t, err)
+		if err != nil {
+			t.FailNow()
+		}
+		defer client.API().VolumeRemove(nil, azurefile.Name, volumeName)
+
+		vol, _, err := client.API().VolumeAttach(
+			nil, azurefile.Name, volumeName, &types.VolumeAttachRequest{})
+		assert.NoError(t, err)
+		if err != nil {
+			t.FailNow()
+		}
+		if !assert.Len(t, vol.Attachments, 1) {
+			t.FailNow()
+		}
+		assert.Equal(t,
+			fmt.Sprintf("//%s.file.core.windows.net/%s",
+				os.Getenv("AZUREFILE_ACCOUNT_NAME"), volumeName),
+			vol.Attachments[0].DeviceName)
+
+		vol, err = client.API().VolumeDetach(
+			nil, azurefile.Name, volumeName, &types.VolumeDetachRequest{})
+		assert.NoError(t, err)
+		assert.Len(t, vol.Attachments, 0)
+	}
+	apitests.Run(t, azurefile.Name, newTestConfig(), tf)
+}
@@ -0,0 +1,2 @@
+AZUREFILE_COVERPKG := $(ROOT_IMPORT_PATH)/drivers/storage/azurefile
+TEST_COVERPKG_./drivers/storage/azurefile/tests := $(AZUREFILE_COVERPKG),$(AZUREFILE_COVERPKG)/executor,$(AZUREFILE_COVERPKG)/storage
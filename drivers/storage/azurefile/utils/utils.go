@@ -0,0 +1,345 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/akutz/goof"
+
+	"github.com/codedellemc/libstorage/api/types"
+	"github.com/codedellemc/libstorage/api/utils"
+)
+
+const (
+	// apiVersion is the version of the Azure Storage REST API.
+	apiVersion = "2017-04-17"
+
+	metaHeaderPrefix = "x-ms-meta-"
+)
+
+// Client is a client of the Azure Files REST API that authorizes its
+// requests with the storage account's shared key.
+type Client struct {
+	accountName string
+	accountKey  []byte
+	endpoint    string
+	httpClient  *http.Client
+}
+
+// Share is a file share.
+type Share struct {
+	Name     string
+	Quota    int64
+	Metadata map[string]string
+}
+
+// NewClient returns a new client for the specified storage account.
+func NewClient(
+	accountName, accountKey, endpointSuffix string) (*Client, error) {
+
+	key, err := base64.StdEncoding.DecodeString(accountKey)
+	if err != nil {
+		return nil, goof.WithFieldE(
+			"accountName", accountName, "invalid account key", err)
+	}
+	return &Client{
+		accountName: accountName,
+		accountKey:  key,
+		endpoint:    Host(accountName, endpointSuffix),
+		httpClient:  &http.Client{Timeout: time.Minute},
+	}, nil
+}
+
+// Host returns the host name of a storage account's file endpoint.
+func Host(accountName, endpointSuffix string) string {
+	return fmt.Sprintf("%s.file.%s", accountName, endpointSuffix)
+}
+
+// Device returns the device of a share, formatted as
+// //account.file.<suffix>/share, which is mounted with the linux OS
+// driver's CIFS mount handler.
+func Device(accountName, endpointSuffix, shareName string) string {
+	return fmt.Sprintf(
+		"//%s/%s", Host(accountName, endpointSuffix), shareName)
+}
+
+type listSharesResponse struct {
+	Shares []struct {
+		Name       string `xml:"Name"`
+		Properties struct {
+			Quota int64 `xml:"Quota"`
+		} `xml:"Properties"`
+		Metadata struct {
+			Items []struct {
+				XMLName xml.Name
+				Value   string `xml:",chardata"`
+			} `xml:",any"`
+		} `xml:"Metadata"`
+	} `xml:"Shares>Share"`
+	NextMarker string `xml:"NextMarker"`
+}
+
+// ListShares returns the storage account's shares.
+func (c *Client) ListShares(ctx types.Context) ([]*Share, error) {
+	shares := []*Share{}
+	marker := ""
+	for {
+		q := url.Values{}
+		q.Set("comp", "list")
+		q.Set("include", "metadata")
+		if marker != "" {
+			q.Set("marker", marker)
+		}
+
+		res, err := c.do(ctx, "GET", "", q, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		buf, err := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		reply := &listSharesResponse{}
+		if err := xml.Unmarshal(buf, reply); err != nil {
+			return nil, err
+		}
+
+		for _, s := range reply.Shares {
+			share := &Share{
+				Name:     s.Name,
+				Quota:    s.Properties.Quota,
+				Metadata: map[string]string{},
+			}
+			for _, m := range s.Metadata.Items {
+				share.Metadata[strings.ToLower(m.XMLName.Local)] = m.Value
+			}
+			shares = append(shares, share)
+		}
+
+		if reply.NextMarker == "" {
+			return shares, nil
+		}
+		marker = reply.NextMarker
+	}
+}
+
+// GetShare returns a share. An ErrNotFound error is returned if the share
+// does not exist.
+func (c *Client) GetShare(ctx types.Context, name string) (*Share, error) {
+	res, err := c.do(ctx, "HEAD", name, shareQuery(""), nil)
+	if err != nil {
+		return nil, err
+	}
+	res.Body.Close()
+
+	share := &Share{Name: name, Metadata: map[string]string{}}
+	if v := res.Header.Get("x-ms-share-quota"); v != "" {
+		if share.Quota, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return nil, err
+		}
+	}
+	for k, v := range res.Header {
+		k = strings.ToLower(k)
+		if strings.HasPrefix(k, metaHeaderPrefix) && len(v) > 0 {
+			share.Metadata[strings.TrimPrefix(k, metaHeaderPrefix)] = v[0]
+		}
+	}
+	return share, nil
+}
+
+// CreateShare creates a share with the specified quota in GB. An
+// ErrConflict error is returned if the share already exists.
+func (c *Client) CreateShare(
+	ctx types.Context, name string, quota int64) error {
+
+	res, err := c.do(ctx, "PUT", name, shareQuery(""), http.Header{
+		"x-ms-share-quota": []string{strconv.FormatInt(quota, 10)},
+	})
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	return nil
+}
+
+// SetShareQuota sets the quota in GB of a share.
+func (c *Client) SetShareQuota(
+	ctx types.Context, name string, quota int64) error {
+
+	res, err := c.do(ctx, "PUT", name, shareQuery("properties"), http.Header{
+		"x-ms-share-quota": []string{strconv.FormatInt(quota, 10)},
+	})
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	return nil
+}
+
+// SetShareMetadata replaces the metadata of a share.
+func (c *Client) SetShareMetadata(
+	ctx types.Context, name string, metadata map[string]string) error {
+
+	headers := http.Header{}
+	for k, v := range metadata {
+		headers[metaHeaderPrefix+strings.ToLower(k)] = []string{v}
+	}
+	res, err := c.do(ctx, "PUT", name, shareQuery("metadata"), headers)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	return nil
+}
+
+// DeleteShare deletes a share along with its snapshots.
+func (c *Client) DeleteShare(ctx types.Context, name string) error {
+	res, err := c.do(ctx, "DELETE", name, shareQuery(""), http.Header{
+		"x-ms-delete-snapshots": []string{"include"},
+	})
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	return nil
+}
+
+func shareQuery(comp string) url.Values {
+	q := url.Values{}
+	q.Set("restype", "share")
+	if comp != "" {
+		q.Set("comp", comp)
+	}
+	return q
+}
+
+// do executes a request and returns its response if the response indicates
+// success. The response's status is converted to an ErrNotFound or an
+// ErrConflict error where appropriate.
+func (c *Client) do(
+	ctx types.Context,
+	method, path string,
+	query url.Values,
+	headers http.Header) (*http.Response, error) {
+
+	u := &url.URL{
+		Scheme:   "https",
+		Host:     c.endpoint,
+		Path:     "/" + path,
+		RawQuery: query.Encode(),
+	}
+
+	req, err := http.NewRequest(method, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range headers {
+		req.Header[k] = v
+	}
+	req.Header["x-ms-date"] = []string{
+		time.Now().UTC().Format(http.TimeFormat)}
+	req.Header["x-ms-version"] = []string{apiVersion}
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"SharedKey %s:%s", c.accountName, c.sign(req, path, query)))
+
+	if ctx != nil {
+		ctx.WithFields(log.Fields{
+			"method": method,
+			"url":    u.String(),
+		}).Debug("azure files request")
+	}
+
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return res, nil
+	}
+
+	defer res.Body.Close()
+	body, _ := ioutil.ReadAll(res.Body)
+	code := res.Header.Get("x-ms-error-code")
+
+	err = goof.WithFields(goof.Fields{
+		"method": method,
+		"path":   path,
+		"status": res.StatusCode,
+		"code":   code,
+		"body":   string(bytes.TrimSpace(body)),
+	}, "azure files request failed")
+
+	switch {
+	case res.StatusCode == http.StatusNotFound:
+		return nil, utils.NewNotFoundError(path)
+	case code == "ShareAlreadyExists":
+		return nil, utils.NewAlreadyExistsError(path)
+	case code == "ShareBeingDeleted",
+		code == "ShareHasSnapshots",
+		code == "LeaseIdMissing":
+		return nil, utils.NewBusyError(path, err)
+	case code == "ShareSizeLimitReached",
+		code == "AccountLimitExceeded":
+		return nil, utils.NewQuotaExceededError(path, err)
+	case code == "AuthenticationFailed",
+		res.StatusCode == http.StatusForbidden:
+		return nil, utils.NewAuthFailedError(err)
+	case code == "OperationTimedOut":
+		return nil, utils.NewTimeoutError(path, err)
+	}
+
+	return nil, err
+}
+
+// sign returns the shared key signature of a request.
+func (c *Client) sign(req *http.Request, path string, query url.Values) string {
+	var msHeaders []string
+	for k, v := range req.Header {
+		k = strings.ToLower(k)
+		if strings.HasPrefix(k, "x-ms-") {
+			msHeaders = append(msHeaders,
+				fmt.Sprintf("%s:%s\n", k, strings.Join(v, ",")))
+		}
+	}
+	sort.Strings(msHeaders)
+
+	resource := fmt.Sprintf("/%s/%s", c.accountName, path)
+	var keys []string
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v := query[k]
+		sort.Strings(v)
+		resource += fmt.Sprintf("\n%s:%s", strings.ToLower(k),
+			strings.Join(v, ","))
+	}
+
+	// the twelve standard headers are empty since the requests have no
+	// bodies and use x-ms-date rather than Date
+	stringToSign := req.Method + "\n" +
+		strings.Repeat("\n", 11) +
+		strings.Join(msHeaders, "") +
+		resource
+
+	mac := hmac.New(sha256.New, c.accountKey)
+	mac.Write([]byte(stringToSign))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,169 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codedellemc/libstorage/api/types"
+)
+
+func TestDevice(t *testing.T) {
+	assert.Equal(t,
+		"//account.file.core.windows.net/share1",
+		Device("account", "core.windows.net", "share1"))
+}
+
+func TestNewClientInvalidKey(t *testing.T) {
+	_, err := NewClient("account", "not base64!", "core.windows.net")
+	assert.Error(t, err)
+}
+
+// redirectTransport sends the client's HTTPS requests to a test server.
+type redirectTransport struct {
+	host string
+}
+
+func (t *redirectTransport) RoundTrip(
+	req *http.Request) (*http.Response, error) {
+
+	req.URL.Scheme = "http"
+	req.URL.Host = t.host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// newTestClient returns a client whose requests are handled by the
+// specified handler.
+func newTestClient(
+	t *testing.T, h http.HandlerFunc) (*Client, func()) {
+
+	s := httptest.NewServer(h)
+	u, _ := url.Parse(s.URL)
+	c, err := NewClient("account", "a2V5", "core.windows.net")
+	if err != nil {
+		s.Close()
+		t.Fatal(err)
+	}
+	c.httpClient = &http.Client{Transport: &redirectTransport{u.Host}}
+	return c, s.Close
+}
+
+func TestGetShare(t *testing.T) {
+	var req *http.Request
+	c, cleanup := newTestClient(t, func(
+		w http.ResponseWriter, r *http.Request) {
+		req = r
+		w.Header().Set("x-ms-share-quota", "10")
+		w.Header().Set("x-ms-meta-libstorageattachments", "i-1,i-2")
+		w.Header().Set("x-ms-meta-Owner", "ops")
+	})
+	defer cleanup()
+
+	share, err := c.GetShare(nil, "share1")
+	assert.NoError(t, err)
+	assert.Equal(t, "share1", share.Name)
+	assert.Equal(t, int64(10), share.Quota)
+	assert.Equal(t, map[string]string{
+		"libstorageattachments": "i-1,i-2",
+		"owner":                 "ops",
+	}, share.Metadata)
+
+	assert.Equal(t, "HEAD", req.Method)
+	assert.Equal(t, "/share1", req.URL.Path)
+	assert.Equal(t, "share", req.URL.Query().Get("restype"))
+	assert.Equal(t, apiVersion, req.Header.Get("x-ms-version"))
+	assert.True(t, strings.HasPrefix(
+		req.Header.Get("Authorization"), "SharedKey account:"))
+}
+
+func TestListShares(t *testing.T) {
+	var markers []string
+	c, cleanup := newTestClient(t, func(
+		w http.ResponseWriter, r *http.Request) {
+		marker := r.URL.Query().Get("marker")
+		markers = append(markers, marker)
+		if marker == "" {
+			w.Write([]byte(`<EnumerationResults><Shares>
+				<Share><Name>share1</Name>
+				<Properties><Quota>5</Quota></Properties>
+				<Metadata><libstorageattachments>i-1</libstorageattachments>
+				</Metadata></Share>
+				</Shares><NextMarker>m1</NextMarker></EnumerationResults>`))
+			return
+		}
+		w.Write([]byte(`<EnumerationResults><Shares>
+			<Share><Name>share2</Name>
+			<Properties><Quota>10</Quota></Properties></Share>
+			</Shares><NextMarker/></EnumerationResults>`))
+	})
+	defer cleanup()
+
+	shares, err := c.ListShares(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"", "m1"}, markers)
+	if assert.Len(t, shares, 2) {
+		assert.Equal(t, "share1", shares[0].Name)
+		assert.Equal(t, int64(5), shares[0].Quota)
+		assert.Equal(t, "i-1", shares[0].Metadata["libstorageattachments"])
+		assert.Equal(t, "share2", shares[1].Name)
+		assert.Equal(t, int64(10), shares[1].Quota)
+		assert.Empty(t, shares[1].Metadata)
+	}
+}
+
+func TestSetShareMetadata(t *testing.T) {
+	var req *http.Request
+	c, cleanup := newTestClient(t, func(
+		w http.ResponseWriter, r *http.Request) {
+		req = r
+	})
+	defer cleanup()
+
+	err := c.SetShareMetadata(nil, "share1", map[string]string{
+		"Owner": "ops",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "PUT", req.Method)
+	assert.Equal(t, "metadata", req.URL.Query().Get("comp"))
+	assert.Equal(t, "ops", req.Header.Get("x-ms-meta-owner"))
+}
+
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		status int
+		code   string
+		err    types.ErrorCode
+	}{
+		{http.StatusNotFound, "ShareNotFound", types.ErrorCodeNotFound},
+		{http.StatusConflict, "ShareAlreadyExists",
+			types.ErrorCodeAlreadyExists},
+		{http.StatusConflict, "ShareBeingDeleted", types.ErrorCodeBusy},
+		{http.StatusConflict, "ShareHasSnapshots", types.ErrorCodeBusy},
+		{http.StatusPreconditionFailed, "LeaseIdMissing",
+			types.ErrorCodeBusy},
+		{http.StatusBadRequest, "ShareSizeLimitReached",
+			types.ErrorCodeQuotaExceeded},
+		{http.StatusForbidden, "AuthenticationFailed",
+			types.ErrorCodeAuthFailed},
+		{http.StatusForbidden, "", types.ErrorCodeAuthFailed},
+		{http.StatusInternalServerError, "OperationTimedOut",
+			types.ErrorCodeTimeout},
+		{http.StatusInternalServerError, "InternalError", ""},
+	}
+	for _, tt := range tests {
+		c, cleanup := newTestClient(t, func(
+			w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("x-ms-error-code", tt.code)
+			w.WriteHeader(tt.status)
+		})
+		err := c.CreateShare(nil, "share1", 5)
+		cleanup()
+		if assert.Error(t, err, tt.code) {
+			assert.Equal(t, tt.err, types.ErrorCodeOf(err), tt.code)
+		}
+	}
+}
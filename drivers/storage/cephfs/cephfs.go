@@ -0,0 +1,51 @@
+package cephfs
+
+import (
+	gofigCore "github.com/akutz/gofig"
+	gofig "github.com/akutz/gofig/types"
+)
+
+const (
+	// Name is the provider's name.
+	Name = "cephfs"
+
+	// ConfigFSName is a config key.
+	ConfigFSName = Name + ".fsName"
+
+	// ConfigGroup is a config key.
+	ConfigGroup = Name + ".group"
+
+	// ConfigMonitors is a config key.
+	ConfigMonitors = Name + ".monitors"
+
+	// ConfigUser is a config key.
+	ConfigUser = Name + ".user"
+
+	// ConfigKeyring is a config key.
+	ConfigKeyring = Name + ".keyring"
+
+	// ConfigCephConfig is a config key.
+	ConfigCephConfig = Name + ".cephConfig"
+
+	// ConfigCloneTimeout is a config key.
+	ConfigCloneTimeout = Name + ".cloneTimeout"
+)
+
+func init() {
+	r := gofigCore.NewRegistration("CephFS")
+	r.Key(gofig.String, "", "cephfs",
+		"The file system in which subvolumes are managed", ConfigFSName)
+	r.Key(gofig.String, "", "",
+		"The subvolume group in which subvolumes are managed", ConfigGroup)
+	r.Key(gofig.String, "", "",
+		"Comma separated addresses of the Ceph monitors", ConfigMonitors)
+	r.Key(gofig.String, "", "admin",
+		"The Ceph user as which the ceph command is executed", ConfigUser)
+	r.Key(gofig.String, "", "",
+		"The path to the Ceph user's keyring", ConfigKeyring)
+	r.Key(gofig.String, "", "",
+		"The path to the Ceph configuration file", ConfigCephConfig)
+	r.Key(gofig.String, "", "5m",
+		"The maximum duration of a subvolume clone", ConfigCloneTimeout)
+	gofigCore.Register(r)
+}
@@ -0,0 +1,803 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	gofig "github.com/akutz/gofig/types"
+	"github.com/akutz/goof"
+
+	"github.com/codedellemc/libstorage/api/context"
+	"github.com/codedellemc/libstorage/api/registry"
+	"github.com/codedellemc/libstorage/api/types"
+	"github.com/codedellemc/libstorage/api/utils"
+	"github.com/codedellemc/libstorage/drivers/storage/cephfs"
+	cephUtils "github.com/codedellemc/libstorage/drivers/storage/cephfs/utils"
+)
+
+const (
+	bytesPerGb    = int64(1024 * 1024 * 1024)
+	snapDelimiter = "@"
+
+	// attachmentsKey is the key of the subvolume metadata in which the
+	// subvolume's attachments are recorded.
+	attachmentsKey = "libstorage.attachments"
+
+	// copySnapshotPrefix is the prefix of the names of the temporary
+	// snapshots from which copies of subvolumes are cloned.
+	copySnapshotPrefix = "libstorage-copy-"
+
+	attachedStatus = "attached"
+)
+
+// driver is a storage driver that manages volumes as the subvolumes of a
+// CephFS file system with the ceph fs subvolume commands. The clients mount
+// the subvolumes with the linux OS driver's kernel CephFS mount handler.
+type driver struct {
+	sync.Mutex
+	config gofig.Config
+
+	// run executes the ceph command. It is cephUtils.Ceph unless the
+	// driver's tests replace the command with a fake.
+	run func(
+		ctx types.Context,
+		config gofig.Config,
+		args ...string) ([]byte, error)
+}
+
+// subvolumeInfo is the output of the ceph fs subvolume info command.
+type subvolumeInfo struct {
+	Path       string      `json:"path"`
+	BytesQuota interface{} `json:"bytes_quota"`
+	CreatedAt  string      `json:"created_at"`
+}
+
+// nameEntry is an entry in the output of the ceph fs subvolume ls and
+// ceph fs subvolume snapshot ls commands.
+type nameEntry struct {
+	Name string `json:"name"`
+}
+
+// cloneStatus is the output of the ceph fs clone status command.
+type cloneStatus struct {
+	Status struct {
+		State string `json:"state"`
+	} `json:"status"`
+}
+
+func init() {
+	registry.RegisterStorageDriver(cephfs.Name, newDriver)
+}
+
+func newDriver() types.StorageDriver {
+	return &driver{run: cephUtils.Ceph}
+}
+
+// Name returns the name of the driver
+func (d *driver) Name() string {
+	return cephfs.Name
+}
+
+// Init initializes the driver.
+func (d *driver) Init(ctx types.Context, config gofig.Config) error {
+	d.config = config
+
+	fields := log.Fields{
+		"fsName":       d.fsName(),
+		"group":        d.group(),
+		"monitors":     d.monitors(),
+		"user":         d.config.GetString(cephfs.ConfigUser),
+		"keyring":      d.config.GetString(cephfs.ConfigKeyring),
+		"cephConfig":   d.config.GetString(cephfs.ConfigCephConfig),
+		"cloneTimeout": d.config.GetString(cephfs.ConfigCloneTimeout),
+	}
+
+	if d.fsName() == "" || d.monitors() == "" {
+		return goof.WithFields(fields,
+			"cephfs.fsName and cephfs.monitors are required")
+	}
+
+	if _, err := d.cloneTimeout(); err != nil {
+		return goof.WithFieldsE(fields, "invalid clone timeout", err)
+	}
+
+	ctx.WithFields(fields).Info("storage driver initialized")
+	return nil
+}
+
+// Type returns the type of storage a driver provides
+func (d *driver) Type(ctx types.Context) (types.StorageType, error) {
+	return types.NAS, nil
+}
+
+// Capabilities advertises NAS attach semantics along with snapshots and
+// expansion. Expanding a subvolume raises its quota.
+func (d *driver) Capabilities(
+	ctx types.Context) (*types.StorageCapabilities, error) {
+	return &types.StorageCapabilities{
+		SupportsSnapshots: true,
+		SupportsExpand:    true,
+		AttachSemantics:   types.NAS,
+	}, nil
+}
+
+// NextDeviceInfo returns the information about the driver's next available
+// device workflow.
+func (d *driver) NextDeviceInfo(
+	ctx types.Context) (*types.NextDeviceInfo, error) {
+	return nil, nil
+}
+
+// InstanceInspect returns an instance.
+func (d *driver) InstanceInspect(
+	ctx types.Context,
+	opts types.Store) (*types.Instance, error) {
+
+	iid := context.MustInstanceID(ctx)
+	return &types.Instance{Name: iid.ID, InstanceID: iid}, nil
+}
+
+func (d *driver) Volumes(
+	ctx types.Context,
+	opts *types.VolumesOpts) ([]*types.Volume, error) {
+
+	names, err := d.subvolumes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	volumes := []*types.Volume{}
+	for _, name := range names {
+		v, err := d.getVolume(ctx, name, opts.Attachments)
+		if err != nil {
+			return nil, err
+		}
+		volumes = append(volumes, v)
+	}
+
+	return utils.SortVolumeByID(volumes), nil
+}
+
+func (d *driver) VolumeInspect(
+	ctx types.Context,
+	volumeID string,
+	opts *types.VolumeInspectOpts) (*types.Volume, error) {
+
+	return d.getVolume(ctx, volumeID, opts.Attachments)
+}
+
+// VolumeCreate creates a new subvolume. The subvolume's size is enforced as
+// a quota; a subvolume without a size is not limited.
+func (d *driver) VolumeCreate(ctx types.Context, volumeName string,
+	opts *types.VolumeCreateOpts) (*types.Volume, error) {
+
+	if !isValidName(volumeName) {
+		return nil, goof.WithField(
+			"volumeName", volumeName, "invalid volume name")
+	}
+
+	if d.subvolumeExists(ctx, volumeName) {
+		return nil, utils.NewAlreadyExistsError(volumeName)
+	}
+
+	var args []string
+	if opts.Size != nil && *opts.Size > 0 {
+		args = d.subvolumeArgs(
+			"create", volumeName, "--size", sizeBytes(*opts.Size))
+	} else {
+		args = d.subvolumeArgs("create", volumeName)
+	}
+
+	if _, err := d.ceph(ctx, args...); err != nil {
+		return nil, err
+	}
+
+	return d.getVolume(ctx, volumeName, 0)
+}
+
+// VolumeRemove removes a subvolume along with its contents and snapshots.
+func (d *driver) VolumeRemove(
+	ctx types.Context,
+	volumeID string,
+	opts types.Store) error {
+
+	if !d.subvolumeExists(ctx, volumeID) {
+		return utils.NewNotFoundError(volumeID)
+	}
+
+	args := d.subvolumeArgs("rm", volumeID)
+	if opts != nil && opts.GetBool("force") {
+		args = d.subvolumeArgs("rm", volumeID, "--force")
+	}
+
+	_, err := d.ceph(ctx, args...)
+	return err
+}
+
+// VolumeAttach records the instance in the subvolume's
+// libstorage.attachments metadata key. No token is returned; the client
+// mounts the subvolume with the cephfs:// device of the attachment. A
+// forced attachment replaces the instances already recorded for the
+// subvolume.
+func (d *driver) VolumeAttach(
+	ctx types.Context,
+	volumeID string,
+	opts *types.VolumeAttachOpts) (*types.Volume, string, error) {
+
+	d.Lock()
+	defer d.Unlock()
+
+	if !d.subvolumeExists(ctx, volumeID) {
+		return nil, "", utils.NewNotFoundError(volumeID)
+	}
+
+	iid := context.MustInstanceID(ctx)
+
+	iids, err := d.readAttachments(ctx, volumeID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if opts.Force {
+		iids = nil
+	}
+
+	attached := false
+	for _, i := range iids {
+		if i.ID == iid.ID {
+			attached = true
+			break
+		}
+	}
+	if !attached {
+		iids = append(
+			iids, &types.InstanceID{ID: iid.ID, Driver: cephfs.Name})
+	}
+
+	if err := d.writeAttachments(ctx, volumeID, iids); err != nil {
+		return nil, "", err
+	}
+
+	vol, err := d.getVolume(ctx, volumeID, types.VolumeAttachmentsTrue)
+	if err != nil {
+		return nil, "", err
+	}
+
+	ctx.WithField("volumeID", volumeID).Info("attached volume")
+	return vol, "", nil
+}
+
+// VolumeDetach detaches a volume from the instance, or from all instances
+// if the detachment is forced.
+func (d *driver) VolumeDetach(
+	ctx types.Context,
+	volumeID string,
+	opts *types.VolumeDetachOpts) (*types.Volume, error) {
+
+	d.Lock()
+	defer d.Unlock()
+
+	if !d.subvolumeExists(ctx, volumeID) {
+		return nil, utils.NewNotFoundError(volumeID)
+	}
+
+	iid := context.MustInstanceID(ctx)
+
+	iids, err := d.readAttachments(ctx, volumeID)
+	if err != nil {
+		return nil, err
+	}
+
+	var newIIDs []*types.InstanceID
+	if !opts.Force {
+		for _, i := range iids {
+			if i.ID != iid.ID {
+				newIIDs = append(newIIDs, i)
+			}
+		}
+	}
+
+	if err := d.writeAttachments(ctx, volumeID, newIIDs); err != nil {
+		return nil, err
+	}
+
+	ctx.WithField("volumeID", volumeID).Info("detached volume")
+	return d.getVolume(ctx, volumeID, types.VolumeAttachmentsTrue)
+}
+
+// VolumeCreateFromSnapshot clones a snapshot into a new subvolume. The
+// clone is complete when the function returns.
+func (d *driver) VolumeCreateFromSnapshot(
+	ctx types.Context,
+	snapshotID, volumeName string,
+	opts *types.VolumeCreateOpts) (*types.Volume, error) {
+
+	volumeID, snapName, err := parseSnapshotID(snapshotID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := d.SnapshotInspect(ctx, snapshotID, nil); err != nil {
+		return nil, err
+	}
+
+	if err := d.clone(ctx, volumeID, snapName, volumeName); err != nil {
+		return nil, err
+	}
+
+	if opts.Size != nil && *opts.Size > 0 {
+		return d.VolumeExpand(ctx, volumeName, *opts.Size, nil)
+	}
+
+	return d.getVolume(ctx, volumeName, 0)
+}
+
+// VolumeCopy copies a subvolume by cloning a temporary snapshot of it.
+func (d *driver) VolumeCopy(
+	ctx types.Context,
+	volumeID, volumeName string,
+	opts types.Store) (*types.Volume, error) {
+
+	if !d.subvolumeExists(ctx, volumeID) {
+		return nil, utils.NewNotFoundError(volumeID)
+	}
+
+	snapName := copySnapshotPrefix + volumeName
+	if _, err := d.ceph(ctx, d.snapshotArgs(
+		"create", volumeID, snapName)...); err != nil {
+		return nil, err
+	}
+
+	cloneErr := d.clone(ctx, volumeID, snapName, volumeName)
+
+	if _, err := d.ceph(ctx, d.snapshotArgs(
+		"rm", volumeID, snapName)...); err != nil {
+		ctx.WithFields(log.Fields{
+			"volumeID": volumeID,
+			"snapshot": snapName,
+		}).WithError(err).Warn("error removing copy snapshot")
+	}
+
+	if cloneErr != nil {
+		return nil, cloneErr
+	}
+
+	return d.getVolume(ctx, volumeName, 0)
+}
+
+// VolumeExpand sets a subvolume's quota to the new size. Subvolumes may not
+// be shrunk.
+func (d *driver) VolumeExpand(
+	ctx types.Context,
+	volumeID string,
+	newSize int64,
+	opts types.Store) (*types.Volume, error) {
+
+	if !d.subvolumeExists(ctx, volumeID) {
+		return nil, utils.NewNotFoundError(volumeID)
+	}
+
+	if _, err := d.ceph(ctx, d.subvolumeArgs(
+		"resize", volumeID, sizeBytes(newSize), "--no_shrink")...); err != nil {
+		return nil, err
+	}
+
+	return d.getVolume(ctx, volumeID, 0)
+}
+
+// VolumeSnapshot creates a snapshot of a subvolume.
+func (d *driver) VolumeSnapshot(
+	ctx types.Context,
+	volumeID, snapshotName string,
+	opts types.Store) (*types.Snapshot, error) {
+
+	if !isValidName(snapshotName) {
+		return nil, goof.WithField(
+			"snapshotName", snapshotName, "invalid snapshot name")
+	}
+
+	if !d.subvolumeExists(ctx, volumeID) {
+		return nil, utils.NewNotFoundError(volumeID)
+	}
+
+	if _, err := d.ceph(ctx, d.snapshotArgs(
+		"create", volumeID, snapshotName)...); err != nil {
+		return nil, err
+	}
+
+	return d.SnapshotInspect(
+		ctx, volumeID+snapDelimiter+snapshotName, opts)
+}
+
+// Snapshots returns the snapshots of all of the subvolumes.
+func (d *driver) Snapshots(
+	ctx types.Context,
+	opts types.Store) ([]*types.Snapshot, error) {
+
+	names, err := d.subvolumes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots := []*types.Snapshot{}
+	for _, volumeID := range names {
+		snaps, err := d.volumeSnapshots(ctx, volumeID)
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, snaps...)
+	}
+	return snapshots, nil
+}
+
+// SnapshotInspect returns a snapshot. A snapshot's ID is the name of its
+// subvolume and the name of the snapshot, delimited by an at sign.
+func (d *driver) SnapshotInspect(
+	ctx types.Context,
+	snapshotID string,
+	opts types.Store) (*types.Snapshot, error) {
+
+	volumeID, _, err := parseSnapshotID(snapshotID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !d.subvolumeExists(ctx, volumeID) {
+		return nil, utils.NewNotFoundError(snapshotID)
+	}
+
+	snaps, err := d.volumeSnapshots(ctx, volumeID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, s := range snaps {
+		if s.ID == snapshotID {
+			return s, nil
+		}
+	}
+	return nil, utils.NewNotFoundError(snapshotID)
+}
+
+// SnapshotCopy (not implemented).
+func (d *driver) SnapshotCopy(
+	ctx types.Context,
+	snapshotID, snapshotName, destinationID string,
+	opts types.Store) (*types.Snapshot, error) {
+	return nil, types.ErrNotImplemented
+}
+
+// SnapshotRemove removes a snapshot. A snapshot cannot be removed while it
+// is being cloned.
+func (d *driver) SnapshotRemove(
+	ctx types.Context,
+	snapshotID string,
+	opts types.Store) error {
+
+	if _, err := d.SnapshotInspect(ctx, snapshotID, opts); err != nil {
+		return err
+	}
+
+	volumeID, snapName, _ := parseSnapshotID(snapshotID)
+	_, err := d.ceph(ctx, d.snapshotArgs("rm", volumeID, snapName)...)
+	return err
+}
+
+// HealthCheck verifies that the subvolumes can be listed.
+func (d *driver) HealthCheck(ctx types.Context) error {
+	_, err := d.subvolumes(ctx)
+	return err
+}
+
+func (d *driver) getVolume(
+	ctx types.Context,
+	volumeID string,
+	attachments types.VolumeAttachmentsTypes) (*types.Volume, error) {
+
+	info, err := d.subvolumeInfo(ctx, volumeID)
+	if err != nil {
+		return nil, err
+	}
+
+	vol := &types.Volume{
+		ID:   volumeID,
+		Name: volumeID,
+		Type: d.fsName(),
+	}
+	if quota, ok := info.BytesQuota.(float64); ok {
+		vol.Size = int64(quota) / bytesPerGb
+	}
+
+	if !attachments.Requested() {
+		return vol, nil
+	}
+
+	iids, err := d.readAttachments(ctx, volumeID)
+	if err != nil {
+		return nil, err
+	}
+	for _, iid := range iids {
+		vol.Attachments = append(vol.Attachments, &types.VolumeAttachment{
+			VolumeID:   volumeID,
+			InstanceID: iid,
+			DeviceName: cephUtils.Device(
+				d.monitors(), d.fsName(), info.Path),
+			Status: attachedStatus,
+		})
+	}
+	return vol, nil
+}
+
+// subvolumes returns the names of the subvolumes in the group.
+func (d *driver) subvolumes(ctx types.Context) ([]string, error) {
+	out, err := d.ceph(ctx, d.withGroup([]string{
+		"fs", "subvolume", "ls", d.fsName(), "--format", "json"})...)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []*nameEntry
+	if err := json.Unmarshal(out, &entries); err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+	for _, e := range entries {
+		names = append(names, e.Name)
+	}
+	return names, nil
+}
+
+// subvolumeInfo returns the information of a subvolume. An ErrNotFound
+// error is returned if the subvolume does not exist.
+func (d *driver) subvolumeInfo(
+	ctx types.Context, volumeID string) (*subvolumeInfo, error) {
+
+	if !isValidName(volumeID) {
+		return nil, utils.NewNotFoundError(volumeID)
+	}
+
+	out, err := d.ceph(ctx, d.subvolumeArgs(
+		"info", volumeID, "--format", "json")...)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, utils.NewNotFoundError(volumeID)
+		}
+		return nil, err
+	}
+
+	info := &subvolumeInfo{}
+	if err := json.Unmarshal(out, info); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+func (d *driver) subvolumeExists(ctx types.Context, volumeID string) bool {
+	_, err := d.subvolumeInfo(ctx, volumeID)
+	return err == nil
+}
+
+func (d *driver) volumeSnapshots(
+	ctx types.Context, volumeID string) ([]*types.Snapshot, error) {
+
+	vol, err := d.getVolume(ctx, volumeID, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := d.ceph(ctx, d.withGroup([]string{
+		"fs", "subvolume", "snapshot", "ls", d.fsName(), volumeID,
+		"--format", "json"})...)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []*nameEntry
+	if err := json.Unmarshal(out, &entries); err != nil {
+		return nil, err
+	}
+
+	snapshots := []*types.Snapshot{}
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name, copySnapshotPrefix) {
+			continue
+		}
+		snapshots = append(snapshots, &types.Snapshot{
+			ID:         volumeID + snapDelimiter + e.Name,
+			Name:       e.Name,
+			VolumeID:   volumeID,
+			VolumeSize: vol.Size,
+			Status:     "available",
+		})
+	}
+	return snapshots, nil
+}
+
+// clone clones a snapshot into a new subvolume and waits for the clone to
+// complete. A clone that does not complete within the clone timeout is
+// cancelled.
+func (d *driver) clone(
+	ctx types.Context,
+	volumeID, snapName, volumeName string) error {
+
+	if !isValidName(volumeName) {
+		return goof.WithField(
+			"volumeName", volumeName, "invalid volume name")
+	}
+
+	if d.subvolumeExists(ctx, volumeName) {
+		return utils.NewAlreadyExistsError(volumeName)
+	}
+
+	args := d.snapshotArgs("clone", volumeID, snapName, volumeName)
+	if g := d.group(); g != "" {
+		args = append(args, "--target_group_name", g)
+	}
+
+	if _, err := d.ceph(ctx, args...); err != nil {
+		return err
+	}
+
+	timeout, _ := d.cloneTimeout()
+	deadline := time.Now().Add(timeout)
+
+	statusArgs := d.withGroup([]string{
+		"fs", "clone", "status", d.fsName(), volumeName, "--format", "json"})
+
+	for {
+		out, err := d.ceph(ctx, statusArgs...)
+		if err != nil {
+			return err
+		}
+
+		status := &cloneStatus{}
+		if err := json.Unmarshal(out, status); err != nil {
+			return err
+		}
+
+		switch status.Status.State {
+		case "complete":
+			return nil
+		case "failed", "canceled":
+			return goof.WithFields(goof.Fields{
+				"volumeName": volumeName,
+				"state":      status.Status.State,
+			}, "clone did not complete")
+		}
+
+		if time.Now().After(deadline) {
+			d.ceph(ctx, d.withGroup([]string{
+				"fs", "clone", "cancel", d.fsName(), volumeName})...)
+			return goof.WithFields(goof.Fields{
+				"volumeName": volumeName,
+				"timeout":    timeout,
+			}, "clone timed out")
+		}
+
+		time.Sleep(time.Second)
+	}
+}
+
+func (d *driver) readAttachments(
+	ctx types.Context, volumeID string) ([]*types.InstanceID, error) {
+
+	out, err := d.ceph(ctx, d.metadataArgs("get", volumeID, attachmentsKey)...)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var iids []*types.InstanceID
+	if err := json.Unmarshal(out, &iids); err != nil {
+		return nil, goof.WithFieldE(
+			"volumeID", volumeID, "error reading volume attachments", err)
+	}
+	return iids, nil
+}
+
+func (d *driver) writeAttachments(
+	ctx types.Context, volumeID string, iids []*types.InstanceID) error {
+
+	if len(iids) == 0 {
+		_, err := d.ceph(ctx, d.metadataArgs(
+			"rm", volumeID, attachmentsKey, "--force")...)
+		if err != nil && !isNotFound(err) {
+			return err
+		}
+		return nil
+	}
+
+	buf, err := json.Marshal(iids)
+	if err != nil {
+		return err
+	}
+	_, err = d.ceph(ctx, d.metadataArgs(
+		"set", volumeID, attachmentsKey, string(buf))...)
+	return err
+}
+
+// subvolumeArgs returns the arguments of a ceph fs subvolume command
+// followed by the name of the group, if any.
+func (d *driver) subvolumeArgs(
+	cmd, volumeID string, extra ...string) []string {
+
+	args := []string{"fs", "subvolume", cmd, d.fsName(), volumeID}
+	return d.withGroup(append(args, extra...))
+}
+
+func (d *driver) snapshotArgs(
+	cmd, volumeID, snapName string, extra ...string) []string {
+
+	args := []string{
+		"fs", "subvolume", "snapshot", cmd, d.fsName(), volumeID, snapName}
+	return d.withGroup(append(args, extra...))
+}
+
+func (d *driver) metadataArgs(
+	cmd, volumeID string, extra ...string) []string {
+
+	args := []string{
+		"fs", "subvolume", "metadata", cmd, d.fsName(), volumeID}
+	return d.withGroup(append(args, extra...))
+}
+
+func (d *driver) withGroup(args []string) []string {
+	if g := d.group(); g != "" {
+		args = append(args, "--group_name", g)
+	}
+	return args
+}
+
+func (d *driver) ceph(ctx types.Context, args ...string) ([]byte, error) {
+	return d.run(ctx, d.config, args...)
+}
+
+func (d *driver) cloneTimeout() (time.Duration, error) {
+	return time.ParseDuration(d.config.GetString(cephfs.ConfigCloneTimeout))
+}
+
+func parseSnapshotID(snapshotID string) (string, string, error) {
+	parts := strings.SplitN(snapshotID, snapDelimiter, 2)
+	if len(parts) != 2 || !isValidName(parts[0]) || !isValidName(parts[1]) {
+		return "", "", utils.NewNotFoundError(snapshotID)
+	}
+	return parts[0], parts[1], nil
+}
+
+// isValidName returns a flag indicating whether the name may be used as the
+// name of a subvolume or snapshot.
+func isValidName(name string) bool {
+	return name != "" &&
+		!strings.HasPrefix(name, "-") &&
+		!strings.HasPrefix(name, ".") &&
+		!strings.ContainsAny(name, `/\@ `)
+}
+
+// isNotFound returns a flag indicating whether the ceph command failed
+// because a subvolume, snapshot, or metadata key does not exist.
+func isNotFound(err error) bool {
+	return types.IsErrorCode(err, types.ErrorCodeNotFound)
+}
+
+func sizeBytes(sizeGB int64) string {
+	return fmt.Sprintf("%d", sizeGB*bytesPerGb)
+}
+
+func (d *driver) fsName() string {
+	return d.config.GetString(cephfs.ConfigFSName)
+}
+
+func (d *driver) group() string {
+	return d.config.GetString(cephfs.ConfigGroup)
+}
+
+func (d *driver) monitors() string {
+	return d.config.GetString(cephfs.ConfigMonitors)
+}
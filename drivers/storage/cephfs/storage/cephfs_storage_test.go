@@ -0,0 +1,483 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	gofigCore "github.com/akutz/gofig"
+	gofig "github.com/akutz/gofig/types"
+	"github.com/akutz/goof"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codedellemc/libstorage/api/context"
+	"github.com/codedellemc/libstorage/api/types"
+	"github.com/codedellemc/libstorage/drivers/storage/cephfs"
+)
+
+// fakeSubvolume is a subvolume managed by fakeCeph.
+type fakeSubvolume struct {
+	quota    int64
+	metadata map[string]string
+	snaps    map[string]bool
+}
+
+// fakeCeph is a fake of the ceph command that manages the subvolumes of a
+// single file system in memory.
+type fakeCeph struct {
+	subvolumes map[string]*fakeSubvolume
+
+	// cloneStates are the states the clone status command reports for a
+	// clone, in order. The last state is reported once the others have
+	// been.
+	cloneStates []string
+
+	// errs are the errors returned by the commands, keyed by their
+	// subcommands, such as "subvolume create" or "clone status".
+	errs map[string]error
+
+	calls []string
+}
+
+func newFakeCeph() *fakeCeph {
+	return &fakeCeph{
+		subvolumes:  map[string]*fakeSubvolume{},
+		cloneStates: []string{"complete"},
+		errs:        map[string]error{},
+	}
+}
+
+// notFound returns the error cephUtils.Ceph returns when the ceph command
+// reports that a subvolume, snapshot, or metadata key does not exist.
+func notFound() error {
+	return goof.WithFields(goof.Fields{
+		"stderr":             "Error ENOENT: subvolume does not exist",
+		types.ErrorCodeField: types.ErrorCodeNotFound,
+	}, "error executing ceph")
+}
+
+func (f *fakeCeph) add(name string, quota int64) *fakeSubvolume {
+	sv := &fakeSubvolume{
+		quota:    quota,
+		metadata: map[string]string{},
+		snaps:    map[string]bool{},
+	}
+	f.subvolumes[name] = sv
+	return sv
+}
+
+func (f *fakeCeph) run(
+	ctx types.Context,
+	config gofig.Config,
+	args ...string) ([]byte, error) {
+
+	f.calls = append(f.calls, strings.Join(args, " "))
+
+	// drop the options and the name of the file system
+	var pos []string
+	for i := 0; i < len(args); i++ {
+		if strings.HasPrefix(args[i], "--") {
+			if args[i] != "--force" && args[i] != "--no_shrink" {
+				i++
+			}
+			continue
+		}
+		pos = append(pos, args[i])
+	}
+	cmd := pos[1] + " " + pos[2]
+	if cmd == "subvolume snapshot" || cmd == "subvolume metadata" {
+		cmd += " " + pos[3]
+		pos = pos[5:]
+	} else {
+		pos = pos[4:]
+	}
+	if err := f.errs[cmd]; err != nil {
+		return nil, err
+	}
+
+	var sv *fakeSubvolume
+	if len(pos) > 0 {
+		sv = f.subvolumes[pos[0]]
+		if sv == nil && cmd != "subvolume create" &&
+			cmd != "clone status" && cmd != "clone cancel" {
+			return nil, notFound()
+		}
+	}
+
+	switch cmd {
+	case "subvolume ls":
+		return f.names(f.subvolumes)
+	case "subvolume info":
+		info := map[string]interface{}{
+			"path":        "/volumes/_nogroup/" + pos[0] + "/uuid",
+			"bytes_quota": "infinite",
+		}
+		if sv.quota > 0 {
+			info["bytes_quota"] = sv.quota
+		}
+		return json.Marshal(info)
+	case "subvolume create":
+		f.add(pos[0], 0)
+		for i, a := range args {
+			if a == "--size" {
+				f.subvolumes[pos[0]].quota, _ = strconv.ParseInt(
+					args[i+1], 10, 64)
+			}
+		}
+	case "subvolume rm":
+		delete(f.subvolumes, pos[0])
+	case "subvolume resize":
+		sv.quota, _ = strconv.ParseInt(pos[1], 10, 64)
+	case "subvolume snapshot create":
+		sv.snaps[pos[1]] = true
+	case "subvolume snapshot rm":
+		delete(sv.snaps, pos[1])
+	case "subvolume snapshot ls":
+		snaps := map[string]*fakeSubvolume{}
+		for n := range sv.snaps {
+			snaps[n] = nil
+		}
+		return f.names(snaps)
+	case "subvolume snapshot clone":
+		f.add(pos[2], sv.quota)
+	case "subvolume metadata get":
+		v, ok := sv.metadata[pos[1]]
+		if !ok {
+			return nil, notFound()
+		}
+		return []byte(v), nil
+	case "subvolume metadata set":
+		sv.metadata[pos[1]] = pos[2]
+	case "subvolume metadata rm":
+		if _, ok := sv.metadata[pos[1]]; !ok {
+			return nil, notFound()
+		}
+		delete(sv.metadata, pos[1])
+	case "clone status":
+		state := f.cloneStates[0]
+		if len(f.cloneStates) > 1 {
+			f.cloneStates = f.cloneStates[1:]
+		}
+		return []byte(fmt.Sprintf(`{"status":{"state":%q}}`, state)), nil
+	}
+	return nil, nil
+}
+
+func (f *fakeCeph) names(m map[string]*fakeSubvolume) ([]byte, error) {
+	names := []string{}
+	for n := range m {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	entries := []*nameEntry{}
+	for _, n := range names {
+		entries = append(entries, &nameEntry{Name: n})
+	}
+	return json.Marshal(entries)
+}
+
+// called returns the commands the fake executed that begin with the
+// specified prefix in the order in which they were executed.
+func (f *fakeCeph) called(prefix string) []string {
+	var calls []string
+	for _, c := range f.calls {
+		if strings.HasPrefix(c, prefix) {
+			calls = append(calls, c)
+		}
+	}
+	return calls
+}
+
+func newTestDriver() (*driver, *fakeCeph) {
+	config := gofigCore.New()
+	config.Set(cephfs.ConfigFSName, "cephfs")
+	config.Set(cephfs.ConfigMonitors, "mon1, mon2")
+	config.Set(cephfs.ConfigCloneTimeout, "5m")
+	f := newFakeCeph()
+	return &driver{config: config, run: f.run}, f
+}
+
+func newTestContext(id string) types.Context {
+	return context.WithValue(
+		context.Background(),
+		context.InstanceIDKey,
+		&types.InstanceID{ID: id, Driver: cephfs.Name})
+}
+
+func size(gb int64) *int64 {
+	return &gb
+}
+
+func TestInit(t *testing.T) {
+	tests := []struct {
+		fsName       string
+		monitors     string
+		cloneTimeout string
+		err          bool
+	}{
+		{"cephfs", "mon1", "5m", false},
+		{"", "mon1", "5m", true},
+		{"cephfs", "", "5m", true},
+		{"cephfs", "mon1", "5", true},
+		{"cephfs", "mon1", "", true},
+	}
+	for _, tt := range tests {
+		config := gofigCore.New()
+		config.Set(cephfs.ConfigFSName, tt.fsName)
+		config.Set(cephfs.ConfigMonitors, tt.monitors)
+		config.Set(cephfs.ConfigCloneTimeout, tt.cloneTimeout)
+		err := (&driver{}).Init(context.Background(), config)
+		if tt.err {
+			assert.Error(t, err, "%+v", tt)
+		} else {
+			assert.NoError(t, err, "%+v", tt)
+		}
+	}
+}
+
+func TestIsValidName(t *testing.T) {
+	tests := []struct {
+		name  string
+		valid bool
+	}{
+		{"vol1", true},
+		{"vol-1.a_b", true},
+		{"", false},
+		{"-vol1", false},
+		{".vol1", false},
+		{"a/b", false},
+		{`a\b`, false},
+		{"vol1@snap1", false},
+		{"vol 1", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.valid, isValidName(tt.name), tt.name)
+	}
+}
+
+func TestParseSnapshotID(t *testing.T) {
+	volumeID, snapName, err := parseSnapshotID("vol1@snap1")
+	assert.NoError(t, err)
+	assert.Equal(t, "vol1", volumeID)
+	assert.Equal(t, "snap1", snapName)
+
+	for _, id := range []string{"vol1", "vol1@", "@snap1", "vol1@a@b"} {
+		_, _, err := parseSnapshotID(id)
+		assert.IsType(t, &types.ErrNotFound{}, err, id)
+	}
+}
+
+func TestVolumeCreate(t *testing.T) {
+	d, f := newTestDriver()
+	ctx := newTestContext("i-1")
+
+	vol, err := d.VolumeCreate(
+		ctx, "vol1", &types.VolumeCreateOpts{Size: size(10)})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(10), vol.Size)
+	assert.Equal(t, "cephfs", vol.Type)
+	assert.Equal(t,
+		[]string{"fs subvolume create cephfs vol1 --size 10737418240"},
+		f.called("fs subvolume create"))
+
+	// a subvolume without a size has no quota
+	d.config.Set(cephfs.ConfigGroup, "csi")
+	vol, err = d.VolumeCreate(ctx, "vol2", &types.VolumeCreateOpts{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), vol.Size)
+	assert.Equal(t,
+		"fs subvolume create cephfs vol2 --group_name csi",
+		f.called("fs subvolume create")[1])
+
+	_, err = d.VolumeCreate(ctx, "vol1", &types.VolumeCreateOpts{})
+	assert.IsType(t, &types.ErrAlreadyExists{}, err)
+	_, err = d.VolumeCreate(ctx, "../vol3", &types.VolumeCreateOpts{})
+	assert.Error(t, err)
+	assert.Len(t, f.called("fs subvolume create"), 2)
+}
+
+func TestNotFound(t *testing.T) {
+	d, f := newTestDriver()
+	ctx := newTestContext("i-1")
+
+	_, err := d.VolumeInspect(ctx, "vol1", &types.VolumeInspectOpts{})
+	assert.IsType(t, &types.ErrNotFound{}, err)
+	assert.IsType(t, &types.ErrNotFound{}, d.VolumeRemove(ctx, "vol1", nil))
+	_, err = d.VolumeExpand(ctx, "vol1", 2, nil)
+	assert.IsType(t, &types.ErrNotFound{}, err)
+	_, _, err = d.VolumeAttach(ctx, "vol1", &types.VolumeAttachOpts{})
+	assert.IsType(t, &types.ErrNotFound{}, err)
+	_, err = d.SnapshotInspect(ctx, "vol1@snap1", nil)
+	assert.IsType(t, &types.ErrNotFound{}, err)
+
+	// invalid names are not found without executing ceph
+	f.calls = nil
+	_, err = d.VolumeInspect(ctx, ".vol1", &types.VolumeInspectOpts{})
+	assert.IsType(t, &types.ErrNotFound{}, err)
+	assert.Empty(t, f.calls)
+
+	f.add("vol1", 0)
+	_, err = d.SnapshotInspect(ctx, "vol1@snap1", nil)
+	assert.IsType(t, &types.ErrNotFound{}, err)
+}
+
+func TestVolumeInspectError(t *testing.T) {
+	d, f := newTestDriver()
+	f.errs["subvolume info"] = goof.WithFields(goof.Fields{
+		types.ErrorCodeField: types.ErrorCodePermissionDenied,
+	}, "error executing ceph")
+
+	_, err := d.VolumeInspect(
+		newTestContext("i-1"), "vol1", &types.VolumeInspectOpts{})
+	assert.Equal(t, f.errs["subvolume info"], err)
+}
+
+func TestVolumeAttachDetach(t *testing.T) {
+	d, f := newTestDriver()
+	ctx1, ctx2 := newTestContext("i-1"), newTestContext("i-2")
+	sv := f.add("vol1", 0)
+
+	vol, token, err := d.VolumeAttach(ctx1, "vol1", &types.VolumeAttachOpts{})
+	assert.NoError(t, err)
+	assert.Equal(t, "", token)
+	if assert.Len(t, vol.Attachments, 1) {
+		assert.Equal(t, "i-1", vol.Attachments[0].InstanceID.ID)
+		assert.Equal(t,
+			"cephfs://mon1,mon2/volumes/_nogroup/vol1/uuid?fs=cephfs",
+			vol.Attachments[0].DeviceName)
+	}
+
+	vol, _, err = d.VolumeAttach(ctx2, "vol1", &types.VolumeAttachOpts{})
+	assert.NoError(t, err)
+	assert.Len(t, vol.Attachments, 2)
+
+	vol, _, err = d.VolumeAttach(
+		ctx1, "vol1", &types.VolumeAttachOpts{Force: true})
+	assert.NoError(t, err)
+	assert.Len(t, vol.Attachments, 1)
+
+	vol, err = d.VolumeDetach(ctx1, "vol1", &types.VolumeDetachOpts{})
+	assert.NoError(t, err)
+	assert.Empty(t, vol.Attachments)
+	assert.NotContains(t, sv.metadata, attachmentsKey)
+
+	// detaching an unattached volume is not an error
+	_, err = d.VolumeDetach(ctx1, "vol1", &types.VolumeDetachOpts{})
+	assert.NoError(t, err)
+
+	sv.metadata[attachmentsKey] = "{"
+	_, err = d.VolumeInspect(ctx1, "vol1", &types.VolumeInspectOpts{
+		Attachments: types.VolumeAttachmentsTrue})
+	assert.Error(t, err)
+}
+
+func TestVolumeExpand(t *testing.T) {
+	d, f := newTestDriver()
+	f.add("vol1", bytesPerGb)
+
+	vol, err := d.VolumeExpand(newTestContext("i-1"), "vol1", 4, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), vol.Size)
+	assert.Equal(t,
+		[]string{"fs subvolume resize cephfs vol1 4294967296 --no_shrink"},
+		f.called("fs subvolume resize"))
+}
+
+func TestVolumeCreateFromSnapshot(t *testing.T) {
+	d, f := newTestDriver()
+	ctx := newTestContext("i-1")
+	d.config.Set(cephfs.ConfigGroup, "csi")
+	f.add("vol1", bytesPerGb).snaps["snap1"] = true
+	f.cloneStates = []string{"in-progress", "complete"}
+
+	vol, err := d.VolumeCreateFromSnapshot(
+		ctx, "vol1@snap1", "vol2", &types.VolumeCreateOpts{})
+	assert.NoError(t, err)
+	assert.Equal(t, "vol2", vol.ID)
+	assert.Equal(t, []string{
+		"fs subvolume snapshot clone cephfs vol1 snap1 vol2 " +
+			"--group_name csi --target_group_name csi",
+	}, f.called("fs subvolume snapshot clone"))
+	assert.Len(t, f.called("fs clone status"), 2)
+
+	_, err = d.VolumeCreateFromSnapshot(
+		ctx, "vol1@snap2", "vol3", &types.VolumeCreateOpts{})
+	assert.IsType(t, &types.ErrNotFound{}, err)
+}
+
+func TestCloneFailed(t *testing.T) {
+	d, f := newTestDriver()
+	ctx := newTestContext("i-1")
+	f.add("vol1", 0).snaps["snap1"] = true
+	f.cloneStates = []string{"failed"}
+
+	_, err := d.VolumeCreateFromSnapshot(
+		ctx, "vol1@snap1", "vol2", &types.VolumeCreateOpts{})
+	assert.Error(t, err)
+	assert.Empty(t, f.called("fs clone cancel"))
+}
+
+func TestCloneTimeout(t *testing.T) {
+	d, f := newTestDriver()
+	ctx := newTestContext("i-1")
+	d.config.Set(cephfs.ConfigCloneTimeout, "0s")
+	f.add("vol1", 0).snaps["snap1"] = true
+	f.cloneStates = []string{"in-progress"}
+
+	_, err := d.VolumeCreateFromSnapshot(
+		ctx, "vol1@snap1", "vol2", &types.VolumeCreateOpts{})
+	assert.Error(t, err)
+	assert.Equal(t,
+		[]string{"fs clone cancel cephfs vol2"}, f.called("fs clone cancel"))
+}
+
+func TestVolumeCopy(t *testing.T) {
+	d, f := newTestDriver()
+	ctx := newTestContext("i-1")
+	sv := f.add("vol1", bytesPerGb)
+
+	vol, err := d.VolumeCopy(ctx, "vol1", "vol2", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), vol.Size)
+	assert.Empty(t, sv.snaps)
+
+	// the temporary snapshot is removed even if the clone fails
+	f.cloneStates = []string{"failed"}
+	_, err = d.VolumeCopy(ctx, "vol1", "vol3", nil)
+	assert.Error(t, err)
+	assert.Empty(t, sv.snaps)
+	assert.Equal(t, []string{
+		"fs subvolume snapshot rm cephfs vol1 libstorage-copy-vol2",
+		"fs subvolume snapshot rm cephfs vol1 libstorage-copy-vol3",
+	}, f.called("fs subvolume snapshot rm"))
+}
+
+func TestSnapshots(t *testing.T) {
+	d, f := newTestDriver()
+	ctx := newTestContext("i-1")
+	sv := f.add("vol1", 2*bytesPerGb)
+	sv.snaps[copySnapshotPrefix+"vol2"] = true
+
+	snap, err := d.VolumeSnapshot(ctx, "vol1", "snap1", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "vol1@snap1", snap.ID)
+	assert.Equal(t, int64(2), snap.VolumeSize)
+
+	_, err = d.VolumeSnapshot(ctx, "vol1", "snap@1", nil)
+	assert.Error(t, err)
+
+	// the snapshots from which copies are cloned are not listed
+	snaps, err := d.Snapshots(ctx, nil)
+	assert.NoError(t, err)
+	if assert.Len(t, snaps, 1) {
+		assert.Equal(t, "vol1@snap1", snaps[0].ID)
+	}
+
+	assert.NoError(t, d.SnapshotRemove(ctx, "vol1@snap1", nil))
+	assert.NotContains(t, sv.snaps, "snap1")
+	assert.IsType(t, &types.ErrNotFound{},
+		d.SnapshotRemove(ctx, "vol1@snap1", nil))
+}
@@ -0,0 +1,129 @@
+package cephfs
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	gofig "github.com/akutz/gofig/types"
+	"github.com/akutz/gotil"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codedellemc/libstorage/api/server"
+	apitests "github.com/codedellemc/libstorage/api/tests"
+	"github.com/codedellemc/libstorage/api/types"
+
+	// load the driver
+	"github.com/codedellemc/libstorage/drivers/storage/cephfs"
+	cephfsx "github.com/codedellemc/libstorage/drivers/storage/cephfs/executor"
+	_ "github.com/codedellemc/libstorage/drivers/storage/cephfs/storage"
+)
+
+// skipTests returns a flag indicating whether the tests are skipped. The
+// tests require the ceph command and access to a Ceph cluster whose
+// monitors are specified with CEPHFS_MONITORS.
+func skipTests() bool {
+	noTest, _ := strconv.ParseBool(os.Getenv("TEST_SKIP_CEPHFS"))
+	return noTest ||
+		os.Getenv("CEPHFS_MONITORS") == "" ||
+		!gotil.FileExistsInPath("ceph")
+}
+
+var volumeName string
+
+func init() {
+	uuid, _ := types.NewUUID()
+	volumeName = "ls-test-" + uuid.String()[:8]
+}
+
+func TestMain(m *testing.M) {
+	server.CloseOnAbort()
+	ec := m.Run()
+	os.Exit(ec)
+}
+
+func newTestConfig() []byte {
+	return []byte(`
+cephfs:
+  monitors: ` + os.Getenv("CEPHFS_MONITORS") + `
+`)
+}
+
+func TestInstanceID(t *testing.T) {
+	if skipTests() {
+		t.SkipNow()
+	}
+
+	iid, err := cephfsx.InstanceID()
+	assert.NoError(t, err)
+	if err != nil {
+		t.FailNow()
+	}
+
+	apitests.Run(
+		t, cephfs.Name, newTestConfig(),
+		(&apitests.InstanceIDTest{
+			Driver:   cephfs.Name,
+			Expected: iid,
+		}).Test)
+}
+
+func TestVolumeCreateRemove(t *testing.T) {
+	if skipTests() {
+		t.SkipNow()
+	}
+
+	tf := func(config gofig.Config, client types.Client, t *testing.T) {
+		size := int64(1)
+		vol, err := client.API().VolumeCreate(
+			nil, cephfs.Name, &types.VolumeCreateRequest{
+				Name: volumeName,
+				Size: &size,
+			})
+		assert.NoError(t, err)
+		if err != nil {
+			t.FailNow()
+		}
+		assert.Equal(t, volumeName, vol.ID)
+		assert.Equal(t, size, vol.Size)
+
+		assert.NoError(t, client.API().VolumeRemove(
+			nil, cephfs.Name, volumeName))
+	}
+	apitests.Run(t, cephfs.Name, newTestConfig(), tf)
+}
+
+func TestVolumeAttachDetach(t *testing.T) {
+	if skipTests() {
+		t.SkipNow()
+	}
+
+	tf := func(config gofig.Config, client types.Client, t *testing.T) {
+		_, err := client.API().VolumeCreate(
+			nil, cephfs.Name, &types.VolumeCreateRequest{Name: volumeName})
+		assert.NoError(t, err)
+		if err != nil {
+			t.FailNow()
+		}
+		defer client.API().VolumeRemove(nil, cephfs.Name, volumeName)
+
+		vol, _, err := client.API().VolumeAttach(
+			nil, cephfs.Name, volumeName, &types.VolumeAttachRequest{})
+		assert.NoError(t, err)
+		if err != nil {
+			t.FailNow()
+		}
+		if !assert.Len(t, vol.Attachments, 1) {
+			t.FailNow()
+		}
+		assert.True(t, strings.HasPrefix(
+			vol.Attachments[0].DeviceName, "cephfs://"))
+
+		vol, err = client.API().VolumeDetach(
+			nil, cephfs.Name, volumeName, &types.VolumeDetachRequest{})
+		assert.NoError(t, err)
+		assert.Len(t, vol.Attachments, 0)
+	}
+	apitests.Run(t, cephfs.Name, newTestConfig(), tf)
+}
@@ -0,0 +1,2 @@
+CEPHFS_COVERPKG := $(ROOT_IMPORT_PATH)/drivers/storage/cephfs
+TEST_COVERPKG_./drivers/storage/cephfs/tests := $(CEPHFS_COVERPKG),$(CEPHFS_COVERPKG)/executor,$(CEPHFS_COVERPKG)/storage
@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	gofig "github.com/akutz/gofig/types"
+	"github.com/akutz/goof"
+
+	"github.com/codedellemc/libstorage/api/types"
+	"github.com/codedellemc/libstorage/api/utils"
+	"github.com/codedellemc/libstorage/drivers/storage/cephfs"
+)
+
+// DevicePrefix is the prefix of the devices of CephFS subvolumes.
+const DevicePrefix = "cephfs://"
+
+// Ceph executes the ceph command as the configured Ceph user and returns the
+// command's standard output. The command's standard error is included in
+// the returned error if the command fails, along with the code of the error
+// the standard error describes.
+func Ceph(
+	ctx types.Context,
+	config gofig.Config,
+	args ...string) ([]byte, error) {
+
+	args = append(credentialArgs(config), args...)
+	if ctx != nil {
+		ctx.WithField("args", args).Debug("executing ceph")
+	}
+
+	stderr := &bytes.Buffer{}
+	cmd := exec.Command("ceph", args...)
+	cmd.Stderr = stderr
+
+	out, err := utils.CommandOutput(ctx, cmd)
+	if err != nil {
+		fields := goof.Fields{
+			"args":   args,
+			"stderr": strings.TrimSpace(stderr.String()),
+		}
+		if code := utils.CommandErrorCode(stderr.String()); code != "" {
+			fields[types.ErrorCodeField] = code
+		}
+		return nil, goof.WithFieldsE(fields, "error executing ceph", err)
+	}
+	return out, nil
+}
+
+func credentialArgs(config gofig.Config) []string {
+	args := []string{"--id", config.GetString(cephfs.ConfigUser)}
+	if v := config.GetString(cephfs.ConfigKeyring); v != "" {
+		args = append(args, "--keyring", v)
+	}
+	if v := config.GetString(cephfs.ConfigCephConfig); v != "" {
+		args = append(args, "--conf", v)
+	}
+	return args
+}
+
+// Device returns the device of a subvolume, formatted as
+// cephfs://mon1[,mon2,...]/path?fs=name, from which the linux OS driver's
+// kernel CephFS mount handler mounts the subvolume.
+func Device(monitors, fsName, subvolumePath string) string {
+	return fmt.Sprintf("%s%s/%s?fs=%s",
+		DevicePrefix,
+		strings.Replace(monitors, " ", "", -1),
+		strings.TrimPrefix(subvolumePath, "/"),
+		fsName)
+}
@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	gofigCore "github.com/akutz/gofig"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codedellemc/libstorage/api/types"
+	"github.com/codedellemc/libstorage/drivers/storage/cephfs"
+)
+
+func TestDevice(t *testing.T) {
+	assert.Equal(t,
+		"cephfs://mon1,mon2/volumes/_nogroup/vol1/abc?fs=cephfs",
+		Device("mon1, mon2", "cephfs", "/volumes/_nogroup/vol1/abc"))
+}
+
+func TestCredentialArgs(t *testing.T) {
+	config := gofigCore.New()
+	config.Set(cephfs.ConfigUser, "admin")
+	assert.Equal(t, []string{"--id", "admin"}, credentialArgs(config))
+
+	config.Set(cephfs.ConfigKeyring, "/etc/ceph/admin.keyring")
+	config.Set(cephfs.ConfigCephConfig, "/etc/ceph/ceph.conf")
+	assert.Equal(t, []string{
+		"--id", "admin",
+		"--keyring", "/etc/ceph/admin.keyring",
+		"--conf", "/etc/ceph/ceph.conf",
+	}, credentialArgs(config))
+}
+
+// withFakeCeph prepends a directory with a ceph script to the path for the
+// duration of a test.
+func withFakeCeph(t *testing.T, script string) func() {
+	dir, err := ioutil.TempDir("", "ceph")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(
+		path.Join(dir, "ceph"), []byte("#!/bin/sh\n"+script), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := os.Getenv("PATH")
+	os.Setenv("PATH", dir+string(os.PathListSeparator)+p)
+	return func() {
+		os.Setenv("PATH", p)
+		os.RemoveAll(dir)
+	}
+}
+
+func TestCeph(t *testing.T) {
+	defer withFakeCeph(t, `echo "$@"`)()
+
+	config := gofigCore.New()
+	config.Set(cephfs.ConfigUser, "admin")
+	out, err := Ceph(nil, config, "fs", "subvolume", "ls", "cephfs")
+	assert.NoError(t, err)
+	assert.Equal(t, "--id admin fs subvolume ls cephfs\n", string(out))
+}
+
+func TestCephError(t *testing.T) {
+	tests := []struct {
+		stderr string
+		code   types.ErrorCode
+	}{
+		{
+			"Error ENOENT: subvolume 'vol1' does not exist: " +
+				"No such file or directory",
+			types.ErrorCodeNotFound,
+		},
+		{
+			"Error EEXIST: subvolume 'vol1' exists: File exists",
+			types.ErrorCodeAlreadyExists,
+		},
+		{"Error EINVAL: invalid command", ""},
+	}
+	for _, tt := range tests {
+		cleanup := withFakeCeph(
+			t, fmt.Sprintf("echo \"%s\" >&2\nexit 2", tt.stderr))
+		_, err := Ceph(nil, gofigCore.New(), "fs", "subvolume", "info")
+		cleanup()
+		if assert.Error(t, err, tt.stderr) {
+			assert.Equal(t, tt.code, types.ErrorCodeOf(err), tt.stderr)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package cephrbd
+
+import (
+	gofigCore "github.com/akutz/gofig"
+	gofig "github.com/akutz/gofig/types"
+)
+
+const (
+	// Name is the provider's name.
+	Name = "cephrbd"
+
+	// ConfigPool is a config key.
+	ConfigPool = Name + ".pool"
+
+	// ConfigUser is a config key.
+	ConfigUser = Name + ".user"
+
+	// ConfigKeyring is a config key.
+	ConfigKeyring = Name + ".keyring"
+
+	// ConfigCephConfig is a config key.
+	ConfigCephConfig = Name + ".cephConfig"
+
+	// ConfigImageFeatures is a config key.
+	ConfigImageFeatures = Name + ".imageFeatures"
+)
+
+func init() {
+	r := gofigCore.NewRegistration("Ceph RBD")
+	r.Key(gofig.String, "", "rbd",
+		"The pool in which images are managed", ConfigPool)
+	r.Key(gofig.String, "", "admin",
+		"The Ceph user as which the rbd command is executed", ConfigUser)
+	r.Key(gofig.String, "", "",
+		"The path to the Ceph user's keyring", ConfigKeyring)
+	r.Key(gofig.String, "", "",
+		"The path to the Ceph configuration file", ConfigCephConfig)
+	r.Key(gofig.String, "", "layering",
+		"The features with which images are created", ConfigImageFeatures)
+	gofigCore.Register(r)
+}
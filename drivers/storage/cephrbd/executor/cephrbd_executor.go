@@ -0,0 +1,90 @@
+package executor
+
+import (
+	gofig "github.com/akutz/gofig/types"
+	"github.com/akutz/gotil"
+
+	"github.com/codedellemc/libstorage/api/registry"
+	"github.com/codedellemc/libstorage/api/types"
+	"github.com/codedellemc/libstorage/api/utils"
+	"github.com/codedellemc/libstorage/drivers/storage/cephrbd"
+	rbdUtils "github.com/codedellemc/libstorage/drivers/storage/cephrbd/utils"
+)
+
+// driver is the storage executor for the cephrbd storage driver.
+type driver struct {
+	config gofig.Config
+}
+
+func init() {
+	registry.RegisterStorageExecutor(cephrbd.Name, newDriver)
+}
+
+func newDriver() types.StorageExecutor {
+	return &driver{}
+}
+
+func (d *driver) Init(ctx types.Context, config gofig.Config) error {
+	d.config = config
+	return nil
+}
+
+func (d *driver) Name() string {
+	return cephrbd.Name
+}
+
+// Supported returns a flag indicating whether or not the platform
+// implementing the executor is valid for the host on which the executor
+// resides.
+func (d *driver) Supported(
+	ctx types.Context,
+	opts types.Store) (bool, error) {
+
+	return gotil.FileExistsInPath("rbd"), nil
+}
+
+// InstanceID returns the local instance ID for the test
+func InstanceID() (*types.InstanceID, error) {
+	return newDriver().InstanceID(nil, nil)
+}
+
+// InstanceID returns the local system's host name.
+func (d *driver) InstanceID(
+	ctx types.Context,
+	opts types.Store) (*types.InstanceID, error) {
+
+	hostName, err := utils.HostName()
+	if err != nil {
+		return nil, err
+	}
+	return &types.InstanceID{ID: hostName, Driver: cephrbd.Name}, nil
+}
+
+func (d *driver) NextDevice(
+	ctx types.Context,
+	opts types.Store) (string, error) {
+	return "", types.ErrNotImplemented
+}
+
+// LocalDevices returns the /dev/rbd* devices to which images are mapped. The
+// devices are mapped to the names of their images.
+func (d *driver) LocalDevices(
+	ctx types.Context,
+	opts *types.LocalDevicesOpts) (*types.LocalDevices, error) {
+
+	mapped, err := rbdUtils.ShowMapped(ctx, d.config)
+	if err != nil {
+		return nil, err
+	}
+
+	devMap := map[string]string{}
+	for dev, i := range mapped {
+		devMap[dev] = i.Pool + "/" + i.Name
+	}
+
+	ld := &types.LocalDevices{Driver: d.Name()}
+	if len(devMap) > 0 {
+		ld.DeviceMap = devMap
+	}
+	return ld, nil
+}
@@ -0,0 +1,678 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	log "github.com/Sirupsen/logrus"
+	gofig "github.com/akutz/gofig/types"
+	"github.com/akutz/goof"
+
+	"github.com/codedellemc/libstorage/api/context"
+	"github.com/codedellemc/libstorage/api/registry"
+	"github.com/codedellemc/libstorage/api/types"
+	"github.com/codedellemc/libstorage/api/utils"
+	"github.com/codedellemc/libstorage/drivers/storage/cephrbd"
+	rbdUtils "github.com/codedellemc/libstorage/drivers/storage/cephrbd/utils"
+)
+
+const (
+	bytesPerGb    = int64(1024 * 1024 * 1024)
+	mbPerGb       = int64(1024)
+	snapDelimiter = "@"
+)
+
+// driver is a storage driver that manages the images in a Ceph pool with
+// the rbd command. Since images are mapped to the devices of the host on
+// which the command is executed, the server must run on the same host as
+// the client, for example as an embedded server.
+type driver struct {
+	sync.Mutex
+	config gofig.Config
+
+	// run executes the rbd command. It is rbdUtils.RBD unless the driver's
+	// tests replace the command with a fake.
+	run func(
+		ctx types.Context,
+		config gofig.Config,
+		args ...string) ([]byte, error)
+}
+
+// rbdImage is an entry in the output of the rbd ls --long command.
+type rbdImage struct {
+	Image    string `json:"image"`
+	Snapshot string `json:"snapshot"`
+	Size     int64  `json:"size"`
+}
+
+// rbdInfo is the output of the rbd info command.
+type rbdInfo struct {
+	Name      string `json:"name"`
+	Size      int64  `json:"size"`
+	Protected string `json:"protected"`
+}
+
+// rbdSnap is an entry in the output of the rbd snap ls command.
+type rbdSnap struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+	Size int64  `json:"size"`
+}
+
+func init() {
+	registry.RegisterStorageDriver(cephrbd.Name, newDriver)
+}
+
+func newDriver() types.StorageDriver {
+	return &driver{run: rbdUtils.RBD}
+}
+
+// Name returns the name of the driver
+func (d *driver) Name() string {
+	return cephrbd.Name
+}
+
+// Init initializes the driver.
+func (d *driver) Init(ctx types.Context, config gofig.Config) error {
+	d.config = config
+
+	fields := log.Fields{
+		"pool":          d.pool(),
+		"user":          d.config.GetString(cephrbd.ConfigUser),
+		"keyring":       d.config.GetString(cephrbd.ConfigKeyring),
+		"cephConfig":    d.config.GetString(cephrbd.ConfigCephConfig),
+		"imageFeatures": d.imageFeatures(),
+	}
+
+	if d.pool() == "" {
+		return goof.WithFields(fields, "pool is required")
+	}
+
+	ctx.WithFields(fields).Info("storage driver initialized")
+	return nil
+}
+
+// Type returns the type of storage a driver provides
+func (d *driver) Type(ctx types.Context) (types.StorageType, error) {
+	return types.Block, nil
+}
+
+// Capabilities advertises block attach semantics along with snapshots and
+// expansion, which rbd supports for every image in the pool.
+func (d *driver) Capabilities(
+	ctx types.Context) (*types.StorageCapabilities, error) {
+	return &types.StorageCapabilities{
+		SupportsSnapshots: true,
+		SupportsExpand:    true,
+		AttachSemantics:   types.Block,
+	}, nil
+}
+
+// NextDeviceInfo returns the information about the driver's next available
+// device workflow.
+func (d *driver) NextDeviceInfo(
+	ctx types.Context) (*types.NextDeviceInfo, error) {
+	return nil, nil
+}
+
+// InstanceInspect returns an instance.
+func (d *driver) InstanceInspect(
+	ctx types.Context,
+	opts types.Store) (*types.Instance, error) {
+
+	iid := context.MustInstanceID(ctx)
+	return &types.Instance{Name: iid.ID, InstanceID: iid}, nil
+}
+
+func (d *driver) Volumes(
+	ctx types.Context,
+	opts *types.VolumesOpts) ([]*types.Volume, error) {
+
+	out, err := d.rbd(ctx, "ls", "--long", "--format", "json", d.pool())
+	if err != nil {
+		return nil, err
+	}
+
+	var images []*rbdImage
+	if err := json.Unmarshal(out, &images); err != nil {
+		return nil, err
+	}
+
+	mapped, err := d.mappedImages(ctx, opts.Attachments)
+	if err != nil {
+		return nil, err
+	}
+
+	volumes := []*types.Volume{}
+	for _, i := range images {
+		if i.Snapshot != "" {
+			continue
+		}
+		volumes = append(volumes, d.toTypesVolume(
+			ctx, i.Image, i.Size, opts.Attachments, mapped))
+	}
+	return utils.SortVolumeByID(volumes), nil
+}
+
+func (d *driver) VolumeInspect(
+	ctx types.Context,
+	volumeID string,
+	opts *types.VolumeInspectOpts) (*types.Volume, error) {
+
+	info, err := d.imageInfo(ctx, volumeID)
+	if err != nil {
+		return nil, err
+	}
+
+	mapped, err := d.mappedImages(ctx, opts.Attachments)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.toTypesVolume(
+		ctx, volumeID, info.Size, opts.Attachments, mapped), nil
+}
+
+// VolumeCreate creates a new image.
+func (d *driver) VolumeCreate(ctx types.Context, volumeName string,
+	opts *types.VolumeCreateOpts) (*types.Volume, error) {
+
+	if !isValidName(volumeName) {
+		return nil, goof.WithField(
+			"volumeName", volumeName, "invalid volume name")
+	}
+
+	if opts.Size == nil || *opts.Size <= 0 {
+		return nil, goof.WithField(
+			"volumeName", volumeName, "volume size is required")
+	}
+
+	args := []string{
+		"create", "--size", sizeMB(*opts.Size),
+	}
+	if v := d.imageFeatures(); v != "" {
+		args = append(args, "--image-feature", v)
+	}
+	args = append(args, d.imageSpec(volumeName))
+
+	if _, err := d.rbd(ctx, args...); err != nil {
+		return nil, err
+	}
+
+	return d.VolumeInspect(ctx, volumeName,
+		&types.VolumeInspectOpts{Attachments: 0})
+}
+
+// VolumeCreateFromSnapshot clones a snapshot. The snapshot is protected
+// first since only protected snapshots may be cloned.
+func (d *driver) VolumeCreateFromSnapshot(
+	ctx types.Context,
+	snapshotID, volumeName string,
+	opts *types.VolumeCreateOpts) (*types.Volume, error) {
+
+	if !isValidName(volumeName) {
+		return nil, goof.WithField(
+			"volumeName", volumeName, "invalid volume name")
+	}
+
+	if _, _, err := parseSnapshotID(snapshotID); err != nil {
+		return nil, err
+	}
+
+	info, err := d.imageInfo(ctx, snapshotID)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.Protected != "true" {
+		if _, err := d.rbd(
+			ctx, "snap", "protect", d.imageSpec(snapshotID)); err != nil {
+			return nil, err
+		}
+	}
+
+	args := []string{"clone"}
+	if v := d.imageFeatures(); v != "" {
+		args = append(args, "--image-feature", v)
+	}
+	args = append(args, d.imageSpec(snapshotID), d.imageSpec(volumeName))
+
+	if _, err := d.rbd(ctx, args...); err != nil {
+		return nil, err
+	}
+
+	if opts.Size != nil && *opts.Size*bytesPerGb > info.Size {
+		if _, err := d.rbd(ctx, "resize", "--size", sizeMB(*opts.Size),
+			d.imageSpec(volumeName)); err != nil {
+			return nil, err
+		}
+	}
+
+	return d.VolumeInspect(ctx, volumeName,
+		&types.VolumeInspectOpts{Attachments: 0})
+}
+
+// VolumeCopy copies an image.
+func (d *driver) VolumeCopy(
+	ctx types.Context,
+	volumeID, volumeName string,
+	opts types.Store) (*types.Volume, error) {
+
+	if !isValidName(volumeName) {
+		return nil, goof.WithField(
+			"volumeName", volumeName, "invalid volume name")
+	}
+
+	if _, err := d.rbd(ctx, "cp",
+		d.imageSpec(volumeID), d.imageSpec(volumeName)); err != nil {
+		return nil, err
+	}
+
+	return d.VolumeInspect(ctx, volumeName,
+		&types.VolumeInspectOpts{Attachments: 0})
+}
+
+// VolumeExpand resizes an image. Images may not be shrunk.
+func (d *driver) VolumeExpand(
+	ctx types.Context,
+	volumeID string,
+	newSize int64,
+	opts types.Store) (*types.Volume, error) {
+
+	info, err := d.imageInfo(ctx, volumeID)
+	if err != nil {
+		return nil, err
+	}
+
+	if newSize*bytesPerGb < info.Size {
+		return nil, goof.WithFields(goof.Fields{
+			"volumeID": volumeID,
+			"size":     info.Size / bytesPerGb,
+			"newSize":  newSize,
+		}, "cannot shrink volume")
+	}
+
+	if _, err := d.rbd(ctx, "resize", "--size", sizeMB(newSize),
+		d.imageSpec(volumeID)); err != nil {
+		return nil, err
+	}
+
+	return d.VolumeInspect(ctx, volumeID,
+		&types.VolumeInspectOpts{Attachments: 0})
+}
+
+// VolumeSnapshot creates a snapshot of an image.
+func (d *driver) VolumeSnapshot(
+	ctx types.Context,
+	volumeID, snapshotName string,
+	opts types.Store) (*types.Snapshot, error) {
+
+	if !isValidName(snapshotName) {
+		return nil, goof.WithField(
+			"snapshotName", snapshotName, "invalid snapshot name")
+	}
+
+	snapshotID := volumeID + snapDelimiter + snapshotName
+	if _, err := d.rbd(
+		ctx, "snap", "create", d.imageSpec(snapshotID)); err != nil {
+		return nil, err
+	}
+
+	return d.SnapshotInspect(ctx, snapshotID, opts)
+}
+
+// VolumeRemove removes an image.
+func (d *driver) VolumeRemove(
+	ctx types.Context,
+	volumeID string,
+	opts types.Store) error {
+
+	if _, err := d.imageInfo(ctx, volumeID); err != nil {
+		return err
+	}
+
+	_, err := d.rbd(ctx, "rm", d.imageSpec(volumeID))
+	return err
+}
+
+// VolumeAttach maps an image to a local device. The device is returned as
+// the attachment's token.
+func (d *driver) VolumeAttach(
+	ctx types.Context,
+	volumeID string,
+	opts *types.VolumeAttachOpts) (*types.Volume, string, error) {
+
+	d.Lock()
+	defer d.Unlock()
+
+	mapped, err := d.showMapped(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if dev := d.mappedDevice(volumeID, mapped); dev != "" {
+		if !opts.Force {
+			return nil, "", goof.WithFields(goof.Fields{
+				"volumeID": volumeID,
+				"device":   dev,
+			}, "volume already attached")
+		}
+		return d.attachedVolume(ctx, volumeID, dev)
+	}
+
+	out, err := d.rbd(ctx, "map", d.imageSpec(volumeID))
+	if err != nil {
+		return nil, "", err
+	}
+	dev := strings.TrimSpace(string(out))
+
+	ctx.WithFields(log.Fields{
+		"volumeID": volumeID,
+		"device":   dev,
+	}).Info("mapped volume")
+
+	return d.attachedVolume(ctx, volumeID, dev)
+}
+
+func (d *driver) attachedVolume(
+	ctx types.Context,
+	volumeID, dev string) (*types.Volume, string, error) {
+
+	vol, err := d.VolumeInspect(ctx, volumeID,
+		&types.VolumeInspectOpts{Attachments: types.VolumeAttachmentsTrue})
+	if err != nil {
+		return nil, "", err
+	}
+	return vol, dev, nil
+}
+
+// VolumeDetach unmaps an image from its local device.
+func (d *driver) VolumeDetach(
+	ctx types.Context,
+	volumeID string,
+	opts *types.VolumeDetachOpts) (*types.Volume, error) {
+
+	d.Lock()
+	defer d.Unlock()
+
+	mapped, err := d.showMapped(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	dev := d.mappedDevice(volumeID, mapped)
+	if dev == "" {
+		return nil, goof.WithField(
+			"volumeID", volumeID, "volume not attached")
+	}
+
+	args := []string{"unmap"}
+	if opts.Force {
+		args = append(args, "-o", "force")
+	}
+	if _, err := d.rbd(ctx, append(args, dev)...); err != nil {
+		return nil, err
+	}
+
+	ctx.WithFields(log.Fields{
+		"volumeID": volumeID,
+		"device":   dev,
+	}).Info("unmapped volume")
+
+	return d.VolumeInspect(ctx, volumeID,
+		&types.VolumeInspectOpts{Attachments: types.VolumeAttachmentsTrue})
+}
+
+// Snapshots returns the snapshots of all of the pool's images.
+func (d *driver) Snapshots(
+	ctx types.Context,
+	opts types.Store) ([]*types.Snapshot, error) {
+
+	out, err := d.rbd(ctx, "ls", "--long", "--format", "json", d.pool())
+	if err != nil {
+		return nil, err
+	}
+
+	var images []*rbdImage
+	if err := json.Unmarshal(out, &images); err != nil {
+		return nil, err
+	}
+
+	snapshots := []*types.Snapshot{}
+	for _, i := range images {
+		if i.Snapshot == "" {
+			continue
+		}
+		snapshots = append(snapshots, &types.Snapshot{
+			ID:         i.Image + snapDelimiter + i.Snapshot,
+			Name:       i.Snapshot,
+			VolumeID:   i.Image,
+			VolumeSize: i.Size / bytesPerGb,
+			Status:     "available",
+		})
+	}
+	return snapshots, nil
+}
+
+// SnapshotInspect returns a snapshot. A snapshot's ID is the name of its
+// image and the name of the snapshot, delimited by an at sign.
+func (d *driver) SnapshotInspect(
+	ctx types.Context,
+	snapshotID string,
+	opts types.Store) (*types.Snapshot, error) {
+
+	volumeID, snapName, err := parseSnapshotID(snapshotID)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := d.rbd(ctx, "snap", "ls", "--format", "json",
+		d.imageSpec(volumeID))
+	if err != nil {
+		return nil, err
+	}
+
+	var snaps []*rbdSnap
+	if err := json.Unmarshal(out, &snaps); err != nil {
+		return nil, err
+	}
+
+	for _, s := range snaps {
+		if s.Name == snapName {
+			return &types.Snapshot{
+				ID:         snapshotID,
+				Name:       snapName,
+				VolumeID:   volumeID,
+				VolumeSize: s.Size / bytesPerGb,
+				Status:     "available",
+			}, nil
+		}
+	}
+	return nil, utils.NewNotFoundError(snapshotID)
+}
+
+// SnapshotCopy (not implemented).
+func (d *driver) SnapshotCopy(
+	ctx types.Context,
+	snapshotID, snapshotName, destinationID string,
+	opts types.Store) (*types.Snapshot, error) {
+	return nil, types.ErrNotImplemented
+}
+
+// SnapshotRemove removes a snapshot. Protected snapshots are unprotected
+// first, which fails if the snapshot has clones.
+func (d *driver) SnapshotRemove(
+	ctx types.Context,
+	snapshotID string,
+	opts types.Store) error {
+
+	if _, _, err := parseSnapshotID(snapshotID); err != nil {
+		return err
+	}
+
+	info, err := d.imageInfo(ctx, snapshotID)
+	if err != nil {
+		return err
+	}
+
+	if info.Protected == "true" {
+		if _, err := d.rbd(
+			ctx, "snap", "unprotect", d.imageSpec(snapshotID)); err != nil {
+			return err
+		}
+	}
+
+	_, err = d.rbd(ctx, "snap", "rm", d.imageSpec(snapshotID))
+	return err
+}
+
+// imageInfo returns the information of an image or snapshot. An
+// ErrNotFound error is returned if the image or snapshot does not exist.
+func (d *driver) imageInfo(
+	ctx types.Context, imageID string) (*rbdInfo, error) {
+
+	if !isValidImageID(imageID) {
+		return nil, utils.NewNotFoundError(imageID)
+	}
+
+	out, err := d.rbd(ctx, "info", "--format", "json", d.imageSpec(imageID))
+	if err != nil {
+		if types.IsErrorCode(err, types.ErrorCodeNotFound) {
+			return nil, utils.NewNotFoundError(imageID)
+		}
+		return nil, err
+	}
+
+	info := &rbdInfo{}
+	if err := json.Unmarshal(out, info); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// mappedImages returns the images mapped to the devices of the host if the
+// attachments are requested.
+func (d *driver) mappedImages(
+	ctx types.Context,
+	attachments types.VolumeAttachmentsTypes) (
+	map[string]*rbdUtils.MappedImage, error) {
+
+	if !attachments.Requested() {
+		return nil, nil
+	}
+	return d.showMapped(ctx)
+}
+
+// mappedDevice returns the device to which an image is mapped, or an empty
+// string if the image is not mapped.
+func (d *driver) mappedDevice(
+	volumeID string, mapped map[string]*rbdUtils.MappedImage) string {
+
+	for dev, i := range mapped {
+		if i.Pool == d.pool() && i.Name == volumeID &&
+			(i.Snap == "" || i.Snap == "-") {
+			return dev
+		}
+	}
+	return ""
+}
+
+// toTypesVolume converts an image to a volume. An image that is mapped to a
+// device of the host is attached to the context's instance since the server
+// runs on the same host as the client.
+func (d *driver) toTypesVolume(
+	ctx types.Context,
+	volumeID string,
+	size int64,
+	attachments types.VolumeAttachmentsTypes,
+	mapped map[string]*rbdUtils.MappedImage) *types.Volume {
+
+	vol := &types.Volume{
+		ID:   volumeID,
+		Name: volumeID,
+		Size: size / bytesPerGb,
+		Type: d.pool(),
+	}
+
+	if !attachments.Requested() {
+		return vol
+	}
+
+	iid, ok := context.InstanceID(ctx)
+	if !ok {
+		return vol
+	}
+
+	if dev := d.mappedDevice(volumeID, mapped); dev != "" {
+		att := &types.VolumeAttachment{
+			VolumeID:   volumeID,
+			InstanceID: iid,
+			Status:     "mapped",
+		}
+		if attachments.Devices() {
+			att.DeviceName = dev
+		}
+		vol.Attachments = []*types.VolumeAttachment{att}
+	}
+	return vol
+}
+
+func (d *driver) rbd(ctx types.Context, args ...string) ([]byte, error) {
+	return d.run(ctx, d.config, args...)
+}
+
+// showMapped returns the images that are mapped to local devices, keyed by
+// their devices.
+func (d *driver) showMapped(
+	ctx types.Context) (map[string]*rbdUtils.MappedImage, error) {
+
+	out, err := d.rbd(ctx, "showmapped", "--format", "json")
+	if err != nil {
+		return nil, err
+	}
+	return rbdUtils.ParseShowMapped(out)
+}
+
+func (d *driver) imageSpec(imageID string) string {
+	return fmt.Sprintf("%s/%s", d.pool(), imageID)
+}
+
+func parseSnapshotID(snapshotID string) (string, string, error) {
+	parts := strings.SplitN(snapshotID, snapDelimiter, 2)
+	if len(parts) != 2 || !isValidName(parts[0]) || !isValidName(parts[1]) {
+		return "", "", utils.NewNotFoundError(snapshotID)
+	}
+	return parts[0], parts[1], nil
+}
+
+// isValidName returns a flag indicating whether the name may be used as the
+// name of an image or snapshot.
+func isValidName(name string) bool {
+	return name != "" &&
+		!strings.HasPrefix(name, "-") &&
+		!strings.ContainsAny(name, "/@ ")
+}
+
+// isValidImageID returns a flag indicating whether the ID is the name of an
+// image or the ID of a snapshot.
+func isValidImageID(imageID string) bool {
+	if strings.Contains(imageID, snapDelimiter) {
+		_, _, err := parseSnapshotID(imageID)
+		return err == nil
+	}
+	return isValidName(imageID)
+}
+
+func sizeMB(sizeGB int64) string {
+	return fmt.Sprintf("%d", sizeGB*mbPerGb)
+}
+
+func (d *driver) pool() string {
+	return d.config.GetString(cephrbd.ConfigPool)
+}
+
+func (d *driver) imageFeatures() string {
+	return d.config.GetString(cephrbd.ConfigImageFeatures)
+}
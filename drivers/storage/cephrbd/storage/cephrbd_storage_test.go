@@ -0,0 +1,432 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+
+	gofigCore "github.com/akutz/gofig"
+	gofig "github.com/akutz/gofig/types"
+	"github.com/akutz/goof"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codedellemc/libstorage/api/context"
+	"github.com/codedellemc/libstorage/api/types"
+	"github.com/codedellemc/libstorage/drivers/storage/cephrbd"
+)
+
+// fakeRBD is a fake of the rbd command that manages the images of a single
+// pool in memory.
+type fakeRBD struct {
+	// images are the sizes of the images in bytes, keyed by their names.
+	images map[string]int64
+
+	// snaps are the protection flags of the snapshots, keyed by their IDs.
+	snaps map[string]bool
+
+	// mapped are the images mapped to devices, keyed by their devices.
+	mapped map[string]string
+
+	// errs are the errors returned by the subcommands, keyed by their
+	// names.
+	errs map[string]error
+
+	calls [][]string
+}
+
+func newFakeRBD() *fakeRBD {
+	return &fakeRBD{
+		images: map[string]int64{},
+		snaps:  map[string]bool{},
+		mapped: map[string]string{},
+		errs:   map[string]error{},
+	}
+}
+
+// notFound returns the error rbdUtils.RBD returns when the rbd command
+// reports that an image does not exist.
+func notFound(spec string) error {
+	return goof.WithFields(goof.Fields{
+		"stderr": fmt.Sprintf(
+			"rbd: error opening image %s: (2) No such file or directory",
+			spec),
+		types.ErrorCodeField: types.ErrorCodeNotFound,
+	}, "error executing rbd")
+}
+
+func (f *fakeRBD) run(
+	ctx types.Context,
+	config gofig.Config,
+	args ...string) ([]byte, error) {
+
+	f.calls = append(f.calls, args)
+	if err := f.errs[args[0]]; err != nil {
+		return nil, err
+	}
+
+	spec := args[len(args)-1]
+	name := strings.TrimPrefix(spec, "rbd/")
+
+	switch args[0] {
+	case "ls":
+		var out []*rbdImage
+		for n, size := range f.images {
+			out = append(out, &rbdImage{Image: n, Size: size})
+		}
+		for id := range f.snaps {
+			parts := strings.SplitN(id, "@", 2)
+			out = append(out, &rbdImage{
+				Image:    parts[0],
+				Snapshot: parts[1],
+				Size:     f.images[parts[0]],
+			})
+		}
+		return json.Marshal(out)
+	case "info":
+		if protected, ok := f.snaps[name]; ok {
+			image := strings.SplitN(name, "@", 2)[0]
+			return json.Marshal(&rbdInfo{
+				Name:      image,
+				Size:      f.images[image],
+				Protected: strconv.FormatBool(protected),
+			})
+		}
+		size, ok := f.images[name]
+		if !ok {
+			return nil, notFound(spec)
+		}
+		return json.Marshal(&rbdInfo{Name: name, Size: size})
+	case "create", "resize":
+		mb, _ := strconv.ParseInt(args[2], 10, 64)
+		f.images[name] = mb * 1024 * 1024
+	case "clone":
+		src := strings.TrimPrefix(args[len(args)-2], "rbd/")
+		f.images[name] = f.images[strings.SplitN(src, "@", 2)[0]]
+	case "cp":
+		f.images[name] = f.images[strings.TrimPrefix(args[1], "rbd/")]
+	case "rm":
+		delete(f.images, name)
+	case "map":
+		dev := fmt.Sprintf("/dev/rbd%d", len(f.mapped))
+		f.mapped[dev] = name
+		return []byte(dev + "\n"), nil
+	case "unmap":
+		delete(f.mapped, spec)
+	case "showmapped":
+		out := []map[string]string{}
+		for dev, n := range f.mapped {
+			out = append(out, map[string]string{
+				"pool": "rbd", "name": n, "snap": "-", "device": dev,
+			})
+		}
+		return json.Marshal(out)
+	case "snap":
+		return f.snap(args[1], name)
+	}
+	return nil, nil
+}
+
+func (f *fakeRBD) snap(cmd, name string) ([]byte, error) {
+	switch cmd {
+	case "create":
+		f.snaps[name] = false
+	case "protect":
+		f.snaps[name] = true
+	case "unprotect":
+		f.snaps[name] = false
+	case "rm":
+		delete(f.snaps, name)
+	case "ls":
+		out := []*rbdSnap{}
+		for id := range f.snaps {
+			parts := strings.SplitN(id, "@", 2)
+			if parts[0] == name {
+				out = append(out, &rbdSnap{
+					Name: parts[1],
+					Size: f.images[name],
+				})
+			}
+		}
+		return json.Marshal(out)
+	}
+	return nil, nil
+}
+
+// called returns the commands the fake executed with the specified
+// subcommand in the order in which they were executed.
+func (f *fakeRBD) called(cmd string) []string {
+	var calls []string
+	for _, args := range f.calls {
+		if args[0] == cmd {
+			calls = append(calls, strings.Join(args, " "))
+		}
+	}
+	return calls
+}
+
+func newTestDriver() (*driver, *fakeRBD) {
+	config := gofigCore.New()
+	config.Set(cephrbd.ConfigPool, "rbd")
+	config.Set(cephrbd.ConfigImageFeatures, "layering")
+	f := newFakeRBD()
+	return &driver{config: config, run: f.run}, f
+}
+
+func newTestContext() types.Context {
+	return context.WithValue(
+		context.Background(),
+		context.InstanceIDKey,
+		&types.InstanceID{ID: "host1", Driver: cephrbd.Name})
+}
+
+func size(gb int64) *int64 {
+	return &gb
+}
+
+func TestInit(t *testing.T) {
+	d, _ := newTestDriver()
+	assert.NoError(t, d.Init(context.Background(), d.config))
+
+	d.config.Set(cephrbd.ConfigPool, "")
+	assert.Error(t, d.Init(context.Background(), d.config))
+}
+
+func TestParseSnapshotID(t *testing.T) {
+	tests := []struct {
+		id       string
+		volumeID string
+		snapName string
+		err      bool
+	}{
+		{"vol1@snap1", "vol1", "snap1", false},
+		{"vol1", "", "", true},
+		{"vol1@", "", "", true},
+		{"@snap1", "", "", true},
+		{"vol1@snap@1", "", "", true},
+		{"pool/vol1@snap1", "", "", true},
+		{"-vol1@snap1", "", "", true},
+	}
+	for _, tt := range tests {
+		volumeID, snapName, err := parseSnapshotID(tt.id)
+		if tt.err {
+			assert.IsType(t, &types.ErrNotFound{}, err, tt.id)
+			continue
+		}
+		assert.NoError(t, err, tt.id)
+		assert.Equal(t, tt.volumeID, volumeID, tt.id)
+		assert.Equal(t, tt.snapName, snapName, tt.id)
+	}
+}
+
+func TestIsValidName(t *testing.T) {
+	tests := []struct {
+		name  string
+		valid bool
+	}{
+		{"vol1", true},
+		{"vol-1.a_b", true},
+		{"", false},
+		{"-vol1", false},
+		{"pool/vol1", false},
+		{"vol1@snap1", false},
+		{"vol 1", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.valid, isValidName(tt.name), tt.name)
+	}
+}
+
+func TestVolumeCreate(t *testing.T) {
+	d, f := newTestDriver()
+	ctx := newTestContext()
+
+	vol, err := d.VolumeCreate(
+		ctx, "vol1", &types.VolumeCreateOpts{Size: size(10)})
+	assert.NoError(t, err)
+	assert.Equal(t, "vol1", vol.ID)
+	assert.Equal(t, int64(10), vol.Size)
+	assert.Equal(t, "rbd", vol.Type)
+	assert.Equal(t,
+		[]string{"create --size 10240 --image-feature layering rbd/vol1"},
+		f.called("create"))
+
+	d.config.Set(cephrbd.ConfigPool, "volumes")
+	d.config.Set(cephrbd.ConfigImageFeatures, "")
+	f.calls = nil
+	d.VolumeCreate(ctx, "vol2", &types.VolumeCreateOpts{Size: size(1)})
+	assert.Equal(t,
+		[]string{"create --size 1024 volumes/vol2"}, f.called("create"))
+
+	f.calls = nil
+	_, err = d.VolumeCreate(ctx, "vol3", &types.VolumeCreateOpts{})
+	assert.Error(t, err)
+	_, err = d.VolumeCreate(
+		ctx, "pool/vol3", &types.VolumeCreateOpts{Size: size(1)})
+	assert.Error(t, err)
+	assert.Empty(t, f.calls)
+}
+
+func TestVolumeCreateError(t *testing.T) {
+	d, f := newTestDriver()
+	f.errs["create"] = goof.New("rbd: create error")
+
+	_, err := d.VolumeCreate(
+		newTestContext(), "vol1", &types.VolumeCreateOpts{Size: size(1)})
+	assert.Equal(t, f.errs["create"], err)
+}
+
+func TestNotFound(t *testing.T) {
+	d, f := newTestDriver()
+	ctx := newTestContext()
+
+	_, err := d.VolumeInspect(ctx, "vol1", &types.VolumeInspectOpts{})
+	assert.IsType(t, &types.ErrNotFound{}, err)
+	assert.IsType(t, &types.ErrNotFound{}, d.VolumeRemove(ctx, "vol1", nil))
+	_, err = d.VolumeExpand(ctx, "vol1", 2, nil)
+	assert.IsType(t, &types.ErrNotFound{}, err)
+
+	// invalid IDs are not found without executing rbd
+	f.calls = nil
+	_, err = d.VolumeInspect(ctx, "../vol1", &types.VolumeInspectOpts{})
+	assert.IsType(t, &types.ErrNotFound{}, err)
+	_, err = d.SnapshotInspect(ctx, "vol1", nil)
+	assert.IsType(t, &types.ErrNotFound{}, err)
+	assert.Empty(t, f.calls)
+
+	f.images["vol1"] = bytesPerGb
+	_, err = d.SnapshotInspect(ctx, "vol1@snap1", nil)
+	assert.IsType(t, &types.ErrNotFound{}, err)
+}
+
+func TestVolumeInspectError(t *testing.T) {
+	d, f := newTestDriver()
+	f.errs["info"] = goof.WithFields(goof.Fields{
+		types.ErrorCodeField: types.ErrorCodePermissionDenied,
+	}, "error executing rbd")
+
+	_, err := d.VolumeInspect(
+		newTestContext(), "vol1", &types.VolumeInspectOpts{})
+	assert.Equal(t, f.errs["info"], err)
+}
+
+func TestVolumes(t *testing.T) {
+	d, f := newTestDriver()
+	f.images["vol2"] = 2 * bytesPerGb
+	f.images["vol1"] = bytesPerGb
+	f.snaps["vol1@snap1"] = false
+	f.mapped["/dev/rbd0"] = "vol2"
+
+	vols, err := d.Volumes(newTestContext(), &types.VolumesOpts{
+		Attachments: types.VolumeAttachmentsRequested |
+			types.VolumeAttachmentsDevices})
+	assert.NoError(t, err)
+	if !assert.Len(t, vols, 2) {
+		t.FailNow()
+	}
+	assert.Equal(t, "vol1", vols[0].ID)
+	assert.Empty(t, vols[0].Attachments)
+	assert.Equal(t, "vol2", vols[1].ID)
+	assert.Equal(t, int64(2), vols[1].Size)
+	if assert.Len(t, vols[1].Attachments, 1) {
+		assert.Equal(t, "/dev/rbd0", vols[1].Attachments[0].DeviceName)
+		assert.Equal(t, "host1", vols[1].Attachments[0].InstanceID.ID)
+	}
+
+	// the mapped images are only listed if the attachments are requested
+	f.calls = nil
+	_, err = d.Volumes(newTestContext(), &types.VolumesOpts{})
+	assert.NoError(t, err)
+	assert.Empty(t, f.called("showmapped"))
+}
+
+func TestVolumeAttachDetach(t *testing.T) {
+	d, f := newTestDriver()
+	ctx := newTestContext()
+	f.images["vol1"] = bytesPerGb
+
+	vol, token, err := d.VolumeAttach(ctx, "vol1", &types.VolumeAttachOpts{})
+	assert.NoError(t, err)
+	assert.Equal(t, "/dev/rbd0", token)
+	assert.Len(t, vol.Attachments, 1)
+	assert.Equal(t, []string{"map rbd/vol1"}, f.called("map"))
+
+	_, _, err = d.VolumeAttach(ctx, "vol1", &types.VolumeAttachOpts{})
+	assert.Error(t, err)
+
+	// a forced attachment returns the device the image is already mapped to
+	_, token, err = d.VolumeAttach(
+		ctx, "vol1", &types.VolumeAttachOpts{Force: true})
+	assert.NoError(t, err)
+	assert.Equal(t, "/dev/rbd0", token)
+	assert.Len(t, f.called("map"), 1)
+
+	vol, err = d.VolumeDetach(
+		ctx, "vol1", &types.VolumeDetachOpts{Force: true})
+	assert.NoError(t, err)
+	assert.Empty(t, vol.Attachments)
+	assert.Equal(t,
+		[]string{"unmap -o force /dev/rbd0"}, f.called("unmap"))
+
+	_, err = d.VolumeDetach(ctx, "vol1", &types.VolumeDetachOpts{})
+	assert.Error(t, err)
+}
+
+func TestVolumeExpand(t *testing.T) {
+	d, f := newTestDriver()
+	ctx := newTestContext()
+	f.images["vol1"] = 2 * bytesPerGb
+
+	_, err := d.VolumeExpand(ctx, "vol1", 1, nil)
+	assert.Error(t, err)
+	assert.Empty(t, f.called("resize"))
+
+	vol, err := d.VolumeExpand(ctx, "vol1", 4, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), vol.Size)
+	assert.Equal(t,
+		[]string{"resize --size 4096 rbd/vol1"}, f.called("resize"))
+}
+
+func TestSnapshots(t *testing.T) {
+	d, f := newTestDriver()
+	ctx := newTestContext()
+	f.images["vol1"] = bytesPerGb
+
+	snap, err := d.VolumeSnapshot(ctx, "vol1", "snap1", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "vol1@snap1", snap.ID)
+	assert.Equal(t, "vol1", snap.VolumeID)
+	assert.Equal(t, int64(1), snap.VolumeSize)
+
+	_, err = d.VolumeSnapshot(ctx, "vol1", "snap@2", nil)
+	assert.Error(t, err)
+
+	snaps, err := d.Snapshots(ctx, nil)
+	assert.NoError(t, err)
+	if assert.Len(t, snaps, 1) {
+		assert.Equal(t, "vol1@snap1", snaps[0].ID)
+	}
+
+	// a snapshot is protected before it is cloned
+	vol, err := d.VolumeCreateFromSnapshot(
+		ctx, "vol1@snap1", "vol2", &types.VolumeCreateOpts{Size: size(3)})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), vol.Size)
+	assert.True(t, f.snaps["vol1@snap1"])
+	assert.Equal(t, []string{
+		"clone --image-feature layering rbd/vol1@snap1 rbd/vol2",
+	}, f.called("clone"))
+
+	// and unprotected before it is removed
+	assert.NoError(t, d.SnapshotRemove(ctx, "vol1@snap1", nil))
+	assert.Equal(t, []string{
+		"snap create rbd/vol1@snap1",
+		"snap ls --format json rbd/vol1",
+		"snap protect rbd/vol1@snap1",
+		"snap unprotect rbd/vol1@snap1",
+		"snap rm rbd/vol1@snap1",
+	}, f.called("snap"))
+	assert.Empty(t, f.snaps)
+}
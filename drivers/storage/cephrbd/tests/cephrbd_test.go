@@ -0,0 +1,128 @@
+package cephrbd
+
+import (
+	"os"
+	"strconv"
+	"testing"
+
+	gofig "github.com/akutz/gofig/types"
+	"github.com/akutz/gotil"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codedellemc/libstorage/api/server"
+	apitests "github.com/codedellemc/libstorage/api/tests"
+	"github.com/codedellemc/libstorage/api/types"
+
+	// load the driver
+	"github.com/codedellemc/libstorage/drivers/storage/cephrbd"
+	rbdx "github.com/codedellemc/libstorage/drivers/storage/cephrbd/executor"
+	_ "github.com/codedellemc/libstorage/drivers/storage/cephrbd/storage"
+)
+
+// skipTests returns a flag indicating whether the tests are skipped. The
+// tests require the rbd command and access to a Ceph cluster with the
+// default pool and user.
+func skipTests() bool {
+	noTest, _ := strconv.ParseBool(os.Getenv("TEST_SKIP_CEPHRBD"))
+	return noTest || !gotil.FileExistsInPath("rbd")
+}
+
+var volumeName string
+
+func init() {
+	uuid, _ := types.NewUUID()
+	volumeName = "ls-test-" + uuid.String()[:8]
+}
+
+func TestMain(m *testing.M) {
+	server.CloseOnAbort()
+	ec := m.Run()
+	os.Exit(ec)
+}
+
+func TestInstanceID(t *testing.T) {
+	if skipTests() {
+		t.SkipNow()
+	}
+
+	iid, err := rbdx.InstanceID()
+	assert.NoError(t, err)
+	if err != nil {
+		t.FailNow()
+	}
+
+	apitests.Run(
+		t, cephrbd.Name, nil,
+		(&apitests.InstanceIDTest{
+			Driver:   cephrbd.Name,
+			Expected: iid,
+		}).Test)
+}
+
+func TestVolumeCreateRemove(t *testing.T) {
+	if skipTests() {
+		t.SkipNow()
+	}
+
+	tf := func(config gofig.Config, client types.Client, t *testing.T) {
+		size := int64(1)
+		vol, err := client.API().VolumeCreate(
+			nil, cephrbd.Name, &types.VolumeCreateRequest{
+				Name: volumeName,
+				Size: &size,
+			})
+		assert.NoError(t, err)
+		if err != nil {
+			t.FailNow()
+		}
+		assert.Equal(t, volumeName, vol.ID)
+		assert.Equal(t, size, vol.Size)
+
+		assert.NoError(t, client.API().VolumeRemove(
+			nil, cephrbd.Name, volumeName))
+	}
+	apitests.Run(t, cephrbd.Name, nil, tf)
+}
+
+func TestVolumeSnapshotClone(t *testing.T) {
+	if skipTests() {
+		t.SkipNow()
+	}
+
+	tf := func(config gofig.Config, client types.Client, t *testing.T) {
+		size := int64(1)
+		_, err := client.API().VolumeCreate(
+			nil, cephrbd.Name, &types.VolumeCreateRequest{
+				Name: volumeName,
+				Size: &size,
+			})
+		assert.NoError(t, err)
+		if err != nil {
+			t.FailNow()
+		}
+		defer client.API().VolumeRemove(nil, cephrbd.Name, volumeName)
+
+		snap, err := client.API().VolumeSnapshot(
+			nil, cephrbd.Name, volumeName,
+			&types.VolumeSnapshotRequest{SnapshotName: "snap1"})
+		assert.NoError(t, err)
+		if err != nil {
+			t.FailNow()
+		}
+		assert.Equal(t, volumeName+"@snap1", snap.ID)
+
+		clone, err := client.API().VolumeCreateFromSnapshot(
+			nil, cephrbd.Name, snap.ID,
+			&types.VolumeCreateRequest{Name: volumeName + "-clone"})
+		assert.NoError(t, err)
+		if err != nil {
+			t.FailNow()
+		}
+
+		assert.NoError(t, client.API().VolumeRemove(
+			nil, cephrbd.Name, clone.ID))
+		assert.NoError(t, client.API().SnapshotRemove(
+			nil, cephrbd.Name, snap.ID))
+	}
+	apitests.Run(t, cephrbd.Name, nil, tf)
+}
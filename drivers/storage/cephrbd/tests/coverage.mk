@@ -0,0 +1,2 @@
+CEPHRBD_COVERPKG := $(ROOT_IMPORT_PATH)/drivers/storage/cephrbd
+TEST_COVERPKG_./drivers/storage/cephrbd/tests := $(CEPHRBD_COVERPKG),$(CEPHRBD_COVERPKG)/executor,$(CEPHRBD_COVERPKG)/storage
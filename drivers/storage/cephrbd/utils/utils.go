@@ -0,0 +1,109 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"os/exec"
+	"strings"
+
+	gofig "github.com/akutz/gofig/types"
+	"github.com/akutz/goof"
+
+	"github.com/codedellemc/libstorage/api/types"
+	"github.com/codedellemc/libstorage/api/utils"
+	"github.com/codedellemc/libstorage/drivers/storage/cephrbd"
+)
+
+// MappedImage is an image that is mapped to a local device.
+type MappedImage struct {
+	Pool   string `json:"pool"`
+	Name   string `json:"name"`
+	Snap   string `json:"snap"`
+	Device string `json:"device"`
+}
+
+// RBD executes the rbd command as the configured Ceph user and returns the
+// command's standard output. The command's standard error is included in
+// the returned error if the command fails, along with the code of the error
+// the standard error describes.
+func RBD(
+	ctx types.Context,
+	config gofig.Config,
+	args ...string) ([]byte, error) {
+
+	args = append(credentialArgs(config), args...)
+	if ctx != nil {
+		ctx.WithField("args", args).Debug("executing rbd")
+	}
+
+	stderr := &bytes.Buffer{}
+	cmd := exec.Command("rbd", args...)
+	cmd.Stderr = stderr
+
+	out, err := utils.CommandOutput(ctx, cmd)
+	if err != nil {
+		fields := goof.Fields{
+			"args":   args,
+			"stderr": strings.TrimSpace(stderr.String()),
+		}
+		if code := utils.CommandErrorCode(stderr.String()); code != "" {
+			fields[types.ErrorCodeField] = code
+		}
+		return nil, goof.WithFieldsE(fields, "error executing rbd", err)
+	}
+	return out, nil
+}
+
+func credentialArgs(config gofig.Config) []string {
+	args := []string{"--id", config.GetString(cephrbd.ConfigUser)}
+	if v := config.GetString(cephrbd.ConfigKeyring); v != "" {
+		args = append(args, "--keyring", v)
+	}
+	if v := config.GetString(cephrbd.ConfigCephConfig); v != "" {
+		args = append(args, "--conf", v)
+	}
+	return args
+}
+
+// ShowMapped returns the images that are mapped to local devices, keyed by
+// their devices.
+func ShowMapped(
+	ctx types.Context,
+	config gofig.Config) (map[string]*MappedImage, error) {
+
+	out, err := RBD(ctx, config, "showmapped", "--format", "json")
+	if err != nil {
+		return nil, err
+	}
+	return ParseShowMapped(out)
+}
+
+// ParseShowMapped parses the JSON output of the rbd showmapped command. Older
+// versions of the command emit an object keyed by the mappings' IDs while
+// newer versions emit an array.
+func ParseShowMapped(buf []byte) (map[string]*MappedImage, error) {
+	var images []*MappedImage
+
+	buf = bytes.TrimSpace(buf)
+	switch {
+	case len(buf) == 0:
+	case buf[0] == '[':
+		if err := json.Unmarshal(buf, &images); err != nil {
+			return nil, err
+		}
+	default:
+		imageMap := map[string]*MappedImage{}
+		if err := json.Unmarshal(buf, &imageMap); err != nil {
+			return nil, err
+		}
+		for _, i := range imageMap {
+			images = append(images, i)
+		}
+	}
+
+	mapped := map[string]*MappedImage{}
+	for _, i := range images {
+		mapped[i.Device] = i
+	}
+	return mapped, nil
+}
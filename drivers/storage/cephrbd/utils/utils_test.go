@@ -0,0 +1,121 @@
+package utils
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	gofigCore "github.com/akutz/gofig"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codedellemc/libstorage/api/types"
+	"github.com/codedellemc/libstorage/drivers/storage/cephrbd"
+)
+
+func TestParseShowMappedObject(t *testing.T) {
+	mapped, err := ParseShowMapped([]byte(`{
+		"0": {"pool":"rbd","name":"vol1","snap":"-","device":"/dev/rbd0"},
+		"1": {"pool":"rbd","name":"vol2","snap":"-","device":"/dev/rbd1"}
+	}`))
+	assert.NoError(t, err)
+	assert.Len(t, mapped, 2)
+	if assert.Contains(t, mapped, "/dev/rbd1") {
+		assert.Equal(t, "vol2", mapped["/dev/rbd1"].Name)
+		assert.Equal(t, "rbd", mapped["/dev/rbd1"].Pool)
+	}
+}
+
+func TestParseShowMappedArray(t *testing.T) {
+	mapped, err := ParseShowMapped([]byte(`[
+		{"id":"0","pool":"rbd","namespace":"","name":"vol1","snap":"-",
+		 "device":"/dev/rbd0"}
+	]`))
+	assert.NoError(t, err)
+	assert.Len(t, mapped, 1)
+	if assert.Contains(t, mapped, "/dev/rbd0") {
+		assert.Equal(t, "vol1", mapped["/dev/rbd0"].Name)
+	}
+}
+
+func TestParseShowMappedEmpty(t *testing.T) {
+	mapped, err := ParseShowMapped([]byte("\n"))
+	assert.NoError(t, err)
+	assert.Len(t, mapped, 0)
+}
+
+func TestCredentialArgs(t *testing.T) {
+	config := gofigCore.New()
+	config.Set(cephrbd.ConfigUser, "admin")
+	assert.Equal(t, []string{"--id", "admin"}, credentialArgs(config))
+
+	config.Set(cephrbd.ConfigKeyring, "/etc/ceph/admin.keyring")
+	config.Set(cephrbd.ConfigCephConfig, "/etc/ceph/ceph.conf")
+	assert.Equal(t, []string{
+		"--id", "admin",
+		"--keyring", "/etc/ceph/admin.keyring",
+		"--conf", "/etc/ceph/ceph.conf",
+	}, credentialArgs(config))
+}
+
+// withFakeRBD prepends a directory with an rbd script to the path for the
+// duration of a test.
+func withFakeRBD(t *testing.T, script string) func() {
+	dir, err := ioutil.TempDir("", "rbd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(
+		path.Join(dir, "rbd"), []byte("#!/bin/sh\n"+script), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := os.Getenv("PATH")
+	os.Setenv("PATH", dir+string(os.PathListSeparator)+p)
+	return func() {
+		os.Setenv("PATH", p)
+		os.RemoveAll(dir)
+	}
+}
+
+func TestRBD(t *testing.T) {
+	defer withFakeRBD(t, `echo "$@"`)()
+
+	config := gofigCore.New()
+	config.Set(cephrbd.ConfigUser, "admin")
+	out, err := RBD(nil, config, "ls", "rbd")
+	assert.NoError(t, err)
+	assert.Equal(t, "--id admin ls rbd\n", string(out))
+}
+
+func TestRBDError(t *testing.T) {
+	tests := []struct {
+		stderr string
+		code   types.ErrorCode
+	}{
+		{
+			"rbd: error opening image vol1: (2) No such file or directory",
+			types.ErrorCodeNotFound,
+		},
+		{
+			"rbd: create error: (17) File exists",
+			types.ErrorCodeAlreadyExists,
+		},
+		{
+			"rbd: sysfs write failed\nrbd: unmap failed: (16) " +
+				"Device or resource busy",
+			types.ErrorCodeBusy,
+		},
+		{"rbd: unknown error", ""},
+	}
+	for _, tt := range tests {
+		cleanup := withFakeRBD(
+			t, fmt.Sprintf("echo '%s' >&2\nexit 2", tt.stderr))
+		_, err := RBD(nil, gofigCore.New(), "info", "rbd/vol1")
+		cleanup()
+		if assert.Error(t, err, tt.stderr) {
+			assert.Equal(t, tt.code, types.ErrorCodeOf(err), tt.stderr)
+		}
+	}
+}
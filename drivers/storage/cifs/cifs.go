@@ -0,0 +1,53 @@
+package cifs
+
+import (
+	gofigCore "github.com/akutz/gofig"
+	gofig "github.com/akutz/gofig/types"
+)
+
+const (
+	// Name is the provider's name.
+	Name = "cifs"
+
+	// ConfigHost is a config key.
+	ConfigHost = Name + ".host"
+
+	// ConfigUsername is a config key.
+	ConfigUsername = Name + ".username"
+
+	// ConfigPassword is a config key.
+	ConfigPassword = Name + ".password"
+
+	// ConfigDomain is a config key.
+	ConfigDomain = Name + ".domain"
+
+	// ConfigSharePath is a config key.
+	ConfigSharePath = Name + ".sharePath"
+
+	// ConfigParentShare is a config key.
+	ConfigParentShare = Name + ".parentShare"
+
+	// ConfigSharePrefix is a config key.
+	ConfigSharePrefix = Name + ".sharePrefix"
+)
+
+func init() {
+	r := gofigCore.NewRegistration("CIFS")
+	r.Key(gofig.String, "", "",
+		"The host name or address of the file server", ConfigHost)
+	r.Key(gofig.String, "", "",
+		"The user as which shares are managed", ConfigUsername)
+	r.Key(gofig.String, "", "", "The password of the user", ConfigPassword)
+	r.Key(gofig.String, "", "", "The domain of the user", ConfigDomain)
+	r.Key(gofig.String, "", "",
+		"The path on the file server in which the directories of created "+
+			"shares reside. Shares are pre-provisioned when empty",
+		ConfigSharePath)
+	r.Key(gofig.String, "", "",
+		"The share through which the directories of shares are created "+
+			"and removed", ConfigParentShare)
+	r.Key(gofig.String, "", "",
+		"The prefix of the names of the shares managed as volumes",
+		ConfigSharePrefix)
+	gofigCore.Register(r)
+}
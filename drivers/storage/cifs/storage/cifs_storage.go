@@ -0,0 +1,550 @@
+package storage
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"sync"
+
+	log "github.com/Sirupsen/logrus"
+	gofig "github.com/akutz/gofig/types"
+	"github.com/akutz/goof"
+
+	"github.com/codedellemc/libstorage/api/context"
+	"github.com/codedellemc/libstorage/api/registry"
+	"github.com/codedellemc/libstorage/api/types"
+	"github.com/codedellemc/libstorage/api/utils"
+	"github.com/codedellemc/libstorage/drivers/storage/cifs"
+	cifsUtils "github.com/codedellemc/libstorage/drivers/storage/cifs/utils"
+)
+
+const (
+	// metaFileName is the name of the file in the root of a share in which
+	// the share's attachments are recorded. Since its name begins with a dot
+	// it is hidden from most listings.
+	metaFileName = ".libstorage.json"
+
+	attachedStatus = "attached"
+)
+
+// shareNameRX matches a valid share name: up to eighty letters, numbers,
+// periods, underscores, and hyphens that begins with a letter or number.
+var shareNameRX = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]{0,79}$`)
+
+// shareClient manages the shares of a file server and the files in their
+// roots. It is implemented by cifsUtils.Client.
+type shareClient interface {
+	Shares(ctx types.Context) ([]string, error)
+	AddShare(ctx types.Context, name, path string) error
+	DeleteShare(ctx types.Context, name string) error
+	Mkdir(ctx types.Context, share, dir string) error
+	RemoveAll(ctx types.Context, share, dir string) error
+	ReadFile(ctx types.Context, share, name string) ([]byte, error)
+	WriteFile(ctx types.Context, share, name string, data []byte) error
+	RemoveFile(ctx types.Context, share, name string) error
+}
+
+// driver is a storage driver that manages volumes as the shares of a Samba
+// or Windows file server. The clients mount the shares over SMB with the
+// linux OS driver's CIFS mount handler. When no share path is configured
+// the shares are pre-provisioned, and the driver only lists and attaches
+// them.
+type driver struct {
+	sync.Mutex
+	config gofig.Config
+	client shareClient
+}
+
+func init() {
+	registry.RegisterStorageDriver(cifs.Name, newDriver)
+}
+
+func newDriver() types.StorageDriver {
+	return &driver{}
+}
+
+// Name returns the name of the driver
+func (d *driver) Name() string {
+	return cifs.Name
+}
+
+// Init initializes the driver.
+func (d *driver) Init(ctx types.Context, config gofig.Config) error {
+	d.config = config
+
+	fields := log.Fields{
+		"host":        d.host(),
+		"username":    d.username(),
+		"domain":      d.domain(),
+		"sharePath":   d.sharePath(),
+		"parentShare": d.parentShare(),
+		"sharePrefix": d.sharePrefix(),
+	}
+
+	if d.host() == "" {
+		return goof.WithFields(fields, "cifs.host is required")
+	}
+
+	d.client = cifsUtils.NewClient(
+		d.host(),
+		d.username(),
+		d.config.GetString(cifs.ConfigPassword),
+		d.domain())
+
+	if _, err := d.client.Shares(ctx); err != nil {
+		return goof.WithFieldsE(fields, "error listing shares", err)
+	}
+
+	ctx.WithFields(fields).Info("storage driver initialized")
+	return nil
+}
+
+// Type returns the type of storage a driver provides
+func (d *driver) Type(ctx types.Context) (types.StorageType, error) {
+	return types.NAS, nil
+}
+
+// Capabilities advertises NAS attach semantics. Shares have no size and
+// cannot be snapshotted, and any number of instances may mount a share.
+func (d *driver) Capabilities(
+	ctx types.Context) (*types.StorageCapabilities, error) {
+	return &types.StorageCapabilities{
+		AttachSemantics: types.NAS,
+	}, nil
+}
+
+// NextDeviceInfo returns the information about the driver's next available
+// device workflow.
+func (d *driver) NextDeviceInfo(
+	ctx types.Context) (*types.NextDeviceInfo, error) {
+	return nil, nil
+}
+
+// InstanceInspect returns an instance.
+func (d *driver) InstanceInspect(
+	ctx types.Context,
+	opts types.Store) (*types.Instance, error) {
+
+	iid := context.MustInstanceID(ctx)
+	return &types.Instance{Name: iid.ID, InstanceID: iid}, nil
+}
+
+// Volumes returns the shares whose names begin with the share prefix.
+func (d *driver) Volumes(
+	ctx types.Context,
+	opts *types.VolumesOpts) ([]*types.Volume, error) {
+
+	shares, err := d.shares(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	volumes := []*types.Volume{}
+	for _, s := range shares {
+		v, err := d.getVolume(ctx, s, opts.Attachments)
+		if err != nil {
+			return nil, err
+		}
+		volumes = append(volumes, v)
+	}
+	return utils.SortVolumeByID(volumes), nil
+}
+
+func (d *driver) VolumeInspect(
+	ctx types.Context,
+	volumeID string,
+	opts *types.VolumeInspectOpts) (*types.Volume, error) {
+
+	if err := d.getShare(ctx, volumeID); err != nil {
+		return nil, err
+	}
+	return d.getVolume(ctx, volumeID, opts.Attachments)
+}
+
+// VolumeCreate creates a new share of a directory created in the share
+// path.
+func (d *driver) VolumeCreate(ctx types.Context, volumeName string,
+	opts *types.VolumeCreateOpts) (*types.Volume, error) {
+
+	if d.sharePath() == "" {
+		return nil, types.ErrNotImplemented
+	}
+
+	shareName := d.sharePrefix() + volumeName
+	if !shareNameRX.MatchString(shareName) {
+		return nil, goof.WithField(
+			"volumeName", volumeName, "invalid volume name")
+	}
+
+	d.Lock()
+	defer d.Unlock()
+
+	exists, err := d.shareExists(ctx, shareName)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, utils.NewAlreadyExistsError(shareName)
+	}
+
+	if d.parentShare() != "" {
+		if err := d.client.Mkdir(ctx, d.parentShare(), shareName); err != nil {
+			return nil, goof.WithFieldE(
+				"shareName", shareName, "error creating share directory", err)
+		}
+	}
+
+	if err := d.client.AddShare(
+		ctx, shareName, d.serverPath(shareName)); err != nil {
+		if d.parentShare() != "" {
+			d.client.RemoveAll(ctx, d.parentShare(), shareName)
+		}
+		return nil, goof.WithFieldE(
+			"shareName", shareName, "error adding share", err)
+	}
+
+	ctx.WithFields(log.Fields{
+		"shareName": shareName,
+		"path":      d.serverPath(shareName),
+	}).Info("created share")
+
+	return d.getVolume(ctx, shareName, 0)
+}
+
+// VolumeRemove deletes a share and, if the parent share is configured,
+// removes the share's directory along with its contents.
+func (d *driver) VolumeRemove(
+	ctx types.Context,
+	volumeID string,
+	opts types.Store) error {
+
+	if d.sharePath() == "" {
+		return types.ErrNotImplemented
+	}
+
+	d.Lock()
+	defer d.Unlock()
+
+	if err := d.getShare(ctx, volumeID); err != nil {
+		return err
+	}
+
+	if err := d.client.DeleteShare(ctx, volumeID); err != nil {
+		return goof.WithFieldE(
+			"volumeID", volumeID, "error deleting share", err)
+	}
+
+	if d.parentShare() != "" {
+		if err := d.client.RemoveAll(
+			ctx, d.parentShare(), volumeID); err != nil {
+			return goof.WithFieldE(
+				"volumeID", volumeID, "error removing share directory", err)
+		}
+	}
+
+	ctx.WithField("volumeID", volumeID).Info("removed share")
+	return nil
+}
+
+// VolumeAttach records the instance in the .libstorage.json file in the
+// root of the share, which it reads and writes with smbclient. No token is
+// returned; the client mounts the //host/share device of the attachment. A
+// forced attachment replaces the instances already recorded for the share.
+func (d *driver) VolumeAttach(
+	ctx types.Context,
+	volumeID string,
+	opts *types.VolumeAttachOpts) (*types.Volume, string, error) {
+
+	d.Lock()
+	defer d.Unlock()
+
+	if err := d.getShare(ctx, volumeID); err != nil {
+		return nil, "", err
+	}
+
+	iid := context.MustInstanceID(ctx)
+
+	iids, err := d.readAttachments(ctx, volumeID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if opts.Force {
+		iids = nil
+	}
+
+	attached := false
+	for _, i := range iids {
+		if i.ID == iid.ID {
+			attached = true
+			break
+		}
+	}
+	if !attached {
+		iids = append(iids, &types.InstanceID{ID: iid.ID, Driver: cifs.Name})
+	}
+
+	if err := d.writeAttachments(ctx, volumeID, iids); err != nil {
+		return nil, "", err
+	}
+
+	ctx.WithFields(log.Fields{
+		"volumeID": volumeID,
+		"device":   d.device(volumeID),
+	}).Info("attached volume")
+
+	vol, err := d.getVolume(ctx, volumeID, types.VolumeAttachmentsTrue)
+	if err != nil {
+		return nil, "", err
+	}
+	return vol, "", nil
+}
+
+// VolumeDetach detaches a volume from the instance, or from all instances
+// if the detachment is forced.
+func (d *driver) VolumeDetach(
+	ctx types.Context,
+	volumeID string,
+	opts *types.VolumeDetachOpts) (*types.Volume, error) {
+
+	d.Lock()
+	defer d.Unlock()
+
+	if err := d.getShare(ctx, volumeID); err != nil {
+		return nil, err
+	}
+
+	iid := context.MustInstanceID(ctx)
+
+	iids, err := d.readAttachments(ctx, volumeID)
+	if err != nil {
+		return nil, err
+	}
+
+	var newIIDs []*types.InstanceID
+	if !opts.Force {
+		for _, i := range iids {
+			if i.ID != iid.ID {
+				newIIDs = append(newIIDs, i)
+			}
+		}
+	}
+
+	if err := d.writeAttachments(ctx, volumeID, newIIDs); err != nil {
+		return nil, err
+	}
+
+	ctx.WithField("volumeID", volumeID).Info("detached volume")
+	return d.getVolume(ctx, volumeID, types.VolumeAttachmentsTrue)
+}
+
+// VolumeCreateFromSnapshot (not implemented).
+func (d *driver) VolumeCreateFromSnapshot(
+	ctx types.Context,
+	snapshotID, volumeName string,
+	opts *types.VolumeCreateOpts) (*types.Volume, error) {
+	return nil, types.ErrNotImplemented
+}
+
+// VolumeCopy copies an existing volume (not implemented)
+func (d *driver) VolumeCopy(
+	ctx types.Context,
+	volumeID, volumeName string,
+	opts types.Store) (*types.Volume, error) {
+	return nil, types.ErrNotImplemented
+}
+
+// VolumeExpand expands a volume (not implemented)
+func (d *driver) VolumeExpand(
+	ctx types.Context,
+	volumeID string,
+	newSize int64,
+	opts types.Store) (*types.Volume, error) {
+	return nil, types.ErrNotImplemented
+}
+
+// VolumeSnapshot snapshots a volume (not implemented)
+func (d *driver) VolumeSnapshot(
+	ctx types.Context,
+	volumeID, snapshotName string,
+	opts types.Store) (*types.Snapshot, error) {
+	return nil, types.ErrNotImplemented
+}
+
+func (d *driver) Snapshots(
+	ctx types.Context,
+	opts types.Store) ([]*types.Snapshot, error) {
+	return nil, nil
+}
+
+func (d *driver) SnapshotInspect(
+	ctx types.Context,
+	snapshotID string,
+	opts types.Store) (*types.Snapshot, error) {
+	return nil, types.ErrNotImplemented
+}
+
+func (d *driver) SnapshotCopy(
+	ctx types.Context,
+	snapshotID, snapshotName, destinationID string,
+	opts types.Store) (*types.Snapshot, error) {
+	return nil, types.ErrNotImplemented
+}
+
+func (d *driver) SnapshotRemove(
+	ctx types.Context,
+	snapshotID string,
+	opts types.Store) error {
+	return types.ErrNotImplemented
+}
+
+// HealthCheck verifies that the file server's shares can be listed.
+func (d *driver) HealthCheck(ctx types.Context) error {
+	_, err := d.client.Shares(ctx)
+	return err
+}
+
+func (d *driver) getVolume(
+	ctx types.Context,
+	shareName string,
+	attachments types.VolumeAttachmentsTypes) (*types.Volume, error) {
+
+	vol := &types.Volume{
+		ID:   shareName,
+		Name: strings.TrimPrefix(shareName, d.sharePrefix()),
+		Type: cifs.Name,
+	}
+
+	if !attachments.Requested() {
+		return vol, nil
+	}
+
+	iids, err := d.readAttachments(ctx, shareName)
+	if err != nil {
+		return nil, err
+	}
+	for _, iid := range iids {
+		vol.Attachments = append(vol.Attachments, &types.VolumeAttachment{
+			VolumeID:   shareName,
+			InstanceID: iid,
+			DeviceName: d.device(shareName),
+			Status:     attachedStatus,
+		})
+	}
+	return vol, nil
+}
+
+func (d *driver) readAttachments(
+	ctx types.Context, shareName string) ([]*types.InstanceID, error) {
+
+	buf, err := d.client.ReadFile(ctx, shareName, metaFileName)
+	if err != nil {
+		return nil, goof.WithFieldE(
+			"shareName", shareName, "error reading share attachments", err)
+	}
+	if len(buf) == 0 {
+		return nil, nil
+	}
+	var iids []*types.InstanceID
+	if err := json.Unmarshal(buf, &iids); err != nil {
+		return nil, goof.WithFieldE(
+			"shareName", shareName, "error reading share attachments", err)
+	}
+	return iids, nil
+}
+
+func (d *driver) writeAttachments(
+	ctx types.Context, shareName string, iids []*types.InstanceID) error {
+
+	if len(iids) == 0 {
+		return d.client.RemoveFile(ctx, shareName, metaFileName)
+	}
+
+	buf, err := json.Marshal(iids)
+	if err != nil {
+		return err
+	}
+	return d.client.WriteFile(ctx, shareName, metaFileName, buf)
+}
+
+// shares returns the names of the shares managed as volumes.
+func (d *driver) shares(ctx types.Context) ([]string, error) {
+	all, err := d.client.Shares(ctx)
+	if err != nil {
+		return nil, goof.WithError("error listing shares", err)
+	}
+	shares := []string{}
+	for _, s := range all {
+		if strings.HasPrefix(s, d.sharePrefix()) {
+			shares = append(shares, s)
+		}
+	}
+	return shares, nil
+}
+
+func (d *driver) shareExists(
+	ctx types.Context, shareName string) (bool, error) {
+
+	shares, err := d.shares(ctx)
+	if err != nil {
+		return false, err
+	}
+	for _, s := range shares {
+		if strings.EqualFold(s, shareName) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// getShare returns an ErrNotFound error if the share does not exist or is
+// not managed as a volume.
+func (d *driver) getShare(ctx types.Context, shareName string) error {
+	exists, err := d.shareExists(ctx, shareName)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return utils.NewNotFoundError(shareName)
+	}
+	return nil
+}
+
+// device returns the UNC path from which the share is mounted.
+func (d *driver) device(shareName string) string {
+	return cifsUtils.Device(d.host(), shareName)
+}
+
+// serverPath returns the path on the file server of a share's directory.
+// Windows paths, which contain backslashes, are joined with a backslash.
+func (d *driver) serverPath(shareName string) string {
+	sep := "/"
+	if strings.Contains(d.sharePath(), `\`) {
+		sep = `\`
+	}
+	return strings.TrimRight(d.sharePath(), sep) + sep + shareName
+}
+
+func (d *driver) host() string {
+	return d.config.GetString(cifs.ConfigHost)
+}
+
+func (d *driver) username() string {
+	return d.config.GetString(cifs.ConfigUsername)
+}
+
+func (d *driver) domain() string {
+	return d.config.GetString(cifs.ConfigDomain)
+}
+
+func (d *driver) sharePath() string {
+	return d.config.GetString(cifs.ConfigSharePath)
+}
+
+func (d *driver) parentShare() string {
+	return d.config.GetString(cifs.ConfigParentShare)
+}
+
+func (d *driver) sharePrefix() string {
+	return d.config.GetString(cifs.ConfigSharePrefix)
+}
@@ -0,0 +1,337 @@
+package storage
+
+import (
+	"sort"
+	"testing"
+
+	gofigCore "github.com/akutz/gofig"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codedellemc/libstorage/api/context"
+	"github.com/codedellemc/libstorage/api/types"
+	"github.com/codedellemc/libstorage/api/utils"
+	"github.com/codedellemc/libstorage/drivers/storage/cifs"
+)
+
+// fakeServer is a fake of a file server's client that manages shares, the
+// directories of the parent share, and the files in the roots of the shares
+// in memory.
+type fakeServer struct {
+	// shares are the shares' paths, keyed by the shares' names.
+	shares map[string]string
+
+	// dirs are the directories, keyed by the shares' names.
+	dirs map[string][]string
+
+	// files are the files in the roots of the shares, keyed by the shares'
+	// names and the files' names.
+	files map[string]map[string][]byte
+
+	// errs are the errors returned by the client's methods, keyed by the
+	// methods' names.
+	errs map[string]error
+}
+
+func newFakeServer() *fakeServer {
+	return &fakeServer{
+		shares: map[string]string{"IPC$": ""},
+		dirs:   map[string][]string{},
+		files:  map[string]map[string][]byte{},
+		errs:   map[string]error{},
+	}
+}
+
+// Shares omits hidden shares as the client does.
+func (f *fakeServer) Shares(ctx types.Context) ([]string, error) {
+	if err := f.errs["Shares"]; err != nil {
+		return nil, err
+	}
+	shares := []string{}
+	for s := range f.shares {
+		if s[len(s)-1] != '$' {
+			shares = append(shares, s)
+		}
+	}
+	sort.Strings(shares)
+	return shares, nil
+}
+
+func (f *fakeServer) AddShare(ctx types.Context, name, path string) error {
+	if err := f.errs["AddShare"]; err != nil {
+		return err
+	}
+	if _, ok := f.shares[name]; ok {
+		return utils.NewAlreadyExistsError(name)
+	}
+	f.shares[name] = path
+	f.files[name] = map[string][]byte{}
+	return nil
+}
+
+func (f *fakeServer) DeleteShare(ctx types.Context, name string) error {
+	if err := f.errs["DeleteShare"]; err != nil {
+		return err
+	}
+	if _, ok := f.shares[name]; !ok {
+		return utils.NewNotFoundError(name)
+	}
+	delete(f.shares, name)
+	return nil
+}
+
+func (f *fakeServer) Mkdir(ctx types.Context, share, dir string) error {
+	if err := f.errs["Mkdir"]; err != nil {
+		return err
+	}
+	f.dirs[share] = append(f.dirs[share], dir)
+	return nil
+}
+
+func (f *fakeServer) RemoveAll(ctx types.Context, share, dir string) error {
+	if err := f.errs["RemoveAll"]; err != nil {
+		return err
+	}
+	dirs := []string{}
+	for _, d := range f.dirs[share] {
+		if d != dir {
+			dirs = append(dirs, d)
+		}
+	}
+	f.dirs[share] = dirs
+	return nil
+}
+
+func (f *fakeServer) ReadFile(
+	ctx types.Context, share, name string) ([]byte, error) {
+
+	if err := f.errs["ReadFile"]; err != nil {
+		return nil, err
+	}
+	return f.files[share][name], nil
+}
+
+func (f *fakeServer) WriteFile(
+	ctx types.Context, share, name string, data []byte) error {
+
+	if err := f.errs["WriteFile"]; err != nil {
+		return err
+	}
+	f.files[share][name] = data
+	return nil
+}
+
+func (f *fakeServer) RemoveFile(ctx types.Context, share, name string) error {
+	if err := f.errs["RemoveFile"]; err != nil {
+		return err
+	}
+	delete(f.files[share], name)
+	return nil
+}
+
+// add adds a share to the fake as though it were pre-provisioned.
+func (f *fakeServer) add(name string) {
+	f.shares[name] = "/srv/" + name
+	f.files[name] = map[string][]byte{}
+}
+
+func newTestDriver(sharePath, parentShare string) (*driver, *fakeServer) {
+	config := gofigCore.New()
+	config.Set(cifs.ConfigHost, "filer")
+	config.Set(cifs.ConfigSharePath, sharePath)
+	config.Set(cifs.ConfigParentShare, parentShare)
+	config.Set(cifs.ConfigSharePrefix, "ls-")
+	f := newFakeServer()
+	return &driver{config: config, client: f}, f
+}
+
+func newTestContext(id string) types.Context {
+	return context.WithValue(
+		context.Background(),
+		context.InstanceIDKey,
+		&types.InstanceID{ID: id, Driver: cifs.Name})
+}
+
+func TestInit(t *testing.T) {
+	err := (&driver{}).Init(context.Background(), gofigCore.New())
+	assert.Error(t, err)
+}
+
+func TestShareNameRX(t *testing.T) {
+	tests := []struct {
+		name  string
+		valid bool
+	}{
+		{"ls-data", true},
+		{"Data_1.a", true},
+		{"-data", false},
+		{"data$", false},
+		{"data 1", false},
+		{"data/1", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.valid, shareNameRX.MatchString(tt.name), tt.name)
+	}
+}
+
+func TestServerPath(t *testing.T) {
+	d, _ := newTestDriver("/srv/shares/", "")
+	assert.Equal(t, "/srv/shares/ls-data", d.serverPath("ls-data"))
+	d, _ = newTestDriver(`D:\Shares`, "")
+	assert.Equal(t, `D:\Shares\ls-data`, d.serverPath("ls-data"))
+	assert.Equal(t, "//filer/ls-data", d.device("ls-data"))
+}
+
+func TestVolumeCreate(t *testing.T) {
+	d, f := newTestDriver("/srv/shares", "shares")
+	ctx := newTestContext("i-1")
+
+	vol, err := d.VolumeCreate(ctx, "data", &types.VolumeCreateOpts{})
+	assert.NoError(t, err)
+	assert.Equal(t, "ls-data", vol.ID)
+	assert.Equal(t, "data", vol.Name)
+	assert.Equal(t, cifs.Name, vol.Type)
+	assert.Equal(t, "/srv/shares/ls-data", f.shares["ls-data"])
+	assert.Equal(t, []string{"ls-data"}, f.dirs["shares"])
+
+	// share names are compared without regard to case
+	_, err = d.VolumeCreate(ctx, "DATA", &types.VolumeCreateOpts{})
+	assert.IsType(t, &types.ErrAlreadyExists{}, err)
+	_, err = d.VolumeCreate(ctx, "data 2", &types.VolumeCreateOpts{})
+	assert.Error(t, err)
+
+	// the directory is removed if the share cannot be added
+	f.errs["AddShare"] = utils.NewAuthFailedError(nil)
+	_, err = d.VolumeCreate(ctx, "logs", &types.VolumeCreateOpts{})
+	assert.Error(t, err)
+	assert.Equal(t, []string{"ls-data"}, f.dirs["shares"])
+
+	f.add("other")
+	vols, err := d.Volumes(ctx, &types.VolumesOpts{})
+	assert.NoError(t, err)
+	if assert.Len(t, vols, 1) {
+		assert.Equal(t, "ls-data", vols[0].ID)
+	}
+
+	f.errs["Shares"] = utils.NewTimeoutError("filer", nil)
+	_, err = d.Volumes(ctx, &types.VolumesOpts{})
+	assert.Error(t, err)
+	assert.Equal(t, f.errs["Shares"], d.HealthCheck(ctx))
+}
+
+func TestPreProvisioned(t *testing.T) {
+	d, f := newTestDriver("", "")
+	ctx := newTestContext("i-1")
+	f.add("ls-data")
+
+	_, err := d.VolumeCreate(ctx, "logs", &types.VolumeCreateOpts{})
+	assert.Equal(t, types.ErrNotImplemented, err)
+	assert.Equal(t,
+		types.ErrNotImplemented, d.VolumeRemove(ctx, "ls-data", nil))
+
+	_, _, err = d.VolumeAttach(ctx, "ls-data", &types.VolumeAttachOpts{})
+	assert.NoError(t, err)
+}
+
+func TestNotFound(t *testing.T) {
+	d, f := newTestDriver("/srv/shares", "")
+	ctx := newTestContext("i-1")
+	f.add("other")
+
+	for _, id := range []string{"ls-data", "other"} {
+		_, err := d.VolumeInspect(ctx, id, &types.VolumeInspectOpts{})
+		assert.IsType(t, &types.ErrNotFound{}, err, id)
+		assert.IsType(t, &types.ErrNotFound{}, d.VolumeRemove(ctx, id, nil))
+		_, _, err = d.VolumeAttach(ctx, id, &types.VolumeAttachOpts{})
+		assert.IsType(t, &types.ErrNotFound{}, err, id)
+		_, err = d.VolumeDetach(ctx, id, &types.VolumeDetachOpts{})
+		assert.IsType(t, &types.ErrNotFound{}, err, id)
+	}
+}
+
+func TestVolumeRemove(t *testing.T) {
+	d, f := newTestDriver("/srv/shares", "shares")
+	ctx := newTestContext("i-1")
+	_, err := d.VolumeCreate(ctx, "data", &types.VolumeCreateOpts{})
+	assert.NoError(t, err)
+
+	f.errs["DeleteShare"] = utils.NewBusyError("ls-data", nil)
+	assert.Error(t, d.VolumeRemove(ctx, "ls-data", nil))
+	assert.Contains(t, f.shares, "ls-data")
+
+	delete(f.errs, "DeleteShare")
+	assert.NoError(t, d.VolumeRemove(ctx, "ls-data", nil))
+	assert.NotContains(t, f.shares, "ls-data")
+	assert.Empty(t, f.dirs["shares"])
+}
+
+func TestVolumeAttachDetach(t *testing.T) {
+	d, f := newTestDriver("", "")
+	ctx1, ctx2 := newTestContext("i-1"), newTestContext("i-2")
+	f.add("ls-data")
+
+	vol, token, err := d.VolumeAttach(
+		ctx1, "ls-data", &types.VolumeAttachOpts{})
+	assert.NoError(t, err)
+	assert.Equal(t, "", token)
+	if assert.Len(t, vol.Attachments, 1) {
+		assert.Equal(t, "i-1", vol.Attachments[0].InstanceID.ID)
+		assert.Equal(t, cifs.Name, vol.Attachments[0].InstanceID.Driver)
+		assert.Equal(t, "//filer/ls-data", vol.Attachments[0].DeviceName)
+	}
+	assert.JSONEq(t,
+		`[{"id":"i-1","driver":"cifs"}]`,
+		string(f.files["ls-data"][metaFileName]))
+
+	// attaching the volume to the same instance again is a no-op
+	vol, _, err = d.VolumeAttach(ctx1, "ls-data", &types.VolumeAttachOpts{})
+	assert.NoError(t, err)
+	assert.Len(t, vol.Attachments, 1)
+
+	vol, _, err = d.VolumeAttach(ctx2, "ls-data", &types.VolumeAttachOpts{})
+	assert.NoError(t, err)
+	assert.Len(t, vol.Attachments, 2)
+
+	vol, err = d.VolumeDetach(ctx1, "ls-data", &types.VolumeDetachOpts{})
+	assert.NoError(t, err)
+	if assert.Len(t, vol.Attachments, 1) {
+		assert.Equal(t, "i-2", vol.Attachments[0].InstanceID.ID)
+	}
+
+	vol, _, err = d.VolumeAttach(
+		ctx1, "ls-data", &types.VolumeAttachOpts{Force: true})
+	assert.NoError(t, err)
+	if assert.Len(t, vol.Attachments, 1) {
+		assert.Equal(t, "i-1", vol.Attachments[0].InstanceID.ID)
+	}
+
+	// the metadata file is removed when the last instance detaches
+	vol, err = d.VolumeDetach(
+		ctx2, "ls-data", &types.VolumeDetachOpts{Force: true})
+	assert.NoError(t, err)
+	assert.Empty(t, vol.Attachments)
+	assert.NotContains(t, f.files["ls-data"], metaFileName)
+
+	f.errs["WriteFile"] = utils.NewAuthFailedError(nil)
+	_, _, err = d.VolumeAttach(ctx1, "ls-data", &types.VolumeAttachOpts{})
+	assert.Equal(t, f.errs["WriteFile"], err)
+}
+
+func TestReadAttachmentsError(t *testing.T) {
+	d, f := newTestDriver("", "")
+	ctx := newTestContext("i-1")
+	f.add("ls-data")
+
+	f.files["ls-data"][metaFileName] = []byte("{")
+	_, err := d.VolumeInspect(ctx, "ls-data", &types.VolumeInspectOpts{
+		Attachments: types.VolumeAttachmentsTrue})
+	assert.Error(t, err)
+
+	// attachments are not read unless they are requested
+	_, err = d.VolumeInspect(ctx, "ls-data", &types.VolumeInspectOpts{})
+	assert.NoError(t, err)
+
+	f.errs["ReadFile"] = utils.NewAuthFailedError(nil)
+	_, err = d.VolumeInspect(ctx, "ls-data", &types.VolumeInspectOpts{
+		Attachments: types.VolumeAttachmentsTrue})
+	assert.Error(t, err)
+}
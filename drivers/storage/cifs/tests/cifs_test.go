@@ -0,0 +1,146 @@
+package cifs
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	gofig "github.com/akutz/gofig/types"
+	"github.com/akutz/gotil"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codedellemc/libstorage/api/server"
+	apitests "github.com/codedellemc/libstorage/api/tests"
+	"github.com/codedellemc/libstorage/api/types"
+
+	// load the driver
+	"github.com/codedellemc/libstorage/drivers/storage/cifs"
+	cifsx "github.com/codedellemc/libstorage/drivers/storage/cifs/executor"
+	_ "github.com/codedellemc/libstorage/drivers/storage/cifs/storage"
+)
+
+// skipTests returns a flag indicating whether the tests are skipped. The
+// tests require the net and smbclient commands and the file server
+// specified with CIFS_HOST, CIFS_USERNAME, CIFS_PASSWORD, and
+// CIFS_SHARE_PATH. The share directories are created through
+// CIFS_PARENT_SHARE when it is set.
+func skipTests() bool {
+	return os.Getenv("TEST_SKIP_CIFS") != "" ||
+		!gotil.FileExistsInPath("net") ||
+		!gotil.FileExistsInPath("smbclient") ||
+		os.Getenv("CIFS_HOST") == "" ||
+		os.Getenv("CIFS_USERNAME") == "" ||
+		os.Getenv("CIFS_SHARE_PATH") == ""
+}
+
+var volumeName string
+
+func init() {
+	uuid, _ := types.NewUUID()
+	volumeName = "ls-test-" + uuid.String()[:8]
+}
+
+func TestMain(m *testing.M) {
+	server.CloseOnAbort()
+	ec := m.Run()
+	os.Exit(ec)
+}
+
+func newTestConfig() []byte {
+	return []byte(fmt.Sprintf(`
+cifs:
+  host:        %s
+  username:    %s
+  password:    %s
+  sharePath:   %s
+  parentShare: %s
+`,
+		os.Getenv("CIFS_HOST"),
+		os.Getenv("CIFS_USERNAME"),
+		os.Getenv("CIFS_PASSWORD"),
+		os.Getenv("CIFS_SHARE_PATH"),
+		os.Getenv("CIFS_PARENT_SHARE")))
+}
+
+func TestInstanceID(t *testing.T) {
+	if skipTests() {
+		t.SkipNow()
+	}
+
+	iid, err := cifsx.InstanceID()
+	assert.NoError(t, err)
+	if err != nil {
+		t.FailNow()
+	}
+
+	apitests.Run(
+		t, cifs.Name, newTestConfig(),
+		(&apitests.InstanceIDTest{
+			Driver:   cifs.Name,
+			Expected: iid,
+		}).Test)
+}
+
+func TestVolumeCreateRemove(t *testing.T) {
+	if skipTests() {
+		t.SkipNow()
+	}
+
+	tf := func(config gofig.Config, client types.Client, t *testing.T) {
+		vol, err := client.API().VolumeCreate(
+			nil, cifs.Name, &types.VolumeCreateRequest{Name: volumeName})
+		assert.NoError(t, err)
+		if err != nil {
+			t.FailNow()
+		}
+		assert.Equal(t, volumeName, vol.ID)
+		assert.Equal(t, volumeName, vol.Name)
+
+		_, err = client.API().VolumeCreate(
+			nil, cifs.Name, &types.VolumeCreateRequest{Name: volumeName})
+		assert.Error(t, err)
+
+		assert.NoError(t, client.API().VolumeRemove(
+			nil, cifs.Name, volumeName))
+
+		_, err = client.API().VolumeInspect(
+			nil, cifs.Name, volumeName, 0)
+		assert.Error(t, err)
+	}
+	apitests.Run(t, cifs.Name, newTestConfig(), tf)
+}
+
+func TestVolumeAttachDetach(t *testing.T) {
+	if skipTests() {
+		t.SkipNow()
+	}
+
+	tf := func(config gofig.Config, client types.Client, t *testing.T) {
+		_, err := client.API().VolumeCreate(
+			nil, cifs.Name, &types.VolumeCreateRequest{Name: volumeName})
+		assert.NoError(t, err)
+		if err != nil {
+			t.FailNow()
+		}
+		defer client.API().VolumeRemove(nil, cifs.Name, volumeName)
+
+		vol, _, err := client.API().VolumeAttach(
+			nil, cifs.Name, volumeName, &types.VolumeAttachRequest{})
+		assert.NoError(t, err)
+		if err != nil {
+			t.FailNow()
+		}
+		if !assert.Len(t, vol.Attachments, 1) {
+			t.FailNow()
+		}
+		assert.Equal(t,
+			fmt.Sprintf("//%s/%s", os.Getenv("CIFS_HOST"), volumeName),
+			vol.Attachments[0].DeviceName)
+
+		vol, err = client.API().VolumeDetach(
+			nil, cifs.Name, volumeName, &types.VolumeDetachRequest{})
+		assert.NoError(t, err)
+		assert.Len(t, vol.Attachments, 0)
+	}
+	apitests.Run(t, cifs.Name, newTestConfig(), tf)
+}
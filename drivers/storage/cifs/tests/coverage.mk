@@ -0,0 +1,2 @@
+CIFS_COVERPKG := $(ROOT_IMPORT_PATH)/drivers/storage/cifs
+TEST_COVERPKG_./drivers/storage/cifs/tests := $(CIFS_COVERPKG),$(CIFS_COVERPKG)/executor,$(CIFS_COVERPKG)/storage
@@ -0,0 +1,245 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/akutz/goof"
+
+	"github.com/codedellemc/libstorage/api/types"
+	"github.com/codedellemc/libstorage/api/utils"
+)
+
+const (
+	// statusNotFound is reported by smbclient when a file or directory does
+	// not exist.
+	statusNotFound = "NT_STATUS_OBJECT_NAME_NOT_FOUND"
+)
+
+// ntStatusErrors maps the NT statuses that net and smbclient report to error
+// codes.
+var ntStatusErrors = []struct {
+	status string
+	code   types.ErrorCode
+}{
+	{statusNotFound, types.ErrorCodeNotFound},
+	{"NT_STATUS_OBJECT_PATH_NOT_FOUND", types.ErrorCodeNotFound},
+	{"NT_STATUS_BAD_NETWORK_NAME", types.ErrorCodeNotFound},
+	{"NT_STATUS_OBJECT_NAME_COLLISION", types.ErrorCodeAlreadyExists},
+	{"WERR_FILE_EXISTS", types.ErrorCodeAlreadyExists},
+	{"WERR_ALREADY_EXISTS", types.ErrorCodeAlreadyExists},
+	{"NT_STATUS_SHARING_VIOLATION", types.ErrorCodeBusy},
+	{"NT_STATUS_DIRECTORY_NOT_EMPTY", types.ErrorCodeBusy},
+	{"NT_STATUS_DISK_FULL", types.ErrorCodeQuotaExceeded},
+	{"NT_STATUS_QUOTA_EXCEEDED", types.ErrorCodeQuotaExceeded},
+	{"NT_STATUS_LOGON_FAILURE", types.ErrorCodeAuthFailed},
+	{"NT_STATUS_ACCESS_DENIED", types.ErrorCodeAuthFailed},
+	{"WERR_ACCESS_DENIED", types.ErrorCodeAuthFailed},
+	{"NT_STATUS_IO_TIMEOUT", types.ErrorCodeTimeout},
+}
+
+// Client manages the shares of a Samba or Windows file server with the
+// Samba net and smbclient commands. The shares are managed over the server
+// service (SRVSVC) RPC interface, which both Samba and Windows provide.
+type Client struct {
+	host     string
+	username string
+	password string
+	domain   string
+}
+
+// NewClient returns a new client for the specified file server. The client
+// authenticates anonymously if the username is empty.
+func NewClient(host, username, password, domain string) *Client {
+	return &Client{
+		host:     host,
+		username: username,
+		password: password,
+		domain:   domain,
+	}
+}
+
+// Device returns the device of a share, formatted as //host/share, which is
+// mounted with the linux OS driver's CIFS mount handler.
+func Device(host, shareName string) string {
+	return fmt.Sprintf("//%s/%s", host, shareName)
+}
+
+// Shares returns the names of the file server's shares. Hidden shares,
+// whose names end with a dollar sign, are omitted.
+func (c *Client) Shares(ctx types.Context) ([]string, error) {
+	out, err := c.net(ctx, "rpc", "share", "list")
+	if err != nil {
+		return nil, err
+	}
+	return ParseShares(out), nil
+}
+
+// AddShare adds a share of the path on the file server.
+func (c *Client) AddShare(ctx types.Context, name, path string) error {
+	_, err := c.net(ctx, "rpc", "share", "add",
+		fmt.Sprintf("%s=%s", name, path))
+	return err
+}
+
+// DeleteShare deletes a share. The shared path is not removed.
+func (c *Client) DeleteShare(ctx types.Context, name string) error {
+	_, err := c.net(ctx, "rpc", "share", "delete", name)
+	return err
+}
+
+// Mkdir creates a directory in a share.
+func (c *Client) Mkdir(ctx types.Context, share, dir string) error {
+	_, err := c.smbclient(ctx, share, fmt.Sprintf(`mkdir "%s"`, dir))
+	return err
+}
+
+// RemoveAll removes a directory of a share along with its contents.
+func (c *Client) RemoveAll(ctx types.Context, share, dir string) error {
+	_, err := c.smbclient(ctx, share, fmt.Sprintf(`deltree "%s"`, dir))
+	return err
+}
+
+// ReadFile returns the contents of a file in the root of a share. A nil
+// slice is returned if the file does not exist.
+func (c *Client) ReadFile(
+	ctx types.Context, share, name string) ([]byte, error) {
+
+	f, err := ioutil.TempFile("", "libstorage-cifs")
+	if err != nil {
+		return nil, err
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+
+	out, err := c.smbclient(
+		ctx, share, fmt.Sprintf(`get "%s" "%s"`, name, f.Name()))
+	if err != nil {
+		if bytes.Contains(out, []byte(statusNotFound)) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return ioutil.ReadFile(f.Name())
+}
+
+// WriteFile writes the contents of a file in the root of a share.
+func (c *Client) WriteFile(
+	ctx types.Context, share, name string, data []byte) error {
+
+	f, err := ioutil.TempFile("", "libstorage-cifs")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	_, err = f.Write(data)
+	f.Close()
+	if err != nil {
+		return err
+	}
+
+	_, err = c.smbclient(
+		ctx, share, fmt.Sprintf(`put "%s" "%s"`, f.Name(), name))
+	return err
+}
+
+// RemoveFile removes a file from the root of a share. No error is returned
+// if the file does not exist.
+func (c *Client) RemoveFile(ctx types.Context, share, name string) error {
+	out, err := c.smbclient(ctx, share, fmt.Sprintf(`del "%s"`, name))
+	if err != nil && !bytes.Contains(out, []byte(statusNotFound)) {
+		return err
+	}
+	return nil
+}
+
+// ErrorCode returns the code of the error described by the NT status that
+// net or smbclient reported in its output, or an empty string if the output
+// does not contain a known status.
+func ErrorCode(out []byte) types.ErrorCode {
+	for _, e := range ntStatusErrors {
+		if bytes.Contains(out, []byte(e.status)) {
+			return e.code
+		}
+	}
+	return ""
+}
+
+// ParseShares parses the output of net rpc share list, omitting hidden
+// shares.
+func ParseShares(out []byte) []string {
+	shares := []string{}
+	s := bufio.NewScanner(bytes.NewReader(out))
+	for s.Scan() {
+		name := strings.TrimSpace(s.Text())
+		if name == "" || strings.HasSuffix(name, "$") {
+			continue
+		}
+		shares = append(shares, name)
+	}
+	return shares
+}
+
+func (c *Client) net(ctx types.Context, args ...string) ([]byte, error) {
+	args = append(args, "-S", c.host)
+	return c.exec(ctx, "net", append(args, c.authArgs()...)...)
+}
+
+func (c *Client) smbclient(
+	ctx types.Context, share, command string) ([]byte, error) {
+
+	args := []string{Device(c.host, share), "-c", command}
+	return c.exec(ctx, "smbclient", append(args, c.authArgs()...)...)
+}
+
+// authArgs returns the arguments with which net and smbclient authenticate.
+// The password is provided in the environment so that it is not visible in
+// the process list.
+func (c *Client) authArgs() []string {
+	if c.username == "" {
+		return []string{"-N"}
+	}
+	args := []string{"-U", c.username}
+	if c.domain != "" {
+		args = append(args, "-W", c.domain)
+	}
+	return args
+}
+
+// exec executes a command and returns its standard output. The command's
+// combined output is returned along with the error if the command fails
+// since smbclient reports the status of failed operations on its standard
+// output.
+func (c *Client) exec(
+	ctx types.Context, name string, args ...string) ([]byte, error) {
+
+	if ctx != nil {
+		ctx.WithField("args", args).Debugf("executing %s", name)
+	}
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	cmd := exec.Command(name, args...)
+	cmd.Env = append(os.Environ(), "PASSWD="+c.password)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	if err := utils.RunCommand(ctx, cmd); err != nil {
+		out := append(stdout.Bytes(), stderr.Bytes()...)
+		fields := goof.Fields{
+			"cmd":    name,
+			"args":   args,
+			"output": strings.TrimSpace(string(out)),
+		}
+		if code := ErrorCode(out); code != "" {
+			fields[types.ErrorCodeField] = code
+		}
+		return out, goof.WithFieldsE(fields, "error executing command", err)
+	}
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,126 @@
+package utils
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codedellemc/libstorage/api/types"
+)
+
+func TestDevice(t *testing.T) {
+	assert.Equal(t, "//filer.example.com/share1",
+		Device("filer.example.com", "share1"))
+}
+
+func TestParseShares(t *testing.T) {
+	out := []byte(`IPC$
+print$
+ls-data
+
+ls-logs
+C$
+`)
+	assert.Equal(t, []string{"ls-data", "ls-logs"}, ParseShares(out))
+}
+
+func TestParseSharesEmpty(t *testing.T) {
+	assert.Equal(t, []string{}, ParseShares(nil))
+}
+
+func TestErrorCode(t *testing.T) {
+	assert.Equal(t, types.ErrorCodeAuthFailed, ErrorCode([]byte(
+		"session setup failed: NT_STATUS_LOGON_FAILURE")))
+	assert.Equal(t, types.ErrorCodeNotFound, ErrorCode([]byte(
+		"tree connect failed: NT_STATUS_BAD_NETWORK_NAME")))
+	assert.Equal(t, types.ErrorCode(""), ErrorCode([]byte("error")))
+}
+
+func TestAuthArgs(t *testing.T) {
+	assert.Equal(t, []string{"-N"}, NewClient("filer", "", "", "").authArgs())
+	assert.Equal(t,
+		[]string{"-U", "admin", "-W", "CORP"},
+		NewClient("filer", "admin", "secret", "CORP").authArgs())
+}
+
+// withFakeCommand prepends a directory with a script of the specified name
+// to the path for the duration of a test.
+func withFakeCommand(t *testing.T, name, script string) func() {
+	dir, err := ioutil.TempDir("", "cifs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(
+		path.Join(dir, name), []byte("#!/bin/sh\n"+script), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := os.Getenv("PATH")
+	os.Setenv("PATH", dir+string(os.PathListSeparator)+p)
+	return func() {
+		os.Setenv("PATH", p)
+		os.RemoveAll(dir)
+	}
+}
+
+func TestShares(t *testing.T) {
+	defer withFakeCommand(t, "net", `echo "$@ $PASSWD"
+echo 'IPC$'
+echo ls-data`)()
+
+	c := NewClient("filer", "admin", "secret", "")
+	shares, err := c.Shares(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"rpc share list -S filer -U admin secret",
+		"ls-data",
+	}, shares)
+}
+
+func TestExecError(t *testing.T) {
+	defer withFakeCommand(t, "net", `echo "Could not connect"
+echo "NT_STATUS_ACCESS_DENIED" >&2
+exit 255`)()
+
+	err := NewClient("filer", "", "", "").DeleteShare(nil, "ls-data")
+	if assert.Error(t, err) {
+		assert.Equal(t, types.ErrorCodeAuthFailed, types.ErrorCodeOf(err))
+	}
+}
+
+func TestFiles(t *testing.T) {
+	// the fake smbclient reports that files do not exist unless they are
+	// put, and gets files with the contents "[]"
+	defer withFakeCommand(t, "smbclient", `case "$3" in
+get*) f=$(echo "$3" | cut -d'"' -f4)
+	[ -n "$FOUND" ] && echo '[]' > "$f" && exit 0
+	echo "NT_STATUS_OBJECT_NAME_NOT_FOUND opening remote file"
+	exit 1;;
+deltree*) ;;
+del*) echo "NT_STATUS_OBJECT_NAME_NOT_FOUND deleting remote file"
+	exit 1;;
+put*) exit 0;;
+esac
+echo "NT_STATUS_SHARING_VIOLATION"
+exit 1`)()
+
+	c := NewClient("filer", "", "", "")
+	buf, err := c.ReadFile(nil, "ls-data", ".libstorage.json")
+	assert.NoError(t, err)
+	assert.Nil(t, buf)
+
+	os.Setenv("FOUND", "1")
+	buf, err = c.ReadFile(nil, "ls-data", ".libstorage.json")
+	os.Unsetenv("FOUND")
+	assert.NoError(t, err)
+	assert.Equal(t, "[]\n", string(buf))
+
+	assert.NoError(t, c.RemoveFile(nil, "ls-data", ".libstorage.json"))
+	assert.NoError(t, c.WriteFile(nil, "ls-data", ".libstorage.json", nil))
+
+	err = c.RemoveAll(nil, "shares", "ls-data")
+	assert.Equal(t, types.ErrorCodeBusy, types.ErrorCodeOf(err))
+}
@@ -0,0 +1,68 @@
+package cinder
+
+import (
+	gofigCore "github.com/akutz/gofig"
+	gofig "github.com/akutz/gofig/types"
+)
+
+const (
+	// Name is the provider's name.
+	Name = "cinder"
+
+	// ConfigAuthURL is a config key.
+	ConfigAuthURL = Name + ".authURL"
+
+	// ConfigUserID is a config key.
+	ConfigUserID = Name + ".userID"
+
+	// ConfigUserName is a config key.
+	ConfigUserName = Name + ".userName"
+
+	// ConfigPassword is a config key.
+	ConfigPassword = Name + ".password"
+
+	// ConfigTenantID is a config key.
+	ConfigTenantID = Name + ".tenantID"
+
+	// ConfigTenantName is a config key.
+	ConfigTenantName = Name + ".tenantName"
+
+	// ConfigDomainID is a config key.
+	ConfigDomainID = Name + ".domainID"
+
+	// ConfigDomainName is a config key.
+	ConfigDomainName = Name + ".domainName"
+
+	// ConfigRegionName is a config key.
+	ConfigRegionName = Name + ".regionName"
+
+	// ConfigAvailabilityZoneName is a config key.
+	ConfigAvailabilityZoneName = Name + ".availabilityZoneName"
+
+	// ConfigStatusTimeout is a config key.
+	ConfigStatusTimeout = Name + ".statusTimeout"
+)
+
+func init() {
+	r := gofigCore.NewRegistration("Cinder")
+	r.Key(gofig.String, "", "",
+		"The URL of the Keystone identity service", ConfigAuthURL)
+	r.Key(gofig.String, "", "", "The ID of the user", ConfigUserID)
+	r.Key(gofig.String, "", "", "The name of the user", ConfigUserName)
+	r.Key(gofig.String, "", "", "The password of the user", ConfigPassword)
+	r.Key(gofig.String, "", "", "The ID of the tenant", ConfigTenantID)
+	r.Key(gofig.String, "", "", "The name of the tenant", ConfigTenantName)
+	r.Key(gofig.String, "", "",
+		"The ID of the user's domain", ConfigDomainID)
+	r.Key(gofig.String, "", "",
+		"The name of the user's domain", ConfigDomainName)
+	r.Key(gofig.String, "", "",
+		"The region of the block storage service", ConfigRegionName)
+	r.Key(gofig.String, "", "",
+		"The availability zone in which volumes are created",
+		ConfigAvailabilityZoneName)
+	r.Key(gofig.String, "", "2m",
+		"The maximum duration of a volume or snapshot status change",
+		ConfigStatusTimeout)
+	gofigCore.Register(r)
+}
@@ -0,0 +1,118 @@
+package executor
+
+import (
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"strings"
+
+	gofig "github.com/akutz/gofig/types"
+	"github.com/akutz/goof"
+
+	"github.com/codedellemc/libstorage/api/registry"
+	"github.com/codedellemc/libstorage/api/types"
+	"github.com/codedellemc/libstorage/drivers/storage/cinder"
+	cinderUtils "github.com/codedellemc/libstorage/drivers/storage/cinder/utils"
+)
+
+const diskIDPath = "/dev/disk/by-id"
+
+// serialPrefixes are the prefixes of the names of the links in
+// /dev/disk/by-id that are followed by the serial number of a volume
+// attached with the virtio-blk or virtio-scsi bus, respectively.
+var serialPrefixes = []string{
+	"virtio-",
+	"scsi-0QEMU_QEMU_HARDDISK_",
+}
+
+// driver is the storage executor for the cinder storage driver.
+type driver struct {
+	config gofig.Config
+}
+
+func init() {
+	registry.RegisterStorageExecutor(cinder.Name, newDriver)
+}
+
+func newDriver() types.StorageExecutor {
+	return &driver{}
+}
+
+func (d *driver) Init(ctx types.Context, config gofig.Config) error {
+	d.config = config
+	return nil
+}
+
+func (d *driver) Name() string {
+	return cinder.Name
+}
+
+// Supported returns a flag indicating whether or not the platform
+// implementing the executor is valid for the host on which the executor
+// resides.
+func (d *driver) Supported(ctx types.Context, opts types.Store) (bool, error) {
+	_, err := cinderUtils.GetMetadata()
+	return err == nil, nil
+}
+
+// InstanceID returns the local instance ID for the test
+func InstanceID() (*types.InstanceID, error) {
+	return newDriver().InstanceID(nil, nil)
+}
+
+// InstanceID returns the UUID of the OpenStack instance, which is read from
+// the metadata service or, if the service is unavailable, the config drive.
+func (d *driver) InstanceID(
+	ctx types.Context,
+	opts types.Store) (*types.InstanceID, error) {
+
+	md, err := cinderUtils.GetMetadata()
+	if err != nil {
+		return nil, err
+	}
+	if md.UUID == "" {
+		return nil, goof.New("instance metadata has no uuid")
+	}
+	return &types.InstanceID{ID: md.UUID, Driver: cinder.Name}, nil
+}
+
+func (d *driver) NextDevice(
+	ctx types.Context,
+	opts types.Store) (string, error) {
+	return "", types.ErrNotImplemented
+}
+
+// LocalDevices returns the devices of the attached volumes, keyed by the
+// serial numbers with which they appear in /dev/disk/by-id.
+func (d *driver) LocalDevices(
+	ctx types.Context,
+	opts *types.LocalDevicesOpts) (*types.LocalDevices, error) {
+
+	ld := &types.LocalDevices{
+		Driver:    cinder.Name,
+		DeviceMap: map[string]string{},
+	}
+
+	files, err := ioutil.ReadDir(diskIDPath)
+	if err != nil {
+		// the directory does not exist until a disk with an ID is attached
+		return ld, nil
+	}
+
+	for _, f := range files {
+		for _, p := range serialPrefixes {
+			if !strings.HasPrefix(f.Name(), p) ||
+				strings.Contains(f.Name(), "-part") {
+				continue
+			}
+			dev, err := filepath.EvalSymlinks(path.Join(diskIDPath, f.Name()))
+			if err != nil {
+				continue
+			}
+			serial := cinderUtils.Serial(strings.TrimPrefix(f.Name(), p))
+			ld.DeviceMap[serial] = dev
+		}
+	}
+
+	return ld, nil
+}
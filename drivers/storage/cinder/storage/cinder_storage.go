@@ -0,0 +1,739 @@
+package storage
+
+import (
+	"net/http"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	gofig "github.com/akutz/gofig/types"
+	"github.com/akutz/goof"
+
+	"github.com/codedellemc/libstorage/api/context"
+	"github.com/codedellemc/libstorage/api/registry"
+	"github.com/codedellemc/libstorage/api/types"
+	"github.com/codedellemc/libstorage/api/utils"
+	"github.com/codedellemc/libstorage/drivers/storage/cinder"
+	cinderUtils "github.com/codedellemc/libstorage/drivers/storage/cinder/utils"
+
+	"github.com/rackspace/gophercloud"
+	"github.com/rackspace/gophercloud/openstack"
+	"github.com/rackspace/gophercloud/openstack/blockstorage/v1/snapshots"
+	"github.com/rackspace/gophercloud/openstack/blockstorage/v1/volumes"
+	"github.com/rackspace/gophercloud/openstack/compute/v2/extensions/volumeattach"
+)
+
+const (
+	statusAvailable = "available"
+	statusInUse     = "in-use"
+	statusError     = "error"
+)
+
+// driver is a storage driver that manages volumes with the OpenStack Block
+// Storage service and attaches them to instances with the Compute service.
+type driver struct {
+	config             gofig.Config
+	provider           *gophercloud.ProviderClient
+	clientCompute      *gophercloud.ServiceClient
+	clientBlockStorage *gophercloud.ServiceClient
+}
+
+func init() {
+	registry.RegisterStorageDriver(cinder.Name, newDriver)
+}
+
+func newDriver() types.StorageDriver {
+	return &driver{}
+}
+
+// Name returns the name of the driver
+func (d *driver) Name() string {
+	return cinder.Name
+}
+
+// Init initializes the driver.
+func (d *driver) Init(ctx types.Context, config gofig.Config) error {
+	d.config = config
+
+	fields := log.Fields{
+		"authURL":              d.authURL(),
+		"userID":               d.userID(),
+		"userName":             d.userName(),
+		"tenantID":             d.tenantID(),
+		"tenantName":           d.tenantName(),
+		"domainID":             d.domainID(),
+		"domainName":           d.domainName(),
+		"regionName":           d.regionName(),
+		"availabilityZoneName": d.availabilityZoneName(),
+		"statusTimeout": d.config.GetString(
+			cinder.ConfigStatusTimeout),
+	}
+	if d.password() == "" {
+		fields["password"] = ""
+	} else {
+		fields["password"] = "******"
+	}
+
+	if d.authURL() == "" {
+		return goof.WithFields(fields, "cinder.authURL is required")
+	}
+
+	if _, err := d.statusTimeout(); err != nil {
+		return goof.WithFieldsE(fields, "invalid status timeout", err)
+	}
+
+	var err error
+	if d.provider, err = openstack.AuthenticatedClient(
+		gophercloud.AuthOptions{
+			IdentityEndpoint: d.authURL(),
+			UserID:           d.userID(),
+			Username:         d.userName(),
+			Password:         d.password(),
+			TenantID:         d.tenantID(),
+			TenantName:       d.tenantName(),
+			DomainID:         d.domainID(),
+			DomainName:       d.domainName(),
+			AllowReauth:      true,
+		}); err != nil {
+		return goof.WithFieldsE(fields,
+			"error getting authenticated client", err)
+	}
+
+	endpointOpts := gophercloud.EndpointOpts{Region: d.regionName()}
+
+	if d.clientCompute, err = openstack.NewComputeV2(
+		d.provider, endpointOpts); err != nil {
+		return goof.WithFieldsE(fields,
+			"error getting compute client", err)
+	}
+
+	if d.clientBlockStorage, err = openstack.NewBlockStorageV1(
+		d.provider, endpointOpts); err != nil {
+		return goof.WithFieldsE(fields,
+			"error getting block storage client", err)
+	}
+
+	ctx.WithFields(fields).Info("storage driver initialized")
+	return nil
+}
+
+// Type returns the type of storage a driver provides
+func (d *driver) Type(ctx types.Context) (types.StorageType, error) {
+	return types.Block, nil
+}
+
+// Capabilities advertises block attach semantics and snapshots. The Compute
+// service attaches a volume to one instance at a time.
+func (d *driver) Capabilities(
+	ctx types.Context) (*types.StorageCapabilities, error) {
+	return &types.StorageCapabilities{
+		SupportsSnapshots: true,
+		AttachSemantics:   types.Block,
+	}, nil
+}
+
+// NextDeviceInfo returns the information about the driver's next available
+// device workflow. The Compute service chooses the device to which a volume
+// is attached, so the next device is never requested.
+func (d *driver) NextDeviceInfo(
+	ctx types.Context) (*types.NextDeviceInfo, error) {
+	return nil, nil
+}
+
+// InstanceInspect returns an instance.
+func (d *driver) InstanceInspect(
+	ctx types.Context,
+	opts types.Store) (*types.Instance, error) {
+
+	iid := context.MustInstanceID(ctx)
+	return &types.Instance{InstanceID: iid}, nil
+}
+
+// Volumes returns all volumes.
+func (d *driver) Volumes(
+	ctx types.Context,
+	opts *types.VolumesOpts) ([]*types.Volume, error) {
+
+	pages, err := volumes.List(
+		d.clientBlockStorage, volumes.ListOpts{}).AllPages()
+	if err != nil {
+		return nil, goof.WithError("error listing volumes", err)
+	}
+	vols, err := volumes.ExtractVolumes(pages)
+	if err != nil {
+		return nil, goof.WithError("error listing volumes", err)
+	}
+
+	ld := localDevices(ctx, opts.Attachments)
+	result := []*types.Volume{}
+	for i := range vols {
+		result = append(result,
+			toTypesVolume(&vols[i], opts.Attachments, ld))
+	}
+	return utils.SortVolumeByID(result), nil
+}
+
+// VolumeInspect inspects a single volume.
+func (d *driver) VolumeInspect(
+	ctx types.Context,
+	volumeID string,
+	opts *types.VolumeInspectOpts) (*types.Volume, error) {
+
+	vol, err := d.getVolume(volumeID)
+	if err != nil {
+		return nil, err
+	}
+	return toTypesVolume(
+		vol, opts.Attachments, localDevices(ctx, opts.Attachments)), nil
+}
+
+// VolumeCreate creates a new volume.
+func (d *driver) VolumeCreate(ctx types.Context, volumeName string,
+	opts *types.VolumeCreateOpts) (*types.Volume, error) {
+
+	createOpts := &volumes.CreateOpts{
+		Name:         volumeName,
+		Availability: d.availabilityZoneName(),
+	}
+	if opts.Size != nil {
+		createOpts.Size = int(*opts.Size)
+	}
+	if opts.Type != nil {
+		createOpts.VolumeType = *opts.Type
+	}
+	if opts.AvailabilityZone != nil && *opts.AvailabilityZone != "" {
+		createOpts.Availability = *opts.AvailabilityZone
+	}
+
+	return d.createVolume(ctx, createOpts)
+}
+
+// VolumeCreateFromSnapshot creates a new volume from an existing snapshot.
+// The volume is the size of the snapshot unless a larger size is requested.
+func (d *driver) VolumeCreateFromSnapshot(
+	ctx types.Context,
+	snapshotID, volumeName string,
+	opts *types.VolumeCreateOpts) (*types.Volume, error) {
+
+	snap, err := d.getSnapshot(snapshotID)
+	if err != nil {
+		return nil, err
+	}
+
+	createOpts := &volumes.CreateOpts{
+		Name:       volumeName,
+		SnapshotID: snapshotID,
+		Size:       snap.Size,
+	}
+	if opts.Size != nil && int(*opts.Size) > snap.Size {
+		createOpts.Size = int(*opts.Size)
+	}
+	if opts.Type != nil {
+		createOpts.VolumeType = *opts.Type
+	}
+
+	return d.createVolume(ctx, createOpts)
+}
+
+// VolumeCopy copies an existing volume.
+func (d *driver) VolumeCopy(
+	ctx types.Context,
+	volumeID, volumeName string,
+	opts types.Store) (*types.Volume, error) {
+
+	vol, err := d.getVolume(volumeID)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.createVolume(ctx, &volumes.CreateOpts{
+		Name:         volumeName,
+		SourceVolID:  volumeID,
+		Size:         vol.Size,
+		VolumeType:   vol.VolumeType,
+		Availability: vol.AvailabilityZone,
+	})
+}
+
+// VolumeRemove removes a volume.
+func (d *driver) VolumeRemove(
+	ctx types.Context,
+	volumeID string,
+	opts types.Store) error {
+
+	if _, err := d.getVolume(volumeID); err != nil {
+		return err
+	}
+
+	if err := volumes.Delete(d.clientBlockStorage, volumeID).Err; err != nil {
+		return goof.WithFieldE(
+			"volumeID", volumeID, "error removing volume", err)
+	}
+
+	ctx.WithField("volumeID", volumeID).Info("removed volume")
+	return nil
+}
+
+// VolumeAttach attaches a volume to the instance and returns the serial
+// number with which the volume's device appears on the instance as the
+// token.
+func (d *driver) VolumeAttach(
+	ctx types.Context,
+	volumeID string,
+	opts *types.VolumeAttachOpts) (*types.Volume, string, error) {
+
+	iid := context.MustInstanceID(ctx)
+	fields := log.Fields{
+		"volumeID":   volumeID,
+		"instanceID": iid.ID,
+	}
+
+	vol, err := d.getVolume(volumeID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if len(vol.Attachments) > 0 {
+		if !opts.Force {
+			return nil, "", goof.WithFields(fields, "volume already attached")
+		}
+		if _, err := d.VolumeDetach(ctx, volumeID,
+			&types.VolumeDetachOpts{Force: true}); err != nil {
+			return nil, "", err
+		}
+	}
+
+	createOpts := &volumeattach.CreateOpts{VolumeID: volumeID}
+	if opts.NextDevice != nil {
+		createOpts.Device = *opts.NextDevice
+	}
+
+	if _, err := volumeattach.Create(
+		d.clientCompute, iid.ID, createOpts).Extract(); err != nil {
+		return nil, "", goof.WithFieldsE(
+			fields, "error attaching volume", err)
+	}
+
+	if vol, err = d.waitForVolumeStatus(
+		ctx, volumeID, statusInUse); err != nil {
+		return nil, "", goof.WithFieldsE(
+			fields, "error waiting for volume to attach", err)
+	}
+
+	ctx.WithFields(fields).Info("attached volume")
+	return toTypesVolume(vol, types.VolumeAttachmentsTrue, nil),
+		cinderUtils.Serial(volumeID), nil
+}
+
+// VolumeDetach detaches a volume from the instances to which it is
+// attached.
+func (d *driver) VolumeDetach(
+	ctx types.Context,
+	volumeID string,
+	opts *types.VolumeDetachOpts) (*types.Volume, error) {
+
+	vol, err := d.getVolume(volumeID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(vol.Attachments) == 0 {
+		return nil, goof.WithField(
+			"volumeID", volumeID, "volume not attached")
+	}
+
+	for _, a := range vol.Attachments {
+		serverID, _ := a["server_id"].(string)
+		if err := volumeattach.Delete(
+			d.clientCompute, serverID, volumeID).Err; err != nil {
+			return nil, goof.WithFieldsE(goof.Fields{
+				"volumeID":   volumeID,
+				"instanceID": serverID,
+			}, "error detaching volume", err)
+		}
+	}
+
+	if vol, err = d.waitForVolumeStatus(
+		ctx, volumeID, statusAvailable); err != nil {
+		return nil, goof.WithFieldE("volumeID", volumeID,
+			"error waiting for volume to detach", err)
+	}
+
+	ctx.WithField("volumeID", volumeID).Info("detached volume")
+	return toTypesVolume(vol, types.VolumeAttachmentsTrue, nil), nil
+}
+
+// VolumeExpand expands a volume (not implemented)
+func (d *driver) VolumeExpand(
+	ctx types.Context,
+	volumeID string,
+	newSize int64,
+	opts types.Store) (*types.Volume, error) {
+	return nil, types.ErrNotImplemented
+}
+
+// VolumeSnapshot snapshots a volume.
+func (d *driver) VolumeSnapshot(
+	ctx types.Context,
+	volumeID, snapshotName string,
+	opts types.Store) (*types.Snapshot, error) {
+
+	fields := log.Fields{
+		"volumeID":     volumeID,
+		"snapshotName": snapshotName,
+	}
+
+	if _, err := d.getVolume(volumeID); err != nil {
+		return nil, err
+	}
+
+	// the snapshot is forced so that attached volumes may be snapshotted
+	snap, err := snapshots.Create(d.clientBlockStorage, snapshots.CreateOpts{
+		Name:     snapshotName,
+		VolumeID: volumeID,
+		Force:    true,
+	}).Extract()
+	if err != nil {
+		return nil, goof.WithFieldsE(fields, "error creating snapshot", err)
+	}
+	fields["snapshotID"] = snap.ID
+
+	if snap, err = d.waitForSnapshotStatus(
+		ctx, snap.ID, statusAvailable); err != nil {
+		return nil, goof.WithFieldsE(fields,
+			"error waiting for snapshot creation to complete", err)
+	}
+
+	ctx.WithFields(fields).Info("created snapshot")
+	return toTypesSnapshot(snap), nil
+}
+
+// Snapshots returns all snapshots.
+func (d *driver) Snapshots(
+	ctx types.Context,
+	opts types.Store) ([]*types.Snapshot, error) {
+
+	pages, err := snapshots.List(
+		d.clientBlockStorage, snapshots.ListOpts{}).AllPages()
+	if err != nil {
+		return nil, goof.WithError("error listing snapshots", err)
+	}
+	snaps, err := snapshots.ExtractSnapshots(pages)
+	if err != nil {
+		return nil, goof.WithError("error listing snapshots", err)
+	}
+
+	result := []*types.Snapshot{}
+	for i := range snaps {
+		result = append(result, toTypesSnapshot(&snaps[i]))
+	}
+	return result, nil
+}
+
+// SnapshotInspect inspects a single snapshot.
+func (d *driver) SnapshotInspect(
+	ctx types.Context,
+	snapshotID string,
+	opts types.Store) (*types.Snapshot, error) {
+
+	snap, err := d.getSnapshot(snapshotID)
+	if err != nil {
+		return nil, err
+	}
+	return toTypesSnapshot(snap), nil
+}
+
+// SnapshotCopy copies an existing snapshot (not implemented)
+func (d *driver) SnapshotCopy(
+	ctx types.Context,
+	snapshotID, snapshotName, destinationID string,
+	opts types.Store) (*types.Snapshot, error) {
+	return nil, types.ErrNotImplemented
+}
+
+// SnapshotRemove removes a snapshot.
+func (d *driver) SnapshotRemove(
+	ctx types.Context,
+	snapshotID string,
+	opts types.Store) error {
+
+	if _, err := d.getSnapshot(snapshotID); err != nil {
+		return err
+	}
+
+	if err := snapshots.Delete(
+		d.clientBlockStorage, snapshotID).Err; err != nil {
+		return goof.WithFieldE(
+			"snapshotID", snapshotID, "error removing snapshot", err)
+	}
+
+	ctx.WithField("snapshotID", snapshotID).Info("removed snapshot")
+	return nil
+}
+
+// HealthCheck verifies that the block storage service's volumes can be
+// listed.
+func (d *driver) HealthCheck(ctx types.Context) error {
+	_, err := volumes.List(
+		d.clientBlockStorage, volumes.ListOpts{}).AllPages()
+	return err
+}
+
+func (d *driver) createVolume(
+	ctx types.Context,
+	createOpts *volumes.CreateOpts) (*types.Volume, error) {
+
+	fields := log.Fields{
+		"volumeName":       createOpts.Name,
+		"size":             createOpts.Size,
+		"volumeType":       createOpts.VolumeType,
+		"availabilityZone": createOpts.Availability,
+		"snapshotID":       createOpts.SnapshotID,
+		"sourceVolumeID":   createOpts.SourceVolID,
+	}
+
+	vol, err := volumes.Create(d.clientBlockStorage, createOpts).Extract()
+	if err != nil {
+		return nil, goof.WithFieldsE(fields, "error creating volume", err)
+	}
+	fields["volumeID"] = vol.ID
+
+	if vol, err = d.waitForVolumeStatus(
+		ctx, vol.ID, statusAvailable); err != nil {
+		return nil, goof.WithFieldsE(fields,
+			"error waiting for volume creation to complete", err)
+	}
+
+	ctx.WithFields(fields).Info("created volume")
+	return toTypesVolume(vol, 0, nil), nil
+}
+
+func (d *driver) getVolume(volumeID string) (*volumes.Volume, error) {
+	if volumeID == "" {
+		return nil, utils.NewNotFoundError(volumeID)
+	}
+	vol, err := volumes.Get(d.clientBlockStorage, volumeID).Extract()
+	if err != nil {
+		if isNotFound(err) {
+			return nil, utils.NewNotFoundError(volumeID)
+		}
+		return nil, goof.WithFieldE(
+			"volumeID", volumeID, "error getting volume", err)
+	}
+	return vol, nil
+}
+
+func (d *driver) getSnapshot(
+	snapshotID string) (*snapshots.Snapshot, error) {
+
+	if snapshotID == "" {
+		return nil, utils.NewNotFoundError(snapshotID)
+	}
+	snap, err := snapshots.Get(d.clientBlockStorage, snapshotID).Extract()
+	if err != nil {
+		if isNotFound(err) {
+			return nil, utils.NewNotFoundError(snapshotID)
+		}
+		return nil, goof.WithFieldE(
+			"snapshotID", snapshotID, "error getting snapshot", err)
+	}
+	return snap, nil
+}
+
+// waitForVolumeStatus polls a volume until it has the specified status or
+// the status timeout elapses.
+func (d *driver) waitForVolumeStatus(
+	ctx types.Context,
+	volumeID, status string) (*volumes.Volume, error) {
+
+	timeout, _ := d.statusTimeout()
+	deadline := time.Now().Add(timeout)
+
+	for {
+		vol, err := d.getVolume(volumeID)
+		if err != nil {
+			return nil, err
+		}
+		if vol.Status == status {
+			return vol, nil
+		}
+		if vol.Status == statusError {
+			return nil, goof.WithField(
+				"volumeID", volumeID, "volume in error state")
+		}
+		if time.Now().After(deadline) {
+			return nil, goof.WithFields(goof.Fields{
+				"volumeID": volumeID,
+				"status":   vol.Status,
+				"timeout":  timeout,
+			}, "timed out waiting for volume status")
+		}
+
+		ctx.WithFields(log.Fields{
+			"volumeID": volumeID,
+			"status":   vol.Status,
+		}).Debug("waiting for volume status")
+		time.Sleep(2 * time.Second)
+	}
+}
+
+// waitForSnapshotStatus polls a snapshot until it has the specified status
+// or the status timeout elapses.
+func (d *driver) waitForSnapshotStatus(
+	ctx types.Context,
+	snapshotID, status string) (*snapshots.Snapshot, error) {
+
+	timeout, _ := d.statusTimeout()
+	deadline := time.Now().Add(timeout)
+
+	for {
+		snap, err := d.getSnapshot(snapshotID)
+		if err != nil {
+			return nil, err
+		}
+		if snap.Status == status {
+			return snap, nil
+		}
+		if snap.Status == statusError {
+			return nil, goof.WithField(
+				"snapshotID", snapshotID, "snapshot in error state")
+		}
+		if time.Now().After(deadline) {
+			return nil, goof.WithFields(goof.Fields{
+				"snapshotID": snapshotID,
+				"status":     snap.Status,
+				"timeout":    timeout,
+			}, "timed out waiting for snapshot status")
+		}
+
+		ctx.WithFields(log.Fields{
+			"snapshotID": snapshotID,
+			"status":     snap.Status,
+		}).Debug("waiting for snapshot status")
+		time.Sleep(2 * time.Second)
+	}
+}
+
+// localDevices returns the map of the serial numbers of the volumes attached
+// to the client's instance to their devices.
+func localDevices(
+	ctx types.Context,
+	attachments types.VolumeAttachmentsTypes) map[string]string {
+
+	if !attachments.Requested() {
+		return nil
+	}
+	if ld, ok := context.LocalDevices(ctx); ok {
+		return ld.DeviceMap
+	}
+	return nil
+}
+
+// toTypesVolume converts a Cinder volume to a libStorage volume. The device
+// name of an attachment is the device on which the volume appears locally
+// if it is known, since the device reported by the Compute service is only
+// a hint.
+func toTypesVolume(
+	vol *volumes.Volume,
+	attachments types.VolumeAttachmentsTypes,
+	localDevices map[string]string) *types.Volume {
+
+	v := &types.Volume{
+		ID:               vol.ID,
+		Name:             vol.Name,
+		Size:             int64(vol.Size),
+		Status:           vol.Status,
+		Type:             vol.VolumeType,
+		AvailabilityZone: vol.AvailabilityZone,
+	}
+
+	if !attachments.Requested() {
+		return v
+	}
+
+	for _, a := range vol.Attachments {
+		serverID, _ := a["server_id"].(string)
+		deviceName, _ := a["device"].(string)
+		if dev, ok := localDevices[cinderUtils.Serial(vol.ID)]; ok {
+			deviceName = dev
+		}
+		v.Attachments = append(v.Attachments, &types.VolumeAttachment{
+			VolumeID: vol.ID,
+			InstanceID: &types.InstanceID{
+				ID:     serverID,
+				Driver: cinder.Name,
+			},
+			DeviceName: deviceName,
+			Status:     vol.Status,
+		})
+	}
+	return v
+}
+
+func toTypesSnapshot(snap *snapshots.Snapshot) *types.Snapshot {
+	var startTime int64
+	if t, err := time.Parse(
+		"2006-01-02T15:04:05.000000", snap.CreatedAt); err == nil {
+		startTime = t.Unix()
+	}
+	return &types.Snapshot{
+		ID:          snap.ID,
+		Name:        snap.Name,
+		VolumeID:    snap.VolumeID,
+		VolumeSize:  int64(snap.Size),
+		StartTime:   startTime,
+		Description: snap.Description,
+		Status:      snap.Status,
+	}
+}
+
+func isNotFound(err error) bool {
+	if e, ok := err.(*gophercloud.UnexpectedResponseCodeError); ok {
+		return e.Actual == http.StatusNotFound
+	}
+	return false
+}
+
+func (d *driver) statusTimeout() (time.Duration, error) {
+	return time.ParseDuration(
+		d.config.GetString(cinder.ConfigStatusTimeout))
+}
+
+func (d *driver) authURL() string {
+	return d.config.GetString(cinder.ConfigAuthURL)
+}
+
+func (d *driver) userID() string {
+	return d.config.GetString(cinder.ConfigUserID)
+}
+
+func (d *driver) userName() string {
+	return d.config.GetString(cinder.ConfigUserName)
+}
+
+func (d *driver) password() string {
+	return d.config.GetString(cinder.ConfigPassword)
+}
+
+func (d *driver) tenantID() string {
+	return d.config.GetString(cinder.ConfigTenantID)
+}
+
+func (d *driver) tenantName() string {
+	return d.config.GetString(cinder.ConfigTenantName)
+}
+
+func (d *driver) domainID() string {
+	return d.config.GetString(cinder.ConfigDomainID)
+}
+
+func (d *driver) domainName() string {
+	return d.config.GetString(cinder.ConfigDomainName)
+}
+
+func (d *driver) regionName() string {
+	return d.config.GetString(cinder.ConfigRegionName)
+}
+
+func (d *driver) availabilityZoneName() string {
+	return d.config.GetString(cinder.ConfigAvailabilityZoneName)
+}
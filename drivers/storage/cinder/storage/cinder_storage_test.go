@@ -0,0 +1,454 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	gofigCore "github.com/akutz/gofig"
+	"github.com/akutz/goof"
+	"github.com/rackspace/gophercloud"
+	"github.com/rackspace/gophercloud/openstack/blockstorage/v1/snapshots"
+	"github.com/rackspace/gophercloud/openstack/blockstorage/v1/volumes"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codedellemc/libstorage/api/context"
+	"github.com/codedellemc/libstorage/api/types"
+	"github.com/codedellemc/libstorage/drivers/storage/cinder"
+)
+
+// fakeOpenStack is a fake of the Block Storage and Compute APIs that
+// manages volumes, snapshots, and volume attachments in memory.
+type fakeOpenStack struct {
+	sync.Mutex
+	volumes   map[string]map[string]interface{}
+	snapshots map[string]map[string]interface{}
+
+	// status is the status of the volumes and snapshots the fake creates.
+	status string
+
+	// requests are the methods and paths of the requests the fake served.
+	requests []string
+
+	next int
+}
+
+func newFakeOpenStack() *fakeOpenStack {
+	return &fakeOpenStack{
+		volumes:   map[string]map[string]interface{}{},
+		snapshots: map[string]map[string]interface{}{},
+		status:    statusAvailable,
+	}
+}
+
+func (f *fakeOpenStack) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.Lock()
+	defer f.Unlock()
+
+	p := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	f.requests = append(f.requests, r.Method+" "+strings.Join(p, "/"))
+
+	switch {
+	case p[0] == "volumes" || p[0] == "snapshots":
+		f.serveResource(w, r, p)
+	case len(p) == 3 && p[2] == "os-volume_attachments":
+		body := map[string]map[string]interface{}{}
+		json.NewDecoder(r.Body).Decode(&body)
+		a := body["volumeAttachment"]
+		v, ok := f.volumes[a["volumeId"].(string)]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		v["status"] = statusInUse
+		v["attachments"] = []interface{}{map[string]interface{}{
+			"server_id": p[1],
+			"device":    "/dev/vdb",
+		}}
+		a["serverId"] = p[1]
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"volumeAttachment": a,
+		})
+	case len(p) == 4 && p[2] == "os-volume_attachments":
+		v, ok := f.volumes[p[3]]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		v["status"] = statusAvailable
+		v["attachments"] = []interface{}{}
+		w.WriteHeader(http.StatusAccepted)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (f *fakeOpenStack) serveResource(
+	w http.ResponseWriter, r *http.Request, p []string) {
+
+	// the names of the JSON objects of a volume or snapshot are the
+	// singular forms of the resources' names
+	res, m := p[0], f.volumes
+	if res == "snapshots" {
+		m = f.snapshots
+	}
+	key := strings.TrimSuffix(res, "s")
+
+	if r.Method == "GET" && (len(p) == 1 || p[1] == "detail") {
+		items := []interface{}{}
+		for _, v := range m {
+			items = append(items, v)
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{res: items})
+		return
+	}
+
+	if len(p) == 1 && r.Method == "POST" {
+		body := map[string]map[string]interface{}{}
+		json.NewDecoder(r.Body).Decode(&body)
+		v := body[key]
+		f.next++
+		v["id"] = fmt.Sprintf("%s-%d", key, f.next)
+		v["status"] = f.status
+		v["attachments"] = []interface{}{}
+		if res == "snapshots" {
+			v["size"] = f.volumes[v["volume_id"].(string)]["size"]
+			v["created_at"] = "2017-01-02T03:04:05.000000"
+		}
+		m[v["id"].(string)] = v
+		writeJSON(w, http.StatusOK, map[string]interface{}{key: v})
+		return
+	}
+
+	v, ok := m[p[1]]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	switch r.Method {
+	case "GET":
+		writeJSON(w, http.StatusOK, map[string]interface{}{key: v})
+	case "DELETE":
+		delete(m, p[1])
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// add adds an available volume to the fake.
+func (f *fakeOpenStack) add(id string, size int) map[string]interface{} {
+	f.Lock()
+	defer f.Unlock()
+	v := map[string]interface{}{
+		"id":                id,
+		"display_name":      id,
+		"size":              size,
+		"status":            statusAvailable,
+		"volume_type":       "ssd",
+		"availability_zone": "nova",
+		"attachments":       []interface{}{},
+	}
+	f.volumes[id] = v
+	return v
+}
+
+// do calls the function while it holds the fake's lock. The tests access
+// the fake's state with do since the state is shared with the server's
+// goroutines.
+func (f *fakeOpenStack) do(fn func()) {
+	f.Lock()
+	defer f.Unlock()
+	fn()
+}
+
+func newTestDriver(t *testing.T) (*driver, *fakeOpenStack, func()) {
+	f := newFakeOpenStack()
+	s := httptest.NewServer(f)
+
+	client := &gophercloud.ServiceClient{
+		ProviderClient: &gophercloud.ProviderClient{TokenID: "token"},
+		Endpoint:       s.URL + "/",
+	}
+
+	config := gofigCore.New()
+	config.Set(cinder.ConfigStatusTimeout, "1m")
+	config.Set(cinder.ConfigAvailabilityZoneName, "nova")
+
+	d := &driver{
+		config:             config,
+		provider:           client.ProviderClient,
+		clientCompute:      client,
+		clientBlockStorage: client,
+	}
+	return d, f, s.Close
+}
+
+func newTestContext(id string) types.Context {
+	return context.WithValue(
+		context.Background(),
+		context.InstanceIDKey,
+		&types.InstanceID{ID: id, Driver: cinder.Name})
+}
+
+func TestInit(t *testing.T) {
+	tests := []struct {
+		authURL       string
+		statusTimeout string
+	}{
+		{"", "2m"},
+		{"http://keystone:5000/v2.0", "2"},
+		{"http://keystone:5000/v2.0", ""},
+	}
+	for _, tt := range tests {
+		config := gofigCore.New()
+		config.Set(cinder.ConfigAuthURL, tt.authURL)
+		config.Set(cinder.ConfigStatusTimeout, tt.statusTimeout)
+		d := &driver{}
+		assert.Error(t, d.Init(context.Background(), config), "%+v", tt)
+		assert.Nil(t, d.provider)
+	}
+}
+
+func TestIsNotFound(t *testing.T) {
+	assert.True(t, isNotFound(&gophercloud.UnexpectedResponseCodeError{
+		Actual: http.StatusNotFound}))
+	assert.False(t, isNotFound(&gophercloud.UnexpectedResponseCodeError{
+		Actual: http.StatusInternalServerError}))
+	assert.False(t, isNotFound(goof.New("not found")))
+}
+
+func TestToTypesVolume(t *testing.T) {
+	vol := &volumes.Volume{
+		ID:               "0a1b2c3d-4e5f-6a7b-8c9d-0e1f2a3b4c5d",
+		Name:             "vol1",
+		Size:             10,
+		Status:           statusInUse,
+		VolumeType:       "ssd",
+		AvailabilityZone: "nova",
+		Attachments: []map[string]interface{}{
+			{"server_id": "server-1", "device": "/dev/vdb"},
+		},
+	}
+
+	v := toTypesVolume(vol, 0, nil)
+	assert.Equal(t, int64(10), v.Size)
+	assert.Equal(t, "ssd", v.Type)
+	assert.Empty(t, v.Attachments)
+
+	// the device reported by the Compute service is only a hint
+	v = toTypesVolume(vol, types.VolumeAttachmentsTrue, nil)
+	if assert.Len(t, v.Attachments, 1) {
+		assert.Equal(t, "server-1", v.Attachments[0].InstanceID.ID)
+		assert.Equal(t, "/dev/vdb", v.Attachments[0].DeviceName)
+	}
+
+	v = toTypesVolume(vol, types.VolumeAttachmentsTrue, map[string]string{
+		"0a1b2c3d-4e5f-6a7b-8": "/dev/vdc",
+	})
+	if assert.Len(t, v.Attachments, 1) {
+		assert.Equal(t, "/dev/vdc", v.Attachments[0].DeviceName)
+	}
+}
+
+func TestLocalDevices(t *testing.T) {
+	ctx := newTestContext("server-1")
+	assert.Nil(t, localDevices(ctx, types.VolumeAttachmentsTrue))
+
+	ctx = ctx.WithValue(context.LocalDevicesKey, &types.LocalDevices{
+		Driver:    cinder.Name,
+		DeviceMap: map[string]string{"0a1b2c3d": "/dev/vdb"},
+	})
+	assert.Nil(t, localDevices(ctx, 0))
+	assert.Equal(t,
+		map[string]string{"0a1b2c3d": "/dev/vdb"},
+		localDevices(ctx, types.VolumeAttachmentsTrue))
+}
+
+func TestToTypesSnapshot(t *testing.T) {
+	s := toTypesSnapshot(&snapshots.Snapshot{
+		ID:        "snapshot-1",
+		VolumeID:  "volume-1",
+		Size:      10,
+		CreatedAt: "2017-01-02T03:04:05.000000",
+	})
+	assert.Equal(t, int64(10), s.VolumeSize)
+	assert.Equal(t, int64(1483326245), s.StartTime)
+
+	s = toTypesSnapshot(&snapshots.Snapshot{CreatedAt: "yesterday"})
+	assert.Equal(t, int64(0), s.StartTime)
+}
+
+func TestVolumeCreate(t *testing.T) {
+	d, _, cleanup := newTestDriver(t)
+	defer cleanup()
+	ctx := newTestContext("server-1")
+
+	size, volType, zone := int64(10), "ssd", "az2"
+	vol, err := d.VolumeCreate(ctx, "vol1", &types.VolumeCreateOpts{
+		Size: &size,
+		Type: &volType,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "volume-1", vol.ID)
+	assert.Equal(t, "vol1", vol.Name)
+	assert.Equal(t, int64(10), vol.Size)
+	assert.Equal(t, "ssd", vol.Type)
+	assert.Equal(t, "nova", vol.AvailabilityZone)
+
+	vol, err = d.VolumeCreate(ctx, "vol2", &types.VolumeCreateOpts{
+		Size:             &size,
+		AvailabilityZone: &zone,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "az2", vol.AvailabilityZone)
+
+	vols, err := d.Volumes(ctx, &types.VolumesOpts{})
+	assert.NoError(t, err)
+	assert.Len(t, vols, 2)
+}
+
+func TestVolumeCreateError(t *testing.T) {
+	d, f, cleanup := newTestDriver(t)
+	defer cleanup()
+	ctx := newTestContext("server-1")
+
+	size := int64(10)
+	f.do(func() { f.status = statusError })
+	_, err := d.VolumeCreate(
+		ctx, "vol1", &types.VolumeCreateOpts{Size: &size})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "volume creation")
+	}
+
+	// a volume whose status does not change times out
+	f.do(func() { f.status = "creating" })
+	d.config.Set(cinder.ConfigStatusTimeout, "0s")
+	_, err = d.VolumeCreate(
+		ctx, "vol2", &types.VolumeCreateOpts{Size: &size})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "volume creation")
+	}
+}
+
+func TestNotFound(t *testing.T) {
+	d, f, cleanup := newTestDriver(t)
+	defer cleanup()
+	ctx := newTestContext("server-1")
+
+	_, err := d.VolumeInspect(ctx, "volume-1", &types.VolumeInspectOpts{})
+	assert.IsType(t, &types.ErrNotFound{}, err)
+	err = d.VolumeRemove(ctx, "volume-1", nil)
+	assert.IsType(t, &types.ErrNotFound{}, err)
+	_, _, err = d.VolumeAttach(ctx, "volume-1", &types.VolumeAttachOpts{})
+	assert.IsType(t, &types.ErrNotFound{}, err)
+	_, err = d.SnapshotInspect(ctx, "snapshot-1", nil)
+	assert.IsType(t, &types.ErrNotFound{}, err)
+	_, err = d.VolumeCreateFromSnapshot(
+		ctx, "snapshot-1", "vol1", &types.VolumeCreateOpts{})
+	assert.IsType(t, &types.ErrNotFound{}, err)
+
+	// empty IDs are not found without a request
+	f.do(func() { f.requests = nil })
+	_, err = d.VolumeInspect(ctx, "", &types.VolumeInspectOpts{})
+	assert.IsType(t, &types.ErrNotFound{}, err)
+	f.do(func() { assert.Empty(t, f.requests) })
+}
+
+func TestVolumeAttachDetach(t *testing.T) {
+	d, f, cleanup := newTestDriver(t)
+	defer cleanup()
+	ctx1, ctx2 := newTestContext("server-1"), newTestContext("server-2")
+	f.add("0a1b2c3d-4e5f-6a7b-8c9d-0e1f2a3b4c5d", 10)
+	volumeID := "0a1b2c3d-4e5f-6a7b-8c9d-0e1f2a3b4c5d"
+
+	vol, token, err := d.VolumeAttach(
+		ctx1, volumeID, &types.VolumeAttachOpts{})
+	assert.NoError(t, err)
+	assert.Equal(t, "0a1b2c3d-4e5f-6a7b-8", token)
+	if assert.Len(t, vol.Attachments, 1) {
+		assert.Equal(t, "server-1", vol.Attachments[0].InstanceID.ID)
+	}
+
+	// a volume attached to another instance is only moved by force
+	_, _, err = d.VolumeAttach(ctx2, volumeID, &types.VolumeAttachOpts{})
+	assert.Error(t, err)
+
+	vol, _, err = d.VolumeAttach(
+		ctx2, volumeID, &types.VolumeAttachOpts{Force: true})
+	assert.NoError(t, err)
+	if assert.Len(t, vol.Attachments, 1) {
+		assert.Equal(t, "server-2", vol.Attachments[0].InstanceID.ID)
+	}
+	f.do(func() {
+		assert.Contains(t, f.requests, "DELETE servers/server-1/"+
+			"os-volume_attachments/"+volumeID)
+	})
+
+	vol, err = d.VolumeDetach(ctx2, volumeID, &types.VolumeDetachOpts{})
+	assert.NoError(t, err)
+	assert.Empty(t, vol.Attachments)
+	assert.Equal(t, statusAvailable, vol.Status)
+
+	_, err = d.VolumeDetach(ctx2, volumeID, &types.VolumeDetachOpts{})
+	assert.Error(t, err)
+}
+
+func TestSnapshots(t *testing.T) {
+	d, f, cleanup := newTestDriver(t)
+	defer cleanup()
+	ctx := newTestContext("server-1")
+	f.add("volume-0", 10)
+
+	snap, err := d.VolumeSnapshot(ctx, "volume-0", "snap1", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "snapshot-1", snap.ID)
+	assert.Equal(t, "snap1", snap.Name)
+	assert.Equal(t, "volume-0", snap.VolumeID)
+	assert.Equal(t, int64(10), snap.VolumeSize)
+
+	// the volume is the size of the snapshot unless it is larger
+	size := int64(5)
+	vol, err := d.VolumeCreateFromSnapshot(
+		ctx, snap.ID, "vol1", &types.VolumeCreateOpts{Size: &size})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(10), vol.Size)
+	size = 20
+	vol, err = d.VolumeCreateFromSnapshot(
+		ctx, snap.ID, "vol2", &types.VolumeCreateOpts{Size: &size})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(20), vol.Size)
+
+	snaps, err := d.Snapshots(ctx, nil)
+	assert.NoError(t, err)
+	assert.Len(t, snaps, 1)
+
+	assert.NoError(t, d.SnapshotRemove(ctx, snap.ID, nil))
+	f.do(func() { assert.Empty(t, f.snapshots) })
+}
+
+func TestVolumeCopyRemove(t *testing.T) {
+	d, f, cleanup := newTestDriver(t)
+	defer cleanup()
+	ctx := newTestContext("server-1")
+	f.add("volume-0", 10)
+
+	vol, err := d.VolumeCopy(ctx, "volume-0", "vol1", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(10), vol.Size)
+	assert.Equal(t, "ssd", vol.Type)
+	f.do(func() {
+		assert.Equal(t, "volume-0", f.volumes[vol.ID]["source_volid"])
+	})
+
+	assert.NoError(t, d.VolumeRemove(ctx, vol.ID, nil))
+	f.do(func() { assert.Len(t, f.volumes, 1) })
+}
@@ -0,0 +1,154 @@
+package cinder
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"testing"
+
+	gofig "github.com/akutz/gofig/types"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codedellemc/libstorage/api/server"
+	apitests "github.com/codedellemc/libstorage/api/tests"
+	"github.com/codedellemc/libstorage/api/types"
+
+	// load the driver
+	"github.com/codedellemc/libstorage/drivers/storage/cinder"
+	cinderx "github.com/codedellemc/libstorage/drivers/storage/cinder/executor"
+	_ "github.com/codedellemc/libstorage/drivers/storage/cinder/storage"
+)
+
+// skipTests returns a flag indicating whether the tests are skipped. The
+// tests must be run on an OpenStack instance and read the Keystone
+// credentials from the standard OS_* environment variables.
+func skipTests() bool {
+	noTest, _ := strconv.ParseBool(os.Getenv("TEST_SKIP_CINDER"))
+	return noTest || os.Getenv("OS_AUTH_URL") == ""
+}
+
+var (
+	volumeName string
+	configYAML []byte
+)
+
+func init() {
+	uuid, _ := types.NewUUID()
+	volumeName = "ls-test-" + uuid.String()[:8]
+
+	configYAML = []byte(fmt.Sprintf(`
+cinder:
+  authURL: %s
+  userName: %s
+  password: %s
+  tenantName: %s
+  domainName: %s
+  regionName: %s
+`,
+		os.Getenv("OS_AUTH_URL"),
+		os.Getenv("OS_USERNAME"),
+		os.Getenv("OS_PASSWORD"),
+		os.Getenv("OS_TENANT_NAME"),
+		os.Getenv("OS_USER_DOMAIN_NAME"),
+		os.Getenv("OS_REGION_NAME")))
+}
+
+func TestMain(m *testing.M) {
+	server.CloseOnAbort()
+	ec := m.Run()
+	os.Exit(ec)
+}
+
+func TestInstanceID(t *testing.T) {
+	if skipTests() {
+		t.SkipNow()
+	}
+
+	iid, err := cinderx.InstanceID()
+	assert.NoError(t, err)
+	if err != nil {
+		t.FailNow()
+	}
+
+	apitests.Run(
+		t, cinder.Name, configYAML,
+		(&apitests.InstanceIDTest{
+			Driver:   cinder.Name,
+			Expected: iid,
+		}).Test)
+}
+
+func TestVolumeCreateAttachRemove(t *testing.T) {
+	if skipTests() {
+		t.SkipNow()
+	}
+
+	tf := func(config gofig.Config, client types.Client, t *testing.T) {
+		size := int64(1)
+		vol, err := client.API().VolumeCreate(
+			nil, cinder.Name,
+			&types.VolumeCreateRequest{Name: volumeName, Size: &size})
+		assert.NoError(t, err)
+		if err != nil {
+			t.FailNow()
+		}
+		defer client.API().VolumeRemove(nil, cinder.Name, vol.ID)
+
+		assert.Equal(t, volumeName, vol.Name)
+		assert.Equal(t, size, vol.Size)
+
+		vol, token, err := client.API().VolumeAttach(
+			nil, cinder.Name, vol.ID, &types.VolumeAttachRequest{})
+		assert.NoError(t, err)
+		if err != nil {
+			t.FailNow()
+		}
+		assert.NotEmpty(t, token)
+		assert.Len(t, vol.Attachments, 1)
+
+		vol, err = client.API().VolumeDetach(
+			nil, cinder.Name, vol.ID, &types.VolumeDetachRequest{})
+		assert.NoError(t, err)
+		assert.Len(t, vol.Attachments, 0)
+	}
+	apitests.Run(t, cinder.Name, configYAML, tf)
+}
+
+func TestVolumeSnapshotCreateFromSnapshot(t *testing.T) {
+	if skipTests() {
+		t.SkipNow()
+	}
+
+	tf := func(config gofig.Config, client types.Client, t *testing.T) {
+		size := int64(1)
+		vol, err := client.API().VolumeCreate(
+			nil, cinder.Name,
+			&types.VolumeCreateRequest{Name: volumeName, Size: &size})
+		assert.NoError(t, err)
+		if err != nil {
+			t.FailNow()
+		}
+		defer client.API().VolumeRemove(nil, cinder.Name, vol.ID)
+
+		snap, err := client.API().VolumeSnapshot(
+			nil, cinder.Name, vol.ID,
+			&types.VolumeSnapshotRequest{SnapshotName: volumeName})
+		assert.NoError(t, err)
+		if err != nil {
+			t.FailNow()
+		}
+		defer client.API().SnapshotRemove(nil, cinder.Name, snap.ID)
+		assert.Equal(t, vol.ID, snap.VolumeID)
+
+		vol2, err := client.API().VolumeCreateFromSnapshot(
+			nil, cinder.Name, snap.ID,
+			&types.VolumeCreateRequest{Name: volumeName + "-2"})
+		assert.NoError(t, err)
+		if err != nil {
+			t.FailNow()
+		}
+		defer client.API().VolumeRemove(nil, cinder.Name, vol2.ID)
+		assert.Equal(t, size, vol2.Size)
+	}
+	apitests.Run(t, cinder.Name, configYAML, tf)
+}
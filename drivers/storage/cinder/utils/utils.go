@@ -0,0 +1,116 @@
+package utils
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"path"
+	"time"
+
+	"github.com/akutz/goof"
+)
+
+const (
+	metadataURL = "http://169.254.169.254/openstack/latest/meta_data.json"
+
+	// configDriveLabel is the label of the file system of a config drive.
+	configDriveLabel = "/dev/disk/by-label/config-2"
+
+	// serialLength is the number of characters of a volume's ID that appear
+	// as the serial number of the volume's device.
+	serialLength = 20
+)
+
+// Metadata is the subset of an instance's OpenStack metadata used by the
+// driver.
+type Metadata struct {
+	UUID             string `json:"uuid"`
+	Name             string `json:"name"`
+	AvailabilityZone string `json:"availability_zone"`
+}
+
+// metadataClient is the client used to query the metadata service. Its
+// short timeout prevents instances without the service from waiting on it
+// before the config drive is read.
+var metadataClient = &http.Client{Timeout: 5 * time.Second}
+
+// GetMetadata returns the metadata of the instance on which the process is
+// running. The metadata service is queried first, and the config drive is
+// read if the service is unavailable.
+func GetMetadata() (*Metadata, error) {
+	md, err := getServiceMetadata()
+	if err == nil {
+		return md, nil
+	}
+	md, cdErr := getConfigDriveMetadata()
+	if cdErr == nil {
+		return md, nil
+	}
+	return nil, goof.WithFieldsE(goof.Fields{
+		"metadataServiceError": err.Error(),
+	}, "error getting instance metadata", cdErr)
+}
+
+func getServiceMetadata() (*Metadata, error) {
+	res, err := metadataClient.Get(metadataURL)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, goof.WithField(
+			"status", res.StatusCode, "error querying metadata service")
+	}
+
+	md := &Metadata{}
+	if err := json.NewDecoder(res.Body).Decode(md); err != nil {
+		return nil, err
+	}
+	return md, nil
+}
+
+// getConfigDriveMetadata mounts the config drive read-only to a temporary
+// directory, reads the metadata, and unmounts the config drive.
+func getConfigDriveMetadata() (*Metadata, error) {
+	if _, err := os.Stat(configDriveLabel); err != nil {
+		return nil, goof.WithError("config drive not found", err)
+	}
+
+	dir, err := ioutil.TempDir("", "config-2")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	out, err := exec.Command(
+		"mount", "-o", "ro", configDriveLabel, dir).CombinedOutput()
+	if err != nil {
+		return nil, goof.WithFieldE(
+			"output", string(out), "error mounting config drive", err)
+	}
+	defer exec.Command("umount", dir).Run()
+
+	buf, err := ioutil.ReadFile(
+		path.Join(dir, "openstack", "latest", "meta_data.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	md := &Metadata{}
+	if err := json.Unmarshal(buf, md); err != nil {
+		return nil, err
+	}
+	return md, nil
+}
+
+// Serial returns the serial number with which a volume's device appears in
+// /dev/disk/by-id.
+func Serial(volumeID string) string {
+	if len(volumeID) > serialLength {
+		return volumeID[:serialLength]
+	}
+	return volumeID
+}
@@ -0,0 +1,13 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSerial(t *testing.T) {
+	assert.Equal(t, "0a1b2c3d-4e5f-6a7b-8",
+		Serial("0a1b2c3d-4e5f-6a7b-8c9d-0e1f2a3b4c5d"))
+	assert.Equal(t, "0a1b2c3d", Serial("0a1b2c3d"))
+}
@@ -56,6 +56,12 @@ func init() {
 	r.Key(gofig.String, "", "", "", Name+"."+Endpoint)
 	r.Key(gofig.Int, "", DefaultMaxRetries, "", Name+"."+MaxRetries)
 	r.Key(gofig.String, "", "", "Tag prefix for EBS naming", Name+"."+Tag)
+	r.Key(gofig.String, "", "",
+		"The timeout of requests to AWS", Name+".httpTimeout")
+	r.Key(gofig.String, "", "10s",
+		"The timeout of connections to AWS", Name+".connectTimeout")
+	r.Key(gofig.String, "", "",
+		"The URL of the proxy of requests to AWS", Name+".proxy")
 
 	r.Key(gofig.String, "", "", "", NameEC2+"."+AccessKey)
 	r.Key(gofig.String, "", "", "", NameEC2+"."+SecretKey)
@@ -14,15 +14,19 @@ import (
 	"github.com/akutz/goof"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/credentials/ec2rolecreds"
 	"github.com/aws/aws-sdk-go/aws/ec2metadata"
-	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/aws/request"
 	awsec2 "github.com/aws/aws-sdk-go/service/ec2"
 
 	"github.com/codedellemc/libstorage/api/context"
 	"github.com/codedellemc/libstorage/api/registry"
 	"github.com/codedellemc/libstorage/api/types"
+	"github.com/codedellemc/libstorage/api/utils"
+	"github.com/codedellemc/libstorage/api/utils/tracing"
+	"github.com/codedellemc/libstorage/drivers/storage/awsutils"
 	"github.com/codedellemc/libstorage/drivers/storage/ebs"
 	ebsUtils "github.com/codedellemc/libstorage/drivers/storage/ebs/utils"
 )
@@ -37,12 +41,13 @@ const (
 )
 
 type driver struct {
-	name       string
-	config     gofig.Config
-	region     *string
-	endpoint   *string
-	maxRetries *int
-	accessKey  string
+	name         string
+	config       gofig.Config
+	region       *string
+	endpoint     *string
+	maxRetries   *int
+	accessKey    string
+	clientConfig *awsutils.ClientConfig
 }
 
 func init() {
@@ -79,6 +84,12 @@ func (d *driver) Init(context types.Context, config gofig.Config) error {
 	}
 	maxRetries := d.getMaxRetries()
 	d.maxRetries = &maxRetries
+	cc, err := awsutils.NewClientConfig(config, ebs.Name)
+	if err != nil {
+		return err
+	}
+	cc.MaxRetries = maxRetries
+	d.clientConfig = cc
 	log.Info("storage driver initialized")
 	return nil
 }
@@ -149,7 +160,7 @@ func (d *driver) Login(ctx types.Context) (interface{}, error) {
 	}
 
 	log.WithFields(fields).Debug("ebs service connetion attempt")
-	sess := session.New()
+	sess := awsutils.Session(d.clientConfig)
 
 	svc := awsec2.New(
 		sess,
@@ -182,7 +193,35 @@ func (d *driver) Login(ctx types.Context) (interface{}, error) {
 }
 
 func mustSession(ctx types.Context) *awsec2.EC2 {
-	return context.MustSession(ctx).(*awsec2.EC2)
+	svc := context.MustSession(ctx).(*awsec2.EC2)
+	svc = &awsec2.EC2{Client: awsutils.WithContext(ctx, svc.Client)}
+	if _, ok := tracing.FromContext(ctx); !ok {
+		return svc
+	}
+	return traceSession(ctx, svc)
+}
+
+// traceSession returns a copy of the cached EC2 client that times each of its
+// requests with a span that is a child of the context's current span.
+func traceSession(ctx types.Context, svc *awsec2.EC2) *awsec2.EC2 {
+	var (
+		c    = *svc.Client
+		span *tracing.Span
+	)
+	c.Handlers = c.Handlers.Copy()
+	c.Handlers.Send.PushFront(func(r *request.Request) {
+		_, span = tracing.StartSpan(ctx, "ec2."+r.Operation.Name)
+		span.SetTag("aws.region", aws.StringValue(r.Config.Region))
+	})
+	c.Handlers.Send.PushBack(func(r *request.Request) {
+		if r.HTTPResponse != nil {
+			span.SetTag(
+				"http.status_code",
+				fmt.Sprintf("%d", r.HTTPResponse.StatusCode))
+		}
+		span.FinishWithError(r.Error)
+	})
+	return &awsec2.EC2{Client: &c}
 }
 
 func mustInstanceIDID(ctx types.Context) *string {
@@ -222,6 +261,16 @@ func (d *driver) Type(ctx types.Context) (types.StorageType, error) {
 	return types.Block, nil
 }
 
+// Capabilities advertises block attach semantics and the 16 TiB size limit
+// of an EBS volume.
+func (d *driver) Capabilities(
+	ctx types.Context) (*types.StorageCapabilities, error) {
+	return &types.StorageCapabilities{
+		AttachSemantics: types.Block,
+		MaxVolumeSize:   16384,
+	}, nil
+}
+
 // InstanceInspect returns an instance.
 func (d *driver) InstanceInspect(
 	ctx types.Context,
@@ -243,7 +292,7 @@ func (d *driver) Volumes(
 	// Get all volumes via EC2 API
 	ec2vols, err := d.getVolume(ctx, "", "")
 	if err != nil {
-		return nil, goof.WithError("error getting volume", err)
+		return nil, wrapError(nil, "error getting volume", err)
 	}
 	if len(ec2vols) == 0 {
 		return nil, errNoVolReturned
@@ -264,7 +313,7 @@ func (d *driver) VolumeInspect(
 	// Get volume corresponding to volume ID via EC2 API
 	ec2vols, err := d.getVolume(ctx, volumeID, "")
 	if err != nil {
-		return nil, goof.WithError("error getting volume", err)
+		return nil, wrapError(nil, "error getting volume", err)
 	}
 	if len(ec2vols) == 0 {
 		return nil, errNoVolReturned
@@ -328,7 +377,7 @@ func (d *driver) VolumeCreate(ctx types.Context, volumeName string,
 	// Pass libStorage types.Volume to helper function which calls EC2 API
 	vol, err := d.createVolume(ctx, volumeName, "", volume)
 	if err != nil {
-		return nil, goof.WithFieldsE(fields, "error creating volume", err)
+		return nil, wrapError(fields, "error creating volume", err)
 	}
 	// Return the volume created
 	return d.VolumeInspect(ctx, *vol.VolumeId, &types.VolumeInspectOpts{
@@ -498,6 +547,15 @@ func (d *driver) VolumeCopy(
 	*/
 }
 
+// VolumeExpand expands a volume (not implemented)
+func (d *driver) VolumeExpand(
+	ctx types.Context,
+	volumeID string,
+	newSize int64,
+	opts types.Store) (*types.Volume, error) {
+	return nil, types.ErrNotImplemented
+}
+
 // VolumeSnapshot snapshots a volume.
 func (d *driver) VolumeSnapshot(
 	ctx types.Context,
@@ -560,7 +618,8 @@ func (d *driver) VolumeRemove(
 	}
 	_, err := mustSession(ctx).DeleteVolume(dvInput)
 	if err != nil {
-		return goof.WithFieldsE(fields, "error deleting volume", err)
+		return wrapError(
+			fields, "error deleting volume", translateError(err, volumeID))
 	}
 
 	return nil
@@ -580,7 +639,7 @@ func (d *driver) VolumeAttach(
 	// review volume with attachments to any host
 	ec2vols, err := d.getVolume(ctx, volumeID, "")
 	if err != nil {
-		return nil, "", goof.WithError("error getting volume", err)
+		return nil, "", wrapError(nil, "error getting volume", err)
 	}
 	volumes, convErr := d.toTypesVolume(
 		ctx, ec2vols, types.VolumeAttachmentsTrue)
@@ -618,7 +677,7 @@ func (d *driver) VolumeAttach(
 	// Attach volume via helper function which uses EC2 API call
 	err = d.attachVolume(ctx, volumeID, volumes[0].Name, *opts.NextDevice)
 	if err != nil {
-		return nil, "", goof.WithFieldsE(
+		return nil, "", wrapError(
 			log.Fields{
 				"provider": d.Name(),
 				"volumeID": volumeID},
@@ -639,7 +698,7 @@ func (d *driver) VolumeAttach(
 			Opts:        opts.Opts,
 		})
 	if err != nil {
-		return nil, "", goof.WithError("error getting volume", err)
+		return nil, "", wrapError(nil, "error getting volume", err)
 	}
 
 	// Token is the attachment's device name, which will be matched
@@ -657,7 +716,7 @@ func (d *driver) VolumeDetach(
 	// review volume with attachments to any host
 	ec2vols, err := d.getVolume(ctx, volumeID, "")
 	if err != nil {
-		return nil, goof.WithError("error getting volume", err)
+		return nil, wrapError(nil, "error getting volume", err)
 	}
 	volumes, convErr := d.toTypesVolume(
 		ctx, ec2vols, types.VolumeAttachmentsTrue)
@@ -682,10 +741,11 @@ func (d *driver) VolumeDetach(
 
 	// Detach volume using EC2 API call
 	if _, err = mustSession(ctx).DetachVolume(dvInput); err != nil {
-		return nil, goof.WithFieldsE(
+		return nil, wrapError(
 			log.Fields{
 				"provider": d.Name(),
-				"volumeID": volumeID}, "error detaching volume", err)
+				"volumeID": volumeID}, "error detaching volume",
+			translateError(err, volumeID))
 	}
 
 	if err = d.waitVolumeComplete(ctx, volumeID, waitVolumeDetach); err != nil {
@@ -701,7 +761,7 @@ func (d *driver) VolumeDetach(
 			Opts:        opts.Opts,
 		})
 	if err != nil {
-		return nil, goof.WithError("error getting volume", err)
+		return nil, wrapError(nil, "error getting volume", err)
 	}
 
 	return detachedVol, nil
@@ -910,12 +970,58 @@ func (d *driver) getVolume(
 	// Retrieve filtered volumes through EC2 API call
 	resp, err := mustSession(ctx).DescribeVolumes(dvInput)
 	if err != nil {
-		return []*awsec2.Volume{}, err
+		return []*awsec2.Volume{}, translateError(err, volumeID)
 	}
 
 	return resp.Volumes, nil
 }
 
+// ec2ErrorCodes maps the codes of the errors reported by EC2 to the codes of
+// the errors returned by the driver.
+var ec2ErrorCodes = map[string]types.ErrorCode{
+	"InvalidVolume.NotFound":     types.ErrorCodeNotFound,
+	"InvalidSnapshot.NotFound":   types.ErrorCodeNotFound,
+	"InvalidAttachment.NotFound": types.ErrorCodeNotFound,
+	"VolumeInUse":                types.ErrorCodeBusy,
+	"IncorrectState":             types.ErrorCodeBusy,
+	"RequestLimitExceeded":       types.ErrorCodeBusy,
+	"VolumeLimitExceeded":        types.ErrorCodeQuotaExceeded,
+	"SnapshotLimitExceeded":      types.ErrorCodeQuotaExceeded,
+	"AttachmentLimitExceeded":    types.ErrorCodeQuotaExceeded,
+	"MaxIOPSLimitExceeded":       types.ErrorCodeQuotaExceeded,
+	"InsufficientVolumeCapacity": types.ErrorCodeQuotaExceeded,
+	"AuthFailure":                types.ErrorCodeAuthFailed,
+	"UnauthorizedOperation":      types.ErrorCodeAuthFailed,
+	"InvalidClientTokenId":       types.ErrorCodeAuthFailed,
+	"SignatureDoesNotMatch":      types.ErrorCodeAuthFailed,
+	"RequestTimeout":             types.ErrorCodeTimeout,
+}
+
+// translateError returns an error with the code that corresponds to the code
+// of an EC2 error. Errors without a corresponding code are returned as is.
+func translateError(err error, resourceID string) error {
+	if awsErr, ok := err.(awserr.Error); ok {
+		if code, ok := ec2ErrorCodes[awsErr.Code()]; ok {
+			return utils.NewErrorCodeError(code, resourceID, err)
+		}
+	}
+	return err
+}
+
+// wrapError wraps an error with a message and fields. Errors with a code are
+// returned as is so that the code is preserved.
+func wrapError(
+	fields map[string]interface{}, msg string, err error) error {
+
+	if types.ErrorCodeOf(err) != "" {
+		return err
+	}
+	if fields == nil {
+		return goof.WithError(msg, err)
+	}
+	return goof.WithFieldsE(fields, msg, err)
+}
+
 var errGetLocDevs = goof.New("error getting local devices from context")
 
 // Converts EC2 API volumes to libStorage types.Volume
@@ -1061,7 +1167,7 @@ func (d *driver) attachVolume(
 	// sanity check # of volumes to attach
 	vol, err := d.getVolume(ctx, volumeID, volumeName)
 	if err != nil {
-		return goof.WithError("error getting volume", err)
+		return wrapError(nil, "error getting volume", err)
 	}
 
 	if len(vol) == 0 {
@@ -1079,7 +1185,7 @@ func (d *driver) attachVolume(
 	}
 
 	if _, err := mustSession(ctx).AttachVolume(avInput); err != nil {
-		return err
+		return translateError(err, volumeID)
 	}
 	return nil
 }
@@ -1116,8 +1222,8 @@ func (d *driver) createVolume(ctx types.Context, volumeName, snapshotID string,
 	var resp *awsec2.Volume
 
 	if resp, err = mustSession(ctx).CreateVolume(options); err != nil {
-		return &awsec2.Volume{}, goof.WithError(
-			"error creating volume", err)
+		return &awsec2.Volume{}, wrapError(
+			nil, "error creating volume", translateError(err, volumeName))
 	}
 
 	// Add tags to created volume
@@ -1207,7 +1313,7 @@ func (d *driver) waitVolumeComplete(
 		// update volume
 		volumes, err := d.getVolume(ctx, volumeID, "")
 		if err != nil {
-			return goof.WithError("error getting volume", err)
+			return wrapError(nil, "error getting volume", err)
 		}
 
 		// check retrieved volume
@@ -1228,7 +1334,11 @@ func (d *driver) waitVolumeComplete(
 		}
 
 		if loop {
-			time.Sleep(1 * time.Second)
+			select {
+			case <-time.After(1 * time.Second):
+			case <-ctx.Done():
+				return wrapError(nil, "error waiting for volume", ctx.Err())
+			}
 		}
 	}
 
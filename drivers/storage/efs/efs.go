@@ -16,6 +16,42 @@ const (
 	// InstanceIDFieldAvailabilityZone is the key to retrieve the availability
 	// zone value from the InstanceID Field map.
 	InstanceIDFieldAvailabilityZone = "availabilityZone"
+
+	// VolumeFieldLifecyclePolicy is the key to retrieve the lifecycle policy
+	// from the Volume Field map. It is also the name of the VolumeCreate
+	// option that overrides the configured lifecycle policy.
+	VolumeFieldLifecyclePolicy = "lifecyclePolicy"
+
+	// VolumeFieldPolicyHash is the key to retrieve the SHA-256 hash of the
+	// file system's resource policy from the Volume Field map. The field is
+	// omitted if the file system has the default policy.
+	VolumeFieldPolicyHash = "policyHash"
+
+	// VolumeOptPolicy is the name of the VolumeCreate option that overrides
+	// the configured resource policy.
+	VolumeOptPolicy = "policy"
+
+	// VolumeOptEnforceTLS is the name of the VolumeCreate option that
+	// overrides whether the configured resource policy enforces TLS.
+	VolumeOptEnforceTLS = "enforceTLS"
+
+	// ConfigLifecyclePolicy is a config key.
+	ConfigLifecyclePolicy = Name + ".lifecyclePolicy"
+
+	// ConfigPolicy is a config key.
+	ConfigPolicy = Name + ".policy"
+
+	// ConfigEnforceTLS is a config key.
+	ConfigEnforceTLS = Name + ".enforceTLS"
+
+	// ConfigTLS is a config key.
+	ConfigTLS = Name + ".tls"
+
+	// DevicePrefix is the prefix of the device names of attachments when
+	// efs.tls is enabled. Such devices are formatted as
+	// efs://mountTargetIP/fileSystemID so that clients are able to mount the
+	// file system with the EFS mount helper.
+	DevicePrefix = "efs://"
 )
 
 func init() {
@@ -26,5 +62,24 @@ func init() {
 		"Comma separated security group ids", "efs.securityGroups")
 	r.Key(gofig.String, "", "", "AWS region", "efs.region")
 	r.Key(gofig.String, "", "", "Tag prefix for EFS naming", "efs.tag")
+	r.Key(gofig.String, "", "",
+		"Days after which files transition to Infrequent Access",
+		ConfigLifecyclePolicy)
+	r.Key(gofig.Bool, "", false,
+		"Mount with the EFS mount helper and TLS", ConfigTLS)
+	r.Key(gofig.String, "", "",
+		"The resource policy of created file systems", ConfigPolicy)
+	r.Key(gofig.Bool, "", false,
+		"Only allow access to created file systems over TLS",
+		ConfigEnforceTLS)
+	r.Key(gofig.String, "", "",
+		"The timeout of requests to AWS", "efs.httpTimeout")
+	r.Key(gofig.String, "", "10s",
+		"The timeout of connections to AWS", "efs.connectTimeout")
+	r.Key(gofig.String, "", "",
+		"The URL of the proxy of requests to AWS", "efs.proxy")
+	r.Key(gofig.Int, "", -1,
+		"The max retries of requests to AWS, or -1 for the default",
+		"efs.maxRetries")
 	gofigCore.Register(r)
 }
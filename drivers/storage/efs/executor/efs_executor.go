@@ -5,9 +5,11 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"os"
 	"strings"
+	"time"
 
 	gofig "github.com/akutz/gofig/types"
 	"github.com/akutz/goof"
@@ -27,6 +29,12 @@ type driver struct {
 const (
 	idDelimiter     = "/"
 	mountinfoFormat = "%d %d %d:%d %s %s %s %s"
+
+	// nfsPort is the port on which mount targets accept NFS connections.
+	nfsPort = "2049"
+
+	// nfsDialTimeout is how long a connection to a mount target may take.
+	nfsDialTimeout = 2 * time.Second
 )
 
 func init() {
@@ -107,6 +115,35 @@ func (d *driver) LocalDevices(
 	}, nil
 }
 
+// DeviceReady returns a flag indicating whether the mount target identified
+// by the attach token, which is formatted as host:/, resolves and accepts
+// NFS connections.
+func (d *driver) DeviceReady(
+	ctx types.Context,
+	token string,
+	opts types.Store) (bool, error) {
+
+	host := strings.SplitN(token, ":", 2)[0]
+	if host == "" {
+		return false, goof.WithField("token", token, "invalid attach token")
+	}
+
+	addrs, err := net.LookupHost(host)
+	if err != nil || len(addrs) == 0 {
+		ctx.WithField("host", host).Debug("mount target does not resolve")
+		return false, nil
+	}
+
+	conn, err := net.DialTimeout(
+		"tcp", net.JoinHostPort(addrs[0], nfsPort), nfsDialTimeout)
+	if err != nil {
+		ctx.WithField("host", host).Debug("mount target is not reachable")
+		return false, nil
+	}
+	conn.Close()
+	return true, nil
+}
+
 func parseMountTable() ([]*types.MountInfo, error) {
 	f, err := os.Open("/proc/self/mountinfo")
 	if err != nil {
@@ -0,0 +1,130 @@
+package storage
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/akutz/goof"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+
+	"github.com/codedellemc/libstorage/api/types"
+)
+
+// lifecyclePath is the path of the EFS API's lifecycle configuration of a
+// file system.
+const lifecyclePath = "/2015-02-01/file-systems/{FileSystemId}/" +
+	"lifecycle-configuration"
+
+// transitionDays are the numbers of days after which EFS can transition the
+// files of a file system to the Infrequent Access storage class.
+var transitionDays = []int{1, 7, 14, 30, 60, 90, 180, 270, 365}
+
+// lifecycleConfigurationInput is the input of the EFS operations that put and
+// describe the lifecycle configuration of a file system. The AWS SDK version
+// the driver uses predates these operations, so they are sent with the EFS
+// client's protocol handlers using these types in place of the SDK's own.
+type lifecycleConfigurationInput struct {
+	_ struct{} `type:"structure"`
+
+	FileSystemId *string `location:"uri" locationName:"FileSystemId" type:"string" required:"true"`
+
+	LifecyclePolicies []*lifecyclePolicy `type:"list"`
+}
+
+// lifecycleConfigurationOutput is the output of the EFS operations that put
+// and describe the lifecycle configuration of a file system.
+type lifecycleConfigurationOutput struct {
+	_ struct{} `type:"structure"`
+
+	LifecyclePolicies []*lifecyclePolicy `type:"list"`
+}
+
+type lifecyclePolicy struct {
+	_ struct{} `type:"structure"`
+
+	TransitionToIA *string `type:"string"`
+}
+
+// parseLifecyclePolicy parses a lifecycle policy, which is either the number
+// of days after which files transition to Infrequent Access, such as 30, or
+// the EFS name of the policy, such as AFTER_30_DAYS. An empty string is
+// returned if the value is empty or "none", indicating files never
+// transition.
+func parseLifecyclePolicy(v string) (string, error) {
+	v = strings.TrimSpace(v)
+	if v == "" || strings.EqualFold(v, "none") {
+		return "", nil
+	}
+	days := strings.TrimSuffix(strings.TrimPrefix(
+		strings.ToUpper(v), "AFTER_"), "_DAYS")
+	if n, err := strconv.Atoi(days); err == nil {
+		for _, td := range transitionDays {
+			if n == td {
+				return fmt.Sprintf("AFTER_%d_DAYS", n), nil
+			}
+		}
+	}
+	return "", goof.WithField("lifecyclePolicy", v, "invalid lifecycle policy")
+}
+
+// putLifecyclePolicy sets the lifecycle policy of a file system. An empty
+// policy removes the file system's lifecycle policy.
+func (d *driver) putLifecyclePolicy(
+	ctx types.Context, fileSystemID, policy string) error {
+
+	in := &lifecycleConfigurationInput{
+		FileSystemId:      aws.String(fileSystemID),
+		LifecyclePolicies: []*lifecyclePolicy{},
+	}
+	if policy != "" {
+		in.LifecyclePolicies = append(in.LifecyclePolicies,
+			&lifecyclePolicy{TransitionToIA: aws.String(policy)})
+	}
+
+	_, err := d.lifecycleConfiguration(ctx, http.MethodPut, in)
+	return err
+}
+
+// getLifecyclePolicy returns the lifecycle policy of a file system, or an
+// empty string if the file system does not have one.
+func (d *driver) getLifecyclePolicy(
+	ctx types.Context, fileSystemID string) (string, error) {
+
+	out, err := d.lifecycleConfiguration(
+		ctx, http.MethodGet,
+		&lifecycleConfigurationInput{FileSystemId: aws.String(fileSystemID)})
+	if err != nil {
+		return "", err
+	}
+	for _, p := range out.LifecyclePolicies {
+		if p.TransitionToIA != nil {
+			return *p.TransitionToIA, nil
+		}
+	}
+	return "", nil
+}
+
+func (d *driver) lifecycleConfiguration(
+	ctx types.Context,
+	method string,
+	in *lifecycleConfigurationInput) (*lifecycleConfigurationOutput, error) {
+
+	op := &request.Operation{
+		Name:       "PutLifecycleConfiguration",
+		HTTPMethod: method,
+		HTTPPath:   lifecyclePath,
+	}
+	if method == http.MethodGet {
+		op.Name = "DescribeLifecycleConfiguration"
+	}
+
+	out := &lifecycleConfigurationOutput{}
+	if err := d.efsClient(ctx).NewRequest(op, in, out).Send(); err != nil {
+		return nil, translateError(err, aws.StringValue(in.FileSystemId))
+	}
+	return out, nil
+}
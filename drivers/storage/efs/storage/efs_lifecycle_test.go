@@ -0,0 +1,40 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLifecyclePolicy(t *testing.T) {
+	tests := []struct {
+		value  string
+		policy string
+		err    bool
+	}{
+		{"", "", false},
+		{" ", "", false},
+		{"none", "", false},
+		{"NONE", "", false},
+		{"30", "AFTER_30_DAYS", false},
+		{" 7 ", "AFTER_7_DAYS", false},
+		{"AFTER_14_DAYS", "AFTER_14_DAYS", false},
+		{"after_365_days", "AFTER_365_DAYS", false},
+		{"1", "AFTER_1_DAYS", false},
+		{"2", "", true},
+		{"0", "", true},
+		{"-30", "", true},
+		{"AFTER_31_DAYS", "", true},
+		{"thirty", "", true},
+		{"30d", "", true},
+	}
+	for _, tt := range tests {
+		policy, err := parseLifecyclePolicy(tt.value)
+		if tt.err {
+			assert.Error(t, err, tt.value)
+			continue
+		}
+		assert.NoError(t, err, tt.value)
+		assert.Equal(t, tt.policy, policy, tt.value)
+	}
+}
@@ -0,0 +1,207 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/akutz/goof"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+
+	"github.com/codedellemc/libstorage/api/types"
+)
+
+// policyPath is the path of the EFS API's resource policy of a file system.
+const policyPath = "/2015-02-01/file-systems/{FileSystemId}/policy"
+
+// fileSystemPolicyInput is the input of the EFS operations that put and
+// describe the resource policy of a file system. Like the lifecycle
+// configuration operations, these operations are newer than the AWS SDK
+// version the driver uses.
+type fileSystemPolicyInput struct {
+	_ struct{} `type:"structure"`
+
+	FileSystemId *string `location:"uri" locationName:"FileSystemId" type:"string" required:"true"`
+
+	Policy *string `type:"string"`
+}
+
+// fileSystemPolicyOutput is the output of the EFS operations that put and
+// describe the resource policy of a file system.
+type fileSystemPolicyOutput struct {
+	_ struct{} `type:"structure"`
+
+	FileSystemId *string `type:"string"`
+
+	Policy *string `type:"string"`
+}
+
+// enforceTLSPolicy is the policy of a file system that may only be accessed
+// through its mount targets over TLS. It is equivalent to the policy the EFS
+// console creates when in-transit encryption is enforced.
+const enforceTLSPolicy = `{
+    "Version": "2012-10-17",
+    "Statement": [
+        {
+            "Effect": "Allow",
+            "Principal": { "AWS": "*" },
+            "Action": [
+                "elasticfilesystem:ClientMount",
+                "elasticfilesystem:ClientWrite",
+                "elasticfilesystem:ClientRootAccess"
+            ],
+            "Resource": "${FileSystemArn}",
+            "Condition": {
+                "Bool": { "elasticfilesystem:AccessedViaMountTarget": "true" }
+            }
+        },
+        {
+            "Effect": "Deny",
+            "Principal": { "AWS": "*" },
+            "Action": "*",
+            "Resource": "${FileSystemArn}",
+            "Condition": { "Bool": { "aws:SecureTransport": "false" } }
+        }
+    ]
+}`
+
+// parsePolicy validates a resource policy, which is a JSON object, or a
+// string of one, that may refer to the file system to which it applies as
+// ${FileSystemArn} or ${FileSystemId}. An empty string is returned if the
+// policy is empty or "none", indicating the file system has the default
+// policy.
+func parsePolicy(v interface{}) (string, error) {
+	var policy string
+	switch tv := v.(type) {
+	case nil:
+	case string:
+		policy = strings.TrimSpace(tv)
+	case types.Store:
+		return parsePolicy(tv.Map())
+	default:
+		buf, err := json.Marshal(tv)
+		if err != nil {
+			return "", goof.WithError("invalid policy", err)
+		}
+		policy = string(buf)
+	}
+	if policy == "" || strings.EqualFold(policy, "none") {
+		return "", nil
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal([]byte(policy), &doc); err != nil {
+		return "", goof.WithError("invalid policy", err)
+	}
+	return policy, nil
+}
+
+// volumePolicy returns the resource policy of a created file system. A
+// policy document takes precedence over enforceTLS, which otherwise selects
+// the policy that only allows access over TLS.
+func volumePolicy(policy interface{}, enforceTLS bool) (string, error) {
+	doc, err := parsePolicy(policy)
+	if err != nil {
+		return "", err
+	}
+	if doc == "" && enforceTLS {
+		return enforceTLSPolicy, nil
+	}
+	return doc, nil
+}
+
+// policyHash returns a hash of a resource policy that does not depend on the
+// policy's formatting or the order of its properties, so that policies are
+// able to be compared.
+func policyHash(policy string) string {
+	var doc interface{}
+	if err := json.Unmarshal([]byte(policy), &doc); err == nil {
+		if buf, err := json.Marshal(doc); err == nil {
+			policy = string(buf)
+		}
+	}
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(policy)))
+}
+
+// regionPartition returns the AWS partition of a region, such as aws-cn for
+// the regions in China.
+func regionPartition(region string) string {
+	switch {
+	case strings.HasPrefix(region, "cn-"):
+		return "aws-cn"
+	case strings.HasPrefix(region, "us-gov-"):
+		return "aws-us-gov"
+	case strings.HasPrefix(region, "us-isob-"):
+		return "aws-iso-b"
+	case strings.HasPrefix(region, "us-iso-"):
+		return "aws-iso"
+	}
+	return "aws"
+}
+
+// fileSystemArn returns the ARN of a file system.
+func fileSystemArn(region, ownerID, fileSystemID string) string {
+	return fmt.Sprintf("arn:%s:elasticfilesystem:%s:%s:file-system/%s",
+		regionPartition(region), region, ownerID, fileSystemID)
+}
+
+// putPolicy sets the resource policy of a file system.
+func (d *driver) putPolicy(
+	ctx types.Context, fileSystemID, ownerID, policy string) error {
+
+	arn := fileSystemArn(d.region(), ownerID, fileSystemID)
+	policy = strings.NewReplacer(
+		"${FileSystemArn}", arn,
+		"${FileSystemId}", fileSystemID).Replace(policy)
+
+	_, err := d.fileSystemPolicy(ctx, http.MethodPut, &fileSystemPolicyInput{
+		FileSystemId: aws.String(fileSystemID),
+		Policy:       aws.String(policy),
+	})
+	if err != nil {
+		return translateError(err, fileSystemID)
+	}
+	return nil
+}
+
+// getPolicy returns the resource policy of a file system, or an empty string
+// if the file system has the default policy.
+func (d *driver) getPolicy(
+	ctx types.Context, fileSystemID string) (string, error) {
+
+	out, err := d.fileSystemPolicy(ctx, http.MethodGet,
+		&fileSystemPolicyInput{FileSystemId: aws.String(fileSystemID)})
+	if err != nil {
+		if awsErr, ok := err.(awserr.Error); ok &&
+			awsErr.Code() == "PolicyNotFound" {
+			return "", nil
+		}
+		return "", translateError(err, fileSystemID)
+	}
+	return aws.StringValue(out.Policy), nil
+}
+
+func (d *driver) fileSystemPolicy(
+	ctx types.Context,
+	method string,
+	in *fileSystemPolicyInput) (*fileSystemPolicyOutput, error) {
+
+	op := &request.Operation{
+		Name:       "PutFileSystemPolicy",
+		HTTPMethod: method,
+		HTTPPath:   policyPath,
+	}
+	if method == http.MethodGet {
+		op.Name = "DescribeFileSystemPolicy"
+	}
+
+	out := &fileSystemPolicyOutput{}
+	if err := d.efsClient(ctx).NewRequest(op, in, out).Send(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,105 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePolicy(t *testing.T) {
+	doc := `{"Version":"2012-10-17","Statement":[]}`
+	tests := []struct {
+		value  interface{}
+		policy string
+		err    bool
+	}{
+		{nil, "", false},
+		{"", "", false},
+		{" none ", "", false},
+		{doc, doc, false},
+		{"  " + doc + "\n", doc, false},
+		{
+			map[string]interface{}{"Version": "2012-10-17"},
+			`{"Version":"2012-10-17"}`,
+			false,
+		},
+		{"{", "", true},
+		{"allow", "", true},
+		{map[string]interface{}{"fn": func() {}}, "", true},
+	}
+	for _, tt := range tests {
+		policy, err := parsePolicy(tt.value)
+		if tt.err {
+			assert.Error(t, err, "%v", tt.value)
+			continue
+		}
+		assert.NoError(t, err, "%v", tt.value)
+		assert.Equal(t, tt.policy, policy, "%v", tt.value)
+	}
+}
+
+func TestVolumePolicy(t *testing.T) {
+	doc := `{"Version":"2012-10-17","Statement":[]}`
+
+	policy, err := volumePolicy(nil, false)
+	assert.NoError(t, err)
+	assert.Equal(t, "", policy)
+
+	policy, err = volumePolicy(nil, true)
+	assert.NoError(t, err)
+	assert.Equal(t, enforceTLSPolicy, policy)
+
+	policy, err = volumePolicy("none", true)
+	assert.NoError(t, err)
+	assert.Equal(t, enforceTLSPolicy, policy)
+
+	// a policy document takes precedence over enforceTLS
+	policy, err = volumePolicy(doc, true)
+	assert.NoError(t, err)
+	assert.Equal(t, doc, policy)
+
+	_, err = volumePolicy("{", true)
+	assert.Error(t, err)
+}
+
+func TestPolicyHash(t *testing.T) {
+	a := policyHash(`{"Version":"2012-10-17","Statement":[]}`)
+	assert.Len(t, a, 64)
+
+	// the hash does not depend on formatting or the order of properties
+	assert.Equal(t, a, policyHash(
+		"{\n  \"Statement\": [],\n  \"Version\": \"2012-10-17\"\n}"))
+
+	assert.NotEqual(t, a, policyHash(
+		`{"Version":"2008-10-17","Statement":[]}`))
+
+	// policies that are not JSON are hashed as is
+	assert.Equal(t, policyHash("{"), policyHash("{"))
+	assert.NotEqual(t, policyHash("{"), policyHash("{ "))
+}
+
+func TestFileSystemArn(t *testing.T) {
+	tests := []struct {
+		region string
+		arn    string
+	}{
+		{"us-east-1", "arn:aws:elasticfilesystem:us-east-1:" +
+			"123456789012:file-system/fs-1"},
+		{"cn-north-1", "arn:aws-cn:elasticfilesystem:cn-north-1:" +
+			"123456789012:file-system/fs-1"},
+		{"us-gov-west-1", "arn:aws-us-gov:elasticfilesystem:us-gov-west-1:" +
+			"123456789012:file-system/fs-1"},
+		{"us-iso-east-1", "arn:aws-iso:elasticfilesystem:us-iso-east-1:" +
+			"123456789012:file-system/fs-1"},
+		{"us-isob-east-1", "arn:aws-iso-b:elasticfilesystem:us-isob-east-1:" +
+			"123456789012:file-system/fs-1"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.arn,
+			fileSystemArn(tt.region, "123456789012", "fs-1"), tt.region)
+	}
+
+	// the TLS policy refers to the file system by its ARN
+	assert.True(t, strings.Contains(enforceTLSPolicy, "${FileSystemArn}"))
+}
@@ -21,17 +21,49 @@ import (
 	"github.com/codedellemc/libstorage/api/context"
 	"github.com/codedellemc/libstorage/api/registry"
 	"github.com/codedellemc/libstorage/api/types"
+	"github.com/codedellemc/libstorage/api/utils"
+	"github.com/codedellemc/libstorage/drivers/storage/awsutils"
 	"github.com/codedellemc/libstorage/drivers/storage/efs"
 )
 
 const (
 	tagDelimiter = "/"
+
+	// deleteFileSystemTimeout is how long the driver waits for a file system
+	// it could not finish creating to become available so it can be deleted.
+	deleteFileSystemTimeout = 2 * time.Minute
 )
 
+// configSpec declares the driver's configuration properties.
+var configSpec = utils.ConfigSpec{
+	{Key: "efs.accessKey", Secret: true},
+	{Key: "efs.secretKey", Secret: true},
+	{Key: "efs.region"},
+	{Key: "efs.tag"},
+	{Key: "efs.securityGroups"},
+	{
+		Key: efs.ConfigLifecyclePolicy,
+		Validate: func(v string) error {
+			_, err := parseLifecyclePolicy(v)
+			return err
+		},
+	},
+	{Key: efs.ConfigTLS, Values: []string{"true", "false"}},
+	{
+		Key: efs.ConfigPolicy,
+		Validate: func(v string) error {
+			_, err := parsePolicy(v)
+			return err
+		},
+	},
+	{Key: efs.ConfigEnforceTLS, Values: []string{"true", "false"}},
+}
+
 // Driver represents a EFS driver implementation of StorageDriver
 type driver struct {
 	config   gofig.Config
 	awsCreds *credentials.Credentials
+	session  *session.Session
 }
 
 func init() {
@@ -51,24 +83,16 @@ func (d *driver) Name() string {
 func (d *driver) Init(ctx types.Context, config gofig.Config) error {
 	d.config = config
 
-	fields := log.Fields{
-		"accessKey": d.accessKey(),
-		"secretKey": d.secretKey(),
-		"region":    d.region(),
-		"tag":       d.tag(),
-	}
-
-	if d.accessKey() == "" {
-		fields["accessKey"] = ""
-	} else {
-		fields["accessKey"] = "******"
+	fields := configSpec.Fields(config)
+	if err := configSpec.Validate(config); err != nil {
+		return err
 	}
 
-	if d.secretKey() == "" {
-		fields["secretKey"] = ""
-	} else {
-		fields["secretKey"] = "******"
+	cc, err := awsutils.NewClientConfig(config, efs.Name)
+	if err != nil {
+		return err
 	}
+	d.session = awsutils.Session(cc)
 
 	d.awsCreds = credentials.NewChainCredentials(
 		[]credentials.Provider{
@@ -76,7 +100,7 @@ func (d *driver) Init(ctx types.Context, config gofig.Config) error {
 			&credentials.EnvProvider{},
 			&credentials.SharedCredentialsProvider{},
 			&ec2rolecreds.EC2RoleProvider{
-				Client: ec2metadata.New(session.New()),
+				Client: ec2metadata.New(d.session),
 			},
 		})
 
@@ -108,6 +132,38 @@ func (d *driver) Type(ctx types.Context) (types.StorageType, error) {
 	return types.NAS, nil
 }
 
+// Capabilities advertises NAS attach semantics. File systems have no fixed
+// size, and any number of instances may mount one through its mount
+// targets.
+func (d *driver) Capabilities(
+	ctx types.Context) (*types.StorageCapabilities, error) {
+	return &types.StorageCapabilities{
+		AttachSemantics: types.NAS,
+	}, nil
+}
+
+// volumeCreateOptsSchema is the JSON schema of the options the driver
+// accepts when creating a volume. Other options are allowed since the
+// integration drivers forward their own generic options, such as size, with
+// the driver's options.
+var volumeCreateOptsSchema = []byte(`{
+    "$schema": "http://json-schema.org/draft-04/schema#",
+    "type": "object",
+    "properties": {
+        "lifecyclePolicy": { "type": [ "string", "integer" ] },
+        "policy": { "type": [ "string", "object" ] },
+        "enforceTLS": { "type": "boolean" }
+    }
+}`)
+
+// OptsSchema returns the JSON schema of the options an operation accepts.
+func (d *driver) OptsSchema(ctx types.Context, operation string) []byte {
+	if operation == "volumeCreate" {
+		return volumeCreateOptsSchema
+	}
+	return nil
+}
+
 // NextDeviceInfo returns the information about the driver's next available
 // device workflow.
 func (d *driver) NextDeviceInfo(
@@ -120,7 +176,7 @@ func (d *driver) Volumes(
 	ctx types.Context,
 	opts *types.VolumesOpts) ([]*types.Volume, error) {
 
-	fileSystems, err := d.getAllFileSystems()
+	fileSystems, err := d.getAllFileSystems(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -174,11 +230,11 @@ func (d *driver) VolumeInspect(
 	volumeID string,
 	opts *types.VolumeInspectOpts) (*types.Volume, error) {
 
-	resp, err := d.efsClient().DescribeFileSystems(&awsefs.DescribeFileSystemsInput{
+	resp, err := d.efsClient(ctx).DescribeFileSystems(&awsefs.DescribeFileSystemsInput{
 		FileSystemId: aws.String(volumeID),
 	})
 	if err != nil {
-		return nil, err
+		return nil, translateError(err, volumeID)
 	}
 	if len(resp.FileSystems) > 0 {
 		fileSystem := resp.FileSystems[0]
@@ -205,6 +261,30 @@ func (d *driver) VolumeInspect(
 			Attachments: nil,
 		}
 
+		policy, err := d.getLifecyclePolicy(ctx, *fileSystem.FileSystemId)
+		if err != nil {
+			ctx.WithError(err).WithField(
+				"filesystemid", *fileSystem.FileSystemId).Warn(
+				"failed to retrieve EFS lifecycle policy")
+		} else if policy != "" {
+			volume.Fields = map[string]string{
+				efs.VolumeFieldLifecyclePolicy: policy,
+			}
+		}
+
+		resourcePolicy, err := d.getPolicy(ctx, *fileSystem.FileSystemId)
+		if err != nil {
+			ctx.WithError(err).WithField(
+				"filesystemid", *fileSystem.FileSystemId).Warn(
+				"failed to retrieve EFS file system policy")
+		} else if resourcePolicy != "" {
+			if volume.Fields == nil {
+				volume.Fields = map[string]string{}
+			}
+			volume.Fields[efs.VolumeFieldPolicyHash] =
+				policyHash(resourcePolicy)
+		}
+
 		var atts []*types.VolumeAttachment
 
 		if opts.Attachments.Requested() {
@@ -228,6 +308,37 @@ func (d *driver) VolumeCreate(
 	name string,
 	opts *types.VolumeCreateOpts) (*types.Volume, error) {
 
+	var (
+		policy         = d.config.GetString(efs.ConfigLifecyclePolicy)
+		resourcePolicy interface{}
+		enforceTLS     = d.config.GetBool(efs.ConfigEnforceTLS)
+	)
+	if v := d.config.GetString(efs.ConfigPolicy); v != "" {
+		resourcePolicy = v
+	}
+	if opts.Opts != nil {
+		if reqOpts := opts.Opts.GetStore("opts"); reqOpts != nil {
+			if reqOpts.IsSet(efs.VolumeFieldLifecyclePolicy) {
+				policy = fmt.Sprintf(
+					"%v", reqOpts.Get(efs.VolumeFieldLifecyclePolicy))
+			}
+			if reqOpts.IsSet(efs.VolumeOptPolicy) {
+				resourcePolicy = reqOpts.Get(efs.VolumeOptPolicy)
+			}
+			if reqOpts.IsSet(efs.VolumeOptEnforceTLS) {
+				enforceTLS = reqOpts.GetBool(efs.VolumeOptEnforceTLS)
+			}
+		}
+	}
+	policy, err := parseLifecyclePolicy(policy)
+	if err != nil {
+		return nil, utils.NewBadRequestError("invalid lifecycle policy", err)
+	}
+	resourcePolicyDoc, err := volumePolicy(resourcePolicy, enforceTLS)
+	if err != nil {
+		return nil, utils.NewBadRequestError("invalid policy", err)
+	}
+
 	// Token is limited to 64 ASCII characters so just create MD5 hash from full
 	// tag/name identifier
 	creationToken := fmt.Sprintf("%x", md5.Sum([]byte(d.getFullVolumeName(name))))
@@ -238,13 +349,13 @@ func (d *driver) VolumeCreate(
 	if opts.Type != nil && strings.ToLower(*opts.Type) == "maxio" {
 		request.PerformanceMode = aws.String(awsefs.PerformanceModeMaxIo)
 	}
-	fileSystem, err := d.efsClient().CreateFileSystem(request)
+	fileSystem, err := d.efsClient(ctx).CreateFileSystem(request)
 
 	if err != nil {
-		return nil, err
+		return nil, translateError(err, name)
 	}
 
-	_, err = d.efsClient().CreateTags(&awsefs.CreateTagsInput{
+	_, err = d.efsClient(ctx).CreateTags(&awsefs.CreateTagsInput{
 		FileSystemId: fileSystem.FileSystemId,
 		Tags: []*awsefs.Tag{
 			{
@@ -257,22 +368,14 @@ func (d *driver) VolumeCreate(
 	if err != nil {
 		// To not leak the EFS instances remove the filesystem that couldn't
 		// be tagged with correct name before returning error response.
-		_, deleteErr := d.efsClient().DeleteFileSystem(
-			&awsefs.DeleteFileSystemInput{
-				FileSystemId: fileSystem.FileSystemId,
-			})
-		if deleteErr != nil {
-			ctx.WithFields(log.Fields{
-				"filesystemid": *fileSystem.FileSystemId,
-			}).Error("failed to delete EFS")
-		}
-
-		return nil, err
+		d.deleteFileSystem(ctx, *fileSystem.FileSystemId)
+		return nil, translateError(err, name)
 	}
 
 	// Wait until FS is in "available" state
 	for {
-		state, err := d.getFileSystemLifeCycleState(*fileSystem.FileSystemId)
+		state, err := d.getFileSystemLifeCycleState(
+			ctx, *fileSystem.FileSystemId)
 		if err == nil {
 			if state != awsefs.LifeCycleStateCreating {
 				break
@@ -288,13 +391,66 @@ func (d *driver) VolumeCreate(
 			}).Error("failed to retrieve EFS state")
 		}
 		// Wait for 2 seconds
-		<-time.After(2 * time.Second)
+		select {
+		case <-time.After(2 * time.Second):
+		case <-ctx.Done():
+			d.deleteFileSystem(ctx, *fileSystem.FileSystemId)
+			return nil, translateError(ctx.Err(), name)
+		}
+	}
+
+	if policy != "" {
+		if err := d.putLifecyclePolicy(
+			ctx, *fileSystem.FileSystemId, policy); err != nil {
+			d.deleteFileSystem(ctx, *fileSystem.FileSystemId)
+			return nil, err
+		}
+	}
+
+	if resourcePolicyDoc != "" {
+		if err := d.putPolicy(
+			ctx, *fileSystem.FileSystemId,
+			aws.StringValue(fileSystem.OwnerId),
+			resourcePolicyDoc); err != nil {
+			d.deleteFileSystem(ctx, *fileSystem.FileSystemId)
+			return nil, err
+		}
 	}
 
 	return d.VolumeInspect(ctx, *fileSystem.FileSystemId,
 		&types.VolumeInspectOpts{Attachments: 0})
 }
 
+// deleteFileSystem deletes a file system that VolumeCreate created but could
+// not finish configuring so that the file system is not leaked. The file
+// system is deleted even if the context is done, since the task's timeout
+// elapsing is one reason the file system is not configured, and a file system
+// that is still being created is deleted once it is available.
+func (d *driver) deleteFileSystem(ctx types.Context, fileSystemID string) {
+	client := d.efsClient(context.Background())
+	deadline := time.Now().Add(deleteFileSystemTimeout)
+	for {
+		_, err := client.DeleteFileSystem(
+			&awsefs.DeleteFileSystemInput{
+				FileSystemId: aws.String(fileSystemID),
+			})
+		if err == nil {
+			return
+		}
+		if awsErr, ok := err.(awserr.Error); ok &&
+			awsErr.Code() == "IncorrectFileSystemLifeCycleState" &&
+			time.Now().Before(deadline) {
+			time.Sleep(2 * time.Second)
+			continue
+		}
+		ctx.WithFields(log.Fields{
+			"error":        err,
+			"filesystemid": fileSystemID,
+		}).Error("failed to delete EFS")
+		return
+	}
+}
+
 // VolumeRemove removes a volume.
 func (d *driver) VolumeRemove(
 	ctx types.Context,
@@ -302,22 +458,22 @@ func (d *driver) VolumeRemove(
 	opts types.Store) error {
 
 	// Remove MountTarget(s)
-	resp, err := d.efsClient().DescribeMountTargets(
+	resp, err := d.efsClient(ctx).DescribeMountTargets(
 		&awsefs.DescribeMountTargetsInput{
 			FileSystemId: aws.String(volumeID),
 		})
 	if err != nil {
-		return err
+		return translateError(err, volumeID)
 	}
 
 	for _, mountTarget := range resp.MountTargets {
-		_, err = d.efsClient().DeleteMountTarget(
+		_, err = d.efsClient(ctx).DeleteMountTarget(
 			&awsefs.DeleteMountTargetInput{
 				MountTargetId: aws.String(*mountTarget.MountTargetId),
 			})
 
 		if err != nil {
-			return err
+			return translateError(err, volumeID)
 		}
 	}
 
@@ -325,12 +481,12 @@ func (d *driver) VolumeRemove(
 	// just in "deleting" life cycle state). Here code will wait until all
 	// mountpoints are deleted.
 	for {
-		resp, err := d.efsClient().DescribeMountTargets(
+		resp, err := d.efsClient(ctx).DescribeMountTargets(
 			&awsefs.DescribeMountTargetsInput{
 				FileSystemId: aws.String(volumeID),
 			})
 		if err != nil {
-			return err
+			return translateError(err, volumeID)
 		}
 
 		if len(resp.MountTargets) == 0 {
@@ -342,16 +498,20 @@ func (d *driver) VolumeRemove(
 			}).Info("waiting for MountTargets deletion")
 		}
 
-		<-time.After(2 * time.Second)
+		select {
+		case <-time.After(2 * time.Second):
+		case <-ctx.Done():
+			return translateError(ctx.Err(), volumeID)
+		}
 	}
 
 	// Remove FileSystem
-	_, err = d.efsClient().DeleteFileSystem(
+	_, err = d.efsClient(ctx).DeleteFileSystem(
 		&awsefs.DeleteFileSystemInput{
 			FileSystemId: aws.String(volumeID),
 		})
 	if err != nil {
-		return err
+		return translateError(err, volumeID)
 	}
 
 	for {
@@ -359,23 +519,23 @@ func (d *driver) VolumeRemove(
 			"filesystemid": volumeID,
 		}).Info("waiting for FileSystem deletion")
 
-		_, err := d.efsClient().DescribeFileSystems(
+		_, err := d.efsClient(ctx).DescribeFileSystems(
 			&awsefs.DescribeFileSystemsInput{
 				FileSystemId: aws.String(volumeID),
 			})
 		if err != nil {
-			if awsErr, ok := err.(awserr.Error); ok {
-				if awsErr.Code() == "FileSystemNotFound" {
-					break
-				} else {
-					return err
-				}
-			} else {
-				return err
+			err = translateError(err, volumeID)
+			if types.IsErrorCode(err, types.ErrorCodeNotFound) {
+				break
 			}
+			return err
 		}
 
-		<-time.After(2 * time.Second)
+		select {
+		case <-time.After(2 * time.Second):
+		case <-ctx.Done():
+			return translateError(ctx.Err(), volumeID)
+		}
 	}
 
 	return nil
@@ -407,8 +567,13 @@ func (d *driver) VolumeAttach(
 		}
 	}
 
-	// No mount targets were found
-	if ma == nil {
+	// the token is the address of the instance's mount target, for which
+	// clients wait until the mount target accepts NFS connections since the
+	// mount target is not available as soon as it is created
+	var token string
+	if ma != nil {
+		token = mountTargetToken(ma.Fields["ipAddress"])
+	} else {
 		request := &awsefs.CreateMountTargetInput{
 			FileSystemId: aws.String(vol.ID),
 			SubnetId:     aws.String(inst.InstanceID.ID),
@@ -416,16 +581,24 @@ func (d *driver) VolumeAttach(
 		if len(d.securityGroups()) > 0 {
 			request.SecurityGroups = aws.StringSlice(d.securityGroups())
 		}
-		// TODO(mhrabovcin): Should we block here until MountTarget is in "available"
-		// LifeCycleState? Otherwise mount could fail until creation is completed.
-		_, err = d.efsClient().CreateMountTarget(request)
+		mt, err := d.efsClient(ctx).CreateMountTarget(request)
 		// Failed to create mount target
 		if err != nil {
-			return nil, "", err
+			return nil, "", translateError(err, vol.ID)
 		}
+		token = mountTargetToken(aws.StringValue(mt.IpAddress))
 	}
 
-	return vol, "", err
+	return vol, token, nil
+}
+
+// mountTargetToken returns the attach token of the mount target with the
+// provided IP address, which is formatted like the mount target's device.
+func mountTargetToken(ipAddress string) string {
+	if ipAddress == "" {
+		return ""
+	}
+	return ipAddress + ":/"
 }
 
 // VolumeDetach detaches a volume.
@@ -456,6 +629,18 @@ func (d *driver) VolumeCopy(
 	return nil, types.ErrNotImplemented
 }
 
+// VolumeExpand returns the volume unchanged since EFS file systems grow and
+// shrink automatically as files are added and removed.
+func (d *driver) VolumeExpand(
+	ctx types.Context,
+	volumeID string,
+	newSize int64,
+	opts types.Store) (*types.Volume, error) {
+
+	return d.VolumeInspect(ctx, volumeID,
+		&types.VolumeInspectOpts{Attachments: types.VolumeAttachmentsTrue})
+}
+
 // VolumeSnapshot snapshots a volume (not implemented)
 func (d *driver) VolumeSnapshot(
 	ctx types.Context,
@@ -494,19 +679,21 @@ func (d *driver) SnapshotRemove(
 
 // Retrieve all filesystems with tags from AWS API. This is very expensive
 // operation as it issues AWS SDK call per filesystem to retrieve tags.
-func (d *driver) getAllFileSystems() (filesystems []*awsefs.FileSystemDescription, err error) {
-	resp, err := d.efsClient().DescribeFileSystems(&awsefs.DescribeFileSystemsInput{})
+func (d *driver) getAllFileSystems(
+	ctx types.Context) (
+	filesystems []*awsefs.FileSystemDescription, err error) {
+	resp, err := d.efsClient(ctx).DescribeFileSystems(&awsefs.DescribeFileSystemsInput{})
 	if err != nil {
-		return nil, err
+		return nil, translateError(err, "")
 	}
 	filesystems = append(filesystems, resp.FileSystems...)
 
 	for resp.NextMarker != nil {
-		resp, err = d.efsClient().DescribeFileSystems(&awsefs.DescribeFileSystemsInput{
+		resp, err = d.efsClient(ctx).DescribeFileSystems(&awsefs.DescribeFileSystemsInput{
 			Marker: resp.NextMarker,
 		})
 		if err != nil {
-			return nil, err
+			return nil, translateError(err, "")
 		}
 		filesystems = append(filesystems, resp.FileSystems...)
 	}
@@ -514,18 +701,49 @@ func (d *driver) getAllFileSystems() (filesystems []*awsefs.FileSystemDescriptio
 	return filesystems, nil
 }
 
-func (d *driver) getFileSystemLifeCycleState(fileSystemID string) (string, error) {
-	resp, err := d.efsClient().DescribeFileSystems(&awsefs.DescribeFileSystemsInput{
+func (d *driver) getFileSystemLifeCycleState(
+	ctx types.Context, fileSystemID string) (string, error) {
+	resp, err := d.efsClient(ctx).DescribeFileSystems(&awsefs.DescribeFileSystemsInput{
 		FileSystemId: aws.String(fileSystemID),
 	})
 	if err != nil {
-		return "", err
+		return "", translateError(err, fileSystemID)
 	}
 
 	fileSystem := resp.FileSystems[0]
 	return *fileSystem.LifeCycleState, nil
 }
 
+// efsErrorCodes maps the codes of the errors reported by EFS to the codes of
+// the errors returned by the driver.
+var efsErrorCodes = map[string]types.ErrorCode{
+	"FileSystemNotFound":                types.ErrorCodeNotFound,
+	"MountTargetNotFound":               types.ErrorCodeNotFound,
+	"FileSystemAlreadyExists":           types.ErrorCodeAlreadyExists,
+	"FileSystemInUse":                   types.ErrorCodeBusy,
+	"IncorrectFileSystemLifeCycleState": types.ErrorCodeBusy,
+	"MountTargetConflict":               types.ErrorCodeBusy,
+	"ThrottlingException":               types.ErrorCodeBusy,
+	"FileSystemLimitExceeded":           types.ErrorCodeQuotaExceeded,
+	"NetworkInterfaceLimitExceeded":     types.ErrorCodeQuotaExceeded,
+	"NoFreeAddressesInSubnet":           types.ErrorCodeQuotaExceeded,
+	"AccessDeniedException":             types.ErrorCodeAuthFailed,
+	"UnrecognizedClientException":       types.ErrorCodeAuthFailed,
+	"InvalidSignatureException":         types.ErrorCodeAuthFailed,
+	"RequestTimeout":                    types.ErrorCodeTimeout,
+}
+
+// translateError returns an error with the code that corresponds to the code
+// of an EFS error. Errors without a corresponding code are returned as is.
+func translateError(err error, resourceID string) error {
+	if awsErr, ok := err.(awserr.Error); ok {
+		if code, ok := efsErrorCodes[awsErr.Code()]; ok {
+			return utils.NewErrorCodeError(code, resourceID, err)
+		}
+	}
+	return err
+}
+
 func (d *driver) getPrintableName(name string) string {
 	return strings.TrimPrefix(name, d.tag()+tagDelimiter)
 }
@@ -540,24 +758,44 @@ func (d *driver) getVolumeAttachments(ctx types.Context, volumeID string) (
 	if volumeID == "" {
 		return nil, goof.New("missing volume ID")
 	}
-	resp, err := d.efsClient().DescribeMountTargets(
+	resp, err := d.efsClient(ctx).DescribeMountTargets(
 		&awsefs.DescribeMountTargetsInput{
 			FileSystemId: aws.String(volumeID),
 		})
 	if err != nil {
-		return nil, err
+		return nil, translateError(err, volumeID)
 	}
 
 	ld, ldOK := context.LocalDevices(ctx)
 
+	// Each mount target serves a single subnet, and so a single availability
+	// zone, which is why the subnet ID is the ID of the attachment's
+	// instance. Only the IP addresses of available mount targets are
+	// reported as devices so that clients do not attempt to mount the
+	// targets that are still being created or are being deleted.
 	var atts []*types.VolumeAttachment
 	for _, mountTarget := range resp.MountTargets {
+		state := aws.StringValue(mountTarget.LifeCycleState)
+		if state == awsefs.LifeCycleStateDeleted {
+			continue
+		}
+
 		var dev string
 		var status string
-		if ldOK {
-			// TODO(kasisnu): Check lifecycle state and build the path better
+		if state != awsefs.LifeCycleStateAvailable {
+			status = state
+		} else if ldOK {
 			dev = *mountTarget.IpAddress + ":" + "/"
-			if _, ok := ld.DeviceMap[dev]; ok {
+			_, mounted := ld.DeviceMap[dev]
+			if d.tls() {
+				// a file system mounted by the EFS mount helper appears
+				// in the mount table with the address of a local TLS
+				// proxy, so it is only reported as mounted if the client
+				// fell back to mounting the mount target without TLS
+				dev = efs.DevicePrefix + *mountTarget.IpAddress + "/" +
+					*mountTarget.FileSystemId
+			}
+			if mounted {
 				status = "Exported and Mounted"
 			} else {
 				status = "Exported and Unmounted"
@@ -570,6 +808,11 @@ func (d *driver) getVolumeAttachments(ctx types.Context, volumeID string) (
 			InstanceID: &types.InstanceID{ID: *mountTarget.SubnetId, Driver: d.Name()},
 			DeviceName: dev,
 			Status:     status,
+			Fields: map[string]string{
+				"lifeCycleState": state,
+				"mountTargetID":  aws.StringValue(mountTarget.MountTargetId),
+				"ipAddress":      aws.StringValue(mountTarget.IpAddress),
+			},
 		}
 		atts = append(atts, attachmentSD)
 	}
@@ -577,7 +820,9 @@ func (d *driver) getVolumeAttachments(ctx types.Context, volumeID string) (
 	return atts, nil
 }
 
-func (d *driver) efsClient() *awsefs.EFS {
+// efsClient returns an EFS client whose requests are cancelled along with the
+// context.
+func (d *driver) efsClient(ctx types.Context) *awsefs.EFS {
 	config := aws.NewConfig().
 		WithCredentials(d.awsCreds).
 		WithRegion(d.region())
@@ -588,7 +833,8 @@ func (d *driver) efsClient() *awsefs.EFS {
 			WithLogLevel(aws.LogDebug)
 	}
 
-	return awsefs.New(session.New(), config)
+	svc := awsefs.New(d.session, config)
+	return &awsefs.EFS{Client: awsutils.WithContext(ctx, svc.Client)}
 }
 
 func (d *driver) accessKey() string {
@@ -607,6 +853,10 @@ func (d *driver) region() string {
 	return d.config.GetString("efs.region")
 }
 
+func (d *driver) tls() bool {
+	return d.config.GetBool(efs.ConfigTLS)
+}
+
 func (d *driver) tag() string {
 	return d.config.GetString("efs.tag")
 }
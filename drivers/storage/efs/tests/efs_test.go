@@ -17,6 +17,7 @@ import (
 	apitests "github.com/codedellemc/libstorage/api/tests"
 	"github.com/codedellemc/libstorage/api/types"
 	"github.com/codedellemc/libstorage/api/utils"
+	"github.com/codedellemc/libstorage/drivers/storage/awsutils"
 
 	// load the driver
 	"github.com/codedellemc/libstorage/drivers/storage/efs"
@@ -32,10 +33,25 @@ efs:
 `)
 )
 
+// cassette is the file to which the requests the driver sends to AWS are
+// recorded when TEST_RECORD_EFS is true. If the file exists the tests replay
+// the recorded responses instead of sending the requests to AWS, which allows
+// the tests that do not require an EC2 instance to be run in CI.
+const cassette = "testdata/efs.json"
+
+// replaying is a flag indicating whether the tests replay the responses
+// recorded to the cassette.
+var replaying bool
+
 func skipTests() bool {
-	travis, _ := strconv.ParseBool(os.Getenv("TRAVIS"))
 	noTest, _ := strconv.ParseBool(os.Getenv("TEST_SKIP_EFS"))
-	return travis || noTest
+	return noTest
+}
+
+// skipReplay returns a flag indicating whether the tests that require an EC2
+// instance are skipped.
+func skipReplay() bool {
+	return skipTests() || replaying
 }
 
 var volumeName string
@@ -74,12 +90,48 @@ func init() {
 
 func TestMain(m *testing.M) {
 	server.CloseOnAbort()
+
+	var stop func() error
+	record, _ := strconv.ParseBool(os.Getenv("TEST_RECORD_EFS"))
+	travis, _ := strconv.ParseBool(os.Getenv("TRAVIS"))
+	_, err := os.Stat(cassette)
+	if !record && os.IsNotExist(err) && travis && !skipTests() {
+		// CI has no AWS environment, so the tests can only be run there by
+		// replaying the cassette and a missing cassette is an error
+		log.WithField("cassette", cassette).Fatal(
+			"missing cassette; record it with TEST_RECORD_EFS=true")
+	}
+	if record || err == nil {
+		var err error
+		if stop, err = awsutils.UseCassette(cassette, record); err != nil {
+			log.Fatal(err)
+		}
+		replaying = !record
+
+		// the requests must be the same when they are recorded and
+		// replayed, so the volumes' names may not be random
+		volumeName = "lstest1"
+		volumeName2 = "lstest2"
+		if replaying {
+			configYAML = append(configYAML, []byte(`
+  accessKey: replay
+  secretKey: replay
+`)...)
+		}
+	}
+
 	ec := m.Run()
+	if stop != nil {
+		if err := stop(); err != nil {
+			log.Error(err)
+			ec = 1
+		}
+	}
 	os.Exit(ec)
 }
 
 func TestInstanceID(t *testing.T) {
-	if skipTests() {
+	if skipReplay() {
 		t.SkipNow()
 	}
 
@@ -295,7 +347,7 @@ func volumeDetach(
 }
 
 func TestVolumeAttach(t *testing.T) {
-	if skipTests() {
+	if skipReplay() {
 		t.SkipNow()
 	}
 	var vol *types.Volume
@@ -49,10 +49,16 @@ EC2_IP_ADDRESS=$(aws cloudformation describe-stacks \
 scp $TEST_BINARY $CF_EC2_USER@$EC2_IP_ADDRESS:efs.test
 
 # Run tests
-CMD="./efs.test -test.coverprofile ${COVERPROFILE_NAME}"
+CMD="TEST_RECORD_EFS=${TEST_RECORD_EFS:-false} ./efs.test -test.coverprofile ${COVERPROFILE_NAME}"
 ssh $CF_EC2_USER@$EC2_IP_ADDRESS $CMD
 
 # Copy test coverage results
 scp $CF_EC2_USER@$EC2_IP_ADDRESS:${COVERPROFILE_NAME} $(dirname $0)
 
+# Copy the recorded requests
+if [ "${TEST_RECORD_EFS}" = "true" ]; then
+  mkdir -p $(dirname $0)/testdata
+  scp $CF_EC2_USER@$EC2_IP_ADDRESS:testdata/efs.json $(dirname $0)/testdata
+fi
+
 echo "Tests passed and coverge results are available at $(dirname $0)/${COVERPROFILE_NAME}"
\ No newline at end of file
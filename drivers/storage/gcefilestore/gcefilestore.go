@@ -0,0 +1,52 @@
+package gcefilestore
+
+import (
+	gofigCore "github.com/akutz/gofig"
+	gofig "github.com/akutz/gofig/types"
+)
+
+const (
+	// Name is the provider's name.
+	Name = "gcefilestore"
+
+	// ConfigProject is a config key.
+	ConfigProject = Name + ".project"
+
+	// ConfigZone is a config key.
+	ConfigZone = Name + ".zone"
+
+	// ConfigNetwork is a config key.
+	ConfigNetwork = Name + ".network"
+
+	// ConfigTier is a config key.
+	ConfigTier = Name + ".tier"
+
+	// ConfigShareName is a config key.
+	ConfigShareName = Name + ".shareName"
+
+	// ConfigCredentialsFile is a config key.
+	ConfigCredentialsFile = Name + ".credentialsFile"
+
+	// ConfigOperationTimeout is a config key.
+	ConfigOperationTimeout = Name + ".operationTimeout"
+)
+
+func init() {
+	r := gofigCore.NewRegistration("GCE Filestore")
+	r.Key(gofig.String, "", "",
+		"The project in which instances are managed", ConfigProject)
+	r.Key(gofig.String, "", "",
+		"The zone in which instances are created", ConfigZone)
+	r.Key(gofig.String, "", "default",
+		"The VPC network to which instances are connected", ConfigNetwork)
+	r.Key(gofig.String, "", "STANDARD",
+		"The service tier of created instances", ConfigTier)
+	r.Key(gofig.String, "", "data",
+		"The name of the file share of created instances", ConfigShareName)
+	r.Key(gofig.String, "", "",
+		"The path to a service account's JSON key", ConfigCredentialsFile)
+	r.Key(gofig.String, "", "10m",
+		"The maximum duration of an instance operation",
+		ConfigOperationTimeout)
+	gofigCore.Register(r)
+}
@@ -0,0 +1,700 @@
+package storage
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	gofig "github.com/akutz/gofig/types"
+	"github.com/akutz/goof"
+	"golang.org/x/oauth2/google"
+	file "google.golang.org/api/file/v1"
+	"google.golang.org/api/googleapi"
+
+	"github.com/codedellemc/libstorage/api/context"
+	"github.com/codedellemc/libstorage/api/registry"
+	"github.com/codedellemc/libstorage/api/types"
+	"github.com/codedellemc/libstorage/api/utils"
+	"github.com/codedellemc/libstorage/drivers/storage/gcefilestore"
+	gceUtils "github.com/codedellemc/libstorage/drivers/storage/gcefilestore/utils"
+)
+
+const (
+	// minCapacityGB is the minimum capacity of a file share.
+	minCapacityGB = int64(1024)
+
+	// attachLabelPrefix is the prefix of the keys of the instance labels in
+	// which the names of the GCE instances to which the volume is attached
+	// are recorded.
+	attachLabelPrefix = "libstorage-attached-"
+
+	attachedStatus = "attached"
+)
+
+// instanceNameRX matches a valid Filestore instance name.
+var instanceNameRX = regexp.MustCompile(`^[a-z](?:[-a-z0-9]{0,61}[a-z0-9])?$`)
+
+// driver is a storage driver that manages volumes as Google Cloud Filestore
+// instances, each of which provides a single NFS file share. The clients
+// mount the file shares with the linux OS driver's NFS mount handler.
+type driver struct {
+	sync.Mutex
+	config    gofig.Config
+	svc       *file.Service
+	projectID string
+	zoneName  string
+}
+
+func init() {
+	registry.RegisterStorageDriver(gcefilestore.Name, newDriver)
+}
+
+func newDriver() types.StorageDriver {
+	return &driver{}
+}
+
+// Name returns the name of the driver
+func (d *driver) Name() string {
+	return gcefilestore.Name
+}
+
+// Init initializes the driver.
+func (d *driver) Init(ctx types.Context, config gofig.Config) error {
+	d.config = config
+	d.projectID = d.config.GetString(gcefilestore.ConfigProject)
+	d.zoneName = d.config.GetString(gcefilestore.ConfigZone)
+
+	fields := log.Fields{
+		"project":         d.project(),
+		"zone":            d.zone(),
+		"network":         d.network(),
+		"tier":            d.tier(),
+		"shareName":       d.shareName(),
+		"credentialsFile": d.credentialsFile(),
+		"operationTimeout": d.config.GetString(
+			gcefilestore.ConfigOperationTimeout),
+	}
+
+	if d.project() == "" {
+		v, err := gceUtils.Metadata("project/project-id")
+		if err != nil {
+			return goof.WithFieldsE(fields,
+				"gcefilestore.project is required outside of GCE", err)
+		}
+		d.projectID = v
+		fields["project"] = v
+	}
+
+	if d.zone() == "" {
+		v, err := gceUtils.Metadata("instance/zone")
+		if err != nil {
+			return goof.WithFieldsE(fields,
+				"gcefilestore.zone is required outside of GCE", err)
+		}
+		// the metadata server returns projects/<number>/zones/<zone>
+		d.zoneName = shortName(v)
+		fields["zone"] = d.zoneName
+	}
+
+	if _, err := d.operationTimeout(); err != nil {
+		return goof.WithFieldsE(fields, "invalid operation timeout", err)
+	}
+
+	client, err := d.httpClient(ctx)
+	if err != nil {
+		return goof.WithFieldsE(fields, "error creating client", err)
+	}
+
+	if d.svc, err = file.New(client); err != nil {
+		return goof.WithFieldsE(fields, "error creating service", err)
+	}
+
+	ctx.WithFields(fields).Info("storage driver initialized")
+	return nil
+}
+
+func (d *driver) httpClient(ctx types.Context) (*http.Client, error) {
+	if d.credentialsFile() == "" {
+		return google.DefaultClient(ctx, file.CloudPlatformScope)
+	}
+	buf, err := ioutil.ReadFile(d.credentialsFile())
+	if err != nil {
+		return nil, err
+	}
+	jwt, err := google.JWTConfigFromJSON(buf, file.CloudPlatformScope)
+	if err != nil {
+		return nil, err
+	}
+	return jwt.Client(ctx), nil
+}
+
+// Type returns the type of storage a driver provides
+func (d *driver) Type(ctx types.Context) (types.StorageType, error) {
+	return types.NAS, nil
+}
+
+// Capabilities advertises NAS attach semantics and expansion. A file share
+// is at least 1 TiB and may grow but not shrink.
+func (d *driver) Capabilities(
+	ctx types.Context) (*types.StorageCapabilities, error) {
+	return &types.StorageCapabilities{
+		SupportsExpand:  true,
+		AttachSemantics: types.NAS,
+	}, nil
+}
+
+// NextDeviceInfo returns the information about the driver's next available
+// device workflow.
+func (d *driver) NextDeviceInfo(
+	ctx types.Context) (*types.NextDeviceInfo, error) {
+	return nil, nil
+}
+
+// InstanceInspect returns an instance.
+func (d *driver) InstanceInspect(
+	ctx types.Context,
+	opts types.Store) (*types.Instance, error) {
+
+	iid := context.MustInstanceID(ctx)
+	return &types.Instance{Name: iid.ID, InstanceID: iid}, nil
+}
+
+// Volumes returns the Filestore instances in all of the project's zones.
+func (d *driver) Volumes(
+	ctx types.Context,
+	opts *types.VolumesOpts) ([]*types.Volume, error) {
+
+	instances, err := d.instances(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	volumes := []*types.Volume{}
+	for _, i := range instances {
+		volumes = append(volumes, d.toTypesVolume(i, opts.Attachments))
+	}
+	return utils.SortVolumeByID(volumes), nil
+}
+
+func (d *driver) VolumeInspect(
+	ctx types.Context,
+	volumeID string,
+	opts *types.VolumeInspectOpts) (*types.Volume, error) {
+
+	i, err := d.getInstance(ctx, volumeID)
+	if err != nil {
+		return nil, err
+	}
+	return d.toTypesVolume(i, opts.Attachments), nil
+}
+
+// VolumeCreate provisions a new Filestore instance with a single file
+// share. The zone, network, tier, and share name may be specified with the
+// options of the same names, and the availability zone and type of the
+// request take precedence over the zone and tier options.
+func (d *driver) VolumeCreate(ctx types.Context, volumeName string,
+	opts *types.VolumeCreateOpts) (*types.Volume, error) {
+
+	if !instanceNameRX.MatchString(volumeName) {
+		return nil, goof.WithField(
+			"volumeName", volumeName, "invalid volume name")
+	}
+
+	if _, err := d.getInstance(ctx, volumeName); err == nil {
+		return nil, utils.NewAlreadyExistsError(volumeName)
+	}
+
+	zone := d.optString(opts.Opts, "zone", d.zone())
+	if opts.AvailabilityZone != nil && *opts.AvailabilityZone != "" {
+		zone = *opts.AvailabilityZone
+	}
+	tier := d.optString(opts.Opts, "tier", d.tier())
+	if opts.Type != nil && *opts.Type != "" {
+		tier = *opts.Type
+	}
+
+	capacity := minCapacityGB
+	if opts.Size != nil && *opts.Size > capacity {
+		capacity = *opts.Size
+	}
+
+	instance := &file.Instance{
+		Tier: strings.ToUpper(tier),
+		FileShares: []*file.FileShareConfig{
+			{
+				Name:       d.optString(opts.Opts, "shareName", d.shareName()),
+				CapacityGb: capacity,
+			},
+		},
+		Networks: []*file.NetworkConfig{
+			{
+				Network: d.optString(opts.Opts, "network", d.network()),
+				Modes:   []string{"MODE_IPV4"},
+			},
+		},
+	}
+
+	fields := log.Fields{
+		"volumeName": volumeName,
+		"zone":       zone,
+		"tier":       instance.Tier,
+		"network":    instance.Networks[0].Network,
+		"shareName":  instance.FileShares[0].Name,
+		"capacityGb": capacity,
+	}
+	ctx.WithFields(fields).Info("creating filestore instance")
+
+	op, err := d.svc.Projects.Locations.Instances.Create(
+		d.location(zone), instance).InstanceId(volumeName).Do()
+	if err != nil {
+		return nil, goof.WithFieldsE(
+			fields, "error creating filestore instance", err)
+	}
+	if err := d.waitForOperation(ctx, op); err != nil {
+		return nil, goof.WithFieldsE(
+			fields, "error creating filestore instance", err)
+	}
+
+	return d.VolumeInspect(ctx, volumeName,
+		&types.VolumeInspectOpts{Attachments: 0})
+}
+
+// VolumeRemove deletes a Filestore instance along with its file share.
+func (d *driver) VolumeRemove(
+	ctx types.Context,
+	volumeID string,
+	opts types.Store) error {
+
+	i, err := d.getInstance(ctx, volumeID)
+	if err != nil {
+		return err
+	}
+
+	op, err := d.svc.Projects.Locations.Instances.Delete(i.Name).Do()
+	if err != nil {
+		return err
+	}
+	return d.waitForOperation(ctx, op)
+}
+
+// VolumeAttach records the GCE instance in a libstorage-attached-<n> label
+// of the Filestore instance. No token is returned; the client mounts the
+// file share over NFS with the <ip>:/<share> device of the attachment. A
+// forced attachment replaces the GCE instances already recorded for the
+// volume.
+func (d *driver) VolumeAttach(
+	ctx types.Context,
+	volumeID string,
+	opts *types.VolumeAttachOpts) (*types.Volume, string, error) {
+
+	d.Lock()
+	defer d.Unlock()
+
+	i, err := d.getInstance(ctx, volumeID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	iid := context.MustInstanceID(ctx)
+
+	names := attachedNames(i)
+	if opts.Force {
+		names = nil
+	}
+
+	attached := false
+	for _, n := range names {
+		if n == iid.ID {
+			attached = true
+			break
+		}
+	}
+	if !attached {
+		names = append(names, iid.ID)
+	}
+
+	if err := d.setAttachedNames(ctx, i, names); err != nil {
+		return nil, "", err
+	}
+
+	ctx.WithFields(log.Fields{
+		"volumeID": volumeID,
+		"device":   device(i),
+	}).Info("attached volume")
+
+	return d.toTypesVolume(i, types.VolumeAttachmentsTrue), "", nil
+}
+
+// VolumeDetach detaches a volume from the GCE instance, or from all GCE
+// instances if the detachment is forced.
+func (d *driver) VolumeDetach(
+	ctx types.Context,
+	volumeID string,
+	opts *types.VolumeDetachOpts) (*types.Volume, error) {
+
+	d.Lock()
+	defer d.Unlock()
+
+	i, err := d.getInstance(ctx, volumeID)
+	if err != nil {
+		return nil, err
+	}
+
+	iid := context.MustInstanceID(ctx)
+
+	var names []string
+	if !opts.Force {
+		for _, n := range attachedNames(i) {
+			if n != iid.ID {
+				names = append(names, n)
+			}
+		}
+	}
+
+	if err := d.setAttachedNames(ctx, i, names); err != nil {
+		return nil, err
+	}
+
+	ctx.WithField("volumeID", volumeID).Info("detached volume")
+	return d.toTypesVolume(i, types.VolumeAttachmentsTrue), nil
+}
+
+// VolumeExpand increases the capacity of an instance's file share. File
+// shares may not be shrunk.
+func (d *driver) VolumeExpand(
+	ctx types.Context,
+	volumeID string,
+	newSize int64,
+	opts types.Store) (*types.Volume, error) {
+
+	i, err := d.getInstance(ctx, volumeID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(i.FileShares) == 0 {
+		return nil, goof.WithField(
+			"volumeID", volumeID, "instance has no file share")
+	}
+
+	if newSize < i.FileShares[0].CapacityGb {
+		return nil, goof.WithFields(goof.Fields{
+			"volumeID": volumeID,
+			"size":     i.FileShares[0].CapacityGb,
+			"newSize":  newSize,
+		}, "cannot shrink volume")
+	}
+
+	i.FileShares[0].CapacityGb = newSize
+	op, err := d.svc.Projects.Locations.Instances.Patch(
+		i.Name, &file.Instance{FileShares: i.FileShares}).
+		UpdateMask("fileShares").Do()
+	if err != nil {
+		return nil, err
+	}
+	if err := d.waitForOperation(ctx, op); err != nil {
+		return nil, err
+	}
+
+	return d.VolumeInspect(ctx, volumeID,
+		&types.VolumeInspectOpts{Attachments: 0})
+}
+
+// VolumeCreateFromSnapshot (not implemented).
+func (d *driver) VolumeCreateFromSnapshot(
+	ctx types.Context,
+	snapshotID, volumeName string,
+	opts *types.VolumeCreateOpts) (*types.Volume, error) {
+	return nil, types.ErrNotImplemented
+}
+
+// VolumeCopy copies an existing volume (not implemented)
+func (d *driver) VolumeCopy(
+	ctx types.Context,
+	volumeID, volumeName string,
+	opts types.Store) (*types.Volume, error) {
+	return nil, types.ErrNotImplemented
+}
+
+// VolumeSnapshot snapshots a volume (not implemented)
+func (d *driver) VolumeSnapshot(
+	ctx types.Context,
+	volumeID, snapshotName string,
+	opts types.Store) (*types.Snapshot, error) {
+	return nil, types.ErrNotImplemented
+}
+
+func (d *driver) Snapshots(
+	ctx types.Context,
+	opts types.Store) ([]*types.Snapshot, error) {
+	return nil, types.ErrNotImplemented
+}
+
+func (d *driver) SnapshotInspect(
+	ctx types.Context,
+	snapshotID string,
+	opts types.Store) (*types.Snapshot, error) {
+	return nil, types.ErrNotImplemented
+}
+
+func (d *driver) SnapshotCopy(
+	ctx types.Context,
+	snapshotID, snapshotName, destinationID string,
+	opts types.Store) (*types.Snapshot, error) {
+	return nil, types.ErrNotImplemented
+}
+
+func (d *driver) SnapshotRemove(
+	ctx types.Context,
+	snapshotID string,
+	opts types.Store) error {
+	return types.ErrNotImplemented
+}
+
+// HealthCheck verifies that the project's instances can be listed.
+func (d *driver) HealthCheck(ctx types.Context) error {
+	_, err := d.instances(ctx)
+	return err
+}
+
+// instances returns the Filestore instances in all of the project's zones.
+func (d *driver) instances(ctx types.Context) ([]*file.Instance, error) {
+	var instances []*file.Instance
+	err := d.svc.Projects.Locations.Instances.List(d.location("-")).Pages(
+		ctx, func(res *file.ListInstancesResponse) error {
+			instances = append(instances, res.Instances...)
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+	return instances, nil
+}
+
+// getInstance returns the Filestore instance with the specified short name.
+// The instance is looked up in the configured zone first, and then in all
+// of the project's zones. An ErrNotFound error is returned if the instance
+// does not exist.
+func (d *driver) getInstance(
+	ctx types.Context, volumeID string) (*file.Instance, error) {
+
+	if !instanceNameRX.MatchString(volumeID) {
+		return nil, utils.NewNotFoundError(volumeID)
+	}
+
+	i, err := d.svc.Projects.Locations.Instances.Get(
+		fmt.Sprintf("%s/instances/%s", d.location(d.zone()), volumeID)).Do()
+	if err == nil {
+		return i, nil
+	}
+	if !isNotFound(err) {
+		return nil, err
+	}
+
+	instances, err := d.instances(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, i := range instances {
+		if shortName(i.Name) == volumeID {
+			return i, nil
+		}
+	}
+	return nil, utils.NewNotFoundError(volumeID)
+}
+
+// setAttachedNames records the names of the GCE instances to which the
+// volume is attached in the labels of the Filestore instance. The instance's
+// other labels are preserved.
+func (d *driver) setAttachedNames(
+	ctx types.Context, i *file.Instance, names []string) error {
+
+	labels := map[string]string{}
+	for k, v := range i.Labels {
+		if !strings.HasPrefix(k, attachLabelPrefix) {
+			labels[k] = v
+		}
+	}
+	for x, n := range names {
+		labels[fmt.Sprintf("%s%d", attachLabelPrefix, x)] = n
+	}
+	i.Labels = labels
+
+	op, err := d.svc.Projects.Locations.Instances.Patch(
+		i.Name, &file.Instance{
+			Labels:          labels,
+			ForceSendFields: []string{"Labels"},
+		}).UpdateMask("labels").Do()
+	if err != nil {
+		return err
+	}
+	return d.waitForOperation(ctx, op)
+}
+
+// attachedNames returns the names of the GCE instances to which the volume
+// is attached.
+func attachedNames(i *file.Instance) []string {
+	var keys []string
+	for k := range i.Labels {
+		if strings.HasPrefix(k, attachLabelPrefix) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	var names []string
+	for _, k := range keys {
+		names = append(names, i.Labels[k])
+	}
+	return names
+}
+
+// waitForOperation polls a long-running operation until it is done or the
+// operation timeout elapses.
+func (d *driver) waitForOperation(
+	ctx types.Context, op *file.Operation) error {
+
+	timeout, _ := d.operationTimeout()
+	deadline := time.Now().Add(timeout)
+
+	for !op.Done {
+		if time.Now().After(deadline) {
+			return goof.WithFields(goof.Fields{
+				"operation": op.Name,
+				"timeout":   timeout,
+			}, "operation timed out")
+		}
+
+		ctx.WithField("operation", op.Name).Debug("waiting for operation")
+		time.Sleep(5 * time.Second)
+
+		var err error
+		op, err = d.svc.Projects.Locations.Operations.Get(op.Name).Do()
+		if err != nil {
+			return err
+		}
+	}
+
+	if op.Error != nil {
+		return goof.WithFields(goof.Fields{
+			"operation": op.Name,
+			"code":      op.Error.Code,
+		}, op.Error.Message)
+	}
+	return nil
+}
+
+func (d *driver) toTypesVolume(
+	i *file.Instance,
+	attachments types.VolumeAttachmentsTypes) *types.Volume {
+
+	vol := &types.Volume{
+		ID:               shortName(i.Name),
+		Name:             shortName(i.Name),
+		Type:             i.Tier,
+		Status:           i.State,
+		AvailabilityZone: zoneOf(i.Name),
+	}
+	if len(i.FileShares) > 0 {
+		vol.Size = i.FileShares[0].CapacityGb
+	}
+
+	if !attachments.Requested() {
+		return vol
+	}
+
+	for _, n := range attachedNames(i) {
+		vol.Attachments = append(vol.Attachments, &types.VolumeAttachment{
+			VolumeID: vol.ID,
+			InstanceID: &types.InstanceID{
+				ID:     n,
+				Driver: gcefilestore.Name,
+			},
+			DeviceName: device(i),
+			Status:     attachedStatus,
+		})
+	}
+	return vol
+}
+
+// device returns the NFS path from which the instance's file share is
+// mounted.
+func device(i *file.Instance) string {
+	if len(i.FileShares) == 0 ||
+		len(i.Networks) == 0 ||
+		len(i.Networks[0].IpAddresses) == 0 {
+		return ""
+	}
+	return gceUtils.Device(
+		i.Networks[0].IpAddresses[0], i.FileShares[0].Name)
+}
+
+// shortName returns the last element of a resource name such as
+// projects/<project>/locations/<zone>/instances/<name>.
+func shortName(name string) string {
+	return name[strings.LastIndex(name, "/")+1:]
+}
+
+// zoneOf returns the zone of an instance from its resource name.
+func zoneOf(name string) string {
+	parts := strings.Split(name, "/")
+	if len(parts) < 4 {
+		return ""
+	}
+	return parts[3]
+}
+
+func isNotFound(err error) bool {
+	if gerr, ok := err.(*googleapi.Error); ok {
+		return gerr.Code == http.StatusNotFound
+	}
+	return false
+}
+
+func (d *driver) location(zone string) string {
+	return fmt.Sprintf("projects/%s/locations/%s", d.project(), zone)
+}
+
+func (d *driver) optString(opts types.Store, key, defaultVal string) string {
+	if opts != nil {
+		if v := opts.GetString(key); v != "" {
+			return v
+		}
+	}
+	return defaultVal
+}
+
+func (d *driver) operationTimeout() (time.Duration, error) {
+	return time.ParseDuration(
+		d.config.GetString(gcefilestore.ConfigOperationTimeout))
+}
+
+func (d *driver) project() string {
+	return d.projectID
+}
+
+func (d *driver) zone() string {
+	return d.zoneName
+}
+
+func (d *driver) network() string {
+	return d.config.GetString(gcefilestore.ConfigNetwork)
+}
+
+func (d *driver) tier() string {
+	return d.config.GetString(gcefilestore.ConfigTier)
+}
+
+func (d *driver) shareName() string {
+	return d.config.GetString(gcefilestore.ConfigShareName)
+}
+
+func (d *driver) credentialsFile() string {
+	return d.config.GetString(gcefilestore.ConfigCredentialsFile)
+}
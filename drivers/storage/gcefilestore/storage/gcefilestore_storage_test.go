@@ -0,0 +1,453 @@
+package storage
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	gofigCore "github.com/akutz/gofig"
+	"github.com/akutz/goof"
+	"github.com/stretchr/testify/assert"
+	file "google.golang.org/api/file/v1"
+	"google.golang.org/api/googleapi"
+
+	"github.com/codedellemc/libstorage/api/context"
+	"github.com/codedellemc/libstorage/api/types"
+	"github.com/codedellemc/libstorage/api/utils"
+	"github.com/codedellemc/libstorage/drivers/storage/gcefilestore"
+)
+
+const (
+	testZone     = "us-central1-a"
+	testLocation = "projects/p/locations/" + testZone
+)
+
+// fakeFilestore is a fake of the Filestore API that manages a project's
+// instances in memory.
+type fakeFilestore struct {
+	sync.Mutex
+	instances map[string]*file.Instance
+
+	// pending causes the operations the fake returns to never complete.
+	pending bool
+
+	// opErr is the error of the operations the fake returns.
+	opErr *file.Status
+
+	// requests are the methods and paths of the requests the fake served.
+	requests []string
+}
+
+func (f *fakeFilestore) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.Lock()
+	defer f.Unlock()
+
+	p := strings.TrimPrefix(r.URL.Path, "/v1/")
+	f.requests = append(f.requests, r.Method+" "+p)
+
+	switch {
+	case r.Method == "GET" && strings.HasSuffix(p, "/instances"):
+		parent := strings.TrimSuffix(p, "/instances")
+		res := &file.ListInstancesResponse{}
+		for name, i := range f.instances {
+			if strings.HasSuffix(parent, "/-") ||
+				strings.HasPrefix(name, parent+"/") {
+				res.Instances = append(res.Instances, i)
+			}
+		}
+		writeJSON(w, res)
+	case r.Method == "GET" && strings.Contains(p, "/operations/"):
+		writeJSON(w, &file.Operation{Name: p, Done: true})
+	case r.Method == "GET":
+		i, ok := f.instances[p]
+		if !ok {
+			writeNotFound(w)
+			return
+		}
+		writeJSON(w, i)
+	case r.Method == "POST":
+		i := &file.Instance{}
+		if err := json.NewDecoder(r.Body).Decode(i); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		i.Name = strings.TrimSuffix(p, "/instances") + "/instances/" +
+			r.URL.Query().Get("instanceId")
+		i.State = "READY"
+		i.Networks[0].IpAddresses = []string{"10.0.0.2"}
+		f.instances[i.Name] = i
+		f.writeOperation(w, i.Name)
+	case r.Method == "DELETE":
+		if _, ok := f.instances[p]; !ok {
+			writeNotFound(w)
+			return
+		}
+		delete(f.instances, p)
+		f.writeOperation(w, p)
+	case r.Method == "PATCH":
+		i, ok := f.instances[p]
+		if !ok {
+			writeNotFound(w)
+			return
+		}
+		patch := &file.Instance{}
+		if err := json.NewDecoder(r.Body).Decode(patch); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		switch r.URL.Query().Get("updateMask") {
+		case "labels":
+			i.Labels = patch.Labels
+		case "fileShares":
+			i.FileShares = patch.FileShares
+		}
+		f.writeOperation(w, p)
+	}
+}
+
+func (f *fakeFilestore) writeOperation(w http.ResponseWriter, name string) {
+	writeJSON(w, &file.Operation{
+		Name:  name[:strings.Index(name, "/instances/")] + "/operations/op1",
+		Done:  !f.pending,
+		Error: f.opErr,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeNotFound(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNotFound)
+	w.Write([]byte(`{"error":{"code":404,"message":"not found"}}`))
+}
+
+// add adds an instance with a 1 TiB file share to the fake.
+func (f *fakeFilestore) add(location, name string) *file.Instance {
+	f.Lock()
+	defer f.Unlock()
+	i := &file.Instance{
+		Name:  location + "/instances/" + name,
+		Tier:  "STANDARD",
+		State: "READY",
+		FileShares: []*file.FileShareConfig{
+			{Name: "data", CapacityGb: 1024},
+		},
+		Networks: []*file.NetworkConfig{
+			{Network: "default", IpAddresses: []string{"10.0.0.2"}},
+		},
+	}
+	f.instances[i.Name] = i
+	return i
+}
+
+// do calls the function while it holds the fake's lock. The tests access
+// the fake's state with do since the state is shared with the server's
+// goroutines.
+func (f *fakeFilestore) do(fn func()) {
+	f.Lock()
+	defer f.Unlock()
+	fn()
+}
+
+func newTestDriver(t *testing.T) (*driver, *fakeFilestore, func()) {
+	f := &fakeFilestore{instances: map[string]*file.Instance{}}
+	s := httptest.NewServer(f)
+
+	svc, err := file.New(http.DefaultClient)
+	if err != nil {
+		s.Close()
+		t.Fatal(err)
+	}
+	svc.BasePath = s.URL + "/"
+
+	config := gofigCore.New()
+	config.Set(gcefilestore.ConfigNetwork, "default")
+	config.Set(gcefilestore.ConfigTier, "STANDARD")
+	config.Set(gcefilestore.ConfigShareName, "data")
+	config.Set(gcefilestore.ConfigOperationTimeout, "10m")
+
+	d := &driver{
+		config:    config,
+		svc:       svc,
+		projectID: "p",
+		zoneName:  testZone,
+	}
+	return d, f, s.Close
+}
+
+func newTestContext(id string) types.Context {
+	return context.WithValue(
+		context.Background(),
+		context.InstanceIDKey,
+		&types.InstanceID{ID: id, Driver: gcefilestore.Name})
+}
+
+func TestInit(t *testing.T) {
+	tests := []struct {
+		operationTimeout string
+		credentialsFile  string
+	}{
+		{"10", ""},
+		{"", ""},
+		{"10m", "/nonexistent/key.json"},
+	}
+	for _, tt := range tests {
+		config := gofigCore.New()
+		config.Set(gcefilestore.ConfigProject, "p")
+		config.Set(gcefilestore.ConfigZone, testZone)
+		config.Set(gcefilestore.ConfigOperationTimeout, tt.operationTimeout)
+		config.Set(gcefilestore.ConfigCredentialsFile, tt.credentialsFile)
+		d := &driver{}
+		assert.Error(t, d.Init(context.Background(), config), "%+v", tt)
+		assert.Nil(t, d.svc)
+	}
+}
+
+func TestNames(t *testing.T) {
+	name := testLocation + "/instances/vol1"
+	assert.Equal(t, "vol1", shortName(name))
+	assert.Equal(t, testZone, zoneOf(name))
+	assert.Equal(t, "vol1", shortName("vol1"))
+	assert.Equal(t, "", zoneOf("vol1"))
+	assert.Equal(t, testZone, shortName("projects/1/zones/"+testZone))
+}
+
+func TestInstanceNameRX(t *testing.T) {
+	tests := []struct {
+		name  string
+		valid bool
+	}{
+		{"v", true},
+		{"vol1", true},
+		{"vol-1", true},
+		{"", false},
+		{"1vol", false},
+		{"vol-", false},
+		{"Vol1", false},
+		{"vol_1", false},
+		{"v" + strings.Repeat("a", 62), true},
+		{"v" + strings.Repeat("a", 63), false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.valid, instanceNameRX.MatchString(tt.name),
+			tt.name)
+	}
+}
+
+func TestDevice(t *testing.T) {
+	i := &file.Instance{
+		FileShares: []*file.FileShareConfig{{Name: "data"}},
+		Networks: []*file.NetworkConfig{
+			{IpAddresses: []string{"10.0.0.2", "10.0.0.3"}},
+		},
+	}
+	assert.Equal(t, "10.0.0.2:/data", device(i))
+
+	// an instance's address is unknown until it is ready
+	i.Networks[0].IpAddresses = nil
+	assert.Equal(t, "", device(i))
+	assert.Equal(t, "", device(&file.Instance{}))
+}
+
+func TestIsNotFound(t *testing.T) {
+	assert.True(t, isNotFound(&googleapi.Error{Code: http.StatusNotFound}))
+	assert.False(t, isNotFound(&googleapi.Error{Code: http.StatusForbidden}))
+	assert.False(t, isNotFound(goof.New("not found")))
+}
+
+func TestVolumeCreate(t *testing.T) {
+	d, f, cleanup := newTestDriver(t)
+	defer cleanup()
+	ctx := newTestContext("gce-1")
+
+	size := int64(100)
+	vol, err := d.VolumeCreate(
+		ctx, "vol1", &types.VolumeCreateOpts{Size: &size})
+	assert.NoError(t, err)
+	assert.Equal(t, "vol1", vol.ID)
+	assert.Equal(t, int64(1024), vol.Size)
+	assert.Equal(t, "STANDARD", vol.Type)
+	assert.Equal(t, testZone, vol.AvailabilityZone)
+
+	zone, tier := "us-east1-b", "premium"
+	size = 2560
+	opts := utils.NewStore()
+	opts.Set("network", "vpc1")
+	opts.Set("shareName", "share")
+	vol, err = d.VolumeCreate(ctx, "vol2", &types.VolumeCreateOpts{
+		AvailabilityZone: &zone,
+		Type:             &tier,
+		Size:             &size,
+		Opts:             opts,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "us-east1-b", vol.AvailabilityZone)
+	assert.Equal(t, "PREMIUM", vol.Type)
+	assert.Equal(t, int64(2560), vol.Size)
+	f.do(func() {
+		i := f.instances["projects/p/locations/us-east1-b/instances/vol2"]
+		if assert.NotNil(t, i) {
+			assert.Equal(t, "vpc1", i.Networks[0].Network)
+			assert.Equal(t, "share", i.FileShares[0].Name)
+		}
+	})
+
+	_, err = d.VolumeCreate(ctx, "vol2", &types.VolumeCreateOpts{})
+	assert.IsType(t, &types.ErrAlreadyExists{}, err)
+	_, err = d.VolumeCreate(ctx, "Vol3", &types.VolumeCreateOpts{})
+	assert.Error(t, err)
+	f.do(func() { assert.Len(t, f.instances, 2) })
+
+	vols, err := d.Volumes(ctx, &types.VolumesOpts{})
+	assert.NoError(t, err)
+	if assert.Len(t, vols, 2) {
+		assert.Equal(t, "vol1", vols[0].ID)
+		assert.Equal(t, "vol2", vols[1].ID)
+	}
+}
+
+func TestNotFound(t *testing.T) {
+	d, f, cleanup := newTestDriver(t)
+	defer cleanup()
+	ctx := newTestContext("gce-1")
+
+	_, err := d.VolumeInspect(ctx, "vol1", &types.VolumeInspectOpts{})
+	assert.IsType(t, &types.ErrNotFound{}, err)
+	assert.IsType(t, &types.ErrNotFound{}, d.VolumeRemove(ctx, "vol1", nil))
+	_, err = d.VolumeExpand(ctx, "vol1", 2048, nil)
+	assert.IsType(t, &types.ErrNotFound{}, err)
+	_, _, err = d.VolumeAttach(ctx, "vol1", &types.VolumeAttachOpts{})
+	assert.IsType(t, &types.ErrNotFound{}, err)
+
+	// invalid names are not found without a request
+	f.do(func() { f.requests = nil })
+	_, err = d.VolumeInspect(ctx, "Vol1", &types.VolumeInspectOpts{})
+	assert.IsType(t, &types.ErrNotFound{}, err)
+	f.do(func() { assert.Empty(t, f.requests) })
+}
+
+func TestGetInstanceOtherZone(t *testing.T) {
+	d, f, cleanup := newTestDriver(t)
+	defer cleanup()
+	ctx := newTestContext("gce-1")
+	f.add("projects/p/locations/us-east1-b", "vol1")
+
+	vol, err := d.VolumeInspect(ctx, "vol1", &types.VolumeInspectOpts{})
+	assert.NoError(t, err)
+	assert.Equal(t, "us-east1-b", vol.AvailabilityZone)
+	f.do(func() {
+		assert.Equal(t, []string{
+			"GET " + testLocation + "/instances/vol1",
+			"GET projects/p/locations/-/instances",
+		}, f.requests)
+	})
+
+	assert.NoError(t, d.VolumeRemove(ctx, "vol1", nil))
+	f.do(func() { assert.Empty(t, f.instances) })
+}
+
+func TestVolumeAttachDetach(t *testing.T) {
+	d, f, cleanup := newTestDriver(t)
+	defer cleanup()
+	ctx1, ctx2 := newTestContext("gce-1"), newTestContext("gce-2")
+	i := f.add(testLocation, "vol1")
+	f.do(func() { i.Labels = map[string]string{"owner": "ops"} })
+
+	vol, token, err := d.VolumeAttach(ctx1, "vol1", &types.VolumeAttachOpts{})
+	assert.NoError(t, err)
+	assert.Equal(t, "", token)
+	if assert.Len(t, vol.Attachments, 1) {
+		assert.Equal(t, "gce-1", vol.Attachments[0].InstanceID.ID)
+		assert.Equal(t, "10.0.0.2:/data", vol.Attachments[0].DeviceName)
+	}
+
+	// attaching the volume to the same instance again is a no-op
+	vol, _, err = d.VolumeAttach(ctx1, "vol1", &types.VolumeAttachOpts{})
+	assert.NoError(t, err)
+	assert.Len(t, vol.Attachments, 1)
+
+	vol, _, err = d.VolumeAttach(ctx2, "vol1", &types.VolumeAttachOpts{})
+	assert.NoError(t, err)
+	assert.Len(t, vol.Attachments, 2)
+	f.do(func() {
+		assert.Equal(t, map[string]string{
+			"owner":                 "ops",
+			"libstorage-attached-0": "gce-1",
+			"libstorage-attached-1": "gce-2",
+		}, i.Labels)
+	})
+
+	vol, err = d.VolumeDetach(ctx1, "vol1", &types.VolumeDetachOpts{})
+	assert.NoError(t, err)
+	if assert.Len(t, vol.Attachments, 1) {
+		assert.Equal(t, "gce-2", vol.Attachments[0].InstanceID.ID)
+	}
+	f.do(func() {
+		assert.Equal(t, map[string]string{
+			"owner":                 "ops",
+			"libstorage-attached-0": "gce-2",
+		}, i.Labels)
+	})
+
+	vol, err = d.VolumeDetach(
+		ctx1, "vol1", &types.VolumeDetachOpts{Force: true})
+	assert.NoError(t, err)
+	assert.Empty(t, vol.Attachments)
+	f.do(func() {
+		assert.Equal(t, map[string]string{"owner": "ops"}, i.Labels)
+	})
+}
+
+func TestVolumeExpand(t *testing.T) {
+	d, f, cleanup := newTestDriver(t)
+	defer cleanup()
+	ctx := newTestContext("gce-1")
+	i := f.add(testLocation, "vol1")
+
+	vol, err := d.VolumeExpand(ctx, "vol1", 2048, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2048), vol.Size)
+
+	_, err = d.VolumeExpand(ctx, "vol1", 1024, nil)
+	assert.Error(t, err)
+	f.do(func() {
+		assert.Equal(t, int64(2048), i.FileShares[0].CapacityGb)
+		i.FileShares = nil
+	})
+	_, err = d.VolumeExpand(ctx, "vol1", 4096, nil)
+	assert.Error(t, err)
+}
+
+func TestOperationError(t *testing.T) {
+	d, f, cleanup := newTestDriver(t)
+	defer cleanup()
+	ctx := newTestContext("gce-1")
+
+	f.do(func() {
+		f.opErr = &file.Status{Code: 8, Message: "quota exceeded"}
+	})
+	_, err := d.VolumeCreate(ctx, "vol1", &types.VolumeCreateOpts{})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "error creating filestore instance")
+	}
+}
+
+func TestOperationTimeout(t *testing.T) {
+	d, f, cleanup := newTestDriver(t)
+	defer cleanup()
+	ctx := newTestContext("gce-1")
+	f.add(testLocation, "vol1")
+
+	f.do(func() { f.pending = true })
+	d.config.Set(gcefilestore.ConfigOperationTimeout, "0s")
+	err := d.VolumeRemove(ctx, "vol1", nil)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "operation timed out")
+	}
+}
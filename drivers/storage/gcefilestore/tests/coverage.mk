@@ -0,0 +1,2 @@
+GCEFILESTORE_COVERPKG := $(ROOT_IMPORT_PATH)/drivers/storage/gcefilestore
+TEST_COVERPKG_./drivers/storage/gcefilestore/tests := $(GCEFILESTORE_COVERPKG),$(GCEFILESTORE_COVERPKG)/executor,$(GCEFILESTORE_COVERPKG)/storage
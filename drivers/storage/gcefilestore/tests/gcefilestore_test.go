@@ -0,0 +1,102 @@
+package gcefilestore
+
+import (
+	"os"
+	"strconv"
+	"testing"
+
+	gofig "github.com/akutz/gofig/types"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codedellemc/libstorage/api/server"
+	apitests "github.com/codedellemc/libstorage/api/tests"
+	"github.com/codedellemc/libstorage/api/types"
+
+	// load the driver
+	"github.com/codedellemc/libstorage/drivers/storage/gcefilestore"
+	gcex "github.com/codedellemc/libstorage/drivers/storage/gcefilestore/executor"
+	_ "github.com/codedellemc/libstorage/drivers/storage/gcefilestore/storage"
+	gceUtils "github.com/codedellemc/libstorage/drivers/storage/gcefilestore/utils"
+)
+
+// skipTests returns a flag indicating whether the tests are skipped. The
+// tests must be run on a GCE instance whose service account may manage
+// Filestore instances. Creating an instance takes several minutes.
+func skipTests() bool {
+	noTest, _ := strconv.ParseBool(os.Getenv("TEST_SKIP_GCEFILESTORE"))
+	if noTest {
+		return true
+	}
+	_, err := gceUtils.InstanceName()
+	return err != nil
+}
+
+var volumeName string
+
+func init() {
+	uuid, _ := types.NewUUID()
+	volumeName = "ls-test-" + uuid.String()[:8]
+}
+
+func TestMain(m *testing.M) {
+	server.CloseOnAbort()
+	ec := m.Run()
+	os.Exit(ec)
+}
+
+func TestInstanceID(t *testing.T) {
+	if skipTests() {
+		t.SkipNow()
+	}
+
+	iid, err := gcex.InstanceID()
+	assert.NoError(t, err)
+	if err != nil {
+		t.FailNow()
+	}
+
+	apitests.Run(
+		t, gcefilestore.Name, nil,
+		(&apitests.InstanceIDTest{
+			Driver:   gcefilestore.Name,
+			Expected: iid,
+		}).Test)
+}
+
+func TestVolumeCreateAttachRemove(t *testing.T) {
+	if skipTests() {
+		t.SkipNow()
+	}
+
+	tf := func(config gofig.Config, client types.Client, t *testing.T) {
+		vol, err := client.API().VolumeCreate(
+			nil, gcefilestore.Name,
+			&types.VolumeCreateRequest{Name: volumeName})
+		assert.NoError(t, err)
+		if err != nil {
+			t.FailNow()
+		}
+		defer client.API().VolumeRemove(nil, gcefilestore.Name, volumeName)
+
+		assert.Equal(t, volumeName, vol.ID)
+		assert.Equal(t, int64(1024), vol.Size)
+
+		vol, _, err = client.API().VolumeAttach(
+			nil, gcefilestore.Name, volumeName,
+			&types.VolumeAttachRequest{})
+		assert.NoError(t, err)
+		if err != nil {
+			t.FailNow()
+		}
+		if assert.Len(t, vol.Attachments, 1) {
+			assert.NotEmpty(t, vol.Attachments[0].DeviceName)
+		}
+
+		vol, err = client.API().VolumeDetach(
+			nil, gcefilestore.Name, volumeName,
+			&types.VolumeDetachRequest{})
+		assert.NoError(t, err)
+		assert.Len(t, vol.Attachments, 0)
+	}
+	apitests.Run(t, gcefilestore.Name, nil, tf)
+}
@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/akutz/goof"
+)
+
+const metadataURL = "http://metadata.google.internal/computeMetadata/v1/"
+
+// metadataClient is the client used to query the metadata server. Its short
+// timeout keeps hosts outside of GCE from waiting on an unreachable server.
+var metadataClient = &http.Client{Timeout: 2 * time.Second}
+
+// Metadata returns the value at the specified path of the GCE metadata
+// server, for example instance/name.
+func Metadata(path string) (string, error) {
+	req, err := http.NewRequest("GET", metadataURL+path, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	res, err := metadataClient.Do(req)
+	if err != nil {
+		return "", goof.WithFieldE(
+			"path", path, "error querying metadata server", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return "", goof.WithFields(goof.Fields{
+			"path":   path,
+			"status": res.StatusCode,
+		}, "error querying metadata server")
+	}
+
+	buf, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(buf)), nil
+}
+
+// InstanceName returns the name of the GCE instance on which the process is
+// running.
+func InstanceName() (string, error) {
+	return Metadata("instance/name")
+}
+
+// Device returns the NFS path from which the file share of an instance is
+// mounted.
+func Device(ipAddress, shareName string) string {
+	return fmt.Sprintf("%s:/%s", ipAddress, shareName)
+}
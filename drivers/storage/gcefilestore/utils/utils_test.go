@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDevice(t *testing.T) {
+	assert.Equal(t, "10.0.0.2:/data", Device("10.0.0.2", "data"))
+}
+
+// redirectTransport sends the metadata client's requests to a test server.
+type redirectTransport struct {
+	host string
+}
+
+func (t *redirectTransport) RoundTrip(
+	req *http.Request) (*http.Response, error) {
+
+	req.URL.Host = t.host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// withMetadataServer directs the metadata client to a server with the
+// specified handler for the duration of a test.
+func withMetadataServer(h http.HandlerFunc) func() {
+	s := httptest.NewServer(h)
+	u, _ := url.Parse(s.URL)
+	c := metadataClient
+	metadataClient = &http.Client{Transport: &redirectTransport{u.Host}}
+	return func() {
+		metadataClient = c
+		s.Close()
+	}
+}
+
+func TestMetadata(t *testing.T) {
+	var flavor, path string
+	defer withMetadataServer(func(w http.ResponseWriter, r *http.Request) {
+		flavor, path = r.Header.Get("Metadata-Flavor"), r.URL.Path
+		w.Write([]byte("instance-1\n"))
+	})()
+
+	name, err := InstanceName()
+	assert.NoError(t, err)
+	assert.Equal(t, "instance-1", name)
+	assert.Equal(t, "Google", flavor)
+	assert.Equal(t, "/computeMetadata/v1/instance/name", path)
+}
+
+func TestMetadataError(t *testing.T) {
+	defer withMetadataServer(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})()
+
+	_, err := Metadata("instance/zone")
+	assert.Error(t, err)
+}
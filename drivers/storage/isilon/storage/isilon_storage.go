@@ -14,6 +14,7 @@ import (
 	"github.com/codedellemc/libstorage/api/context"
 	"github.com/codedellemc/libstorage/api/registry"
 	"github.com/codedellemc/libstorage/api/types"
+	"github.com/codedellemc/libstorage/api/utils"
 	"github.com/codedellemc/libstorage/drivers/storage/isilon"
 )
 
@@ -160,6 +161,15 @@ func (d *driver) Type(ctx types.Context) (types.StorageType, error) {
 	return types.NAS, nil
 }
 
+// Capabilities advertises NAS attach semantics. Volumes are directories of
+// the cluster exported over NFS, which any number of instances may mount.
+func (d *driver) Capabilities(
+	ctx types.Context) (*types.StorageCapabilities, error) {
+	return &types.StorageCapabilities{
+		AttachSemantics: types.NAS,
+	}, nil
+}
+
 // NextDeviceInfo returns the information about the driver's next available
 // device workflow.
 func (d *driver) NextDeviceInfo(
@@ -246,11 +256,15 @@ func (d *driver) VolumeCreate(ctx types.Context, volumeName string,
 	}
 
 	if vol != nil {
-		return nil, goof.New("volume name already exists")
+		return nil, utils.NewAlreadyExistsError(volumeName)
 	}
 
 	_, err = d.client.CreateVolume(ctx, volumeName)
 	if err != nil {
+		err = translateError(err, volumeName)
+		if types.ErrorCodeOf(err) != "" {
+			return nil, err
+		}
 		return nil, goof.WithFieldE(
 			"volumeName", volumeName, "Error creating volume", err)
 	}
@@ -293,7 +307,7 @@ func (d *driver) VolumeRemove(
 	if d.quotas() {
 		ctx.WithField("volume", volumeID).Debug("clearing volume quotas")
 		if err := d.client.ClearQuota(ctx, volumeID); err != nil {
-			return err
+			return translateError(err, volumeID)
 		}
 	}
 
@@ -302,12 +316,12 @@ func (d *driver) VolumeRemove(
 		"owner":  d.client.API.User(),
 	}).Debug("setting volume owner to current user")
 	if err := d.client.SetVolumeOwnerToCurrentUser(ctx, volumeID); err != nil {
-		return err
+		return translateError(err, volumeID)
 	}
 
 	ctx.WithField("volume", volumeID).Debug("force deleting volume")
 	if err := d.client.ForceDeleteVolume(ctx, volumeID); err != nil {
-		return err
+		return translateError(err, volumeID)
 	}
 
 	return nil
@@ -472,6 +486,60 @@ func (d *driver) VolumeCopy(
 	return nil, types.ErrNotImplemented
 }
 
+// VolumeExpand expands a volume by raising its quota. Volumes may only be
+// expanded when quotas are enabled.
+func (d *driver) VolumeExpand(
+	ctx types.Context,
+	volumeID string,
+	newSize int64,
+	opts types.Store) (*types.Volume, error) {
+
+	if !d.quotas() {
+		return nil, types.ErrNotImplemented
+	}
+
+	vol, err := d.VolumeInspect(ctx, volumeID,
+		&types.VolumeInspectOpts{Attachments: 0})
+	if err != nil {
+		return nil, err
+	}
+	if vol == nil {
+		return nil, utils.NewNotFoundError(volumeID)
+	}
+
+	if newSize < vol.Size {
+		return nil, goof.WithFields(goof.Fields{
+			"volumeID": volumeID,
+			"size":     vol.Size,
+			"newSize":  newSize,
+		}, "cannot shrink volume")
+	}
+
+	ctx.WithFields(log.Fields{
+		"volume":  volumeID,
+		"newSize": newSize,
+	}).Debug("updating volume quota")
+
+	// PAPI uses bytes for it's size units, but REX-Ray uses gigs
+	quota, err := d.client.GetQuota(ctx, volumeID)
+	if err != nil && !isQuotaNotFound(err) {
+		return nil, goof.WithFieldE(
+			"volumeID", volumeID, "Error getting volume quota", err)
+	}
+	if quota == nil {
+		err = d.client.SetQuotaSize(ctx, volumeID, newSize*bytesPerGb)
+	} else {
+		err = d.client.UpdateQuotaSize(ctx, volumeID, newSize*bytesPerGb)
+	}
+	if err != nil {
+		return nil, goof.WithFieldE(
+			"volumeID", volumeID, "Error expanding volume", err)
+	}
+
+	return d.VolumeInspect(ctx, volumeID,
+		&types.VolumeInspectOpts{Attachments: 0})
+}
+
 // VolumeSnapshot snapshots a volume (not implemented)
 func (d *driver) VolumeSnapshot(
 	ctx types.Context,
@@ -523,9 +591,11 @@ func (d *driver) getVolume(
 	var volumes []isi.Volume
 	if volumeID != "" || volumeName != "" {
 		volume, err := d.client.GetVolume(ctx, volumeID, volumeName)
-		if err != nil &&
-			!strings.Contains(err.Error(), "Unable to open object") {
-			return nil, err
+		if err != nil {
+			err = translateError(err, volumeID+volumeName)
+			if !types.IsErrorCode(err, types.ErrorCodeNotFound) {
+				return nil, err
+			}
 		}
 		if volume != nil {
 			volumes = append(volumes, volume)
@@ -534,7 +604,7 @@ func (d *driver) getVolume(
 		var err error
 		volumes, err = d.client.GetVolumes(ctx)
 		if err != nil {
-			return nil, err
+			return nil, translateError(err, "")
 		}
 	}
 
@@ -648,6 +718,43 @@ func (d *driver) getVolumeExports(ctx types.Context) ([]*isiVolExport, error) {
 	return volExports, nil
 }
 
+// papiErrors maps the messages of the errors reported by the OneFS Platform
+// API (PAPI) to the codes of the errors returned by the driver. The goisilon
+// client reports PAPI errors with the messages of the errors in PAPI's
+// responses.
+var papiErrors = []struct {
+	message string
+	code    types.ErrorCode
+}{
+	{"unable to open object", types.ErrorCodeNotFound},
+	{"no such file or directory", types.ErrorCodeNotFound},
+	{"already exists", types.ErrorCodeAlreadyExists},
+	{"resource busy", types.ErrorCodeBusy},
+	{"quota exceeded", types.ErrorCodeQuotaExceeded},
+	{"authorization required", types.ErrorCodeAuthFailed},
+	{"unauthorized", types.ErrorCodeAuthFailed},
+	{"timed out", types.ErrorCodeTimeout},
+}
+
+// translateError returns an error with the code that corresponds to the
+// message of a PAPI error. Errors without a corresponding code are returned
+// as is.
+func translateError(err error, resourceID string) error {
+	msg := strings.ToLower(err.Error())
+	for _, e := range papiErrors {
+		if strings.Contains(msg, e.message) {
+			return utils.NewErrorCodeError(e.code, resourceID, err)
+		}
+	}
+	return err
+}
+
+// isQuotaNotFound returns a flag indicating whether an error returned by the
+// goisilon client's GetQuota function reports that the volume has no quota.
+func isQuotaNotFound(err error) bool {
+	return strings.Contains(strings.ToLower(err.Error()), "quota not found")
+}
+
 func (d *driver) endpoint() string {
 	return d.config.GetString("isilon.endpoint")
 }
@@ -0,0 +1,98 @@
+package libstorage
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/codedellemc/libstorage/api/context"
+	"github.com/codedellemc/libstorage/api/types"
+	"github.com/codedellemc/libstorage/api/utils"
+)
+
+// InvalidateInstanceID removes the cached instance ID of the context's
+// service, or those of all services if the context does not specify one.
+func (c *client) InvalidateInstanceID(ctx types.Context) error {
+
+	if c.isController() {
+		return utils.NewUnsupportedForClientTypeError(
+			c.clientType, "InvalidateInstanceID")
+	}
+
+	driverName, err := c.cacheDriverName(c.requireCtx(ctx))
+	if err != nil {
+		return err
+	}
+	c.invalidateInstanceID(driverName)
+	return nil
+}
+
+// InvalidateLocalDevices removes the cached local devices of the context's
+// service, or those of all services if the context does not specify one.
+func (c *client) InvalidateLocalDevices(ctx types.Context) error {
+
+	if c.isController() {
+		return utils.NewUnsupportedForClientTypeError(
+			c.clientType, "InvalidateLocalDevices")
+	}
+
+	driverName, err := c.cacheDriverName(c.requireCtx(ctx))
+	if err != nil {
+		return err
+	}
+	c.invalidateLocalDevices(driverName)
+	return nil
+}
+
+// cacheDriverName returns the name of the driver of the context's service.
+// An empty string is returned if the context does not specify a service.
+func (c *client) cacheDriverName(ctx types.Context) (string, error) {
+	serviceName, ok := context.ServiceName(ctx)
+	if !ok {
+		return "", nil
+	}
+	si, err := c.getServiceInfo(serviceName)
+	if err != nil {
+		return "", err
+	}
+	return strings.ToLower(si.Driver.Name), nil
+}
+
+// serviceDriverName returns the name of the service's driver, or an empty
+// string if the service is unknown.
+func (c *client) serviceDriverName(service string) string {
+	if si := c.serviceCache.GetServiceInfo(service); si != nil {
+		return strings.ToLower(si.Driver.Name)
+	}
+	return ""
+}
+
+func (c *client) invalidateInstanceID(driverName string) {
+	if c.instanceIDCache == nil {
+		return
+	}
+	if driverName != "" {
+		c.instanceIDCache.Delete(driverName)
+		return
+	}
+	for _, k := range c.instanceIDCache.Keys() {
+		c.instanceIDCache.Delete(k)
+	}
+}
+
+func (c *client) invalidateLocalDevices(driverName string) {
+	if c.localDevicesCache == nil {
+		return
+	}
+	prefix := localDevicesKey(driverName, "")
+	for _, k := range c.localDevicesCache.Keys() {
+		if driverName == "" || strings.HasPrefix(k, prefix) {
+			c.localDevicesCache.Delete(k)
+		}
+	}
+}
+
+// localDevicesKey returns the key at which the result of a driver's local
+// devices scan is cached.
+func localDevicesKey(driverName, scanType string) string {
+	return fmt.Sprintf("%s:%s", strings.ToLower(driverName), scanType)
+}
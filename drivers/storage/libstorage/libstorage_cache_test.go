@@ -0,0 +1,74 @@
+package libstorage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codedellemc/libstorage/api/context"
+	"github.com/codedellemc/libstorage/api/types"
+	"github.com/codedellemc/libstorage/api/utils"
+)
+
+func newCacheTestClient() *client {
+	c := &client{
+		ctx:               context.Background(),
+		clientType:        types.IntegrationClient,
+		serviceCache:      &lss{Store: utils.NewStore()},
+		instanceIDCache:   &lss{Store: utils.NewTTLStore(time.Minute, true)},
+		localDevicesCache: utils.NewTTLStore(time.Minute, true),
+	}
+	for _, dn := range []string{"ebs", "vfs"} {
+		c.serviceCache.Set(dn+"-00", &types.ServiceInfo{
+			Name:   dn + "-00",
+			Driver: &types.DriverInfo{Name: dn},
+		})
+		c.instanceIDCache.Set(dn, &types.InstanceID{ID: dn, Driver: dn})
+		for _, st := range []types.DeviceScanType{
+			types.DeviceScanQuick, types.DeviceScanDeep} {
+			c.localDevicesCache.Set(
+				localDevicesKey(dn, st.String()),
+				&types.LocalDevices{Driver: dn})
+		}
+	}
+	return c
+}
+
+func TestInvalidateLocalDevices(t *testing.T) {
+	c := newCacheTestClient()
+
+	ctx := context.Background().WithValue(context.ServiceKey, "vfs-00")
+	assert.NoError(t, c.InvalidateLocalDevices(ctx))
+	assert.False(t, c.localDevicesCache.IsSet("vfs:quick"))
+	assert.False(t, c.localDevicesCache.IsSet("vfs:deep"))
+	assert.True(t, c.localDevicesCache.IsSet("ebs:quick"))
+	assert.True(t, c.localDevicesCache.IsSet("ebs:deep"))
+	assert.True(t, c.instanceIDCache.IsSet("vfs"))
+
+	assert.NoError(t, c.InvalidateLocalDevices(context.Background()))
+	assert.Len(t, c.localDevicesCache.Keys(), 0)
+
+	ctx = context.Background().WithValue(context.ServiceKey, "gce-00")
+	assert.Error(t, c.InvalidateLocalDevices(ctx))
+}
+
+func TestInvalidateInstanceID(t *testing.T) {
+	c := newCacheTestClient()
+
+	ctx := context.Background().WithValue(context.ServiceKey, "ebs-00")
+	assert.NoError(t, c.InvalidateInstanceID(ctx))
+	assert.False(t, c.instanceIDCache.IsSet("ebs"))
+	assert.True(t, c.instanceIDCache.IsSet("vfs"))
+	assert.Len(t, c.localDevicesCache.Keys(), 4)
+
+	assert.NoError(t, c.InvalidateInstanceID(context.Background()))
+	assert.Len(t, c.instanceIDCache.Keys(), 0)
+}
+
+func TestInvalidateControllerClient(t *testing.T) {
+	c := newCacheTestClient()
+	c.clientType = types.ControllerClient
+	assert.Error(t, c.InvalidateInstanceID(context.Background()))
+	assert.Error(t, c.InvalidateLocalDevices(context.Background()))
+}
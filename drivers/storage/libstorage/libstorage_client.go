@@ -20,13 +20,14 @@ import (
 
 type client struct {
 	types.APIClient
-	ctx             types.Context
-	config          gofig.Config
-	clientType      types.ClientType
-	lsxCache        *lss
-	serviceCache    *lss
-	supportedCache  types.Store
-	instanceIDCache types.Store
+	ctx               types.Context
+	config            gofig.Config
+	clientType        types.ClientType
+	lsxCache          *lss
+	serviceCache      *lss
+	supportedCache    types.Store
+	instanceIDCache   types.Store
+	localDevicesCache types.Store
 }
 
 var errExecutorNotSupported = errors.New("executor not supported")
@@ -52,7 +53,10 @@ func (c *client) dial(ctx types.Context) error {
 	store := utils.NewStore()
 	c.ctx = c.ctx.WithValue(context.ServerKey, c.ServerName())
 
-	if !c.config.GetBool(types.ConfigExecutorNoDownload) {
+	// the executor is not downloaded when the host runs the executor agent
+	// so that clients work on hosts with read-only root file systems
+	if !c.config.GetBool(types.ConfigExecutorNoDownload) &&
+		!c.executorAgentRunning(ctx) {
 
 		ctx.Info("initializing executors cache")
 		if _, err := c.Executors(ctx); err != nil {
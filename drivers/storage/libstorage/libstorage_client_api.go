@@ -94,6 +94,27 @@ func (c *client) VolumesByService(
 	return c.APIClient.VolumesByService(ctx, service, attachments)
 }
 
+func (c *client) VolumesStream(
+	ctx types.Context,
+	service string,
+	attachments types.VolumeAttachmentsTypes,
+	fn func(service string, volume *types.Volume) error) error {
+
+	ctx = c.requireCtx(ctx)
+	if service != "" {
+		ctx = c.withInstanceID(ctx, service)
+	} else {
+		ctx = c.withAllInstanceIDs(ctx)
+	}
+	ctxA, err := c.withAllLocalDevices(ctx)
+	if err != nil {
+		return err
+	}
+	ctx = ctxA
+
+	return c.APIClient.VolumesStream(ctx, service, attachments, fn)
+}
+
 func (c *client) VolumeInspect(
 	ctx types.Context,
 	service, volumeID string,
@@ -208,6 +229,16 @@ func (c *client) VolumeCopy(
 	return vol, nil
 }
 
+func (c *client) VolumeExpand(
+	ctx types.Context,
+	service string,
+	volumeID string,
+	request *types.VolumeExpandRequest) (*types.Volume, error) {
+
+	ctx = c.withInstanceID(c.requireCtx(ctx), service)
+	return c.APIClient.VolumeExpand(ctx, service, volumeID, request)
+}
+
 func (c *client) VolumeRemove(
 	ctx types.Context,
 	service, volumeID string) error {
@@ -256,7 +287,13 @@ func (c *client) VolumeAttach(
 	}
 	ctx = ctxA
 
-	return c.APIClient.VolumeAttach(ctx, service, volumeID, request)
+	vol, token, err := c.APIClient.VolumeAttach(
+		ctx, service, volumeID, request)
+
+	// the host's devices change when a volume is attached
+	c.invalidateLocalDevices(c.serviceDriverName(service))
+
+	return vol, token, err
 }
 
 func (c *client) VolumeDetach(
@@ -277,7 +314,9 @@ func (c *client) VolumeDetach(
 	}
 	ctx = ctxA
 
-	return c.APIClient.VolumeDetach(ctx, service, volumeID, request)
+	vol, err := c.APIClient.VolumeDetach(ctx, service, volumeID, request)
+	c.invalidateLocalDevices(c.serviceDriverName(service))
+	return vol, err
 }
 
 func (c *client) VolumeDetachAll(
@@ -296,7 +335,9 @@ func (c *client) VolumeDetachAll(
 	}
 	ctx = ctxA
 
-	return c.APIClient.VolumeDetachAll(ctx, request)
+	vols, err := c.APIClient.VolumeDetachAll(ctx, request)
+	c.invalidateLocalDevices("")
+	return vols, err
 }
 
 func (c *client) VolumeDetachAllForService(
@@ -316,7 +357,9 @@ func (c *client) VolumeDetachAllForService(
 	}
 	ctx = ctxA
 
-	return c.APIClient.VolumeDetachAllForService(ctx, service, request)
+	vols, err := c.APIClient.VolumeDetachAllForService(ctx, service, request)
+	c.invalidateLocalDevices(c.serviceDriverName(service))
+	return vols, err
 }
 
 func (c *client) VolumeSnapshot(
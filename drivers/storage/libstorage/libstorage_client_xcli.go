@@ -2,6 +2,7 @@ package libstorage
 
 import (
 	"bytes"
+	"net/rpc"
 	"os"
 	"os/exec"
 	"strconv"
@@ -97,13 +98,21 @@ func (c *client) InstanceID(
 	}
 	driverName := strings.ToLower(si.Driver.Name)
 
-	// check to see if the driver's instance ID is cached
-	if iid := c.instanceIDCache.GetInstanceID(driverName); iid != nil {
-		return iid, nil
+	// check to see if the driver's instance ID is cached. the cache also
+	// records the drivers whose executors do not implement the command so
+	// that the executor is not run again every time the instance ID is sent.
+	switch v := c.instanceIDCache.Get(driverName).(type) {
+	case *types.InstanceID:
+		return v, nil
+	case error:
+		return nil, v
 	}
 
 	out, err := c.runExecutor(ctx, driverName, types.LSXCmdInstanceID)
 	if err != nil {
+		if err == types.ErrNotImplemented {
+			c.instanceIDCache.Set(driverName, err)
+		}
 		return nil, err
 	}
 
@@ -193,9 +202,19 @@ func (c *client) LocalDevices(
 		return nil, err
 	}
 	driverName := si.Driver.Name
+	scanType := opts.ScanType.String()
+
+	// check to see if the driver's local devices are cached
+	cacheKey := localDevicesKey(driverName, scanType)
+	if c.localDevicesCache != nil {
+		if ld, ok := c.localDevicesCache.Get(
+			cacheKey).(*types.LocalDevices); ok {
+			return ld, nil
+		}
+	}
 
 	out, err := c.runExecutor(
-		ctx, driverName, types.LSXCmdLocalDevices, opts.ScanType.String())
+		ctx, driverName, types.LSXCmdLocalDevices, scanType)
 	if err != nil {
 		return nil, err
 	}
@@ -205,6 +224,11 @@ func (c *client) LocalDevices(
 		return nil, err
 	}
 
+	if c.localDevicesCache != nil {
+		c.localDevicesCache.Set(cacheKey, ld)
+		ctx.Debug("cached local devices")
+	}
+
 	ctx.Debug("xli localdevices success")
 	return ld, nil
 }
@@ -239,14 +263,21 @@ func (c *client) WaitForDevice(
 		ctx, driverName, types.LSXCmdWaitForDevice,
 		opts.ScanType.String(), opts.Token, opts.Timeout.String())
 
-	if err != types.ErrTimedOut {
+	// the executor exits with the timed out code, and writes the local
+	// devices it last listed, if the device did not appear before the timeout
+	// expired
+	if err != nil && err != types.ErrTimedOut {
 		return false, nil, err
 	}
-
 	matched := err == nil
 
 	ld, err := unmarshalLocalDevices(ctx, out)
 	if err != nil {
+		// the executor has no local devices to write if the timeout expired
+		// before it listed them
+		if !matched {
+			return false, nil, nil
+		}
 		return false, nil, err
 	}
 
@@ -282,6 +313,12 @@ func (c *client) runExecutor(
 			c.clientType, "runExecutor")
 	}
 
+	// prefer the executor agent to executing the executor binary
+	if rc := c.dialExecutorAgent(ctx); rc != nil {
+		defer rc.Close()
+		return c.runExecutorAgent(ctx, rc, args...)
+	}
+
 	ctx.Debug("waiting on executor lock")
 	if err := c.lsxMutexWait(); err != nil {
 		return nil, err
@@ -312,7 +349,7 @@ func (c *client) runExecutor(
 		case types.LSXExitCodeNotImplemented:
 			return nil, types.ErrNotImplemented
 		case types.LSXExitCodeTimedOut:
-			return nil, types.ErrTimedOut
+			return out, types.ErrTimedOut
 		}
 		return nil, goof.WithFieldsE(
 			map[string]interface{}{
@@ -326,6 +363,74 @@ func (c *client) runExecutor(
 	return out, err
 }
 
+// executorAgentRunning returns a flag indicating whether the executor agent
+// is running on the host.
+func (c *client) executorAgentRunning(ctx types.Context) bool {
+	rc := c.dialExecutorAgent(ctx)
+	if rc == nil {
+		return false
+	}
+	rc.Close()
+	return true
+}
+
+// dialExecutorAgent connects to the executor agent if it is running on the
+// host. A socket left behind by an agent that is no longer running is ignored
+// so that the executor binary is executed instead.
+func (c *client) dialExecutorAgent(ctx types.Context) *rpc.Client {
+	sock := c.config.GetString(types.ConfigExecutorAgent)
+	if sock == "" || !gotil.FileExists(sock) {
+		return nil
+	}
+	rc, err := rpc.Dial("unix", sock)
+	if err != nil {
+		ctx.WithFields(map[string]interface{}{
+			"agent": sock,
+			"error": err,
+		}).Warn("executor agent not running")
+		return nil
+	}
+	return rc
+}
+
+func (c *client) runExecutorAgent(
+	ctx types.Context, rc *rpc.Client, args ...string) ([]byte, error) {
+
+	fields := map[string]interface{}{
+		"agent": c.config.GetString(types.ConfigExecutorAgent),
+		"args":  args,
+	}
+
+	// the client's configuration is sent with each command since the agent
+	// serves clients with different configurations
+	config, err := c.config.ToJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx.WithFields(fields).Debug("running command in executor agent")
+
+	reply := &types.LSXAgentReply{}
+	if err := rc.Call(
+		types.LSXAgentRPCRun,
+		&types.LSXAgentRequest{Args: args, Config: []byte(config)},
+		reply); err != nil {
+		return nil, goof.WithFieldsE(
+			fields, "error calling executor agent", err)
+	}
+
+	switch reply.ExitCode {
+	case 0:
+		return reply.Output, nil
+	case types.LSXExitCodeNotImplemented:
+		return nil, types.ErrNotImplemented
+	case types.LSXExitCodeTimedOut:
+		return reply.Output, types.ErrTimedOut
+	}
+	return nil, goof.WithFieldsE(
+		fields, "error executing xcli", goof.New(reply.Error))
+}
+
 func (c *client) lsxMutexWait() error {
 
 	if c.isController() {
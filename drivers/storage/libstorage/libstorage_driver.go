@@ -8,10 +8,12 @@ import (
 
 	log "github.com/Sirupsen/logrus"
 	gofig "github.com/akutz/gofig/types"
+	"github.com/akutz/goof"
 	"github.com/akutz/gotil"
 
 	apiclient "github.com/codedellemc/libstorage/api/client"
 	"github.com/codedellemc/libstorage/api/context"
+	"github.com/codedellemc/libstorage/api/registry"
 	"github.com/codedellemc/libstorage/api/types"
 	"github.com/codedellemc/libstorage/api/utils"
 )
@@ -39,51 +41,61 @@ func newDriver() types.StorageDriver {
 func (d *driver) Init(ctx types.Context, config gofig.Config) error {
 	logFields := log.Fields{}
 
-	addr := config.GetString(types.ConfigHost)
-	d.ctx = ctx.WithValue(context.HostKey, addr)
-	d.ctx.Debug("got configured host address")
-
-	proto, lAddr, err := gotil.ParseAddress(addr)
-	if err != nil {
-		return err
+	var (
+		host          string
+		httpTransport http.RoundTripper
+		hbDur         time.Duration
+		err           error
+	)
+
+	cliTransport := types.ParseClientTransport(
+		config.GetString(types.ConfigClientTransport))
+	logFields["transport"] = cliTransport
+
+	switch cliTransport {
+	case types.EmbeddedClientTransport:
+		host, httpTransport, err = d.newEmbeddedTransport(ctx, config)
+	case types.HTTPClientTransport:
+		host, httpTransport, err = d.newHTTPTransport(ctx, config, logFields)
+	default:
+		return goof.WithField(
+			"transport", config.GetString(types.ConfigClientTransport),
+			"invalid client transport")
 	}
-
-	tlsConfig, err := utils.ParseTLSConfig(
-		config, logFields, "libstorage.client")
 	if err != nil {
 		return err
 	}
 
-	host := getHost(proto, lAddr, tlsConfig)
 	lsxPath := config.GetString(types.ConfigExecutorPath)
 	cliType := types.ParseClientType(config.GetString(types.ConfigClientType))
-	disableKeepAlive := config.GetBool(types.ConfigHTTPDisableKeepAlive)
 
 	logFields["host"] = host
 	logFields["lsxPath"] = lsxPath
 	logFields["clientType"] = cliType
-	logFields["disableKeepAlive"] = disableKeepAlive
-
-	httpTransport := &http.Transport{
-		Dial: func(string, string) (net.Conn, error) {
-			if tlsConfig == nil {
-				return net.Dial(proto, lAddr)
-			}
-			return tls.Dial(proto, lAddr, tlsConfig)
-		},
-		DisableKeepAlives: disableKeepAlive,
-	}
 
 	apiClient := apiclient.New(host, httpTransport)
 	logReq := config.GetBool(types.ConfigLogHTTPRequests)
 	logRes := config.GetBool(types.ConfigLogHTTPResponses)
 	apiClient.LogRequests(logReq)
 	apiClient.LogResponses(logRes)
+	apiClient.BearerToken(config.GetString(types.ConfigClientAuthToken))
+	apiClient.TraceCollector(
+		config.GetString(types.ConfigTracingCollector),
+		config.GetInt(types.ConfigTracingSampleRate))
+
+	retries := config.GetInt(types.ConfigClientRetries)
+	retryBackoff, err := time.ParseDuration(
+		config.GetString(types.ConfigClientRetryBackoff))
+	if err != nil {
+		return err
+	}
+	apiClient.Retries(retries, retryBackoff)
 
 	logFields["enableInstanceIDHeaders"] = EnableInstanceIDHeaders
 	logFields["enableLocalDevicesHeaders"] = EnableLocalDevicesHeaders
 	logFields["logRequests"] = logReq
 	logFields["logResponses"] = logRes
+	logFields["retries"] = retries
 
 	d.client = client{
 		APIClient:    apiClient,
@@ -95,19 +107,39 @@ func (d *driver) Init(ctx types.Context, config gofig.Config) error {
 
 	if d.clientType == types.IntegrationClient {
 
-		newIIDCache := utils.NewStore
-		dur, err := time.ParseDuration(
+		// a zero duration caches instance IDs until they are invalidated
+		iidCache := utils.NewStore()
+		iidDur, err := time.ParseDuration(
 			config.GetString(types.ConfigClientCacheInstanceID))
 		if err != nil {
-			logFields["iidCacheDuration"] = dur.String()
-			newIIDCache = func() types.Store {
-				return utils.NewTTLStore(dur, true)
-			}
+			return err
+		}
+		if iidDur > 0 {
+			iidCache = utils.NewTTLStore(iidDur, true)
+		}
+		logFields["iidCacheDuration"] = iidDur.String()
+
+		// a zero duration disables the local devices cache
+		ldDur, err := time.ParseDuration(
+			config.GetString(types.ConfigClientCacheLocalDevices))
+		if err != nil {
+			return err
+		}
+		if ldDur > 0 {
+			d.localDevicesCache = utils.NewTTLStore(ldDur, true)
+		}
+		logFields["ldCacheDuration"] = ldDur.String()
+
+		// a zero duration disables the heartbeat
+		if hbDur, err = time.ParseDuration(
+			config.GetString(types.ConfigClientHeartbeatInterval)); err != nil {
+			return err
 		}
+		logFields["heartbeatInterval"] = hbDur.String()
 
 		d.lsxCache = &lss{Store: utils.NewStore()}
 		d.supportedCache = utils.NewStore()
-		d.instanceIDCache = &lss{Store: newIIDCache()}
+		d.instanceIDCache = &lss{Store: iidCache}
 	}
 
 	d.ctx.WithFields(logFields).Info("created libStorage client")
@@ -117,5 +149,104 @@ func (d *driver) Init(ctx types.Context, config gofig.Config) error {
 	}
 
 	d.ctx.Info("successefully dialed libStorage server")
+
+	if hbDur > 0 {
+		go d.heartbeat(hbDur)
+	}
+
 	return nil
 }
+
+// newHTTPTransport returns a transport that sends requests to the libStorage
+// endpoint specified by the host address. If more than one address is
+// specified by the client's hosts property, the transport fails over to the
+// next server when the current one cannot be reached.
+func (d *driver) newHTTPTransport(
+	ctx types.Context,
+	config gofig.Config,
+	logFields log.Fields) (string, http.RoundTripper, error) {
+
+	addrs := config.GetStringSlice(types.ConfigClientHosts)
+	if len(addrs) == 0 {
+		addrs = []string{config.GetString(types.ConfigHost)}
+	}
+	d.ctx = ctx.WithValue(context.HostKey, addrs[0])
+	d.ctx.Debug("got configured host address")
+
+	tlsConfig, err := utils.ParseTLSConfig(
+		config, logFields, "libstorage.client")
+	if err != nil {
+		return "", nil, err
+	}
+
+	disableKeepAlive := config.GetBool(types.ConfigHTTPDisableKeepAlive)
+	logFields["disableKeepAlive"] = disableKeepAlive
+
+	var endpoints []*failoverEndpoint
+	for _, addr := range addrs {
+		proto, lAddr, err := gotil.ParseAddress(addr)
+		if err != nil {
+			return "", nil, err
+		}
+		endpoints = append(endpoints, &failoverEndpoint{
+			host: getHost(proto, lAddr, tlsConfig),
+			transport: newHostTransport(
+				proto, lAddr, tlsConfig, disableKeepAlive),
+		})
+	}
+
+	if len(endpoints) == 1 {
+		return endpoints[0].host, endpoints[0].transport, nil
+	}
+
+	hcDur, err := time.ParseDuration(
+		config.GetString(types.ConfigClientHealthCheckInterval))
+	if err != nil {
+		return "", nil, err
+	}
+	logFields["hosts"] = addrs
+	logFields["healthCheckInterval"] = hcDur.String()
+
+	t := newFailoverTransport(d.ctx, endpoints)
+	if hcDur > 0 {
+		go t.healthCheck(hcDur)
+	}
+	return endpoints[0].host, t, nil
+}
+
+// newHostTransport returns a transport that sends requests to the server
+// listening on the provided address.
+func newHostTransport(
+	proto, lAddr string,
+	tlsConfig *tls.Config,
+	disableKeepAlive bool) http.RoundTripper {
+
+	return &http.Transport{
+		Dial: func(string, string) (net.Conn, error) {
+			if tlsConfig == nil {
+				return net.Dial(proto, lAddr)
+			}
+			return tls.Dial(proto, lAddr, tlsConfig)
+		},
+		DisableKeepAlives: disableKeepAlive,
+	}
+}
+
+// newEmbeddedTransport starts an embedded libStorage server and returns a
+// transport that dispatches requests to it directly. The server's services
+// are configured the same way as those of a standalone server. The server
+// is only available if the program links the server package.
+func (d *driver) newEmbeddedTransport(
+	ctx types.Context,
+	config gofig.Config) (string, http.RoundTripper, error) {
+
+	d.ctx = ctx.WithValue(context.HostKey, "embedded://"+embeddedHost)
+	d.ctx.Debug("starting embedded server")
+
+	s, err := registry.NewEmbeddedServer(ctx, config)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return embeddedHost, &embeddedTransport{handler: s}, nil
+}
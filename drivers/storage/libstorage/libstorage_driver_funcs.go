@@ -48,6 +48,26 @@ func (d *driver) Type(ctx types.Context) (types.StorageType, error) {
 	return si.Driver.Type, nil
 }
 
+// Capabilities returns the capabilities the server advertised for the
+// context's service.
+func (d *driver) Capabilities(
+	ctx types.Context) (*types.StorageCapabilities, error) {
+
+	serviceName, ok := context.ServiceName(ctx)
+	if !ok {
+		return nil, goof.New("missing service name")
+	}
+
+	si, err := d.getServiceInfo(serviceName)
+	if err != nil {
+		return nil, err
+	}
+	if si.Driver.Capabilities == nil {
+		return nil, types.ErrNotImplemented
+	}
+	return si.Driver.Capabilities, nil
+}
+
 func (d *driver) InstanceInspect(
 	ctx types.Context,
 	opts types.Store) (*types.Instance, error) {
@@ -168,6 +188,26 @@ func (d *driver) VolumeCopy(
 	return d.client.VolumeCopy(ctx, serviceName, volumeID, req)
 }
 
+func (d *driver) VolumeExpand(
+	ctx types.Context,
+	volumeID string,
+	newSize int64,
+	opts types.Store) (*types.Volume, error) {
+
+	ctx = d.requireCtx(ctx)
+	serviceName, ok := context.ServiceName(ctx)
+	if !ok {
+		return nil, goof.New("missing service name")
+	}
+
+	req := &types.VolumeExpandRequest{
+		NewSize: newSize,
+		Opts:    opts.Map(),
+	}
+
+	return d.client.VolumeExpand(ctx, serviceName, volumeID, req)
+}
+
 func (d *driver) VolumeSnapshot(
 	ctx types.Context,
 	volumeID, snapshotName string,
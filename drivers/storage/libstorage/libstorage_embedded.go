@@ -0,0 +1,132 @@
+package libstorage
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+
+	"github.com/akutz/goof"
+)
+
+const embeddedHost = "libstorage-embedded"
+
+// embeddedTransport is an http.RoundTripper that dispatches requests to the
+// handler of an embedded server in the same process instead of sending them
+// over a network connection.
+type embeddedTransport struct {
+	handler http.Handler
+}
+
+func (t *embeddedTransport) RoundTrip(
+	req *http.Request) (*http.Response, error) {
+
+	// the request is copied so that it resembles one the server received
+	// over a network connection
+	sreq := *req
+	sreq.RemoteAddr = embeddedHost
+	sreq.RequestURI = req.URL.RequestURI()
+	if sreq.Body == nil {
+		sreq.Body = ioutil.NopCloser(&bytes.Buffer{})
+	}
+
+	pr, pw := io.Pipe()
+	w := &embeddedResponseWriter{
+		header: http.Header{},
+		body:   pw,
+		ready:  make(chan struct{}),
+		closed: make(chan bool, 1),
+	}
+
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				pw.CloseWithError(goof.WithField(
+					"panic", r, "embedded server error"))
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+			pw.Close()
+		}()
+		t.handler.ServeHTTP(w, &sreq)
+	}()
+
+	// wait until the handler writes the response's header, or until the
+	// request is cancelled, in which case the handler is notified that the
+	// client is gone and its writes fail
+	select {
+	case <-w.ready:
+	case <-req.Context().Done():
+		w.closedOnce.Do(func() { w.closed <- true })
+		pr.CloseWithError(req.Context().Err())
+		return nil, req.Context().Err()
+	}
+
+	return &http.Response{
+		Status:     fmt.Sprintf("%d %s", w.status, http.StatusText(w.status)),
+		StatusCode: w.status,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     w.sentHeader,
+		Body:       &embeddedResponseBody{PipeReader: pr, w: w},
+		Request:    req,
+	}, nil
+}
+
+// embeddedResponseWriter streams a response's body to the client as it is
+// written so that long-lived responses, such as event streams, are possible.
+type embeddedResponseWriter struct {
+	header     http.Header
+	sentHeader http.Header
+	status     int
+	body       *io.PipeWriter
+	ready      chan struct{}
+	readyOnce  sync.Once
+	closed     chan bool
+	closedOnce sync.Once
+}
+
+func (w *embeddedResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *embeddedResponseWriter) WriteHeader(status int) {
+	w.readyOnce.Do(func() {
+		w.status = status
+		w.sentHeader = http.Header{}
+		for k, v := range w.header {
+			w.sentHeader[k] = v
+		}
+		close(w.ready)
+	})
+}
+
+func (w *embeddedResponseWriter) Write(p []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.body.Write(p)
+}
+
+// Flush is a no-op since the response's body is not buffered.
+func (w *embeddedResponseWriter) Flush() {
+	w.WriteHeader(http.StatusOK)
+}
+
+// CloseNotify returns a channel that receives a value when the client closes
+// the response's body.
+func (w *embeddedResponseWriter) CloseNotify() <-chan bool {
+	return w.closed
+}
+
+type embeddedResponseBody struct {
+	*io.PipeReader
+	w *embeddedResponseWriter
+}
+
+func (b *embeddedResponseBody) Close() error {
+	b.w.closedOnce.Do(func() { b.w.closed <- true })
+	return b.PipeReader.Close()
+}
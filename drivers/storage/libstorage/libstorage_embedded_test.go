@@ -0,0 +1,92 @@
+package libstorage
+
+import (
+	"bufio"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codedellemc/libstorage/api/context"
+)
+
+func TestEmbeddedTransport(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		buf, _ := ioutil.ReadAll(req.Body)
+		w.Header().Set("X-Test", req.URL.Path)
+		w.WriteHeader(http.StatusCreated)
+		w.Write(buf)
+	})
+
+	c := &http.Client{Transport: &embeddedTransport{handler: h}}
+	res, err := c.Post(
+		"http://"+embeddedHost+"/volumes", "text/plain",
+		strings.NewReader("hello"))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusCreated, res.StatusCode)
+	assert.Equal(t, "/volumes", res.Header.Get("X-Test"))
+	buf, err := ioutil.ReadAll(res.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", string(buf))
+}
+
+func TestEmbeddedTransportStream(t *testing.T) {
+	done := make(chan struct{})
+	h := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		defer close(done)
+		w.(http.Flusher).Flush()
+		closed := w.(http.CloseNotifier).CloseNotify()
+		w.Write([]byte("event\n"))
+		<-closed
+	})
+
+	c := &http.Client{Transport: &embeddedTransport{handler: h}}
+	res, err := c.Get("http://" + embeddedHost + "/events")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	line, err := bufio.NewReader(res.Body).ReadString('\n')
+	assert.NoError(t, err)
+	assert.Equal(t, "event\n", line)
+
+	res.Body.Close()
+	<-done
+}
+
+// TestEmbeddedTransportCancel asserts that a request whose handler never
+// writes a response returns once the request's context is cancelled.
+func TestEmbeddedTransportCancel(t *testing.T) {
+	done := make(chan struct{})
+	h := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		defer close(done)
+		<-w.(http.CloseNotifier).CloseNotify()
+	})
+
+	ctx, cancel := context.WithTimeout(
+		context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, err := http.NewRequest(
+		http.MethodGet, "http://"+embeddedHost+"/volumes", nil)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	c := &http.Client{Transport: &embeddedTransport{handler: h}}
+	_, err = c.Do(req.WithContext(ctx))
+	assert.Error(t, err)
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("handler was not notified")
+	}
+}
@@ -0,0 +1,208 @@
+package libstorage
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/codedellemc/libstorage/api/types"
+)
+
+// failoverTransport is an http.RoundTripper that sends requests to one of
+// several libStorage servers. Requests stick to the same server until it
+// cannot be reached, at which point they are sent to the next healthy server.
+// The status of a task is always requested from the server that created it.
+type failoverTransport struct {
+	sync.RWMutex
+	ctx       types.Context
+	endpoints []*failoverEndpoint
+	current   int
+	tasks     map[string]*failoverEndpoint
+}
+
+// failoverEndpoint is one of the servers of a failoverTransport.
+type failoverEndpoint struct {
+	host      string
+	transport http.RoundTripper
+	healthy   bool
+}
+
+func newFailoverTransport(
+	ctx types.Context, endpoints []*failoverEndpoint) *failoverTransport {
+
+	for _, ep := range endpoints {
+		ep.healthy = true
+	}
+	return &failoverTransport{
+		ctx:       ctx,
+		endpoints: endpoints,
+		tasks:     map[string]*failoverEndpoint{},
+	}
+}
+
+func (t *failoverTransport) RoundTrip(
+	req *http.Request) (*http.Response, error) {
+
+	// the body is buffered so the request can be resent to another server
+	var body []byte
+	if req.Body != nil {
+		buf, err := ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		body = buf
+	}
+
+	path := req.URL.Path
+	t.RLock()
+	pinned := t.tasks[path]
+	t.RUnlock()
+
+	if pinned != nil {
+		res, err := t.send(pinned, req, body)
+		if err != nil || res.StatusCode != http.StatusAccepted {
+			t.Lock()
+			delete(t.tasks, path)
+			t.Unlock()
+		}
+		return res, err
+	}
+
+	var lastErr error
+	for _, ep := range t.candidates() {
+		res, err := t.send(ep, req, body)
+		if err == nil {
+			t.succeeded(ep, res)
+			return res, nil
+		}
+		lastErr = err
+		t.failed(ep, err)
+		if isMutation(req.Method) && !isDialError(err) {
+			break
+		}
+	}
+	return nil, lastErr
+}
+
+func (t *failoverTransport) send(
+	ep *failoverEndpoint,
+	req *http.Request,
+	body []byte) (*http.Response, error) {
+
+	r := *req
+	if body != nil {
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+	return ep.transport.RoundTrip(&r)
+}
+
+// candidates returns the endpoints in the order in which a request is sent to
+// them, beginning with the current endpoint and followed by the other healthy
+// endpoints. The unhealthy endpoints are tried last.
+func (t *failoverTransport) candidates() []*failoverEndpoint {
+	t.RLock()
+	defer t.RUnlock()
+
+	var healthy, unhealthy []*failoverEndpoint
+	for i := range t.endpoints {
+		ep := t.endpoints[(t.current+i)%len(t.endpoints)]
+		if ep.healthy {
+			healthy = append(healthy, ep)
+		} else {
+			unhealthy = append(unhealthy, ep)
+		}
+	}
+	return append(healthy, unhealthy...)
+}
+
+// succeeded makes the endpoint that responded to a request the current one
+// and pins the task the response refers to, if any, to the endpoint.
+func (t *failoverTransport) succeeded(
+	ep *failoverEndpoint, res *http.Response) {
+
+	t.Lock()
+	defer t.Unlock()
+
+	ep.healthy = true
+	for i, e := range t.endpoints {
+		if e == ep && i != t.current {
+			t.ctx.WithField("host", ep.host).Warn("failed over to server")
+			t.current = i
+		}
+	}
+
+	if res.StatusCode == http.StatusAccepted {
+		if loc := res.Header.Get("Location"); loc != "" {
+			t.tasks[loc] = ep
+		}
+	}
+}
+
+func (t *failoverTransport) failed(ep *failoverEndpoint, err error) {
+	t.Lock()
+	defer t.Unlock()
+	if ep.healthy {
+		t.ctx.WithField("host", ep.host).WithError(err).Warn(
+			"server unavailable")
+	}
+	ep.healthy = false
+}
+
+// healthCheck periodically sends a request to every endpoint so that a
+// server that became available again is preferred to the servers that
+// remain unavailable. The health check stops once the transport's context is
+// done.
+func (t *failoverTransport) healthCheck(interval time.Duration) {
+	t.ctx.WithField("interval", interval).Debug("started health check")
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			t.checkEndpoints()
+		case <-t.ctx.Done():
+			t.ctx.Debug("stopped health check")
+			return
+		}
+	}
+}
+
+func (t *failoverTransport) checkEndpoints() {
+	for _, ep := range t.endpoints {
+		req, err := http.NewRequest(
+			http.MethodHead, "http://"+ep.host+"/", nil)
+		if err != nil {
+			continue
+		}
+		res, err := ep.transport.RoundTrip(req)
+		t.Lock()
+		if err != nil {
+			ep.healthy = false
+		} else {
+			res.Body.Close()
+			ep.healthy = res.StatusCode < http.StatusInternalServerError
+		}
+		t.Unlock()
+	}
+}
+
+func isMutation(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	return true
+}
+
+// isDialError returns a flag indicating whether an error occurred while
+// connecting to a server, in which case the request was never sent.
+func isDialError(err error) bool {
+	opErr, ok := err.(*net.OpError)
+	return ok && opErr.Op == "dial"
+}
@@ -0,0 +1,139 @@
+package libstorage
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codedellemc/libstorage/api/context"
+)
+
+type testRoundTripper struct {
+	down     bool
+	status   int
+	location string
+	requests int
+}
+
+func (t *testRoundTripper) RoundTrip(
+	req *http.Request) (*http.Response, error) {
+
+	t.requests++
+	if t.down {
+		return nil, &net.OpError{Op: "dial", Err: net.UnknownNetworkError("")}
+	}
+	res := &http.Response{
+		StatusCode: t.status,
+		Header:     http.Header{},
+		Body:       ioutil.NopCloser(strings.NewReader("")),
+	}
+	if t.location != "" {
+		res.Header.Set("Location", t.location)
+	}
+	return res, nil
+}
+
+func newFailoverTestTransport(
+	rts ...*testRoundTripper) *failoverTransport {
+
+	var endpoints []*failoverEndpoint
+	for _, rt := range rts {
+		endpoints = append(endpoints, &failoverEndpoint{transport: rt})
+	}
+	return newFailoverTransport(context.Background(), endpoints)
+}
+
+func TestFailoverTransport(t *testing.T) {
+	a := &testRoundTripper{status: http.StatusOK}
+	b := &testRoundTripper{status: http.StatusOK}
+	ft := newFailoverTestTransport(a, b)
+
+	req, _ := http.NewRequest(
+		http.MethodPost, "http://libstorage-server/volumes",
+		strings.NewReader("{}"))
+	_, err := ft.RoundTrip(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, a.requests)
+	assert.Equal(t, 0, b.requests)
+
+	a.down = true
+	_, err = ft.RoundTrip(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, a.requests)
+	assert.Equal(t, 1, b.requests)
+
+	// requests stick to the server that was failed over to
+	a.down = false
+	_, err = ft.RoundTrip(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, a.requests)
+	assert.Equal(t, 2, b.requests)
+
+	b.down = true
+	_, err = ft.RoundTrip(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, a.requests)
+
+	a.down = true
+	_, err = ft.RoundTrip(req)
+	assert.Error(t, err)
+}
+
+func TestFailoverTransportTasks(t *testing.T) {
+	a := &testRoundTripper{
+		status:   http.StatusAccepted,
+		location: "/tasks/1",
+	}
+	b := &testRoundTripper{status: http.StatusOK}
+	ft := newFailoverTestTransport(a, b)
+
+	req, _ := http.NewRequest(
+		http.MethodPost, "http://libstorage-server/volumes/vfs?async", nil)
+	_, err := ft.RoundTrip(req)
+	assert.NoError(t, err)
+
+	// the task's status is requested from the server that created it even
+	// if the requests are otherwise sent to another server
+	ft.current = 1
+	req, _ = http.NewRequest(
+		http.MethodGet, "http://libstorage-server/tasks/1", nil)
+	_, err = ft.RoundTrip(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, a.requests)
+	assert.Equal(t, 0, b.requests)
+
+	a.status = http.StatusOK
+	_, err = ft.RoundTrip(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, a.requests)
+
+	_, err = ft.RoundTrip(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, a.requests)
+	assert.Equal(t, 1, b.requests)
+}
+
+func TestFailoverTransportHealthCheckStops(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	ft := newFailoverTransport(ctx, []*failoverEndpoint{
+		{transport: &testRoundTripper{status: http.StatusOK}},
+	})
+
+	done := make(chan struct{})
+	go func() {
+		ft.healthCheck(time.Millisecond)
+		close(done)
+	}()
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second * 5):
+		t.Fatal("health check did not stop")
+	}
+}
@@ -0,0 +1,31 @@
+package libstorage
+
+import (
+	"time"
+)
+
+// heartbeat periodically sends a request that includes the instance IDs of
+// the client's services so the server knows the instance is alive. The
+// server preempts the attachments of instances from which it has not
+// received a request within its heartbeat timeout.
+//
+// The heartbeat stops once the client's context is done.
+func (c *client) heartbeat(interval time.Duration) {
+	c.ctx.WithField("interval", interval).Debug("started heartbeat")
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			ctx := c.withAllInstanceIDs(c.ctx)
+			if _, err := c.APIClient.Root(ctx); err != nil {
+				c.ctx.WithError(err).Warn("error sending heartbeat")
+			}
+		case <-c.ctx.Done():
+			c.ctx.Debug("stopped heartbeat")
+			return
+		}
+	}
+}
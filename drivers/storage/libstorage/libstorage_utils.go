@@ -5,6 +5,7 @@ import (
 
 	"github.com/codedellemc/libstorage/api/context"
 	"github.com/codedellemc/libstorage/api/types"
+	"github.com/codedellemc/libstorage/api/utils"
 )
 
 func (c *client) requireCtx(ctx types.Context) types.Context {
@@ -32,8 +33,15 @@ func (c *client) withAllInstanceIDs(ctx types.Context) types.Context {
 	}
 
 	iidm := types.InstanceIDMap{}
-	for _, k := range c.instanceIDCache.Keys() {
-		iidm[k] = c.instanceIDCache.GetInstanceID(k)
+	for _, service := range c.serviceCache.Keys() {
+		dn := c.serviceDriverName(service)
+		if _, ok := iidm[dn]; ok {
+			continue
+		}
+		ctx := ctx.WithValue(context.ServiceKey, service)
+		if iid := c.cachedInstanceID(ctx, dn); iid != nil {
+			iidm[dn] = iid
+		}
 	}
 
 	if len(iidm) == 0 {
@@ -52,19 +60,41 @@ func (c *client) withInstanceID(
 		return ctx
 	}
 
-	si := c.serviceCache.GetServiceInfo(service)
-	if si == nil {
+	dn := c.serviceDriverName(service)
+	if dn == "" {
 		return ctx
 	}
 
-	if !c.instanceIDCache.IsSet(si.Driver.Name) {
+	iid := c.cachedInstanceID(ctx, dn)
+	if iid == nil {
 		return ctx
 	}
-
-	iid := c.instanceIDCache.GetInstanceID(si.Driver.Name)
 	return ctx.WithValue(context.InstanceIDKey, iid)
 }
 
+// cachedInstanceID returns the cached instance ID of the driver of the
+// context's service. An instance ID that expired or was invalidated is
+// retrieved again unless the context already has an instance ID, as it does
+// while the instance ID is being retrieved.
+func (c *client) cachedInstanceID(
+	ctx types.Context, driverName string) *types.InstanceID {
+
+	if iid := c.instanceIDCache.GetInstanceID(driverName); iid != nil {
+		return iid
+	}
+
+	if _, ok := context.InstanceID(ctx); ok {
+		return nil
+	}
+
+	iid, err := c.InstanceID(ctx, utils.NewStore())
+	if err != nil {
+		ctx.WithError(err).Debug("cannot refresh instance ID")
+		return nil
+	}
+	return iid
+}
+
 func (c *client) withAllLocalDevices(ctx types.Context) (types.Context, error) {
 
 	if c.isController() {
@@ -0,0 +1,114 @@
+package executor
+
+import (
+	"path"
+
+	gofig "github.com/akutz/gofig/types"
+	"github.com/akutz/gotil"
+
+	"github.com/codedellemc/libstorage/api/registry"
+	"github.com/codedellemc/libstorage/api/types"
+	"github.com/codedellemc/libstorage/api/utils"
+	"github.com/codedellemc/libstorage/drivers/storage/local"
+	localUtils "github.com/codedellemc/libstorage/drivers/storage/local/utils"
+)
+
+// attachedTag is the tag with which the storage driver marks the logical
+// volumes of attached volumes.
+const attachedTag = "libstorage_attached"
+
+// driver is the storage executor for the local storage driver.
+type driver struct {
+	config gofig.Config
+}
+
+func init() {
+	registry.RegisterStorageExecutor(local.Name, newDriver)
+}
+
+func newDriver() types.StorageExecutor {
+	return &driver{}
+}
+
+func (d *driver) Init(ctx types.Context, config gofig.Config) error {
+	d.config = config
+	return nil
+}
+
+func (d *driver) Name() string {
+	return local.Name
+}
+
+// Supported returns a flag indicating whether or not the platform
+// implementing the executor is valid for the host on which the executor
+// resides.
+func (d *driver) Supported(
+	ctx types.Context,
+	opts types.Store) (bool, error) {
+
+	if local.VolumeGroup(d.config) != "" {
+		return gotil.FileExistsInPath("lvs"), nil
+	}
+	return gotil.FileExistsInPath("losetup"), nil
+}
+
+// InstanceID returns the local instance ID for the test
+func InstanceID() (*types.InstanceID, error) {
+	return newDriver().InstanceID(nil, nil)
+}
+
+// InstanceID returns the local system's host name.
+func (d *driver) InstanceID(
+	ctx types.Context,
+	opts types.Store) (*types.InstanceID, error) {
+
+	hostName, err := utils.HostName()
+	if err != nil {
+		return nil, err
+	}
+	return &types.InstanceID{ID: hostName, Driver: local.Name}, nil
+}
+
+func (d *driver) NextDevice(
+	ctx types.Context,
+	opts types.Store) (string, error) {
+	return "", types.ErrNotImplemented
+}
+
+// LocalDevices returns the devices of the attached volumes. The devices are
+// mapped to the IDs of their volumes.
+func (d *driver) LocalDevices(
+	ctx types.Context,
+	opts *types.LocalDevicesOpts) (*types.LocalDevices, error) {
+
+	devMap := map[string]string{}
+
+	if vg := local.VolumeGroup(d.config); vg != "" {
+		lvs, err := localUtils.LVs(ctx, vg)
+		if err != nil {
+			return nil, err
+		}
+		for _, lv := range lvs {
+			if lv.HasTag(attachedTag) {
+				devMap[lv.Path] = lv.Name
+			}
+		}
+	} else {
+		loops, err := localUtils.LoopDevices(ctx)
+		if err != nil {
+			return nil, err
+		}
+		volumesDir := local.VolumesDirPath(d.config)
+		for dev, file := range loops {
+			if path.Dir(file) == volumesDir {
+				devMap[dev] = path.Base(file)
+			}
+		}
+	}
+
+	ld := &types.LocalDevices{Driver: d.Name()}
+	if len(devMap) > 0 {
+		ld.DeviceMap = devMap
+	}
+	return ld, nil
+}
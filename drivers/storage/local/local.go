@@ -0,0 +1,51 @@
+package local
+
+import (
+	"path"
+
+	gofigCore "github.com/akutz/gofig"
+	gofig "github.com/akutz/gofig/types"
+
+	"github.com/codedellemc/libstorage/api/types"
+)
+
+const (
+	// Name is the provider's name.
+	Name = "local"
+
+	// ConfigVolumeGroup is a config key.
+	ConfigVolumeGroup = Name + ".volumeGroup"
+
+	// ConfigRootDir is a config key.
+	ConfigRootDir = Name + ".rootDir"
+)
+
+func init() {
+	r := gofigCore.NewRegistration("Local")
+	r.Key(gofig.String, "", "",
+		"The LVM volume group in which volumes are managed as logical "+
+			"volumes. Volumes are managed as sparse files when empty",
+		ConfigVolumeGroup)
+	r.Key(gofig.String, "", types.Lib.Join("local"),
+		"The directory in which volumes are managed as sparse files",
+		ConfigRootDir)
+	gofigCore.Register(r)
+}
+
+// VolumeGroup returns the LVM volume group in which volumes are managed.
+// An empty string indicates that volumes are managed as sparse files.
+func VolumeGroup(config gofig.Config) string {
+	return config.GetString(ConfigVolumeGroup)
+}
+
+// VolumesDirPath returns the path to the directory of the volumes' sparse
+// files.
+func VolumesDirPath(config gofig.Config) string {
+	return path.Join(config.GetString(ConfigRootDir), "vol")
+}
+
+// SnapshotsDirPath returns the path to the directory of the snapshots'
+// sparse files.
+func SnapshotsDirPath(config gofig.Config) string {
+	return path.Join(config.GetString(ConfigRootDir), "snap")
+}
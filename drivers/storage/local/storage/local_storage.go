@@ -0,0 +1,606 @@
+package storage
+
+import (
+	"regexp"
+	"sync"
+
+	log "github.com/Sirupsen/logrus"
+	gofig "github.com/akutz/gofig/types"
+	"github.com/akutz/goof"
+
+	"github.com/codedellemc/libstorage/api/context"
+	"github.com/codedellemc/libstorage/api/registry"
+	"github.com/codedellemc/libstorage/api/types"
+	"github.com/codedellemc/libstorage/api/utils"
+	"github.com/codedellemc/libstorage/drivers/storage/local"
+)
+
+const (
+	bytesPerGb = int64(1024 * 1024 * 1024)
+
+	attachedStatus  = "attached"
+	availableStatus = "available"
+)
+
+// nameRX matches a valid volume or snapshot name, which is valid both as the
+// name of a logical volume and as the name of a file.
+var nameRX = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9+_.-]{0,126}$`)
+
+// backend manages the volumes and snapshots of the local host.
+type backend interface {
+	// name returns the name of the backend, which is the type of the
+	// volumes.
+	name() string
+
+	// init verifies that the backend may be used.
+	init(ctx types.Context) error
+
+	// volumes returns the volumes.
+	volumes(ctx types.Context) ([]*volume, error)
+
+	// snapshots returns the snapshots.
+	snapshots(ctx types.Context) ([]*snapshot, error)
+
+	// create creates a volume of the specified size in bytes.
+	create(ctx types.Context, volumeName string, size int64) error
+
+	// clone creates a volume with the contents of the volume or snapshot
+	// whose device or file is at the source path. The new volume is the
+	// specified size in bytes.
+	clone(ctx types.Context, src, volumeName string, size int64) error
+
+	// expand grows a volume to the specified size in bytes.
+	expand(ctx types.Context, v *volume, size int64) error
+
+	// remove removes a volume.
+	remove(ctx types.Context, volumeID string) error
+
+	// snapshot creates a snapshot of a volume and returns the snapshot's ID.
+	snapshot(ctx types.Context, v *volume, snapshotName string) (string, error)
+
+	// removeSnapshot removes a snapshot.
+	removeSnapshot(ctx types.Context, snapshotID string) error
+
+	// attach attaches a volume and returns its device.
+	attach(ctx types.Context, v *volume) (string, error)
+
+	// detach detaches a volume.
+	detach(ctx types.Context, v *volume) error
+}
+
+// volume is a volume managed by a backend.
+type volume struct {
+	id   string
+	size int64
+
+	// path is the path to the volume's logical volume or file.
+	path string
+
+	// device is the device to which the volume is attached, or an empty
+	// string if the volume is not attached.
+	device string
+}
+
+// snapshot is a snapshot managed by a backend.
+type snapshot struct {
+	id       string
+	name     string
+	volumeID string
+	size     int64
+
+	// path is the path to the snapshot's logical volume or file.
+	path string
+}
+
+// driver is a storage driver that manages volumes on the host on which the
+// server runs, either as the logical volumes of an LVM volume group or as
+// sparse files attached to loop devices. The server must therefore run on
+// the same host as the client, for example as an embedded server.
+type driver struct {
+	sync.Mutex
+	config gofig.Config
+	b      backend
+}
+
+func init() {
+	registry.RegisterStorageDriver(local.Name, newDriver)
+}
+
+func newDriver() types.StorageDriver {
+	return &driver{}
+}
+
+// Name returns the name of the driver
+func (d *driver) Name() string {
+	return local.Name
+}
+
+// Init initializes the driver.
+func (d *driver) Init(ctx types.Context, config gofig.Config) error {
+	d.config = config
+
+	fields := log.Fields{
+		"volumeGroup": local.VolumeGroup(config),
+		"rootDir":     config.GetString(local.ConfigRootDir),
+	}
+
+	if vg := local.VolumeGroup(config); vg != "" {
+		d.b = &lvmBackend{volumeGroup: vg}
+	} else {
+		d.b = &fileBackend{
+			volumesDir:   local.VolumesDirPath(config),
+			snapshotsDir: local.SnapshotsDirPath(config),
+		}
+	}
+	fields["backend"] = d.b.name()
+
+	if err := d.b.init(ctx); err != nil {
+		return goof.WithFieldsE(fields, "error initializing backend", err)
+	}
+
+	ctx.WithFields(fields).Info("storage driver initialized")
+	return nil
+}
+
+// Type returns the type of storage a driver provides
+func (d *driver) Type(ctx types.Context) (types.StorageType, error) {
+	return types.Block, nil
+}
+
+// Capabilities advertises block attach semantics, snapshots and expansion.
+// Volumes are devices of the server's own host, so only that host may use
+// them.
+func (d *driver) Capabilities(
+	ctx types.Context) (*types.StorageCapabilities, error) {
+	return &types.StorageCapabilities{
+		SupportsSnapshots: true,
+		SupportsExpand:    true,
+		AttachSemantics:   types.Block,
+	}, nil
+}
+
+// NextDeviceInfo returns the information about the driver's next available
+// device workflow.
+func (d *driver) NextDeviceInfo(
+	ctx types.Context) (*types.NextDeviceInfo, error) {
+	return nil, nil
+}
+
+// InstanceInspect returns an instance.
+func (d *driver) InstanceInspect(
+	ctx types.Context,
+	opts types.Store) (*types.Instance, error) {
+
+	iid := context.MustInstanceID(ctx)
+	return &types.Instance{Name: iid.ID, InstanceID: iid}, nil
+}
+
+func (d *driver) Volumes(
+	ctx types.Context,
+	opts *types.VolumesOpts) ([]*types.Volume, error) {
+
+	vols, err := d.b.volumes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	volumes := []*types.Volume{}
+	for _, v := range vols {
+		volumes = append(volumes, d.toTypesVolume(ctx, v, opts.Attachments))
+	}
+	return utils.SortVolumeByID(volumes), nil
+}
+
+func (d *driver) VolumeInspect(
+	ctx types.Context,
+	volumeID string,
+	opts *types.VolumeInspectOpts) (*types.Volume, error) {
+
+	v, err := d.getVolume(ctx, volumeID)
+	if err != nil {
+		return nil, err
+	}
+	return d.toTypesVolume(ctx, v, opts.Attachments), nil
+}
+
+// VolumeCreate creates a new volume.
+func (d *driver) VolumeCreate(ctx types.Context, volumeName string,
+	opts *types.VolumeCreateOpts) (*types.Volume, error) {
+
+	if err := d.validateNewName(ctx, volumeName); err != nil {
+		return nil, err
+	}
+
+	if opts.Size == nil || *opts.Size <= 0 {
+		return nil, goof.WithField(
+			"volumeName", volumeName, "volume size is required")
+	}
+
+	if err := d.b.create(ctx, volumeName, *opts.Size*bytesPerGb); err != nil {
+		return nil, err
+	}
+
+	ctx.WithFields(log.Fields{
+		"volumeName": volumeName,
+		"size":       *opts.Size,
+	}).Info("created volume")
+
+	return d.VolumeInspect(ctx, volumeName,
+		&types.VolumeInspectOpts{Attachments: 0})
+}
+
+// VolumeCreateFromSnapshot creates a new volume with the contents of a
+// snapshot. The volume is the size of the snapshot unless a larger size is
+// requested.
+func (d *driver) VolumeCreateFromSnapshot(
+	ctx types.Context,
+	snapshotID, volumeName string,
+	opts *types.VolumeCreateOpts) (*types.Volume, error) {
+
+	if err := d.validateNewName(ctx, volumeName); err != nil {
+		return nil, err
+	}
+
+	s, err := d.getSnapshot(ctx, snapshotID)
+	if err != nil {
+		return nil, err
+	}
+
+	size := s.size
+	if opts.Size != nil && *opts.Size*bytesPerGb > size {
+		size = *opts.Size * bytesPerGb
+	}
+
+	if err := d.b.clone(ctx, s.path, volumeName, size); err != nil {
+		return nil, err
+	}
+
+	ctx.WithFields(log.Fields{
+		"snapshotID": snapshotID,
+		"volumeName": volumeName,
+	}).Info("created volume from snapshot")
+
+	return d.VolumeInspect(ctx, volumeName,
+		&types.VolumeInspectOpts{Attachments: 0})
+}
+
+// VolumeCopy copies a volume. The volume should not be written to while it
+// is copied.
+func (d *driver) VolumeCopy(
+	ctx types.Context,
+	volumeID, volumeName string,
+	opts types.Store) (*types.Volume, error) {
+
+	if err := d.validateNewName(ctx, volumeName); err != nil {
+		return nil, err
+	}
+
+	v, err := d.getVolume(ctx, volumeID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := d.b.clone(ctx, v.path, volumeName, v.size); err != nil {
+		return nil, err
+	}
+
+	ctx.WithFields(log.Fields{
+		"volumeID":   volumeID,
+		"volumeName": volumeName,
+	}).Info("copied volume")
+
+	return d.VolumeInspect(ctx, volumeName,
+		&types.VolumeInspectOpts{Attachments: 0})
+}
+
+// VolumeExpand grows a volume. Volumes may not be shrunk.
+func (d *driver) VolumeExpand(
+	ctx types.Context,
+	volumeID string,
+	newSize int64,
+	opts types.Store) (*types.Volume, error) {
+
+	v, err := d.getVolume(ctx, volumeID)
+	if err != nil {
+		return nil, err
+	}
+
+	if newSize*bytesPerGb < v.size {
+		return nil, goof.WithFields(goof.Fields{
+			"volumeID": volumeID,
+			"size":     v.size / bytesPerGb,
+			"newSize":  newSize,
+		}, "cannot shrink volume")
+	}
+
+	if err := d.b.expand(ctx, v, newSize*bytesPerGb); err != nil {
+		return nil, err
+	}
+
+	return d.VolumeInspect(ctx, volumeID,
+		&types.VolumeInspectOpts{Attachments: 0})
+}
+
+// VolumeSnapshot creates a snapshot of a volume.
+func (d *driver) VolumeSnapshot(
+	ctx types.Context,
+	volumeID, snapshotName string,
+	opts types.Store) (*types.Snapshot, error) {
+
+	if !nameRX.MatchString(snapshotName) {
+		return nil, goof.WithField(
+			"snapshotName", snapshotName, "invalid snapshot name")
+	}
+
+	v, err := d.getVolume(ctx, volumeID)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshotID, err := d.b.snapshot(ctx, v, snapshotName)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx.WithFields(log.Fields{
+		"volumeID":   volumeID,
+		"snapshotID": snapshotID,
+	}).Info("created snapshot")
+
+	return d.SnapshotInspect(ctx, snapshotID, opts)
+}
+
+// VolumeRemove removes a volume. Attached volumes may not be removed.
+func (d *driver) VolumeRemove(
+	ctx types.Context,
+	volumeID string,
+	opts types.Store) error {
+
+	d.Lock()
+	defer d.Unlock()
+
+	v, err := d.getVolume(ctx, volumeID)
+	if err != nil {
+		return err
+	}
+
+	if v.device != "" {
+		return goof.WithFields(goof.Fields{
+			"volumeID": volumeID,
+			"device":   v.device,
+		}, "volume is attached")
+	}
+
+	return d.b.remove(ctx, volumeID)
+}
+
+// VolumeAttach attaches a volume to a local device. The device is returned
+// as the attachment's token.
+func (d *driver) VolumeAttach(
+	ctx types.Context,
+	volumeID string,
+	opts *types.VolumeAttachOpts) (*types.Volume, string, error) {
+
+	d.Lock()
+	defer d.Unlock()
+
+	v, err := d.getVolume(ctx, volumeID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if v.device != "" {
+		if !opts.Force {
+			return nil, "", goof.WithFields(goof.Fields{
+				"volumeID": volumeID,
+				"device":   v.device,
+			}, "volume already attached")
+		}
+		return d.attachedVolume(ctx, volumeID, v.device)
+	}
+
+	dev, err := d.b.attach(ctx, v)
+	if err != nil {
+		return nil, "", err
+	}
+
+	ctx.WithFields(log.Fields{
+		"volumeID": volumeID,
+		"device":   dev,
+	}).Info("attached volume")
+
+	return d.attachedVolume(ctx, volumeID, dev)
+}
+
+func (d *driver) attachedVolume(
+	ctx types.Context,
+	volumeID, dev string) (*types.Volume, string, error) {
+
+	vol, err := d.VolumeInspect(ctx, volumeID,
+		&types.VolumeInspectOpts{Attachments: types.VolumeAttachmentsTrue})
+	if err != nil {
+		return nil, "", err
+	}
+	return vol, dev, nil
+}
+
+// VolumeDetach detaches a volume from its local device.
+func (d *driver) VolumeDetach(
+	ctx types.Context,
+	volumeID string,
+	opts *types.VolumeDetachOpts) (*types.Volume, error) {
+
+	d.Lock()
+	defer d.Unlock()
+
+	v, err := d.getVolume(ctx, volumeID)
+	if err != nil {
+		return nil, err
+	}
+
+	if v.device == "" {
+		return nil, goof.WithField(
+			"volumeID", volumeID, "volume not attached")
+	}
+
+	if err := d.b.detach(ctx, v); err != nil {
+		return nil, err
+	}
+
+	ctx.WithFields(log.Fields{
+		"volumeID": volumeID,
+		"device":   v.device,
+	}).Info("detached volume")
+
+	return d.VolumeInspect(ctx, volumeID,
+		&types.VolumeInspectOpts{Attachments: types.VolumeAttachmentsTrue})
+}
+
+func (d *driver) Snapshots(
+	ctx types.Context,
+	opts types.Store) ([]*types.Snapshot, error) {
+
+	snaps, err := d.b.snapshots(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots := []*types.Snapshot{}
+	for _, s := range snaps {
+		snapshots = append(snapshots, toTypesSnapshot(s))
+	}
+	return snapshots, nil
+}
+
+func (d *driver) SnapshotInspect(
+	ctx types.Context,
+	snapshotID string,
+	opts types.Store) (*types.Snapshot, error) {
+
+	s, err := d.getSnapshot(ctx, snapshotID)
+	if err != nil {
+		return nil, err
+	}
+	return toTypesSnapshot(s), nil
+}
+
+// SnapshotCopy (not implemented).
+func (d *driver) SnapshotCopy(
+	ctx types.Context,
+	snapshotID, snapshotName, destinationID string,
+	opts types.Store) (*types.Snapshot, error) {
+	return nil, types.ErrNotImplemented
+}
+
+func (d *driver) SnapshotRemove(
+	ctx types.Context,
+	snapshotID string,
+	opts types.Store) error {
+
+	if _, err := d.getSnapshot(ctx, snapshotID); err != nil {
+		return err
+	}
+	return d.b.removeSnapshot(ctx, snapshotID)
+}
+
+// HealthCheck verifies that the backend's volumes can be listed.
+func (d *driver) HealthCheck(ctx types.Context) error {
+	_, err := d.b.volumes(ctx)
+	return err
+}
+
+// getVolume returns a volume. An ErrNotFound error is returned if the
+// volume does not exist.
+func (d *driver) getVolume(
+	ctx types.Context, volumeID string) (*volume, error) {
+
+	vols, err := d.b.volumes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range vols {
+		if v.id == volumeID {
+			return v, nil
+		}
+	}
+	return nil, utils.NewNotFoundError(volumeID)
+}
+
+// getSnapshot returns a snapshot. An ErrNotFound error is returned if the
+// snapshot does not exist.
+func (d *driver) getSnapshot(
+	ctx types.Context, snapshotID string) (*snapshot, error) {
+
+	snaps, err := d.b.snapshots(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range snaps {
+		if s.id == snapshotID {
+			return s, nil
+		}
+	}
+	return nil, utils.NewNotFoundError(snapshotID)
+}
+
+// validateNewName returns an error if a volume name is invalid or is already
+// in use.
+func (d *driver) validateNewName(ctx types.Context, volumeName string) error {
+	if !nameRX.MatchString(volumeName) {
+		return goof.WithField(
+			"volumeName", volumeName, "invalid volume name")
+	}
+	if _, err := d.getVolume(ctx, volumeName); err == nil {
+		return utils.NewAlreadyExistsError(volumeName)
+	}
+	return nil
+}
+
+// toTypesVolume converts a volume. An attached volume is attached to the
+// context's instance since the server runs on the same host as the client.
+func (d *driver) toTypesVolume(
+	ctx types.Context,
+	v *volume,
+	attachments types.VolumeAttachmentsTypes) *types.Volume {
+
+	vol := &types.Volume{
+		ID:     v.id,
+		Name:   v.id,
+		Size:   v.size / bytesPerGb,
+		Type:   d.b.name(),
+		Status: availableStatus,
+	}
+	if v.device != "" {
+		vol.Status = attachedStatus
+	}
+
+	if !attachments.Requested() || v.device == "" {
+		return vol
+	}
+
+	iid, ok := context.InstanceID(ctx)
+	if !ok {
+		return vol
+	}
+
+	att := &types.VolumeAttachment{
+		VolumeID:   v.id,
+		InstanceID: iid,
+		Status:     attachedStatus,
+	}
+	if attachments.Devices() {
+		att.DeviceName = v.device
+	}
+	vol.Attachments = []*types.VolumeAttachment{att}
+	return vol
+}
+
+func toTypesSnapshot(s *snapshot) *types.Snapshot {
+	return &types.Snapshot{
+		ID:         s.id,
+		Name:       s.name,
+		VolumeID:   s.volumeID,
+		VolumeSize: s.size / bytesPerGb,
+		Status:     availableStatus,
+	}
+}
@@ -0,0 +1,176 @@
+package storage
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/akutz/goof"
+
+	"github.com/codedellemc/libstorage/api/types"
+	localUtils "github.com/codedellemc/libstorage/drivers/storage/local/utils"
+)
+
+// snapDelimiter delimits the name of a volume and the name of one of its
+// snapshots in the snapshot's ID.
+const snapDelimiter = "@"
+
+// fileBackend manages volumes as sparse files that are attached to loop
+// devices and snapshots as sparse copies of the files.
+type fileBackend struct {
+	volumesDir   string
+	snapshotsDir string
+}
+
+func (b *fileBackend) name() string {
+	return "file"
+}
+
+func (b *fileBackend) init(ctx types.Context) error {
+	if err := os.MkdirAll(b.volumesDir, 0750); err != nil {
+		return err
+	}
+	return os.MkdirAll(b.snapshotsDir, 0750)
+}
+
+func (b *fileBackend) volumes(ctx types.Context) ([]*volume, error) {
+	files, err := ioutil.ReadDir(b.volumesDir)
+	if err != nil {
+		return nil, err
+	}
+
+	loops, err := localUtils.LoopDevices(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	devs := map[string]string{}
+	for dev, file := range loops {
+		devs[file] = dev
+	}
+
+	vols := []*volume{}
+	for _, f := range files {
+		if !f.Mode().IsRegular() {
+			continue
+		}
+		p := path.Join(b.volumesDir, f.Name())
+		vols = append(vols, &volume{
+			id:     f.Name(),
+			size:   f.Size(),
+			path:   p,
+			device: devs[p],
+		})
+	}
+	return vols, nil
+}
+
+// snapshots returns the snapshots. A snapshot's ID is the name of its
+// volume and the name of the snapshot, delimited by an at sign.
+func (b *fileBackend) snapshots(ctx types.Context) ([]*snapshot, error) {
+	files, err := ioutil.ReadDir(b.snapshotsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	snaps := []*snapshot{}
+	for _, f := range files {
+		parts := strings.SplitN(f.Name(), snapDelimiter, 2)
+		if !f.Mode().IsRegular() || len(parts) != 2 {
+			continue
+		}
+		snaps = append(snaps, &snapshot{
+			id:       f.Name(),
+			name:     parts[1],
+			volumeID: parts[0],
+			size:     f.Size(),
+			path:     path.Join(b.snapshotsDir, f.Name()),
+		})
+	}
+	return snaps, nil
+}
+
+func (b *fileBackend) create(
+	ctx types.Context, volumeName string, size int64) error {
+
+	f, err := os.OpenFile(path.Join(b.volumesDir, volumeName),
+		os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0640)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return f.Truncate(size)
+}
+
+// clone copies the source file, preserving its holes.
+func (b *fileBackend) clone(
+	ctx types.Context, src, volumeName string, size int64) error {
+
+	dst := path.Join(b.volumesDir, volumeName)
+	if err := copySparse(ctx, src, dst); err != nil {
+		return err
+	}
+	if err := os.Truncate(dst, size); err != nil {
+		os.Remove(dst)
+		return err
+	}
+	return nil
+}
+
+// expand grows a volume's file and, if the volume is attached, updates the
+// size of its loop device.
+func (b *fileBackend) expand(ctx types.Context, v *volume, size int64) error {
+	if err := os.Truncate(v.path, size); err != nil {
+		return err
+	}
+	if v.device == "" {
+		return nil
+	}
+	_, err := localUtils.Exec(ctx, "losetup", "--set-capacity", v.device)
+	return err
+}
+
+// remove removes a volume's file. The volume's snapshots are preserved.
+func (b *fileBackend) remove(ctx types.Context, volumeID string) error {
+	return os.Remove(path.Join(b.volumesDir, volumeID))
+}
+
+func (b *fileBackend) snapshot(
+	ctx types.Context, v *volume, snapshotName string) (string, error) {
+
+	snapshotID := v.id + snapDelimiter + snapshotName
+	dst := path.Join(b.snapshotsDir, snapshotID)
+	if _, err := os.Stat(dst); err == nil {
+		return "", goof.WithField(
+			"snapshotID", snapshotID, "snapshot already exists")
+	}
+	if err := copySparse(ctx, v.path, dst); err != nil {
+		return "", err
+	}
+	return snapshotID, nil
+}
+
+func (b *fileBackend) removeSnapshot(
+	ctx types.Context, snapshotID string) error {
+
+	return os.Remove(path.Join(b.snapshotsDir, snapshotID))
+}
+
+func (b *fileBackend) attach(ctx types.Context, v *volume) (string, error) {
+	out, err := localUtils.Exec(ctx, "losetup", "--find", "--show", v.path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func (b *fileBackend) detach(ctx types.Context, v *volume) error {
+	_, err := localUtils.Exec(ctx, "losetup", "--detach", v.device)
+	return err
+}
+
+func copySparse(ctx types.Context, src, dst string) error {
+	_, err := localUtils.Exec(ctx, "cp", "--sparse=always", src, dst)
+	return err
+}
@@ -0,0 +1,157 @@
+package storage
+
+import (
+	"fmt"
+
+	"github.com/codedellemc/libstorage/api/types"
+	localUtils "github.com/codedellemc/libstorage/drivers/storage/local/utils"
+)
+
+// attachedTag is the tag with which the logical volumes of attached volumes
+// are marked. Since the logical volumes of a volume group are always
+// available as devices of the host, attaching a volume only records that the
+// volume is in use.
+const attachedTag = "libstorage_attached"
+
+// lvmBackend manages volumes as the logical volumes of a volume group and
+// snapshots as LVM snapshots of the logical volumes.
+type lvmBackend struct {
+	volumeGroup string
+}
+
+func (b *lvmBackend) name() string {
+	return "lvm"
+}
+
+func (b *lvmBackend) init(ctx types.Context) error {
+	_, err := localUtils.Exec(ctx, "vgs", b.volumeGroup)
+	return err
+}
+
+func (b *lvmBackend) volumes(ctx types.Context) ([]*volume, error) {
+	lvs, err := localUtils.LVs(ctx, b.volumeGroup)
+	if err != nil {
+		return nil, err
+	}
+
+	vols := []*volume{}
+	for _, lv := range lvs {
+		if lv.Origin != "" {
+			continue
+		}
+		v := &volume{id: lv.Name, size: lv.Size, path: lv.Path}
+		if lv.HasTag(attachedTag) {
+			v.device = lv.Path
+		}
+		vols = append(vols, v)
+	}
+	return vols, nil
+}
+
+// snapshots returns the snapshots, each of which is an LVM snapshot whose
+// ID and name are the name of its logical volume.
+func (b *lvmBackend) snapshots(ctx types.Context) ([]*snapshot, error) {
+	lvs, err := localUtils.LVs(ctx, b.volumeGroup)
+	if err != nil {
+		return nil, err
+	}
+
+	snaps := []*snapshot{}
+	for _, lv := range lvs {
+		if lv.Origin == "" {
+			continue
+		}
+		snaps = append(snaps, &snapshot{
+			id:       lv.Name,
+			name:     lv.Name,
+			volumeID: lv.Origin,
+			size:     lv.Size,
+			path:     lv.Path,
+		})
+	}
+	return snaps, nil
+}
+
+func (b *lvmBackend) create(
+	ctx types.Context, volumeName string, size int64) error {
+
+	_, err := localUtils.Exec(ctx, "lvcreate", "--yes",
+		"--name", volumeName, "--size", sizeArg(size), b.volumeGroup)
+	return err
+}
+
+// clone creates a logical volume and copies the source's contents to it.
+func (b *lvmBackend) clone(
+	ctx types.Context, src, volumeName string, size int64) error {
+
+	if err := b.create(ctx, volumeName, size); err != nil {
+		return err
+	}
+
+	if _, err := localUtils.Exec(ctx, "dd",
+		"if="+src, "of="+b.lvPath(volumeName),
+		"bs=4M", "conv=fsync"); err != nil {
+		b.remove(ctx, volumeName)
+		return err
+	}
+	return nil
+}
+
+func (b *lvmBackend) expand(ctx types.Context, v *volume, size int64) error {
+	_, err := localUtils.Exec(ctx, "lvextend",
+		"--size", sizeArg(size), b.lvSpec(v.id))
+	return err
+}
+
+// remove removes a logical volume along with its snapshots.
+func (b *lvmBackend) remove(ctx types.Context, volumeID string) error {
+	_, err := localUtils.Exec(ctx, "lvremove", "--force", b.lvSpec(volumeID))
+	return err
+}
+
+// snapshot creates an LVM snapshot whose copy-on-write space is the size of
+// its origin so that the snapshot cannot overflow.
+func (b *lvmBackend) snapshot(
+	ctx types.Context, v *volume, snapshotName string) (string, error) {
+
+	if _, err := localUtils.Exec(ctx, "lvcreate", "--snapshot",
+		"--name", snapshotName, "--extents", "100%ORIGIN",
+		b.lvSpec(v.id)); err != nil {
+		return "", err
+	}
+	return snapshotName, nil
+}
+
+func (b *lvmBackend) removeSnapshot(
+	ctx types.Context, snapshotID string) error {
+
+	return b.remove(ctx, snapshotID)
+}
+
+func (b *lvmBackend) attach(ctx types.Context, v *volume) (string, error) {
+	if _, err := localUtils.Exec(ctx, "lvchange",
+		"--addtag", attachedTag, b.lvSpec(v.id)); err != nil {
+		return "", err
+	}
+	return v.path, nil
+}
+
+func (b *lvmBackend) detach(ctx types.Context, v *volume) error {
+	_, err := localUtils.Exec(ctx, "lvchange",
+		"--deltag", attachedTag, b.lvSpec(v.id))
+	return err
+}
+
+func (b *lvmBackend) lvSpec(name string) string {
+	return b.volumeGroup + "/" + name
+}
+
+func (b *lvmBackend) lvPath(name string) string {
+	return "/dev/" + b.lvSpec(name)
+}
+
+// sizeArg formats a size in bytes as the argument of the --size option of
+// the LVM commands.
+func sizeArg(size int64) string {
+	return fmt.Sprintf("%db", size)
+}
@@ -0,0 +1,465 @@
+package storage
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"testing"
+
+	gofigCore "github.com/akutz/gofig"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codedellemc/libstorage/api/context"
+	"github.com/codedellemc/libstorage/api/types"
+	"github.com/codedellemc/libstorage/api/utils"
+	"github.com/codedellemc/libstorage/drivers/storage/local"
+)
+
+// fakeBackend is a fake backend that manages volumes and snapshots in
+// memory.
+type fakeBackend struct {
+	vols  map[string]*volume
+	snaps map[string]*snapshot
+
+	// devs is the number of devices to which volumes have been attached.
+	devs int
+
+	// errs are the errors returned by the backend's methods, keyed by the
+	// methods' names.
+	errs map[string]error
+}
+
+func newFakeBackend() *fakeBackend {
+	return &fakeBackend{
+		vols:  map[string]*volume{},
+		snaps: map[string]*snapshot{},
+		errs:  map[string]error{},
+	}
+}
+
+func (b *fakeBackend) name() string {
+	return "fake"
+}
+
+func (b *fakeBackend) init(ctx types.Context) error {
+	return b.errs["init"]
+}
+
+func (b *fakeBackend) volumes(ctx types.Context) ([]*volume, error) {
+	if err := b.errs["volumes"]; err != nil {
+		return nil, err
+	}
+	vols := []*volume{}
+	for _, v := range b.vols {
+		c := *v
+		vols = append(vols, &c)
+	}
+	return vols, nil
+}
+
+func (b *fakeBackend) snapshots(ctx types.Context) ([]*snapshot, error) {
+	if err := b.errs["snapshots"]; err != nil {
+		return nil, err
+	}
+	ids := []string{}
+	for id := range b.snaps {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	snaps := []*snapshot{}
+	for _, id := range ids {
+		c := *b.snaps[id]
+		snaps = append(snaps, &c)
+	}
+	return snaps, nil
+}
+
+func (b *fakeBackend) create(
+	ctx types.Context, volumeName string, size int64) error {
+
+	if err := b.errs["create"]; err != nil {
+		return err
+	}
+	b.vols[volumeName] = &volume{
+		id:   volumeName,
+		size: size,
+		path: "/vol/" + volumeName,
+	}
+	return nil
+}
+
+func (b *fakeBackend) clone(
+	ctx types.Context, src, volumeName string, size int64) error {
+
+	if err := b.errs["clone"]; err != nil {
+		return err
+	}
+	b.vols[volumeName] = &volume{
+		id:   volumeName,
+		size: size,
+		path: "/vol/" + volumeName,
+	}
+	return nil
+}
+
+func (b *fakeBackend) expand(ctx types.Context, v *volume, size int64) error {
+	if err := b.errs["expand"]; err != nil {
+		return err
+	}
+	b.vols[v.id].size = size
+	return nil
+}
+
+func (b *fakeBackend) remove(ctx types.Context, volumeID string) error {
+	if err := b.errs["remove"]; err != nil {
+		return err
+	}
+	delete(b.vols, volumeID)
+	return nil
+}
+
+func (b *fakeBackend) snapshot(
+	ctx types.Context, v *volume, snapshotName string) (string, error) {
+
+	if err := b.errs["snapshot"]; err != nil {
+		return "", err
+	}
+	id := v.id + snapDelimiter + snapshotName
+	b.snaps[id] = &snapshot{
+		id:       id,
+		name:     snapshotName,
+		volumeID: v.id,
+		size:     v.size,
+		path:     "/snap/" + id,
+	}
+	return id, nil
+}
+
+func (b *fakeBackend) removeSnapshot(
+	ctx types.Context, snapshotID string) error {
+
+	if err := b.errs["removeSnapshot"]; err != nil {
+		return err
+	}
+	delete(b.snaps, snapshotID)
+	return nil
+}
+
+func (b *fakeBackend) attach(ctx types.Context, v *volume) (string, error) {
+	if err := b.errs["attach"]; err != nil {
+		return "", err
+	}
+	b.vols[v.id].device = "/dev/loop" + strconv.Itoa(b.devs)
+	b.devs++
+	return b.vols[v.id].device, nil
+}
+
+func (b *fakeBackend) detach(ctx types.Context, v *volume) error {
+	if err := b.errs["detach"]; err != nil {
+		return err
+	}
+	b.vols[v.id].device = ""
+	return nil
+}
+
+func newTestDriver() (*driver, *fakeBackend) {
+	b := newFakeBackend()
+	return &driver{config: gofigCore.New(), b: b}, b
+}
+
+func newTestContext() types.Context {
+	return context.WithValue(
+		context.Background(),
+		context.InstanceIDKey,
+		&types.InstanceID{ID: "host1", Driver: local.Name})
+}
+
+func size(gb int64) *int64 {
+	return &gb
+}
+
+func TestInit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "local")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	config := gofigCore.New()
+	config.Set(local.ConfigRootDir, dir)
+	d := &driver{}
+	assert.NoError(t, d.Init(context.Background(), config))
+	if assert.IsType(t, &fileBackend{}, d.b) {
+		assert.Equal(t, path.Join(dir, "vol"), d.b.(*fileBackend).volumesDir)
+		assert.Equal(t,
+			path.Join(dir, "snap"), d.b.(*fileBackend).snapshotsDir)
+	}
+	_, err = os.Stat(path.Join(dir, "snap"))
+	assert.NoError(t, err)
+
+	// the volume group does not exist or the LVM commands are not installed
+	config.Set(local.ConfigVolumeGroup, "libstorage-test-missing")
+	d = &driver{}
+	assert.Error(t, d.Init(context.Background(), config))
+	assert.IsType(t, &lvmBackend{}, d.b)
+}
+
+func TestNameRX(t *testing.T) {
+	tests := []struct {
+		name  string
+		valid bool
+	}{
+		{"vol1", true},
+		{"vol_1.img", true},
+		{"a+b-c", true},
+		{"", false},
+		{"-vol", false},
+		{".vol", false},
+		{"vol/1", false},
+		{"vol@snap", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.valid, nameRX.MatchString(tt.name), tt.name)
+	}
+}
+
+func TestVolumeCreate(t *testing.T) {
+	d, b := newTestDriver()
+	ctx := newTestContext()
+
+	vol, err := d.VolumeCreate(
+		ctx, "vol1", &types.VolumeCreateOpts{Size: size(2)})
+	assert.NoError(t, err)
+	assert.Equal(t, "vol1", vol.ID)
+	assert.Equal(t, int64(2), vol.Size)
+	assert.Equal(t, "fake", vol.Type)
+	assert.Equal(t, availableStatus, vol.Status)
+	assert.Equal(t, 2*bytesPerGb, b.vols["vol1"].size)
+
+	_, err = d.VolumeCreate(
+		ctx, "vol1", &types.VolumeCreateOpts{Size: size(2)})
+	assert.IsType(t, &types.ErrAlreadyExists{}, err)
+	_, err = d.VolumeCreate(
+		ctx, "vol/2", &types.VolumeCreateOpts{Size: size(2)})
+	assert.Error(t, err)
+	_, err = d.VolumeCreate(ctx, "vol2", &types.VolumeCreateOpts{})
+	assert.Error(t, err)
+	_, err = d.VolumeCreate(
+		ctx, "vol2", &types.VolumeCreateOpts{Size: size(0)})
+	assert.Error(t, err)
+	assert.Len(t, b.vols, 1)
+
+	b.errs["create"] = utils.NewQuotaExceededError("vol2", nil)
+	_, err = d.VolumeCreate(
+		ctx, "vol2", &types.VolumeCreateOpts{Size: size(2)})
+	assert.Equal(t, types.ErrorCodeQuotaExceeded, types.ErrorCodeOf(err))
+}
+
+func TestNotFound(t *testing.T) {
+	d, b := newTestDriver()
+	ctx := newTestContext()
+
+	_, err := d.VolumeInspect(ctx, "vol1", &types.VolumeInspectOpts{})
+	assert.IsType(t, &types.ErrNotFound{}, err)
+	assert.IsType(t, &types.ErrNotFound{}, d.VolumeRemove(ctx, "vol1", nil))
+	_, err = d.VolumeExpand(ctx, "vol1", 10, nil)
+	assert.IsType(t, &types.ErrNotFound{}, err)
+	_, _, err = d.VolumeAttach(ctx, "vol1", &types.VolumeAttachOpts{})
+	assert.IsType(t, &types.ErrNotFound{}, err)
+	_, err = d.VolumeDetach(ctx, "vol1", &types.VolumeDetachOpts{})
+	assert.IsType(t, &types.ErrNotFound{}, err)
+	_, err = d.VolumeSnapshot(ctx, "vol1", "snap1", nil)
+	assert.IsType(t, &types.ErrNotFound{}, err)
+	_, err = d.VolumeCopy(ctx, "vol1", "vol2", nil)
+	assert.IsType(t, &types.ErrNotFound{}, err)
+	_, err = d.SnapshotInspect(ctx, "vol1@snap1", nil)
+	assert.IsType(t, &types.ErrNotFound{}, err)
+	assert.IsType(t,
+		&types.ErrNotFound{}, d.SnapshotRemove(ctx, "vol1@snap1", nil))
+	_, err = d.VolumeCreateFromSnapshot(
+		ctx, "vol1@snap1", "vol2", &types.VolumeCreateOpts{})
+	assert.IsType(t, &types.ErrNotFound{}, err)
+
+	// errors listing the volumes are returned as they are
+	b.errs["volumes"] = utils.NewBusyError("vol1", nil)
+	_, err = d.VolumeInspect(ctx, "vol1", &types.VolumeInspectOpts{})
+	assert.Equal(t, b.errs["volumes"], err)
+	assert.Equal(t, b.errs["volumes"], d.HealthCheck(ctx))
+}
+
+func TestVolumeExpand(t *testing.T) {
+	d, b := newTestDriver()
+	ctx := newTestContext()
+	b.create(ctx, "vol1", 2*bytesPerGb)
+
+	vol, err := d.VolumeExpand(ctx, "vol1", 4, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), vol.Size)
+
+	_, err = d.VolumeExpand(ctx, "vol1", 3, nil)
+	assert.Error(t, err)
+	assert.Equal(t, 4*bytesPerGb, b.vols["vol1"].size)
+}
+
+func TestVolumeAttachDetach(t *testing.T) {
+	d, b := newTestDriver()
+	ctx := newTestContext()
+	b.create(ctx, "vol1", bytesPerGb)
+
+	vol, token, err := d.VolumeAttach(ctx, "vol1", &types.VolumeAttachOpts{})
+	assert.NoError(t, err)
+	assert.Equal(t, "/dev/loop0", token)
+	assert.Equal(t, attachedStatus, vol.Status)
+	if assert.Len(t, vol.Attachments, 1) {
+		assert.Equal(t, "host1", vol.Attachments[0].InstanceID.ID)
+		assert.Equal(t, "/dev/loop0", vol.Attachments[0].DeviceName)
+	}
+
+	_, _, err = d.VolumeAttach(ctx, "vol1", &types.VolumeAttachOpts{})
+	assert.Error(t, err)
+
+	// forcing the attachment returns the volume's device
+	_, token, err = d.VolumeAttach(
+		ctx, "vol1", &types.VolumeAttachOpts{Force: true})
+	assert.NoError(t, err)
+	assert.Equal(t, "/dev/loop0", token)
+	assert.Equal(t, 1, b.devs)
+
+	// attachments are listed without devices unless devices are requested
+	vol, err = d.VolumeInspect(ctx, "vol1", &types.VolumeInspectOpts{
+		Attachments: types.VolumeAttachmentsRequested})
+	assert.NoError(t, err)
+	if assert.Len(t, vol.Attachments, 1) {
+		assert.Equal(t, "", vol.Attachments[0].DeviceName)
+	}
+	vol, err = d.VolumeInspect(ctx, "vol1", &types.VolumeInspectOpts{})
+	assert.NoError(t, err)
+	assert.Empty(t, vol.Attachments)
+
+	assert.Error(t, d.VolumeRemove(ctx, "vol1", nil))
+
+	vol, err = d.VolumeDetach(ctx, "vol1", &types.VolumeDetachOpts{})
+	assert.NoError(t, err)
+	assert.Equal(t, availableStatus, vol.Status)
+	assert.Empty(t, vol.Attachments)
+
+	_, err = d.VolumeDetach(ctx, "vol1", &types.VolumeDetachOpts{})
+	assert.Error(t, err)
+
+	b.errs["attach"] = utils.NewBusyError("vol1", nil)
+	_, _, err = d.VolumeAttach(ctx, "vol1", &types.VolumeAttachOpts{})
+	assert.Equal(t, b.errs["attach"], err)
+
+	assert.NoError(t, d.VolumeRemove(ctx, "vol1", nil))
+	assert.Empty(t, b.vols)
+}
+
+func TestSnapshots(t *testing.T) {
+	d, b := newTestDriver()
+	ctx := newTestContext()
+	b.create(ctx, "vol1", 2*bytesPerGb)
+
+	_, err := d.VolumeSnapshot(ctx, "vol1", "snap/1", nil)
+	assert.Error(t, err)
+
+	snap, err := d.VolumeSnapshot(ctx, "vol1", "snap1", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "vol1@snap1", snap.ID)
+	assert.Equal(t, "snap1", snap.Name)
+	assert.Equal(t, "vol1", snap.VolumeID)
+	assert.Equal(t, int64(2), snap.VolumeSize)
+	assert.Equal(t, availableStatus, snap.Status)
+
+	b.snapshot(ctx, b.vols["vol1"], "snap2")
+	snaps, err := d.Snapshots(ctx, nil)
+	assert.NoError(t, err)
+	if assert.Len(t, snaps, 2) {
+		assert.Equal(t, "vol1@snap1", snaps[0].ID)
+		assert.Equal(t, "vol1@snap2", snaps[1].ID)
+	}
+
+	assert.NoError(t, d.SnapshotRemove(ctx, "vol1@snap2", nil))
+	assert.Len(t, b.snaps, 1)
+
+	b.errs["snapshots"] = utils.NewBusyError("vol1@snap1", nil)
+	_, err = d.Snapshots(ctx, nil)
+	assert.Equal(t, b.errs["snapshots"], err)
+}
+
+func TestVolumeCreateFromSnapshot(t *testing.T) {
+	d, b := newTestDriver()
+	ctx := newTestContext()
+	b.create(ctx, "vol1", 2*bytesPerGb)
+	b.snapshot(ctx, b.vols["vol1"], "snap1")
+
+	// the volume is the size of the snapshot unless it is requested larger
+	vol, err := d.VolumeCreateFromSnapshot(
+		ctx, "vol1@snap1", "vol2", &types.VolumeCreateOpts{Size: size(1)})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), vol.Size)
+
+	vol, err = d.VolumeCreateFromSnapshot(
+		ctx, "vol1@snap1", "vol3", &types.VolumeCreateOpts{Size: size(5)})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), vol.Size)
+
+	_, err = d.VolumeCreateFromSnapshot(
+		ctx, "vol1@snap1", "vol3", &types.VolumeCreateOpts{})
+	assert.IsType(t, &types.ErrAlreadyExists{}, err)
+
+	vol, err = d.VolumeCopy(ctx, "vol3", "vol4", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), vol.Size)
+
+	b.errs["clone"] = utils.NewQuotaExceededError("vol5", nil)
+	_, err = d.VolumeCopy(ctx, "vol3", "vol5", nil)
+	assert.Equal(t, types.ErrorCodeQuotaExceeded, types.ErrorCodeOf(err))
+	assert.Len(t, b.vols, 4)
+}
+
+func TestFileBackendSnapshots(t *testing.T) {
+	dir, err := ioutil.TempDir("", "local")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	b := &fileBackend{
+		volumesDir:   path.Join(dir, "vol"),
+		snapshotsDir: path.Join(dir, "snap"),
+	}
+	assert.NoError(t, b.init(nil))
+	assert.NoError(t, b.create(nil, "vol1", bytesPerGb))
+	assert.Error(t, b.create(nil, "vol1", bytesPerGb))
+
+	fi, err := os.Stat(path.Join(dir, "vol", "vol1"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, bytesPerGb, fi.Size())
+	}
+
+	// files without a delimiter are not snapshots
+	for _, name := range []string{"vol1@snap1", "stray"} {
+		err := ioutil.WriteFile(path.Join(dir, "snap", name), nil, 0640)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	snaps, err := b.snapshots(nil)
+	assert.NoError(t, err)
+	if assert.Len(t, snaps, 1) {
+		assert.Equal(t, "vol1@snap1", snaps[0].id)
+		assert.Equal(t, "snap1", snaps[0].name)
+		assert.Equal(t, "vol1", snaps[0].volumeID)
+		assert.Equal(t, path.Join(dir, "snap", "vol1@snap1"), snaps[0].path)
+	}
+
+	assert.NoError(t, b.removeSnapshot(nil, "vol1@snap1"))
+	assert.NoError(t, b.remove(nil, "vol1"))
+	assert.Error(t, b.remove(nil, "vol1"))
+}
+
+func TestSizeArg(t *testing.T) {
+	assert.Equal(t, "1073741824b", sizeArg(bytesPerGb))
+}
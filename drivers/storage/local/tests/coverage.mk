@@ -0,0 +1,2 @@
+LOCAL_COVERPKG := $(ROOT_IMPORT_PATH)/drivers/storage/local
+TEST_COVERPKG_./drivers/storage/local/tests := $(LOCAL_COVERPKG),$(LOCAL_COVERPKG)/executor,$(LOCAL_COVERPKG)/storage
@@ -0,0 +1,191 @@
+package local
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"testing"
+
+	gofig "github.com/akutz/gofig/types"
+	"github.com/akutz/gotil"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codedellemc/libstorage/api/server"
+	apitests "github.com/codedellemc/libstorage/api/tests"
+	"github.com/codedellemc/libstorage/api/types"
+
+	// load the driver
+	"github.com/codedellemc/libstorage/drivers/storage/local"
+	localx "github.com/codedellemc/libstorage/drivers/storage/local/executor"
+	_ "github.com/codedellemc/libstorage/drivers/storage/local/storage"
+)
+
+// skipTests returns a flag indicating whether the tests are skipped. The
+// tests manage sparse files in a temporary directory and require the
+// losetup command.
+func skipTests() bool {
+	noTest, _ := strconv.ParseBool(os.Getenv("TEST_SKIP_LOCAL"))
+	return noTest || !gotil.FileExistsInPath("losetup")
+}
+
+// skipAttachTests returns a flag indicating whether the tests that attach
+// volumes to loop devices are skipped, which requires root.
+func skipAttachTests() bool {
+	return skipTests() || os.Geteuid() != 0
+}
+
+var (
+	volumeName string
+	configYAML []byte
+)
+
+func init() {
+	uuid, _ := types.NewUUID()
+	volumeName = "ls-test-" + uuid.String()[:8]
+}
+
+func TestMain(m *testing.M) {
+	server.CloseOnAbort()
+
+	rootDir, err := ioutil.TempDir("", "libstorage-local")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	configYAML = []byte(fmt.Sprintf("local:\n  rootDir: %s\n", rootDir))
+
+	ec := m.Run()
+	os.RemoveAll(rootDir)
+	os.Exit(ec)
+}
+
+func TestInstanceID(t *testing.T) {
+	if skipTests() {
+		t.SkipNow()
+	}
+
+	iid, err := localx.InstanceID()
+	assert.NoError(t, err)
+	if err != nil {
+		t.FailNow()
+	}
+
+	apitests.Run(
+		t, local.Name, configYAML,
+		(&apitests.InstanceIDTest{
+			Driver:   local.Name,
+			Expected: iid,
+		}).Test)
+}
+
+func TestVolumeCreateRemove(t *testing.T) {
+	if skipTests() {
+		t.SkipNow()
+	}
+
+	tf := func(config gofig.Config, client types.Client, t *testing.T) {
+		size := int64(1)
+		vol, err := client.API().VolumeCreate(
+			nil, local.Name, &types.VolumeCreateRequest{
+				Name: volumeName,
+				Size: &size,
+			})
+		assert.NoError(t, err)
+		if err != nil {
+			t.FailNow()
+		}
+		assert.Equal(t, volumeName, vol.ID)
+		assert.Equal(t, size, vol.Size)
+
+		assert.NoError(t, client.API().VolumeRemove(
+			nil, local.Name, volumeName))
+	}
+	apitests.Run(t, local.Name, configYAML, tf)
+}
+
+func TestVolumeSnapshotCreateFromSnapshot(t *testing.T) {
+	if skipTests() {
+		t.SkipNow()
+	}
+
+	tf := func(config gofig.Config, client types.Client, t *testing.T) {
+		size := int64(1)
+		_, err := client.API().VolumeCreate(
+			nil, local.Name, &types.VolumeCreateRequest{
+				Name: volumeName,
+				Size: &size,
+			})
+		assert.NoError(t, err)
+		if err != nil {
+			t.FailNow()
+		}
+		defer client.API().VolumeRemove(nil, local.Name, volumeName)
+
+		snap, err := client.API().VolumeSnapshot(
+			nil, local.Name, volumeName,
+			&types.VolumeSnapshotRequest{SnapshotName: "snap1"})
+		assert.NoError(t, err)
+		if err != nil {
+			t.FailNow()
+		}
+		assert.Equal(t, volumeName+"@snap1", snap.ID)
+		assert.Equal(t, volumeName, snap.VolumeID)
+
+		newSize := int64(2)
+		vol, err := client.API().VolumeCreateFromSnapshot(
+			nil, local.Name, snap.ID,
+			&types.VolumeCreateRequest{
+				Name: volumeName + "-clone",
+				Size: &newSize,
+			})
+		assert.NoError(t, err)
+		if err != nil {
+			t.FailNow()
+		}
+		assert.Equal(t, newSize, vol.Size)
+
+		assert.NoError(t, client.API().VolumeRemove(
+			nil, local.Name, vol.ID))
+		assert.NoError(t, client.API().SnapshotRemove(
+			nil, local.Name, snap.ID))
+	}
+	apitests.Run(t, local.Name, configYAML, tf)
+}
+
+func TestVolumeAttachDetach(t *testing.T) {
+	if skipAttachTests() {
+		t.SkipNow()
+	}
+
+	tf := func(config gofig.Config, client types.Client, t *testing.T) {
+		size := int64(1)
+		_, err := client.API().VolumeCreate(
+			nil, local.Name, &types.VolumeCreateRequest{
+				Name: volumeName,
+				Size: &size,
+			})
+		assert.NoError(t, err)
+		if err != nil {
+			t.FailNow()
+		}
+		defer client.API().VolumeRemove(nil, local.Name, volumeName)
+
+		vol, token, err := client.API().VolumeAttach(
+			nil, local.Name, volumeName, &types.VolumeAttachRequest{})
+		assert.NoError(t, err)
+		if err != nil {
+			t.FailNow()
+		}
+		assert.NotEmpty(t, token)
+		if assert.Len(t, vol.Attachments, 1) {
+			assert.Equal(t, token, vol.Attachments[0].DeviceName)
+		}
+
+		vol, err = client.API().VolumeDetach(
+			nil, local.Name, volumeName, &types.VolumeDetachRequest{})
+		assert.NoError(t, err)
+		assert.Len(t, vol.Attachments, 0)
+	}
+	apitests.Run(t, local.Name, configYAML, tf)
+}
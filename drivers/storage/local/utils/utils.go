@@ -0,0 +1,138 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/akutz/goof"
+
+	"github.com/codedellemc/libstorage/api/types"
+	"github.com/codedellemc/libstorage/api/utils"
+)
+
+const (
+	lvsSeparator = "|"
+
+	// deletedSuffix is appended by losetup to the backing file of a loop
+	// device whose file has been removed.
+	deletedSuffix = " (deleted)"
+)
+
+// LV is a logical volume.
+type LV struct {
+	Name   string
+	Path   string
+	Origin string
+	Size   int64
+	Tags   []string
+}
+
+// HasTag returns a flag indicating whether or not the logical volume has
+// the specified tag.
+func (lv *LV) HasTag(tag string) bool {
+	for _, t := range lv.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// Exec executes a command and returns the command's standard output. The
+// command's standard error is included in the returned error if the command
+// fails.
+func Exec(ctx types.Context, name string, args ...string) ([]byte, error) {
+	if ctx != nil {
+		ctx.WithField("args", args).Debugf("executing %s", name)
+	}
+
+	stderr := &bytes.Buffer{}
+	cmd := exec.Command(name, args...)
+	cmd.Stderr = stderr
+
+	out, err := utils.CommandOutput(ctx, cmd)
+	if err != nil {
+		return nil, goof.WithFieldsE(goof.Fields{
+			"cmd":    name,
+			"args":   args,
+			"stderr": strings.TrimSpace(stderr.String()),
+		}, "error executing command", err)
+	}
+	return out, nil
+}
+
+// LVs returns the logical volumes in a volume group.
+func LVs(ctx types.Context, volumeGroup string) ([]*LV, error) {
+	out, err := Exec(ctx, "lvs",
+		"--noheadings", "--nosuffix", "--units", "b",
+		"--separator", lvsSeparator,
+		"-o", "lv_name,lv_path,origin,lv_size,lv_tags",
+		volumeGroup)
+	if err != nil {
+		return nil, err
+	}
+	return ParseLVs(out)
+}
+
+// ParseLVs parses the output of the lvs command.
+func ParseLVs(buf []byte) ([]*LV, error) {
+	lvs := []*LV{}
+	scanner := bufio.NewScanner(bytes.NewReader(buf))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		fields := strings.Split(line, lvsSeparator)
+		if len(fields) != 5 {
+			return nil, goof.WithField("line", line, "invalid lvs output")
+		}
+
+		size, err := strconv.ParseInt(fields[3], 10, 64)
+		if err != nil {
+			return nil, goof.WithFieldE("line", line, "invalid lvs size", err)
+		}
+
+		lv := &LV{
+			Name:   fields[0],
+			Path:   fields[1],
+			Origin: fields[2],
+			Size:   size,
+		}
+		if fields[4] != "" {
+			lv.Tags = strings.Split(fields[4], ",")
+		}
+		lvs = append(lvs, lv)
+	}
+	return lvs, scanner.Err()
+}
+
+// LoopDevices returns the backing files of the loop devices, keyed by their
+// devices.
+func LoopDevices(ctx types.Context) (map[string]string, error) {
+	out, err := Exec(ctx, "losetup",
+		"--list", "--noheadings", "--output", "NAME,BACK-FILE")
+	if err != nil {
+		return nil, err
+	}
+	return ParseLoopDevices(out), nil
+}
+
+// ParseLoopDevices parses the output of the losetup --list command.
+func ParseLoopDevices(buf []byte) map[string]string {
+	devs := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(buf))
+	for scanner.Scan() {
+		fields := strings.SplitN(strings.TrimSpace(scanner.Text()), " ", 2)
+		if len(fields) != 2 {
+			continue
+		}
+		devs[fields[0]] = strings.TrimSuffix(
+			strings.TrimSpace(fields[1]), deletedSuffix)
+	}
+	return devs
+}
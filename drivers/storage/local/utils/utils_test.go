@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLVs(t *testing.T) {
+	lvs, err := ParseLVs([]byte(`
+  vol1|/dev/vg0/vol1||1073741824|libstorage_attached
+  vol2|/dev/vg0/vol2||2147483648|
+  snap1|/dev/vg0/snap1|vol2|2147483648|
+`))
+	assert.NoError(t, err)
+	if !assert.Len(t, lvs, 3) {
+		t.FailNow()
+	}
+
+	assert.Equal(t, "vol1", lvs[0].Name)
+	assert.Equal(t, "/dev/vg0/vol1", lvs[0].Path)
+	assert.Equal(t, int64(1073741824), lvs[0].Size)
+	assert.True(t, lvs[0].HasTag("libstorage_attached"))
+
+	assert.False(t, lvs[1].HasTag("libstorage_attached"))
+	assert.Equal(t, "", lvs[1].Origin)
+
+	assert.Equal(t, "vol2", lvs[2].Origin)
+
+	_, err = ParseLVs([]byte("vol1|/dev/vg0/vol1\n"))
+	assert.Error(t, err)
+}
+
+func TestParseLoopDevices(t *testing.T) {
+	devs := ParseLoopDevices([]byte(`/dev/loop0 /var/lib/libstorage/local/vol/vol1
+/dev/loop1 /var/lib/libstorage/local/vol/my vol (deleted)
+`))
+	assert.Equal(t, map[string]string{
+		"/dev/loop0": "/var/lib/libstorage/local/vol/vol1",
+		"/dev/loop1": "/var/lib/libstorage/local/vol/my vol",
+	}, devs)
+}
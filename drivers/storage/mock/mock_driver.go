@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	log "github.com/Sirupsen/logrus"
+	gofig "github.com/akutz/gofig/types"
 	"github.com/akutz/goof"
 
 	"github.com/codedellemc/libstorage/api/context"
@@ -27,6 +28,7 @@ type driver struct {
 	volumes        []*types.Volume
 	snapshots      []*types.Snapshot
 	storageType    types.StorageType
+	faults         *faults
 }
 
 func init() {
@@ -91,6 +93,37 @@ func newDriver() types.StorageDriver {
 	return d
 }
 
+func (d *driver) Init(ctx types.Context, config gofig.Config) error {
+	if err := d.Executor.Init(ctx, config); err != nil {
+		return err
+	}
+	f, err := newFaults(ctx, config)
+	if err != nil {
+		return err
+	}
+	d.faults = f
+	return nil
+}
+
+// volumeCreateOptsSchema is the JSON schema of the options the driver
+// accepts when creating a volume.
+var volumeCreateOptsSchema = []byte(`{
+    "$schema": "http://json-schema.org/draft-04/schema#",
+    "type": "object",
+    "properties": {
+        "owner": { "type": "string" },
+        "priority": { "type": "integer" }
+    },
+    "additionalProperties": false
+}`)
+
+func (d *driver) OptsSchema(ctx types.Context, operation string) []byte {
+	if operation == "volumeCreate" {
+		return volumeCreateOptsSchema
+	}
+	return nil
+}
+
 func (d *driver) Type(ctx types.Context) (types.StorageType, error) {
 	return types.Block, nil
 }
@@ -111,6 +144,10 @@ func (d *driver) Volumes(
 	ctx types.Context,
 	opts *types.VolumesOpts) ([]*types.Volume, error) {
 
+	if err := d.faults.inject(ctx, "volumes"); err != nil {
+		return nil, err
+	}
+
 	xiid := executor.GetInstanceID()
 
 	if serviceName, ok := context.ServiceName(ctx); ok && serviceName == Name {
@@ -159,6 +196,10 @@ func (d *driver) VolumeInspect(
 	volumeID string,
 	opts *types.VolumeInspectOpts) (*types.Volume, error) {
 
+	if err := d.faults.inject(ctx, "volumeInspect"); err != nil {
+		return nil, err
+	}
+
 	for _, v := range d.volumes {
 		if strings.ToLower(v.ID) == strings.ToLower(volumeID) {
 			return v, nil
@@ -172,6 +213,10 @@ func (d *driver) VolumeCreate(
 	name string,
 	opts *types.VolumeCreateOpts) (*types.Volume, error) {
 
+	if err := d.faults.inject(ctx, "volumeCreate"); err != nil {
+		return nil, err
+	}
+
 	if name == "Volume 010" {
 		return nil, goof.WithFieldE(
 			"iops", opts.IOPS,
@@ -183,6 +228,13 @@ func (d *driver) VolumeCreate(
 			),
 		)
 	}
+
+	if opts.Size != nil {
+		if err := d.faults.reserve(d.volumes, *opts.Size); err != nil {
+			return nil, err
+		}
+	}
+
 	lenVols := len(d.volumes)
 
 	volume := &types.Volume{
@@ -223,6 +275,10 @@ func (d *driver) VolumeCreateFromSnapshot(
 	snapshotID, volumeName string,
 	opts *types.VolumeCreateOpts) (*types.Volume, error) {
 
+	if err := d.faults.inject(ctx, "volumeCreateFromSnapshot"); err != nil {
+		return nil, err
+	}
+
 	s, err := d.SnapshotInspect(ctx, snapshotID, nil)
 	if err != nil {
 		return nil, err
@@ -268,6 +324,10 @@ func (d *driver) VolumeCopy(
 	volumeID, volumeName string,
 	opts types.Store) (*types.Volume, error) {
 
+	if err := d.faults.inject(ctx, "volumeCopy"); err != nil {
+		return nil, err
+	}
+
 	ctx.WithFields(log.Fields{
 		"volumeID":   volumeID,
 		"volumeName": volumeName,
@@ -302,11 +362,40 @@ func (d *driver) VolumeCopy(
 
 }
 
+func (d *driver) VolumeExpand(
+	ctx types.Context,
+	volumeID string,
+	newSize int64,
+	opts types.Store) (*types.Volume, error) {
+
+	if err := d.faults.inject(ctx, "volumeExpand"); err != nil {
+		return nil, err
+	}
+
+	ctx.WithFields(log.Fields{
+		"volumeID": volumeID,
+		"newSize":  newSize,
+	}).Debug("mockDriver.VolumeExpand")
+
+	for _, v := range d.volumes {
+		if strings.ToLower(v.ID) == strings.ToLower(volumeID) {
+			v.Size = newSize
+			return v, nil
+		}
+	}
+
+	return nil, utils.NewNotFoundError(volumeID)
+}
+
 func (d *driver) VolumeSnapshot(
 	ctx types.Context,
 	volumeID, snapshotName string,
 	opts types.Store) (*types.Snapshot, error) {
 
+	if err := d.faults.inject(ctx, "volumeSnapshot"); err != nil {
+		return nil, err
+	}
+
 	ctx.WithFields(log.Fields{
 		"volumeID":     volumeID,
 		"snapshotName": snapshotName,
@@ -331,6 +420,10 @@ func (d *driver) VolumeRemove(
 	volumeID string,
 	opts types.Store) error {
 
+	if err := d.faults.inject(ctx, "volumeRemove"); err != nil {
+		return err
+	}
+
 	ctx.WithFields(log.Fields{
 		"volumeID": volumeID,
 	}).Debug("mockDriver.VolumeRemove")
@@ -359,6 +452,10 @@ func (d *driver) VolumeAttach(
 	volumeID string,
 	opts *types.VolumeAttachOpts) (*types.Volume, string, error) {
 
+	if err := d.faults.inject(ctx, "volumeAttach"); err != nil {
+		return nil, "", err
+	}
+
 	var modVol *types.Volume
 	for _, vol := range d.volumes {
 		if vol.ID == volumeID {
@@ -385,6 +482,10 @@ func (d *driver) VolumeDetach(
 	volumeID string,
 	opts *types.VolumeDetachOpts) (*types.Volume, error) {
 
+	if err := d.faults.inject(ctx, "volumeDetach"); err != nil {
+		return nil, err
+	}
+
 	var modVol *types.Volume
 	for _, vol := range d.volumes {
 		if vol.ID == volumeID {
@@ -403,6 +504,10 @@ func (d *driver) VolumeDetachAll(
 	volumeID string,
 	opts types.Store) error {
 
+	if err := d.faults.inject(ctx, "volumeDetachAll"); err != nil {
+		return err
+	}
+
 	for _, vol := range d.volumes {
 		vol.Attachments = nil
 	}
@@ -414,6 +519,10 @@ func (d *driver) Snapshots(
 	ctx types.Context,
 	opts types.Store) ([]*types.Snapshot, error) {
 
+	if err := d.faults.inject(ctx, "snapshots"); err != nil {
+		return nil, err
+	}
+
 	return d.snapshots, nil
 }
 
@@ -422,6 +531,10 @@ func (d *driver) SnapshotInspect(
 	snapshotID string,
 	opts types.Store) (*types.Snapshot, error) {
 
+	if err := d.faults.inject(ctx, "snapshotInspect"); err != nil {
+		return nil, err
+	}
+
 	for _, v := range d.snapshots {
 		if strings.ToLower(v.ID) == strings.ToLower(snapshotID) {
 			return v, nil
@@ -435,6 +548,10 @@ func (d *driver) SnapshotCopy(
 	snapshotID, snapshotName, destinationID string,
 	opts types.Store) (*types.Snapshot, error) {
 
+	if err := d.faults.inject(ctx, "snapshotCopy"); err != nil {
+		return nil, err
+	}
+
 	ctx.WithFields(log.Fields{
 		"snapshotID":    snapshotID,
 		"snapshotName":  snapshotName,
@@ -472,6 +589,10 @@ func (d *driver) SnapshotRemove(
 	snapshotID string,
 	opts types.Store) error {
 
+	if err := d.faults.inject(ctx, "snapshotRemove"); err != nil {
+		return err
+	}
+
 	ctx.WithFields(log.Fields{
 		"snapshotID": snapshotID,
 	}).Debug("mockDriver.SnapshotRemove")
@@ -0,0 +1,156 @@
+// +build mock
+
+package mock
+
+import (
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	gofig "github.com/akutz/gofig/types"
+	"github.com/akutz/goof"
+
+	"github.com/codedellemc/libstorage/api/types"
+	"github.com/codedellemc/libstorage/api/utils"
+)
+
+const (
+	// ConfigLatency is a config key.
+	ConfigLatency = Name + ".latency"
+
+	// ConfigErrorRate is a config key.
+	ConfigErrorRate = Name + ".errorRate"
+
+	// ConfigErrorCode is a config key.
+	ConfigErrorCode = Name + ".errorCode"
+
+	// ConfigFaultOperations is a config key.
+	ConfigFaultOperations = Name + ".faultOperations"
+
+	// ConfigCapacity is a config key.
+	ConfigCapacity = Name + ".capacity"
+)
+
+// faults are the faults the driver injects into its operations so that the
+// features of the server and client that do not depend on a storage platform,
+// such as retries and the task queue, can be tested against slow or failing
+// storage platforms.
+type faults struct {
+	// latency is the duration each operation takes.
+	latency time.Duration
+
+	// errorRate is the probability, from 0 to 1, that an operation fails.
+	errorRate float64
+
+	// errorCode is the code of the errors of the failed operations.
+	errorCode types.ErrorCode
+
+	// operations are the names of the operations that may fail. All
+	// operations may fail if there are none.
+	operations map[string]bool
+
+	// capacity is the total size of the volumes that may be created. The
+	// capacity is unlimited if it is zero.
+	capacity int64
+}
+
+func newFaults(ctx types.Context, config gofig.Config) (*faults, error) {
+	f := &faults{
+		errorCode:  types.ErrorCodeBusy,
+		operations: map[string]bool{},
+	}
+
+	if v := config.GetString(ConfigLatency); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, goof.WithFieldE(
+				ConfigLatency, v, "invalid latency", err)
+		}
+		f.latency = d
+	}
+
+	if v := config.GetString(ConfigErrorRate); v != "" {
+		r, err := strconv.ParseFloat(v, 64)
+		if err != nil || r < 0 || r > 1 {
+			return nil, goof.WithField(
+				ConfigErrorRate, v, "invalid error rate")
+		}
+		f.errorRate = r
+	}
+
+	if v := config.GetString(ConfigErrorCode); v != "" {
+		f.errorCode = types.ErrorCode(v)
+	}
+
+	for _, op := range config.GetStringSlice(ConfigFaultOperations) {
+		f.operations[strings.ToLower(op)] = true
+	}
+
+	f.capacity = int64(config.GetInt(ConfigCapacity))
+
+	ctx.WithFields(log.Fields{
+		"latency":    f.latency,
+		"errorRate":  f.errorRate,
+		"errorCode":  f.errorCode,
+		"operations": config.GetStringSlice(ConfigFaultOperations),
+		"capacity":   f.capacity,
+	}).Debug("configured mock faults")
+
+	return f, nil
+}
+
+// inject waits for the configured latency and then fails the operation at
+// the configured error rate. An error is also returned if the context is
+// cancelled while waiting.
+func (f *faults) inject(ctx types.Context, op string) error {
+	if f == nil {
+		return nil
+	}
+
+	if f.latency > 0 {
+		select {
+		case <-time.After(f.latency):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	if f.errorRate == 0 {
+		return nil
+	}
+	if len(f.operations) > 0 && !f.operations[strings.ToLower(op)] {
+		return nil
+	}
+	if rand.Float64() >= f.errorRate {
+		return nil
+	}
+
+	ctx.WithField("operation", op).Debug("injecting mock fault")
+	return utils.NewErrorCodeError(
+		f.errorCode, "",
+		goof.WithField("operation", op, "injected fault"))
+}
+
+// reserve returns an error if creating a volume of the specified size would
+// exceed the configured capacity.
+func (f *faults) reserve(volumes []*types.Volume, size int64) error {
+	if f == nil || f.capacity == 0 {
+		return nil
+	}
+	used := size
+	for _, v := range volumes {
+		used += v.Size
+	}
+	if used <= f.capacity {
+		return nil
+	}
+	return utils.NewErrorCodeError(
+		types.ErrorCodeQuotaExceeded, "",
+		goof.WithFields(goof.Fields{
+			"capacity": f.capacity,
+			"used":     used - size,
+			"size":     size,
+		}, "insufficient capacity"))
+}
@@ -318,6 +318,84 @@ func TestVolumeCreateWithError(t *testing.T) {
 	apitests.Run(t, mock.Name, configYAML, tf)
 }
 
+func TestVolumeCreateWithFault(t *testing.T) {
+
+	faultConfigYAML := []byte(`
+libstorage:
+  driver: mock
+  client:
+    retries: 0
+mock:
+  latency: 10ms
+  errorRate: 1
+  faultOperations: volumeCreate
+`)
+
+	tf := func(config gofig.Config, client types.Client, t *testing.T) {
+		_, err := client.API().VolumeCreate(
+			nil, mock.Name, &types.VolumeCreateRequest{Name: "Volume 011"})
+		assert.Error(t, err)
+		assert.True(t, types.IsErrorCode(err, types.ErrorCodeBusy))
+
+		_, err = client.API().VolumeInspect(nil, mock.Name, "vol-000", false)
+		assert.NoError(t, err)
+	}
+	apitests.Run(t, mock.Name, faultConfigYAML, tf)
+}
+
+func TestVolumeCreateWithCapacity(t *testing.T) {
+
+	capacityConfigYAML := []byte(`
+libstorage:
+  driver: mock
+mock:
+  capacity: 250000
+`)
+
+	tf := func(config gofig.Config, client types.Client, t *testing.T) {
+		size := int64(10240)
+		_, err := client.API().VolumeCreate(
+			nil, mock.Name,
+			&types.VolumeCreateRequest{Name: "Volume 011", Size: &size})
+		assert.NoError(t, err)
+
+		size = 40960
+		_, err = client.API().VolumeCreate(
+			nil, mock.Name,
+			&types.VolumeCreateRequest{Name: "Volume 012", Size: &size})
+		assert.Error(t, err)
+		assert.True(t, types.IsErrorCode(err, types.ErrorCodeQuotaExceeded))
+	}
+	apitests.Run(t, mock.Name, capacityConfigYAML, tf)
+}
+
+func TestVolumeCreateWithInvalidOpts(t *testing.T) {
+
+	tf := func(config gofig.Config, client types.Client, t *testing.T) {
+		for _, opts := range []map[string]interface{}{
+			{"priorty": 2},
+			{"priority": "high"},
+		} {
+			_, err := client.API().VolumeCreate(
+				nil, mock.Name,
+				&types.VolumeCreateRequest{Name: "Volume 013", Opts: opts})
+			assert.Error(t, err)
+			if httpErr, ok := err.(goof.HTTPError); assert.True(t, ok) {
+				assert.Equal(t, 400, httpErr.Status())
+			}
+		}
+
+		_, err := client.API().VolumeCreate(
+			nil, mock.Name,
+			&types.VolumeCreateRequest{
+				Name: "Volume 013",
+				Opts: map[string]interface{}{"priority": 2},
+			})
+		assert.NoError(t, err)
+	}
+	apitests.Run(t, mock.Name, configYAML, tf)
+}
+
 func TestVolumeRemove(t *testing.T) {
 
 	tf1 := func(config gofig.Config, client types.Client, t *testing.T) {
@@ -0,0 +1,23 @@
+package nfs
+
+import (
+	gofigCore "github.com/akutz/gofig"
+	gofig "github.com/akutz/gofig/types"
+)
+
+const (
+	// Name is the provider's name.
+	Name = "nfs"
+)
+
+func init() {
+	r := gofigCore.NewRegistration("NFS")
+	r.Key(gofig.String, "", "",
+		"The host name or address of the NFS server", "nfs.host")
+	r.Key(gofig.String, "", "",
+		"The path of the NFS server's export", "nfs.exportPath")
+	r.Key(gofig.String, "", "",
+		"The path at which the export is mounted on the libStorage server",
+		"nfs.mountPath")
+	gofigCore.Register(r)
+}
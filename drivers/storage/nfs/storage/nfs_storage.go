@@ -0,0 +1,471 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"sync"
+
+	log "github.com/Sirupsen/logrus"
+	gofig "github.com/akutz/gofig/types"
+	"github.com/akutz/goof"
+
+	"github.com/codedellemc/libstorage/api/context"
+	"github.com/codedellemc/libstorage/api/registry"
+	"github.com/codedellemc/libstorage/api/types"
+	"github.com/codedellemc/libstorage/api/utils"
+	"github.com/codedellemc/libstorage/drivers/storage/nfs"
+)
+
+const (
+	// metaDirName is the name of the directory in the export's root in which
+	// the volumes' attachments are recorded. Since its name begins with a dot
+	// it is never listed as a volume.
+	metaDirName = ".libstorage"
+
+	attachedStatus = "attached"
+)
+
+// driver is a storage driver that manages volumes as the subdirectories of
+// an NFS export. The export is mounted on the libStorage server at the
+// configured mount path, and the volumes are attached by exporting them to
+// the clients as subdirectories of the export.
+type driver struct {
+	sync.Mutex
+	config gofig.Config
+}
+
+func init() {
+	registry.RegisterStorageDriver(nfs.Name, newDriver)
+}
+
+func newDriver() types.StorageDriver {
+	return &driver{}
+}
+
+// Name returns the name of the driver
+func (d *driver) Name() string {
+	return nfs.Name
+}
+
+// Init initializes the driver.
+func (d *driver) Init(ctx types.Context, config gofig.Config) error {
+	d.config = config
+
+	fields := log.Fields{
+		"host":       d.host(),
+		"exportPath": d.exportPath(),
+		"mountPath":  d.mountPath(),
+	}
+
+	if d.host() == "" || d.exportPath() == "" || d.mountPath() == "" {
+		return goof.WithFields(fields,
+			"nfs.host, nfs.exportPath, and nfs.mountPath are required")
+	}
+
+	fi, err := os.Stat(d.mountPath())
+	if err != nil {
+		return goof.WithFieldsE(fields, "invalid mount path", err)
+	}
+	if !fi.IsDir() {
+		return goof.WithFields(fields, "mount path is not a directory")
+	}
+
+	if err := os.MkdirAll(d.metaDirPath(), 0755); err != nil {
+		return goof.WithFieldsE(fields, "error creating meta dir", err)
+	}
+
+	ctx.WithFields(fields).Info("storage driver initialized")
+	return nil
+}
+
+// Type returns the type of storage a driver provides
+func (d *driver) Type(ctx types.Context) (types.StorageType, error) {
+	return types.NAS, nil
+}
+
+// Capabilities advertises NAS attach semantics. A volume is a subdirectory
+// of the export, and any number of clients may mount it at once.
+func (d *driver) Capabilities(
+	ctx types.Context) (*types.StorageCapabilities, error) {
+	return &types.StorageCapabilities{
+		AttachSemantics: types.NAS,
+	}, nil
+}
+
+// NextDeviceInfo returns the information about the driver's next available
+// device workflow.
+func (d *driver) NextDeviceInfo(
+	ctx types.Context) (*types.NextDeviceInfo, error) {
+	return nil, nil
+}
+
+// InstanceInspect returns an instance.
+func (d *driver) InstanceInspect(
+	ctx types.Context,
+	opts types.Store) (*types.Instance, error) {
+
+	iid := context.MustInstanceID(ctx)
+	return &types.Instance{Name: iid.ID, InstanceID: iid}, nil
+}
+
+func (d *driver) Volumes(
+	ctx types.Context,
+	opts *types.VolumesOpts) ([]*types.Volume, error) {
+
+	fis, err := ioutil.ReadDir(d.mountPath())
+	if err != nil {
+		return nil, err
+	}
+
+	volumes := []*types.Volume{}
+	for _, fi := range fis {
+		if !fi.IsDir() || strings.HasPrefix(fi.Name(), ".") {
+			continue
+		}
+		v, err := d.getVolume(fi.Name(), opts.Attachments)
+		if err != nil {
+			return nil, err
+		}
+		volumes = append(volumes, v)
+	}
+
+	return utils.SortVolumeByID(volumes), nil
+}
+
+func (d *driver) VolumeInspect(
+	ctx types.Context,
+	volumeID string,
+	opts *types.VolumeInspectOpts) (*types.Volume, error) {
+
+	if !d.volumeExists(volumeID) {
+		return nil, utils.NewNotFoundError(volumeID)
+	}
+	return d.getVolume(volumeID, opts.Attachments)
+}
+
+// VolumeCreate creates a new volume by creating a directory in the export.
+func (d *driver) VolumeCreate(ctx types.Context, volumeName string,
+	opts *types.VolumeCreateOpts) (*types.Volume, error) {
+
+	if !isValidVolumeName(volumeName) {
+		return nil, goof.WithField(
+			"volumeName", volumeName, "invalid volume name")
+	}
+
+	d.Lock()
+	defer d.Unlock()
+
+	if d.volumeExists(volumeName) {
+		return nil, goof.WithField(
+			"volumeName", volumeName, "volume name already exists")
+	}
+
+	if err := os.Mkdir(d.volumePath(volumeName), 0777); err != nil {
+		return nil, goof.WithFieldE(
+			"volumeName", volumeName, "error creating volume", err)
+	}
+
+	ctx.WithField("volumeName", volumeName).Info("created volume")
+	return d.getVolume(volumeName, 0)
+}
+
+// VolumeRemove removes a volume along with its contents.
+func (d *driver) VolumeRemove(
+	ctx types.Context,
+	volumeID string,
+	opts types.Store) error {
+
+	d.Lock()
+	defer d.Unlock()
+
+	if !d.volumeExists(volumeID) {
+		return utils.NewNotFoundError(volumeID)
+	}
+
+	if err := os.RemoveAll(d.volumePath(volumeID)); err != nil {
+		return goof.WithFieldE(
+			"volumeID", volumeID, "error removing volume", err)
+	}
+
+	if err := os.Remove(d.metaFilePath(volumeID)); err != nil &&
+		!os.IsNotExist(err) {
+		return goof.WithFieldE(
+			"volumeID", volumeID, "error removing volume attachments", err)
+	}
+
+	ctx.WithField("volumeID", volumeID).Info("removed volume")
+	return nil
+}
+
+// VolumeAttach records the instance in the volume's attachment file in the
+// export's .libstorage directory. No token is returned; the client mounts
+// the subdirectory named by the attachment's device. A forced attachment
+// replaces the instances already recorded for the volume.
+func (d *driver) VolumeAttach(
+	ctx types.Context,
+	volumeID string,
+	opts *types.VolumeAttachOpts) (*types.Volume, string, error) {
+
+	d.Lock()
+	defer d.Unlock()
+
+	if !d.volumeExists(volumeID) {
+		return nil, "", utils.NewNotFoundError(volumeID)
+	}
+
+	iid := context.MustInstanceID(ctx)
+
+	iids, err := d.readAttachments(volumeID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if opts.Force {
+		iids = nil
+	}
+
+	attached := false
+	for _, i := range iids {
+		if i.ID == iid.ID {
+			attached = true
+			break
+		}
+	}
+	if !attached {
+		iids = append(iids, &types.InstanceID{ID: iid.ID, Driver: nfs.Name})
+	}
+
+	if err := d.writeAttachments(volumeID, iids); err != nil {
+		return nil, "", err
+	}
+
+	ctx.WithFields(log.Fields{
+		"volumeID": volumeID,
+		"device":   d.device(volumeID),
+	}).Info("attached volume")
+
+	vol, err := d.getVolume(volumeID, types.VolumeAttachmentsTrue)
+	if err != nil {
+		return nil, "", err
+	}
+	return vol, "", nil
+}
+
+// VolumeDetach detaches a volume from the instance, or from all instances
+// if the detachment is forced.
+func (d *driver) VolumeDetach(
+	ctx types.Context,
+	volumeID string,
+	opts *types.VolumeDetachOpts) (*types.Volume, error) {
+
+	d.Lock()
+	defer d.Unlock()
+
+	if !d.volumeExists(volumeID) {
+		return nil, utils.NewNotFoundError(volumeID)
+	}
+
+	iid := context.MustInstanceID(ctx)
+
+	iids, err := d.readAttachments(volumeID)
+	if err != nil {
+		return nil, err
+	}
+
+	var newIIDs []*types.InstanceID
+	if !opts.Force {
+		for _, i := range iids {
+			if i.ID != iid.ID {
+				newIIDs = append(newIIDs, i)
+			}
+		}
+	}
+
+	if err := d.writeAttachments(volumeID, newIIDs); err != nil {
+		return nil, err
+	}
+
+	ctx.WithField("volumeID", volumeID).Info("detached volume")
+	return d.getVolume(volumeID, types.VolumeAttachmentsTrue)
+}
+
+// VolumeCreateFromSnapshot (not implemented).
+func (d *driver) VolumeCreateFromSnapshot(
+	ctx types.Context,
+	snapshotID, volumeName string,
+	opts *types.VolumeCreateOpts) (*types.Volume, error) {
+	return nil, types.ErrNotImplemented
+}
+
+// VolumeCopy copies an existing volume (not implemented)
+func (d *driver) VolumeCopy(
+	ctx types.Context,
+	volumeID, volumeName string,
+	opts types.Store) (*types.Volume, error) {
+	return nil, types.ErrNotImplemented
+}
+
+// VolumeExpand expands a volume (not implemented)
+func (d *driver) VolumeExpand(
+	ctx types.Context,
+	volumeID string,
+	newSize int64,
+	opts types.Store) (*types.Volume, error) {
+	return nil, types.ErrNotImplemented
+}
+
+// VolumeSnapshot snapshots a volume (not implemented)
+func (d *driver) VolumeSnapshot(
+	ctx types.Context,
+	volumeID, snapshotName string,
+	opts types.Store) (*types.Snapshot, error) {
+	return nil, types.ErrNotImplemented
+}
+
+func (d *driver) Snapshots(
+	ctx types.Context,
+	opts types.Store) ([]*types.Snapshot, error) {
+	return nil, nil
+}
+
+func (d *driver) SnapshotInspect(
+	ctx types.Context,
+	snapshotID string,
+	opts types.Store) (*types.Snapshot, error) {
+	return nil, types.ErrNotImplemented
+}
+
+func (d *driver) SnapshotCopy(
+	ctx types.Context,
+	snapshotID, snapshotName, destinationID string,
+	opts types.Store) (*types.Snapshot, error) {
+	return nil, types.ErrNotImplemented
+}
+
+func (d *driver) SnapshotRemove(
+	ctx types.Context,
+	snapshotID string,
+	opts types.Store) error {
+	return types.ErrNotImplemented
+}
+
+// HealthCheck verifies that the export is still mounted and readable.
+func (d *driver) HealthCheck(ctx types.Context) error {
+	_, err := ioutil.ReadDir(d.mountPath())
+	return err
+}
+
+func (d *driver) getVolume(
+	volumeID string,
+	attachments types.VolumeAttachmentsTypes) (*types.Volume, error) {
+
+	vol := &types.Volume{
+		ID:   volumeID,
+		Name: volumeID,
+		Type: nfs.Name,
+	}
+
+	if !attachments.Requested() {
+		return vol, nil
+	}
+
+	iids, err := d.readAttachments(volumeID)
+	if err != nil {
+		return nil, err
+	}
+	for _, iid := range iids {
+		vol.Attachments = append(vol.Attachments, &types.VolumeAttachment{
+			VolumeID:   volumeID,
+			InstanceID: iid,
+			DeviceName: d.device(volumeID),
+			Status:     attachedStatus,
+		})
+	}
+	return vol, nil
+}
+
+func (d *driver) readAttachments(
+	volumeID string) ([]*types.InstanceID, error) {
+
+	buf, err := ioutil.ReadFile(d.metaFilePath(volumeID))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var iids []*types.InstanceID
+	if err := json.Unmarshal(buf, &iids); err != nil {
+		return nil, goof.WithFieldE(
+			"volumeID", volumeID, "error reading volume attachments", err)
+	}
+	return iids, nil
+}
+
+func (d *driver) writeAttachments(
+	volumeID string, iids []*types.InstanceID) error {
+
+	if len(iids) == 0 {
+		err := os.Remove(d.metaFilePath(volumeID))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	buf, err := json.Marshal(iids)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(d.metaFilePath(volumeID), buf, 0644)
+}
+
+func (d *driver) volumeExists(volumeID string) bool {
+	if !isValidVolumeName(volumeID) {
+		return false
+	}
+	fi, err := os.Stat(d.volumePath(volumeID))
+	return err == nil && fi.IsDir()
+}
+
+// isValidVolumeName returns a flag indicating whether the name may be used as
+// the name of a volume's directory.
+func isValidVolumeName(name string) bool {
+	return name != "" &&
+		!strings.HasPrefix(name, ".") &&
+		!strings.ContainsAny(name, `/\`)
+}
+
+// device returns the NFS path from which the volume is mounted.
+func (d *driver) device(volumeID string) string {
+	return fmt.Sprintf(
+		"%s:%s", d.host(), path.Join(d.exportPath(), volumeID))
+}
+
+func (d *driver) volumePath(volumeID string) string {
+	return path.Join(d.mountPath(), volumeID)
+}
+
+func (d *driver) metaDirPath() string {
+	return path.Join(d.mountPath(), metaDirName)
+}
+
+func (d *driver) metaFilePath(volumeID string) string {
+	return path.Join(d.metaDirPath(), fmt.Sprintf("%s.json", volumeID))
+}
+
+func (d *driver) host() string {
+	return d.config.GetString("nfs.host")
+}
+
+func (d *driver) exportPath() string {
+	return d.config.GetString("nfs.exportPath")
+}
+
+func (d *driver) mountPath() string {
+	return d.config.GetString("nfs.mountPath")
+}
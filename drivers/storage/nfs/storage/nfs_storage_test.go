@@ -0,0 +1,211 @@
+package storage
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	gofigCore "github.com/akutz/gofig"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codedellemc/libstorage/api/context"
+	"github.com/codedellemc/libstorage/api/types"
+)
+
+// newTestDriver returns a driver whose export is "mounted" at a new
+// temporary directory. The directory stands in for the NFS server.
+func newTestDriver(t *testing.T) (*driver, func()) {
+	dir, err := ioutil.TempDir("", "nfs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := gofigCore.New()
+	config.Set("nfs.host", "filer")
+	config.Set("nfs.exportPath", "/export")
+	config.Set("nfs.mountPath", dir)
+
+	d := &driver{}
+	if err := d.Init(context.Background(), config); err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	return d, func() { os.RemoveAll(dir) }
+}
+
+func newTestContext(id string) types.Context {
+	return context.WithValue(
+		context.Background(),
+		context.InstanceIDKey,
+		&types.InstanceID{ID: id, Driver: "nfs"})
+}
+
+func TestInit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nfs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := path.Join(dir, "file")
+	if err := ioutil.WriteFile(file, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		host       string
+		exportPath string
+		mountPath  string
+		err        bool
+	}{
+		{"filer", "/export", dir, false},
+		{"", "/export", dir, true},
+		{"filer", "", dir, true},
+		{"filer", "/export", "", true},
+		{"filer", "/export", path.Join(dir, "missing"), true},
+		{"filer", "/export", file, true},
+	}
+	for _, tt := range tests {
+		config := gofigCore.New()
+		config.Set("nfs.host", tt.host)
+		config.Set("nfs.exportPath", tt.exportPath)
+		config.Set("nfs.mountPath", tt.mountPath)
+		err := (&driver{}).Init(context.Background(), config)
+		if tt.err {
+			assert.Error(t, err, "%+v", tt)
+			continue
+		}
+		assert.NoError(t, err, "%+v", tt)
+		assert.True(t, isDir(path.Join(dir, metaDirName)))
+	}
+}
+
+func isDir(p string) bool {
+	fi, err := os.Stat(p)
+	return err == nil && fi.IsDir()
+}
+
+func TestIsValidVolumeName(t *testing.T) {
+	tests := []struct {
+		name  string
+		valid bool
+	}{
+		{"vol1", true},
+		{"vol.1", true},
+		{"", false},
+		{".libstorage", false},
+		{"..", false},
+		{"a/b", false},
+		{`a\b`, false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.valid, isValidVolumeName(tt.name), tt.name)
+	}
+}
+
+func TestDevice(t *testing.T) {
+	d, cleanup := newTestDriver(t)
+	defer cleanup()
+
+	assert.Equal(t, "filer:/export/vol1", d.device("vol1"))
+
+	d.config.Set("nfs.exportPath", "/export/")
+	assert.Equal(t, "filer:/export/vol1", d.device("vol1"))
+}
+
+func TestVolumeCreateRemove(t *testing.T) {
+	d, cleanup := newTestDriver(t)
+	defer cleanup()
+	ctx := newTestContext("i-1")
+
+	vol, err := d.VolumeCreate(ctx, "vol1", &types.VolumeCreateOpts{})
+	assert.NoError(t, err)
+	assert.Equal(t, "vol1", vol.ID)
+	assert.True(t, isDir(d.volumePath("vol1")))
+
+	_, err = d.VolumeCreate(ctx, "vol1", &types.VolumeCreateOpts{})
+	assert.Error(t, err)
+	_, err = d.VolumeCreate(ctx, ".libstorage", &types.VolumeCreateOpts{})
+	assert.Error(t, err)
+
+	vols, err := d.Volumes(ctx, &types.VolumesOpts{})
+	assert.NoError(t, err)
+	assert.Len(t, vols, 1)
+
+	assert.NoError(t, d.VolumeRemove(ctx, "vol1", nil))
+	assert.False(t, isDir(d.volumePath("vol1")))
+}
+
+func TestNotFound(t *testing.T) {
+	d, cleanup := newTestDriver(t)
+	defer cleanup()
+	ctx := newTestContext("i-1")
+
+	_, err := d.VolumeInspect(ctx, "missing", &types.VolumeInspectOpts{})
+	assert.IsType(t, &types.ErrNotFound{}, err)
+	_, err = d.VolumeInspect(ctx, "../etc", &types.VolumeInspectOpts{})
+	assert.IsType(t, &types.ErrNotFound{}, err)
+	err = d.VolumeRemove(ctx, "missing", nil)
+	assert.IsType(t, &types.ErrNotFound{}, err)
+	_, _, err = d.VolumeAttach(ctx, "missing", &types.VolumeAttachOpts{})
+	assert.IsType(t, &types.ErrNotFound{}, err)
+	_, err = d.VolumeDetach(ctx, "missing", &types.VolumeDetachOpts{})
+	assert.IsType(t, &types.ErrNotFound{}, err)
+}
+
+func TestVolumeAttachDetach(t *testing.T) {
+	d, cleanup := newTestDriver(t)
+	defer cleanup()
+	ctx1, ctx2 := newTestContext("i-1"), newTestContext("i-2")
+
+	_, err := d.VolumeCreate(ctx1, "vol1", &types.VolumeCreateOpts{})
+	assert.NoError(t, err)
+
+	vol, token, err := d.VolumeAttach(ctx1, "vol1", &types.VolumeAttachOpts{})
+	assert.NoError(t, err)
+	assert.Equal(t, "", token)
+	if assert.Len(t, vol.Attachments, 1) {
+		assert.Equal(t, "i-1", vol.Attachments[0].InstanceID.ID)
+		assert.Equal(t, "filer:/export/vol1", vol.Attachments[0].DeviceName)
+	}
+
+	// attaching the volume to the same instance again is a no-op
+	vol, _, err = d.VolumeAttach(ctx1, "vol1", &types.VolumeAttachOpts{})
+	assert.NoError(t, err)
+	assert.Len(t, vol.Attachments, 1)
+
+	vol, _, err = d.VolumeAttach(ctx2, "vol1", &types.VolumeAttachOpts{})
+	assert.NoError(t, err)
+	assert.Len(t, vol.Attachments, 2)
+
+	vol, _, err = d.VolumeAttach(
+		ctx2, "vol1", &types.VolumeAttachOpts{Force: true})
+	assert.NoError(t, err)
+	if assert.Len(t, vol.Attachments, 1) {
+		assert.Equal(t, "i-2", vol.Attachments[0].InstanceID.ID)
+	}
+
+	vol, err = d.VolumeDetach(ctx1, "vol1", &types.VolumeDetachOpts{})
+	assert.NoError(t, err)
+	assert.Len(t, vol.Attachments, 1)
+
+	vol, err = d.VolumeDetach(ctx2, "vol1", &types.VolumeDetachOpts{})
+	assert.NoError(t, err)
+	assert.Len(t, vol.Attachments, 0)
+	_, err = os.Stat(d.metaFilePath("vol1"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestReadAttachmentsCorrupt(t *testing.T) {
+	d, cleanup := newTestDriver(t)
+	defer cleanup()
+	ctx := newTestContext("i-1")
+
+	_, err := d.VolumeCreate(ctx, "vol1", &types.VolumeCreateOpts{})
+	assert.NoError(t, err)
+	err = ioutil.WriteFile(d.metaFilePath("vol1"), []byte("{"), 0644)
+	assert.NoError(t, err)
+
+	_, err = d.VolumeInspect(ctx, "vol1", &types.VolumeInspectOpts{
+		Attachments: types.VolumeAttachmentsTrue})
+	assert.Error(t, err)
+}
@@ -0,0 +1,2 @@
+NFS_COVERPKG := $(ROOT_IMPORT_PATH)/drivers/storage/nfs
+TEST_COVERPKG_./drivers/storage/nfs/tests := $(NFS_COVERPKG),$(NFS_COVERPKG)/executor,$(NFS_COVERPKG)/storage
@@ -0,0 +1,136 @@
+package nfs
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strconv"
+	"testing"
+
+	gofig "github.com/akutz/gofig/types"
+	"github.com/akutz/gotil"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codedellemc/libstorage/api/server"
+	apitests "github.com/codedellemc/libstorage/api/tests"
+	"github.com/codedellemc/libstorage/api/types"
+
+	// load the driver
+	"github.com/codedellemc/libstorage/drivers/storage/nfs"
+	nfsx "github.com/codedellemc/libstorage/drivers/storage/nfs/executor"
+	_ "github.com/codedellemc/libstorage/drivers/storage/nfs/storage"
+)
+
+// skipTests returns a flag indicating whether the tests are skipped. The
+// tests manage volumes in a temporary directory rather than on an NFS
+// server, but the executor is only supported on hosts that can mount NFS
+// exports.
+func skipTests() bool {
+	noTest, _ := strconv.ParseBool(os.Getenv("TEST_SKIP_NFS"))
+	return noTest || !gotil.FileExistsInPath("mount.nfs")
+}
+
+func TestMain(m *testing.M) {
+	server.CloseOnAbort()
+	ec := m.Run()
+	os.Exit(ec)
+}
+
+// newTestConfig returns the configuration of a driver whose export is
+// "mounted" at a new temporary directory.
+func newTestConfig(t *testing.T) []byte {
+	d, err := ioutil.TempDir("", "")
+	if err != nil {
+		assert.NoError(t, err)
+		t.FailNow()
+	}
+	return []byte(fmt.Sprintf(configYAML, d))
+}
+
+const configYAML = `
+nfs:
+  host:       filer
+  exportPath: /export
+  mountPath:  %s
+`
+
+func TestInstanceID(t *testing.T) {
+	if skipTests() {
+		t.SkipNow()
+	}
+
+	iid, err := nfsx.InstanceID()
+	assert.NoError(t, err)
+	if err != nil {
+		t.FailNow()
+	}
+
+	apitests.Run(
+		t, nfs.Name, newTestConfig(t),
+		(&apitests.InstanceIDTest{
+			Driver:   nfs.Name,
+			Expected: iid,
+		}).Test)
+}
+
+func TestVolumeCreateRemove(t *testing.T) {
+	if skipTests() {
+		t.SkipNow()
+	}
+
+	tf := func(config gofig.Config, client types.Client, t *testing.T) {
+		vol, err := client.API().VolumeCreate(
+			nil, nfs.Name, &types.VolumeCreateRequest{Name: "vol1"})
+		assert.NoError(t, err)
+		if err != nil {
+			t.FailNow()
+		}
+		assert.Equal(t, "vol1", vol.ID)
+		assert.True(t, gotil.FileExists(
+			path.Join(config.GetString("nfs.mountPath"), "vol1")))
+
+		_, err = client.API().VolumeCreate(
+			nil, nfs.Name, &types.VolumeCreateRequest{Name: "vol1"})
+		assert.Error(t, err)
+
+		vols, err := client.API().VolumesByService(nil, nfs.Name, 0)
+		assert.NoError(t, err)
+		assert.Len(t, vols, 1)
+
+		assert.NoError(t, client.API().VolumeRemove(nil, nfs.Name, "vol1"))
+		assert.False(t, gotil.FileExists(
+			path.Join(config.GetString("nfs.mountPath"), "vol1")))
+	}
+	apitests.Run(t, nfs.Name, newTestConfig(t), tf)
+}
+
+func TestVolumeAttachDetach(t *testing.T) {
+	if skipTests() {
+		t.SkipNow()
+	}
+
+	tf := func(config gofig.Config, client types.Client, t *testing.T) {
+		_, err := client.API().VolumeCreate(
+			nil, nfs.Name, &types.VolumeCreateRequest{Name: "vol1"})
+		assert.NoError(t, err)
+
+		vol, attTokn, err := client.API().VolumeAttach(
+			nil, nfs.Name, "vol1", &types.VolumeAttachRequest{})
+		assert.NoError(t, err)
+		if err != nil {
+			t.FailNow()
+		}
+		assert.Equal(t, "", attTokn)
+		if !assert.Len(t, vol.Attachments, 1) {
+			t.FailNow()
+		}
+		assert.Equal(t, "filer:/export/vol1", vol.Attachments[0].DeviceName)
+
+		vol, err = client.API().VolumeDetach(
+			nil, nfs.Name, "vol1", &types.VolumeDetachRequest{})
+		assert.NoError(t, err)
+		assert.Len(t, vol.Attachments, 0)
+	}
+	apitests.Run(t, nfs.Name, newTestConfig(t), tf)
+}
@@ -0,0 +1,182 @@
+package executor
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	gofig "github.com/akutz/gofig/types"
+	"github.com/akutz/gotil"
+
+	"github.com/codedellemc/libstorage/api/registry"
+	"github.com/codedellemc/libstorage/api/types"
+	"github.com/codedellemc/libstorage/api/utils"
+	"github.com/codedellemc/libstorage/drivers/storage/objectstore"
+	osUtils "github.com/codedellemc/libstorage/drivers/storage/objectstore/utils"
+)
+
+// driver is the storage executor for the objectstore storage driver.
+type driver struct {
+	config gofig.Config
+}
+
+const (
+	mountinfoFormat = "%d %d %d:%d %s %s %s %s"
+
+	// bucketRequestTimeout is how long a request for a bucket may take.
+	bucketRequestTimeout = 5 * time.Second
+)
+
+func init() {
+	registry.RegisterStorageExecutor(objectstore.Name, newDriver)
+}
+
+func newDriver() types.StorageExecutor {
+	return &driver{}
+}
+
+func (d *driver) Init(ctx types.Context, config gofig.Config) error {
+	d.config = config
+	return nil
+}
+
+func (d *driver) Name() string {
+	return objectstore.Name
+}
+
+func (d *driver) Supported(ctx types.Context, opts types.Store) (bool, error) {
+	// make sure buckets can be mounted with one of the FUSE clients
+	return gotil.FileExistsInPath("s3fs") ||
+		gotil.FileExistsInPath("goofys"), nil
+}
+
+// InstanceID returns the local instance ID for the test
+func InstanceID() (*types.InstanceID, error) {
+	return newDriver().InstanceID(nil, nil)
+}
+
+// InstanceID returns the local system's host name.
+func (d *driver) InstanceID(
+	ctx types.Context,
+	opts types.Store) (*types.InstanceID, error) {
+
+	hostName, err := utils.HostName()
+	if err != nil {
+		return nil, err
+	}
+	return &types.InstanceID{ID: hostName, Driver: objectstore.Name}, nil
+}
+
+func (d *driver) NextDevice(
+	ctx types.Context,
+	opts types.Store) (string, error) {
+	return "", types.ErrNotImplemented
+}
+
+// LocalDevices returns the host's mounted buckets, keyed by their names.
+func (d *driver) LocalDevices(
+	ctx types.Context,
+	opts *types.LocalDevicesOpts) (*types.LocalDevices, error) {
+
+	mtt, err := parseMountTable()
+	if err != nil {
+		return nil, err
+	}
+
+	idmnt := make(map[string]string)
+	for _, mt := range mtt {
+		if mt.FSType == "fuse.s3fs" || mt.FSType == "fuse.goofys" {
+			idmnt[mt.Source] = mt.MountPoint
+		}
+	}
+
+	return &types.LocalDevices{
+		Driver:    objectstore.Name,
+		DeviceMap: idmnt,
+	}, nil
+}
+
+// DeviceReady returns a flag indicating whether the bucket identified by the
+// attach token, which is the bucket's device, exists at its endpoint. The
+// bucket is requested anonymously, so a bucket that exists but denies the
+// request is also ready.
+func (d *driver) DeviceReady(
+	ctx types.Context,
+	token string,
+	opts types.Store) (bool, error) {
+
+	bucketURL, err := osUtils.BucketURL(token)
+	if err != nil {
+		return false, err
+	}
+
+	client := &http.Client{Timeout: bucketRequestTimeout}
+	res, err := client.Head(bucketURL)
+	if err != nil {
+		ctx.WithField("url", bucketURL).Debug("bucket is not reachable")
+		return false, nil
+	}
+	res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		ctx.WithField("url", bucketURL).Debug("bucket does not exist")
+		return false, nil
+	}
+	return true, nil
+}
+
+func parseMountTable() ([]*types.MountInfo, error) {
+	f, err := os.Open("/proc/self/mountinfo")
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return parseInfoFile(f)
+}
+
+func parseInfoFile(r io.Reader) ([]*types.MountInfo, error) {
+	var (
+		s   = bufio.NewScanner(r)
+		out = []*types.MountInfo{}
+	)
+
+	for s.Scan() {
+		if err := s.Err(); err != nil {
+			return nil, err
+		}
+
+		var (
+			p              = &types.MountInfo{}
+			text           = s.Text()
+			optionalFields string
+		)
+
+		if _, err := fmt.Sscanf(text, mountinfoFormat,
+			&p.ID, &p.Parent, &p.Major, &p.Minor,
+			&p.Root, &p.MountPoint, &p.Opts, &optionalFields); err != nil {
+			return nil, fmt.Errorf("Scanning '%s' failed: %s", text, err)
+		}
+		// Safe as mountinfo encodes mountpoints with spaces as \040.
+		index := strings.Index(text, " - ")
+		postSeparatorFields := strings.Fields(text[index+3:])
+		if len(postSeparatorFields) < 3 {
+			return nil, fmt.Errorf(
+				"Error found less than 3 fields post '-' in %q", text)
+		}
+
+		if optionalFields != "-" {
+			p.Optional = optionalFields
+		}
+
+		p.FSType = postSeparatorFields[0]
+		p.Source = postSeparatorFields[1]
+		p.VFSOpts = strings.Join(postSeparatorFields[2:], " ")
+		out = append(out, p)
+	}
+	return out, nil
+}
@@ -0,0 +1,101 @@
+package objectstore
+
+import (
+	gofigCore "github.com/akutz/gofig"
+	gofig "github.com/akutz/gofig/types"
+)
+
+const (
+	// Name is the provider's name.
+	Name = "objectstore"
+
+	// ConfigEndpoint is a config key.
+	ConfigEndpoint = Name + ".endpoint"
+
+	// ConfigRegion is a config key.
+	ConfigRegion = Name + ".region"
+
+	// ConfigPathStyle is a config key.
+	ConfigPathStyle = Name + ".pathStyle"
+
+	// ConfigCredentialsType is a config key.
+	ConfigCredentialsType = Name + ".credentialsType"
+
+	// ConfigAccessKey is a config key.
+	ConfigAccessKey = Name + ".accessKey"
+
+	// ConfigSecretKey is a config key.
+	ConfigSecretKey = Name + ".secretKey"
+
+	// ConfigSessionToken is a config key.
+	ConfigSessionToken = Name + ".sessionToken"
+
+	// ConfigRoleARN is a config key.
+	ConfigRoleARN = Name + ".roleARN"
+
+	// ConfigBucketPrefix is a config key.
+	ConfigBucketPrefix = Name + ".bucketPrefix"
+
+	// ConfigMounter is a config key.
+	ConfigMounter = Name + ".mounter"
+)
+
+const (
+	// CredentialsStatic indicates that requests are signed with the
+	// configured access key, secret key, and optional session token.
+	CredentialsStatic = "static"
+
+	// CredentialsSTS indicates that requests are signed with the temporary
+	// credentials of the configured role, which is assumed with the static
+	// credentials if they are configured and with the default credentials
+	// otherwise.
+	CredentialsSTS = "sts"
+
+	// CredentialsIAM indicates that requests are signed with the credentials
+	// of the EC2 instance's IAM role.
+	CredentialsIAM = "iam"
+
+	// MounterS3FS indicates that buckets are mounted with s3fs-fuse.
+	MounterS3FS = "s3fs"
+
+	// MounterGoofys indicates that buckets are mounted with goofys.
+	MounterGoofys = "goofys"
+)
+
+func init() {
+	r := gofigCore.NewRegistration("Object Store")
+	r.Key(gofig.String, "", "",
+		"The URL of the S3-compatible endpoint. AWS S3 is used when empty",
+		ConfigEndpoint)
+	r.Key(gofig.String, "", "us-east-1",
+		"The region with which requests are signed", ConfigRegion)
+	r.Key(gofig.Bool, "", false,
+		"Address buckets with path-style rather than virtual-hosted URLs",
+		ConfigPathStyle)
+	r.Key(gofig.String, "", CredentialsStatic,
+		"The type of credentials: static, sts, or iam",
+		ConfigCredentialsType)
+	r.Key(gofig.String, "", "", "The access key", ConfigAccessKey)
+	r.Key(gofig.String, "", "", "The secret key", ConfigSecretKey)
+	r.Key(gofig.String, "", "",
+		"The session token of temporary static credentials",
+		ConfigSessionToken)
+	r.Key(gofig.String, "", "",
+		"The ARN of the role assumed with sts credentials", ConfigRoleARN)
+	r.Key(gofig.String, "", "",
+		"The prefix of the names of the buckets managed as volumes",
+		ConfigBucketPrefix)
+	r.Key(gofig.String, "", MounterS3FS,
+		"The FUSE client with which buckets are mounted: s3fs or goofys",
+		ConfigMounter)
+	r.Key(gofig.String, "", "",
+		"The timeout of requests to the endpoint", Name+".httpTimeout")
+	r.Key(gofig.String, "", "10s",
+		"The timeout of connections to the endpoint", Name+".connectTimeout")
+	r.Key(gofig.String, "", "",
+		"The URL of the proxy of requests to the endpoint", Name+".proxy")
+	r.Key(gofig.Int, "", -1,
+		"The max retries of requests to the endpoint, or -1 for the default",
+		Name+".maxRetries")
+	gofigCore.Register(r)
+}
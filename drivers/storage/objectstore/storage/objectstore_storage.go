@@ -0,0 +1,637 @@
+package storage
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+
+	gofig "github.com/akutz/gofig/types"
+	"github.com/akutz/goof"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/ec2rolecreds"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/ec2metadata"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+
+	"github.com/codedellemc/libstorage/api/context"
+	"github.com/codedellemc/libstorage/api/registry"
+	"github.com/codedellemc/libstorage/api/types"
+	"github.com/codedellemc/libstorage/api/utils"
+	"github.com/codedellemc/libstorage/drivers/storage/awsutils"
+	"github.com/codedellemc/libstorage/drivers/storage/objectstore"
+	osUtils "github.com/codedellemc/libstorage/drivers/storage/objectstore/utils"
+)
+
+const (
+	// attachmentsTag is the key of the bucket tag in which the IDs of the
+	// instances to which the bucket is attached are recorded as a
+	// space-separated list. Commas are not valid in tag values.
+	attachmentsTag = "libstorage-attachments"
+
+	// defaultRegion is the region in which buckets are created without a
+	// location constraint.
+	defaultRegion = "us-east-1"
+
+	attachedStatus = "attached"
+)
+
+// bucketNameRX matches a valid bucket name: three to sixty-three lowercase
+// letters, numbers, periods, and hyphens that begins and ends with a letter
+// or number.
+var bucketNameRX = regexp.MustCompile(`^[a-z0-9][a-z0-9.-]{1,61}[a-z0-9]$`)
+
+// configSpec declares the driver's configuration properties.
+var configSpec = utils.ConfigSpec{
+	{
+		Key: objectstore.ConfigEndpoint,
+		Validate: func(v string) error {
+			_, _, err := osUtils.ParseEndpoint(v)
+			return err
+		},
+	},
+	{Key: objectstore.ConfigRegion},
+	{Key: objectstore.ConfigPathStyle, Values: []string{"true", "false"}},
+	{
+		Key: objectstore.ConfigCredentialsType,
+		Values: []string{
+			objectstore.CredentialsStatic,
+			objectstore.CredentialsSTS,
+			objectstore.CredentialsIAM,
+		},
+	},
+	{Key: objectstore.ConfigAccessKey, Secret: true},
+	{Key: objectstore.ConfigSecretKey, Secret: true},
+	{Key: objectstore.ConfigSessionToken, Secret: true},
+	{Key: objectstore.ConfigRoleARN},
+	{Key: objectstore.ConfigBucketPrefix},
+	{
+		Key:    objectstore.ConfigMounter,
+		Values: []string{objectstore.MounterS3FS, objectstore.MounterGoofys},
+	},
+}
+
+// driver is a storage driver that manages volumes as the buckets of an
+// S3-compatible object store, such as AWS S3 or Minio. The clients mount the
+// buckets with s3fs-fuse or goofys using the linux OS driver's object store
+// mount handler.
+type driver struct {
+	sync.Mutex
+	config  gofig.Config
+	session *session.Session
+	client  *s3.S3
+}
+
+func init() {
+	registry.RegisterStorageDriver(objectstore.Name, newDriver)
+}
+
+func newDriver() types.StorageDriver {
+	return &driver{}
+}
+
+// Name returns the name of the driver
+func (d *driver) Name() string {
+	return objectstore.Name
+}
+
+// Init initializes the driver.
+func (d *driver) Init(ctx types.Context, config gofig.Config) error {
+	d.config = config
+
+	fields := configSpec.Fields(config)
+	if err := configSpec.Validate(config); err != nil {
+		return err
+	}
+
+	cc, err := awsutils.NewClientConfig(config, objectstore.Name)
+	if err != nil {
+		return err
+	}
+	d.session = awsutils.Session(cc)
+
+	creds, err := d.credentials()
+	if err != nil {
+		return goof.WithFieldsE(fields, "invalid credentials", err)
+	}
+
+	awsConfig := &aws.Config{
+		Credentials:      creds,
+		Region:           aws.String(d.region()),
+		S3ForcePathStyle: aws.Bool(d.pathStyle()),
+	}
+	if d.endpoint() != "" {
+		awsConfig.Endpoint = aws.String(d.endpoint())
+	}
+	d.client = s3.New(d.session, awsConfig)
+
+	ctx.WithFields(fields).Info("storage driver initialized")
+	return nil
+}
+
+// s3Client returns the driver's S3 client with requests that are cancelled
+// along with the context.
+func (d *driver) s3Client(ctx types.Context) *s3.S3 {
+	return &s3.S3{Client: awsutils.WithContext(ctx, d.client.Client)}
+}
+
+// credentials returns the credentials of the configured type.
+func (d *driver) credentials() (*credentials.Credentials, error) {
+	var static *credentials.Credentials
+	if v := d.config.GetString(objectstore.ConfigAccessKey); v != "" {
+		static = credentials.NewStaticCredentials(
+			v,
+			d.config.GetString(objectstore.ConfigSecretKey),
+			d.config.GetString(objectstore.ConfigSessionToken))
+	}
+
+	switch d.credentialsType() {
+	case objectstore.CredentialsStatic:
+		if static == nil {
+			return nil, goof.New("static credentials require an access key")
+		}
+		return static, nil
+	case objectstore.CredentialsSTS:
+		roleARN := d.config.GetString(objectstore.ConfigRoleARN)
+		if roleARN == "" {
+			return nil, goof.New("sts credentials require a role ARN")
+		}
+		return stscreds.NewCredentials(d.session.Copy(&aws.Config{
+			Credentials: static,
+			Region:      aws.String(d.region()),
+		}), roleARN), nil
+	case objectstore.CredentialsIAM:
+		return credentials.NewCredentials(&ec2rolecreds.EC2RoleProvider{
+			Client: ec2metadata.New(d.session),
+		}), nil
+	}
+	return nil, goof.WithField(
+		"credentialsType", d.credentialsType(), "invalid credentials type")
+}
+
+// Type returns the type of storage a driver provides
+func (d *driver) Type(ctx types.Context) (types.StorageType, error) {
+	return types.Object, nil
+}
+
+// Capabilities advertises object attach semantics. Buckets have no size and
+// cannot be snapshotted, so neither expansion nor snapshots are supported.
+func (d *driver) Capabilities(
+	ctx types.Context) (*types.StorageCapabilities, error) {
+	return &types.StorageCapabilities{
+		AttachSemantics: types.Object,
+	}, nil
+}
+
+// NextDeviceInfo returns the information about the driver's next available
+// device workflow.
+func (d *driver) NextDeviceInfo(
+	ctx types.Context) (*types.NextDeviceInfo, error) {
+	return nil, nil
+}
+
+// InstanceInspect returns an instance.
+func (d *driver) InstanceInspect(
+	ctx types.Context,
+	opts types.Store) (*types.Instance, error) {
+
+	iid := context.MustInstanceID(ctx)
+	return &types.Instance{Name: iid.ID, InstanceID: iid}, nil
+}
+
+// Volumes returns the buckets whose names begin with the bucket prefix.
+func (d *driver) Volumes(
+	ctx types.Context,
+	opts *types.VolumesOpts) ([]*types.Volume, error) {
+
+	res, err := d.s3Client(ctx).ListBuckets(&s3.ListBucketsInput{})
+	if err != nil {
+		return nil, goof.WithError("error listing buckets", err)
+	}
+
+	volumes := []*types.Volume{}
+	for _, b := range res.Buckets {
+		name := aws.StringValue(b.Name)
+		if !strings.HasPrefix(name, d.bucketPrefix()) {
+			continue
+		}
+		vol, err := d.toTypesVolume(ctx, name, opts.Attachments)
+		if err != nil {
+			return nil, err
+		}
+		volumes = append(volumes, vol)
+	}
+	return utils.SortVolumeByID(volumes), nil
+}
+
+func (d *driver) VolumeInspect(
+	ctx types.Context,
+	volumeID string,
+	opts *types.VolumeInspectOpts) (*types.Volume, error) {
+
+	if err := d.getBucket(ctx, volumeID); err != nil {
+		return nil, err
+	}
+	return d.toTypesVolume(ctx, volumeID, opts.Attachments)
+}
+
+// VolumeCreate creates a new bucket whose name is the volume's name
+// prefixed with the bucket prefix. Buckets have no size, so the requested
+// size is ignored.
+func (d *driver) VolumeCreate(ctx types.Context, volumeName string,
+	opts *types.VolumeCreateOpts) (*types.Volume, error) {
+
+	bucket := d.bucketPrefix() + volumeName
+	if !bucketNameRX.MatchString(bucket) {
+		return nil, goof.WithFields(goof.Fields{
+			"volumeName": volumeName,
+			"bucket":     bucket,
+		}, "invalid volume name")
+	}
+
+	input := &s3.CreateBucketInput{Bucket: aws.String(bucket)}
+	if d.region() != defaultRegion {
+		input.CreateBucketConfiguration = &s3.CreateBucketConfiguration{
+			LocationConstraint: aws.String(d.region()),
+		}
+	}
+
+	if _, err := d.s3Client(ctx).CreateBucket(input); err != nil {
+		return nil, translateError(err, bucket, "error creating bucket")
+	}
+
+	ctx.WithField("bucket", bucket).Info("created bucket")
+
+	return d.VolumeInspect(ctx, bucket,
+		&types.VolumeInspectOpts{Attachments: 0})
+}
+
+// VolumeRemove deletes a bucket. Only empty buckets may be deleted.
+func (d *driver) VolumeRemove(
+	ctx types.Context,
+	volumeID string,
+	opts types.Store) error {
+
+	if err := d.getBucket(ctx, volumeID); err != nil {
+		return err
+	}
+
+	if _, err := d.s3Client(ctx).DeleteBucket(
+		&s3.DeleteBucketInput{Bucket: aws.String(volumeID)}); err != nil {
+		return translateError(err, volumeID, "error deleting bucket")
+	}
+
+	ctx.WithField("bucket", volumeID).Info("deleted bucket")
+	return nil
+}
+
+// VolumeAttach records the instance in the bucket's libstorage-attachments
+// tag. The token is the bucket's objectstore:// device, which names the
+// endpoint and the mounter with which the client mounts the bucket. A forced
+// attachment replaces the instances already recorded for the bucket.
+func (d *driver) VolumeAttach(
+	ctx types.Context,
+	volumeID string,
+	opts *types.VolumeAttachOpts) (*types.Volume, string, error) {
+
+	d.Lock()
+	defer d.Unlock()
+
+	if err := d.getBucket(ctx, volumeID); err != nil {
+		return nil, "", err
+	}
+
+	tags, err := d.getTags(ctx, volumeID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	iid := context.MustInstanceID(ctx)
+
+	ids := attachedIDs(tags)
+	if opts.Force {
+		ids = nil
+	}
+
+	attached := false
+	for _, id := range ids {
+		if id == iid.ID {
+			attached = true
+			break
+		}
+	}
+	if !attached {
+		ids = append(ids, iid.ID)
+	}
+
+	if err := d.setAttachedIDs(ctx, volumeID, tags, ids); err != nil {
+		return nil, "", err
+	}
+
+	ctx.WithField("bucket", volumeID).Info("attached volume")
+
+	vol, err := d.toTypesVolume(ctx, volumeID, types.VolumeAttachmentsTrue)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// the token is the bucket's device, for which clients wait until the
+	// bucket can be reached at the endpoint
+	token, err := osUtils.Device(
+		d.endpoint(), d.region(), volumeID, d.mounter(), d.pathStyle())
+	if err != nil {
+		return nil, "", err
+	}
+	return vol, token, nil
+}
+
+// VolumeDetach detaches a volume from the instance, or from all instances
+// if the detachment is forced.
+func (d *driver) VolumeDetach(
+	ctx types.Context,
+	volumeID string,
+	opts *types.VolumeDetachOpts) (*types.Volume, error) {
+
+	d.Lock()
+	defer d.Unlock()
+
+	if err := d.getBucket(ctx, volumeID); err != nil {
+		return nil, err
+	}
+
+	tags, err := d.getTags(ctx, volumeID)
+	if err != nil {
+		return nil, err
+	}
+
+	iid := context.MustInstanceID(ctx)
+
+	var ids []string
+	if !opts.Force {
+		for _, id := range attachedIDs(tags) {
+			if id != iid.ID {
+				ids = append(ids, id)
+			}
+		}
+	}
+
+	if err := d.setAttachedIDs(ctx, volumeID, tags, ids); err != nil {
+		return nil, err
+	}
+
+	ctx.WithField("bucket", volumeID).Info("detached volume")
+	return d.toTypesVolume(ctx, volumeID, types.VolumeAttachmentsTrue)
+}
+
+// VolumeExpand (not implemented).
+func (d *driver) VolumeExpand(
+	ctx types.Context,
+	volumeID string,
+	newSize int64,
+	opts types.Store) (*types.Volume, error) {
+	return nil, types.ErrNotImplemented
+}
+
+// VolumeCreateFromSnapshot (not implemented).
+func (d *driver) VolumeCreateFromSnapshot(
+	ctx types.Context,
+	snapshotID, volumeName string,
+	opts *types.VolumeCreateOpts) (*types.Volume, error) {
+	return nil, types.ErrNotImplemented
+}
+
+// VolumeCopy copies an existing volume (not implemented)
+func (d *driver) VolumeCopy(
+	ctx types.Context,
+	volumeID, volumeName string,
+	opts types.Store) (*types.Volume, error) {
+	return nil, types.ErrNotImplemented
+}
+
+// VolumeSnapshot snapshots a volume (not implemented)
+func (d *driver) VolumeSnapshot(
+	ctx types.Context,
+	volumeID, snapshotName string,
+	opts types.Store) (*types.Snapshot, error) {
+	return nil, types.ErrNotImplemented
+}
+
+func (d *driver) Snapshots(
+	ctx types.Context,
+	opts types.Store) ([]*types.Snapshot, error) {
+	return nil, types.ErrNotImplemented
+}
+
+func (d *driver) SnapshotInspect(
+	ctx types.Context,
+	snapshotID string,
+	opts types.Store) (*types.Snapshot, error) {
+	return nil, types.ErrNotImplemented
+}
+
+func (d *driver) SnapshotCopy(
+	ctx types.Context,
+	snapshotID, snapshotName, destinationID string,
+	opts types.Store) (*types.Snapshot, error) {
+	return nil, types.ErrNotImplemented
+}
+
+func (d *driver) SnapshotRemove(
+	ctx types.Context,
+	snapshotID string,
+	opts types.Store) error {
+	return types.ErrNotImplemented
+}
+
+// HealthCheck verifies that the buckets can be listed.
+func (d *driver) HealthCheck(ctx types.Context) error {
+	_, err := d.s3Client(ctx).ListBuckets(&s3.ListBucketsInput{})
+	return err
+}
+
+// getBucket returns an ErrNotFound error if a bucket does not exist or is
+// not managed by the driver.
+func (d *driver) getBucket(ctx types.Context, bucket string) error {
+	if !bucketNameRX.MatchString(bucket) ||
+		!strings.HasPrefix(bucket, d.bucketPrefix()) {
+		return utils.NewNotFoundError(bucket)
+	}
+	if _, err := d.s3Client(ctx).HeadBucket(
+		&s3.HeadBucketInput{Bucket: aws.String(bucket)}); err != nil {
+		if isNotFound(err) {
+			return utils.NewNotFoundError(bucket)
+		}
+		return translateError(err, bucket, "error getting bucket")
+	}
+	return nil
+}
+
+// getTags returns a bucket's tags.
+func (d *driver) getTags(
+	ctx types.Context, bucket string) ([]*s3.Tag, error) {
+	res, err := d.s3Client(ctx).GetBucketTagging(
+		&s3.GetBucketTaggingInput{Bucket: aws.String(bucket)})
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok &&
+			aerr.Code() == "NoSuchTagSet" {
+			return nil, nil
+		}
+		return nil, translateError(err, bucket, "error getting bucket tags")
+	}
+	return res.TagSet, nil
+}
+
+// setAttachedIDs records the IDs of the instances to which a bucket is
+// attached in the bucket's tags. The bucket's other tags are preserved.
+func (d *driver) setAttachedIDs(
+	ctx types.Context,
+	bucket string,
+	tags []*s3.Tag,
+	ids []string) error {
+
+	tagSet := []*s3.Tag{}
+	for _, t := range tags {
+		if aws.StringValue(t.Key) != attachmentsTag {
+			tagSet = append(tagSet, t)
+		}
+	}
+	if len(ids) > 0 {
+		tagSet = append(tagSet, &s3.Tag{
+			Key:   aws.String(attachmentsTag),
+			Value: aws.String(strings.Join(ids, " ")),
+		})
+	}
+
+	var err error
+	if len(tagSet) == 0 {
+		_, err = d.s3Client(ctx).DeleteBucketTagging(
+			&s3.DeleteBucketTaggingInput{Bucket: aws.String(bucket)})
+	} else {
+		_, err = d.s3Client(ctx).PutBucketTagging(&s3.PutBucketTaggingInput{
+			Bucket:  aws.String(bucket),
+			Tagging: &s3.Tagging{TagSet: tagSet},
+		})
+	}
+	if err != nil {
+		return translateError(err, bucket, "error setting bucket tags")
+	}
+	return nil
+}
+
+func attachedIDs(tags []*s3.Tag) []string {
+	for _, t := range tags {
+		if aws.StringValue(t.Key) == attachmentsTag {
+			return strings.Fields(aws.StringValue(t.Value))
+		}
+	}
+	return nil
+}
+
+func (d *driver) toTypesVolume(
+	ctx types.Context,
+	bucket string,
+	attachments types.VolumeAttachmentsTypes) (*types.Volume, error) {
+
+	vol := &types.Volume{
+		ID:   bucket,
+		Name: strings.TrimPrefix(bucket, d.bucketPrefix()),
+		Type: d.mounter(),
+	}
+
+	if !attachments.Requested() {
+		return vol, nil
+	}
+
+	tags, err := d.getTags(ctx, bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	dev, err := osUtils.Device(
+		d.endpoint(), d.region(), bucket, d.mounter(), d.pathStyle())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range attachedIDs(tags) {
+		vol.Attachments = append(vol.Attachments, &types.VolumeAttachment{
+			VolumeID: bucket,
+			InstanceID: &types.InstanceID{
+				ID:     id,
+				Driver: objectstore.Name,
+			},
+			DeviceName: dev,
+			Status:     attachedStatus,
+		})
+	}
+	return vol, nil
+}
+
+// s3ErrorCodes maps the codes of the errors reported by S3 to the codes of
+// the errors returned by the driver.
+var s3ErrorCodes = map[string]types.ErrorCode{
+	"NoSuchBucket":            types.ErrorCodeNotFound,
+	"NotFound":                types.ErrorCodeNotFound,
+	"BucketAlreadyExists":     types.ErrorCodeAlreadyExists,
+	"BucketAlreadyOwnedByYou": types.ErrorCodeAlreadyExists,
+	"BucketNotEmpty":          types.ErrorCodeBusy,
+	"OperationAborted":        types.ErrorCodeBusy,
+	"SlowDown":                types.ErrorCodeBusy,
+	"TooManyBuckets":          types.ErrorCodeQuotaExceeded,
+	"AccessDenied":            types.ErrorCodeAuthFailed,
+	"Forbidden":               types.ErrorCodeAuthFailed,
+	"InvalidAccessKeyId":      types.ErrorCodeAuthFailed,
+	"SignatureDoesNotMatch":   types.ErrorCodeAuthFailed,
+	"RequestTimeout":          types.ErrorCodeTimeout,
+}
+
+// translateError returns an error with the code that corresponds to the code
+// of an S3 error. Errors without a corresponding code are wrapped with the
+// specified message.
+func translateError(err error, bucket, msg string) error {
+	if aerr, ok := err.(awserr.Error); ok {
+		if code, ok := s3ErrorCodes[aerr.Code()]; ok {
+			return utils.NewErrorCodeError(code, bucket, err)
+		}
+	}
+	return goof.WithFieldE("bucket", bucket, msg, err)
+}
+
+func isNotFound(err error) bool {
+	if rerr, ok := err.(awserr.RequestFailure); ok {
+		return rerr.StatusCode() == http.StatusNotFound
+	}
+	return false
+}
+
+func (d *driver) endpoint() string {
+	return d.config.GetString(objectstore.ConfigEndpoint)
+}
+
+func (d *driver) region() string {
+	if v := d.config.GetString(objectstore.ConfigRegion); v != "" {
+		return v
+	}
+	return defaultRegion
+}
+
+func (d *driver) pathStyle() bool {
+	return d.config.GetBool(objectstore.ConfigPathStyle)
+}
+
+func (d *driver) credentialsType() string {
+	return strings.ToLower(
+		d.config.GetString(objectstore.ConfigCredentialsType))
+}
+
+func (d *driver) bucketPrefix() string {
+	return d.config.GetString(objectstore.ConfigBucketPrefix)
+}
+
+func (d *driver) mounter() string {
+	return strings.ToLower(d.config.GetString(objectstore.ConfigMounter))
+}
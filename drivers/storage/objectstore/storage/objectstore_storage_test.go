@@ -0,0 +1,530 @@
+package storage
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	gofigCore "github.com/akutz/gofig"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codedellemc/libstorage/api/context"
+	"github.com/codedellemc/libstorage/api/types"
+	"github.com/codedellemc/libstorage/drivers/storage/objectstore"
+	osUtils "github.com/codedellemc/libstorage/drivers/storage/objectstore/utils"
+)
+
+// fakeBucket is a bucket of the fake S3 service.
+type fakeBucket struct {
+	location string
+	tags     map[string]string
+}
+
+// s3Error is an error returned by the fake S3 service.
+type s3Error struct {
+	status int
+	code   string
+}
+
+// fakeS3 is a fake of the S3 API that manages buckets in memory. The
+// buckets are addressed in path style.
+type fakeS3 struct {
+	sync.Mutex
+	buckets map[string]*fakeBucket
+
+	// errs are the errors returned by the fake, keyed by the names of the
+	// operations.
+	errs map[string]*s3Error
+}
+
+type xmlTag struct {
+	Key   string `xml:"Key"`
+	Value string `xml:"Value"`
+}
+
+type xmlTagging struct {
+	XMLName xml.Name `xml:"Tagging"`
+	TagSet  []xmlTag `xml:"TagSet>Tag"`
+}
+
+type xmlBucket struct {
+	Name         string `xml:"Name"`
+	CreationDate string `xml:"CreationDate"`
+}
+
+type xmlListBuckets struct {
+	XMLName xml.Name    `xml:"ListAllMyBucketsResult"`
+	Buckets []xmlBucket `xml:"Buckets>Bucket"`
+}
+
+type xmlCreateBucket struct {
+	LocationConstraint string `xml:"LocationConstraint"`
+}
+
+// operation returns the name of the S3 operation of a request.
+func operation(r *http.Request, bucket string) string {
+	_, tagging := r.URL.Query()["tagging"]
+	switch {
+	case bucket == "":
+		return "ListBuckets"
+	case tagging:
+		return map[string]string{
+			"GET":    "GetBucketTagging",
+			"PUT":    "PutBucketTagging",
+			"DELETE": "DeleteBucketTagging",
+		}[r.Method]
+	}
+	return map[string]string{
+		"HEAD":   "HeadBucket",
+		"PUT":    "CreateBucket",
+		"DELETE": "DeleteBucket",
+	}[r.Method]
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.Lock()
+	defer f.Unlock()
+
+	name := strings.Trim(r.URL.Path, "/")
+	op := operation(r, name)
+	if err := f.errs[op]; err != nil {
+		writeError(w, r, err.status, err.code)
+		return
+	}
+
+	b, ok := f.buckets[name]
+	if !ok && name != "" && op != "CreateBucket" {
+		writeError(w, r, http.StatusNotFound, "NoSuchBucket")
+		return
+	}
+
+	switch op {
+	case "ListBuckets":
+		res := &xmlListBuckets{}
+		for n := range f.buckets {
+			res.Buckets = append(res.Buckets, xmlBucket{
+				Name:         n,
+				CreationDate: "2017-01-02T03:04:05.000Z",
+			})
+		}
+		writeXML(w, res)
+	case "HeadBucket":
+		w.WriteHeader(http.StatusOK)
+	case "CreateBucket":
+		if ok {
+			writeError(w, r, http.StatusConflict, "BucketAlreadyOwnedByYou")
+			return
+		}
+		cfg := &xmlCreateBucket{}
+		xml.NewDecoder(r.Body).Decode(cfg)
+		f.buckets[name] = &fakeBucket{
+			location: cfg.LocationConstraint,
+			tags:     map[string]string{},
+		}
+		w.Header().Set("Location", "/"+name)
+		w.WriteHeader(http.StatusOK)
+	case "DeleteBucket":
+		delete(f.buckets, name)
+		w.WriteHeader(http.StatusNoContent)
+	case "GetBucketTagging":
+		if len(b.tags) == 0 {
+			writeError(w, r, http.StatusNotFound, "NoSuchTagSet")
+			return
+		}
+		res := &xmlTagging{}
+		for k, v := range b.tags {
+			res.TagSet = append(res.TagSet, xmlTag{Key: k, Value: v})
+		}
+		writeXML(w, res)
+	case "PutBucketTagging":
+		req := &xmlTagging{}
+		if err := xml.NewDecoder(r.Body).Decode(req); err != nil {
+			writeError(w, r, http.StatusBadRequest, "MalformedXML")
+			return
+		}
+		b.tags = map[string]string{}
+		for _, t := range req.TagSet {
+			b.tags[t.Key] = t.Value
+		}
+		w.WriteHeader(http.StatusOK)
+	case "DeleteBucketTagging":
+		b.tags = map[string]string{}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func writeXML(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/xml")
+	xml.NewEncoder(w).Encode(v)
+}
+
+// writeError writes an S3 error. The responses to HEAD requests have no
+// body, so the SDK derives the error's code from the status.
+func writeError(
+	w http.ResponseWriter, r *http.Request, status int, code string) {
+
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	if r.Method != "HEAD" {
+		w.Write([]byte("<Error><Code>" + code +
+			"</Code><Message>" + code + "</Message></Error>"))
+	}
+}
+
+// do invokes a function with the fake locked so a test may access the
+// fake's state while the fake serves the driver's requests.
+func (f *fakeS3) do(fn func()) {
+	f.Lock()
+	defer f.Unlock()
+	fn()
+}
+
+func (f *fakeS3) add(name string, tags map[string]string) {
+	f.do(func() {
+		if tags == nil {
+			tags = map[string]string{}
+		}
+		f.buckets[name] = &fakeBucket{tags: tags}
+	})
+}
+
+// newTestDriver returns a driver initialized with a client of a fake S3
+// service. Requests are not retried so that errors are returned at once.
+func newTestDriver(
+	t *testing.T, region string) (*driver, *fakeS3, func()) {
+
+	f := &fakeS3{
+		buckets: map[string]*fakeBucket{},
+		errs:    map[string]*s3Error{},
+	}
+	s := httptest.NewServer(f)
+
+	config := gofigCore.New()
+	config.Set(objectstore.ConfigEndpoint, s.URL)
+	config.Set(objectstore.ConfigRegion, region)
+	config.Set(objectstore.ConfigPathStyle, true)
+	config.Set(objectstore.ConfigCredentialsType, objectstore.CredentialsStatic)
+	config.Set(objectstore.ConfigAccessKey, "AKIDTEST")
+	config.Set(objectstore.ConfigSecretKey, "secret")
+	config.Set(objectstore.ConfigBucketPrefix, "ls-")
+	config.Set(objectstore.ConfigMounter, objectstore.MounterGoofys)
+	config.Set(objectstore.Name+".maxRetries", 0)
+
+	d := &driver{}
+	if err := d.Init(context.Background(), config); err != nil {
+		s.Close()
+		t.Fatal(err)
+	}
+	return d, f, s.Close
+}
+
+func newTestContext(id string) types.Context {
+	return context.WithValue(
+		context.Background(),
+		context.InstanceIDKey,
+		&types.InstanceID{ID: id, Driver: objectstore.Name})
+}
+
+func TestInit(t *testing.T) {
+	tests := []struct {
+		config map[string]interface{}
+		err    bool
+	}{
+		{map[string]interface{}{
+			objectstore.ConfigAccessKey: "AKIDTEST",
+		}, false},
+		{map[string]interface{}{}, true},
+		{map[string]interface{}{
+			objectstore.ConfigAccessKey:       "AKIDTEST",
+			objectstore.ConfigCredentialsType: objectstore.CredentialsSTS,
+		}, true},
+		{map[string]interface{}{
+			objectstore.ConfigAccessKey:       "AKIDTEST",
+			objectstore.ConfigCredentialsType: objectstore.CredentialsSTS,
+			objectstore.ConfigRoleARN:         "arn:aws:iam::1:role/ls",
+		}, false},
+		{map[string]interface{}{
+			objectstore.ConfigCredentialsType: objectstore.CredentialsIAM,
+		}, false},
+		{map[string]interface{}{
+			objectstore.ConfigAccessKey:       "AKIDTEST",
+			objectstore.ConfigCredentialsType: "token",
+		}, true},
+		{map[string]interface{}{
+			objectstore.ConfigAccessKey: "AKIDTEST",
+			objectstore.ConfigEndpoint:  "ftp://minio:9000",
+		}, true},
+		{map[string]interface{}{
+			objectstore.ConfigAccessKey: "AKIDTEST",
+			objectstore.ConfigMounter:   "rclone",
+		}, true},
+	}
+	for _, tt := range tests {
+		config := gofigCore.New()
+		config.Set(objectstore.ConfigCredentialsType,
+			objectstore.CredentialsStatic)
+		config.Set(objectstore.ConfigMounter, objectstore.MounterS3FS)
+		for k, v := range tt.config {
+			config.Set(k, v)
+		}
+		err := (&driver{}).Init(context.Background(), config)
+		if tt.err {
+			assert.Error(t, err, "%+v", tt.config)
+		} else {
+			assert.NoError(t, err, "%+v", tt.config)
+		}
+	}
+}
+
+func TestBucketNameRX(t *testing.T) {
+	tests := []struct {
+		name  string
+		valid bool
+	}{
+		{"abc", true},
+		{"ls-data.1", true},
+		{"ab", false},
+		{"Data", false},
+		{"-data", false},
+		{"data-", false},
+		{"data_1", false},
+		{strings.Repeat("a", 63), true},
+		{strings.Repeat("a", 64), false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.valid, bucketNameRX.MatchString(tt.name), tt.name)
+	}
+}
+
+func TestTranslateError(t *testing.T) {
+	tests := []struct {
+		code     string
+		expected types.ErrorCode
+	}{
+		{"NoSuchBucket", types.ErrorCodeNotFound},
+		{"BucketAlreadyOwnedByYou", types.ErrorCodeAlreadyExists},
+		{"BucketNotEmpty", types.ErrorCodeBusy},
+		{"TooManyBuckets", types.ErrorCodeQuotaExceeded},
+		{"SignatureDoesNotMatch", types.ErrorCodeAuthFailed},
+		{"RequestTimeout", types.ErrorCodeTimeout},
+		{"InternalError", ""},
+	}
+	for _, tt := range tests {
+		err := translateError(
+			awserr.New(tt.code, "message", nil), "ls-data", "error")
+		assert.Equal(t, tt.expected, types.ErrorCodeOf(err), tt.code)
+	}
+
+	assert.True(t, isNotFound(awserr.NewRequestFailure(
+		awserr.New("NotFound", "", nil), http.StatusNotFound, "req1")))
+	assert.False(t, isNotFound(awserr.NewRequestFailure(
+		awserr.New("Forbidden", "", nil), http.StatusForbidden, "req1")))
+	assert.False(t, isNotFound(awserr.New("NotFound", "", nil)))
+}
+
+func TestVolumeCreate(t *testing.T) {
+	d, f, cleanup := newTestDriver(t, "eu-west-1")
+	defer cleanup()
+	ctx := newTestContext("i-1")
+
+	vol, err := d.VolumeCreate(ctx, "data", &types.VolumeCreateOpts{})
+	assert.NoError(t, err)
+	assert.Equal(t, "ls-data", vol.ID)
+	assert.Equal(t, "data", vol.Name)
+	assert.Equal(t, objectstore.MounterGoofys, vol.Type)
+	f.do(func() {
+		if assert.Contains(t, f.buckets, "ls-data") {
+			assert.Equal(t, "eu-west-1", f.buckets["ls-data"].location)
+		}
+	})
+
+	_, err = d.VolumeCreate(ctx, "data", &types.VolumeCreateOpts{})
+	assert.Equal(t, types.ErrorCodeAlreadyExists, types.ErrorCodeOf(err))
+	_, err = d.VolumeCreate(ctx, "Data", &types.VolumeCreateOpts{})
+	assert.Error(t, err)
+
+	// buckets without the prefix are not the driver's volumes
+	f.add("other", nil)
+	vols, err := d.Volumes(ctx, &types.VolumesOpts{})
+	assert.NoError(t, err)
+	if assert.Len(t, vols, 1) {
+		assert.Equal(t, "ls-data", vols[0].ID)
+	}
+
+	f.do(func() {
+		f.errs["CreateBucket"] = &s3Error{
+			http.StatusBadRequest, "TooManyBuckets"}
+	})
+	_, err = d.VolumeCreate(ctx, "data2", &types.VolumeCreateOpts{})
+	assert.Equal(t, types.ErrorCodeQuotaExceeded, types.ErrorCodeOf(err))
+}
+
+func TestVolumeCreateDefaultRegion(t *testing.T) {
+	d, f, cleanup := newTestDriver(t, "")
+	defer cleanup()
+
+	_, err := d.VolumeCreate(
+		newTestContext("i-1"), "data", &types.VolumeCreateOpts{})
+	assert.NoError(t, err)
+	f.do(func() {
+		if assert.Contains(t, f.buckets, "ls-data") {
+			assert.Equal(t, "", f.buckets["ls-data"].location)
+		}
+	})
+}
+
+func TestNotFound(t *testing.T) {
+	d, f, cleanup := newTestDriver(t, "us-east-1")
+	defer cleanup()
+	ctx := newTestContext("i-1")
+	f.add("other", nil)
+
+	for _, id := range []string{"ls-data", "other", "Ls-data"} {
+		_, err := d.VolumeInspect(ctx, id, &types.VolumeInspectOpts{})
+		assert.IsType(t, &types.ErrNotFound{}, err, id)
+		assert.IsType(t, &types.ErrNotFound{}, d.VolumeRemove(ctx, id, nil))
+		_, _, err = d.VolumeAttach(ctx, id, &types.VolumeAttachOpts{})
+		assert.IsType(t, &types.ErrNotFound{}, err, id)
+		_, err = d.VolumeDetach(ctx, id, &types.VolumeDetachOpts{})
+		assert.IsType(t, &types.ErrNotFound{}, err, id)
+	}
+
+	f.add("ls-data", nil)
+	f.do(func() {
+		f.errs["HeadBucket"] = &s3Error{http.StatusForbidden, "Forbidden"}
+	})
+	_, err := d.VolumeInspect(ctx, "ls-data", &types.VolumeInspectOpts{})
+	assert.Equal(t, types.ErrorCodeAuthFailed, types.ErrorCodeOf(err))
+}
+
+func TestVolumeRemove(t *testing.T) {
+	d, f, cleanup := newTestDriver(t, "us-east-1")
+	defer cleanup()
+	ctx := newTestContext("i-1")
+	f.add("ls-data", nil)
+
+	f.do(func() {
+		f.errs["DeleteBucket"] = &s3Error{http.StatusConflict, "BucketNotEmpty"}
+	})
+	err := d.VolumeRemove(ctx, "ls-data", nil)
+	assert.Equal(t, types.ErrorCodeBusy, types.ErrorCodeOf(err))
+
+	f.do(func() { delete(f.errs, "DeleteBucket") })
+	assert.NoError(t, d.VolumeRemove(ctx, "ls-data", nil))
+	f.do(func() { assert.Empty(t, f.buckets) })
+}
+
+func TestVolumeAttachDetach(t *testing.T) {
+	d, f, cleanup := newTestDriver(t, "us-east-1")
+	defer cleanup()
+	ctx1, ctx2 := newTestContext("i-1"), newTestContext("i-2")
+	f.add("ls-data", map[string]string{"owner": "ops"})
+
+	dev, err := osUtils.Device(d.endpoint(), "us-east-1", "ls-data",
+		objectstore.MounterGoofys, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vol, token, err := d.VolumeAttach(
+		ctx1, "ls-data", &types.VolumeAttachOpts{})
+	assert.NoError(t, err)
+	assert.Equal(t, dev, token)
+	if assert.Len(t, vol.Attachments, 1) {
+		assert.Equal(t, "i-1", vol.Attachments[0].InstanceID.ID)
+		assert.Equal(t, dev, vol.Attachments[0].DeviceName)
+	}
+
+	// attaching the volume to the same instance again is a no-op
+	vol, _, err = d.VolumeAttach(ctx1, "ls-data", &types.VolumeAttachOpts{})
+	assert.NoError(t, err)
+	assert.Len(t, vol.Attachments, 1)
+
+	vol, _, err = d.VolumeAttach(ctx2, "ls-data", &types.VolumeAttachOpts{})
+	assert.NoError(t, err)
+	assert.Len(t, vol.Attachments, 2)
+	f.do(func() {
+		assert.Equal(t, "i-1 i-2", f.buckets["ls-data"].tags[attachmentsTag])
+	})
+
+	vol, err = d.VolumeDetach(ctx1, "ls-data", &types.VolumeDetachOpts{})
+	assert.NoError(t, err)
+	if assert.Len(t, vol.Attachments, 1) {
+		assert.Equal(t, "i-2", vol.Attachments[0].InstanceID.ID)
+	}
+
+	vol, _, err = d.VolumeAttach(
+		ctx1, "ls-data", &types.VolumeAttachOpts{Force: true})
+	assert.NoError(t, err)
+	f.do(func() {
+		assert.Equal(t, "i-1", f.buckets["ls-data"].tags[attachmentsTag])
+	})
+
+	vol, err = d.VolumeDetach(
+		ctx2, "ls-data", &types.VolumeDetachOpts{Force: true})
+	assert.NoError(t, err)
+	assert.Empty(t, vol.Attachments)
+
+	// the bucket's other tags are preserved
+	f.do(func() {
+		tags := []string{}
+		for k, v := range f.buckets["ls-data"].tags {
+			tags = append(tags, k+"="+v)
+		}
+		sort.Strings(tags)
+		assert.Equal(t, []string{"owner=ops"}, tags)
+	})
+
+	f.do(func() {
+		f.errs["PutBucketTagging"] = &s3Error{
+			http.StatusServiceUnavailable, "SlowDown"}
+	})
+	_, _, err = d.VolumeAttach(ctx1, "ls-data", &types.VolumeAttachOpts{})
+	assert.Equal(t, types.ErrorCodeBusy, types.ErrorCodeOf(err))
+}
+
+func TestVolumeDetachLastTag(t *testing.T) {
+	d, f, cleanup := newTestDriver(t, "us-east-1")
+	defer cleanup()
+	ctx := newTestContext("i-1")
+	f.add("ls-data", map[string]string{attachmentsTag: "i-1"})
+
+	// the tag set is deleted rather than emptied
+	f.do(func() {
+		f.errs["PutBucketTagging"] = &s3Error{
+			http.StatusBadRequest, "MalformedXML"}
+	})
+	vol, err := d.VolumeDetach(ctx, "ls-data", &types.VolumeDetachOpts{})
+	assert.NoError(t, err)
+	assert.Empty(t, vol.Attachments)
+	f.do(func() { assert.Empty(t, f.buckets["ls-data"].tags) })
+
+	f.do(func() {
+		f.errs["GetBucketTagging"] = &s3Error{
+			http.StatusForbidden, "AccessDenied"}
+	})
+	_, err = d.VolumeInspect(ctx, "ls-data", &types.VolumeInspectOpts{
+		Attachments: types.VolumeAttachmentsTrue})
+	assert.Equal(t, types.ErrorCodeAuthFailed, types.ErrorCodeOf(err))
+}
+
+func TestHealthCheck(t *testing.T) {
+	d, f, cleanup := newTestDriver(t, "us-east-1")
+	defer cleanup()
+	ctx := newTestContext("i-1")
+
+	assert.NoError(t, d.HealthCheck(ctx))
+
+	f.do(func() {
+		f.errs["ListBuckets"] = &s3Error{
+			http.StatusForbidden, "InvalidAccessKeyId"}
+	})
+	assert.Error(t, d.HealthCheck(ctx))
+	_, err := d.Volumes(ctx, &types.VolumesOpts{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,2 @@
+OBJECTSTORE_COVERPKG := $(ROOT_IMPORT_PATH)/drivers/storage/objectstore
+TEST_COVERPKG_./drivers/storage/objectstore/tests := $(OBJECTSTORE_COVERPKG),$(OBJECTSTORE_COVERPKG)/executor,$(OBJECTSTORE_COVERPKG)/storage
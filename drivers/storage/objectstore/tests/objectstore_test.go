@@ -0,0 +1,198 @@
+package objectstore
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"testing"
+
+	log "github.com/Sirupsen/logrus"
+	gofig "github.com/akutz/gofig/types"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codedellemc/libstorage/api/server"
+	apitests "github.com/codedellemc/libstorage/api/tests"
+	"github.com/codedellemc/libstorage/api/types"
+	"github.com/codedellemc/libstorage/drivers/storage/awsutils"
+
+	// load the driver
+	"github.com/codedellemc/libstorage/drivers/storage/objectstore"
+	objectstorex "github.com/codedellemc/libstorage/drivers/storage/objectstore/executor"
+	_ "github.com/codedellemc/libstorage/drivers/storage/objectstore/storage"
+	osUtils "github.com/codedellemc/libstorage/drivers/storage/objectstore/utils"
+)
+
+// cassette is the file to which the requests the driver sends to the
+// S3-compatible endpoint are recorded when TEST_RECORD_OBJECTSTORE is true.
+// If the file exists the tests replay the recorded responses instead of
+// sending the requests to an endpoint.
+const cassette = "testdata/objectstore.json"
+
+// replaying is a flag indicating whether the tests replay the responses
+// recorded to the cassette.
+var replaying bool
+
+// skipTests returns a flag indicating whether the tests are skipped.
+func skipTests() bool {
+	return os.Getenv("TEST_SKIP_OBJECTSTORE") != ""
+}
+
+// hasEndpoint returns a flag indicating whether the tests may send requests
+// to an endpoint. Unless the responses are replayed, the tests require the
+// S3-compatible endpoint specified with OBJECTSTORE_ENDPOINT and the static
+// credentials specified with OBJECTSTORE_ACCESS_KEY and
+// OBJECTSTORE_SECRET_KEY. Buckets are addressed with path-style URLs so that
+// the tests may be run against a local Minio server.
+func hasEndpoint() bool {
+	return os.Getenv("OBJECTSTORE_ENDPOINT") != "" &&
+		os.Getenv("OBJECTSTORE_ACCESS_KEY") != "" &&
+		os.Getenv("OBJECTSTORE_SECRET_KEY") != ""
+}
+
+var volumeName string
+
+func init() {
+	uuid, _ := types.NewUUID()
+	volumeName = "ls-test-" + uuid.String()[:8]
+}
+
+func TestMain(m *testing.M) {
+	server.CloseOnAbort()
+
+	var stop func() error
+	record, _ := strconv.ParseBool(os.Getenv("TEST_RECORD_OBJECTSTORE"))
+	_, err := os.Stat(cassette)
+	if !record && os.IsNotExist(err) && !skipTests() && !hasEndpoint() {
+		// without an endpoint the tests can only be run by replaying the
+		// cassette, so a missing cassette is an error
+		log.WithField("cassette", cassette).Fatal(
+			"missing cassette; record it with TEST_RECORD_OBJECTSTORE=true")
+	}
+	if record || err == nil {
+		var err error
+		if stop, err = awsutils.UseCassette(cassette, record); err != nil {
+			log.Fatal(err)
+		}
+		replaying = !record
+
+		// the requests must be the same when they are recorded and
+		// replayed, so the bucket's name may not be random
+		volumeName = "ls-test-replay"
+	}
+
+	ec := m.Run()
+	if stop != nil {
+		if err := stop(); err != nil {
+			log.Error(err)
+			ec = 1
+		}
+	}
+	os.Exit(ec)
+}
+
+func newTestConfig() []byte {
+	endpoint := os.Getenv("OBJECTSTORE_ENDPOINT")
+	accessKey := os.Getenv("OBJECTSTORE_ACCESS_KEY")
+	secretKey := os.Getenv("OBJECTSTORE_SECRET_KEY")
+	if replaying {
+		endpoint = "http://127.0.0.1:9000"
+		accessKey = "replay"
+		secretKey = "replay"
+	}
+	return []byte(fmt.Sprintf(`
+objectstore:
+  endpoint:  %s
+  pathStyle: true
+  accessKey: %s
+  secretKey: %s
+`, endpoint, accessKey, secretKey))
+}
+
+func TestInstanceID(t *testing.T) {
+	if skipTests() {
+		t.SkipNow()
+	}
+
+	iid, err := objectstorex.InstanceID()
+	assert.NoError(t, err)
+	if err != nil {
+		t.FailNow()
+	}
+
+	apitests.Run(
+		t, objectstore.Name, newTestConfig(),
+		(&apitests.InstanceIDTest{
+			Driver:   objectstore.Name,
+			Expected: iid,
+		}).Test)
+}
+
+func TestVolumeCreateRemove(t *testing.T) {
+	if skipTests() {
+		t.SkipNow()
+	}
+
+	tf := func(config gofig.Config, client types.Client, t *testing.T) {
+		vol, err := client.API().VolumeCreate(
+			nil, objectstore.Name,
+			&types.VolumeCreateRequest{Name: volumeName})
+		assert.NoError(t, err)
+		if err != nil {
+			t.FailNow()
+		}
+		assert.Equal(t, volumeName, vol.ID)
+		assert.Equal(t, volumeName, vol.Name)
+
+		_, err = client.API().VolumeCreate(
+			nil, objectstore.Name,
+			&types.VolumeCreateRequest{Name: volumeName})
+		assert.Error(t, err)
+
+		assert.NoError(t, client.API().VolumeRemove(
+			nil, objectstore.Name, volumeName))
+	}
+	apitests.Run(t, objectstore.Name, newTestConfig(), tf)
+}
+
+func TestVolumeAttachDetach(t *testing.T) {
+	// the requests include the host name of the instance to which the bucket
+	// is attached, so they cannot be replayed on another host
+	if skipTests() || replaying {
+		t.SkipNow()
+	}
+
+	tf := func(config gofig.Config, client types.Client, t *testing.T) {
+		_, err := client.API().VolumeCreate(
+			nil, objectstore.Name,
+			&types.VolumeCreateRequest{Name: volumeName})
+		assert.NoError(t, err)
+		if err != nil {
+			t.FailNow()
+		}
+		defer client.API().VolumeRemove(nil, objectstore.Name, volumeName)
+
+		vol, _, err := client.API().VolumeAttach(
+			nil, objectstore.Name, volumeName,
+			&types.VolumeAttachRequest{})
+		assert.NoError(t, err)
+		if err != nil {
+			t.FailNow()
+		}
+		if !assert.Len(t, vol.Attachments, 1) {
+			t.FailNow()
+		}
+
+		dev, err := osUtils.Device(
+			os.Getenv("OBJECTSTORE_ENDPOINT"), "us-east-1", volumeName,
+			objectstore.MounterS3FS, true)
+		assert.NoError(t, err)
+		assert.Equal(t, dev, vol.Attachments[0].DeviceName)
+
+		vol, err = client.API().VolumeDetach(
+			nil, objectstore.Name, volumeName,
+			&types.VolumeDetachRequest{})
+		assert.NoError(t, err)
+		assert.Len(t, vol.Attachments, 0)
+	}
+	apitests.Run(t, objectstore.Name, newTestConfig(), tf)
+}
@@ -0,0 +1,102 @@
+package utils
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/akutz/goof"
+)
+
+const (
+	// DeviceScheme is the scheme of the devices of volumes, which are
+	// mounted with the linux OS driver's object store mount handler.
+	DeviceScheme = "objectstore"
+
+	// awsHost is the host of the AWS S3 endpoint, which is used if no
+	// endpoint is configured.
+	awsHost = "s3.amazonaws.com"
+)
+
+// ParseEndpoint returns the scheme and host of an endpoint. The scheme
+// defaults to https if the endpoint does not specify one, and the AWS S3
+// endpoint is returned if the endpoint is empty.
+func ParseEndpoint(endpoint string) (string, string, error) {
+	if endpoint == "" {
+		return "https", awsHost, nil
+	}
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "https://" + endpoint
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", "", goof.WithFieldE(
+			"endpoint", endpoint, "invalid endpoint", err)
+	}
+	if u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return "", "", goof.WithField(
+			"endpoint", endpoint, "invalid endpoint")
+	}
+	return u.Scheme, u.Host, nil
+}
+
+// Device returns the device of a bucket, formatted as
+// objectstore://host/bucket?mounter=m&region=r[&pathStyle=true]
+// [&scheme=http]. The device identifies the bucket along with everything a
+// client requires to mount it other than the credentials.
+func Device(
+	endpoint, region, bucket, mounter string,
+	pathStyle bool) (string, error) {
+
+	scheme, host, err := ParseEndpoint(endpoint)
+	if err != nil {
+		return "", err
+	}
+
+	q := url.Values{}
+	q.Set("mounter", mounter)
+	q.Set("region", region)
+	if pathStyle {
+		q.Set("pathStyle", "true")
+	}
+	if scheme != "https" {
+		q.Set("scheme", scheme)
+	}
+
+	u := &url.URL{
+		Scheme:   DeviceScheme,
+		Host:     host,
+		Path:     "/" + bucket,
+		RawQuery: q.Encode(),
+	}
+	return u.String(), nil
+}
+
+// BucketURL returns the URL of the bucket identified by a device. The bucket
+// is addressed with a path-style URL if the device specifies it, otherwise
+// with a virtual-hosted URL.
+func BucketURL(device string) (string, error) {
+	u, err := url.Parse(device)
+	if err != nil {
+		return "", goof.WithFieldE(
+			"device", device, "invalid object store device", err)
+	}
+
+	bucket := strings.Trim(u.Path, "/")
+	if u.Scheme != DeviceScheme || u.Host == "" || bucket == "" ||
+		strings.Contains(bucket, "/") {
+		return "", goof.WithField(
+			"device", device, "invalid object store device")
+	}
+
+	q := u.Query()
+	scheme := q.Get("scheme")
+	if scheme == "" {
+		scheme = "https"
+	}
+
+	if q.Get("pathStyle") == "true" {
+		return fmt.Sprintf("%s://%s/%s", scheme, u.Host, bucket), nil
+	}
+	return fmt.Sprintf("%s://%s.%s/", scheme, bucket, u.Host), nil
+}
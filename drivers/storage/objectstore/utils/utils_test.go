@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseEndpoint(t *testing.T) {
+	scheme, host, err := ParseEndpoint("")
+	assert.NoError(t, err)
+	assert.Equal(t, "https", scheme)
+	assert.Equal(t, "s3.amazonaws.com", host)
+
+	scheme, host, err = ParseEndpoint("http://minio:9000")
+	assert.NoError(t, err)
+	assert.Equal(t, "http", scheme)
+	assert.Equal(t, "minio:9000", host)
+
+	scheme, host, err = ParseEndpoint("storage.example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, "https", scheme)
+	assert.Equal(t, "storage.example.com", host)
+
+	_, _, err = ParseEndpoint("ftp://storage.example.com")
+	assert.Error(t, err)
+}
+
+func TestDevice(t *testing.T) {
+	dev, err := Device("", "us-east-1", "data", "s3fs", false)
+	assert.NoError(t, err)
+	assert.Equal(t,
+		"objectstore://s3.amazonaws.com/data?mounter=s3fs&region=us-east-1",
+		dev)
+
+	dev, err = Device("http://minio:9000", "us-east-1", "data", "goofys", true)
+	assert.NoError(t, err)
+	assert.Equal(t,
+		"objectstore://minio:9000/data"+
+			"?mounter=goofys&pathStyle=true&region=us-east-1&scheme=http",
+		dev)
+}
+
+func TestBucketURL(t *testing.T) {
+	u, err := BucketURL(
+		"objectstore://s3.amazonaws.com/data?mounter=s3fs&region=us-east-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://data.s3.amazonaws.com/", u)
+
+	u, err = BucketURL("objectstore://minio:9000/data" +
+		"?mounter=goofys&pathStyle=true&region=us-east-1&scheme=http")
+	assert.NoError(t, err)
+	assert.Equal(t, "http://minio:9000/data", u)
+
+	_, err = BucketURL("objectstore://minio:9000/")
+	assert.Error(t, err)
+
+	_, err = BucketURL("s3://minio:9000/data")
+	assert.Error(t, err)
+}
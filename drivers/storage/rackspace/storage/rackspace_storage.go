@@ -109,6 +109,16 @@ func (d *driver) Type(ctx types.Context) (types.StorageType, error) {
 	return types.Block, nil
 }
 
+// Capabilities advertises block attach semantics and snapshots of Cloud
+// Block Storage volumes.
+func (d *driver) Capabilities(
+	ctx types.Context) (*types.StorageCapabilities, error) {
+	return &types.StorageCapabilities{
+		SupportsSnapshots: true,
+		AttachSemantics:   types.Block,
+	}, nil
+}
+
 // 	// NextDeviceInfo returns the information about the driver's next available
 // 	// device workflow.
 func (d *driver) NextDeviceInfo(
@@ -196,6 +206,15 @@ func (d *driver) VolumeCopy(
 	return d.createVolume(ctx, volumeName, volumeID, "", volumeCreateOpts)
 }
 
+// VolumeExpand expands a volume (not implemented)
+func (d *driver) VolumeExpand(
+	ctx types.Context,
+	volumeID string,
+	newSize int64,
+	opts types.Store) (*types.Volume, error) {
+	return nil, types.ErrNotImplemented
+}
+
 // 	// VolumeSnapshot snapshots a volume.
 func (d *driver) VolumeSnapshot(
 	ctx types.Context,
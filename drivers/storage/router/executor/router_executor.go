@@ -0,0 +1,142 @@
+package executor
+
+import (
+	"fmt"
+	"strings"
+
+	gofig "github.com/akutz/gofig/types"
+
+	"github.com/codedellemc/libstorage/api/registry"
+	"github.com/codedellemc/libstorage/api/types"
+	"github.com/codedellemc/libstorage/drivers/storage/router"
+)
+
+// driver is the storage executor for the router storage driver. It combines
+// the results of the executors of the router's backends.
+type driver struct {
+	config         gofig.Config
+	names          []string
+	executors      map[string]types.StorageExecutor
+	defaultBackend string
+}
+
+func init() {
+	registry.RegisterStorageExecutor(router.Name, newDriver)
+}
+
+func newDriver() types.StorageExecutor {
+	return &driver{}
+}
+
+func (d *driver) Name() string {
+	return router.Name
+}
+
+func (d *driver) Init(ctx types.Context, config gofig.Config) error {
+	d.config = config
+	d.names = router.BackendNames(config)
+	d.executors = map[string]types.StorageExecutor{}
+
+	for _, name := range d.names {
+		driverName, err := router.BackendDriverName(config, name)
+		if err != nil {
+			return err
+		}
+		x, err := registry.NewStorageExecutor(driverName)
+		if err != nil {
+			return err
+		}
+		if err := x.Init(ctx, router.BackendConfig(config, name)); err != nil {
+			return err
+		}
+		d.executors[name] = x
+	}
+
+	defaultBackend, err := router.DefaultBackendName(config, d.names)
+	if err != nil {
+		return err
+	}
+	d.defaultBackend = defaultBackend
+
+	return nil
+}
+
+// Supported returns true if at least one of the backends' executors is
+// supported on this host.
+func (d *driver) Supported(
+	ctx types.Context,
+	opts types.Store) (bool, error) {
+
+	for _, name := range d.names {
+		x, ok := d.executors[name].(types.StorageExecutorWithSupported)
+		if !ok {
+			return true, nil
+		}
+		supported, err := x.Supported(ctx, opts)
+		if err != nil {
+			return false, err
+		}
+		if supported {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// InstanceID returns the local system's InstanceID. The InstanceID's metadata
+// contains the InstanceID of every backend.
+func (d *driver) InstanceID(
+	ctx types.Context,
+	opts types.Store) (*types.InstanceID, error) {
+
+	ids := []string{}
+	iids := map[string]*types.InstanceID{}
+	for _, name := range d.names {
+		iid, err := d.executors[name].InstanceID(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+		iids[name] = iid
+		ids = append(ids, fmt.Sprintf("%s=%s", name, iid.ID))
+	}
+
+	iid := &types.InstanceID{
+		ID:     strings.Join(ids, ","),
+		Driver: router.Name,
+	}
+	if err := iid.MarshalMetadata(iids); err != nil {
+		return nil, err
+	}
+	return iid, nil
+}
+
+// NextDevice returns the next available device of the default backend.
+func (d *driver) NextDevice(
+	ctx types.Context,
+	opts types.Store) (string, error) {
+
+	return d.executors[d.defaultBackend].NextDevice(ctx, opts)
+}
+
+// LocalDevices returns a map of the system's local devices reported by all
+// of the backends.
+func (d *driver) LocalDevices(
+	ctx types.Context,
+	opts *types.LocalDevicesOpts) (*types.LocalDevices, error) {
+
+	devMap := map[string]string{}
+	for _, name := range d.names {
+		ld, err := d.executors[name].LocalDevices(ctx, opts)
+		if err != nil {
+			if err == types.ErrNotImplemented {
+				continue
+			}
+			return nil, err
+		}
+		for k, v := range ld.DeviceMap {
+			devMap[k] = v
+		}
+	}
+
+	return &types.LocalDevices{Driver: router.Name, DeviceMap: devMap}, nil
+}
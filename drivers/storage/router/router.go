@@ -0,0 +1,114 @@
+package router
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	gofigCore "github.com/akutz/gofig"
+	gofig "github.com/akutz/gofig/types"
+	"github.com/akutz/goof"
+)
+
+const (
+	// Name is the name of the driver.
+	Name = "router"
+
+	// BackendField is the name of the field that records the backend from
+	// which a volume or snapshot originates.
+	BackendField = "backend"
+
+	// ConfigBackends is the config key for the map of backend names to the
+	// backends' configurations.
+	ConfigBackends = "router.backends"
+
+	// ConfigDefault is the config key for the name of the backend on which
+	// volumes are created when no route matches.
+	ConfigDefault = "router.default"
+
+	// ConfigRouteBy is the config key for the name of the volume create
+	// option that selects a route.
+	ConfigRouteBy = "router.routeBy"
+
+	// ConfigRoutes is the config key for the map of route names to backend
+	// names.
+	ConfigRoutes = "router.routes"
+
+	// idDelimiter separates a backend's name from the ID of one of the
+	// backend's volumes or snapshots.
+	idDelimiter = ":"
+)
+
+func init() {
+	r := gofigCore.NewRegistration("Router")
+	r.Key(gofig.String, "", "", "", ConfigDefault)
+	r.Key(gofig.String, "", "type", "", ConfigRouteBy)
+	gofigCore.Register(r)
+}
+
+// ID returns the ID the router uses for a backend's volume or snapshot.
+func ID(backend, id string) string {
+	if id == "" {
+		return ""
+	}
+	return backend + idDelimiter + id
+}
+
+// ParseID returns the name of the backend and the backend's ID for an ID
+// returned by ID. A false value is returned if the ID was not returned by ID.
+func ParseID(id string) (string, string, bool) {
+	parts := strings.SplitN(id, idDelimiter, 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+// BackendNames returns the sorted names of the configured backends.
+func BackendNames(config gofig.Config) []string {
+	names := []string{}
+	if m, ok := config.Get(ConfigBackends).(map[string]interface{}); ok {
+		for name := range m {
+			names = append(names, strings.ToLower(name))
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// BackendConfig returns the configuration of the named backend.
+func BackendConfig(config gofig.Config, name string) gofig.Config {
+	return config.Scope(fmt.Sprintf("%s.%s", ConfigBackends, name))
+}
+
+// BackendDriverName returns the name of the driver used by the named backend.
+// An error is returned if the backend does not specify a driver or specifies
+// the router itself.
+func BackendDriverName(config gofig.Config, name string) (string, error) {
+	driverName := config.GetString(
+		fmt.Sprintf("%s.%s.driver", ConfigBackends, name))
+	if driverName == "" || strings.EqualFold(driverName, Name) {
+		return "", goof.WithField(
+			"backend", name, "invalid backend driver")
+	}
+	return driverName, nil
+}
+
+// DefaultBackendName returns the name of the backend on which volumes are
+// created when no route matches. The default may only be omitted when a
+// single backend is configured.
+func DefaultBackendName(config gofig.Config, names []string) (string, error) {
+	name := strings.ToLower(config.GetString(ConfigDefault))
+	if name == "" {
+		if len(names) != 1 {
+			return "", goof.New("router.default is required")
+		}
+		return names[0], nil
+	}
+	for _, n := range names {
+		if n == name {
+			return name, nil
+		}
+	}
+	return "", goof.WithField("backend", name, "invalid default backend")
+}
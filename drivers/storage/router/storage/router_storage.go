@@ -0,0 +1,575 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	gofig "github.com/akutz/gofig/types"
+	"github.com/akutz/goof"
+
+	"github.com/codedellemc/libstorage/api/context"
+	"github.com/codedellemc/libstorage/api/registry"
+	"github.com/codedellemc/libstorage/api/types"
+	"github.com/codedellemc/libstorage/api/utils"
+	"github.com/codedellemc/libstorage/drivers/storage/router"
+)
+
+// driver is a storage driver that fronts the storage drivers of one or more
+// backends. Volumes are created on the backend selected by the configured
+// routes, and all other operations are sent to the backend that owns the
+// volume or snapshot.
+type driver struct {
+	config         gofig.Config
+	backends       map[string]*backend
+	names          []string
+	defaultBackend *backend
+	routeBy        string
+	routes         map[string]*backend
+}
+
+type backend struct {
+	name   string
+	driver types.StorageDriver
+}
+
+func init() {
+	registry.RegisterStorageDriver(router.Name, newDriver)
+}
+
+func newDriver() types.StorageDriver {
+	return &driver{}
+}
+
+func (d *driver) Name() string {
+	return router.Name
+}
+
+func (d *driver) Init(ctx types.Context, config gofig.Config) error {
+	d.config = config
+	d.backends = map[string]*backend{}
+	d.names = router.BackendNames(config)
+
+	if len(d.names) == 0 {
+		return goof.New("no router backends configured")
+	}
+
+	for _, name := range d.names {
+		driverName, err := router.BackendDriverName(config, name)
+		if err != nil {
+			return err
+		}
+		sd, err := registry.NewStorageDriver(driverName)
+		if err != nil {
+			return err
+		}
+		bctx := ctx.WithValue(context.DriverKey, sd)
+		if err := sd.Init(bctx, router.BackendConfig(config, name)); err != nil {
+			return err
+		}
+		d.backends[name] = &backend{name: name, driver: sd}
+		ctx.WithFields(log.Fields{
+			"backend":    name,
+			"driverName": driverName,
+		}).Info("initialized router backend")
+	}
+
+	defaultName, err := router.DefaultBackendName(config, d.names)
+	if err != nil {
+		return err
+	}
+	d.defaultBackend = d.backends[defaultName]
+
+	d.routeBy = config.GetString(router.ConfigRouteBy)
+	d.routes = map[string]*backend{}
+	if m, ok := config.Get(router.ConfigRoutes).(map[string]interface{}); ok {
+		for route := range m {
+			name := strings.ToLower(config.GetString(
+				fmt.Sprintf("%s.%s", router.ConfigRoutes, route)))
+			b, ok := d.backends[name]
+			if !ok {
+				return goof.WithFields(goof.Fields{
+					"route":   route,
+					"backend": name,
+				}, "invalid route backend")
+			}
+			d.routes[strings.ToLower(route)] = b
+		}
+	}
+
+	ctx.WithFields(log.Fields{
+		"default": defaultName,
+		"routeBy": d.routeBy,
+		"routes":  len(d.routes),
+	}).Info("configured router")
+
+	return nil
+}
+
+func (d *driver) Type(ctx types.Context) (types.StorageType, error) {
+	bctx, err := d.backendContext(ctx, d.defaultBackend)
+	if err != nil {
+		return "", err
+	}
+	return d.defaultBackend.driver.Type(bctx)
+}
+
+func (d *driver) NextDeviceInfo(
+	ctx types.Context) (*types.NextDeviceInfo, error) {
+
+	bctx, err := d.backendContext(ctx, d.defaultBackend)
+	if err != nil {
+		return nil, err
+	}
+	return d.defaultBackend.driver.NextDeviceInfo(bctx)
+}
+
+func (d *driver) InstanceInspect(
+	ctx types.Context,
+	opts types.Store) (*types.Instance, error) {
+
+	bctx, err := d.backendContext(ctx, d.defaultBackend)
+	if err != nil {
+		return nil, err
+	}
+	i, err := d.defaultBackend.driver.InstanceInspect(bctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	if iid, ok := context.InstanceID(ctx); ok {
+		i.InstanceID = iid
+	}
+	return i, nil
+}
+
+func (d *driver) Volumes(
+	ctx types.Context,
+	opts *types.VolumesOpts) ([]*types.Volume, error) {
+
+	// pagination is applied to the merged volumes, not to each backend's
+	bopts := *opts
+	bopts.Pagination = nil
+
+	vols := []*types.Volume{}
+	for _, name := range d.names {
+		b := d.backends[name]
+		bctx, err := d.backendContext(ctx, b)
+		if err != nil {
+			return nil, err
+		}
+		bvols, err := b.driver.Volumes(bctx, &bopts)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range bvols {
+			vols = append(vols, d.volume(ctx, bctx, b, v))
+		}
+	}
+	return vols, nil
+}
+
+func (d *driver) VolumeInspect(
+	ctx types.Context,
+	volumeID string,
+	opts *types.VolumeInspectOpts) (*types.Volume, error) {
+
+	b, id, err := d.backendForID(volumeID)
+	if err != nil {
+		return nil, err
+	}
+	bctx, err := d.backendContext(ctx, b)
+	if err != nil {
+		return nil, err
+	}
+	v, err := b.driver.VolumeInspect(bctx, id, opts)
+	if err != nil {
+		return nil, err
+	}
+	return d.volume(ctx, bctx, b, v), nil
+}
+
+func (d *driver) VolumeCreate(
+	ctx types.Context,
+	name string,
+	opts *types.VolumeCreateOpts) (*types.Volume, error) {
+
+	b := d.backendForCreate(ctx, opts)
+	bctx, err := d.backendContext(ctx, b)
+	if err != nil {
+		return nil, err
+	}
+	v, err := b.driver.VolumeCreate(bctx, name, opts)
+	if err != nil {
+		return nil, err
+	}
+	return d.volume(ctx, bctx, b, v), nil
+}
+
+func (d *driver) VolumeCreateFromSnapshot(
+	ctx types.Context,
+	snapshotID,
+	volumeName string,
+	opts *types.VolumeCreateOpts) (*types.Volume, error) {
+
+	b, id, err := d.backendForID(snapshotID)
+	if err != nil {
+		return nil, err
+	}
+	bctx, err := d.backendContext(ctx, b)
+	if err != nil {
+		return nil, err
+	}
+	v, err := b.driver.VolumeCreateFromSnapshot(bctx, id, volumeName, opts)
+	if err != nil {
+		return nil, err
+	}
+	return d.volume(ctx, bctx, b, v), nil
+}
+
+func (d *driver) VolumeCopy(
+	ctx types.Context,
+	volumeID,
+	volumeName string,
+	opts types.Store) (*types.Volume, error) {
+
+	b, id, err := d.backendForID(volumeID)
+	if err != nil {
+		return nil, err
+	}
+	bctx, err := d.backendContext(ctx, b)
+	if err != nil {
+		return nil, err
+	}
+	v, err := b.driver.VolumeCopy(bctx, id, volumeName, opts)
+	if err != nil {
+		return nil, err
+	}
+	return d.volume(ctx, bctx, b, v), nil
+}
+
+func (d *driver) VolumeExpand(
+	ctx types.Context,
+	volumeID string,
+	newSize int64,
+	opts types.Store) (*types.Volume, error) {
+
+	b, id, err := d.backendForID(volumeID)
+	if err != nil {
+		return nil, err
+	}
+	bctx, err := d.backendContext(ctx, b)
+	if err != nil {
+		return nil, err
+	}
+	v, err := b.driver.VolumeExpand(bctx, id, newSize, opts)
+	if err != nil {
+		return nil, err
+	}
+	return d.volume(ctx, bctx, b, v), nil
+}
+
+func (d *driver) VolumeSnapshot(
+	ctx types.Context,
+	volumeID,
+	snapshotName string,
+	opts types.Store) (*types.Snapshot, error) {
+
+	b, id, err := d.backendForID(volumeID)
+	if err != nil {
+		return nil, err
+	}
+	bctx, err := d.backendContext(ctx, b)
+	if err != nil {
+		return nil, err
+	}
+	s, err := b.driver.VolumeSnapshot(bctx, id, snapshotName, opts)
+	if err != nil {
+		return nil, err
+	}
+	return snapshot(b, s), nil
+}
+
+func (d *driver) VolumeRemove(
+	ctx types.Context,
+	volumeID string,
+	opts types.Store) error {
+
+	b, id, err := d.backendForID(volumeID)
+	if err != nil {
+		return err
+	}
+	bctx, err := d.backendContext(ctx, b)
+	if err != nil {
+		return err
+	}
+	return b.driver.VolumeRemove(bctx, id, opts)
+}
+
+func (d *driver) VolumeAttach(
+	ctx types.Context,
+	volumeID string,
+	opts *types.VolumeAttachOpts) (*types.Volume, string, error) {
+
+	b, id, err := d.backendForID(volumeID)
+	if err != nil {
+		return nil, "", err
+	}
+	bctx, err := d.backendContext(ctx, b)
+	if err != nil {
+		return nil, "", err
+	}
+	v, token, err := b.driver.VolumeAttach(bctx, id, opts)
+	if err != nil {
+		return nil, "", err
+	}
+	return d.volume(ctx, bctx, b, v), token, nil
+}
+
+func (d *driver) VolumeDetach(
+	ctx types.Context,
+	volumeID string,
+	opts *types.VolumeDetachOpts) (*types.Volume, error) {
+
+	b, id, err := d.backendForID(volumeID)
+	if err != nil {
+		return nil, err
+	}
+	bctx, err := d.backendContext(ctx, b)
+	if err != nil {
+		return nil, err
+	}
+	v, err := b.driver.VolumeDetach(bctx, id, opts)
+	if err != nil {
+		return nil, err
+	}
+	return d.volume(ctx, bctx, b, v), nil
+}
+
+func (d *driver) Snapshots(
+	ctx types.Context,
+	opts types.Store) ([]*types.Snapshot, error) {
+
+	snaps := []*types.Snapshot{}
+	for _, name := range d.names {
+		b := d.backends[name]
+		bctx, err := d.backendContext(ctx, b)
+		if err != nil {
+			return nil, err
+		}
+		bsnaps, err := b.driver.Snapshots(bctx, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range bsnaps {
+			snaps = append(snaps, snapshot(b, s))
+		}
+	}
+	return snaps, nil
+}
+
+func (d *driver) SnapshotInspect(
+	ctx types.Context,
+	snapshotID string,
+	opts types.Store) (*types.Snapshot, error) {
+
+	b, id, err := d.backendForID(snapshotID)
+	if err != nil {
+		return nil, err
+	}
+	bctx, err := d.backendContext(ctx, b)
+	if err != nil {
+		return nil, err
+	}
+	s, err := b.driver.SnapshotInspect(bctx, id, opts)
+	if err != nil {
+		return nil, err
+	}
+	return snapshot(b, s), nil
+}
+
+func (d *driver) SnapshotCopy(
+	ctx types.Context,
+	snapshotID,
+	snapshotName,
+	destinationID string,
+	opts types.Store) (*types.Snapshot, error) {
+
+	b, id, err := d.backendForID(snapshotID)
+	if err != nil {
+		return nil, err
+	}
+	bctx, err := d.backendContext(ctx, b)
+	if err != nil {
+		return nil, err
+	}
+	s, err := b.driver.SnapshotCopy(
+		bctx, id, snapshotName, destinationID, opts)
+	if err != nil {
+		return nil, err
+	}
+	return snapshot(b, s), nil
+}
+
+func (d *driver) SnapshotRemove(
+	ctx types.Context,
+	snapshotID string,
+	opts types.Store) error {
+
+	b, id, err := d.backendForID(snapshotID)
+	if err != nil {
+		return err
+	}
+	bctx, err := d.backendContext(ctx, b)
+	if err != nil {
+		return err
+	}
+	return b.driver.SnapshotRemove(bctx, id, opts)
+}
+
+// backendForID returns the backend that owns the volume or snapshot with the
+// specified router ID as well as the backend's ID for the object.
+func (d *driver) backendForID(id string) (*backend, string, error) {
+	name, bid, ok := router.ParseID(id)
+	if !ok {
+		return nil, "", utils.NewNotFoundError(id)
+	}
+	b, ok := d.backends[name]
+	if !ok {
+		return nil, "", utils.NewNotFoundError(id)
+	}
+	return b, bid, nil
+}
+
+// backendForCreate returns the backend on which a volume is created. The
+// value of the create option named by router.routeBy selects the route. The
+// volume type is a routing hint when it selects a route, so it is not passed
+// on to the backend.
+func (d *driver) backendForCreate(
+	ctx types.Context, opts *types.VolumeCreateOpts) *backend {
+
+	var route string
+	if strings.EqualFold(d.routeBy, "type") {
+		if opts.Type != nil {
+			route = *opts.Type
+		}
+	} else if opts.Opts != nil {
+		route = opts.Opts.GetString(d.routeBy)
+	}
+
+	b, ok := d.routes[strings.ToLower(route)]
+	if !ok {
+		b = d.defaultBackend
+	} else if strings.EqualFold(d.routeBy, "type") {
+		opts.Type = nil
+	}
+
+	ctx.WithFields(log.Fields{
+		"route":   route,
+		"backend": b.name,
+	}).Debug("routing volume create")
+
+	return b
+}
+
+// backendContext returns a context for invoking the backend's driver that
+// is logged into the backend's storage platform and that contains the
+// instance ID and local devices for the backend's driver.
+func (d *driver) backendContext(
+	ctx types.Context, b *backend) (types.Context, error) {
+
+	driverName := strings.ToLower(b.driver.Name())
+	ctx = ctx.WithValue(context.DriverKey, b.driver)
+
+	if iid := backendInstanceID(ctx, b, driverName); iid != nil {
+		ctx = ctx.WithValue(context.InstanceIDKey, iid)
+	}
+
+	if ldm, ok := ctx.Value(
+		context.AllLocalDevicesKey).(types.LocalDevicesMap); ok {
+		if ld, ok := ldm[driverName]; ok {
+			ctx = ctx.WithValue(context.LocalDevicesKey, ld)
+		}
+	}
+	if ld, ok := context.LocalDevices(ctx); ok &&
+		strings.EqualFold(ld.Driver, router.Name) {
+		ctx = ctx.WithValue(context.LocalDevicesKey, &types.LocalDevices{
+			Driver:    driverName,
+			DeviceMap: ld.DeviceMap,
+		})
+	}
+
+	return context.WithStorageSession(ctx)
+}
+
+// backendInstanceID returns the instance ID for the backend. The router's
+// executor records the instance ID of every backend in the router's instance
+// ID. Otherwise the instance ID is the one the client sent for the backend's
+// driver, if any.
+func backendInstanceID(
+	ctx types.Context, b *backend, driverName string) *types.InstanceID {
+
+	if iid, ok := context.InstanceID(ctx); ok &&
+		strings.EqualFold(iid.Driver, router.Name) {
+		iids := map[string]*types.InstanceID{}
+		if err := iid.UnmarshalMetadata(&iids); err == nil {
+			if biid, ok := iids[b.name]; ok {
+				return biid
+			}
+		}
+	}
+
+	if iidm, ok := ctx.Value(
+		context.AllInstanceIDsKey).(types.InstanceIDMap); ok {
+		if iid, ok := iidm[driverName]; ok {
+			return iid
+		}
+	}
+
+	return nil
+}
+
+// volume converts a backend's volume to the router's volume. The volume's
+// attachments to the requesting instance are attributed to the router's
+// instance ID so that the client recognizes them as its own.
+func (d *driver) volume(
+	ctx, bctx types.Context,
+	b *backend,
+	v *types.Volume) *types.Volume {
+
+	if v == nil {
+		return nil
+	}
+
+	v.ID = router.ID(b.name, v.ID)
+	if v.Fields == nil {
+		v.Fields = map[string]string{}
+	}
+	v.Fields[router.BackendField] = b.name
+
+	iid, iidOK := context.InstanceID(ctx)
+	biid, biidOK := context.InstanceID(bctx)
+
+	for _, a := range v.Attachments {
+		a.VolumeID = router.ID(b.name, a.VolumeID)
+		if iidOK && biidOK && iid != biid && a.InstanceID != nil &&
+			strings.EqualFold(a.InstanceID.ID, biid.ID) {
+			a.InstanceID = iid
+		}
+	}
+
+	return v
+}
+
+// snapshot converts a backend's snapshot to the router's snapshot.
+func snapshot(b *backend, s *types.Snapshot) *types.Snapshot {
+	if s == nil {
+		return nil
+	}
+	s.ID = router.ID(b.name, s.ID)
+	s.VolumeID = router.ID(b.name, s.VolumeID)
+	if s.Fields == nil {
+		s.Fields = map[string]string{}
+	}
+	s.Fields[router.BackendField] = b.name
+	return s
+}
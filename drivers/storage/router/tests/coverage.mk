@@ -0,0 +1,2 @@
+ROUTER_COVERPKG := $(ROOT_IMPORT_PATH)/drivers/storage/router
+TEST_COVERPKG_./drivers/storage/router/tests := $(ROUTER_COVERPKG),$(ROUTER_COVERPKG)/executor,$(ROUTER_COVERPKG)/storage
@@ -0,0 +1,32 @@
+package router
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codedellemc/libstorage/drivers/storage/router"
+)
+
+func TestID(t *testing.T) {
+	assert.Equal(t, "fast:vol-1", router.ID("fast", "vol-1"))
+	assert.Equal(t, "fast:a:b", router.ID("fast", "a:b"))
+	assert.Equal(t, "", router.ID("fast", ""))
+}
+
+func TestParseID(t *testing.T) {
+	backend, id, ok := router.ParseID("fast:vol-1")
+	assert.True(t, ok)
+	assert.Equal(t, "fast", backend)
+	assert.Equal(t, "vol-1", id)
+
+	backend, id, ok = router.ParseID(router.ID("cheap", "a:b"))
+	assert.True(t, ok)
+	assert.Equal(t, "cheap", backend)
+	assert.Equal(t, "a:b", id)
+
+	for _, v := range []string{"", "vol-1", ":vol-1", "fast:"} {
+		_, _, ok = router.ParseID(v)
+		assert.False(t, ok, v)
+	}
+}
@@ -153,6 +153,11 @@ func (d *driver) LocalDevices(
 	}
 
 	for _, f := range files {
+		// the links of a disk's partitions share the disk's serial number
+		// and would otherwise replace the disk in the map
+		if strings.Contains(f.Name(), "-part") {
+			continue
+		}
 		if strings.Contains(f.Name(), "VBOX_HARDDISK_VB") {
 			sid := d.getShortDeviceID(f.Name())
 			if sid == "" {
@@ -183,14 +188,10 @@ func (d *driver) rescanScsiHosts() {
 
 func (d *driver) getShortDeviceID(f string) string {
 	sid := strings.Split(f, "VBOX_HARDDISK_VB")
-	if len(sid) < 1 {
-		return ""
-	}
-	aid := strings.Split(sid[1], "-")
-	if len(aid) < 1 {
+	if len(sid) < 2 {
 		return ""
 	}
-	return aid[0]
+	return strings.Split(sid[1], "-")[0]
 }
 
 func (d *driver) username() string {
@@ -15,6 +15,7 @@ import (
 	"github.com/codedellemc/libstorage/api/context"
 	"github.com/codedellemc/libstorage/api/registry"
 	"github.com/codedellemc/libstorage/api/types"
+	"github.com/codedellemc/libstorage/api/utils"
 	"github.com/codedellemc/libstorage/drivers/storage/vbox"
 )
 
@@ -49,11 +50,17 @@ func (d *driver) Init(ctx types.Context, config gofig.Config) error {
 		"userName":        d.username(),
 		"tls":             d.tls(),
 		"volumePath":      d.volumePath(),
+		"diskFormat":      d.diskFormat(),
 		"controllerName":  d.controllerName(),
 		"machineNameOrId": d.machineNameID(""),
 	}
 
 	ctx.Info("initializing driver: ", fields)
+
+	if f := d.diskFormat(); f != "vmdk" && f != "vdi" {
+		return goof.WithFields(fields, "invalid disk format")
+	}
+
 	d.vbox = vboxc.New(d.username(), d.password(),
 		d.endpoint(), d.tls(), d.controllerName())
 
@@ -88,6 +95,15 @@ func (d *driver) Type(ctx types.Context) (types.StorageType, error) {
 	return types.Block, nil
 }
 
+// Capabilities advertises block attach semantics. Volumes are media that
+// are attached to a port of the VM's storage controller.
+func (d *driver) Capabilities(
+	ctx types.Context) (*types.StorageCapabilities, error) {
+	return &types.StorageCapabilities{
+		AttachSemantics: types.Block,
+	}, nil
+}
+
 // NextDeviceInfo returns the information about the driver's next available
 // device workflow.
 func (d *driver) NextDeviceInfo(
@@ -280,6 +296,15 @@ func (d *driver) VolumeCopy(
 	return nil, types.ErrNotImplemented
 }
 
+// VolumeExpand expands a volume (not implemented)
+func (d *driver) VolumeExpand(
+	ctx types.Context,
+	volumeID string,
+	newSize int64,
+	opts types.Store) (*types.Volume, error) {
+	return nil, types.ErrNotImplemented
+}
+
 // VolumeSnapshot snapshots a volume (not implemented)
 func (d *driver) VolumeSnapshot(
 	ctx types.Context,
@@ -334,7 +359,7 @@ func (d *driver) VolumeAttach(
 	}
 
 	if len(volumes) == 0 {
-		return nil, "", goof.New("no volume found")
+		return nil, "", utils.NewNotFoundError(volumeID)
 	}
 
 	if len(volumes[0].Attachments) > 0 && !opts.Force {
@@ -391,7 +416,7 @@ func (d *driver) VolumeDetach(
 	}
 
 	if len(volumes) == 0 {
-		return nil, goof.New("no volume returned")
+		return nil, utils.NewNotFoundError(volumeID)
 	}
 
 	if err := d.detachVolume(ctx, volumeID, ""); err != nil {
@@ -470,7 +495,7 @@ func (d *driver) VolumeInspect(
 		return nil, err
 	}
 	if len(vols) == 0 {
-		return nil, goof.New("no volumes returned")
+		return nil, utils.NewNotFoundError(volumeID)
 	}
 	return vols[0], nil
 }
@@ -648,8 +673,11 @@ func (d *driver) createVolume(
 		return nil, goof.New("name is empty")
 	}
 	path := filepath.Join(d.volumePath(), name)
-	ctx.WithField("path", path).Debug("creating vmdk")
-	return d.vbox.CreateMedium("vmdk", path, size)
+	ctx.WithFields(log.Fields{
+		"path":   path,
+		"format": d.diskFormat(),
+	}).Debug("creating disk")
+	return d.vbox.CreateMedium(d.diskFormat(), path, size)
 }
 
 func (d *driver) attachVolume(
@@ -736,6 +764,10 @@ func (d *driver) volumePath() string {
 	return d.config.GetString("virtualbox.volumePath")
 }
 
+func (d *driver) diskFormat() string {
+	return strings.ToLower(d.config.GetString("virtualbox.diskFormat"))
+}
+
 func (d *driver) controllerName() string {
 	return d.config.GetString("virtualbox.controllerName")
 }
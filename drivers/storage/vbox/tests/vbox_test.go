@@ -1,10 +1,12 @@
 package vbox
 
 import (
+	"io/ioutil"
 	"os"
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	log "github.com/Sirupsen/logrus"
 	gofig "github.com/akutz/gofig/types"
@@ -319,3 +321,68 @@ func TestVolumeAttach(t *testing.T) {
 	}
 	apitests.Run(t, vbox.Name, nil, tf)
 }
+
+// TestVolumeAttachFormatMount exercises the block workflow end-to-end: the
+// volume is attached to the local VM, its device is formatted and mounted
+// with the OS driver, and the volume is then unmounted and detached. The
+// test must be run as root from within the VM.
+func TestVolumeAttachFormatMount(t *testing.T) {
+	if skipTests() || os.Geteuid() != 0 {
+		t.SkipNow()
+	}
+
+	tf := func(config gofig.Config, client types.Client, t *testing.T) {
+		ctx := context.Background().WithValue(context.ServiceKey, vbox.Name)
+
+		vol := volumeCreate(t, client, volumeName)
+		defer volumeRemove(t, client, vol.ID)
+
+		_, token, err := client.API().VolumeAttach(
+			nil, vbox.Name, vol.ID, &types.VolumeAttachRequest{})
+		assert.NoError(t, err)
+		if err != nil {
+			t.FailNow()
+		}
+		defer volumeDetach(t, client, vol.ID)
+
+		found, ld, err := client.Executor().WaitForDevice(
+			ctx, &types.WaitForDeviceOpts{
+				LocalDevicesOpts: types.LocalDevicesOpts{
+					ScanType: types.DeviceScanDeep,
+					Opts:     utils.NewStore(),
+				},
+				Token:   token,
+				Timeout: time.Minute,
+			})
+		assert.NoError(t, err)
+		if !found {
+			t.Fatalf("device of attach token %s not found", token)
+		}
+		deviceName := ld.DeviceMap[token]
+
+		assert.NoError(t, client.OS().Format(
+			ctx, deviceName, &types.DeviceFormatOpts{
+				NewFSType: "ext4",
+				Opts:      utils.NewStore(),
+			}))
+
+		mountPoint, err := ioutil.TempDir("", "libstorage-vbox")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(mountPoint)
+
+		assert.NoError(t, client.OS().Mount(
+			ctx, deviceName, mountPoint,
+			&types.DeviceMountOpts{Opts: utils.NewStore()}))
+
+		mounted, err := client.OS().IsMounted(
+			ctx, mountPoint, utils.NewStore())
+		assert.NoError(t, err)
+		assert.True(t, mounted)
+
+		assert.NoError(t, client.OS().Unmount(
+			ctx, mountPoint, utils.NewStore()))
+	}
+	apitests.Run(t, vbox.Name, nil, tf)
+}
@@ -88,6 +88,18 @@ func (d *driver) Type(ctx types.Context) (types.StorageType, error) {
 	return types.Object, nil
 }
 
+// Capabilities advertises object attach semantics, snapshots, and
+// expansion. Volumes and snapshots are only JSON files in the driver's
+// root directory, so every operation succeeds without a real backend.
+func (d *driver) Capabilities(
+	ctx types.Context) (*types.StorageCapabilities, error) {
+	return &types.StorageCapabilities{
+		SupportsSnapshots: true,
+		SupportsExpand:    true,
+		AttachSemantics:   types.Object,
+	}, nil
+}
+
 func (d *driver) NextDeviceInfo(
 	ctx types.Context) (*types.NextDeviceInfo, error) {
 	return &types.NextDeviceInfo{
@@ -156,6 +168,38 @@ func (d *driver) Volumes(
 	return utils.SortVolumeByID(volumes), nil
 }
 
+func (d *driver) VolumesIterate(
+	ctx types.Context,
+	opts *types.VolumesOpts,
+	fn func(volume *types.Volume) error) error {
+
+	context.MustSession(ctx)
+
+	iid, iidOK := context.InstanceID(ctx)
+	if iidOK {
+		if iid.ID == "" {
+			return goof.New("missing instance ID")
+		}
+	}
+
+	volJSONPaths, err := d.getVolJSONs()
+	if err != nil {
+		return err
+	}
+
+	for _, volJSONPath := range volJSONPaths {
+		v, err := readVolume(volJSONPath)
+		if err != nil {
+			return err
+		}
+		if err := fn(v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (d *driver) VolumeInspect(
 	ctx types.Context,
 	volumeID string,
@@ -295,6 +339,35 @@ func (d *driver) VolumeCopy(
 	return newVol, nil
 }
 
+func (d *driver) VolumeExpand(
+	ctx types.Context,
+	volumeID string,
+	newSize int64,
+	opts types.Store) (*types.Volume, error) {
+
+	context.MustSession(ctx)
+
+	vol, err := d.getVolumeByID(volumeID)
+	if err != nil {
+		return nil, err
+	}
+
+	if newSize < vol.Size {
+		return nil, goof.WithFields(goof.Fields{
+			"volumeID": volumeID,
+			"size":     vol.Size,
+			"newSize":  newSize,
+		}, "cannot shrink volume")
+	}
+
+	vol.Size = newSize
+	if err := d.writeVolume(vol); err != nil {
+		return nil, err
+	}
+
+	return vol, nil
+}
+
 func (d *driver) VolumeSnapshot(
 	ctx types.Context,
 	volumeID, snapshotName string,
@@ -369,6 +442,11 @@ func (d *driver) VolumeAttach(
 		Status:     "attached",
 	}
 
+	// a forced attachment detaches the volume from all other instances
+	if opts.Force {
+		vol.Attachments = nil
+	}
+
 	vol.Attachments = append(vol.Attachments, att)
 	if err := d.writeVolume(vol); err != nil {
 		return nil, "", err
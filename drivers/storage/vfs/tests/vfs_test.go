@@ -88,6 +88,28 @@ func TestServiceInpspect(t *testing.T) {
 	apitests.Run(t, vfs.Name, newTestConfig(t), tf)
 }
 
+func TestServiceCapabilities(t *testing.T) {
+	tf := func(config gofig.Config, client types.Client, t *testing.T) {
+
+		reply, err := client.API().Services(nil)
+		assert.NoError(t, err)
+		si, ok := reply[vfs.Name]
+		assert.True(t, ok)
+		if !ok {
+			t.FailNow()
+		}
+		caps := si.Driver.Capabilities
+		assert.NotNil(t, caps)
+		if caps == nil {
+			t.FailNow()
+		}
+		assert.True(t, caps.SupportsSnapshots)
+		assert.True(t, caps.SupportsExpand)
+		assert.Equal(t, types.Object, caps.AttachSemantics)
+	}
+	apitests.Run(t, vfs.Name, newTestConfig(t), tf)
+}
+
 func TestExecutors(t *testing.T) {
 	apitests.Run(t, vfs.Name, newTestConfig(t), apitests.TestExecutors)
 }
@@ -206,6 +228,65 @@ func TestVolumesByServiceWithAttachments(t *testing.T) {
 	apitests.Run(t, vfs.Name, tc, tf)
 }
 
+func TestVolumesStream(t *testing.T) {
+	tc, _, vols, _ := newTestConfigAll(t)
+	tf := func(config gofig.Config, client types.Client, t *testing.T) {
+		reply := types.VolumeMap{}
+		err := client.API().VolumesStream(nil, "", 0,
+			func(service string, volume *types.Volume) error {
+				assert.Equal(t, "vfs", service)
+				reply[volume.ID] = volume
+				return nil
+			})
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Len(t, reply, len(vols))
+		for volumeID, volume := range vols {
+			assert.EqualValues(t, volume, reply[volumeID])
+		}
+	}
+	apitests.Run(t, vfs.Name, tc, tf)
+	apitests.RunWithClientType(t, types.ControllerClient, vfs.Name, tc, tf)
+}
+
+func TestVolumesStreamByServiceWithAttachments(t *testing.T) {
+	tc, _, vols, _ := newTestConfigAll(t)
+	tf := func(config gofig.Config, client types.Client, t *testing.T) {
+		reply := types.VolumeMap{}
+		err := client.API().VolumesStream(
+			nil, "vfs", types.VolumeAttachmentsTrue,
+			func(service string, volume *types.Volume) error {
+				reply[volume.ID] = volume
+				return nil
+			})
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Len(t, reply, 2)
+		assert.EqualValues(t, vols["vfs-000"], reply["vfs-000"])
+		assert.EqualValues(t, vols["vfs-001"], reply["vfs-001"])
+		assert.Nil(t, reply["vfs-002"])
+	}
+	apitests.Run(t, vfs.Name, tc, tf)
+}
+
+func TestVolumesStreamStop(t *testing.T) {
+	tc, _, _, _ := newTestConfigAll(t)
+	tf := func(config gofig.Config, client types.Client, t *testing.T) {
+		errStop := goof.New("stop")
+		count := 0
+		err := client.API().VolumesStream(nil, "vfs", 0,
+			func(service string, volume *types.Volume) error {
+				count++
+				return errStop
+			})
+		assert.Equal(t, errStop, err)
+		assert.Equal(t, 1, count)
+	}
+	apitests.Run(t, vfs.Name, tc, tf)
+}
+
 func TestVolumeInspect(t *testing.T) {
 	tc, _, vols, _ := newTestConfigAll(t)
 	tf := func(config gofig.Config, client types.Client, t *testing.T) {
@@ -305,6 +386,39 @@ func TestVolumeCreate(t *testing.T) {
 	apitests.Run(t, vfs.Name, newTestConfig(t), tf)
 }
 
+func TestVolumeCreateIdempotent(t *testing.T) {
+	// send the idempotency key explicitly instead of having the client
+	// generate a new key for each request
+	context.RegisterCustomKey(
+		types.IdempotencyKeyHeader, context.CustomHeaderKey)
+
+	tf := func(config gofig.Config, client types.Client, t *testing.T) {
+		ctx := context.Background().WithValue(
+			types.IdempotencyKeyHeader, "create-volume-003")
+		request := &types.VolumeCreateRequest{Name: "Volume 003"}
+
+		reply1, err := client.API().VolumeCreate(ctx, vfs.Name, request)
+		assert.NoError(t, err)
+		if err != nil {
+			t.FailNow()
+		}
+
+		reply2, err := client.API().VolumeCreate(ctx, vfs.Name, request)
+		assert.NoError(t, err)
+		if err != nil {
+			t.FailNow()
+		}
+		assert.Equal(t, reply1.ID, reply2.ID)
+
+		vols, err := client.API().VolumesByService(nil, vfs.Name, 0)
+		assert.NoError(t, err)
+		assert.Len(t, vols, 4)
+	}
+
+	tc := append(newTestConfig(t), []byte(retriesDisabledYAML)...)
+	apitests.Run(t, vfs.Name, tc, tf)
+}
+
 func TestVolumeCopy(t *testing.T) {
 	tf := func(config gofig.Config, client types.Client, t *testing.T) {
 		request := &types.VolumeCopyRequest{
@@ -333,6 +447,29 @@ func TestVolumeCopy(t *testing.T) {
 	apitests.Run(t, vfs.Name, newTestConfig(t), tf)
 }
 
+func TestVolumeExpand(t *testing.T) {
+	tf := func(config gofig.Config, client types.Client, t *testing.T) {
+		request := &types.VolumeExpandRequest{NewSize: 20480}
+
+		reply, err := client.API().VolumeExpand(
+			nil, vfs.Name, "vfs-000", request)
+		assert.NoError(t, err)
+		if err != nil {
+			t.FailNow()
+		}
+
+		assert.NotNil(t, reply)
+		assert.Equal(t, "vfs-000", reply.ID)
+		assert.EqualValues(t, 20480, reply.Size)
+
+		request.NewSize = 1024
+		_, err = client.API().VolumeExpand(nil, vfs.Name, "vfs-000", request)
+		assert.Error(t, err)
+	}
+
+	apitests.Run(t, vfs.Name, newTestConfig(t), tf)
+}
+
 func TestVolumeRemove(t *testing.T) {
 
 	tf1 := func(config gofig.Config, client types.Client, t *testing.T) {
@@ -473,6 +610,58 @@ func TestVolumeAttach(t *testing.T) {
 	apitests.Run(t, vfs.Name, newTestConfig(t), tf)
 }
 
+func TestVolumeAttachPreempt(t *testing.T) {
+	tf := func(config gofig.Config, client types.Client, t *testing.T) {
+		hostName, err := utils.HostName()
+		assert.NoError(t, err)
+		writeDeadHostVolume(config, t)
+
+		// wait for the dead host's heartbeat timeout to elapse
+		time.Sleep(10 * time.Millisecond)
+
+		_, _, err = client.API().VolumeAttach(
+			nil, vfs.Name, "vfs-003", &types.VolumeAttachRequest{})
+		assert.NoError(t, err)
+
+		reply, err := client.API().VolumeInspect(
+			nil, vfs.Name, "vfs-003", types.VolumeAttachmentsTrue)
+		assert.NoError(t, err)
+		if !assert.Equal(t, 1, len(reply.Attachments)) {
+			t.FailNow()
+		}
+		assert.Equal(t, hostName, reply.Attachments[0].InstanceID.ID)
+	}
+	tc := append(newTestConfig(t), []byte(attachPreemptYAML)...)
+	apitests.Run(t, vfs.Name, tc, tf)
+}
+
+func TestVolumeAttachPreemptDisabled(t *testing.T) {
+	tf := func(config gofig.Config, client types.Client, t *testing.T) {
+		writeDeadHostVolume(config, t)
+
+		_, _, err := client.API().VolumeAttach(
+			nil, vfs.Name, "vfs-003", &types.VolumeAttachRequest{})
+		assert.NoError(t, err)
+
+		reply, err := client.API().VolumeInspect(
+			nil, vfs.Name, "vfs-003", types.VolumeAttachmentsTrue)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, len(reply.Attachments))
+	}
+	apitests.Run(t, vfs.Name, newTestConfig(t), tf)
+}
+
+// writeDeadHostVolume creates the volume vfs-003, which is attached to an
+// instance from which the server never receives a heartbeat.
+func writeDeadHostVolume(config gofig.Config, t *testing.T) {
+	vj := []byte(fmt.Sprintf(volJSON, 3, "deadhost"))
+	vjp := path.Join(vfs.VolumesDirPath(config), "vfs-003.json")
+	if err := ioutil.WriteFile(vjp, vj, 0644); err != nil {
+		assert.NoError(t, err)
+		t.FailNow()
+	}
+}
+
 func TestVolumeAttachWithControllerClient(t *testing.T) {
 	tf := func(config gofig.Config, client types.Client, t *testing.T) {
 
@@ -842,6 +1031,22 @@ func newTestConfigAll(
 
 const configYAML = "vfs:\n  root: %s"
 
+const retriesDisabledYAML = `
+libstorage:
+  client:
+    retries: 0
+`
+
+const attachPreemptYAML = `
+libstorage:
+  server:
+    heartbeat:
+      timeout: 1ms
+    volume:
+      attach:
+        preempt: true
+`
+
 const volJSON = `{
     "availabilityZone": "US",
     "iops":             1000,
@@ -51,6 +51,7 @@ func init() {
 	defaultStorageDriver := types.LibStorageDriverName
 	defaultLogLevel := logLevel.String()
 	defaultClientType := types.IntegrationClient.String()
+	defaultClientTransport := types.HTTPClientTransport.String()
 
 	rk(gofig.String, "", "", types.ConfigHost)
 	rk(gofig.String, "", "", types.ConfigService)
@@ -59,28 +60,71 @@ func init() {
 	rk(gofig.String, defaultStorageDriver, "", types.ConfigStorageDriver)
 	rk(gofig.String, defaultIntDriver, "", types.ConfigIntegrationDriver)
 	rk(gofig.String, defaultClientType, "", types.ConfigClientType)
+	rk(gofig.String, defaultClientTransport, "", types.ConfigClientTransport)
 	rk(gofig.String, defaultLogLevel, "", types.ConfigLogLevel)
 	rk(gofig.String, "", logStdoutDesc, types.ConfigLogStderr)
 	rk(gofig.String, "", logStderrDesc, types.ConfigLogStdout)
 	rk(gofig.Bool, false, "", types.ConfigLogHTTPRequests)
 	rk(gofig.Bool, false, "", types.ConfigLogHTTPResponses)
+	rk(gofig.String, "", "", types.ConfigTracingCollector)
+	rk(gofig.Int, 100, "", types.ConfigTracingSampleRate)
 	rk(gofig.Bool, false, "", types.ConfigHTTPDisableKeepAlive)
 	rk(gofig.Int, 300, "", types.ConfigHTTPWriteTimeout)
 	rk(gofig.Int, 300, "", types.ConfigHTTPReadTimeout)
 	rk(gofig.String, types.LSX.String(), "", types.ConfigExecutorPath)
 	rk(gofig.Bool, false, "", types.ConfigExecutorNoDownload)
+	rk(gofig.String, types.Run.Join("lsx.sock"), "", types.ConfigExecutorAgent)
 	rk(gofig.Bool, false, "", types.ConfigIgVolOpsMountPreempt)
 	rk(gofig.Bool, false, "", types.ConfigIgVolOpsCreateDisable)
 	rk(gofig.Bool, false, "", types.ConfigIgVolOpsRemoveDisable)
 	rk(gofig.Bool, false, "", types.ConfigIgVolOpsUnmountIgnoreUsed)
+	rk(gofig.String, types.Lib.Join("mounts.json"), "",
+		types.ConfigIgVolOpsMountStateFile)
 	rk(gofig.Bool, true, "", types.ConfigIgVolOpsPathCacheEnabled)
 	rk(gofig.Bool, true, "", types.ConfigIgVolOpsPathCacheAsync)
 	rk(gofig.String, "30m", "", types.ConfigClientCacheInstanceID)
+	rk(gofig.String, "30s", "", types.ConfigClientCacheLocalDevices)
+	rk(gofig.String, "0s", "", types.ConfigClientHeartbeatInterval)
 	rk(gofig.String, "30s", "", types.ConfigDeviceAttachTimeout)
 	rk(gofig.Int, 0, "", types.ConfigDeviceScanType)
 	rk(gofig.Bool, false, "", types.ConfigEmbedded)
 	rk(gofig.String, "1m", "", types.ConfigServerTasksExeTimeout)
 	rk(gofig.String, "0s", "", types.ConfigServerTasksLogTimeout)
+	rk(gofig.String, "10m", "", types.ConfigServerTasksAsyncRetention)
+	rk(gofig.Int, 1, "", types.ConfigServerTasksWorkers)
+	rk(gofig.String, "30s", "", types.ConfigServerTasksDrainTimeout)
+	rk(gofig.String, "1h", "", types.ConfigServerTasksTimeout)
+	rk(gofig.String, "", "", types.ConfigServerAuthJWTKey)
+	rk(gofig.String, "", "", types.ConfigServerAuthJWKS)
+	rk(gofig.String, "", "", types.ConfigServerAuthJWTAudience)
+	rk(gofig.String, "", "", types.ConfigServerAuthJWTIssuer)
+	rk(gofig.String, "", "", types.ConfigClientAuthToken)
+	rk(gofig.Int, 3, "", types.ConfigClientRetries)
+	rk(gofig.String, "500ms", "", types.ConfigClientRetryBackoff)
+	rk(gofig.String, "10s", "", types.ConfigClientHealthCheckInterval)
+	rk(gofig.String, "", "", types.ConfigServerAuditFile)
+	rk(gofig.Bool, false, "", types.ConfigServerAuditSyslog)
+	rk(gofig.String, "", "", types.ConfigServerAuditWebhook)
+	rk(gofig.String, "0s", "", types.ConfigServerCacheTTL)
+	rk(gofig.String, "1h", "", types.ConfigServerIdempotencyTTL)
+	rk(gofig.String, "0s", "", types.ConfigServerHealthInterval)
+	rk(gofig.String, "10s", "", types.ConfigServerHealthTimeout)
+	rk(gofig.String, "", "", types.ConfigServerPluginsDir)
+	rk(gofig.String, "2m", "", types.ConfigServerHeartbeatTimeout)
+	rk(gofig.Bool, false, "", types.ConfigServerVolumeAttachPreempt)
+	rk(gofig.Int, 0, "", types.ConfigServerPolicyMaxVolumeSize)
+	rk(gofig.Int, 0, "", types.ConfigServerPolicyMaxVolumeCount)
+	rk(gofig.String, "", "", types.ConfigServerPolicyVolumeNamePattern)
+	rk(gofig.Int, 0, "", types.ConfigServerRateLimitReadRate)
+	rk(gofig.Int, 0, "", types.ConfigServerRateLimitReadBurst)
+	rk(gofig.Int, 0, "", types.ConfigServerRateLimitMutateRate)
+	rk(gofig.Int, 0, "", types.ConfigServerRateLimitMutateBurst)
+	rk(gofig.Bool, false, "", types.ConfigServerCORSAllowCredentials)
+	rk(gofig.String, "10m", "", types.ConfigServerCORSMaxAge)
+	rk(gofig.Bool, false, "", types.ConfigServerCompressionDisabled)
+	rk(gofig.Int, 1024, "", types.ConfigServerCompressionMinSize)
+	rk(gofig.Int, 10485760, "", types.ConfigServerCompressionMaxRequestSize)
+	rk(gofig.Bool, false, "", types.ConfigServerUIEnabled)
 
 	gofigCore.Register(r)
 }
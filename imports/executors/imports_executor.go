@@ -2,11 +2,21 @@ package executors
 
 import (
 	// load the storage executors
+	_ "github.com/codedellemc/libstorage/drivers/storage/azurefile/executor"
+	_ "github.com/codedellemc/libstorage/drivers/storage/cephfs/executor"
+	_ "github.com/codedellemc/libstorage/drivers/storage/cephrbd/executor"
+	_ "github.com/codedellemc/libstorage/drivers/storage/cifs/executor"
+	_ "github.com/codedellemc/libstorage/drivers/storage/cinder/executor"
 	_ "github.com/codedellemc/libstorage/drivers/storage/ebs/executor"
 	_ "github.com/codedellemc/libstorage/drivers/storage/efs/executor"
 	//_ "github.com/codedellemc/libstorage/drivers/storage/gce/executor"
+	_ "github.com/codedellemc/libstorage/drivers/storage/gcefilestore/executor"
 	_ "github.com/codedellemc/libstorage/drivers/storage/isilon/executor"
+	_ "github.com/codedellemc/libstorage/drivers/storage/local/executor"
+	_ "github.com/codedellemc/libstorage/drivers/storage/nfs/executor"
+	_ "github.com/codedellemc/libstorage/drivers/storage/objectstore/executor"
 	//_ "github.com/codedellemc/libstorage/drivers/storage/openstack/executor"
+	_ "github.com/codedellemc/libstorage/drivers/storage/router/executor"
 	_ "github.com/codedellemc/libstorage/drivers/storage/scaleio/executor"
 	_ "github.com/codedellemc/libstorage/drivers/storage/vbox/executor"
 	_ "github.com/codedellemc/libstorage/drivers/storage/vfs/executor"
@@ -2,9 +2,19 @@ package remote
 
 import (
 	// import to load
+	_ "github.com/codedellemc/libstorage/drivers/storage/azurefile/storage"
+	_ "github.com/codedellemc/libstorage/drivers/storage/cephfs/storage"
+	_ "github.com/codedellemc/libstorage/drivers/storage/cephrbd/storage"
+	_ "github.com/codedellemc/libstorage/drivers/storage/cifs/storage"
+	_ "github.com/codedellemc/libstorage/drivers/storage/cinder/storage"
 	_ "github.com/codedellemc/libstorage/drivers/storage/ebs/storage"
 	_ "github.com/codedellemc/libstorage/drivers/storage/efs/storage"
+	_ "github.com/codedellemc/libstorage/drivers/storage/gcefilestore/storage"
 	_ "github.com/codedellemc/libstorage/drivers/storage/isilon/storage"
+	_ "github.com/codedellemc/libstorage/drivers/storage/local/storage"
+	_ "github.com/codedellemc/libstorage/drivers/storage/nfs/storage"
+	_ "github.com/codedellemc/libstorage/drivers/storage/objectstore/storage"
+	_ "github.com/codedellemc/libstorage/drivers/storage/router/storage"
 	_ "github.com/codedellemc/libstorage/drivers/storage/scaleio/storage"
 	_ "github.com/codedellemc/libstorage/drivers/storage/vbox/storage"
 	_ "github.com/codedellemc/libstorage/drivers/storage/vfs/storage"